@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/x402-rs/x402-go/pkg/config"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// checkProbeTimeout bounds each RPC probe made by --check
+const checkProbeTimeout = 10 * time.Second
+
+// runCheck validates the configuration and probes every configured RPC (or the
+// upstream facilitator) without starting the server, returning the exit code
+func runCheck(cfg *config.Config, checkBalances bool) int {
+	v := cfg.Validate()
+
+	if cfg.UpstreamURL != "" {
+		probeUpstream(cfg.UpstreamURL, v)
+	} else {
+		signers := probeSigners(cfg, v)
+		for net, rpcURL := range cfg.RPCURLs {
+			if !net.IsEVM() {
+				continue
+			}
+			probeRPC(cfg, net, rpcURL, signers, checkBalances, v)
+		}
+	}
+
+	for _, warning := range v.Warnings {
+		fmt.Printf("WARN  %s\n", warning)
+	}
+	for _, err := range v.Errors {
+		fmt.Printf("ERROR %s\n", err)
+	}
+	if !v.OK() {
+		fmt.Printf("Configuration check failed: %d error(s), %d warning(s)\n", len(v.Errors), len(v.Warnings))
+		return 1
+	}
+	fmt.Printf("Configuration OK (%d warning(s))\n", len(v.Warnings))
+	return 0
+}
+
+// probeUpstream confirms the upstream facilitator answers /supported
+func probeUpstream(url string, v *config.Validation) {
+	ctx, cancel := context.WithTimeout(context.Background(), checkProbeTimeout)
+	defer cancel()
+
+	supported, err := facilitator.NewClient(url, nil).Supported(ctx)
+	if err != nil {
+		v.Errors = append(v.Errors, fmt.Sprintf("upstream facilitator %s is unreachable: %v", url, err))
+		return
+	}
+	fmt.Printf("OK    upstream %s (%d payment kinds)\n", url, len(supported.Kinds))
+}
+
+// probeSigners connects the kms or remote signers, if configured, and
+// returns their addresses (nil for local keys)
+func probeSigners(cfg *config.Config, v *config.Validation) []common.Address {
+	if cfg.SignerBackend == config.SignerBackendLocal || cfg.VerifyOnly() || !v.OK() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), checkProbeTimeout)
+	defer cancel()
+
+	signers, err := cfg.SettlementSigners(ctx)
+	if err != nil {
+		v.Errors = append(v.Errors, fmt.Sprintf("%s signers: %v", cfg.SignerBackend, err))
+		return nil
+	}
+	var addresses []common.Address
+	for _, signer := range signers {
+		fmt.Printf("OK    %s signer %s\n", cfg.SignerBackend, signer.Address().Hex())
+		addresses = append(addresses, signer.Address())
+	}
+	return addresses
+}
+
+// probeRPC confirms an EVM RPC is reachable and serves the expected chain,
+// optionally flagging signers with no native balance to pay gas
+func probeRPC(cfg *config.Config, net types.Network, rpcURL string, signers []common.Address, checkBalances bool, v *config.Validation) {
+	ctx, cancel := context.WithTimeout(context.Background(), checkProbeTimeout)
+	defer cancel()
+
+	netInfo, err := network.GetNetworkInfo(net)
+	if err != nil {
+		v.Errors = append(v.Errors, fmt.Sprintf("%s: %v", net, err))
+		return
+	}
+
+	client, err := ethclient.DialContext(ctx, rpcURL)
+	if err != nil {
+		v.Errors = append(v.Errors, fmt.Sprintf("%s: cannot connect to RPC %s: %v", net, rpcURL, err))
+		return
+	}
+	defer client.Close()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		v.Errors = append(v.Errors, fmt.Sprintf("%s: RPC %s did not return a chain ID: %v", net, rpcURL, err))
+		return
+	}
+	if chainID.Uint64() != uint64(netInfo.ChainID) {
+		v.Errors = append(v.Errors, fmt.Sprintf("%s: RPC %s serves chain ID %s, expected %d", net, rpcURL, chainID, netInfo.ChainID))
+		return
+	}
+	fmt.Printf("OK    %s RPC %s (chain ID %s)\n", net, rpcURL, chainID)
+
+	if !checkBalances {
+		return
+	}
+	if cfg.SignerBackend == config.SignerBackendLocal {
+		for _, key := range cfg.PrivateKeysFor(net) {
+			if addr, err := config.KeyAddress(key); err == nil { // invalid keys are reported by Validate
+				signers = append(signers, addr)
+			}
+		}
+	}
+	for _, addr := range signers {
+		balance, err := client.BalanceAt(ctx, addr, nil)
+		if err != nil {
+			v.Errors = append(v.Errors, fmt.Sprintf("%s: cannot read balance of signer %s: %v", net, addr.Hex(), err))
+			continue
+		}
+		if balance.Sign() == 0 {
+			v.Errors = append(v.Errors, fmt.Sprintf("%s: signer %s has zero balance and cannot pay gas", net, addr.Hex()))
+		}
+	}
+}
@@ -2,112 +2,265 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
 	"time"
 
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
 	"github.com/x402-rs/x402-go/pkg/config"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
 	"github.com/x402-rs/x402-go/pkg/handlers"
 	"github.com/x402-rs/x402-go/pkg/middleware"
+	"github.com/x402-rs/x402-go/pkg/transport"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/web"
 )
 
 func main() {
 	// Configure logging
 	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
 
+	check := flag.Bool("check", false, "validate configuration and probe RPCs, then exit")
+	checkBalances := flag.Bool("check-balances", false, "with --check, also flag signers with zero balance")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize facilitator
-	fac, err := cfg.InitializeFacilitator()
-	if err != nil {
-		log.Fatalf("Failed to initialize facilitator: %v", err)
+	if *check {
+		os.Exit(runCheck(cfg, *checkBalances))
+	}
+
+	validation := cfg.Validate()
+	for _, warning := range validation.Warnings {
+		log.Printf("Config warning: %s", warning)
+	}
+	if !validation.OK() {
+		for _, err := range validation.Errors {
+			log.Printf("Config error: %s", err)
+		}
+		log.Fatalf("Invalid configuration (run with --check for details)")
+	}
+
+	// Initialize facilitator (proxy mode when an upstream is configured)
+	var fac facilitator.Facilitator
+	var gasLedger *accounting.GasLedger
+	var journal *accounting.SettlementJournal
+	var reconciler *facilitator.Reconciler
+	var balanceMonitors []*evm.BalanceMonitor
+	var reorgWatchers []*evm.ReorgWatcher
+	var events *facilitator.EventQueue
+	var auditLog facilitator.AuditLogger = facilitator.NopAuditLogger{}
+	if cfg.UpstreamURL != "" {
+		proxy := facilitator.NewProxyFacilitator(cfg.UpstreamURL)
+		proxy.SetClockSkewTolerance(cfg.ClockSkewTolerance)
+		fac = proxy
+		log.Printf("Proxy mode: forwarding verify/settle to %s", cfg.UpstreamURL)
+		journal = accounting.NewSettlementJournal(cfg.SettlementJournalSize)
+	} else {
+		local, err := cfg.InitializeFacilitator()
+		if err != nil {
+			log.Fatalf("Failed to initialize facilitator: %v", err)
+		}
+		// Gas spend per payTo, for billing back subsidized settlements
+		gasLedger = accounting.NewGasLedger(nil)
+		local.SetGasLedger(gasLedger)
+		fac = local
+
+		// Resolve settlements left in flight by a previous run, then keep checking
+		journal = local.Journal()
+		reconciler = local.StartReconciler(cfg.ReconcileInterval)
+
+		// Watch signer gas balances on networks with a threshold
+		balanceMonitors = local.StartBalanceMonitors(cfg.BalanceCheckInterval)
+
+		// Recheck recent settlements for reorgs (REORG_WATCH_WINDOW)
+		reorgWatchers = local.StartReorgWatchers(cfg.ReorgCheckInterval)
+
+		// Settlement lifecycle events (EVENT_SINK), if configured
+		events = local.Events()
+		auditLog = local.AuditLogger()
 	}
 
 	// Create HTTP handler
 	handler := handlers.NewHandler(fac)
+	if gasLedger != nil {
+		handler.SetGasLedger(gasLedger)
+	}
+	handler.SetJournal(journal)
+	handler.SetStrictDecoding(cfg.StrictDecoding)
+	types.SetStrictHex(cfg.StrictHex)
+	if local, ok := fac.(*facilitator.LocalFacilitator); ok && cfg.DevMode {
+		handler.SetFaucet(facilitator.NewFaucet(local, cfg.DevFundAmount, cfg.DevFundDailyLimit))
+		log.Printf("Development mode: testnet faucet enabled at POST /dev/fund (%d grant(s) per address per day)", cfg.DevFundDailyLimit)
+	}
+	if cfg.DevMode {
+		handler.SetDocs(true)
+		log.Println("Development mode: API docs at GET /docs")
+	}
+	log.Println(handler.BuildInfo(context.Background()))
 
 	// Setup routes
 	mux := http.NewServeMux()
-	handler.SetupRoutes(mux)
-
-	// Serve frontend SPA at "/" from web/dist if it exists
-	webDistDir := filepath.Join("web", "dist")
-	if stat, err := os.Stat(webDistDir); err == nil && stat.IsDir() {
-		fileServer := http.FileServer(http.Dir(webDistDir))
-		mux.Handle("/", spaHandler(webDistDir, fileServer))
-		log.Printf("Serving frontend SPA from %s at /", webDistDir)
+	if err := handler.SetupRoutesWithConfig(mux, handlers.RouteConfig{}); err != nil {
+		log.Fatalf("Failed to set up routes: %v", err)
+	}
+
+	// Serve the frontend SPA at "/": the embedded build, or web/dist on disk
+	// in development mode
+	if frontend, source, err := web.Load(cfg.DevMode, web.DistDir); err == nil {
+		mux.Handle("/", web.Handler(frontend))
+		log.Printf("Serving frontend SPA from %s at /", source)
 	} else {
-		log.Printf("Frontend build directory not found at %s; '/' will not serve the SPA", webDistDir)
+		log.Printf("%v; '/' will not serve the SPA", err)
 	}
 
-	// Add logging middleware based on LOG_FORMAT environment variable
-	// Options: "detailed" (default), "compact", "json", "none"
-	logFormat := os.Getenv("LOG_FORMAT")
-	if logFormat == "" {
-		logFormat = "detailed"
+	// Track verify/settle latency percentiles for /admin/stats
+	latency := middleware.NewLatencyTracker(middleware.DefaultLatencyWindow)
+	handler.SetLatencyTracker(latency)
+	stack := middleware.StackConfig{
+		RoutePrefix: cfg.RoutePrefix,
+		Latency:     latency,
+		// Refuse repeated or oversized payment headers; a request a proxy could
+		// read differently from us is never served
+		// Set PAYMENT_HEADER_MAX_BYTES=0 to check duplicates only
+		PaymentHeaderLimit: getEnvInt("PAYMENT_HEADER_MAX_BYTES", types.DefaultPaymentHeaderLimit),
+		AdminToken:         os.Getenv("ADMIN_TOKEN"),
+		// Credentials and limits apply to the routes as mux serves them
+		SettleRoutes: handler.SettleRoutes(mux),
+		AdminRoutes:  handler.AdminRoutes(mux),
+		PayerRoutes:  handler.PayerRoutes(mux),
 	}
 
-	var loggedHandler http.Handler
-	switch logFormat {
-	case "compact":
+	// Logging format from LOG_FORMAT
+	// Options: "detailed" (default), "compact", "json", "body", "none"
+	stack.LogFormat = middleware.LogFormat(os.Getenv("LOG_FORMAT"))
+	switch stack.LogFormat {
+	case middleware.LogFormatCompact:
 		log.Println("Using compact logging format")
-		loggedHandler = middleware.CompactLoggingMiddleware(mux)
-	case "json":
+	case middleware.LogFormatJSON:
 		log.Println("Using JSON structured logging format")
-		loggedHandler = middleware.StructuredLoggingMiddleware(mux)
-	case "none":
+	case middleware.LogFormatBody:
+		// Debug only: includes redacted request/response bodies
+		stack.LogBodyBytes = getEnvInt("LOG_BODY_MAX_BYTES", middleware.DefaultLogBodyBytes)
+		log.Printf("Using JSON logging with bodies (up to %d bytes, redacted)", stack.LogBodyBytes)
+	case middleware.LogFormatNone:
 		log.Println("Logging disabled")
-		loggedHandler = mux
 	default:
 		log.Println("Using detailed logging format")
-		loggedHandler = middleware.LoggingMiddleware(mux)
 	}
 
-	// Add request size limit middleware (1MB limit)
-	sizeLimitedHandler := requestSizeLimitMiddleware(loggedHandler, 1<<20) // 1MB
+	// Per-payer rate limiting on /verify and /settle
+	// Set PAYER_RATE_LIMIT_RPM=0 (default) to disable
+	if payerRate := getEnvInt("PAYER_RATE_LIMIT_RPM", 0); payerRate > 0 {
+		payerBurst := getEnvInt("PAYER_RATE_LIMIT_BURST", 10)
+		log.Printf("Payer rate limiting enabled: %d requests/minute (burst: %d)", payerRate, payerBurst)
+		stack.PayerLimiter = middleware.NewRateLimiter(payerRate, payerBurst)
+	}
+
+	// Require API keys for POST /settle, /settle/simulate and /cancel (verify and
+	// /supported stay public); unset SETTLE_API_KEYS(_FILE) leaves settle open
+	if apiKeys := loadSettleAPIKeys(); apiKeys.Len() > 0 {
+		log.Printf("Settlement API keys enabled: %d key(s)", apiKeys.Len())
+		// A tenant's key authorizes its settlements too
+		if cfg.Tenants != nil {
+			if err := apiKeys.Merge(cfg.Tenants.APIKeys()); err != nil {
+				log.Fatalf("Invalid TENANTS_FILE: %v", err)
+			}
+		}
+		stack.SettleAPIKeys = apiKeys
+	}
+
+	// Attribute requests to tenants (TENANTS_FILE) and apply their rate limits
+	if cfg.Tenants != nil {
+		log.Printf("Multi-tenant mode: %d tenant(s)", cfg.Tenants.Len())
+		stack.Tenants = cfg.Tenants
+	}
+
+	// Guard /admin/ with ADMIN_TOKEN; without it, admin changes such as
+	// disabling a network are refused and read-only admin endpoints stay open
+	if stack.AdminToken != "" {
+		log.Println("Admin API enabled (ADMIN_TOKEN set)")
+	}
+	// With TLS_CLIENT_AUTH=require, settlement also needs a client
+	// certificate from TLS_CLIENT_CA_FILE
+	var clientCAs *x509.CertPool
+	if cfg.TLSClientAuth == config.TLSClientAuthRequire {
+		if clientCAs, err = transport.LoadCertPool(cfg.TLSClientCAFile); err != nil {
+			log.Fatalf("Invalid TLS_CLIENT_CA_FILE: %v", err)
+		}
+		log.Println("Settlement requires a TLS client certificate")
+		stack.ClientCAs = clientCAs
+	}
 
-	// Add rate limiting middleware
+	// Rate limiting per client IP
 	// Default: 100 requests/minute per IP, burst of 20
 	// Set RATE_LIMIT=0 to disable rate limiting
 	rateLimit := getEnvInt("RATE_LIMIT_PER_MINUTE", 100)
 	burstSize := getEnvInt("RATE_LIMIT_BURST", 20)
-
-	var rateLimitedHandler http.Handler
 	if rateLimit > 0 {
 		log.Printf("Rate limiting enabled: %d requests/minute (burst: %d)", rateLimit, burstSize)
-		rateLimiter := middleware.NewRateLimiter(rateLimit, burstSize)
-		rateLimitedHandler = middleware.RateLimitMiddleware(rateLimiter)(sizeLimitedHandler)
+		stack.RateLimiter = middleware.NewRateLimiter(rateLimit, burstSize)
+
+		// Only honor X-Forwarded-For from these peers (comma-separated CIDRs)
+		trustedProxies, err := middleware.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+		if err != nil {
+			log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+		}
+		stack.RateLimiter.SetTrustedProxies(trustedProxies)
 	} else {
 		log.Println("Rate limiting disabled")
-		rateLimitedHandler = sizeLimitedHandler
 	}
 
-	// Add CORS middleware
-	corsHandler := corsMiddleware(rateLimitedHandler)
+	// Mount everything (API and SPA) under ROUTE_PREFIX, e.g. /x402/verify;
+	// the prefix is stripped first so the middleware sees the default paths,
+	// and requests outside it get 404
+	if cfg.RoutePrefix != "" {
+		log.Printf("Serving under route prefix %s", cfg.RoutePrefix)
+	}
+	rootHandler := middleware.DefaultStack(stack).Then(mux)
 
 	// Create server
 	addr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
 	server := &http.Server{
 		Addr:         addr,
-		Handler:      corsHandler,
+		Handler:      rootHandler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	if cfg.TLSCertFile != "" {
+		// Client certificates are requested but optional at the handshake,
+		// so only settlement (checked above) depends on them
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if clientCAs != nil {
+			server.TLSConfig.ClientCAs = clientCAs
+			server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
 
 	// Start server in goroutine
 	go func() {
 		log.Printf("Starting x402 facilitator on %s", addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.TLSCertFile != "" {
+			err = server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed: %v", err)
 		}
 	}()
@@ -127,9 +280,48 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	if reconciler != nil {
+		reconciler.Stop()
+	}
+	for _, monitor := range balanceMonitors {
+		monitor.Stop()
+	}
+	for _, watcher := range reorgWatchers {
+		watcher.Stop()
+	}
+	if events != nil {
+		events.Stop(ctx)
+	}
+	if closer, ok := auditLog.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Failed to close audit log: %v", err)
+		}
+	}
+	if err := journal.Close(); err != nil {
+		log.Printf("Failed to close settlement journal: %v", err)
+	}
+
 	log.Println("Server exited")
 }
 
+// loadSettleAPIKeys reads SETTLE_API_KEYS and SETTLE_API_KEYS_FILE
+func loadSettleAPIKeys() *middleware.APIKeys {
+	keys, err := middleware.ParseAPIKeys(os.Getenv("SETTLE_API_KEYS"))
+	if err != nil {
+		log.Fatalf("Invalid SETTLE_API_KEYS: %v", err)
+	}
+	if path := os.Getenv("SETTLE_API_KEYS_FILE"); path != "" {
+		fileKeys, err := middleware.LoadAPIKeysFile(path)
+		if err != nil {
+			log.Fatalf("Invalid SETTLE_API_KEYS_FILE: %v", err)
+		}
+		if err := keys.Merge(fileKeys); err != nil {
+			log.Fatalf("Invalid settlement API keys: %v", err)
+		}
+	}
+	return keys
+}
+
 // getEnvInt gets an integer environment variable with a default value
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
@@ -140,58 +332,3 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
-
-// requestSizeLimitMiddleware limits the maximum size of request bodies to prevent DoS attacks
-func requestSizeLimitMiddleware(next http.Handler, maxBytes int64) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Limit the request body size
-		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
-		next.ServeHTTP(w, r)
-	})
-}
-
-// corsMiddleware adds CORS headers to responses
-// Uses reflective CORS pattern for public API - allows any origin but without credentials
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-
-		if origin != "" {
-			// Reflect the origin back (allows any origin for browser requests)
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Vary", "Origin")
-		} else {
-			// For non-CORS requests (same-origin, curl, postman, etc.)
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-		}
-
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Payment-Payload")
-
-		// IMPORTANT: Do NOT set Access-Control-Allow-Credentials
-		// This is a public API and should never use credentials
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
-// spaHandler serves static files if they exist, otherwise falls back to index.html for SPA routing
-func spaHandler(root string, fileServer http.Handler) http.Handler {
-    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-        // try to serve the requested file from the built assets
-        requestedPath := filepath.Clean(r.URL.Path)
-        // ensure leading slash is trimmed when joining paths
-        candidate := filepath.Join(root, requestedPath)
-        if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
-            fileServer.ServeHTTP(w, r)
-            return
-        }
-        // fallback to index.html for client-side routes
-        http.ServeFile(w, r, filepath.Join(root, "index.html"))
-    })
-}
@@ -12,18 +12,19 @@ import (
 )
 
 func main() {
-	// Create x402 middleware pointing to facilitator
-	x402 := server.NewX402Middleware("http://localhost:8080")
+	// Create x402 middleware pointing to facilitator; the testnet profile
+	// refuses mainnet price tags (switch to ProfileMainnet and NetworkBase
+	// for production)
+	x402 := server.NewX402Middleware("http://localhost:8080", server.WithEnvironmentProfile(server.ProfileTestnet))
 
 	// Create price tag for protected content
-	// 0.025 USDC on Base Sepolia
-	priceTag := server.NewPriceTagBuilder().
-		Network(types.NetworkBaseSepolia).
-		Amount("25000"). // 0.025 USDC in smallest units (6 decimals)
-		TokenSymbol("USDC").
-		PayTo(types.NewEvmAddress(common.HexToAddress("0xYourAddress"))).                              // Replace with your address
-		Token(types.NewEvmAddress(common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e"))). // USDC on Base Sepolia
-		Build()
+	// 0.025 USDC on Base Sepolia, with the USDC address and decimals taken
+	// from the network registry; the resource defaults to each request's URL
+	priceTag, err := server.RequirementsForNetwork(types.NetworkBaseSepolia, "0.025",
+		types.NewEvmAddress(common.HexToAddress("0xYourAddress"))) // Replace with your address
+	if err != nil {
+		log.Fatalf("Invalid price: %v", err)
+	}
 
 	// Create HTTP handlers
 	mux := http.NewServeMux()
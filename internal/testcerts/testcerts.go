@@ -0,0 +1,160 @@
+// Package testcerts issues throwaway certificates for exercising TLS and
+// mutual TLS in tests: a self-signed authority, and server or client
+// certificates it signs, each also written as PEM files:
+//
+//	ca := testcerts.NewAuthority(t, "Test CA")
+//	server := ca.Issue(t, "facilitator", testcerts.Server, "127.0.0.1")
+//	client := ca.Issue(t, "shop", testcerts.Client)
+//	roots := ca.Pool()
+package testcerts
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Usage is what an issued certificate may authenticate
+type Usage int
+
+const (
+	// Server certificates authenticate TLS servers for their hosts
+	Server Usage = iota
+	// Client certificates authenticate TLS clients
+	Client
+)
+
+// Authority is a self-signed certificate authority
+type Authority struct {
+	// Cert is the authority's certificate
+	Cert *x509.Certificate
+	// CertFile holds Cert as PEM
+	CertFile string
+
+	key *ecdsa.PrivateKey
+	dir string
+}
+
+// Pair is an issued certificate with its key
+type Pair struct {
+	// Certificate is ready for tls.Config.Certificates
+	Certificate tls.Certificate
+	// Leaf is the parsed certificate
+	Leaf *x509.Certificate
+	// CertFile and KeyFile hold the certificate and key as PEM
+	CertFile, KeyFile string
+}
+
+// NewAuthority creates an authority named name whose files are removed
+// when the test ends
+func NewAuthority(t testing.TB, name string) *Authority {
+	t.Helper()
+	key := newKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          serial(t),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Authority{Cert: cert, key: key, dir: t.TempDir()}
+	a.CertFile = a.writePEM(t, "ca.pem", "CERTIFICATE", der)
+	return a
+}
+
+// Pool returns a pool trusting only the authority
+func (a *Authority) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(a.Cert)
+	return pool
+}
+
+// Issue signs a certificate for commonName valid for usage; hosts (IP
+// addresses or DNS names) are the names a server certificate is valid for
+func (a *Authority) Issue(t testing.TB, commonName string, usage Usage, hosts ...string) Pair {
+	t.Helper()
+	key := newKey(t)
+	template := &x509.Certificate{
+		SerialNumber: serial(t),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if usage == Client {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.Cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Pair{
+		Certificate: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf},
+		Leaf:        leaf,
+		CertFile:    a.writePEM(t, leaf.SerialNumber.String()+".pem", "CERTIFICATE", der),
+		KeyFile:     a.writePEM(t, leaf.SerialNumber.String()+".key", "EC PRIVATE KEY", keyDER),
+	}
+}
+
+func (a *Authority) writePEM(t testing.TB, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(a.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newKey(t testing.TB) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func serial(t testing.TB) *big.Int {
+	t.Helper()
+	n, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
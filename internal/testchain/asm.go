@@ -0,0 +1,98 @@
+package testchain
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// assembler builds EVM bytecode from opcodes, with named jump targets
+// resolved once the code is complete
+type assembler struct {
+	code   []byte
+	labels map[string]int
+	jumps  map[int]string // Offset of a PUSH2 operand -> label it targets
+}
+
+func newAssembler() *assembler {
+	return &assembler{
+		labels: make(map[string]int),
+		jumps:  make(map[int]string),
+	}
+}
+
+// op appends opcodes
+func (a *assembler) op(ops ...vm.OpCode) *assembler {
+	for _, op := range ops {
+		a.code = append(a.code, byte(op))
+	}
+	return a
+}
+
+// push appends the shortest PUSH of v (an int, uint64, *big.Int or a byte
+// slice of at most 32 bytes)
+func (a *assembler) push(v interface{}) *assembler {
+	var data []byte
+	switch v := v.(type) {
+	case int:
+		data = new(big.Int).SetInt64(int64(v)).Bytes()
+	case uint64:
+		data = new(big.Int).SetUint64(v).Bytes()
+	case *big.Int:
+		data = v.Bytes()
+	case []byte:
+		data = v
+	default:
+		panic(fmt.Sprintf("testchain: cannot push %T", v))
+	}
+	if len(data) == 0 {
+		data = []byte{0}
+	}
+	if len(data) > 32 {
+		panic("testchain: push operand longer than 32 bytes")
+	}
+	a.code = append(a.code, byte(vm.PUSH1)+byte(len(data)-1))
+	a.code = append(a.code, data...)
+	return a
+}
+
+// pushLabel appends a PUSH2 of the offset of label
+func (a *assembler) pushLabel(label string) *assembler {
+	a.code = append(a.code, byte(vm.PUSH2))
+	a.jumps[len(a.code)] = label
+	a.code = append(a.code, 0, 0)
+	return a
+}
+
+// jumpIf jumps to label if the top of the stack is non-zero
+func (a *assembler) jumpIf(label string) *assembler {
+	return a.pushLabel(label).op(vm.JUMPI)
+}
+
+// jumpUnless jumps to label if the top of the stack is zero
+func (a *assembler) jumpUnless(label string) *assembler {
+	return a.op(vm.ISZERO).jumpIf(label)
+}
+
+// label marks the next instruction as the jump target label
+func (a *assembler) label(label string) *assembler {
+	if _, ok := a.labels[label]; ok {
+		panic("testchain: duplicate label " + label)
+	}
+	a.labels[label] = len(a.code)
+	return a.op(vm.JUMPDEST)
+}
+
+// bytes returns the code with every jump target filled in
+func (a *assembler) bytes() []byte {
+	code := append([]byte(nil), a.code...)
+	for at, label := range a.jumps {
+		target, ok := a.labels[label]
+		if !ok {
+			panic("testchain: undefined label " + label)
+		}
+		code[at], code[at+1] = byte(target>>8), byte(target)
+	}
+	return code
+}
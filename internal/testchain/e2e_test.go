@@ -0,0 +1,175 @@
+package testchain_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/middleware/client"
+	"github.com/x402-rs/x402-go/middleware/server"
+	"github.com/x402-rs/x402-go/pkg/handlers"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestPaidRequestSettlesOnChain(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	// The client signs validAfter as now; let the chain's clock catch up
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	handlers.NewHandler(fac).SetupRoutes(mux)
+	facilitatorServer := httptest.NewServer(mux)
+	defer facilitatorServer.Close()
+
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	priceTag := &server.PriceTag{Requirements: chain.Requirements(payTo, big.NewInt(1000))}
+	shop := httptest.NewServer(server.NewX402Middleware(facilitatorServer.URL, server.WithSettleAfterSuccess()).Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("paid content"))
+	}), priceTag))
+	defer shop.Close()
+
+	payer := chain.Accounts[0]
+	c, err := client.NewPayingClient(payer.KeyHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get(shop.URL + "/resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "paid content" {
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Payment-Response") == "" {
+		t.Error("the response carries no settlement")
+	}
+
+	for account, want := range map[common.Address]int64{payer.Address: 10_000_000 - 1000, payTo: 1000} {
+		balance, err := chain.BalanceOf(account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance.Int64() != want {
+			t.Errorf("%s holds %s, want %d", account.Hex(), balance, want)
+		}
+	}
+}
+
+func TestMintCreditsAccount(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	payer := chain.Accounts[0].Address
+	if err := chain.Mint(payer, big.NewInt(7)); err != nil {
+		t.Fatal(err)
+	}
+	if balance, err := chain.BalanceOf(payer); err != nil || balance.Int64() != 12 {
+		t.Errorf("balance %v (%v), want 12", balance, err)
+	}
+}
+
+func TestAdjustTimeWhileSending(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	payer := chain.Accounts[0].Address
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 20; i++ {
+			if err := chain.Mint(payer, big.NewInt(1)); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for i := 0; i < 20; i++ {
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Errorf("AdjustTime: %v", err)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Mint: %v", err)
+	}
+	if balance, err := chain.BalanceOf(payer); err != nil || balance.Int64() != 20 {
+		t.Errorf("balance %v (%v), want 20", balance, err)
+	}
+}
+
+func TestCancelPaymentRevokesUnsettledPayment(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	handlers.NewHandler(fac).SetupRoutes(mux)
+	facilitatorServer := httptest.NewServer(mux)
+	defer facilitatorServer.Close()
+
+	payer := chain.Accounts[0]
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+	payload, err := chain.Authorize(payer, requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := payload.Payload.Authorization.Nonce.String()
+
+	unconfigured, err := client.NewPayingClient(payer.KeyHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unconfigured.CancelPayment(context.Background(), &requirements, nonce); !errors.Is(err, client.ErrNoFacilitator) {
+		t.Errorf("cancel without a facilitator: %v, want ErrNoFacilitator", err)
+	}
+
+	c, err := client.NewPayingClient(payer.KeyHex(), client.WithFacilitator(facilitatorServer.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, err := c.CancelPayment(context.Background(), &requirements, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cancelled.Success {
+		t.Fatalf("cancel refused: %s (%s)", cancelled.Error, cancelled.ReasonCode)
+	}
+
+	settled, err := fac.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err == nil && settled.Success {
+		t.Fatal("a cancelled payment settled")
+	}
+	if balance, err := chain.BalanceOf(payer.Address); err != nil || balance.Int64() != 10_000_000 {
+		t.Errorf("payer holds %v (%v), want the full balance", balance, err)
+	}
+}
@@ -0,0 +1,359 @@
+// Package testchain runs an in-process EVM chain (a go-ethereum simulated
+// backend) with a minimal ERC-3009 USDC and funded accounts, so verify and
+// settle can be exercised end to end without testnet funds:
+//
+//	chain, err := testchain.New(2, big.NewInt(10_000_000))
+//	defer chain.Close()
+//	fac, err := chain.Facilitator()            // serves network testchain
+//	payer, err := client.NewPayingClient(chain.Accounts[0].KeyHex())
+//	...
+//	balance, err := chain.BalanceOf(payTo)
+//
+// Transactions are mined as soon as they are sent, like anvil's automine.
+package testchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+const (
+	// Network is the network the test chain serves
+	Network types.Network = "testchain"
+	// ChainID is the chain ID of every simulated backend
+	ChainID = 1337
+)
+
+// TokenAddress is where the test USDC lives on every test chain
+var TokenAddress = common.HexToAddress("0x0000000000000000000000000000000000003009")
+
+// signerFunds is the native balance of the settlement signer
+var signerFunds = new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+
+// Account is a funded test account
+type Account struct {
+	Key     *ecdsa.PrivateKey
+	Address common.Address
+}
+
+// KeyHex returns the account's private key as 0x-prefixed hex
+func (a Account) KeyHex() string {
+	return "0x" + hex.EncodeToString(crypto.FromECDSA(a.Key))
+}
+
+// Chain is a running test chain
+type Chain struct {
+	backend *simulated.Backend
+	client  *autoMineClient
+	erc20   abi.ABI
+
+	// Signer settles payments and may mint; it holds ETH but no USDC
+	Signer Account
+	// Accounts hold USDC (the balance passed to New) but no ETH
+	Accounts []Account
+}
+
+// New starts a chain with accounts payer accounts holding balance USDC
+// (atomic units) each. Account keys are derived from their index, so they
+// are the same on every run. The network is registered as Network with the
+// token as its USDC deployment.
+func New(accounts int, balance *big.Int) (*Chain, error) {
+	if err := network.RegisterNetwork(Network, ChainID, "Test Chain"); err != nil {
+		return nil, err
+	}
+	if err := network.RegisterUSDCDeployment(network.USDCDeployment{
+		Network:      Network,
+		TokenAddress: TokenAddress,
+	}); err != nil {
+		return nil, err
+	}
+	erc20, err := abi.JSON(strings.NewReader(erc20ABI))
+	if err != nil {
+		return nil, err
+	}
+
+	signer := deriveAccount("signer")
+	tokenStorage := make(map[common.Hash]common.Hash, accounts)
+	payers := make([]Account, accounts)
+	for i := range payers {
+		payers[i] = deriveAccount("account " + strconv.Itoa(i))
+		tokenStorage[common.BytesToHash(payers[i].Address.Bytes())] = common.BigToHash(balance)
+	}
+
+	name, version := network.TokenDomain(Network, TokenAddress)
+	separator := domainSeparator(name, version, big.NewInt(ChainID), TokenAddress)
+	backend := simulated.NewBackend(ethtypes.GenesisAlloc{
+		signer.Address: {Balance: signerFunds},
+		TokenAddress: {
+			Code:    tokenCode(separator, signer.Address),
+			Storage: tokenStorage,
+			Balance: new(big.Int),
+		},
+	})
+	// Move past genesis (timestamp 0) so calls see the current time
+	backend.Commit()
+
+	return &Chain{
+		backend:  backend,
+		client:   &autoMineClient{Client: backend.Client(), backend: backend},
+		erc20:    erc20,
+		Signer:   signer,
+		Accounts: payers,
+	}, nil
+}
+
+// deriveAccount returns the account whose key is keccak256("testchain " + seed)
+func deriveAccount(seed string) Account {
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte("testchain " + seed)))
+	if err != nil {
+		panic(err) // A keccak digest is a valid key but for negligible odds
+	}
+	return Account{Key: key, Address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// Close stops the chain
+func (c *Chain) Close() error {
+	return c.backend.Close()
+}
+
+// Client returns an RPC client of the chain
+func (c *Chain) Client() evm.Client {
+	return c.client
+}
+
+// Options returns provider options settling on the chain with Signer
+func (c *Chain) Options() evm.Options {
+	return evm.Options{
+		ChainID:        big.NewInt(ChainID),
+		Keys:           []string{c.Signer.KeyHex()},
+		AssetWhitelist: []common.Address{TokenAddress},
+		Client:         c.client,
+	}
+}
+
+// Provider creates an EVM provider for the chain
+func (c *Chain) Provider(opts ...evm.ProviderOption) (*evm.Provider, error) {
+	return evm.New(Network, c.Options(), opts...)
+}
+
+// Facilitator creates a local facilitator serving only the chain
+func (c *Chain) Facilitator(opts ...evm.ProviderOption) (*facilitator.LocalFacilitator, error) {
+	return facilitator.NewBuilder().WithEVMNetwork(Network, c.Options(), opts...).Build()
+}
+
+// Commit mines a block (sent transactions are mined already; this only
+// moves the chain forward)
+func (c *Chain) Commit() {
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	c.backend.Commit()
+}
+
+// AdjustTime moves the chain's clock forward by d and mines a block, e.g. to
+// expire an authorization
+func (c *Chain) AdjustTime(d time.Duration) error {
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	return c.client.adjustTime(d)
+}
+
+// BalanceOf returns the USDC balance of account
+func (c *Chain) BalanceOf(account common.Address) (*big.Int, error) {
+	result, err := c.call("balanceOf", account)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(result), nil
+}
+
+// AuthorizationUsed reports whether the token recorded nonce of from as used
+func (c *Chain) AuthorizationUsed(from common.Address, nonce types.Nonce) (bool, error) {
+	result, err := c.call("authorizationState", from, [32]byte(nonce))
+	if err != nil {
+		return false, err
+	}
+	return new(big.Int).SetBytes(result).Sign() != 0, nil
+}
+
+// Mint credits amount USDC to account
+func (c *Chain) Mint(account common.Address, amount *big.Int) error {
+	data, err := c.erc20.Pack("mint", account, amount)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	nonce, err := c.client.PendingNonceAt(ctx, c.Signer.Address)
+	if err != nil {
+		return err
+	}
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := ethtypes.SignTx(
+		ethtypes.NewTransaction(nonce, TokenAddress, new(big.Int), 100000, gasPrice, data),
+		ethtypes.LatestSignerForChainID(big.NewInt(ChainID)),
+		c.Signer.Key,
+	)
+	if err != nil {
+		return err
+	}
+	if err := c.client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	receipt, err := c.client.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return err
+	}
+	if receipt.Status != ethtypes.ReceiptStatusSuccessful {
+		return fmt.Errorf("mint reverted")
+	}
+	return nil
+}
+
+// Requirements returns exact-scheme requirements for amount USDC paid to payTo
+func (c *Chain) Requirements(payTo common.Address, amount *big.Int) types.PaymentRequirements {
+	return types.PaymentRequirements{
+		Scheme:            types.SchemeExact,
+		Network:           Network,
+		PayTo:             payTo.Hex(),
+		MaxAmountRequired: amount.String(),
+		Resource:          "https://testchain.local/resource",
+		MaxTimeoutSeconds: 60,
+		Asset:             TokenAddress,
+	}
+}
+
+// Authorize signs a payment from account for requirements with a random
+// nonce, valid from now for the requirements' timeout (as the paying client
+// signs them)
+func (c *Chain) Authorize(account Account, requirements types.PaymentRequirements) (*types.PaymentPayload, error) {
+	var nonce types.Nonce
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	auth := types.ExactEvmPayloadAuthorization{
+		From:        account.Address,
+		To:          common.HexToAddress(requirements.PayTo),
+		Value:       requirements.MaxAmountRequired,
+		ValidAfter:  strconv.FormatInt(now, 10),
+		ValidBefore: strconv.FormatInt(now+int64(requirements.MaxTimeoutSeconds), 10),
+		Nonce:       nonce[:],
+	}
+	domain := eip712.TokenDomain(Network, big.NewInt(ChainID), requirements.Asset)
+	signature, err := eip712.SignTransferWithAuthorization(&auth, domain, account.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &types.PaymentPayload{
+		X402Version: 1,
+		Scheme:      types.SchemeExact,
+		Network:     Network,
+		Payload: types.ExactEvmPayload{
+			Signature:     signature,
+			Authorization: auth,
+		},
+	}, nil
+}
+
+// CancelRequest returns the request by which account cancels its
+// authorization with nonce, signed as the paying client signs it
+func (c *Chain) CancelRequest(account Account, nonce types.Nonce) (*types.CancelRequest, error) {
+	domain := eip712.TokenDomain(Network, big.NewInt(ChainID), TokenAddress)
+	typedData := eip712.TypedDataForCancelAuthorization(account.Address, nonce.String(), domain)
+	signature, err := eip712.SignTypedData(typedData, account.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &types.CancelRequest{
+		X402Version: 1,
+		Network:     Network,
+		Asset:       TokenAddress,
+		Authorization: types.CancelAuthorization{
+			Authorizer: account.Address,
+			Nonce:      nonce[:],
+		},
+		Signature: signature,
+	}, nil
+}
+
+// call runs a read-only token call and returns its output
+func (c *Chain) call(method string, args ...interface{}) ([]byte, error) {
+	data, err := c.erc20.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	token := TokenAddress
+	return c.client.CallContract(context.Background(), ethereum.CallMsg{To: &token, Data: data}, nil)
+}
+
+// autoMineClient mines a block after every transaction it sends. The block
+// is sealed after the current second, as on a live chain: FiatToken requires
+// block.timestamp > validAfter, which a payment signed this second would fail
+// in a block stamped this second.
+type autoMineClient struct {
+	simulated.Client
+	backend *simulated.Backend
+	mu      sync.Mutex // One transaction per block; guards the backend's pending block
+}
+
+func (c *autoMineClient) SendTransaction(ctx context.Context, tx *ethtypes.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	head, err := c.Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if behind := time.Now().Unix() - int64(head.Time); behind >= 0 {
+		if err := c.adjustTime(time.Duration(behind+1) * time.Second); err != nil {
+			return err
+		}
+	}
+	if err := c.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	c.backend.Commit()
+	return nil
+}
+
+// adjustTime moves the clock forward by d; the caller holds mu. The backend
+// refuses while its pool holds pending transactions, and the pool drops the
+// ones just mined in the background, so this waits for it to catch up.
+func (c *autoMineClient) adjustTime(d time.Duration) error {
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := c.backend.AdjustTime(d)
+		if err == nil || !strings.Contains(err.Error(), "non-empty block") || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// erc20ABI covers the token calls the harness makes itself
+const erc20ABI = `[
+	{"name":"balanceOf","type":"function","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"name":"authorizationState","type":"function","stateMutability":"view","inputs":[{"name":"authorizer","type":"address"},{"name":"nonce","type":"bytes32"}],"outputs":[{"name":"","type":"bool"}]},
+	{"name":"mint","type":"function","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}
+]`
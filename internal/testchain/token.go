@@ -0,0 +1,248 @@
+package testchain
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Revert reasons of the token, worded like FiatToken's so the provider
+// classifies them the same way (see evm.classifyRevert)
+const (
+	reasonNotYetValid   = "FiatTokenV2: authorization is not yet valid"
+	reasonExpired       = "FiatTokenV2: authorization is expired"
+	reasonUsed          = "FiatTokenV2: authorization is used or canceled"
+	reasonSignature     = "FiatTokenV2: invalid signature"
+	reasonBalance       = "ERC20: transfer amount exceeds balance"
+	reasonNotMinter     = "FiatToken: caller is not a minter"
+	reasonUnknownCall   = "testchain: unknown function"
+	tokenDecimals       = 6
+	transferWithAuthSig = "transferWithAuthorization(address,address,uint256,uint256,uint256,bytes32,bytes)"
+	cancelAuthSig       = "cancelAuthorization(address,bytes32,bytes)"
+)
+
+var (
+	transferTopic              = crypto.Keccak256([]byte("Transfer(address,address,uint256)"))
+	authorizationUsedTopic     = crypto.Keccak256([]byte("AuthorizationUsed(address,bytes32)"))
+	authorizationCanceledTopic = crypto.Keccak256([]byte("AuthorizationCanceled(address,bytes32)"))
+
+	transferWithAuthorizationTypeHash = crypto.Keccak256([]byte("TransferWithAuthorization(address from,address to,uint256 value,uint256 validAfter,uint256 validBefore,bytes32 nonce)"))
+	cancelAuthorizationTypeHash       = crypto.Keccak256([]byte("CancelAuthorization(address authorizer,bytes32 nonce)"))
+	domainTypeHash                    = crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+)
+
+// selector returns the 4-byte function selector of signature
+func selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// domainSeparator is the EIP-712 domain separator of a token, computed here
+// independently of pkg/eip712 so the chain checks the signer's hashing
+func domainSeparator(name, version string, chainID *big.Int, token common.Address) []byte {
+	return crypto.Keccak256(
+		domainTypeHash,
+		crypto.Keccak256([]byte(name)),
+		crypto.Keccak256([]byte(version)),
+		common.LeftPadBytes(chainID.Bytes(), 32),
+		common.LeftPadBytes(token.Bytes(), 32),
+	)
+}
+
+// tokenCode returns the runtime bytecode of a minimal ERC-3009 token, the
+// subset of FiatToken x402 settlement touches:
+//
+//	balanceOf(address) uint256
+//	decimals() uint8
+//	transfer(address,uint256) bool
+//	mint(address,uint256) bool                 (minter only)
+//	authorizationState(address,bytes32) bool
+//	transferWithAuthorization(address,address,uint256,uint256,uint256,bytes32,bytes)
+//	cancelAuthorization(address,bytes32,bytes)
+//
+// Balances live in the storage slot named by the holder's address, used
+// authorizations at keccak256(from, nonce). The domain separator is baked
+// in, as the code only ever runs at one address on one chain.
+func tokenCode(separator []byte, minter common.Address) []byte {
+	a := newAssembler()
+	arg := func(i int) { a.push(4 + 32*i).op(vm.CALLDATALOAD) }
+
+	// Dispatch on the selector
+	a.push(0).op(vm.CALLDATALOAD).push(224).op(vm.SHR)
+	for _, fn := range []struct{ signature, label string }{
+		{"balanceOf(address)", "balanceOf"},
+		{"decimals()", "decimals"},
+		{"transfer(address,uint256)", "transfer"},
+		{"mint(address,uint256)", "mint"},
+		{"authorizationState(address,bytes32)", "authorizationState"},
+		{transferWithAuthSig, "transferWithAuthorization"},
+		{cancelAuthSig, "cancelAuthorization"},
+	} {
+		a.op(vm.DUP1).push(selector(fn.signature)).op(vm.EQ).jumpIf(fn.label)
+	}
+	revertWith(a, reasonUnknownCall)
+
+	a.label("balanceOf")
+	arg(0)
+	a.op(vm.SLOAD)
+	returnWord(a)
+
+	a.label("decimals")
+	a.push(tokenDecimals)
+	returnWord(a)
+
+	a.label("transfer")
+	moveBalance(a, func() { a.op(vm.CALLER) }, func() { arg(0) }, func() { arg(1) })
+	a.push(1)
+	returnWord(a)
+
+	a.label("mint")
+	a.op(vm.CALLER).push(minter.Bytes()).op(vm.EQ).jumpUnless("notMinter")
+	arg(1)
+	arg(0)
+	a.op(vm.SLOAD, vm.ADD)
+	arg(0)
+	a.op(vm.SSTORE)
+	logTransfer(a, func() { a.push(0) }, func() { arg(0) }, func() { arg(1) })
+	a.push(1)
+	returnWord(a)
+
+	a.label("authorizationState")
+	authorizationSlot(a, func() { arg(0) }, func() { arg(1) })
+	a.op(vm.SLOAD)
+	returnWord(a)
+
+	// transferWithAuthorization(from, to, value, validAfter, validBefore, nonce, signature)
+	a.label("transferWithAuthorization")
+	arg(3)
+	a.op(vm.TIMESTAMP, vm.GT).jumpUnless("notYetValid") // block.timestamp > validAfter
+	arg(4)
+	a.op(vm.TIMESTAMP, vm.LT).jumpUnless("expired") // block.timestamp < validBefore
+
+	authorizationSlot(a, func() { arg(0) }, func() { arg(5) })
+	a.op(vm.DUP1, vm.SLOAD).jumpIf("used")
+	a.push(1).op(vm.SWAP1, vm.SSTORE)
+	// Signed fields: from, to, value, validAfter, validBefore, nonce
+	checkSignature(a, separator, transferWithAuthorizationTypeHash, 6, func() { arg(6) }, func() { arg(0) })
+
+	moveBalance(a, func() { arg(0) }, func() { arg(1) }, func() { arg(2) })
+	arg(5)
+	arg(0)
+	a.push(authorizationUsedTopic).push(0).push(0).op(vm.LOG3)
+	a.op(vm.STOP)
+
+	// cancelAuthorization(authorizer, nonce, signature)
+	a.label("cancelAuthorization")
+	authorizationSlot(a, func() { arg(0) }, func() { arg(1) })
+	a.op(vm.DUP1, vm.SLOAD).jumpIf("used")
+	a.push(1).op(vm.SWAP1, vm.SSTORE)
+	checkSignature(a, separator, cancelAuthorizationTypeHash, 2, func() { arg(2) }, func() { arg(0) })
+	arg(1)
+	arg(0)
+	a.push(authorizationCanceledTopic).push(0).push(0).op(vm.LOG3)
+	a.op(vm.STOP)
+
+	for _, fail := range []struct{ label, reason string }{
+		{"notYetValid", reasonNotYetValid},
+		{"expired", reasonExpired},
+		{"used", reasonUsed},
+		{"badSignature", reasonSignature},
+		{"insufficientBalance", reasonBalance},
+		{"notMinter", reasonNotMinter},
+	} {
+		a.label(fail.label)
+		revertWith(a, fail.reason)
+	}
+	return a.bytes()
+}
+
+// checkSignature appends the EIP-712 check of the signature whose ABI offset
+// signature pushes: the struct is typeHash followed by the first fields
+// calldata words, and the recovered signer must equal what signer pushes
+func checkSignature(a *assembler, separator, typeHash []byte, fields int, signature, signer func()) {
+	// structHash = keccak256(typeHash, fields...)
+	a.push(typeHash).push(0x80).op(vm.MSTORE)
+	a.push(fields * 32).push(4).push(0xa0).op(vm.CALLDATACOPY)
+	a.push((fields + 1) * 32).push(0x80).op(vm.KECCAK256)
+	// digest = keccak256("\x19\x01" || domainSeparator || structHash)
+	a.push(0x1901).push(240).op(vm.SHL).push(0x200).op(vm.MSTORE)
+	a.push(separator).push(0x202).op(vm.MSTORE)
+	a.push(0x222).op(vm.MSTORE)
+	a.push(66).push(0x200).op(vm.KECCAK256)
+	a.push(0x300).op(vm.MSTORE)
+
+	// signature is 65 bytes of r || s || v
+	signature()
+	a.push(4).op(vm.ADD) // Offset of the signature length
+	a.op(vm.DUP1, vm.CALLDATALOAD).push(65).op(vm.EQ).jumpUnless("badSignature")
+	a.op(vm.DUP1).push(32).op(vm.ADD, vm.CALLDATALOAD).push(0x340).op(vm.MSTORE)
+	a.op(vm.DUP1).push(64).op(vm.ADD, vm.CALLDATALOAD).push(0x360).op(vm.MSTORE)
+	a.push(96).op(vm.ADD, vm.CALLDATALOAD).push(0).op(vm.BYTE).push(0x320).op(vm.MSTORE)
+
+	// ecrecover(digest, v, r, s) must be signer; it returns nothing on failure
+	a.push(0).push(0x400).op(vm.MSTORE)
+	a.push(32).push(0x400).push(128).push(0x300).push(1).op(vm.GAS, vm.STATICCALL, vm.POP)
+	a.push(0x400).op(vm.MLOAD)
+	a.op(vm.DUP1, vm.ISZERO).jumpIf("badSignature")
+	signer()
+	a.op(vm.EQ).jumpUnless("badSignature")
+}
+
+// moveBalance appends a transfer of value from one holder to another,
+// reverting if from's balance is short; each func pushes one operand
+func moveBalance(a *assembler, from, to, value func()) {
+	value()
+	from()
+	a.op(vm.SLOAD, vm.LT).jumpIf("insufficientBalance") // balance < value
+
+	value()
+	from()
+	a.op(vm.SLOAD, vm.SUB)
+	from()
+	a.op(vm.SSTORE)
+
+	value()
+	to()
+	a.op(vm.SLOAD, vm.ADD)
+	to()
+	a.op(vm.SSTORE)
+
+	logTransfer(a, from, to, value)
+}
+
+// logTransfer appends a Transfer(from, to, value) event
+func logTransfer(a *assembler, from, to, value func()) {
+	value()
+	a.push(0).op(vm.MSTORE)
+	to()
+	from()
+	a.push(transferTopic).push(32).push(0).op(vm.LOG3)
+}
+
+// authorizationSlot appends the computation of keccak256(from, nonce)
+func authorizationSlot(a *assembler, from, nonce func()) {
+	from()
+	a.push(0).op(vm.MSTORE)
+	nonce()
+	a.push(32).op(vm.MSTORE)
+	a.push(64).push(0).op(vm.KECCAK256)
+}
+
+// returnWord appends a return of the top of the stack as one ABI word
+func returnWord(a *assembler) {
+	a.push(0).op(vm.MSTORE)
+	a.push(32).push(0).op(vm.RETURN)
+}
+
+// revertWith appends a revert with Error(reason)
+func revertWith(a *assembler, reason string) {
+	a.push(selector("Error(string)")).push(224).op(vm.SHL).push(0).op(vm.MSTORE)
+	a.push(32).push(4).op(vm.MSTORE)
+	a.push(len(reason)).push(0x24).op(vm.MSTORE)
+	padded := common.RightPadBytes([]byte(reason), (len(reason)+31)/32*32)
+	for i := 0; i < len(padded); i += 32 {
+		a.push(padded[i : i+32]).push(0x44 + i).op(vm.MSTORE)
+	}
+	a.push(0x44 + len(padded)).push(0).op(vm.REVERT)
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultBalanceTTL is how long a balance read for WithBalanceChecks is reused
+const DefaultBalanceTTL = 30 * time.Second
+
+// balanceOfSelector is the ERC-20 balanceOf(address) selector
+var balanceOfSelector = common.FromHex("0x70a08231")
+
+// WithBalanceChecks makes the client read its own token balance on each
+// offered network with an RPC URL before choosing one: options its balance
+// covers are paid first, options it is known to be short on last. When the
+// server still refuses a payment for insufficient funds, the request is paid
+// once more on the next network the balance is not known to be short on.
+// Balances are reused for DefaultBalanceTTL; networks without a URL are
+// never checked.
+func WithBalanceChecks(rpcURLs map[types.Network]string) Option {
+	return func(c *PayingClient) {
+		if len(rpcURLs) == 0 {
+			c.balances = nil
+			return
+		}
+		urls := make(map[types.Network]string, len(rpcURLs))
+		for net, url := range rpcURLs {
+			urls[net] = url
+		}
+		c.balances = &balanceChecker{
+			rpcURLs:  urls,
+			ttl:      DefaultBalanceTTL,
+			clients:  make(map[types.Network]*ethclient.Client),
+			balances: make(map[balanceKey]balanceEntry),
+		}
+	}
+}
+
+// balanceStanding is what the client's balance is known to cover of an option
+type balanceStanding int
+
+const (
+	balanceCovers balanceStanding = iota
+	balanceUnknown
+	balanceShort
+)
+
+// balanceChecker reads and caches the client's token balances
+type balanceChecker struct {
+	rpcURLs map[types.Network]string
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	clients  map[types.Network]*ethclient.Client // Dialed on first use
+	balances map[balanceKey]balanceEntry
+}
+
+type balanceKey struct {
+	network types.Network
+	token   common.Address
+}
+
+type balanceEntry struct {
+	balance   *big.Int
+	expiresAt time.Time
+}
+
+// orderByBalance moves the options the client's balance covers to the
+// front and those it is short on to the back, keeping the order otherwise
+func (c *PayingClient) orderByBalance(ctx context.Context, options []*types.PaymentRequirements) []*types.PaymentRequirements {
+	if c.balances == nil || len(options) < 2 {
+		return options
+	}
+	standings := make(map[*types.PaymentRequirements]balanceStanding, len(options))
+	for _, option := range options {
+		standings[option] = c.balanceStanding(ctx, option)
+	}
+	ordered := append([]*types.PaymentRequirements(nil), options...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return standings[ordered[i]] < standings[ordered[j]]
+	})
+	return ordered
+}
+
+// balanceStanding compares the client's balance of the option's token with
+// its amount; a balance that cannot be read is unknown
+func (c *PayingClient) balanceStanding(ctx context.Context, option *types.PaymentRequirements) balanceStanding {
+	amount, err := types.ParseTokenAmount(option.MaxAmountRequired)
+	if err != nil {
+		return balanceUnknown
+	}
+	balance, err := c.balances.balanceOf(ctx, c.client, option.Network, option.Asset, c.signerAddr)
+	if err != nil || balance == nil {
+		return balanceUnknown
+	}
+	if balance.Cmp(amount) < 0 {
+		return balanceShort
+	}
+	return balanceCovers
+}
+
+// fallBackOnInsufficientFunds pays req once more on the next candidate
+// network when the server refused the payment on paid for insufficient
+// funds (see WithBalanceChecks); resp is returned as is otherwise
+func (c *PayingClient) fallBackOnInsufficientFunds(req *http.Request, resp *http.Response, paid *types.PaymentRequirements, candidates []*types.PaymentRequirements) (*http.Response, error) {
+	if c.balances == nil || resp.StatusCode != http.StatusPaymentRequired {
+		return resp, nil
+	}
+	if _, code := c.rejectionReason(resp); code != types.ReasonInsufficientFunds {
+		return resp, nil
+	}
+
+	// The cached balance was wrong, or spent since
+	c.balances.forget(paid.Network, paid.Asset)
+
+	var next *types.PaymentRequirements
+	for _, option := range candidates {
+		if option.Network != paid.Network && c.balanceStanding(req.Context(), option) != balanceShort {
+			next = option
+			break
+		}
+	}
+	if next == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+	c.cacheRequirements(req, next)
+	return c.payAndSend(req, next)
+}
+
+// balanceOf returns account's balance of token on net, nil if net has no
+// RPC URL
+func (b *balanceChecker) balanceOf(ctx context.Context, httpClient *http.Client, net types.Network, token, account common.Address) (*big.Int, error) {
+	key := balanceKey{network: net, token: token}
+	b.mu.Lock()
+	entry, ok := b.balances[key]
+	b.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.balance, nil
+	}
+
+	client, err := b.client(net, httpClient)
+	if err != nil || client == nil {
+		return nil, err
+	}
+	data := append(append([]byte(nil), balanceOfSelector...), common.LeftPadBytes(account.Bytes(), 32)...)
+	result, err := client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("balanceOf call on %s failed: %w", net, err)
+	}
+	if len(result) != 32 {
+		return nil, fmt.Errorf("balanceOf call on %s returned %d bytes", net, len(result))
+	}
+	balance := new(big.Int).SetBytes(result)
+
+	b.mu.Lock()
+	b.balances[key] = balanceEntry{balance: balance, expiresAt: time.Now().Add(b.ttl)}
+	b.mu.Unlock()
+	return balance, nil
+}
+
+// client returns the RPC client of net, dialing it on first use (nil if
+// net has no RPC URL)
+func (b *balanceChecker) client(net types.Network, httpClient *http.Client) (*ethclient.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if client := b.clients[net]; client != nil {
+		return client, nil
+	}
+	url, ok := b.rpcURLs[net]
+	if !ok {
+		return nil, nil
+	}
+	rpcClient, err := rpc.DialHTTPWithClient(url, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RPC URL for %s: %w", net, err)
+	}
+	client := ethclient.NewClient(rpcClient)
+	b.clients[net] = client
+	return client, nil
+}
+
+// forget drops the cached balance of token on net
+func (b *balanceChecker) forget(net types.Network, token common.Address) {
+	b.mu.Lock()
+	delete(b.balances, balanceKey{network: net, token: token})
+	b.mu.Unlock()
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// balanceRPC is a JSON-RPC endpoint answering every eth_call with balance
+// (or an error when it is nil), counting the calls
+type balanceRPC struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	balance *big.Int
+	calls   int
+}
+
+func newBalanceRPC(t *testing.T, balance *big.Int) *balanceRPC {
+	t.Helper()
+	b := &balanceRPC{balance: balance}
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b.mu.Lock()
+		b.calls++
+		balance := b.balance
+		b.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if call.Method != "eth_call" || balance == nil {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":"unavailable"}}`, call.ID)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x%064x"}`, call.ID, balance)
+	}))
+	t.Cleanup(b.Close)
+	return b
+}
+
+func (b *balanceRPC) callCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls
+}
+
+// refusingServer offers options and refuses payments on the networks in
+// refused for insufficient funds, recording the networks paid on
+func refusingServer(t *testing.T, refused map[types.Network]bool, options ...types.PaymentRequirements) (*httptest.Server, func() []types.Network) {
+	t.Helper()
+	var (
+		mu   sync.Mutex
+		paid []types.Network
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if header := r.Header.Get(types.HeaderXPayment); header != "" {
+			var payload types.PaymentPayload
+			if err := json.Unmarshal([]byte(header), &payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			paid = append(paid, payload.Network)
+			mu.Unlock()
+			if !refused[payload.Network] {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.WriteHeader(http.StatusPaymentRequired)
+			json.NewEncoder(w).Encode(map[string]interface{}{"reason": "insufficient funds", "reasonCode": types.ReasonInsufficientFunds, "accepts": options})
+			return
+		}
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(map[string]interface{}{"x402Version": 1, "accepts": options})
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []types.Network {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]types.Network(nil), paid...)
+	}
+}
+
+func TestBalanceChecksPreferAffordableNetworks(t *testing.T) {
+	offered := []types.PaymentRequirements{
+		requirementsOn(t, types.NetworkBaseSepolia),
+		requirementsOn(t, types.NetworkBase),
+		requirementsOn(t, types.NetworkPolygon),
+	}
+	short := newBalanceRPC(t, big.NewInt(0))
+	funded := newBalanceRPC(t, big.NewInt(1_000_000_000))
+	failing := newBalanceRPC(t, nil)
+
+	for _, tc := range []struct {
+		name string
+		urls map[types.Network]string
+		want types.Network
+	}{
+		{"affordable option last", map[types.Network]string{types.NetworkBaseSepolia: short.URL, types.NetworkBase: short.URL, types.NetworkPolygon: funded.URL}, types.NetworkPolygon},
+		{"unknown before short", map[types.Network]string{types.NetworkBaseSepolia: short.URL, types.NetworkBase: failing.URL}, types.NetworkBase},
+		{"all affordable", map[types.Network]string{types.NetworkBaseSepolia: funded.URL, types.NetworkPolygon: funded.URL}, types.NetworkBaseSepolia},
+		{"no checks", nil, types.NetworkBaseSepolia},
+	} {
+		server, paid := offeringServer(t, offered...)
+		c, err := NewPayingClient(testKeyHex, WithBalanceChecks(tc.urls))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Get(server.URL + "/resource")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if networks := paid(); len(networks) != 1 || networks[0] != tc.want {
+			t.Errorf("%s: paid on %v, want %s", tc.name, networks, tc.want)
+		}
+	}
+}
+
+func TestBalanceChecksAreCached(t *testing.T) {
+	server, paid := offeringServer(t, requirementsOn(t, types.NetworkBaseSepolia), requirementsOn(t, types.NetworkPolygon))
+	rpc := newBalanceRPC(t, big.NewInt(1_000_000_000))
+	c, err := NewPayingClient(testKeyHex, WithBalanceChecks(map[types.Network]string{types.NetworkBaseSepolia: rpc.URL, types.NetworkPolygon: rpc.URL}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/a", "/b"} {
+		resp, err := c.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if n := len(paid()); n != 2 {
+		t.Fatalf("%d payments, want 2", n)
+	}
+	if n := rpc.callCount(); n != 2 {
+		t.Errorf("%d balance reads, want one per network within the TTL", n)
+	}
+}
+
+func TestBalanceChecksFallBackOnce(t *testing.T) {
+	offered := []types.PaymentRequirements{
+		requirementsOn(t, types.NetworkBaseSepolia),
+		requirementsOn(t, types.NetworkBase),
+		requirementsOn(t, types.NetworkPolygon),
+	}
+	funded := newBalanceRPC(t, big.NewInt(1_000_000_000))
+	urls := map[types.Network]string{types.NetworkBaseSepolia: funded.URL, types.NetworkBase: funded.URL, types.NetworkPolygon: funded.URL}
+
+	for _, tc := range []struct {
+		name    string
+		refused map[types.Network]bool
+		checks  bool
+		paid    []types.Network
+		status  int
+	}{
+		{"first refused", map[types.Network]bool{types.NetworkBaseSepolia: true}, true,
+			[]types.Network{types.NetworkBaseSepolia, types.NetworkBase}, http.StatusOK},
+		{"two refused", map[types.Network]bool{types.NetworkBaseSepolia: true, types.NetworkBase: true}, true,
+			[]types.Network{types.NetworkBaseSepolia, types.NetworkBase}, http.StatusPaymentRequired},
+		{"no checks", map[types.Network]bool{types.NetworkBaseSepolia: true}, false,
+			[]types.Network{types.NetworkBaseSepolia}, http.StatusPaymentRequired},
+	} {
+		server, paid := refusingServer(t, tc.refused, offered...)
+		opts := []Option{}
+		if tc.checks {
+			opts = append(opts, WithBalanceChecks(urls))
+		}
+		c, err := NewPayingClient(testKeyHex, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Get(server.URL + "/resource")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		networks := paid()
+		if resp.StatusCode != tc.status || fmt.Sprint(networks) != fmt.Sprint(tc.paid) {
+			t.Errorf("%s: status %d after paying on %v, want %d after %v", tc.name, resp.StatusCode, networks, tc.status, tc.paid)
+		}
+	}
+}
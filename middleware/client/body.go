@@ -0,0 +1,134 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultMaxBufferedBody is the largest request body buffered for the paid retry
+const DefaultMaxBufferedBody = 10 << 20 // 10MB
+
+// WithMaxBufferedBody sets how many request body bytes may be buffered so the
+// paid retry can replay them; larger bodies skip the unpaid first attempt
+func WithMaxBufferedBody(n int64) Option {
+	return func(c *PayingClient) {
+		c.maxBufferedBody = n
+	}
+}
+
+// prepareBody makes req's body replayable when it fits in the buffer limit
+// Returns false when the body is too large and may only be sent once
+func (c *PayingClient) prepareBody(req *http.Request) (bool, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return true, nil
+	}
+
+	// Read one byte past the limit to detect oversized bodies
+	prefix, err := io.ReadAll(io.LimitReader(req.Body, c.maxBufferedBody+1))
+	if err != nil {
+		return false, fmt.Errorf("failed to buffer request body: %w", err)
+	}
+
+	if int64(len(prefix)) > c.maxBufferedBody {
+		// Stitch the consumed prefix back in front of the unread remainder
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(prefix), req.Body), req.Body}
+		return false, nil
+	}
+
+	req.Body.Close()
+	req.ContentLength = int64(len(prefix))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(prefix)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return true, nil
+}
+
+// doStreaming sends a request whose body cannot be replayed exactly once,
+// attaching payment up front when requirements are known
+func (c *PayingClient) doStreaming(req *http.Request) (*http.Response, error) {
+	requirements := c.cachedRequirements(req)
+	if requirements == nil {
+		probed, err := c.probeRequirements(req)
+		if err != nil {
+			return nil, err
+		}
+		requirements = probed
+	}
+
+	// Resource is not paid (as far as we can tell); send as-is
+	if requirements == nil {
+		return c.client.Do(req)
+	}
+	return c.payAndSend(req, requirements)
+}
+
+// probeRequirements asks the server for payment requirements with a bodyless HEAD
+func (c *PayingClient) probeRequirements(req *http.Request) (*types.PaymentRequirements, error) {
+	probe, err := http.NewRequestWithContext(req.Context(), http.MethodHead, req.URL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range req.Header {
+		probe.Header[key] = values
+	}
+
+	resp, err := c.client.Do(probe)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPaymentRequired {
+		return nil, nil
+	}
+	options, err := c.parsePaymentOptions(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRequirementsParse, err)
+	}
+	if options, err = c.checkRequirementsSignature(resp, options); err != nil {
+		return nil, err
+	}
+	requirements, err := c.selectRequirements(req.Context(), options)
+	if err != nil {
+		return nil, err
+	}
+	c.cacheRequirements(req, requirements)
+	return requirements, nil
+}
+
+// cacheRequirements remembers the last 402 requirements seen for a request target
+func (c *PayingClient) cacheRequirements(req *http.Request, requirements *types.PaymentRequirements) {
+	// Challenges are single-use, so challenged requirements cannot be reused
+	if challenge, _ := types.ParseChallenge(requirements.Extra); challenge != nil {
+		return
+	}
+	c.requirementsMu.Lock()
+	defer c.requirementsMu.Unlock()
+	c.requirements[req.Method+" "+req.URL.String()] = requirements
+}
+
+// cachedRequirements returns previously seen requirements for a request
+// target, forgetting them once their price quote has expired
+func (c *PayingClient) cachedRequirements(req *http.Request) *types.PaymentRequirements {
+	c.requirementsMu.Lock()
+	defer c.requirementsMu.Unlock()
+	key := req.Method + " " + req.URL.String()
+	requirements := c.requirements[key]
+	if requirements == nil {
+		return nil
+	}
+	if quote, _ := types.ParseQuote(requirements.Extra); quote != nil && quote.Expired(time.Now()) {
+		delete(c.requirements, key)
+		return nil
+	}
+	return requirements
+}
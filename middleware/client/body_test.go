@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// uploadServer charges the x402test requirements for uploads and records
+// what reached it
+type uploadServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	unpaid   []string // Methods of the requests answered with 402
+	paidBody []byte
+}
+
+func newUploadServer(t *testing.T) *uploadServer {
+	t.Helper()
+	s := &uploadServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get(types.HeaderXPayment) != "" {
+			s.paidBody = body
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		s.unpaid = append(s.unpaid, r.Method)
+		requirements, _ := json.Marshal(x402test.Requirements())
+		w.Header().Set(types.HeaderPaymentRequired, string(requirements))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		if r.Method != http.MethodHead {
+			json.NewEncoder(w).Encode(map[string]interface{}{"x402Version": 1, "payment_requirements": x402test.Requirements()})
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func randomBody(t *testing.T, size int) []byte {
+	t.Helper()
+	body := make([]byte, size)
+	if _, err := rand.Read(body); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestPaidRetryReplaysLargeBodies(t *testing.T) {
+	body := randomBody(t, 4<<20)
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch} {
+		server := newUploadServer(t)
+		c, err := NewPayingClient(testKeyHex, WithMaxBufferedBody(8<<20))
+		if err != nil {
+			t.Fatal(err)
+		}
+		send := map[string]func(string, string, io.Reader) (*http.Response, error){
+			http.MethodPost:  c.Post,
+			http.MethodPut:   c.Put,
+			http.MethodPatch: c.Patch,
+		}[method]
+		// A plain reader, so only the client's buffering makes it replayable
+		resp, err := send(server.URL+"/upload", "application/octet-stream", io.MultiReader(bytes.NewReader(body)))
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Errorf("%s: status %d, want %d", method, resp.StatusCode, http.StatusCreated)
+		}
+		if !bytes.Equal(server.paidBody, body) {
+			t.Errorf("%s: the paid retry delivered %d bytes that differ from the %d sent", method, len(server.paidBody), len(body))
+		}
+	}
+}
+
+func TestOversizedBodiesAreSentOnceAfterProbing(t *testing.T) {
+	server := newUploadServer(t)
+	c, err := NewPayingClient(testKeyHex, WithMaxBufferedBody(1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := randomBody(t, 3<<20)
+	resp, err := c.Post(server.URL+"/upload", "application/octet-stream", io.MultiReader(bytes.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || !bytes.Equal(server.paidBody, body) {
+		t.Errorf("status %d, %d bytes delivered; want the %d byte body uploaded with payment", resp.StatusCode, len(server.paidBody), len(body))
+	}
+	if len(server.unpaid) != 1 || server.unpaid[0] != http.MethodHead {
+		t.Errorf("unpaid requests %v, want a single HEAD probe", server.unpaid)
+	}
+
+	// The next upload pays up front from the cached requirements
+	server.unpaid = nil
+	resp, err = c.Post(server.URL+"/upload", "application/octet-stream", io.MultiReader(bytes.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(server.unpaid) != 0 {
+		t.Errorf("second upload made unpaid requests %v, want none", server.unpaid)
+	}
+}
+
+func TestDeleteIsPaid(t *testing.T) {
+	server := newUploadServer(t)
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Delete(server.URL + "/upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || len(server.unpaid) != 1 || server.unpaid[0] != http.MethodDelete {
+		t.Errorf("status %d, unpaid requests %v; want a DELETE paid on retry", resp.StatusCode, server.unpaid)
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// CancelPayment revokes a payment the client signed but the server has not
+// settled, e.g. after a request that failed before the response arrived.
+// requirements and nonce identify the payment (both are in the
+// PaymentSigned event); the client signs a CancelAuthorization and the
+// facilitator set with WithFacilitator submits it on-chain. Once cancelled,
+// the payment can no longer be settled.
+func (c *PayingClient) CancelPayment(ctx context.Context, requirements *types.PaymentRequirements, nonce string) (*types.CancelResponse, error) {
+	if c.discovery == nil {
+		return nil, ErrNoFacilitator
+	}
+	parsed, err := types.ParseNonce(strings.TrimSpace(nonce))
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	chainID, err := c.getChainID(requirements.Network)
+	if err != nil {
+		return nil, err
+	}
+	domain := eip712.TokenDomain(requirements.Network, chainID, requirements.Asset)
+	signature, err := eip712.SignTypedData(eip712.TypedDataForCancelAuthorization(c.signerAddr, parsed.String(), domain), c.signer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSigning, err)
+	}
+
+	body, err := json.Marshal(types.CancelRequest{
+		X402Version: 1,
+		Network:     requirements.Network,
+		Asset:       requirements.Asset,
+		Authorization: types.CancelAuthorization{
+			Authorizer: c.signerAddr,
+			Nonce:      parsed[:],
+		},
+		Signature: signature,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cancellation: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.discovery.url+"/cancel", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send cancellation: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, fmt.Errorf("facilitator /cancel returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var cancelResp types.CancelResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cancelResp); err != nil {
+		return nil, fmt.Errorf("failed to parse facilitator /cancel: %w", err)
+	}
+	if cancelResp.Success {
+		c.outstanding.release(parsed.String())
+	}
+	return &cancelResp, nil
+}
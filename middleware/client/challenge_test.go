@@ -0,0 +1,79 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+func TestPayingClientAnswersChallenges(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		issued   []types.PaymentChallenge
+		payloads []types.PaymentPayload
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if header := r.Header.Get(types.HeaderXPayment); header != "" {
+			var payload types.PaymentPayload
+			if err := json.Unmarshal([]byte(header), &payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			payloads = append(payloads, payload)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		challenge := types.PaymentChallenge{Nonce: fmt.Sprintf("challenge-%d", len(issued)), Resource: r.Host + r.URL.Path}
+		issued = append(issued, challenge)
+		requirements := x402test.Requirements()
+		extra, err := types.MergeExtra(nil, types.RequirementsExtra{Challenge: &challenge})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		requirements.Extra = extra
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(map[string]interface{}{"x402Version": 1, "payment_requirements": requirements})
+	}))
+	defer server.Close()
+
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(server.URL + "/resource")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, resp.StatusCode)
+		}
+	}
+
+	// Challenges are single-use: each request gets its own 402 and answers it
+	if len(issued) != 2 || len(payloads) != 2 {
+		t.Fatalf("%d challenges issued, %d payments; want a challenge per payment", len(issued), len(payloads))
+	}
+	for i, payload := range payloads {
+		echoed, err := types.ParseChallenge(payload.Extra)
+		if err != nil || echoed == nil || *echoed != issued[i] {
+			t.Errorf("payment %d echoes challenge %+v (%v), want %+v", i, echoed, err, issued[i])
+			continue
+		}
+		if nonce := payload.Payload.Authorization.Nonce.String(); !strings.EqualFold(nonce, issued[i].AuthorizationNonce()) {
+			t.Errorf("payment %d signs nonce %s, want %s derived from the challenge", i, nonce, issued[i].AuthorizationNonce())
+		}
+	}
+}
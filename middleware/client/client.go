@@ -2,21 +2,23 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/rand"
-	"encoding/hex"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math/big"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/transport"
 	"github.com/x402-rs/x402-go/pkg/types"
 )
 
@@ -25,10 +27,51 @@ type PayingClient struct {
 	client     *http.Client
 	signer     *ecdsa.PrivateKey
 	signerAddr common.Address
+	eventHook  func(PaymentEvent)
+	discovery  *facilitatorDiscovery // nil unless WithFacilitator
+
+	// Request header the signed payment is sent in (see WithPaymentHeader)
+	paymentHeader string
+
+	// Headers added to requests to paid servers, and transport settings
+	// applied once the options have run (see WithDefaultHeader, WithProxy)
+	defaultHeaders http.Header
+	proxyURL       string
+	tlsConfig      *tls.Config
+
+	// Signers whose requirements may be paid (nil trusts any requirements)
+	trustedSigners map[common.Address]bool
+
+	// Settlement receipt checks (see WithReceiptHandler)
+	receiptHandler     func(resp *http.Response, settlement *types.SettleResponse, err error)
+	trustedFacilitator *common.Address
+
+	// Body buffering and requirements cache for non-replayable requests
+	maxBufferedBody int64
+	requirementsMu  sync.Mutex
+	requirements    map[string]*types.PaymentRequirements // key: "METHOD URL"
+
+	// Requirements each target was last paid under, guarded by requirementsMu
+	// (see OutputSchema)
+	paidRequirements map[string]*types.PaymentRequirements // key: "METHOD URL"
+
+	// Pre-signed payments used before signing live (see WithVoucherStore)
+	vouchers *VoucherStore
+
+	// Signed authorizations that may still be settled (nil unless
+	// WithMaxOutstandingAuthorizations)
+	outstanding *outstandingAuths
+
+	// Least time left of the request deadline worth attempting the paid
+	// retry in (see WithMinRetryBudget)
+	minRetryBudget time.Duration
+
+	// The client's token balances per network (nil unless WithBalanceChecks)
+	balances *balanceChecker
 }
 
 // NewPayingClient creates a new client with payment capabilities
-func NewPayingClient(privateKeyHex string) (*PayingClient, error) {
+func NewPayingClient(privateKeyHex string, opts ...Option) (*PayingClient, error) {
 	// Parse private key
 	privateKeyHex = strings.TrimPrefix(privateKeyHex, "0x")
 	privateKey, err := crypto.HexToECDSA(privateKeyHex)
@@ -44,18 +87,53 @@ func NewPayingClient(privateKeyHex string) (*PayingClient, error) {
 	}
 	address := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	return &PayingClient{
+	c := &PayingClient{
 		client: &http.Client{
-			Timeout: 30 * time.Second, // Prevent indefinite hangs
+			Timeout:   30 * time.Second, // Prevent indefinite hangs
+			Transport: transport.DefaultConfig().RoundTripper(),
 		},
-		signer:     privateKey,
-		signerAddr: address,
-	}, nil
+		signer:           privateKey,
+		signerAddr:       address,
+		paymentHeader:    types.HeaderXPayment,
+		maxBufferedBody:  DefaultMaxBufferedBody,
+		requirements:     make(map[string]*types.PaymentRequirements),
+		paidRequirements: make(map[string]*types.PaymentRequirements),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.configureTransport(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WithTransport tunes connection pooling for requests to paid servers
+func WithTransport(cfg transport.Config) Option {
+	return func(c *PayingClient) {
+		c.client.Transport = cfg.RoundTripper()
+	}
+}
+
+// WithPaymentHeader sends the signed payment in the named request header
+// instead of X-PAYMENT (e.g. types.HeaderPaymentPayload for servers running
+// x402-go releases that only read X-Payment-Payload)
+func WithPaymentHeader(name string) Option {
+	return func(c *PayingClient) {
+		if name != "" {
+			c.paymentHeader = name
+		}
+	}
 }
 
 // Get performs a GET request with automatic payment handling
 func (c *PayingClient) Get(url string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
+	return c.GetContext(context.Background(), url)
+}
+
+// GetContext is Get with a context bounding the whole payment flow
+func (c *PayingClient) GetContext(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +142,51 @@ func (c *PayingClient) Get(url string) (*http.Response, error) {
 
 // Post performs a POST request with automatic payment handling
 func (c *PayingClient) Post(url, contentType string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequest("POST", url, body)
+	return c.PostContext(context.Background(), url, contentType, body)
+}
+
+// PostContext is Post with a context bounding the whole payment flow
+func (c *PayingClient) PostContext(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	return c.doWithBody(ctx, http.MethodPost, url, contentType, body)
+}
+
+// Put performs a PUT request with automatic payment handling
+func (c *PayingClient) Put(url, contentType string, body io.Reader) (*http.Response, error) {
+	return c.PutContext(context.Background(), url, contentType, body)
+}
+
+// PutContext is Put with a context bounding the whole payment flow
+func (c *PayingClient) PutContext(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	return c.doWithBody(ctx, http.MethodPut, url, contentType, body)
+}
+
+// Patch performs a PATCH request with automatic payment handling
+func (c *PayingClient) Patch(url, contentType string, body io.Reader) (*http.Response, error) {
+	return c.PatchContext(context.Background(), url, contentType, body)
+}
+
+// PatchContext is Patch with a context bounding the whole payment flow
+func (c *PayingClient) PatchContext(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	return c.doWithBody(ctx, http.MethodPatch, url, contentType, body)
+}
+
+// Delete performs a DELETE request with automatic payment handling
+func (c *PayingClient) Delete(url string) (*http.Response, error) {
+	return c.DeleteContext(context.Background(), url)
+}
+
+// DeleteContext is Delete with a context bounding the whole payment flow
+func (c *PayingClient) DeleteContext(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// doWithBody builds a request with a body and content type and executes it
+func (c *PayingClient) doWithBody(ctx context.Context, method, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -72,8 +194,36 @@ func (c *PayingClient) Post(url, contentType string, body io.Reader) (*http.Resp
 	return c.Do(req)
 }
 
+// DoContext is Do with ctx in place of req's context
+func (c *PayingClient) DoContext(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return c.Do(req.WithContext(ctx))
+}
+
 // Do executes an HTTP request with automatic payment handling
+//
+// Request bodies are buffered (up to the WithMaxBufferedBody limit) so the
+// paid retry replays them byte for byte. Larger bodies are sent only once:
+// requirements come from a cached 402 for the URL or a HEAD probe instead.
+//
+// req.Context() and the HTTP client's Timeout bound the whole flow, the
+// paid retry included; the response body stays under that deadline until
+// it is closed.
 func (c *PayingClient) Do(req *http.Request) (*http.Response, error) {
+	req, cancel := c.withDeadlineBudget(c.withDefaultHeaders(req))
+	resp, err := c.do(req)
+	return finishDeadlineBudget(resp, err, cancel)
+}
+
+// do runs the payment flow for Do
+func (c *PayingClient) do(req *http.Request) (*http.Response, error) {
+	replayable, err := c.prepareBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if !replayable {
+		return c.doStreaming(req)
+	}
+
 	// First, try the request without payment
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -86,43 +236,145 @@ func (c *PayingClient) Do(req *http.Request) (*http.Response, error) {
 	}
 
 	// Parse payment requirements from 402 response
-	requirements, err := c.parsePaymentRequirements(resp)
+	options, err := c.parsePaymentOptions(resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRequirementsParse, err)
+	}
+	resp.Body.Close()
+	if options, err = c.checkRequirementsSignature(resp, options); err != nil {
+		return nil, err
+	}
+	candidates, err := c.paymentCandidates(req.Context(), options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse payment requirements: %w", err)
+		return nil, err
 	}
+	requirements := candidates[0]
+	c.cacheRequirements(req, requirements)
 
-	// Generate payment payload
-	payload, err := c.generatePaymentPayload(requirements)
+	resp, err = c.payAndSend(req, requirements)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate payment: %w", err)
+		return nil, err
+	}
+	return c.fallBackOnInsufficientFunds(req, resp, requirements, candidates)
+}
+
+// payAndSend signs a payment for the requirements and sends req with it attached
+func (c *PayingClient) payAndSend(req *http.Request, requirements *types.PaymentRequirements) (*http.Response, error) {
+	url := req.URL.String()
+	c.emit(PaymentEvent{Type: PaymentRequired, URL: url, Requirements: requirements})
+
+	// Give up before signing if the deadline leaves no room for the retry
+	if err := c.checkRetryBudget(req.Context()); err != nil {
+		return nil, err
+	}
+
+	// Wait for room under the outstanding authorization cap
+	if err := c.outstanding.acquire(req.Context()); err != nil {
+		return nil, err
+	}
+
+	// Pay from a voucher if one matches, else sign now
+	payload, ok := c.takeVoucher(requirements)
+	if !ok {
+		var err error
+		if payload, err = c.generatePaymentPayload(requirements); err != nil {
+			c.outstanding.abandon()
+			return nil, err
+		}
+	}
+	c.recordPaidRequirements(req, requirements)
+	signed := PaymentEvent{
+		Type:         PaymentSigned,
+		URL:          url,
+		Requirements: requirements,
+		Network:      payload.Network,
+		Amount:       payload.Payload.Authorization.Value,
+		Nonce:        payload.Payload.Authorization.Nonce.String(),
 	}
+	c.emit(signed)
 
 	// Retry request with payment
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payment: %w", err)
+		c.outstanding.abandon()
+		return nil, fmt.Errorf("%w: failed to marshal payment: %w", ErrSigning, err)
 	}
 
-	// Clone request
+	// Clone request, replaying the buffered body
 	retryReq := req.Clone(req.Context())
-	retryReq.Header.Set("X-Payment-Payload", string(payloadJSON))
+	if req.GetBody != nil {
+		if retryReq.Body, err = req.GetBody(); err != nil {
+			c.outstanding.abandon()
+			return nil, fmt.Errorf("failed to replay request body: %w", err)
+		}
+	}
+	retryReq.Header.Set(c.paymentHeader, string(payloadJSON))
+
+	// Execute with payment; from here on the server may hold the
+	// authorization, so it counts until settled or expired
+	c.outstanding.bind(payload)
+	retryResp, err := c.client.Do(retryReq)
+	if err != nil {
+		if neverSent(err) {
+			c.outstanding.release(payload.Payload.Authorization.Nonce.String())
+		}
+		return nil, err
+	}
+
+	outcome := signed
+	outcome.StatusCode = retryResp.StatusCode
+	if retryResp.StatusCode == http.StatusPaymentRequired {
+		outcome.Type = PaymentRejected
+		outcome.Reason, outcome.ReasonCode = c.rejectionReason(retryResp)
+	} else {
+		outcome.Type = PaymentAccepted
+	}
+	c.emit(outcome)
+	c.outstanding.releaseSettled(retryResp, payload)
+
+	if outcome.Type == PaymentAccepted && c.receiptHandler != nil {
+		settlement, receiptErr := c.checkReceipt(retryResp, payload)
+		c.receiptHandler(retryResp, settlement, receiptErr)
+	}
+
+	return retryResp, nil
+}
+
+// rejectionReason reads the reason and its code from a 402 body, leaving the body readable
+func (c *PayingClient) rejectionReason(resp *http.Response) (string, types.ReasonCode) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", ""
+	}
 
-	// Execute with payment
-	return c.client.Do(retryReq)
+	var response struct {
+		Reason     string           `json:"reason"`
+		ReasonCode types.ReasonCode `json:"reasonCode"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", ""
+	}
+	return response.Reason, response.ReasonCode
 }
 
-// parsePaymentRequirements extracts payment requirements from a 402 response
-func (c *PayingClient) parsePaymentRequirements(resp *http.Response) (*types.PaymentRequirements, error) {
-	// Try header first
-	reqHeader := resp.Header.Get("X-Payment-Required")
-	if reqHeader != "" {
+// parsePaymentOptions extracts the payment options from a 402 response
+// The body carries the full document (payment_requirements, plus any
+// alternatives in accepts); the X-Payment-Required header carries a summary
+// (or the full document on bodyless HEAD responses) used to fill gaps
+func (c *PayingClient) parsePaymentOptions(resp *http.Response) ([]*types.PaymentRequirements, error) {
+	var fromHeader *types.PaymentRequirements
+	reqHeader := strings.TrimSpace(resp.Header.Get(types.HeaderPaymentRequired))
+	if reqHeader != "" && reqHeader != types.RequirementsHeaderRef {
 		var requirements types.PaymentRequirements
-		if err := json.Unmarshal([]byte(reqHeader), &requirements); err == nil {
-			return &requirements, nil
+		if err := json.Unmarshal([]byte(reqHeader), &requirements); err != nil {
+			return nil, fmt.Errorf("invalid X-Payment-Required header: %w", err)
 		}
+		fromHeader = &requirements
 	}
 
-	// Try body
+	// Read body, leaving it readable for the caller
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -131,38 +383,86 @@ func (c *PayingClient) parsePaymentRequirements(resp *http.Response) (*types.Pay
 	resp.Body = io.NopCloser(bytes.NewReader(body))
 
 	var response struct {
-		PaymentRequirements types.PaymentRequirements `json:"payment_requirements"`
+		PaymentRequirements *types.PaymentRequirements   `json:"payment_requirements"`
+		Accepts             []*types.PaymentRequirements `json:"accepts"`
 	}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, err
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &response); err != nil && fromHeader == nil {
+			return nil, err
+		}
+	}
+
+	requirements := response.PaymentRequirements
+	switch {
+	case requirements == nil && fromHeader != nil:
+		requirements = fromHeader
+	case requirements != nil && fromHeader != nil:
+		requirements.MergeFallback(fromHeader)
 	}
 
-	return &response.PaymentRequirements, nil
+	var options []*types.PaymentRequirements
+	if requirements != nil {
+		options = append(options, requirements)
+	}
+	for _, option := range response.Accepts {
+		if option != nil {
+			options = append(options, option)
+		}
+	}
+	if len(options) == 0 {
+		return nil, fmt.Errorf("402 response carries no payment requirements")
+	}
+	return options, nil
 }
 
 // generatePaymentPayload creates a payment payload for the given requirements
 func (c *PayingClient) generatePaymentPayload(requirements *types.PaymentRequirements) (*types.PaymentPayload, error) {
+	// Set validity window based on server's MaxTimeoutSeconds (default: 1
+	// hour), never shorter than its MinTimeoutSeconds
+	now := time.Now().Unix()
+	return c.signPayment(requirements, now, now+paymentTimeout(requirements))
+}
+
+// paymentTimeout returns the validity window length in seconds for requirements
+func paymentTimeout(requirements *types.PaymentRequirements) int64 {
+	timeout := int64(3600) // Default: 1 hour
+	if requirements.MaxTimeoutSeconds > 0 {
+		timeout = int64(requirements.MaxTimeoutSeconds)
+	}
+	return max(timeout, int64(requirements.MinTimeoutSeconds))
+}
+
+// signPayment signs a payment for requirements valid from validAfter until
+// validBefore (unix seconds)
+func (c *PayingClient) signPayment(requirements *types.PaymentRequirements, validAfter, validBefore int64) (*types.PaymentPayload, error) {
 	// Only support EVM for now
 	if !requirements.Network.IsEVM() {
-		return nil, fmt.Errorf("unsupported network: %s", requirements.Network)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, requirements.Network)
 	}
 
-	// Generate nonce
-	nonce := make([]byte, 32)
-	_, err := rand.Read(nonce)
+	// Generate nonce; a server challenge dictates it so the signature is bound
+	// to it, otherwise it is derived from the resource (see types.ResourceBinding)
+	var authNonce types.HexBytes
+	var binding *types.ResourceBinding
+	extra, err := types.ParseExtra(requirements.Extra)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrRequirementsParse, err)
 	}
-
-	// Set validity window based on server's MaxTimeoutSeconds (default: 1 hour)
-	now := time.Now().Unix()
-	validAfter := uint64(now)
-
-	timeout := 3600 // Default: 1 hour
-	if requirements.MaxTimeoutSeconds > 0 {
-		timeout = requirements.MaxTimeoutSeconds
+	challenge := extra.Challenge
+	if challenge != nil {
+		authNonce = common.HexToHash(challenge.AuthorizationNonce()).Bytes()
+	} else if requirements.Resource != "" {
+		if binding, err = types.NewResourceBinding(requirements.Resource); err != nil {
+			return nil, fmt.Errorf("%w: failed to generate nonce: %w", ErrSigning, err)
+		}
+		authNonce = common.HexToHash(binding.AuthorizationNonce()).Bytes()
+	} else {
+		var nonce types.Nonce
+		if _, err := rand.Read(nonce[:]); err != nil {
+			return nil, fmt.Errorf("%w: failed to generate nonce: %w", ErrSigning, err)
+		}
+		authNonce = nonce[:]
 	}
-	validBefore := uint64(now + int64(timeout))
 
 	// Parse receiver address
 	receiverAddr := requirements.PayTo
@@ -174,117 +474,84 @@ func (c *PayingClient) generatePaymentPayload(requirements *types.PaymentRequire
 		Value:       requirements.MaxAmountRequired,
 		ValidAfter:  fmt.Sprintf("%d", validAfter),
 		ValidBefore: fmt.Sprintf("%d", validBefore),
-		Nonce:       "0x" + hex.EncodeToString(nonce),
+		Nonce:       authNonce,
 	}
 
-	// Sign with EIP-712
-	signature, err := c.signEIP712(&auth, requirements.Asset.Hex(), requirements.Network)
+	// Sign with EIP-712: a transferWithAuthorization, or in the permit2
+	// scheme a Permit2 transfer for the spender the requirements name
+	scheme := types.SchemeExact
+	var permit2 *types.Permit2Payload
+	var signature []byte
+	if requirements.Scheme == types.SchemePermit2 {
+		if extra.Permit2 == nil {
+			return nil, fmt.Errorf("%w: permit2 requirements name no spender", ErrRequirementsParse)
+		}
+		scheme = types.SchemePermit2
+		permit2 = &types.Permit2Payload{Spender: extra.Permit2.Spender}
+		signature, err = c.signPermit2(&auth, requirements.Asset, extra.Permit2.Spender, requirements.Network)
+	} else {
+		signature, err = c.signEIP712(&auth, requirements.Asset.Hex(), requirements.Network)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrSigning, err)
 	}
 
 	// Create payload
-	return &types.PaymentPayload{
+	payload := &types.PaymentPayload{
 		X402Version: 1,
-		Scheme:      types.SchemeExact,
+		Scheme:      scheme,
 		Network:     requirements.Network,
 		Payload: types.ExactEvmPayload{
-			Signature:     "0x" + hex.EncodeToString(signature),
+			Signature:     signature,
 			Authorization: auth,
+			Permit2:       permit2,
 		},
-	}, nil
+	}
+	if challenge != nil {
+		// Echo the extra field so the server can find its challenge
+		payload.Extra = requirements.Extra
+	}
+	if binding != nil {
+		if payload.Extra, err = types.MergeExtra(nil, types.RequirementsExtra{ResourceBinding: binding}); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSigning, err)
+		}
+	}
+	if extra.Quote != nil && challenge == nil {
+		// Echo the price quote so the server can tell it has not lapsed
+		if payload.Extra, err = types.MergeExtra(payload.Extra, types.RequirementsExtra{Quote: extra.Quote}); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSigning, err)
+		}
+	}
+	return payload, nil
 }
 
 // signEIP712 signs the authorization with EIP-712
-func (c *PayingClient) signEIP712(auth *types.ExactEvmPayloadAuthorization, tokenAddress string, network types.Network) ([]byte, error) {
+func (c *PayingClient) signEIP712(auth *types.ExactEvmPayloadAuthorization, tokenAddress string, net types.Network) ([]byte, error) {
 	// Get chain ID for network
-	chainID, err := c.getChainID(network)
+	chainID, err := c.getChainID(net)
 	if err != nil {
 		return nil, err
 	}
+	domain := eip712.TokenDomain(net, chainID, common.HexToAddress(tokenAddress))
+	return eip712.SignTransferWithAuthorization(auth, domain, c.signer)
+}
 
-	// Create EIP-712 typed data
-	typedData := apitypes.TypedData{
-		Types: apitypes.Types{
-			"EIP712Domain": []apitypes.Type{
-				{Name: "name", Type: "string"},
-				{Name: "version", Type: "string"},
-				{Name: "chainId", Type: "uint256"},
-				{Name: "verifyingContract", Type: "address"},
-			},
-			"TransferWithAuthorization": []apitypes.Type{
-				{Name: "from", Type: "address"},
-				{Name: "to", Type: "address"},
-				{Name: "value", Type: "uint256"},
-				{Name: "validAfter", Type: "uint256"},
-				{Name: "validBefore", Type: "uint256"},
-				{Name: "nonce", Type: "bytes32"},
-			},
-		},
-		PrimaryType: "TransferWithAuthorization",
-		Domain: apitypes.TypedDataDomain{
-			Name:              "USD Coin",
-			Version:           "2",
-			ChainId:           (*math.HexOrDecimal256)(chainID),
-			VerifyingContract: tokenAddress,
-		},
-		Message: apitypes.TypedDataMessage{
-			"from":        auth.From.Hex(),
-			"to":          auth.To.Hex(),
-			"value":       auth.Value,
-			"validAfter":  fmt.Sprintf("%d", auth.ValidAfter),
-			"validBefore": fmt.Sprintf("%d", auth.ValidBefore),
-			"nonce":       auth.Nonce,
-		},
-	}
-
-	// Hash the typed data
-	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
-	if err != nil {
-		return nil, fmt.Errorf("failed to hash domain: %w", err)
-	}
-
-	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
-	if err != nil {
-		return nil, fmt.Errorf("failed to hash message: %w", err)
-	}
-
-	rawData := []byte(fmt.Sprintf("\x19\x01%s%s", string(domainSeparator), string(typedDataHash)))
-	hash := crypto.Keccak256Hash(rawData)
-
-	// Sign the hash
-	signature, err := crypto.Sign(hash.Bytes(), c.signer)
+// signPermit2 signs the authorization as a Permit2 PermitWitnessTransferFrom
+// of token, to be submitted by spender
+func (c *PayingClient) signPermit2(auth *types.ExactEvmPayloadAuthorization, token, spender common.Address, net types.Network) ([]byte, error) {
+	chainID, err := c.getChainID(net)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign: %w", err)
-	}
-
-	// Adjust V value
-	if signature[64] < 27 {
-		signature[64] += 27
+		return nil, err
 	}
-
-	return signature, nil
+	typedData := eip712.TypedDataForPermit2(auth, token, spender, eip712.Permit2Domain(chainID))
+	return eip712.SignTypedData(typedData, c.signer)
 }
 
-// getChainID returns the chain ID for a network
+// getChainID returns the chain ID for a network (see types.RegisterNetwork)
 func (c *PayingClient) getChainID(network types.Network) (*big.Int, error) {
-	// Hardcoded chain IDs for now
-	chainIDs := map[types.Network]int64{
-		types.NetworkBaseSepolia:   84532,
-		types.NetworkBase:          8453,
-		types.NetworkAvalancheFuji: 43113,
-		types.NetworkAvalanche:     43114,
-		types.NetworkPolygonAmoy:   80002,
-		types.NetworkPolygon:       137,
-		types.NetworkSei:           1329,
-		types.NetworkSeiTestnet:    1328,
-		types.NetworkXDC:           50,
-	}
-
-	chainID, ok := chainIDs[network]
+	chainID, ok := network.ChainID()
 	if !ok {
 		return nil, fmt.Errorf("unknown chain ID for network: %s", network)
 	}
-
-	return big.NewInt(chainID), nil
+	return new(big.Int).SetUint64(chainID), nil
 }
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+const testKeyHex = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// word left-pads big-endian bytes to a 32-byte ABI word
+func word(b []byte) []byte {
+	return common.LeftPadBytes(b, 32)
+}
+
+func uint256(v int64) []byte {
+	return math.U256Bytes(big.NewInt(v))
+}
+
+func TestSignEIP712SignsDecimalValidityBounds(t *testing.T) {
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const net types.Network = "base-sepolia"
+	payer := crypto.PubkeyToAddress(c.signer.PublicKey)
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	token := common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e")
+	auth := types.ExactEvmPayloadAuthorization{
+		From:        payer,
+		To:          payTo,
+		Value:       "1000",
+		ValidAfter:  "1700000000",
+		ValidBefore: "1700000300",
+		Nonce:       make([]byte, 32),
+	}
+	signature, err := c.signEIP712(&auth, token.Hex(), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The digest as the token computes it, with the bounds as uint256
+	name, version := network.TokenDomain(net, token)
+	chainID, _ := net.ChainID()
+	domainSeparator := crypto.Keccak256(
+		crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)")),
+		crypto.Keccak256([]byte(name)),
+		crypto.Keccak256([]byte(version)),
+		math.U256Bytes(new(big.Int).SetUint64(chainID)),
+		word(token.Bytes()),
+	)
+	structHash := crypto.Keccak256(
+		crypto.Keccak256([]byte("TransferWithAuthorization(address from,address to,uint256 value,uint256 validAfter,uint256 validBefore,bytes32 nonce)")),
+		word(payer.Bytes()),
+		word(payTo.Bytes()),
+		uint256(1000),
+		uint256(1700000000),
+		uint256(1700000300),
+		make([]byte, 32),
+	)
+	digest := crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, structHash)
+
+	sig := append([]byte(nil), signature...)
+	sig[64] -= 27
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer := crypto.PubkeyToAddress(*pub); signer != payer {
+		t.Errorf("signature over the token's digest recovers %s, want the payer %s", signer.Hex(), payer.Hex())
+	}
+}
+
+func TestPaymentsRecoverThroughSharedTypedData(t *testing.T) {
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirements := x402test.Requirements()
+	payload, err := c.generatePaymentPayload(&requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID, _ := requirements.Network.ChainID()
+	domain := eip712.TokenDomain(requirements.Network, new(big.Int).SetUint64(chainID), requirements.Asset)
+	auth := payload.Payload.Authorization
+	signer, err := eip712.RecoverSigner(&auth, payload.Payload.Signature, domain)
+	if err != nil || signer != crypto.PubkeyToAddress(c.signer.PublicKey) {
+		t.Errorf("client signature recovers %s (%v), want the client's address", signer.Hex(), err)
+	}
+}
+
+func TestPermit2PaymentsSignForTheSpender(t *testing.T) {
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spender := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	requirements := x402test.Requirements()
+	requirements.Scheme = types.SchemePermit2
+	requirements.Extra, err = types.SetExtraField(nil, types.Permit2ExtraKey, types.Permit2Extra{Spender: spender})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := c.generatePaymentPayload(&requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Scheme != types.SchemePermit2 || payload.Payload.Permit2 == nil || payload.Payload.Permit2.Spender != spender {
+		t.Fatalf("payload scheme %q with permit2 %+v, want a permit2 payment for %s", payload.Scheme, payload.Payload.Permit2, spender.Hex())
+	}
+
+	chainID, _ := requirements.Network.ChainID()
+	auth := payload.Payload.Authorization
+	typedData := eip712.TypedDataForPermit2(&auth, requirements.Asset, spender, eip712.Permit2Domain(new(big.Int).SetUint64(chainID)))
+	signer, err := eip712.RecoverTypedDataSigner(typedData, payload.Payload.Signature)
+	if err != nil || signer != crypto.PubkeyToAddress(c.signer.PublicKey) {
+		t.Errorf("permit recovers %s (%v), want the client's address", signer.Hex(), err)
+	}
+
+	// Without a spender there is nothing to sign for
+	requirements.Extra = nil
+	if _, err := c.generatePaymentPayload(&requirements); !errors.Is(err, ErrRequirementsParse) {
+		t.Errorf("permit2 requirements without a spender: error %v, want ErrRequirementsParse", err)
+	}
+}
+
+func TestPaymentWindowHonorsMinimum(t *testing.T) {
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		maxTimeout, minTimeout int
+		want                   int64
+	}{
+		{0, 0, 3600},
+		{300, 0, 300},
+		{300, 60, 300},
+		{300, 300, 300},
+		{30, 120, 120}, // A maximum under the minimum admits no window; sign the minimum
+		{0, 7200, 7200},
+	} {
+		requirements := x402test.Requirements()
+		requirements.MaxTimeoutSeconds, requirements.MinTimeoutSeconds = tc.maxTimeout, tc.minTimeout
+		payload, err := c.generatePaymentPayload(&requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		auth := payload.Payload.Authorization
+		validAfter, _ := new(big.Int).SetString(auth.ValidAfter, 10)
+		validBefore, _ := new(big.Int).SetString(auth.ValidBefore, 10)
+		if window := new(big.Int).Sub(validBefore, validAfter).Int64(); window != tc.want {
+			t.Errorf("max %d, min %d: signed a %d second window, want %d", tc.maxTimeout, tc.minTimeout, window, tc.want)
+		}
+	}
+}
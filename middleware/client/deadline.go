@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithMinRetryBudget aborts a payment flow with context.DeadlineExceeded,
+// before anything is signed, when less than d remains of the request's
+// deadline after the 402; the paid retry would likely time out after the
+// payment went out otherwise
+func WithMinRetryBudget(d time.Duration) Option {
+	return func(c *PayingClient) {
+		c.minRetryBudget = d
+	}
+}
+
+// withDeadlineBudget bounds the whole payment flow (the unpaid attempt,
+// signing and the paid retry) by the HTTP client's Timeout, on top of any
+// deadline req.Context() already has, so the retry does not get a fresh
+// timeout of its own. The cancel func must run once the flow is over.
+func (c *PayingClient) withDeadlineBudget(req *http.Request) (*http.Request, context.CancelFunc) {
+	if c.client.Timeout <= 0 {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), c.client.Timeout)
+	return req.WithContext(ctx), cancel
+}
+
+// checkRetryBudget returns an error unless enough of ctx remains for the
+// paid retry (see WithMinRetryBudget)
+func (c *PayingClient) checkRetryBudget(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok || c.minRetryBudget <= 0 {
+		return nil
+	}
+	if remaining := time.Until(deadline); remaining < c.minRetryBudget {
+		return fmt.Errorf("%w: %s left for the paid retry, need %s",
+			context.DeadlineExceeded, remaining.Round(time.Millisecond), c.minRetryBudget)
+	}
+	return nil
+}
+
+// finishDeadlineBudget ties cancel to the response body, so the deadline
+// keeps covering the body until the caller closes it, or runs it right
+// away when there is no response
+func finishDeadlineBudget(resp *http.Response, err error, cancel context.CancelFunc) (*http.Response, error) {
+	if err != nil || resp == nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose runs cancel once the body it wraps is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
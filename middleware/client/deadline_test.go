@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// slowServer answers unpaid requests with a 402 after unpaidDelay and paid
+// ones with a settled 200 after paidDelay, unless the request is cancelled
+// first; it counts the paid attempts it sees
+type slowServer struct {
+	unpaidDelay, paidDelay time.Duration
+	paid                   atomic.Int32
+}
+
+func (s *slowServer) RoundTrip(r *http.Request) (*http.Response, error) {
+	delay, resp := s.unpaidDelay, offering(x402test.Requirements())
+	if r.Header.Get(types.HeaderXPayment) != "" {
+		s.paid.Add(1)
+		delay, resp = s.paidDelay, settledResponse
+	}
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return nil, r.Context().Err()
+	}
+	out := resp()
+	out.Request = r
+	return out, nil
+}
+
+func TestDeadlineExpiringBeforeRetrySignsNothing(t *testing.T) {
+	server := &slowServer{unpaidDelay: 80 * time.Millisecond}
+	var events []PaymentEventType
+	c, err := NewPayingClient(testKeyHex,
+		WithHTTPClient(&http.Client{Transport: server}),
+		WithMinRetryBudget(50*time.Millisecond),
+		WithEventHook(func(e PaymentEvent) { events = append(events, e.Type) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 20ms are left after the 402, less than the 50ms budget
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = c.GetContext(ctx, "http://paid.test/resource")
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "left for the paid retry") {
+		t.Fatalf("error %v, want context.DeadlineExceeded from the budget check", err)
+	}
+	if server.paid.Load() != 0 {
+		t.Error("the paid retry was sent")
+	}
+	for _, e := range events {
+		if e == PaymentSigned {
+			t.Errorf("events %v: a payment was signed", events)
+		}
+	}
+
+	// With time to spare the same flow pays
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := c.GetContext(ctx, "http://paid.test/resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || server.paid.Load() != 1 {
+		t.Errorf("status %d after %d paid attempts, want one paid 200", resp.StatusCode, server.paid.Load())
+	}
+}
+
+func TestClientTimeoutCoversBothAttempts(t *testing.T) {
+	// Each attempt fits the timeout on its own, both together do not
+	server := &slowServer{unpaidDelay: 120 * time.Millisecond, paidDelay: 120 * time.Millisecond}
+	c, err := NewPayingClient(testKeyHex, WithHTTPClient(&http.Client{Transport: server, Timeout: 200 * time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err = c.Get("http://paid.test/resource")
+	if err == nil {
+		t.Fatal("the flow outlived the client timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %v, want within the 200ms timeout", elapsed)
+	}
+	if server.paid.Load() != 1 {
+		t.Errorf("%d paid attempts, want the retry cut short", server.paid.Load())
+	}
+}
+
+func TestContextCancelsPaidRetry(t *testing.T) {
+	server := &slowServer{paidDelay: time.Hour}
+	c, err := NewPayingClient(testKeyHex, WithHTTPClient(&http.Client{Transport: server}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.PostContext(ctx, "http://paid.test/resource", "application/json", strings.NewReader(`{}`))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("error %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling did not stop the paid retry")
+	}
+}
+
+func TestResponseBodyReadableUnderDeadline(t *testing.T) {
+	server := &slowServer{}
+	c, err := NewPayingClient(testKeyHex, WithHTTPClient(&http.Client{Transport: server, Timeout: time.Second}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, do := range map[string]func() (*http.Response, error){
+		"get": func() (*http.Response, error) { return c.GetContext(context.Background(), "http://paid.test/resource") },
+		"put": func() (*http.Response, error) {
+			return c.PutContext(context.Background(), "http://paid.test/resource", "text/plain", strings.NewReader("x"))
+		},
+		"patch": func() (*http.Response, error) {
+			return c.PatchContext(context.Background(), "http://paid.test/resource", "text/plain", strings.NewReader("x"))
+		},
+		"delete": func() (*http.Response, error) {
+			return c.DeleteContext(context.Background(), "http://paid.test/resource")
+		},
+		"do": func() (*http.Response, error) {
+			req, _ := http.NewRequest(http.MethodGet, "http://paid.test/resource", nil)
+			return c.DoContext(context.Background(), req)
+		},
+	} {
+		resp, err := do()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "ok") {
+			t.Errorf("%s: status %d, body %q, %v; want the paid response intact", name, resp.StatusCode, body, err)
+		}
+	}
+	if server.paid.Load() != 5 {
+		t.Errorf("%d paid attempts, want one per request", server.paid.Load())
+	}
+}
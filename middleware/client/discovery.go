@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultSupportedTTL is how long a facilitator's /supported response is reused
+const DefaultSupportedTTL = 5 * time.Minute
+
+// supportedRefreshMinInterval limits refetches triggered by unknown networks
+const supportedRefreshMinInterval = 10 * time.Second
+
+// facilitatorDiscovery caches what a facilitator can settle
+type facilitatorDiscovery struct {
+	url string
+	ttl time.Duration
+
+	mu      sync.Mutex
+	kinds   []types.SupportedPaymentKind
+	fetched time.Time
+}
+
+// WithFacilitator makes the client check the facilitator's GET /supported
+// before signing: only requirements it lists (network, scheme and asset) are
+// paid, and payments it could never settle fail with ErrNetworkNotSettleable
+func WithFacilitator(url string) Option {
+	return func(c *PayingClient) {
+		c.discovery = &facilitatorDiscovery{
+			url: strings.TrimSuffix(url, "/"),
+			ttl: DefaultSupportedTTL,
+		}
+	}
+}
+
+// supportedKinds returns the facilitator's payment kinds, fetching them when
+// the cache has expired or refresh is requested
+func (c *PayingClient) supportedKinds(ctx context.Context, refresh bool) ([]types.SupportedPaymentKind, error) {
+	d := c.discovery
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	age := time.Since(d.fetched)
+	if d.kinds != nil && age < d.ttl && (!refresh || age < supportedRefreshMinInterval) {
+		return d.kinds, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url+"/supported", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch facilitator /supported: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("facilitator /supported returned %d", resp.StatusCode)
+	}
+
+	var supported types.SupportedPaymentKindsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&supported); err != nil {
+		return nil, fmt.Errorf("failed to parse facilitator /supported: %w", err)
+	}
+	d.kinds = supported.Kinds
+	d.fetched = time.Now()
+	return d.kinds, nil
+}
+
+// selectRequirements picks the first payment option the client can sign and,
+// with WithFacilitator, the facilitator can settle (see paymentCandidates)
+func (c *PayingClient) selectRequirements(ctx context.Context, options []*types.PaymentRequirements) (*types.PaymentRequirements, error) {
+	candidates, err := c.paymentCandidates(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return candidates[0], nil
+}
+
+// paymentCandidates returns the payment options the client can sign and,
+// with WithFacilitator, the facilitator can settle, best first: in the
+// server's order, except that with WithBalanceChecks options the client's
+// balance covers come before the rest
+func (c *PayingClient) paymentCandidates(ctx context.Context, options []*types.PaymentRequirements) ([]*types.PaymentRequirements, error) {
+	var signable []*types.PaymentRequirements
+	for _, option := range options {
+		if option.Network.IsEVM() {
+			signable = append(signable, option)
+		}
+	}
+	if len(signable) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, options[0].Network)
+	}
+	if c.discovery == nil {
+		return c.orderByBalance(ctx, signable), nil
+	}
+
+	kinds, err := c.supportedKinds(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if settleable := settleableOptions(kinds, signable); len(settleable) > 0 {
+		return c.orderByBalance(ctx, settleable), nil
+	}
+
+	// The facilitator may have added networks since the cache was filled
+	if kinds, err = c.supportedKinds(ctx, true); err != nil {
+		return nil, err
+	}
+	if settleable := settleableOptions(kinds, signable); len(settleable) > 0 {
+		return c.orderByBalance(ctx, settleable), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNetworkNotSettleable, signable[0].Network)
+}
+
+// settleableOptions returns the options matching one of the kinds
+func settleableOptions(kinds []types.SupportedPaymentKind, options []*types.PaymentRequirements) []*types.PaymentRequirements {
+	var settleable []*types.PaymentRequirements
+	for _, option := range options {
+		for _, kind := range kinds {
+			if kind.Scheme != option.Scheme || kind.Network != option.Network {
+				continue
+			}
+			if kind.Token.Address != "" && !strings.EqualFold(kind.Token.Address, option.Asset.Hex()) {
+				continue
+			}
+			settleable = append(settleable, option)
+			break
+		}
+	}
+	return settleable
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// stubFacilitator serves GET /supported listing exact USDC on the given
+// networks and counts the fetches
+type stubFacilitator struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	networks []types.Network
+	fetches  int
+}
+
+func newStubFacilitator(t *testing.T, networks ...types.Network) *stubFacilitator {
+	t.Helper()
+	s := &stubFacilitator{networks: networks}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/supported" {
+			http.NotFound(w, r)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		var kinds []types.SupportedPaymentKind
+		for _, net := range s.networks {
+			deployment, err := network.GetUSDCDeployment(net)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			kinds = append(kinds, types.SupportedPaymentKind{
+				Version: types.X402VersionV1,
+				Scheme:  types.SchemeExact,
+				Network: net,
+				Token:   types.MixedAddress{Type: "evm", Address: deployment.TokenAddress.Hex()},
+			})
+		}
+		json.NewEncoder(w).Encode(types.SupportedPaymentKindsResponse{Kinds: kinds})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubFacilitator) setNetworks(networks ...types.Network) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.networks = networks
+}
+
+func (s *stubFacilitator) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+// requirementsOn returns the x402test requirements moved to net's USDC
+func requirementsOn(t *testing.T, net types.Network) types.PaymentRequirements {
+	t.Helper()
+	deployment, err := network.GetUSDCDeployment(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirements := x402test.Requirements()
+	requirements.Network = net
+	requirements.Asset = deployment.TokenAddress
+	return requirements
+}
+
+// offeringServer answers unpaid requests with a 402 offering options and
+// records the networks of the payments it receives
+func offeringServer(t *testing.T, options ...types.PaymentRequirements) (*httptest.Server, func() []types.Network) {
+	t.Helper()
+	var (
+		mu   sync.Mutex
+		paid []types.Network
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header := r.Header.Get(types.HeaderXPayment); header != "" {
+			var payload types.PaymentPayload
+			if err := json.Unmarshal([]byte(header), &payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			paid = append(paid, payload.Network)
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(map[string]interface{}{"x402Version": 1, "accepts": options})
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []types.Network {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]types.Network(nil), paid...)
+	}
+}
+
+func TestWithFacilitatorRefusesUnsettleableNetworks(t *testing.T) {
+	facilitator := newStubFacilitator(t, types.NetworkBaseSepolia)
+	server, paid := offeringServer(t, requirementsOn(t, types.NetworkPolygon))
+	c, err := NewPayingClient(testKeyHex, WithFacilitator(facilitator.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Get(server.URL + "/resource")
+	if !errors.Is(err, ErrNetworkNotSettleable) {
+		t.Fatalf("402 demanding Polygon: error %v, want ErrNetworkNotSettleable", err)
+	}
+	if networks := paid(); len(networks) != 0 {
+		t.Errorf("signed payments on %v, want none", networks)
+	}
+
+	// Without a facilitator to ask, the client pays as before
+	c, err = NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get(server.URL + "/resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if networks := paid(); len(networks) != 1 || networks[0] != types.NetworkPolygon {
+		t.Errorf("paid on %v, want Polygon", networks)
+	}
+}
+
+func TestWithFacilitatorChoosesSettleableOption(t *testing.T) {
+	facilitator := newStubFacilitator(t, types.NetworkBaseSepolia)
+	server, paid := offeringServer(t, requirementsOn(t, types.NetworkPolygon), requirementsOn(t, types.NetworkBaseSepolia))
+	c, err := NewPayingClient(testKeyHex, WithFacilitator(facilitator.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(server.URL + "/resource")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if networks := paid(); len(networks) != 2 || networks[0] != types.NetworkBaseSepolia || networks[1] != types.NetworkBaseSepolia {
+		t.Errorf("paid on %v, want Base Sepolia, the option the facilitator settles", networks)
+	}
+	if n := facilitator.fetchCount(); n != 1 {
+		t.Errorf("/supported fetched %d times, want once within the TTL", n)
+	}
+}
+
+func TestWithFacilitatorRefreshesSupported(t *testing.T) {
+	facilitator := newStubFacilitator(t, types.NetworkBaseSepolia)
+	server, paid := offeringServer(t, requirementsOn(t, types.NetworkPolygon))
+	c, err := NewPayingClient(testKeyHex, WithFacilitator(facilitator.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(server.URL + "/resource"); !errors.Is(err, ErrNetworkNotSettleable) {
+		t.Fatalf("error %v, want ErrNetworkNotSettleable", err)
+	}
+
+	// The facilitator adds Polygon; an unknown network refetches once the
+	// cache is old enough to refresh
+	facilitator.setNetworks(types.NetworkBaseSepolia, types.NetworkPolygon)
+	if _, err := c.Get(server.URL + "/resource"); !errors.Is(err, ErrNetworkNotSettleable) {
+		t.Errorf("retry within the refresh interval: error %v, want the cached answer", err)
+	}
+	c.discovery.mu.Lock()
+	c.discovery.fetched = time.Now().Add(-supportedRefreshMinInterval)
+	c.discovery.mu.Unlock()
+	resp, err := c.Get(server.URL + "/resource")
+	if err != nil {
+		t.Fatalf("after Polygon was added: %v", err)
+	}
+	resp.Body.Close()
+	if networks := paid(); len(networks) != 1 || networks[0] != types.NetworkPolygon {
+		t.Errorf("paid on %v, want Polygon", networks)
+	}
+
+	// Past the TTL the list is refetched even for known networks
+	fetches := facilitator.fetchCount()
+	c.discovery.mu.Lock()
+	c.discovery.fetched = time.Now().Add(-DefaultSupportedTTL)
+	c.discovery.mu.Unlock()
+	resp, err = c.Get(server.URL + "/resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := facilitator.fetchCount(); n != fetches+1 {
+		t.Errorf("/supported fetched %d more times after the TTL, want 1", n-fetches)
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Sentinel errors returned by PayingClient; match with errors.Is
+var (
+	// ErrUnsupportedNetwork is returned when the server asks for a network the client cannot pay on
+	ErrUnsupportedNetwork = errors.New("unsupported network")
+	// ErrRequirementsParse is returned when a 402 response carries unreadable requirements
+	ErrRequirementsParse = errors.New("failed to parse payment requirements")
+	// ErrNetworkNotSettleable is returned when the configured facilitator cannot
+	// settle any of the payment options the server offers (see WithFacilitator)
+	ErrNetworkNotSettleable = errors.New("network not settleable by facilitator")
+	// ErrUntrustedRequirements is returned when requirements are not signed by a trusted signer
+	ErrUntrustedRequirements = errors.New("untrusted payment requirements")
+	// ErrReceiptMissing is reported when a paid response carries no X-Payment-Response
+	ErrReceiptMissing = errors.New("no settlement receipt")
+	// ErrReceiptMismatch is reported when a receipt does not match the signed payment (see ReceiptError)
+	ErrReceiptMismatch = errors.New("settlement receipt does not match payment")
+	// ErrUntrustedReceipt is reported when a receipt is not signed by the trusted facilitator
+	ErrUntrustedReceipt = errors.New("untrusted settlement receipt")
+	// ErrSigning is returned when the payment authorization cannot be built or signed
+	ErrSigning = errors.New("failed to sign payment")
+	// ErrNoFacilitator is returned by CancelPayment without WithFacilitator
+	ErrNoFacilitator = errors.New("no facilitator configured")
+	// ErrTooManyOutstanding is returned when the WithMaxOutstandingAuthorizations
+	// cap is reached under OutstandingFail
+	ErrTooManyOutstanding = errors.New("too many outstanding authorizations")
+)
+
+// PaymentEventType identifies a step in the payment flow
+type PaymentEventType string
+
+const (
+	// PaymentRequired fires when a server answers 402 with parseable requirements
+	PaymentRequired PaymentEventType = "payment_required"
+	// PaymentSigned fires once an authorization has been signed for the retry
+	PaymentSigned PaymentEventType = "payment_signed"
+	// PaymentAccepted fires when the paid retry is not answered with 402
+	PaymentAccepted PaymentEventType = "payment_accepted"
+	// PaymentRejected fires when the paid retry is answered with 402 again
+	PaymentRejected PaymentEventType = "payment_rejected"
+)
+
+// PaymentEvent describes a payment decision made by PayingClient
+type PaymentEvent struct {
+	Type         PaymentEventType
+	URL          string
+	Requirements *types.PaymentRequirements // Set for every event after PaymentRequired
+	Network      types.Network              // Set from PaymentSigned onwards
+	Amount       string                     // Set from PaymentSigned onwards
+	Nonce        string                     // Set from PaymentSigned onwards
+	StatusCode   int                        // Set for PaymentAccepted and PaymentRejected
+	Reason       string                     // Set for PaymentRejected when the server gives one
+	ReasonCode   types.ReasonCode           // Machine-readable form of Reason
+}
+
+// Option configures a PayingClient
+type Option func(*PayingClient)
+
+// WithEventHook registers a callback invoked synchronously for every payment event
+func WithEventHook(hook func(PaymentEvent)) Option {
+	return func(c *PayingClient) {
+		c.eventHook = hook
+	}
+}
+
+// emit delivers an event to the configured hook, if any
+func (c *PayingClient) emit(event PaymentEvent) {
+	if c.eventHook != nil {
+		c.eventHook(event)
+	}
+}
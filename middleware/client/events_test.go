@@ -0,0 +1,144 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// paidServer answers unpaid requests with unpaid and paid ones with paid
+type paidServer struct {
+	unpaid func() *http.Response
+	paid   func() *http.Response
+}
+
+func (s paidServer) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp := s.unpaid()
+	if r.Header.Get(types.HeaderXPayment) != "" {
+		resp = s.paid()
+	}
+	resp.Request = r
+	return resp, nil
+}
+
+func jsonResponse(status int, body interface{}) *http.Response {
+	data, _ := json.Marshal(body)
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+	}
+}
+
+// offering returns a 402 offering the given requirements
+func offering(requirements ...types.PaymentRequirements) func() *http.Response {
+	return func() *http.Response {
+		return jsonResponse(http.StatusPaymentRequired, map[string]interface{}{
+			"x402Version": 1,
+			"accepts":     requirements,
+		})
+	}
+}
+
+// recordEvents runs a GET through a client of server and returns the events it emitted
+func recordEvents(t *testing.T, server paidServer) ([]PaymentEvent, error) {
+	t.Helper()
+	var events []PaymentEvent
+	c, err := NewPayingClient(testKeyHex,
+		WithHTTPClient(&http.Client{Transport: server}),
+		WithEventHook(func(e PaymentEvent) { events = append(events, e) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get("http://paid.test/resource")
+	if err == nil {
+		resp.Body.Close()
+	}
+	return events, err
+}
+
+func eventTypes(events []PaymentEvent) []PaymentEventType {
+	kinds := make([]PaymentEventType, len(events))
+	for i, e := range events {
+		kinds[i] = e.Type
+	}
+	return kinds
+}
+
+func TestEventsOfAcceptedPayment(t *testing.T) {
+	events, err := recordEvents(t, paidServer{
+		unpaid: offering(x402test.Requirements()),
+		paid:   func() *http.Response { return jsonResponse(http.StatusOK, map[string]string{"ok": "yes"}) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PaymentEventType{PaymentRequired, PaymentSigned, PaymentAccepted}
+	if got := eventTypes(events); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events %v, want %v", got, want)
+	}
+	signed := events[1]
+	if signed.Network != x402test.Requirements().Network || signed.Amount != x402test.Requirements().MaxAmountRequired || signed.Nonce == "" {
+		t.Errorf("PaymentSigned: network %q, amount %q, nonce %q", signed.Network, signed.Amount, signed.Nonce)
+	}
+	if accepted := events[2]; accepted.StatusCode != http.StatusOK || accepted.Nonce != signed.Nonce {
+		t.Errorf("PaymentAccepted: status %d, nonce %q; want 200 and the signed nonce", accepted.StatusCode, accepted.Nonce)
+	}
+}
+
+func TestEventsOfRejectedPayment(t *testing.T) {
+	events, err := recordEvents(t, paidServer{
+		unpaid: offering(x402test.Requirements()),
+		paid: func() *http.Response {
+			return jsonResponse(http.StatusPaymentRequired, map[string]string{
+				"reason":     "insufficient funds",
+				"reasonCode": string(types.ReasonInsufficientFunds),
+			})
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PaymentEventType{PaymentRequired, PaymentSigned, PaymentRejected}
+	if got := eventTypes(events); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events %v, want %v", got, want)
+	}
+	if rejected := events[2]; rejected.Reason != "insufficient funds" || rejected.ReasonCode != types.ReasonInsufficientFunds {
+		t.Errorf("PaymentRejected: reason %q (%q)", rejected.Reason, rejected.ReasonCode)
+	}
+}
+
+func TestEventsAndErrorsOfUnpayableRequirements(t *testing.T) {
+	solana := x402test.Requirements()
+	solana.Network = "solana"
+	for _, tc := range []struct {
+		name   string
+		unpaid func() *http.Response
+		want   error
+	}{
+		{"unparseable 402", func() *http.Response {
+			return &http.Response{StatusCode: http.StatusPaymentRequired, Body: io.NopCloser(bytes.NewReader([]byte("not json")))}
+		}, ErrRequirementsParse},
+		{"no requirements", offering(), ErrRequirementsParse},
+		{"unsupported network", offering(solana), ErrUnsupportedNetwork},
+	} {
+		events, err := recordEvents(t, paidServer{
+			unpaid: tc.unpaid,
+			paid:   func() *http.Response { return jsonResponse(http.StatusOK, nil) },
+		})
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, tc.want)
+		}
+		if len(events) != 0 {
+			t.Errorf("%s: events %v before anything was paid", tc.name, eventTypes(events))
+		}
+	}
+}
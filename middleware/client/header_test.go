@@ -0,0 +1,194 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/middleware/server"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// response402 builds a 402 with the given X-Payment-Required header and body
+func response402(header, body string) *http.Response {
+	resp := &http.Response{
+		StatusCode: http.StatusPaymentRequired,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+	if header != "" {
+		resp.Header.Set(types.HeaderPaymentRequired, header)
+	}
+	return resp
+}
+
+func TestParsePaymentOptionsPrefersBody(t *testing.T) {
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := x402test.Requirements()
+	full.OutputSchema = json.RawMessage(`{"type":"object"}`)
+	summary, err := json.Marshal(full.Summary(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The body's document wins; the header summary fills what it leaves out
+	partial := full
+	partial.PayTo = ""
+	body, err := json.Marshal(map[string]interface{}{"payment_requirements": partial})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options, err := c.parsePaymentOptions(response402(string(summary), string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := options[0]; got.PayTo != full.PayTo || got.Description != full.Description || string(got.OutputSchema) != `{"type":"object"}` {
+		t.Errorf("merged requirements %+v", got)
+	}
+
+	// A reference header defers to the body entirely
+	body, _ = json.Marshal(map[string]interface{}{"payment_requirements": full})
+	if options, err := c.parsePaymentOptions(response402(types.RequirementsHeaderRef, string(body))); err != nil || options[0].MaxAmountRequired != full.MaxAmountRequired {
+		t.Errorf("%q header: %+v, %v", types.RequirementsHeaderRef, options, err)
+	}
+
+	// A bodyless (HEAD) 402 is read from the header
+	header, _ := json.Marshal(full)
+	if options, err := c.parsePaymentOptions(response402(string(header), "")); err != nil || options[0].Description != full.Description {
+		t.Errorf("bodyless 402: %+v, %v", options, err)
+	}
+}
+
+func TestParsePaymentOptionsReportsBrokenHeader(t *testing.T) {
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(map[string]interface{}{"payment_requirements": x402test.Requirements()})
+	if _, err := c.parsePaymentOptions(response402(`{"scheme":`, string(body))); err == nil || !strings.Contains(err.Error(), "X-Payment-Required") {
+		t.Errorf("truncated header: error %v, want it reported", err)
+	}
+	if _, err := c.parsePaymentOptions(response402(types.RequirementsHeaderRef, "")); err == nil {
+		t.Error("a 402 with neither header nor body requirements parsed")
+	}
+}
+
+// headerLimitedProxy forwards to target like a proxy that drops responses
+// with a header line over limit bytes
+func headerLimitedProxy(t *testing.T, target string, limit int) *httptest.Server {
+	t.Helper()
+	u, err := url.Parse(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		for name, values := range resp.Header {
+			for _, value := range values {
+				if len(name)+len(value) > limit {
+					return fmt.Errorf("response header %s is %d bytes", name, len(value))
+				}
+			}
+		}
+		return nil
+	}
+	s := httptest.NewServer(proxy)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestPaymentWithLargeSchemaThroughHeaderLimitedProxy(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	tag := &server.PriceTag{Requirements: x402test.Requirements()}
+	tag.Requirements.OutputSchema = json.RawMessage(fmt.Sprintf(`{"type":"object","description":%q}`, strings.Repeat("x", 50<<10)))
+	origin := httptest.NewServer(server.NewX402Middleware(facilitator.URL, server.WithSettleAfterSuccess()).Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("content"))
+	}), tag))
+	defer origin.Close()
+	proxy := headerLimitedProxy(t, origin.URL, 8<<10)
+
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get(proxy.URL + "/resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "content" {
+		t.Fatalf("status %d, body %q; want the paid content", resp.StatusCode, body)
+	}
+	if n := len(facilitator.Settled()); n != 1 {
+		t.Errorf("%d settlements, want 1", n)
+	}
+}
+
+func TestWithPaymentHeaderInteroperatesWithServerDialects(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	content := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("content")) })
+	for _, tc := range []struct {
+		name         string
+		serverNames  []string // Accepted by the server (nil for every alias)
+		clientHeader string   // Sent by the client ("" for the default)
+		paid         bool
+	}{
+		{"default client, default server", nil, "", true},
+		{"default client, legacy server", []string{types.HeaderPaymentPayload}, "", false},
+		{"legacy client, legacy server", []string{types.HeaderPaymentPayload}, types.HeaderPaymentPayload, true},
+		{"bare header client, default server", nil, types.HeaderPayment, true},
+	} {
+		var opts []server.Option
+		if tc.serverNames != nil {
+			opts = append(opts, server.WithPaymentHeader(tc.serverNames...))
+		}
+		var seen []string
+		m := server.NewX402Middleware(facilitator.URL, opts...)
+		protected := m.Protect(content, &server.PriceTag{Requirements: x402test.Requirements()})
+		origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, name := range types.PaymentHeaderAliases {
+				if r.Header.Get(name) != "" {
+					seen = append(seen, name)
+				}
+			}
+			protected.ServeHTTP(w, r)
+		}))
+
+		var clientOpts []Option
+		if tc.clientHeader != "" {
+			clientOpts = append(clientOpts, WithPaymentHeader(tc.clientHeader))
+		}
+		c, err := NewPayingClient(testKeyHex, clientOpts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Get(origin.URL + "/resource")
+		paid := err == nil && resp.StatusCode == http.StatusOK
+		if resp != nil {
+			resp.Body.Close()
+		}
+		origin.Close()
+
+		if paid != tc.paid {
+			t.Errorf("%s: paid %v (%v), want %v", tc.name, paid, err, tc.paid)
+		}
+		want := tc.clientHeader
+		if want == "" {
+			want = types.HeaderXPayment
+		}
+		if len(seen) == 0 || seen[0] != want {
+			t.Errorf("%s: payment sent in %q, want %s", tc.name, seen, want)
+		}
+	}
+}
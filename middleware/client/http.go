@@ -0,0 +1,116 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// WithHTTPClient sends requests to paid servers through httpClient (a copy
+// is taken, so later options do not change the caller's client)
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *PayingClient) {
+		if httpClient != nil {
+			copied := *httpClient
+			c.client = &copied
+		}
+	}
+}
+
+// WithDefaultHeader adds a header to every request to a paid server, the
+// paid retry included, unless the request already sets it (e.g. an API key
+// the server expects)
+func WithDefaultHeader(key, value string) Option {
+	return func(c *PayingClient) {
+		if c.defaultHeaders == nil {
+			c.defaultHeaders = make(http.Header)
+		}
+		c.defaultHeaders.Add(key, value)
+	}
+}
+
+// WithUserAgent sets the User-Agent of requests that do not set their own
+func WithUserAgent(userAgent string) Option {
+	return func(c *PayingClient) {
+		if c.defaultHeaders == nil {
+			c.defaultHeaders = make(http.Header)
+		}
+		c.defaultHeaders.Set("User-Agent", userAgent)
+	}
+}
+
+// WithProxy sends requests through the HTTP(S) proxy at proxyURL instead of
+// the one named by HTTP_PROXY / HTTPS_PROXY; NewPayingClient fails if the
+// URL is invalid or the client's transport is not an *http.Transport
+func WithProxy(proxyURL string) Option {
+	return func(c *PayingClient) {
+		c.proxyURL = proxyURL
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of connections to paid servers,
+// e.g. RootCAs holding a private CA bundle (same transport requirement as
+// WithProxy)
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *PayingClient) {
+		c.tlsConfig = cfg
+	}
+}
+
+// configureTransport applies WithProxy and WithTLSConfig to a copy of the
+// client's transport, once every option has run
+func (c *PayingClient) configureTransport() error {
+	if c.proxyURL == "" && c.tlsConfig == nil {
+		return nil
+	}
+
+	var base *http.Transport
+	switch rt := c.client.Transport.(type) {
+	case nil:
+		base = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		base = rt
+	default:
+		return fmt.Errorf("proxy and TLS options need an *http.Transport, client has %T", rt)
+	}
+	transport := base.Clone()
+
+	if c.proxyURL != "" {
+		proxy, err := url.Parse(c.proxyURL)
+		if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", c.proxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if c.tlsConfig != nil {
+		transport.TLSClientConfig = c.tlsConfig.Clone()
+	}
+
+	client := *c.client
+	client.Transport = transport
+	c.client = &client
+	return nil
+}
+
+// withDefaultHeaders returns req with the WithDefaultHeader headers it does
+// not set itself (req when there are none to add)
+func (c *PayingClient) withDefaultHeaders(req *http.Request) *http.Request {
+	var missing []string
+	for key := range c.defaultHeaders {
+		if _, ok := req.Header[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return req
+	}
+	withDefaults := req.Clone(req.Context())
+	if withDefaults.Header == nil {
+		withDefaults.Header = make(http.Header)
+	}
+	for _, key := range missing {
+		withDefaults.Header[key] = append([]string(nil), c.defaultHeaders[key]...)
+	}
+	return withDefaults
+}
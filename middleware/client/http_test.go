@@ -0,0 +1,149 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// headerServer charges the x402test requirements and records the headers
+// and target host of every request it answers
+type headerServer struct {
+	mu      sync.Mutex
+	headers []http.Header
+	hosts   []string
+}
+
+func (s *headerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.headers = append(s.headers, r.Header.Clone())
+	s.hosts = append(s.hosts, r.URL.Host)
+	s.mu.Unlock()
+	if r.Header.Get(types.HeaderXPayment) != "" {
+		w.Write([]byte("paid"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPaymentRequired)
+	json.NewEncoder(w).Encode(map[string]interface{}{"x402Version": 1, "payment_requirements": x402test.Requirements()})
+}
+
+func TestHeadersSurviveThePaidRetry(t *testing.T) {
+	recorder := &headerServer{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	c, err := NewPayingClient(testKeyHex,
+		WithDefaultHeader("X-Api-Key", "default-key"),
+		WithDefaultHeader("X-Tenant", "shop"),
+		WithUserAgent("x402-test/1.0"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/resource", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Api-Key", "request-key") // The request's own value wins
+	req.Header.Set("X-Trace", "trace-1")
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(recorder.headers) != 2 {
+		t.Fatalf("status %d after %d requests, want a paid retry", resp.StatusCode, len(recorder.headers))
+	}
+	for i, header := range recorder.headers {
+		for key, want := range map[string]string{
+			"X-Api-Key":  "request-key",
+			"X-Tenant":   "shop",
+			"X-Trace":    "trace-1",
+			"User-Agent": "x402-test/1.0",
+		} {
+			if got := header.Get(key); got != want {
+				t.Errorf("request %d: %s = %q, want %q", i+1, key, got, want)
+			}
+		}
+	}
+	if recorder.headers[1].Get(types.HeaderXPayment) == "" {
+		t.Error("the retry carries no payment")
+	}
+	if req.Header.Get("X-Tenant") != "" {
+		t.Error("default headers were added to the caller's request")
+	}
+}
+
+func TestWithProxyRoutesThroughProxy(t *testing.T) {
+	proxy := &headerServer{}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+	c, err := NewPayingClient(testKeyHex, WithProxy(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get("http://shop.invalid/resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	if len(proxy.hosts) != 2 || proxy.hosts[0] != "shop.invalid" || proxy.hosts[1] != "shop.invalid" {
+		t.Errorf("proxy saw requests for %v, want both legs for shop.invalid", proxy.hosts)
+	}
+}
+
+func TestWithProxyRejectsBadConfigurations(t *testing.T) {
+	if _, err := NewPayingClient(testKeyHex, WithProxy("://nowhere")); err == nil {
+		t.Error("accepted an invalid proxy URL")
+	}
+	custom := &http.Client{Transport: paidServer{unpaid: offering(x402test.Requirements())}}
+	if _, err := NewPayingClient(testKeyHex, WithHTTPClient(custom), WithProxy("http://proxy.test:3128")); err == nil {
+		t.Error("applied a proxy to a transport that cannot take one")
+	}
+
+	// The caller's client is left alone
+	base := &http.Client{}
+	if _, err := NewPayingClient(testKeyHex, WithHTTPClient(base), WithProxy("http://proxy.test:3128")); err != nil {
+		t.Fatal(err)
+	}
+	if base.Transport != nil {
+		t.Error("WithProxy modified the caller's client")
+	}
+}
+
+func TestWithTLSConfigTrustsPrivateCA(t *testing.T) {
+	server := httptest.NewTLSServer(&headerServer{})
+	defer server.Close()
+
+	if c, err := NewPayingClient(testKeyHex); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.Get(server.URL + "/resource"); err == nil {
+		t.Fatal("trusted a certificate from an unknown CA")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	c, err := NewPayingClient(testKeyHex, WithTLSConfig(&tls.Config{RootCAs: pool}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get(server.URL + "/resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status %d, want the payment accepted over TLS", resp.StatusCode)
+	}
+}
@@ -0,0 +1,230 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// OutstandingPolicy decides what a request does when the client already has
+// the maximum number of outstanding authorizations
+type OutstandingPolicy int
+
+const (
+	// OutstandingBlock waits until an authorization is released or expires,
+	// or the request's context ends (the default)
+	OutstandingBlock OutstandingPolicy = iota
+	// OutstandingFail fails the request with ErrTooManyOutstanding
+	OutstandingFail
+)
+
+// WithMaxOutstandingAuthorizations caps the authorizations the client has
+// signed that may still be settled. An authorization stops counting once a
+// 2xx response carries a successful settlement receipt for it, once it is
+// cancelled with CancelPayment, or once its validBefore passes; a payment the
+// server rejected keeps counting, as nothing stops the server settling it
+// later, whereas one that never reached the server stops counting at once.
+// n <= 0 disables tracking.
+func WithMaxOutstandingAuthorizations(n int) Option {
+	return func(c *PayingClient) {
+		if n <= 0 {
+			c.outstanding = nil
+			return
+		}
+		c.outstanding = &outstandingAuths{
+			max:     n,
+			entries: make(map[string]time.Time),
+			changed: make(chan struct{}),
+		}
+	}
+}
+
+// WithOutstandingPolicy sets what a request does at the
+// WithMaxOutstandingAuthorizations cap (default OutstandingBlock); it must
+// come after that option
+func WithOutstandingPolicy(policy OutstandingPolicy) Option {
+	return func(c *PayingClient) {
+		if c.outstanding != nil {
+			c.outstanding.policy = policy
+		}
+	}
+}
+
+// Outstanding returns how many authorizations the client has signed (or is
+// signing) that may still be settled; always 0 without
+// WithMaxOutstandingAuthorizations
+func (c *PayingClient) Outstanding() int {
+	return c.outstanding.count()
+}
+
+// outstandingAuths tracks signed authorizations that may still be settled.
+// Its methods are no-ops on a nil receiver, so callers need no checks.
+type outstandingAuths struct {
+	max    int
+	policy OutstandingPolicy
+
+	mu      sync.Mutex
+	entries map[string]time.Time // Canonical nonce -> validBefore
+	signing int                  // Slots reserved for authorizations being signed
+	changed chan struct{}        // Closed (and replaced) whenever a slot frees up
+}
+
+// acquire reserves a slot for an authorization about to be signed, waiting
+// for one or failing according to the policy; the slot is then bound to the
+// signed authorization or abandoned
+func (o *outstandingAuths) acquire(ctx context.Context) error {
+	if o == nil {
+		return nil
+	}
+	for {
+		o.mu.Lock()
+		next := o.prune(time.Now())
+		if o.signing+len(o.entries) < o.max {
+			o.signing++
+			o.mu.Unlock()
+			return nil
+		}
+		changed := o.changed
+		o.mu.Unlock()
+
+		if o.policy == OutstandingFail {
+			return fmt.Errorf("%w: %d authorizations may still be settled", ErrTooManyOutstanding, o.max)
+		}
+
+		// Wake on a release, the next expiry or the end of the request
+		var expired <-chan time.Time
+		var timer *time.Timer
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			expired = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-expired:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// bind turns a reserved slot into an entry for the signed payload
+func (o *outstandingAuths) bind(payload *types.PaymentPayload) {
+	if o == nil {
+		return
+	}
+	auth := payload.Payload.Authorization
+	validBefore, err := strconv.ParseInt(auth.ValidBefore, 10, 64)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.signing--
+	if err != nil {
+		// Unparseable expiry: the server cannot settle it either
+		o.signalLocked()
+		return
+	}
+	o.entries[outstandingKey(auth.Nonce.String())] = time.Unix(validBefore, 0)
+}
+
+// abandon frees a reserved slot whose authorization was never signed
+func (o *outstandingAuths) abandon() {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.signing--
+	o.signalLocked()
+}
+
+// release stops counting the authorization with nonce
+func (o *outstandingAuths) release(nonce string) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key := outstandingKey(nonce)
+	if _, ok := o.entries[key]; ok {
+		delete(o.entries, key)
+		o.signalLocked()
+	}
+}
+
+// releaseSettled releases the authorization of payload if resp is a 2xx
+// carrying a successful settlement receipt
+func (o *outstandingAuths) releaseSettled(resp *http.Response, payload *types.PaymentPayload) {
+	if o == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return
+	}
+	header := strings.TrimSpace(resp.Header.Get("X-Payment-Response"))
+	if header == "" {
+		return
+	}
+	if settlement, err := parseSettlementHeader(header); err == nil && settlement.Success {
+		o.release(payload.Payload.Authorization.Nonce.String())
+	}
+}
+
+// count returns the number of outstanding authorizations
+func (o *outstandingAuths) count() int {
+	if o == nil {
+		return 0
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.prune(time.Now())
+	return o.signing + len(o.entries)
+}
+
+// prune drops expired entries and returns the earliest remaining expiry
+// (zero if there is none); o.mu must be held
+func (o *outstandingAuths) prune(now time.Time) time.Time {
+	var next time.Time
+	for nonce, validBefore := range o.entries {
+		if !now.Before(validBefore) {
+			delete(o.entries, nonce)
+			continue
+		}
+		if next.IsZero() || validBefore.Before(next) {
+			next = validBefore
+		}
+	}
+	return next
+}
+
+// outstandingKey canonicalizes a nonce, so prefix or case variants of it
+// name the same authorization
+func outstandingKey(nonce string) string {
+	if canonical, err := types.NormalizeNonce(nonce); err == nil {
+		return canonical
+	}
+	return strings.ToLower(nonce)
+}
+
+// neverSent reports whether err, from sending a request, shows the request
+// never reached the server (its host did not resolve or the connection was
+// refused), so the server holds no authorization it carried
+func neverSent(err error) bool {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	return errors.As(err, &dnsErr) || errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// signalLocked wakes requests waiting for a slot; o.mu must be held
+func (o *outstandingAuths) signalLocked() {
+	close(o.changed)
+	o.changed = make(chan struct{})
+}
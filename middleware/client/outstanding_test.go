@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// settledResponse is a 200 carrying a successful settlement receipt
+func settledResponse() *http.Response {
+	resp := jsonResponse(http.StatusOK, map[string]string{"status": "ok"})
+	resp.Header.Set("X-Payment-Response", `{"success":true,"transaction":"0x01","network":"base-sepolia"}`)
+	return resp
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// parallelGets runs n GETs through c at once and returns their errors
+func parallelGets(c *PayingClient, n int) []error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := c.Get("http://paid.test/resource")
+			if err == nil {
+				resp.Body.Close()
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestOutstandingCapFailsBeyondLimit(t *testing.T) {
+	// Accepted without a receipt: every authorization stays outstanding
+	server := paidServer{
+		unpaid: offering(x402test.Requirements()),
+		paid:   func() *http.Response { return jsonResponse(http.StatusOK, nil) },
+	}
+	c, err := NewPayingClient(testKeyHex,
+		WithHTTPClient(&http.Client{Transport: server}),
+		WithMaxOutstandingAuthorizations(3),
+		WithOutstandingPolicy(OutstandingFail),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paid, refused int
+	for _, err := range parallelGets(c, 50) {
+		switch {
+		case err == nil:
+			paid++
+		case errors.Is(err, ErrTooManyOutstanding):
+			refused++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if paid != 3 || refused != 47 {
+		t.Errorf("%d paid and %d refused, want 3 and 47", paid, refused)
+	}
+	if n := c.Outstanding(); n != 3 {
+		t.Errorf("Outstanding() = %d, want 3", n)
+	}
+}
+
+func TestOutstandingCapBlocksUntilSettled(t *testing.T) {
+	const limit = 2
+	var inFlight, peak atomic.Int32
+	server := paidServer{
+		unpaid: offering(x402test.Requirements()),
+		paid: func() *http.Response {
+			n := inFlight.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(10 * time.Millisecond)
+			inFlight.Add(-1)
+			return settledResponse()
+		},
+	}
+	c, err := NewPayingClient(testKeyHex,
+		WithHTTPClient(&http.Client{Transport: server}),
+		WithMaxOutstandingAuthorizations(limit),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, err := range parallelGets(c, 30) {
+		if err != nil {
+			t.Errorf("blocked request failed: %v", err)
+		}
+	}
+	if p := peak.Load(); p > limit {
+		t.Errorf("%d payments in flight at once, want at most %d", p, limit)
+	}
+	if n := c.Outstanding(); n != 0 {
+		t.Errorf("Outstanding() = %d after every payment settled, want 0", n)
+	}
+}
+
+func TestOutstandingCapWaitHonoursContext(t *testing.T) {
+	server := paidServer{
+		unpaid: offering(x402test.Requirements()),
+		paid:   func() *http.Response { return jsonResponse(http.StatusOK, nil) },
+	}
+	c, err := NewPayingClient(testKeyHex,
+		WithHTTPClient(&http.Client{Transport: server}),
+		WithMaxOutstandingAuthorizations(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get("http://paid.test/resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://paid.test/resource", nil)
+	if _, err := c.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("request at the cap: %v, want the context's deadline", err)
+	}
+}
+
+func TestOutstandingReleasedWhenNeverSent(t *testing.T) {
+	refused := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get(types.HeaderXPayment) != "" {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		}
+		resp := offering(x402test.Requirements())()
+		resp.Request = r
+		return resp, nil
+	})
+	c, err := NewPayingClient(testKeyHex,
+		WithHTTPClient(&http.Client{Transport: refused}),
+		WithMaxOutstandingAuthorizations(1),
+		WithOutstandingPolicy(OutstandingFail),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get("http://paid.test/resource"); errors.Is(err, ErrTooManyOutstanding) {
+			t.Fatalf("attempt %d: a payment that never left the client still counts", i+1)
+		}
+	}
+	if n := c.Outstanding(); n != 0 {
+		t.Errorf("Outstanding() = %d, want 0", n)
+	}
+}
+
+func TestOutstandingExpiresAtValidBefore(t *testing.T) {
+	o := &outstandingAuths{max: 1, entries: make(map[string]time.Time), changed: make(chan struct{})}
+	if err := o.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var payload types.PaymentPayload
+	payload.Payload.Authorization.Nonce = make(types.HexBytes, 32)
+	payload.Payload.Authorization.ValidBefore = strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10)
+	o.bind(&payload)
+	if n := o.count(); n != 1 {
+		t.Fatalf("count %d, want 1", n)
+	}
+
+	// A blocked request wakes when the authorization expires
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := o.acquire(ctx); err != nil {
+		t.Fatalf("waiting for expiry: %v", err)
+	}
+	o.abandon()
+	if n := o.count(); n != 0 {
+		t.Errorf("count %d after validBefore, want 0", n)
+	}
+
+	var disabled *outstandingAuths
+	if err := disabled.acquire(context.Background()); err != nil || disabled.count() != 0 {
+		t.Errorf("nil tracker: %v, count %d", err, disabled.count())
+	}
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// BuildPaidURL signs a payment for requirements and returns rawURL with it
+// in the x402_payment query parameter, for servers accepting query payments
+// (see server.WithQueryPayment) and tools that cannot set headers. The
+// payment is bound to the URL's scheme, host and path unless requirements
+// name a resource or carry a challenge, so the URL only pays for that
+// resource. It counts towards WithMaxOutstandingAuthorizations like any
+// other signed payment.
+func (c *PayingClient) BuildPaidURL(rawURL string, requirements *types.PaymentRequirements) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("paid URL %q must be absolute", rawURL)
+	}
+	bound := *requirements
+	if bound.Resource == "" {
+		bound.Resource = u.Scheme + "://" + u.Host + u.EscapedPath()
+	}
+
+	if err := c.outstanding.acquire(context.Background()); err != nil {
+		return "", err
+	}
+	payload, err := c.generatePaymentPayload(&bound)
+	if err != nil {
+		c.outstanding.abandon()
+		return "", err
+	}
+	c.outstanding.bind(payload)
+	c.emit(PaymentEvent{
+		Type:         PaymentSigned,
+		URL:          rawURL,
+		Requirements: requirements,
+		Network:      payload.Network,
+		Amount:       payload.Payload.Authorization.Value,
+		Nonce:        payload.Payload.Authorization.Nonce.String(),
+	})
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to marshal payment: %w", ErrSigning, err)
+	}
+	return types.AppendQueryPayment(rawURL, payloadJSON)
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/x402-rs/x402-go/middleware/server"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+func TestBuildPaidURLBindsResource(t *testing.T) {
+	c, err := NewPayingClient(testKeyHex, WithMaxOutstandingAuthorizations(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirements := x402test.Requirements()
+	paid, err := c.BuildPaidURL("https://shop.test/files/report.pdf?version=2", &requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(paid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Query().Get("version") != "2" {
+		t.Errorf("paid URL %s lost its query", paid)
+	}
+	decoded, err := types.DecodeQueryPayment(u.Query().Get(types.QueryPaymentParam))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload types.PaymentPayload
+	if err := json.Unmarshal(decoded, &payload); err != nil {
+		t.Fatal(err)
+	}
+	bound := requirements
+	bound.Resource = "https://shop.test/files/report.pdf"
+	if err := types.CheckResourceBinding(&payload, &bound); err != nil {
+		t.Errorf("payment is not bound to the URL's resource: %v", err)
+	}
+	if requirements.Resource != "" {
+		t.Error("BuildPaidURL changed the caller's requirements")
+	}
+	if n := c.Outstanding(); n != 1 {
+		t.Errorf("Outstanding() = %d, want the signed URL counted", n)
+	}
+
+	if _, err := c.BuildPaidURL("/files/report.pdf", &requirements); err == nil {
+		t.Error("a relative URL was signed")
+	}
+}
+
+func TestBuildPaidURLPaysQueryServer(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	var seen string
+	shop := httptest.NewServer(server.NewX402Middleware(facilitator.URL, server.WithQueryPayment(0)).Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.RawQuery
+		w.Write([]byte("download"))
+	}), &server.PriceTag{Requirements: x402test.Requirements()}))
+	defer shop.Close()
+
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirements := x402test.Requirements()
+	paid, err := c.BuildPaidURL(shop.URL+"/files/report.pdf", &requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A plain GET, as a download tool would send
+	resp, err := http.Get(paid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "download" {
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+	if seen != "" {
+		t.Errorf("handler saw query %q, want the payment removed", seen)
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// quotedRequirements returns the x402test requirements quoted until expiresAt
+func quotedRequirements(t *testing.T, expiresAt time.Time) *types.PaymentRequirements {
+	t.Helper()
+	requirements := x402test.Requirements()
+	extra, err := types.SetExtraField(requirements.Extra, types.QuoteExtraKey, types.PaymentQuote{ID: "q1", ExpiresAt: expiresAt.UTC().Format(time.RFC3339)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirements.Extra = extra
+	return &requirements
+}
+
+func TestRequirementsCacheForgetsExpiredQuotes(t *testing.T) {
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := httptest.NewRequest(http.MethodPost, "http://shop.test/live", nil)
+	lapsed := httptest.NewRequest(http.MethodPost, "http://shop.test/lapsed", nil)
+	unquoted := httptest.NewRequest(http.MethodPost, "http://shop.test/unquoted", nil)
+	plain := x402test.Requirements()
+	c.cacheRequirements(live, quotedRequirements(t, time.Now().Add(time.Minute)))
+	c.cacheRequirements(lapsed, quotedRequirements(t, time.Now().Add(-time.Second)))
+	c.cacheRequirements(unquoted, &plain)
+
+	if c.cachedRequirements(live) == nil || c.cachedRequirements(unquoted) == nil {
+		t.Error("live requirements were forgotten")
+	}
+	if c.cachedRequirements(lapsed) != nil {
+		t.Error("requirements served from an expired quote")
+	}
+	c.requirementsMu.Lock()
+	defer c.requirementsMu.Unlock()
+	if _, ok := c.requirements["POST http://shop.test/lapsed"]; ok {
+		t.Error("the expired quote is still cached")
+	}
+}
+
+func TestPaymentEchoesQuote(t *testing.T) {
+	requirements := quotedRequirements(t, time.Now().Add(time.Minute))
+	var echoed *types.PaymentQuote
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header := r.Header.Get(types.HeaderXPayment); header != "" {
+			var payload types.PaymentPayload
+			if err := json.Unmarshal([]byte(header), &payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			echoed, _ = types.ParseQuote(payload.Extra)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(map[string]interface{}{"x402Version": 1, "payment_requirements": requirements})
+	}))
+	defer server.Close()
+
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get(server.URL + "/resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	want, _ := types.ParseQuote(requirements.Extra)
+	if resp.StatusCode != http.StatusOK || echoed == nil || *echoed != *want {
+		t.Errorf("status %d, payment echoed quote %+v; want %+v", resp.StatusCode, echoed, want)
+	}
+}
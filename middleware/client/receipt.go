@@ -0,0 +1,115 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// WithReceiptHandler checks the settlement receipt (X-Payment-Response) of
+// every paid response against the payment the client signed and reports the
+// outcome to fn. err is nil for a matching receipt, ErrReceiptMissing when the
+// server attached none, a *ReceiptError on a mismatch, or ErrUntrustedReceipt
+// when WithTrustedFacilitator is set and the signature does not check out.
+// These are warnings: the response is still returned to the caller intact.
+func WithReceiptHandler(fn func(resp *http.Response, settlement *types.SettleResponse, err error)) Option {
+	return func(c *PayingClient) {
+		c.receiptHandler = fn
+	}
+}
+
+// WithTrustedFacilitator requires receipts to be signed by the facilitator
+// address (see facilitator.LocalFacilitator.SetReceiptSigner)
+func WithTrustedFacilitator(addr common.Address) Option {
+	return func(c *PayingClient) {
+		c.trustedFacilitator = &addr
+	}
+}
+
+// ReceiptError reports a receipt field that differs from the signed payment
+type ReceiptError struct {
+	Field string
+	Want  string
+	Got   string
+}
+
+func (e *ReceiptError) Error() string {
+	return fmt.Sprintf("%v: %s is %q, signed %q", ErrReceiptMismatch, e.Field, e.Got, e.Want)
+}
+
+// Unwrap lets errors.Is match ErrReceiptMismatch
+func (e *ReceiptError) Unwrap() error {
+	return ErrReceiptMismatch
+}
+
+// checkReceipt parses the receipt on a paid response and compares it with payload
+func (c *PayingClient) checkReceipt(resp *http.Response, payload *types.PaymentPayload) (*types.SettleResponse, error) {
+	header := strings.TrimSpace(resp.Header.Get("X-Payment-Response"))
+	if header == "" {
+		return nil, ErrReceiptMissing
+	}
+	settlement, err := parseSettlementHeader(header)
+	if err != nil {
+		return nil, &ReceiptError{Field: "X-Payment-Response", Want: "settlement JSON", Got: err.Error()}
+	}
+	if !settlement.Success {
+		return settlement, &ReceiptError{Field: "success", Want: "true", Got: "false"}
+	}
+	receipt := settlement.Receipt
+	if receipt == nil {
+		return settlement, &ReceiptError{Field: "receipt", Want: "present", Got: "missing"}
+	}
+
+	auth := payload.Payload.Authorization
+	checks := []struct {
+		field     string
+		want, got string
+	}{
+		{"network", string(payload.Network), string(receipt.Network)},
+		{"payer", auth.From.Hex(), types.NormalizeEVMAddress(receipt.Payer)},
+		{"payTo", auth.To.Hex(), types.NormalizeEVMAddress(receipt.PayTo)},
+		{"amount", auth.Value, receipt.Amount},
+		{"nonce", auth.Nonce.String(), receipt.Nonce},
+	}
+	for _, check := range checks {
+		if !strings.EqualFold(check.want, check.got) {
+			return settlement, &ReceiptError{Field: check.field, Want: check.want, Got: check.got}
+		}
+	}
+
+	if c.trustedFacilitator != nil {
+		if settlement.ReceiptSignature == "" {
+			return settlement, fmt.Errorf("%w: receipt is not signed", ErrUntrustedReceipt)
+		}
+		signer, err := receipt.VerifySignature(settlement.ReceiptSignature)
+		if err != nil {
+			return settlement, fmt.Errorf("%w: %w", ErrUntrustedReceipt, err)
+		}
+		if signer != *c.trustedFacilitator {
+			return settlement, fmt.Errorf("%w: signed by %s", ErrUntrustedReceipt, signer.Hex())
+		}
+	}
+	return settlement, nil
+}
+
+// parseSettlementHeader decodes X-Payment-Response as JSON or base64-encoded JSON
+func parseSettlementHeader(header string) (*types.SettleResponse, error) {
+	raw := []byte(header)
+	if !strings.HasPrefix(header, "{") {
+		decoded, err := base64.StdEncoding.DecodeString(header)
+		if err != nil {
+			return nil, err
+		}
+		raw = decoded
+	}
+	var settlement types.SettleResponse
+	if err := json.Unmarshal(raw, &settlement); err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
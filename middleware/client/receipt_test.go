@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/middleware/server"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// receiptServer charges the x402test requirements and answers paid requests
+// with the receipt built by receipt (no X-Payment-Response if it returns "")
+func receiptServer(t *testing.T, receipt func(payload *types.PaymentPayload) string) *httptest.Server {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(types.HeaderXPayment)
+		if header == "" {
+			requirements, _ := json.Marshal(x402test.Requirements())
+			w.Header().Set(types.HeaderPaymentRequired, string(requirements))
+			w.WriteHeader(http.StatusPaymentRequired)
+			return
+		}
+		var payload types.PaymentPayload
+		if err := json.Unmarshal([]byte(header), &payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if value := receipt(&payload); value != "" {
+			w.Header().Set("X-Payment-Response", value)
+		}
+		w.Write([]byte("content"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// settlementHeader encodes a successful settlement carrying receipt, signed by
+// key unless it is nil
+func settlementHeader(t *testing.T, receipt *types.PaymentReceipt, signer types.HashSigner) string {
+	t.Helper()
+	settlement := types.SettleResponse{Success: true, Receipt: receipt}
+	if signer != nil {
+		signature, err := receipt.Sign(context.Background(), signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		settlement.ReceiptSignature = signature
+	}
+	raw, err := json.Marshal(settlement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(raw)
+}
+
+func TestReceiptHandler(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	stranger, _ := crypto.GenerateKey()
+	facilitator := types.KeySigner{Key: key}
+
+	for _, tc := range []struct {
+		name    string
+		receipt func(t *testing.T, payload *types.PaymentPayload) string
+		want    error
+		field   string // Mismatched field of a *ReceiptError
+	}{
+		{"valid", func(t *testing.T, p *types.PaymentPayload) string {
+			return settlementHeader(t, types.NewPaymentReceipt(p, "0x01"), facilitator)
+		}, nil, ""},
+		{"valid base64", func(t *testing.T, p *types.PaymentPayload) string {
+			return base64.StdEncoding.EncodeToString([]byte(settlementHeader(t, types.NewPaymentReceipt(p, "0x01"), facilitator)))
+		}, nil, ""},
+		{"valid with xdc-prefixed addresses", func(t *testing.T, p *types.PaymentPayload) string {
+			receipt := types.NewPaymentReceipt(p, "0x01")
+			receipt.Payer, receipt.PayTo = types.FormatXDCAddress(receipt.Payer), types.FormatXDCAddress(receipt.PayTo)
+			return settlementHeader(t, receipt, facilitator)
+		}, nil, ""},
+		{"missing", func(t *testing.T, p *types.PaymentPayload) string {
+			return ""
+		}, ErrReceiptMissing, ""},
+		{"forged payTo", func(t *testing.T, p *types.PaymentPayload) string {
+			receipt := types.NewPaymentReceipt(p, "0x01")
+			receipt.PayTo = "0x00000000000000000000000000000000000000e1"
+			return settlementHeader(t, receipt, facilitator)
+		}, ErrReceiptMismatch, "payTo"},
+		{"forged amount", func(t *testing.T, p *types.PaymentPayload) string {
+			receipt := types.NewPaymentReceipt(p, "0x01")
+			receipt.Amount = "1"
+			return settlementHeader(t, receipt, facilitator)
+		}, ErrReceiptMismatch, "amount"},
+		{"forged nonce", func(t *testing.T, p *types.PaymentPayload) string {
+			receipt := types.NewPaymentReceipt(p, "0x01")
+			receipt.Nonce = "0x" + "ab"
+			return settlementHeader(t, receipt, facilitator)
+		}, ErrReceiptMismatch, "nonce"},
+		{"signed by another key", func(t *testing.T, p *types.PaymentPayload) string {
+			return settlementHeader(t, types.NewPaymentReceipt(p, "0x01"), types.KeySigner{Key: stranger})
+		}, ErrUntrustedReceipt, ""},
+		{"unsigned", func(t *testing.T, p *types.PaymentPayload) string {
+			return settlementHeader(t, types.NewPaymentReceipt(p, "0x01"), nil)
+		}, ErrUntrustedReceipt, ""},
+		{"tampered after signing", func(t *testing.T, p *types.PaymentPayload) string {
+			receipt := types.NewPaymentReceipt(p, "0x01")
+			header := settlementHeader(t, receipt, facilitator)
+			var settlement types.SettleResponse
+			json.Unmarshal([]byte(header), &settlement)
+			settlement.Receipt.TransactionHash = "0x02"
+			raw, _ := json.Marshal(settlement)
+			return string(raw)
+		}, ErrUntrustedReceipt, ""},
+	} {
+		server := receiptServer(t, func(p *types.PaymentPayload) string { return tc.receipt(t, p) })
+		var calls int
+		var gotSettlement *types.SettleResponse
+		var gotErr error
+		c, err := NewPayingClient(testKeyHex,
+			WithTrustedFacilitator(facilitator.Address()),
+			WithReceiptHandler(func(resp *http.Response, settlement *types.SettleResponse, err error) {
+				calls++
+				gotSettlement, gotErr = settlement, err
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Get(server.URL + "/resource")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if string(body) != "content" {
+			t.Errorf("%s: body %q, want the response kept intact", tc.name, body)
+		}
+		if calls != 1 {
+			t.Fatalf("%s: receipt handler called %d times", tc.name, calls)
+		}
+		if !errors.Is(gotErr, tc.want) || (tc.want == nil) != (gotErr == nil) {
+			t.Errorf("%s: receipt error %v, want %v", tc.name, gotErr, tc.want)
+		}
+		if tc.field != "" {
+			var mismatch *ReceiptError
+			if !errors.As(gotErr, &mismatch) || mismatch.Field != tc.field {
+				t.Errorf("%s: error %v, want a mismatch on %s", tc.name, gotErr, tc.field)
+			}
+		}
+		if tc.want == nil && (gotSettlement == nil || gotSettlement.Receipt == nil || gotSettlement.Receipt.TransactionHash != "0x01") {
+			t.Errorf("%s: settlement %+v, want the parsed receipt", tc.name, gotSettlement)
+		}
+	}
+}
+
+func TestReceiptIsOptionalWithoutTrustedFacilitator(t *testing.T) {
+	server := receiptServer(t, func(p *types.PaymentPayload) string {
+		return settlementHeader(t, types.NewPaymentReceipt(p, "0x01"), nil)
+	})
+	var gotErr error = errors.New("not called")
+	c, err := NewPayingClient(testKeyHex, WithReceiptHandler(func(resp *http.Response, settlement *types.SettleResponse, err error) {
+		gotErr = err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get(server.URL + "/resource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if gotErr != nil {
+		t.Errorf("unsigned receipt without a trusted facilitator: %v, want it accepted", gotErr)
+	}
+}
+
+func TestReceiptHandlerSurfacesSettlementID(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	origin := signedOrigin(t, facilitator, server.WithSettleAfterSuccess())
+	var settled *types.SettleResponse
+	c, err := NewPayingClient(testKeyHex, WithReceiptHandler(func(resp *http.Response, settlement *types.SettleResponse, err error) {
+		if err != nil {
+			t.Errorf("receipt: %v", err)
+		}
+		settled = settlement
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", "order-2026-10-14_0001"} {
+		settled = nil
+		req, err := http.NewRequest(http.MethodGet, origin.URL+"/resource", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "" {
+			req.Header.Set(types.HeaderIdempotencyKey, key)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if settled == nil || settled.Receipt == nil {
+			t.Fatalf("key %q: no settlement reached the receipt handler", key)
+		}
+		if settled.SettlementID == "" || settled.Receipt.SettlementID != settled.SettlementID {
+			t.Errorf("key %q: settlement ID %q, receipt's %q; want one ID in both", key, settled.SettlementID, settled.Receipt.SettlementID)
+		}
+		if key != "" && settled.SettlementID != key {
+			t.Errorf("settled under %q, want the client's key %q", settled.SettlementID, key)
+		}
+	}
+}
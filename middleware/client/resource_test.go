@@ -0,0 +1,69 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+func TestPayingClientBindsPaymentsToResource(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		offered  []types.PaymentRequirements
+		payloads []types.PaymentPayload
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if header := r.Header.Get(types.HeaderXPayment); header != "" {
+			var payload types.PaymentPayload
+			if err := json.Unmarshal([]byte(header), &payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			payloads = append(payloads, payload)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		requirements := x402test.Requirements()
+		requirements.Resource = "http://" + r.Host + r.URL.Path
+		offered = append(offered, requirements)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(map[string]interface{}{"x402Version": 1, "payment_requirements": requirements})
+	}))
+	defer server.Close()
+
+	c, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/cheap-endpoint", "/expensive-endpoint"} {
+		resp, err := c.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: status %d", path, resp.StatusCode)
+		}
+	}
+
+	if len(payloads) != 2 {
+		t.Fatalf("%d payments, want 2", len(payloads))
+	}
+	for i, payload := range payloads {
+		if err := types.CheckResourceBinding(&payload, &offered[i]); err != nil {
+			t.Errorf("payment %d: %v", i, err)
+		}
+		// Presented to the other route, the binding gives it away
+		if err := types.CheckResourceBinding(&payload, &offered[1-i]); err == nil {
+			t.Errorf("payment %d for %s also binds to %s", i, offered[i].Resource, offered[1-i].Resource)
+		}
+	}
+}
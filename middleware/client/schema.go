@@ -0,0 +1,28 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// OutputSchema returns the JSON Schema of the response body advertised by
+// the last 402 the client paid for method and url, or nil if it carried none
+// (or no payment was made there). An error means the server advertised a
+// schema that is not valid.
+func (c *PayingClient) OutputSchema(method, url string) (*types.JSONSchema, error) {
+	c.requirementsMu.Lock()
+	requirements := c.paidRequirements[method+" "+url]
+	c.requirementsMu.Unlock()
+	if requirements == nil {
+		return nil, nil
+	}
+	return requirements.ParseOutputSchema()
+}
+
+// recordPaidRequirements remembers the requirements a request was paid under
+func (c *PayingClient) recordPaidRequirements(req *http.Request, requirements *types.PaymentRequirements) {
+	c.requirementsMu.Lock()
+	defer c.requirementsMu.Unlock()
+	c.paidRequirements[req.Method+" "+req.URL.String()] = requirements
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+func TestOutputSchemaOfPaidRequest(t *testing.T) {
+	requirements := x402test.Requirements()
+	requirements.OutputSchema = json.RawMessage(`{"type": "object", "required": ["city"]}`)
+	server := paidServer{
+		unpaid: offering(requirements),
+		paid:   func() *http.Response { return jsonResponse(http.StatusOK, map[string]string{"city": "Oslo"}) },
+	}
+	c, err := NewPayingClient(testKeyHex, WithHTTPClient(&http.Client{Transport: server}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema, err := c.OutputSchema(http.MethodGet, "http://paid.test/forecast"); schema != nil || err != nil {
+		t.Errorf("schema before paying: %v, %v; want none", schema, err)
+	}
+
+	resp, err := c.Get("http://paid.test/forecast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	schema, err := c.OutputSchema(http.MethodGet, "http://paid.test/forecast")
+	if err != nil || schema == nil {
+		t.Fatalf("schema after paying: %v, %v", schema, err)
+	}
+	if schema.Validate([]byte(`{"city": "Oslo"}`)) != nil || schema.Validate([]byte(`{}`)) == nil {
+		t.Errorf("schema %s is not the one the 402 advertised", schema.Raw())
+	}
+	if schema, _ := c.OutputSchema(http.MethodPost, "http://paid.test/forecast"); schema != nil {
+		t.Error("the schema leaked to another method")
+	}
+
+	// A malformed advertised schema is reported, not hidden
+	requirements.OutputSchema = json.RawMessage(`{"type": "text"}`)
+	server.unpaid = offering(requirements)
+	c, err = NewPayingClient(testKeyHex, WithHTTPClient(&http.Client{Transport: server}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = c.Get("http://paid.test/forecast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if _, err := c.OutputSchema(http.MethodGet, "http://paid.test/forecast"); err == nil {
+		t.Error("an invalid advertised schema parsed")
+	}
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/transport"
+)
+
+func TestPaidRequestsReuseServerConnection(t *testing.T) {
+	server := newUploadServer(t)
+	var stats transport.ConnStats
+	c, err := NewPayingClient(testKeyHex, WithTransport(transport.Config{OnConn: stats.Observe}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const requests = 50
+	for i := 0; i < requests; i++ {
+		resp, err := c.Get(server.URL + "/upload")
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("request %d: status %d", i, resp.StatusCode)
+		}
+	}
+	if stats.Opened() != 1 {
+		t.Errorf("%d connections opened (%d reused), want all requests on one", stats.Opened(), stats.Reused())
+	}
+}
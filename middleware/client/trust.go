@@ -0,0 +1,44 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// WithTrustedRequirementSigners only pays requirements signed by one of
+// signers (see server.WithRequirementsSigner); anything else, including
+// unsigned alternatives in accepts, fails with ErrUntrustedRequirements
+// before a payment is signed
+func WithTrustedRequirementSigners(signers []common.Address) Option {
+	return func(c *PayingClient) {
+		c.trustedSigners = make(map[common.Address]bool, len(signers))
+		for _, signer := range signers {
+			c.trustedSigners[signer] = true
+		}
+	}
+}
+
+// checkRequirementsSignature verifies the 402's primary requirements against
+// the trusted signers and returns the options that may be paid
+// Without a trust list every option is returned unchecked
+func (c *PayingClient) checkRequirementsSignature(resp *http.Response, options []*types.PaymentRequirements) ([]*types.PaymentRequirements, error) {
+	if c.trustedSigners == nil {
+		return options, nil
+	}
+	signature := resp.Header.Get("X-Payment-Required-Signature")
+	if signature == "" {
+		return nil, fmt.Errorf("%w: 402 response is not signed", ErrUntrustedRequirements)
+	}
+	signer, err := types.VerifyRequirementsSignature(options[0], signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUntrustedRequirements, err)
+	}
+	if !c.trustedSigners[signer] {
+		return nil, fmt.Errorf("%w: signer %s is not trusted", ErrUntrustedRequirements, signer.Hex())
+	}
+	// The signature covers only the primary requirements
+	return options[:1], nil
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/middleware/server"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// attackerPayTo is where a man in the middle redirects payments
+const attackerPayTo = "0x00000000000000000000000000000000000000e1"
+
+// signedOrigin serves a resource whose 402s are signed by key (unsigned if nil)
+func signedOrigin(t *testing.T, facilitator *x402test.FakeFacilitator, opts ...server.Option) *httptest.Server {
+	t.Helper()
+	m := server.NewX402Middleware(facilitator.URL, opts...)
+	origin := httptest.NewServer(m.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("content"))
+	}), &server.PriceTag{Requirements: x402test.Requirements()}))
+	t.Cleanup(origin.Close)
+	return origin
+}
+
+// payToRewriter forwards to target, rewriting PayTo in 402 headers and bodies
+func payToRewriter(t *testing.T, target string) *httptest.Server {
+	t.Helper()
+	u, err := url.Parse(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payTo := []byte(x402test.PayTo.Hex())
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusPaymentRequired {
+			return nil
+		}
+		for name, values := range resp.Header {
+			for i, value := range values {
+				values[i] = string(bytes.ReplaceAll([]byte(value), payTo, []byte(attackerPayTo)))
+			}
+			resp.Header[name] = values
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		body = bytes.ReplaceAll(body, payTo, []byte(attackerPayTo))
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		return nil
+	}
+	s := httptest.NewServer(proxy)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestTrustedRequirementSigners(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	stranger, _ := crypto.GenerateKey()
+	trusted := []common.Address{crypto.PubkeyToAddress(key.PublicKey)}
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	signed := signedOrigin(t, facilitator, server.WithRequirementsSigner(key))
+
+	for _, tc := range []struct {
+		name    string
+		url     string
+		trusted []common.Address // nil configures no trust list
+		paid    bool
+	}{
+		{"signed by a trusted signer", signed.URL, trusted, true},
+		{"tampered payTo", payToRewriter(t, signed.URL).URL, trusted, false},
+		{"signed by an untrusted signer", signedOrigin(t, facilitator, server.WithRequirementsSigner(stranger)).URL, trusted, false},
+		{"unsigned with a trust list", signedOrigin(t, facilitator).URL, trusted, false},
+		{"unsigned without a trust list", signedOrigin(t, facilitator).URL, nil, true},
+	} {
+		var opts []Option
+		if tc.trusted != nil {
+			opts = append(opts, WithTrustedRequirementSigners(tc.trusted))
+		}
+		c, err := NewPayingClient(testKeyHex, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verified := facilitator.VerifyCount()
+		resp, err := c.Get(tc.url + "/resource")
+		if !tc.paid {
+			if !errors.Is(err, ErrUntrustedRequirements) {
+				t.Errorf("%s: error %v, want ErrUntrustedRequirements", tc.name, err)
+			}
+			if facilitator.VerifyCount() != verified {
+				t.Errorf("%s: a payment was sent", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: status %d, want the paid resource", tc.name, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package client
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"golang.org/x/crypto/scrypt"
+)
+
+// voucherExpiryMargin keeps vouchers about to expire from being sent, so
+// they do not lapse between the request and settlement
+const voucherExpiryMargin = 10 * time.Second
+
+// Voucher key derivation parameters (scrypt)
+const (
+	voucherScryptN = 1 << 15
+	voucherScryptR = 8
+	voucherScryptP = 1
+)
+
+// Voucher is a pre-signed payment for one set of requirements, usable from
+// ValidAfter until ValidBefore (unix seconds)
+type Voucher struct {
+	Network     types.Network        `json:"network"`
+	PayTo       string               `json:"payTo"`
+	Asset       common.Address       `json:"asset"`
+	Amount      string               `json:"amount"`
+	Resource    string               `json:"resource,omitempty"`
+	ValidAfter  int64                `json:"validAfter"`
+	ValidBefore int64                `json:"validBefore"`
+	Payload     types.PaymentPayload `json:"payload"`
+}
+
+// matches reports whether the voucher pays exactly these requirements,
+// within their minimum and maximum validity window
+func (v *Voucher) matches(requirements *types.PaymentRequirements) bool {
+	window := v.ValidBefore - v.ValidAfter
+	if requirements.MaxTimeoutSeconds > 0 && window > int64(requirements.MaxTimeoutSeconds) {
+		return false
+	}
+	if window < int64(requirements.MinTimeoutSeconds) {
+		return false
+	}
+	return (requirements.Scheme == "" || requirements.Scheme == types.SchemeExact) &&
+		v.Network == requirements.Network &&
+		strings.EqualFold(v.PayTo, requirements.PayTo) &&
+		v.Asset == requirements.Asset &&
+		v.Amount == requirements.MaxAmountRequired &&
+		v.Resource == requirements.Resource
+}
+
+// usable reports whether the voucher's validity window covers now
+func (v *Voucher) usable(now time.Time) bool {
+	return now.Unix() >= v.ValidAfter && now.Add(voucherExpiryMargin).Unix() < v.ValidBefore
+}
+
+// voucherFile is the on-disk form of a voucher store: the vouchers as JSON,
+// sealed with AES-GCM under a key derived from the passphrase
+type voucherFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// VoucherStore holds pre-signed payments so a PayingClient can pay without
+// signing in the hot path (see WithVoucherStore). Vouchers are encrypted at
+// rest; consumed vouchers are recorded by nonce in an append-only
+// path.consumed file so they are never sent twice, even across restarts.
+// A store file must not be shared by concurrent processes.
+type VoucherStore struct {
+	path string
+	salt []byte
+	aead cipher.AEAD
+
+	mu       sync.Mutex
+	vouchers []*Voucher
+	consumed map[string]bool // Authorization nonces already handed out
+	log      *os.File
+}
+
+// OpenVoucherStore opens (or creates) the voucher store at path, decrypting
+// it with passphrase
+func OpenVoucherStore(path, passphrase string) (*VoucherStore, error) {
+	if passphrase == "" {
+		return nil, errors.New("voucher store passphrase is required")
+	}
+	s := &VoucherStore{path: path, consumed: make(map[string]bool)}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		s.salt = make([]byte, 32)
+		if _, err := rand.Read(s.salt); err != nil {
+			return nil, err
+		}
+		if s.aead, err = voucherCipher(passphrase, s.salt); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to read voucher store: %w", err)
+	default:
+		var file voucherFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("invalid voucher store %s: %w", path, err)
+		}
+		s.salt = file.Salt
+		if s.aead, err = voucherCipher(passphrase, s.salt); err != nil {
+			return nil, err
+		}
+		plaintext, err := s.aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+		if err != nil {
+			return nil, errors.New("failed to decrypt voucher store (wrong passphrase?)")
+		}
+		if err := json.Unmarshal(plaintext, &s.vouchers); err != nil {
+			return nil, fmt.Errorf("invalid voucher store %s: %w", path, err)
+		}
+	}
+
+	if err := s.loadConsumed(); err != nil {
+		return nil, err
+	}
+	if s.log, err = os.OpenFile(path+".consumed", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to open voucher consumption log: %w", err)
+	}
+	return s, nil
+}
+
+// voucherCipher derives the store key from the passphrase
+func voucherCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, voucherScryptN, voucherScryptR, voucherScryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// loadConsumed reads the nonces recorded as consumed
+func (s *VoucherStore) loadConsumed() error {
+	f, err := os.Open(s.path + ".consumed")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read voucher consumption log: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if nonce := strings.TrimSpace(scanner.Text()); nonce != "" {
+			s.consumed[nonce] = true
+		}
+	}
+	return scanner.Err()
+}
+
+// GenerateVouchers pre-signs count payments for requirements with signer,
+// staggered so that together they cover window from now: voucher i becomes
+// valid at now + i·window/count and stays valid for the requirements'
+// MaxTimeoutSeconds (default: 1 hour, at least MinTimeoutSeconds). The store is saved before returning.
+func (s *VoucherStore) GenerateVouchers(signer *PayingClient, requirements *types.PaymentRequirements, count int, window time.Duration) error {
+	if count <= 0 {
+		return fmt.Errorf("voucher count must be positive")
+	}
+	challenge, err := types.ParseChallenge(requirements.Extra)
+	if err != nil {
+		return fmt.Errorf("%w: invalid challenge: %w", ErrRequirementsParse, err)
+	}
+	if challenge != nil {
+		return errors.New("cannot pre-sign vouchers for challenge requirements (the server dictates each nonce)")
+	}
+
+	now := time.Now().Unix()
+	timeout := paymentTimeout(requirements)
+	stride := int64(window/time.Second) / int64(count)
+	vouchers := make([]*Voucher, 0, count)
+	for i := 0; i < count; i++ {
+		validAfter := now + int64(i)*stride
+		payload, err := signer.signPayment(requirements, validAfter, validAfter+timeout)
+		if err != nil {
+			return err
+		}
+		vouchers = append(vouchers, &Voucher{
+			Network:     requirements.Network,
+			PayTo:       requirements.PayTo,
+			Asset:       requirements.Asset,
+			Amount:      requirements.MaxAmountRequired,
+			Resource:    requirements.Resource,
+			ValidAfter:  validAfter,
+			ValidBefore: validAfter + timeout,
+			Payload:     *payload,
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vouchers = append(s.vouchers, vouchers...)
+	return s.save()
+}
+
+// save writes the vouchers, dropping expired and consumed ones (caller holds s.mu)
+func (s *VoucherStore) save() error {
+	now := time.Now()
+	kept := s.vouchers[:0]
+	for _, voucher := range s.vouchers {
+		if !s.consumed[voucher.Payload.Payload.Authorization.Nonce.String()] && now.Unix() < voucher.ValidBefore {
+			kept = append(kept, voucher)
+		}
+	}
+	s.vouchers = kept
+
+	plaintext, err := json.Marshal(s.vouchers)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data, err := json.Marshal(voucherFile{
+		Version:    1,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: s.aead.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write voucher store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write voucher store: %w", err)
+	}
+	return nil
+}
+
+// Take hands out the unused voucher matching requirements that expires
+// first, marking it consumed; ok is false if none is usable right now.
+// Challenge requirements never match: the server dictates their nonce.
+func (s *VoucherStore) Take(requirements *types.PaymentRequirements) (payload *types.PaymentPayload, ok bool) {
+	if challenge, err := types.ParseChallenge(requirements.Extra); err != nil || challenge != nil {
+		return nil, false
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var best *Voucher
+	for _, voucher := range s.vouchers {
+		if s.consumed[voucher.Payload.Payload.Authorization.Nonce.String()] || !voucher.matches(requirements) || !voucher.usable(now) {
+			continue
+		}
+		if best == nil || voucher.ValidBefore < best.ValidBefore {
+			best = voucher
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+
+	nonce := best.Payload.Payload.Authorization.Nonce.String()
+	s.consumed[nonce] = true
+	if _, err := s.log.WriteString(nonce + "\n"); err != nil {
+		// Still handed out: reuse after a restart is rejected as a replay
+		log.Printf("x402 client: failed to record voucher consumption: %v", err)
+	}
+	voucherPayload := best.Payload
+	return &voucherPayload, true
+}
+
+// Remaining returns the unused vouchers matching requirements that are valid
+// now or become valid later
+func (s *VoucherStore) Remaining(requirements *types.PaymentRequirements) int {
+	now := time.Now().Add(voucherExpiryMargin).Unix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remaining := 0
+	for _, voucher := range s.vouchers {
+		if !s.consumed[voucher.Payload.Payload.Authorization.Nonce.String()] && voucher.matches(requirements) && now < voucher.ValidBefore {
+			remaining++
+		}
+	}
+	return remaining
+}
+
+// Close saves the store, compacting away consumed vouchers (and with them
+// the consumption log), and closes it
+func (s *VoucherStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.save()
+	if err == nil {
+		err = s.log.Truncate(0)
+	}
+	if closeErr := s.log.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// WithVoucherStore pays from pre-signed vouchers matching the requirements,
+// signing live only when none is usable
+func WithVoucherStore(store *VoucherStore) Option {
+	return func(c *PayingClient) {
+		c.vouchers = store
+	}
+}
+
+// takeVoucher returns a pre-signed payment for requirements, if the client
+// has a voucher store with a usable one
+func (c *PayingClient) takeVoucher(requirements *types.PaymentRequirements) (*types.PaymentPayload, bool) {
+	if c.vouchers == nil {
+		return nil, false
+	}
+	return c.vouchers.Take(requirements)
+}
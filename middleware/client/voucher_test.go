@@ -0,0 +1,249 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+const voucherPassphrase = "correct horse battery staple"
+
+// voucherStore opens a fresh store holding count vouchers for requirements,
+// staggered over window
+func voucherStore(t *testing.T, requirements types.PaymentRequirements, count int, window time.Duration) (*VoucherStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vouchers.json")
+	store, err := OpenVoucherStore(path, voucherPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	signer, err := NewPayingClient(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.GenerateVouchers(signer, &requirements, count, window); err != nil {
+		t.Fatal(err)
+	}
+	return store, path
+}
+
+func TestVoucherStoreEncryptsAndReopens(t *testing.T) {
+	requirements := x402test.Requirements()
+	store, path := voucherStore(t, requirements, 3, 0)
+	taken, ok := store.Take(&requirements)
+	if !ok {
+		t.Fatal("no voucher to take")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{taken.Payload.Signature.String(), strings.TrimPrefix(requirements.PayTo, "0x"), requirements.MaxAmountRequired} {
+		if bytes.Contains(bytes.ToLower(data), []byte(strings.ToLower(secret))) {
+			t.Errorf("the store file holds %q in the clear", secret)
+		}
+	}
+
+	if _, err := OpenVoucherStore(path, "wrong passphrase"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("wrong passphrase: error %v", err)
+	}
+	if _, err := OpenVoucherStore(path, ""); err == nil {
+		t.Error("opened a store without a passphrase")
+	}
+
+	// The consumption survives a reopen, closed or not
+	reopened, err := OpenVoucherStore(path, voucherPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := reopened.Remaining(&requirements); n != 2 {
+		t.Errorf("%d vouchers left after reopening, want 2", n)
+	}
+	if err := reopened.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if compacted, err := OpenVoucherStore(path, voucherPassphrase); err != nil || compacted.Remaining(&requirements) != 2 {
+		t.Errorf("after a close: %v vouchers, %v; want 2", compacted.Remaining(&requirements), err)
+	}
+}
+
+func TestVoucherStoreExhaustion(t *testing.T) {
+	requirements := x402test.Requirements()
+	store, _ := voucherStore(t, requirements, 3, 0)
+	nonces := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		payload, ok := store.Take(&requirements)
+		if !ok {
+			t.Fatalf("voucher %d missing", i+1)
+		}
+		nonces[payload.Payload.Authorization.Nonce.String()] = true
+	}
+	if len(nonces) != 3 {
+		t.Errorf("%d distinct nonces across 3 vouchers", len(nonces))
+	}
+	if _, ok := store.Take(&requirements); ok {
+		t.Error("took a voucher from an exhausted store")
+	}
+	if n := store.Remaining(&requirements); n != 0 {
+		t.Errorf("%d vouchers remaining, want none", n)
+	}
+}
+
+func TestVoucherStoreStaggersValidity(t *testing.T) {
+	requirements := x402test.Requirements()
+	requirements.MaxTimeoutSeconds = 600
+	store, _ := voucherStore(t, requirements, 3, 3*time.Hour)
+	if n := store.Remaining(&requirements); n != 3 {
+		t.Fatalf("%d vouchers remaining, want 3", n)
+	}
+
+	payload, ok := store.Take(&requirements)
+	if !ok {
+		t.Fatal("the first voucher is not usable now")
+	}
+	auth := payload.Payload.Authorization
+	if validAfter, validBefore := mustUnix(t, auth.ValidAfter), mustUnix(t, auth.ValidBefore); validBefore-validAfter != 600 || validAfter > time.Now().Unix() {
+		t.Errorf("first voucher valid %d..%d, want a 600s window starting now", validAfter, validBefore)
+	}
+	if _, ok := store.Take(&requirements); ok {
+		t.Error("took a voucher that only becomes valid in an hour")
+	}
+	if n := store.Remaining(&requirements); n != 2 {
+		t.Errorf("%d vouchers remaining, want the 2 valid later", n)
+	}
+}
+
+// mustUnix parses an authorization's validAfter or validBefore
+func mustUnix(t *testing.T, field string) int64 {
+	t.Helper()
+	sec, err := strconv.ParseInt(field, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sec
+}
+
+func TestVoucherStoreRequirementsMismatch(t *testing.T) {
+	requirements := x402test.Requirements()
+	store, _ := voucherStore(t, requirements, 1, 0)
+	for name, mutate := range map[string]func(*types.PaymentRequirements){
+		"amount":   func(r *types.PaymentRequirements) { r.MaxAmountRequired = "1" },
+		"payTo":    func(r *types.PaymentRequirements) { r.PayTo = "0x00000000000000000000000000000000000000c0" },
+		"network":  func(r *types.PaymentRequirements) { r.Network = types.NetworkBase },
+		"resource": func(r *types.PaymentRequirements) { r.Resource = "https://example.com/other" },
+		"scheme":   func(r *types.PaymentRequirements) { r.Scheme = "upto" },
+		"shorter maximum window": func(r *types.PaymentRequirements) {
+			r.MaxTimeoutSeconds = 10
+		},
+		"longer minimum window": func(r *types.PaymentRequirements) {
+			r.MinTimeoutSeconds = 7200
+		},
+		"challenge": func(r *types.PaymentRequirements) {
+			r.Extra = json.RawMessage(`{"challenge":{"nonce":"c0ffee","resource":"https://example.com/resource"}}`)
+		},
+	} {
+		other := requirements
+		mutate(&other)
+		if _, ok := store.Take(&other); ok {
+			t.Errorf("%s: took a voucher for other requirements", name)
+		}
+	}
+	if _, ok := store.Take(&requirements); !ok {
+		t.Error("the voucher was lost to the mismatched requests")
+	}
+}
+
+func TestVoucherStoreConcurrentTake(t *testing.T) {
+	requirements := x402test.Requirements()
+	const vouchers, takers = 40, 100
+	store, _ := voucherStore(t, requirements, vouchers, 0)
+
+	var mu sync.Mutex
+	nonces := make(map[string]int)
+	var wg sync.WaitGroup
+	for i := 0; i < takers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if payload, ok := store.Take(&requirements); ok {
+				mu.Lock()
+				nonces[payload.Payload.Authorization.Nonce.String()]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(nonces) != vouchers {
+		t.Errorf("%d vouchers handed out, want all %d", len(nonces), vouchers)
+	}
+	for nonce, n := range nonces {
+		if n != 1 {
+			t.Errorf("voucher %s handed out %d times", nonce, n)
+		}
+	}
+}
+
+func TestPayingClientPaysFromVouchersThenSignsLive(t *testing.T) {
+	requirements := x402test.Requirements()
+	store, _ := voucherStore(t, requirements, 2, 0)
+	vouchered := make(map[string]bool)
+	for _, voucher := range store.vouchers {
+		vouchered[voucher.Payload.Payload.Authorization.Nonce.String()] = true
+	}
+
+	var mu sync.Mutex
+	var paid []types.PaymentPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header := r.Header.Get(types.HeaderXPayment); header != "" {
+			var payload types.PaymentPayload
+			if err := json.Unmarshal([]byte(header), &payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			paid = append(paid, payload)
+			mu.Unlock()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(map[string]interface{}{"x402Version": 1, "payment_requirements": requirements})
+	}))
+	defer server.Close()
+
+	c, err := NewPayingClient(testKeyHex, WithVoucherStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get(server.URL + "/resource")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: status %d", i+1, resp.StatusCode)
+		}
+	}
+	if len(paid) != 3 {
+		t.Fatalf("%d payments, want 3", len(paid))
+	}
+	for i, payload := range paid {
+		if fromVoucher := vouchered[payload.Payload.Authorization.Nonce.String()]; fromVoucher != (i < 2) {
+			t.Errorf("payment %d from a voucher: %v; want vouchers first, then live signing", i+1, fromVoucher)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// paidWith returns a GET of target paying the x402test requirements under header
+func paidWith(t *testing.T, header, target string) *http.Request {
+	t.Helper()
+	req := paidRequest(t, http.MethodGet, target)
+	value := req.Header.Get(types.HeaderXPayment)
+	req.Header.Del(types.HeaderXPayment)
+	req.Header.Set(header, value)
+	return req
+}
+
+func TestProtectAcceptsEveryPaymentHeaderAlias(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorVerify)
+	var hit bool
+	handler := NewX402Middleware(facilitator.URL).Protect(served(&hit), fixturePriceTag())
+
+	for _, header := range append(append([]string(nil), types.PaymentHeaderAliases...), "x-payment", "payment") {
+		hit = false
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, paidWith(t, header, "/resource"))
+		if rec.Code != http.StatusNoContent || !hit {
+			t.Errorf("payment in %s: status %d, served %v: %s", header, rec.Code, hit, rec.Body.String())
+		}
+	}
+}
+
+func TestWithPaymentHeaderRestrictsInboundNames(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorVerify)
+	m := NewX402Middleware(facilitator.URL, WithPaymentHeader(types.HeaderPaymentPayload, "X-Shop-Payment"))
+	if got := m.PaymentHeaders(); len(got) != 2 || got[0] != types.HeaderPaymentPayload || got[1] != "X-Shop-Payment" {
+		t.Errorf("PaymentHeaders() = %q", got)
+	}
+	var hit bool
+	handler := m.Protect(served(&hit), fixturePriceTag())
+
+	for header, accepted := range map[string]bool{
+		types.HeaderPaymentPayload: true,
+		"X-Shop-Payment":           true,
+		types.HeaderXPayment:       false,
+		types.HeaderPayment:        false,
+	} {
+		hit = false
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, paidWith(t, header, "/resource"))
+		if hit != accepted {
+			t.Errorf("payment in %s: status %d, served %v; want served %v", header, rec.Code, hit, accepted)
+		}
+		if !accepted && rec.Code != http.StatusPaymentRequired {
+			t.Errorf("payment in %s: status %d, want %d", header, rec.Code, http.StatusPaymentRequired)
+		}
+	}
+
+	// The 402 varies on the accepted names only
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resource", nil))
+	if vary := strings.Join(rec.Header().Values("Vary"), ", "); !strings.Contains(vary, "X-Shop-Payment") || strings.Contains(vary, types.HeaderXPayment) {
+		t.Errorf("402 Vary %q, want the configured payment headers", vary)
+	}
+}
+
+func TestWithRequirementsHeader(t *testing.T) {
+	m := NewX402Middleware("http://facilitator.test")
+	if m.RequirementsHeader() != types.HeaderPaymentRequired {
+		t.Errorf("default requirements header %q", m.RequirementsHeader())
+	}
+
+	m = NewX402Middleware("http://facilitator.test", WithRequirementsHeader("Payment-Required"))
+	rec := httptest.NewRecorder()
+	m.Protect(http.NotFoundHandler(), fixturePriceTag()).ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/resource", nil))
+	if rec.Code != http.StatusPaymentRequired || rec.Header().Get("Payment-Required") == "" {
+		t.Errorf("402 status %d, headers %v; want requirements in Payment-Required", rec.Code, rec.Header())
+	}
+	if rec.Header().Get(types.HeaderPaymentRequired) != "" {
+		t.Errorf("402 still carries %s", types.HeaderPaymentRequired)
+	}
+	if m.RequirementsHeader() != "Payment-Required" {
+		t.Errorf("RequirementsHeader() = %q", m.RequirementsHeader())
+	}
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Verify retry defaults: verification is idempotent, so transient facilitator
+// failures are retried this many times with jittered exponential backoff
+const (
+	DefaultVerifyRetries    = 2
+	DefaultVerifyRetryDelay = 100 * time.Millisecond
+)
+
+// HeaderPaymentWarning is set on responses served without verification while
+// the facilitator is unavailable in fail-open mode
+const HeaderPaymentWarning = "X-Payment-Warning"
+
+// errFacilitatorUnavailable is returned for verifications refused by an open breaker
+var errFacilitatorUnavailable = errors.New("facilitator unavailable (circuit open)")
+
+// BreakerState is the state of the facilitator circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Requests flow normally
+	BreakerOpen     BreakerState = "open"      // Facilitator skipped; fallback applies
+	BreakerHalfOpen BreakerState = "half-open" // One probe in flight decides the next state
+)
+
+// BreakerStats summarizes the facilitator circuit breaker
+type BreakerStats struct {
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	Opens               uint64       `json:"opens"`
+	Rejected            uint64       `json:"rejected"`    // Verifications skipped while open
+	ServedOpen          uint64       `json:"served_open"` // Requests served unverified (fail-open)
+}
+
+// WithVerifyRetries retries verifications that fail in transit or with a 5xx
+// up to retries times, waiting a random delay of up to baseDelay·2^attempt
+// between attempts (defaults: DefaultVerifyRetries, DefaultVerifyRetryDelay);
+// settlement is never retried
+func WithVerifyRetries(retries int, baseDelay time.Duration) Option {
+	return func(m *X402Middleware) {
+		if retries >= 0 {
+			m.verifyRetries = retries
+		}
+		if baseDelay > 0 {
+			m.verifyRetryDelay = baseDelay
+		}
+	}
+}
+
+// WithCircuitBreaker stops calling the facilitator after threshold
+// consecutive failed verifications; after cooldown one probe request is let
+// through (half-open), closing the breaker on success. While it is open
+// requests get the fallback (see WithFailOpen).
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(m *X402Middleware) {
+		if threshold > 0 && cooldown > 0 {
+			m.breaker = &circuitBreaker{threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+		}
+	}
+}
+
+// WithFailOpen serves protected handlers without payment, flagged with an
+// X-Payment-Warning header, when the facilitator cannot be reached, instead
+// of the default 503 (fail-closed). Only for content cheaper than an outage.
+func WithFailOpen() Option {
+	return func(m *X402Middleware) {
+		m.failOpen = true
+	}
+}
+
+// WithBreakerStateChange registers a callback invoked on every circuit
+// breaker transition (for metrics and alerting)
+func WithBreakerStateChange(fn func(from, to BreakerState)) Option {
+	return func(m *X402Middleware) {
+		m.onBreakerChange = fn
+	}
+}
+
+// BreakerStats returns the circuit breaker state and counters (a closed,
+// zero breaker if none is configured)
+func (m *X402Middleware) BreakerStats() BreakerStats {
+	if m.breaker == nil {
+		return BreakerStats{State: BreakerClosed, ServedOpen: m.servedOpen.Load()}
+	}
+	stats := m.breaker.snapshot()
+	stats.ServedOpen = m.servedOpen.Load()
+	return stats
+}
+
+// verifyPayment calls the facilitators to verify a payment, failing over and
+// retrying transient failures within the verify deadline (or the shorter
+// timeout, if set), and honoring the circuit breaker
+func (m *X402Middleware) verifyPayment(ctx context.Context, req *types.VerifyRequest, timeout time.Duration) (*verification, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	if m.breaker != nil && !m.breaker.allow(m.onBreakerChange) {
+		return nil, errFacilitatorUnavailable
+	}
+
+	deadline := m.verifyDeadline
+	if timeout > 0 && timeout < deadline {
+		deadline = timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	var resp *verification
+	for attempt := 0; ; attempt++ {
+		var retryable bool
+		resp, retryable, err = m.verifyAny(ctx, body)
+		if err == nil || !retryable || attempt >= m.verifyRetries {
+			break
+		}
+		delay := time.Duration(rand.Int64N(int64(m.verifyRetryDelay) << attempt))
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(delay):
+			continue
+		}
+		break
+	}
+
+	if m.breaker != nil {
+		m.breaker.record(err == nil, m.onBreakerChange)
+	}
+	return resp, err
+}
+
+// postVerify makes one /verify call to the facilitator at url; retryable
+// reports whether a failure is transient (transport error or 5xx)
+func (m *X402Middleware) postVerify(ctx context.Context, url string, body []byte) (*types.VerifyResponse, bool, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/verify", bytes.NewReader(body))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if id := types.SettlementIDFromContext(ctx); id != "" {
+		httpReq.Header.Set(types.HeaderIdempotencyKey, id)
+	}
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, ctx.Err() == nil, fmt.Errorf("facilitator request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil, true, fmt.Errorf("facilitator returned %d", resp.StatusCode)
+	}
+
+	var verifyResp types.VerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&verifyResp); err != nil {
+		return nil, false, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &verifyResp, false, nil
+}
+
+// facilitatorUnavailable answers a request whose payment could not be
+// verified: 504 if verification ran out of time, 503 otherwise, or the
+// unverified handler in fail-open mode
+func (m *X402Middleware) facilitatorUnavailable(w http.ResponseWriter, r *http.Request, next http.Handler, err error) {
+	if m.failOpen {
+		m.servedOpen.Add(1)
+		log.Printf("x402: serving %s without payment verification: %v", r.URL.Path, err)
+		w.Header().Set(HeaderPaymentWarning, "facilitator unavailable; payment not verified")
+		next.ServeHTTP(w, r)
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		http.Error(w, "payment verification timed out: the facilitator did not answer in time", http.StatusGatewayTimeout)
+		return
+	}
+	if m.breaker != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(m.breaker.cooldown.Round(time.Second).Seconds()))))
+	}
+	http.Error(w, fmt.Sprintf("payment verification unavailable: %v", err), http.StatusServiceUnavailable)
+}
+
+// circuitBreaker tracks consecutive facilitator failures
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool // Half-open probe in flight
+	opens    uint64
+	rejected uint64
+}
+
+// allow reports whether a request may go to the facilitator, moving an
+// expired open breaker to half-open for a single probe
+func (b *circuitBreaker) allow(onChange func(from, to BreakerState)) bool {
+	b.mu.Lock()
+	from := b.state
+	allowed := true
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			allowed = false
+			break
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+	case BreakerHalfOpen:
+		if b.probing {
+			allowed = false
+			break
+		}
+		b.probing = true
+	}
+	if !allowed {
+		b.rejected++
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	notifyBreaker(onChange, from, to)
+	return allowed
+}
+
+// record updates the breaker with the outcome of an allowed request
+func (b *circuitBreaker) record(success bool, onChange func(from, to BreakerState)) {
+	b.mu.Lock()
+	from := b.state
+	switch {
+	case success:
+		b.failures = 0
+		b.state = BreakerClosed
+	default:
+		b.failures++
+		if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+			if b.state != BreakerOpen {
+				b.opens++
+			}
+			b.state = BreakerOpen
+			b.openedAt = time.Now()
+		}
+	}
+	b.probing = false
+	to := b.state
+	b.mu.Unlock()
+
+	notifyBreaker(onChange, from, to)
+}
+
+func (b *circuitBreaker) snapshot() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BreakerStats{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Opens:               b.opens,
+		Rejected:            b.rejected,
+	}
+}
+
+// notifyBreaker logs a transition and reports it to onChange
+func notifyBreaker(onChange func(from, to BreakerState), from, to BreakerState) {
+	if from == to {
+		return
+	}
+	log.Printf("x402: facilitator circuit breaker %s -> %s", from, to)
+	if onChange != nil {
+		onChange(from, to)
+	}
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// flappingFacilitator fronts a fake facilitator, answering every call with
+// 500 while down and /verify with 500 for the next failNext calls
+type flappingFacilitator struct {
+	URL      string
+	fake     *x402test.FakeFacilitator
+	down     atomic.Bool
+	failNext atomic.Int64
+	verifies atomic.Int64
+	hold     atomic.Pointer[chan struct{}] // When set, verifications wait for it to close
+}
+
+func newFlappingFacilitator(t *testing.T) *flappingFacilitator {
+	t.Helper()
+	fake := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	target, err := url.Parse(fake.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &flappingFacilitator{fake: fake}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/verify" {
+			f.verifies.Add(1)
+			if hold := f.hold.Load(); hold != nil {
+				<-*hold
+			}
+			if f.failNext.Add(-1) >= 0 {
+				http.Error(w, "facilitator failing", http.StatusInternalServerError)
+				return
+			}
+		}
+		if f.down.Load() {
+			http.Error(w, "facilitator down", http.StatusInternalServerError)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	f.URL = server.URL
+	return f
+}
+
+// content is a protected handler recording whether it was reached
+func content(hit *atomic.Bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit.Store(true)
+		w.Write([]byte("content"))
+	})
+}
+
+func TestVerifyRetriesTransientFailures(t *testing.T) {
+	facilitator := newFlappingFacilitator(t)
+	var hit atomic.Bool
+	handler := NewX402Middleware(facilitator.URL, WithVerifyRetries(2, time.Millisecond)).Protect(content(&hit), fixturePriceTag())
+
+	facilitator.failNext.Store(2)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusOK || !hit.Load() {
+		t.Errorf("status %d after two transient failures, want the retry served", rec.Code)
+	}
+	if n := facilitator.verifies.Load(); n != 3 {
+		t.Errorf("%d verify calls, want the first and two retries", n)
+	}
+
+	// Retries are bounded
+	facilitator.verifies.Store(0)
+	facilitator.down.Store(true)
+	hit.Store(false)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusServiceUnavailable || hit.Load() {
+		t.Errorf("status %d, reached %v with the facilitator down; want a 503", rec.Code, hit.Load())
+	}
+	if n := facilitator.verifies.Load(); n != 3 {
+		t.Errorf("%d verify calls with the facilitator down, want 3", n)
+	}
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	facilitator := newFlappingFacilitator(t)
+	var mu sync.Mutex
+	var transitions []string
+	m := NewX402Middleware(facilitator.URL,
+		WithVerifyRetries(0, 0),
+		WithCircuitBreaker(2, cooldown),
+		WithBreakerStateChange(func(from, to BreakerState) {
+			mu.Lock()
+			defer mu.Unlock()
+			transitions = append(transitions, string(from)+"->"+string(to))
+		}),
+	)
+	var hit atomic.Bool
+	handler := m.Protect(content(&hit), fixturePriceTag())
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+		return rec
+	}
+
+	facilitator.down.Store(true)
+	for i := 0; i < 2; i++ {
+		if rec := serve(); rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("failure %d: status %d, want 503", i+1, rec.Code)
+		}
+	}
+	if stats := m.BreakerStats(); stats.State != BreakerOpen || stats.Opens != 1 || stats.ConsecutiveFailures != 2 {
+		t.Fatalf("stats %+v after the threshold, want the breaker open", stats)
+	}
+
+	// Open: the facilitator is not called
+	rec := serve()
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("open breaker: status %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if n := facilitator.verifies.Load(); n != 2 {
+		t.Errorf("%d verify calls, want none while open", n)
+	}
+
+	// A failed half-open probe opens it again
+	time.Sleep(cooldown + 10*time.Millisecond)
+	if rec := serve(); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("failed probe: status %d", rec.Code)
+	}
+	if n := facilitator.verifies.Load(); n != 3 || m.BreakerStats().State != BreakerOpen {
+		t.Errorf("%d verify calls, breaker %s after a failed probe; want 3, open", n, m.BreakerStats().State)
+	}
+
+	// A successful probe closes it
+	facilitator.down.Store(false)
+	time.Sleep(cooldown + 10*time.Millisecond)
+	if rec := serve(); rec.Code != http.StatusOK || !hit.Load() {
+		t.Errorf("successful probe: status %d", rec.Code)
+	}
+	stats := m.BreakerStats()
+	if stats.State != BreakerClosed || stats.ConsecutiveFailures != 0 || stats.Opens != 2 || stats.Rejected != 1 {
+		t.Errorf("stats %+v after recovering", stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transition %d: %s, want %s", i, transitions[i], want[i])
+		}
+	}
+}
+
+func TestCircuitBreakerHalfOpenAllowsOneProbe(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+	facilitator := newFlappingFacilitator(t)
+	m := NewX402Middleware(facilitator.URL, WithVerifyRetries(0, 0), WithCircuitBreaker(1, cooldown))
+	var hit atomic.Bool
+	handler := m.Protect(content(&hit), fixturePriceTag())
+
+	facilitator.down.Store(true)
+	handler.ServeHTTP(httptest.NewRecorder(), paidRequest(t, http.MethodGet, "/resource"))
+	if m.BreakerStats().State != BreakerOpen {
+		t.Fatalf("breaker %s, want open", m.BreakerStats().State)
+	}
+	facilitator.down.Store(false)
+	hold := make(chan struct{})
+	facilitator.hold.Store(&hold)
+	time.Sleep(cooldown + 10*time.Millisecond)
+
+	probe := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(probe, paidRequest(t, http.MethodGet, "/resource"))
+	}()
+	for facilitator.verifies.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if state := m.BreakerStats().State; state != BreakerHalfOpen {
+		t.Errorf("breaker %s during the probe, want half-open", state)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("second request during the probe: status %d, want 503", rec.Code)
+	}
+	close(hold)
+	<-done
+	if probe.Code != http.StatusOK || m.BreakerStats().State != BreakerClosed {
+		t.Errorf("probe: status %d, breaker %s; want 200 and closed", probe.Code, m.BreakerStats().State)
+	}
+	if n := facilitator.verifies.Load(); n != 2 {
+		t.Errorf("%d verify calls, want one probe", n)
+	}
+}
+
+func TestFacilitatorFallbacks(t *testing.T) {
+	facilitator := newFlappingFacilitator(t)
+	facilitator.down.Store(true)
+
+	for name, tc := range map[string]struct {
+		opts   []Option
+		status int
+		served bool
+	}{
+		"fail-closed": {nil, http.StatusServiceUnavailable, false},
+		"fail-open":   {[]Option{WithFailOpen()}, http.StatusOK, true},
+	} {
+		m := NewX402Middleware(facilitator.URL, append(tc.opts, WithVerifyRetries(0, 0), WithCircuitBreaker(1, time.Minute))...)
+		var hit atomic.Bool
+		handler := m.Protect(content(&hit), fixturePriceTag())
+		for i, phase := range []string{"facilitator failing", "breaker open"} {
+			hit.Store(false)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+			warned := rec.Header().Get(HeaderPaymentWarning) != ""
+			if rec.Code != tc.status || hit.Load() != tc.served || warned != tc.served {
+				t.Errorf("%s, %s: status %d, reached %v, warning %v", name, phase, rec.Code, hit.Load(), warned)
+			}
+			if state := m.BreakerStats().State; state != BreakerOpen {
+				t.Errorf("%s, request %d: breaker %s, want open", name, i+1, state)
+			}
+		}
+		if served := m.BreakerStats().ServedOpen; (served == 2) != tc.served {
+			t.Errorf("%s: %d requests served open", name, served)
+		}
+	}
+}
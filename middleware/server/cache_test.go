@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// variesOnPayment reports whether h lists every payment header name in Vary
+func variesOnPayment(h http.Header) bool {
+	vary := strings.Join(h.Values("Vary"), ", ")
+	for _, name := range types.PaymentHeaderAliases {
+		if !strings.Contains(vary, name) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestUnpaid402IsUncacheableAndAdvertisesScheme(t *testing.T) {
+	handler := NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), fixturePriceTag())
+	want := x402test.Requirements()
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/resource", nil))
+		if rec.Code != http.StatusPaymentRequired {
+			t.Fatalf("%s: status %d", method, rec.Code)
+		}
+		if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+			t.Errorf("%s: Cache-Control %q, want no-store", method, cc)
+		}
+		if !variesOnPayment(rec.Header()) {
+			t.Errorf("%s: Vary %v, want the payment headers", method, rec.Header().Values("Vary"))
+		}
+		advert := rec.Header().Get(types.HeaderXPayment)
+		if !strings.HasPrefix(advert, "x402 ") || !strings.Contains(advert, `scheme="`+string(want.Scheme)+`"`) || !strings.Contains(advert, `network="`+string(want.Network)+`"`) {
+			t.Errorf("%s: X-Payment %q, want the scheme and network advertised", method, advert)
+		}
+	}
+}
+
+func TestInvalidPayment402IsUncacheable(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorReject)
+	handler := NewX402Middleware(facilitator.URL).Protect(http.NotFoundHandler(), fixturePriceTag())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusPaymentRequired)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control %q, want no-store", cc)
+	}
+	if !variesOnPayment(rec.Header()) {
+		t.Errorf("Vary %v, want the payment headers", rec.Header().Values("Vary"))
+	}
+	if advert := rec.Header().Get(types.HeaderXPayment); advert != "" {
+		t.Errorf("X-Payment %q on a rejected payment, want it only on the bare 402", advert)
+	}
+}
+
+func TestVaryOnPaidResponses(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	for _, vary := range []bool{false, true} {
+		var opts []Option
+		if vary {
+			opts = append(opts, WithVaryOnPaidResponses())
+		}
+		handler := NewX402Middleware(facilitator.URL, opts...).Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("content"))
+		}), fixturePriceTag())
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("vary %v: status %d", vary, rec.Code)
+		}
+		if got := variesOnPayment(rec.Header()); got != vary {
+			t.Errorf("WithVaryOnPaidResponses %v: Vary %v", vary, rec.Header().Values("Vary"))
+		}
+	}
+}
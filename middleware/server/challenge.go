@@ -0,0 +1,149 @@
+package server
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultChallengeTTL is how long an issued 402 challenge can be answered
+const DefaultChallengeTTL = 5 * time.Minute
+
+// maxOutstandingChallenges bounds the challenge store; the oldest challenge
+// is dropped when an unpaid client floods the server with 402s
+const maxOutstandingChallenges = 100000
+
+// WithChallenge enables challenge mode: every 402 carries a single-use
+// server nonce and the resource in requirements.Extra, and a payment is only
+// accepted if its authorization nonce is derived from an unused challenge
+// issued for the same resource (see types.PaymentChallenge). This stops a
+// captured payload from being replayed against another resource before it
+// settles. A ttl of 0 uses DefaultChallengeTTL.
+func WithChallenge(ttl time.Duration) Option {
+	return func(m *X402Middleware) {
+		if ttl <= 0 {
+			ttl = DefaultChallengeTTL
+		}
+		m.challenges = newChallengeStore(ttl, maxOutstandingChallenges)
+	}
+}
+
+// challengeStore tracks outstanding challenges until they are used or expire
+// Challenges share a TTL, so issue order is also expiry order
+type challengeStore struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	order   *list.List // *challengeEntry, oldest first
+	entries map[string]*list.Element
+}
+
+type challengeEntry struct {
+	nonce     string
+	expiresAt time.Time
+}
+
+func newChallengeStore(ttl time.Duration, maxEntries int) *challengeStore {
+	return &challengeStore{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// issue creates and records a new challenge nonce
+func (s *challengeStore) issue() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	nonce := hex.EncodeToString(buf)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired challenges, then the oldest if still full
+	for front := s.order.Front(); front != nil; front = s.order.Front() {
+		entry := front.Value.(*challengeEntry)
+		if now.Before(entry.expiresAt) && s.order.Len() < s.maxEntries {
+			break
+		}
+		s.order.Remove(front)
+		delete(s.entries, entry.nonce)
+	}
+
+	s.entries[nonce] = s.order.PushBack(&challengeEntry{nonce: nonce, expiresAt: now.Add(s.ttl)})
+	return nonce, nil
+}
+
+// consume marks a challenge used, reporting whether it was outstanding
+func (s *challengeStore) consume(nonce string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[nonce]
+	if !ok {
+		return false
+	}
+	s.order.Remove(elem)
+	delete(s.entries, nonce)
+	return time.Now().Before(elem.Value.(*challengeEntry).expiresAt)
+}
+
+// challengeResource identifies the resource a challenge is issued for
+func challengeResource(r *http.Request) string {
+	return r.Host + r.URL.EscapedPath()
+}
+
+// challengeRequirements returns a copy of requirements carrying a fresh
+// challenge for r, or requirements itself when challenge mode is off
+func (m *X402Middleware) challengeRequirements(r *http.Request, requirements *types.PaymentRequirements) *types.PaymentRequirements {
+	if m.challenges == nil {
+		return requirements
+	}
+	nonce, err := m.challenges.issue()
+	if err != nil {
+		return requirements
+	}
+	challenged := *requirements
+	extra, err := types.MergeExtra(requirements.Extra, types.RequirementsExtra{Challenge: &types.PaymentChallenge{
+		Nonce:    nonce,
+		Resource: challengeResource(r),
+	}})
+	if err != nil {
+		return requirements
+	}
+	challenged.Extra = extra
+	return &challenged
+}
+
+// checkChallenge validates and consumes the challenge answered by payload
+// Returns a rejection reason, or "" if the payment may proceed
+func (m *X402Middleware) checkChallenge(r *http.Request, payload *types.PaymentPayload) string {
+	if m.challenges == nil {
+		return ""
+	}
+	challenge, err := types.ParseChallenge(payload.Extra)
+	if err != nil || challenge == nil {
+		return "payment does not answer a challenge"
+	}
+	if challenge.Resource != challengeResource(r) {
+		return "payment challenge was issued for a different resource"
+	}
+	if !strings.EqualFold(payload.Payload.Authorization.Nonce.String(), challenge.AuthorizationNonce()) {
+		return "authorization nonce is not bound to the challenge"
+	}
+	if !m.challenges.consume(challenge.Nonce) {
+		return "payment challenge expired or already used"
+	}
+	return ""
+}
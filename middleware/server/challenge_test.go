@@ -0,0 +1,202 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// challengeOffer returns the requirements of the 402 answering an unpaid GET of target
+func challengeOffer(t *testing.T, handler http.Handler, target string) types.PaymentRequirements {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("unpaid GET %s: status %d", target, rec.Code)
+	}
+	var body struct {
+		Requirements types.PaymentRequirements `json:"payment_requirements"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Requirements
+}
+
+// payChallenge sends a GET of target paying requirements
+func payChallenge(t *testing.T, handler http.Handler, target string, requirements types.PaymentRequirements) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := x402test.PaymentHeader(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set(types.HeaderXPayment, header)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// challengeRejection returns the reason of a 402 answering a challenged payment
+func challengeRejection(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusPaymentRequired)
+	}
+	var body struct {
+		Reason     string           `json:"reason"`
+		ReasonCode types.ReasonCode `json:"reasonCode"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.ReasonCode != types.ReasonChallengeFailed {
+		t.Errorf("reason code %q, want %q", body.ReasonCode, types.ReasonChallengeFailed)
+	}
+	return body.Reason
+}
+
+func challengeHandler(t *testing.T, ttl time.Duration) (http.Handler, *x402test.FakeFacilitator) {
+	t.Helper()
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	var hit bool
+	return NewX402Middleware(facilitator.URL, WithChallenge(ttl)).Protect(served(&hit), fixturePriceTag()), facilitator
+}
+
+func TestChallenge402CarriesFreshChallenge(t *testing.T) {
+	handler, _ := challengeHandler(t, 0)
+	first, err := types.ParseChallenge(challengeOffer(t, handler, "http://shop.test/a").Extra)
+	if err != nil || first == nil {
+		t.Fatalf("402 carries no challenge (%v)", err)
+	}
+	second, _ := types.ParseChallenge(challengeOffer(t, handler, "http://shop.test/a").Extra)
+	if first.Resource != "shop.test/a" || second == nil || second.Nonce == first.Nonce {
+		t.Errorf("challenges %+v then %+v, want fresh nonces for shop.test/a", first, second)
+	}
+
+	// Without challenge mode the requirements are served as configured
+	plain := NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), fixturePriceTag())
+	if challenge, _ := types.ParseChallenge(challengeOffer(t, plain, "http://shop.test/a").Extra); challenge != nil {
+		t.Errorf("challenge %+v issued outside challenge mode", challenge)
+	}
+}
+
+func TestChallengeAcceptsAnsweredChallenge(t *testing.T) {
+	handler, facilitator := challengeHandler(t, 0)
+	offer := challengeOffer(t, handler, "http://shop.test/a")
+	if rec := payChallenge(t, handler, "http://shop.test/a", offer); rec.Code != http.StatusNoContent {
+		t.Fatalf("answered challenge: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if facilitator.VerifyCount() != 1 {
+		t.Errorf("%d verifications, want 1", facilitator.VerifyCount())
+	}
+}
+
+func TestChallengeRejectsReplayAgainstAnotherResource(t *testing.T) {
+	handler, facilitator := challengeHandler(t, 0)
+	offer := challengeOffer(t, handler, "http://shop.test/a")
+
+	reason := challengeRejection(t, payChallenge(t, handler, "http://shop.test/b", offer))
+	if !strings.Contains(reason, "different resource") {
+		t.Errorf("payment for /a replayed on /b: reason %q", reason)
+	}
+	if facilitator.VerifyCount() != 0 {
+		t.Errorf("%d verifications, want the replay stopped before the facilitator", facilitator.VerifyCount())
+	}
+}
+
+func TestChallengeRejectsReuse(t *testing.T) {
+	handler, facilitator := challengeHandler(t, 0)
+	offer := challengeOffer(t, handler, "http://shop.test/a")
+	if rec := payChallenge(t, handler, "http://shop.test/a", offer); rec.Code != http.StatusNoContent {
+		t.Fatalf("first payment: status %d", rec.Code)
+	}
+
+	rec := payChallenge(t, handler, "http://shop.test/a", offer)
+	if reason := challengeRejection(t, rec); !strings.Contains(reason, "already used") {
+		t.Errorf("reused challenge: reason %q", reason)
+	}
+	if facilitator.VerifyCount() != 1 {
+		t.Errorf("%d verifications, want only the first payment verified", facilitator.VerifyCount())
+	}
+
+	// The rejection offers a new challenge to pay with
+	var body struct {
+		Requirements types.PaymentRequirements `json:"payment_requirements"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	used, _ := types.ParseChallenge(offer.Extra)
+	if fresh, _ := types.ParseChallenge(body.Requirements.Extra); fresh == nil || fresh.Nonce == used.Nonce {
+		t.Errorf("rejection offers challenge %+v, want a fresh one", fresh)
+	}
+}
+
+func TestChallengeRejectsUnboundPayments(t *testing.T) {
+	handler, _ := challengeHandler(t, 0)
+
+	// No challenge echoed
+	if reason := challengeRejection(t, payChallenge(t, handler, "http://shop.test/a", x402test.Requirements())); !strings.Contains(reason, "does not answer") {
+		t.Errorf("payment without a challenge: reason %q", reason)
+	}
+
+	// Challenge echoed, but the signed nonce is not derived from it
+	offer := challengeOffer(t, handler, "http://shop.test/a")
+	payload, err := x402test.GenerateValidPayload(x402test.Requirements(), x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload.Extra = offer.Extra
+	header, err := x402test.PaymentHeader(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "http://shop.test/a", nil)
+	req.Header.Set(types.HeaderXPayment, header)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if reason := challengeRejection(t, rec); !strings.Contains(reason, "not bound") {
+		t.Errorf("payment with an unrelated nonce: reason %q", reason)
+	}
+}
+
+func TestChallengeExpires(t *testing.T) {
+	handler, _ := challengeHandler(t, 20*time.Millisecond)
+	offer := challengeOffer(t, handler, "http://shop.test/a")
+	time.Sleep(50 * time.Millisecond)
+	if reason := challengeRejection(t, payChallenge(t, handler, "http://shop.test/a", offer)); !strings.Contains(reason, "expired") {
+		t.Errorf("expired challenge: reason %q", reason)
+	}
+}
+
+func TestChallengeStoreDropsOldestWhenFull(t *testing.T) {
+	store := newChallengeStore(time.Minute, 2)
+	var nonces []string
+	for i := 0; i < 3; i++ {
+		nonce, err := store.issue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces = append(nonces, nonce)
+	}
+	if store.consume(nonces[0]) {
+		t.Error("the oldest challenge survived a full store")
+	}
+	if !store.consume(nonces[1]) || !store.consume(nonces[2]) {
+		t.Error("a recent challenge was dropped")
+	}
+	if store.consume(nonces[2]) {
+		t.Error("a challenge was consumed twice")
+	}
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultVerifyDeadline bounds one verification across all retries and
+// fallback facilitators
+const DefaultVerifyDeadline = 10 * time.Second
+
+// DefaultProbeInterval is how often StartFacilitatorProber checks each facilitator
+const DefaultProbeInterval = 30 * time.Second
+
+// facilitatorEndpoint is one facilitator in priority order
+type facilitatorEndpoint struct {
+	url     string
+	healthy atomic.Bool
+}
+
+func newFacilitatorEndpoint(url string) *facilitatorEndpoint {
+	endpoint := &facilitatorEndpoint{url: strings.TrimSuffix(url, "/")}
+	endpoint.healthy.Store(true)
+	return endpoint
+}
+
+// setHealthy records an endpoint's health, logging changes
+func (e *facilitatorEndpoint) setHealthy(healthy bool, cause error) {
+	if e.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		log.Printf("x402: facilitator %s healthy again", e.url)
+	} else {
+		log.Printf("x402: facilitator %s demoted: %v", e.url, cause)
+	}
+}
+
+// FacilitatorStatus reports one facilitator's health
+type FacilitatorStatus struct {
+	URL     string `json:"url"`
+	Healthy bool   `json:"healthy"`
+}
+
+// verification is a verify result tagged with the facilitator that produced
+// it; settlement goes to the same facilitator so its nonce store stays the
+// one that saw the payload
+type verification struct {
+	*types.VerifyResponse
+	facilitatorURL string
+}
+
+// WithFallbackFacilitators adds backup facilitators, tried in order after the
+// primary when it fails in transit or with a 5xx. Healthy facilitators are
+// always tried before demoted ones (see StartFacilitatorProber).
+func WithFallbackFacilitators(urls ...string) Option {
+	return func(m *X402Middleware) {
+		for _, url := range urls {
+			if url != "" {
+				m.facilitators = append(m.facilitators, newFacilitatorEndpoint(url))
+			}
+		}
+	}
+}
+
+// WithVerifyDeadline bounds each verification, including retries and
+// failover, to d (default: DefaultVerifyDeadline)
+func WithVerifyDeadline(d time.Duration) Option {
+	return func(m *X402Middleware) {
+		if d > 0 {
+			m.verifyDeadline = d
+		}
+	}
+}
+
+// Facilitators returns the facilitators in priority order with their health
+func (m *X402Middleware) Facilitators() []FacilitatorStatus {
+	statuses := make([]FacilitatorStatus, len(m.facilitators))
+	for i, endpoint := range m.facilitators {
+		statuses[i] = FacilitatorStatus{URL: endpoint.url, Healthy: endpoint.healthy.Load()}
+	}
+	return statuses
+}
+
+// endpointOrder returns healthy facilitators in priority order, then demoted
+// ones as a last resort
+func (m *X402Middleware) endpointOrder() []*facilitatorEndpoint {
+	if len(m.facilitators) == 1 {
+		return m.facilitators
+	}
+	ordered := make([]*facilitatorEndpoint, 0, len(m.facilitators))
+	for _, endpoint := range m.facilitators {
+		if endpoint.healthy.Load() {
+			ordered = append(ordered, endpoint)
+		}
+	}
+	for _, endpoint := range m.facilitators {
+		if !endpoint.healthy.Load() {
+			ordered = append(ordered, endpoint)
+		}
+	}
+	return ordered
+}
+
+// verifyAny sends one verification to the facilitators in order, moving on
+// after transient failures; retryable reports whether all of them failed
+// transiently
+func (m *X402Middleware) verifyAny(ctx context.Context, body []byte) (*verification, bool, error) {
+	var lastErr error
+	for _, endpoint := range m.endpointOrder() {
+		resp, retryable, err := m.postVerify(ctx, endpoint.url, body)
+		if err == nil {
+			endpoint.setHealthy(true, nil)
+			return &verification{VerifyResponse: resp, facilitatorURL: endpoint.url}, false, nil
+		}
+		if !retryable {
+			return nil, false, err
+		}
+		endpoint.setHealthy(false, err)
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, ctx.Err() == nil, lastErr
+}
+
+// ProbeFacilitators checks every facilitator's GET /supported once,
+// promoting those that answer and demoting those that do not
+func (m *X402Middleware) ProbeFacilitators(ctx context.Context) {
+	for _, endpoint := range m.facilitators {
+		endpoint.setHealthy(m.probe(ctx, endpoint.url))
+	}
+}
+
+// probe reports whether a facilitator answers /supported without a 5xx
+func (m *X402Middleware) probe(ctx context.Context, url string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.verifyDeadline)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/supported", nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return false, fmt.Errorf("probe returned %d", resp.StatusCode)
+	}
+	return true, nil
+}
+
+// FacilitatorProber periodically re-probes the facilitators (see
+// StartFacilitatorProber)
+type FacilitatorProber struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// StartFacilitatorProber probes the facilitators every interval
+// (DefaultProbeInterval if interval <= 0) until Stop, so a recovered primary
+// takes traffic back and a failing one is skipped before requests hit it
+func (m *X402Middleware) StartFacilitatorProber(interval time.Duration) *FacilitatorProber {
+	if interval <= 0 {
+		interval = DefaultProbeInterval
+	}
+	p := &FacilitatorProber{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				m.ProbeFacilitators(context.Background())
+			}
+		}
+	}()
+	return p
+}
+
+// Stop ends the probe loop and waits for it
+func (p *FacilitatorProber) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+	})
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFailoverToBackupFacilitator(t *testing.T) {
+	primary, backup := newFlappingFacilitator(t), newFlappingFacilitator(t)
+	m := NewX402Middleware(primary.URL, WithFallbackFacilitators(backup.URL), WithVerifyRetries(0, 0))
+	var hit atomic.Bool
+	handler := m.Protect(content(&hit), fixturePriceTag())
+
+	primary.down.Store(true)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusOK || !hit.Load() {
+		t.Fatalf("status %d with the primary down, want the backup to verify", rec.Code)
+	}
+	if primary.verifies.Load() != 1 || backup.verifies.Load() != 1 {
+		t.Errorf("verify calls: primary %d, backup %d; want 1 each", primary.verifies.Load(), backup.verifies.Load())
+	}
+	if statuses := m.Facilitators(); len(statuses) != 2 || statuses[0].Healthy || !statuses[1].Healthy {
+		t.Errorf("facilitators %+v, want the primary demoted", statuses)
+	}
+
+	// The demoted primary is tried last
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusOK || primary.verifies.Load() != 1 || backup.verifies.Load() != 2 {
+		t.Errorf("status %d, verify calls primary %d, backup %d; want the backup first", rec.Code, primary.verifies.Load(), backup.verifies.Load())
+	}
+
+	// Both down: the request fails after trying each
+	backup.down.Store(true)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusServiceUnavailable || primary.verifies.Load() != 2 || backup.verifies.Load() != 3 {
+		t.Errorf("status %d, verify calls primary %d, backup %d; want a 503 after both", rec.Code, primary.verifies.Load(), backup.verifies.Load())
+	}
+}
+
+func TestSettlementSticksToVerifyingFacilitator(t *testing.T) {
+	primary, backup := newFlappingFacilitator(t), newFlappingFacilitator(t)
+	m := NewX402Middleware(primary.URL, WithFallbackFacilitators(backup.URL), WithVerifyRetries(0, 0), WithSettleAfterSuccess())
+	handler := m.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primary.down.Store(false) // Recovered before settlement
+		w.Write([]byte("content"))
+	}), fixturePriceTag())
+
+	primary.down.Store(true)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Payment-Response") == "" {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(backup.fake.Settled()) != 1 || len(primary.fake.Settled()) != 0 {
+		t.Errorf("settlements: primary %d, backup %d; want the backup that verified", len(primary.fake.Settled()), len(backup.fake.Settled()))
+	}
+}
+
+func TestProbeFacilitatorsPromotesRecoveredPrimary(t *testing.T) {
+	primary, backup := newFlappingFacilitator(t), newFlappingFacilitator(t)
+	m := NewX402Middleware(primary.URL, WithFallbackFacilitators(backup.URL, ""))
+	if n := len(m.Facilitators()); n != 2 {
+		t.Fatalf("%d facilitators, want the empty URL skipped", n)
+	}
+
+	primary.down.Store(true)
+	m.ProbeFacilitators(context.Background())
+	if statuses := m.Facilitators(); statuses[0].Healthy || !statuses[1].Healthy {
+		t.Fatalf("facilitators %+v after probing, want the primary demoted", statuses)
+	}
+
+	primary.down.Store(false)
+	prober := m.StartFacilitatorProber(10 * time.Millisecond)
+	defer prober.Stop()
+	deadline := time.Now().Add(2 * time.Second)
+	for !m.Facilitators()[0].Healthy {
+		if time.Now().After(deadline) {
+			t.Fatal("the prober did not promote the recovered primary")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	prober.Stop()
+	prober.Stop() // Stopping twice is harmless
+
+	var hit atomic.Bool
+	rec := httptest.NewRecorder()
+	m.Protect(content(&hit), fixturePriceTag()).ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusOK || primary.verifies.Load() != 1 || backup.verifies.Load() != 0 {
+		t.Errorf("status %d, verify calls primary %d, backup %d; want the primary back in front", rec.Code, primary.verifies.Load(), backup.verifies.Load())
+	}
+}
+
+func TestVerifyDeadlineCoversFailover(t *testing.T) {
+	primary, backup := newFlappingFacilitator(t), newFlappingFacilitator(t)
+	hold := make(chan struct{})
+	defer close(hold)
+	primary.hold.Store(&hold)
+	m := NewX402Middleware(primary.URL, WithFallbackFacilitators(backup.URL), WithVerifyDeadline(50*time.Millisecond))
+
+	var hit atomic.Bool
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	m.Protect(content(&hit), fixturePriceTag()).ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusGatewayTimeout || hit.Load() {
+		t.Errorf("status %d with a hanging primary, want 504", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("verification took %v, want it cut off at the deadline", elapsed)
+	}
+	if n := backup.verifies.Load(); n != 0 {
+		t.Errorf("%d backup verifications once the deadline passed", n)
+	}
+}
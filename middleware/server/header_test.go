@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// largeSchemaPriceTag charges the x402test requirements for a resource
+// with a 50KB output schema
+func largeSchemaPriceTag() *PriceTag {
+	tag := fixturePriceTag()
+	tag.Requirements.OutputSchema = json.RawMessage(fmt.Sprintf(`{"type":"object","description":%q}`, strings.Repeat("x", 50<<10)))
+	return tag
+}
+
+func TestLarge402PutsSummaryInHeader(t *testing.T) {
+	tag := largeSchemaPriceTag()
+	handler := NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), tag)
+
+	for _, version := range []string{"1", "2"} {
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		req.Header.Set("X-Payment-Version", version)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		header := rec.Header().Get(types.HeaderPaymentRequired)
+		if len(header) > DefaultRequirementsHeaderLimit {
+			t.Fatalf("v%s: %d byte X-Payment-Required header", version, len(header))
+		}
+		var summary types.RequirementsSummary
+		if err := json.Unmarshal([]byte(header), &summary); err != nil {
+			t.Fatalf("v%s: header %q: %v", version, header, err)
+		}
+		want := tag.Requirements.Summary(map[string]int{"1": 1, "2": 2}[version])
+		if summary != want {
+			t.Errorf("v%s: header summary %+v, want %+v", version, summary, want)
+		}
+
+		// v1 keeps the schema in the requirements, v2 in the resource
+		var body struct {
+			Requirements struct {
+				OutputSchema json.RawMessage `json:"outputSchema"`
+			} `json:"payment_requirements"`
+			Resource struct {
+				OutputSchema json.RawMessage `json:"outputSchema"`
+			} `json:"resource"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		schema := body.Requirements.OutputSchema
+		if version == "2" {
+			schema = body.Resource.OutputSchema
+		}
+		if len(schema) < 50<<10 {
+			t.Errorf("v%s: body carries a %d byte output schema, want the full document", version, len(schema))
+		}
+	}
+}
+
+func TestRequirementsHeaderFallsBackToBodyReference(t *testing.T) {
+	// A bodyless HEAD 402 carries the full requirements only while they fit
+	rec := httptest.NewRecorder()
+	NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), largeSchemaPriceTag()).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/resource", nil))
+	if header := rec.Header().Get(types.HeaderPaymentRequired); header != types.RequirementsHeaderRef {
+		t.Errorf("HEAD with a 50KB schema: %d byte header, want %q", len(header), types.RequirementsHeaderRef)
+	}
+
+	rec = httptest.NewRecorder()
+	NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), fixturePriceTag()).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/resource", nil))
+	var requirements types.PaymentRequirements
+	if err := json.Unmarshal([]byte(rec.Header().Get(types.HeaderPaymentRequired)), &requirements); err != nil || requirements.Description == "" {
+		t.Errorf("HEAD with small requirements: %+v (%v), want the full document", requirements, err)
+	}
+
+	// Even the summary gives way to the reference under a tight limit
+	rec = httptest.NewRecorder()
+	NewX402Middleware("http://facilitator.test", WithRequirementsHeaderLimit(64)).Protect(http.NotFoundHandler(), fixturePriceTag()).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resource", nil))
+	if header := rec.Header().Get(types.HeaderPaymentRequired); header != types.RequirementsHeaderRef {
+		t.Errorf("64 byte limit: header %q, want %q", header, types.RequirementsHeaderRef)
+	}
+	if rec.Body.Len() == 0 {
+		t.Error("402 body is empty")
+	}
+}
+
+func TestProtectRejectsRepeatedAndOversizedPaymentHeaders(t *testing.T) {
+	fake := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	valid := paidRequest(t, http.MethodGet, "/resource").Header.Get(types.HeaderXPayment)
+
+	for _, tc := range []struct {
+		name  string
+		opts  []Option
+		setup func(h http.Header)
+		code  types.ReasonCode // Empty when the request is served
+	}{
+		{"one header", nil, func(h http.Header) { h.Set(types.HeaderXPayment, valid) }, ""},
+		{"repeated", nil, func(h http.Header) {
+			h.Add(types.HeaderXPayment, valid)
+			h.Add(types.HeaderXPayment, valid)
+		}, types.ReasonDuplicatePayment},
+		{"two aliases", nil, func(h http.Header) {
+			h.Set(types.HeaderXPayment, valid)
+			h.Set(types.HeaderPaymentPayload, "something else")
+		}, types.ReasonDuplicatePayment},
+		{"oversized", nil, func(h http.Header) {
+			h.Set(types.HeaderXPayment, strings.Repeat("x", types.DefaultPaymentHeaderLimit+1))
+		}, types.ReasonPaymentTooLarge},
+		{"above a lowered limit", []Option{WithPaymentHeaderLimit(len(valid) - 1)}, func(h http.Header) {
+			h.Set(types.HeaderXPayment, valid)
+		}, types.ReasonPaymentTooLarge},
+	} {
+		var hit bool
+		handler := NewX402Middleware(fake.URL, tc.opts...).Protect(served(&hit), fixturePriceTag())
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		tc.setup(req.Header)
+		rec := httptest.NewRecorder()
+		before := fake.VerifyCount()
+		handler.ServeHTTP(rec, req)
+
+		if tc.code == "" {
+			if !hit {
+				t.Errorf("%s: status %d: %s; want it served", tc.name, rec.Code, rec.Body.String())
+			}
+			continue
+		}
+		var body struct {
+			ReasonCode types.ReasonCode `json:"reasonCode"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if hit || rec.Code != http.StatusBadRequest || body.ReasonCode != tc.code {
+			t.Errorf("%s: status %d, reached %v, body %s; want a 400 with %s", tc.name, rec.Code, hit, rec.Body.String(), tc.code)
+		}
+		if fake.VerifyCount() != before {
+			t.Errorf("%s: the rejected header was sent to the facilitator", tc.name)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultMaxTimeoutSeconds is the validity window RequirementsFromKind asks
+// payers for, comfortably above the time a settlement takes to be included
+const DefaultMaxTimeoutSeconds = 300
+
+// RequirementsOption adjusts the requirements built by RequirementsFromKind
+type RequirementsOption func(*types.PaymentRequirements)
+
+// WithResourceURL names the paid resource (left empty, each request's URL
+// is used once the requirements are protected by a PriceTag)
+func WithResourceURL(url string) RequirementsOption {
+	return func(r *types.PaymentRequirements) {
+		r.Resource = url
+	}
+}
+
+// WithDescription describes the paid resource
+func WithDescription(description string) RequirementsOption {
+	return func(r *types.PaymentRequirements) {
+		r.Description = description
+	}
+}
+
+// WithMimeType sets the MIME type of the paid resource
+func WithMimeType(mimeType string) RequirementsOption {
+	return func(r *types.PaymentRequirements) {
+		r.MimeType = mimeType
+	}
+}
+
+// WithMaxTimeout sets the validity window asked of payers, in seconds
+// (default DefaultMaxTimeoutSeconds)
+func WithMaxTimeout(seconds int) RequirementsOption {
+	return func(r *types.PaymentRequirements) {
+		r.MaxTimeoutSeconds = seconds
+	}
+}
+
+// WithMinTimeout sets the shortest validity window payers may sign, in
+// seconds (default: the facilitator's)
+func WithMinTimeout(seconds int) RequirementsOption {
+	return func(r *types.PaymentRequirements) {
+		r.MinTimeoutSeconds = seconds
+	}
+}
+
+// RequirementsFromKind returns requirements for amountDecimal (e.g.
+// "0.025") of the token of kind, an entry of the facilitator's /supported,
+// payable to payTo: scheme, network, version, asset and scheme parameters
+// come from the kind, and its advertised fee is added on top of the amount
+func RequirementsFromKind(kind types.SupportedPaymentKind, amountDecimal string, payTo types.MixedAddress, opts ...RequirementsOption) (*types.PaymentRequirements, error) {
+	if !kind.Network.IsEVM() || kind.Token.Type != "evm" {
+		return nil, fmt.Errorf("RequirementsFromKind supports EVM networks only, not %s", kind.Network)
+	}
+	asset := types.NormalizeEVMAddress(kind.Token.Address)
+	if !common.IsHexAddress(asset) {
+		return nil, fmt.Errorf("kind for %s has invalid token address %q", kind.Network, kind.Token.Address)
+	}
+	if kind.Decimals == 0 {
+		return nil, fmt.Errorf("kind for %s token %s does not advertise its decimals", kind.Network, kind.Token.Address)
+	}
+	recipient := types.NormalizeEVMAddress(payTo.Address)
+	if !common.IsHexAddress(recipient) {
+		return nil, fmt.Errorf("invalid payTo address %q", payTo.Address)
+	}
+	if common.HexToAddress(recipient) == (common.Address{}) {
+		return nil, fmt.Errorf("payTo is the zero address; payments to it are burned")
+	}
+	amount, err := types.ParseDecimalAmount(amountDecimal, kind.Decimals)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Sign() == 0 {
+		return nil, fmt.Errorf("amount %q is zero", amountDecimal)
+	}
+
+	version := kind.Version
+	if version == "" {
+		version = types.X402VersionV1
+	}
+	requirements := &types.PaymentRequirements{
+		Version:           version,
+		Scheme:            kind.Scheme,
+		Network:           kind.Network,
+		PayTo:             common.HexToAddress(recipient).Hex(),
+		MaxAmountRequired: amount.String(),
+		MaxTimeoutSeconds: DefaultMaxTimeoutSeconds,
+		Asset:             common.HexToAddress(asset),
+		Extra:             kind.Extra,
+	}
+	for _, opt := range opts {
+		opt(requirements)
+	}
+	if err := checkTimeouts(requirements); err != nil {
+		return nil, err
+	}
+
+	if kind.Fee != nil {
+		policy := types.FeePolicy{BasisPoints: kind.Fee.BasisPoints}
+		if flat, ok := new(big.Int).SetString(kind.Fee.FlatAmount, 10); ok {
+			policy.FlatAmount = flat
+		} else if kind.Fee.FlatAmount != "" {
+			return nil, fmt.Errorf("kind for %s has invalid flat fee %q", kind.Network, kind.Fee.FlatAmount)
+		}
+		if err := policy.ApplyFee(requirements); err != nil {
+			return nil, err
+		}
+	}
+	return requirements, nil
+}
+
+// NewPriceTagFromKind returns a price tag for the requirements of
+// RequirementsFromKind
+func NewPriceTagFromKind(kind types.SupportedPaymentKind, amountDecimal string, payTo types.MixedAddress, opts ...RequirementsOption) (*PriceTag, error) {
+	requirements, err := RequirementsFromKind(kind, amountDecimal, payTo, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &PriceTag{Requirements: *requirements}, nil
+}
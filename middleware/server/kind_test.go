@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// supportedFixture returns the kinds of testdata/supported.json, shaped like
+// a live facilitator's /supported, by network and scheme
+func supportedFixture(t *testing.T) map[string]types.SupportedPaymentKind {
+	t.Helper()
+	data, err := os.ReadFile("testdata/supported.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp types.SupportedPaymentKindsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]types.SupportedPaymentKind)
+	for _, kind := range resp.Kinds {
+		kinds[string(kind.Network)+"/"+string(kind.Scheme)] = kind
+	}
+	return kinds
+}
+
+var kindPayTo = types.MixedAddress{Type: "evm", Address: "0x00000000000000000000000000000000000000b0"}
+
+func TestRequirementsFromKind(t *testing.T) {
+	kinds := supportedFixture(t)
+
+	requirements, err := RequirementsFromKind(kinds["base-sepolia/exact"], "0.025", kindPayTo,
+		WithResourceURL("https://example.com/article"), WithDescription("One article"), WithMimeType("text/html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requirements.Scheme != types.SchemeExact || requirements.Network != types.NetworkBaseSepolia || requirements.Version != types.X402VersionV1 ||
+		requirements.Asset != common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e") || requirements.PayTo != common.HexToAddress(kindPayTo.Address).Hex() {
+		t.Errorf("requirements %+v, want the kind's scheme, network, version and asset", requirements)
+	}
+	if requirements.Resource != "https://example.com/article" || requirements.Description != "One article" || requirements.MimeType != "text/html" || requirements.MaxTimeoutSeconds != DefaultMaxTimeoutSeconds {
+		t.Errorf("requirements %+v, want the options applied over the default timeout", requirements)
+	}
+	// 25000 plus the advertised fee of 100 and 50 basis points
+	extra, err := types.ParseExtra(requirements.Extra)
+	if requirements.MaxAmountRequired != "25225" || err != nil || extra.FeeBreakdown == nil || extra.FeeBreakdown.ResourceAmount != "25000" {
+		t.Errorf("amount %s, extra %s (%v); want the fee added on top of 25000", requirements.MaxAmountRequired, requirements.Extra, err)
+	}
+
+	xdc, err := RequirementsFromKind(kinds["xdc/exact"], "1", types.MixedAddress{Type: "evm", Address: "xdc00000000000000000000000000000000000000b0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xdc.Asset != common.HexToAddress("0xD4B5f10D61916Bd6E0860144a91Ac658dE8a1437") || xdc.PayTo != common.HexToAddress(kindPayTo.Address).Hex() || xdc.MaxAmountRequired != "1000000" {
+		t.Errorf("xdc requirements %+v, want the xdc-prefixed addresses normalized", xdc)
+	}
+
+	tag, err := NewPriceTagFromKind(kinds["base-sepolia/exact"], "0.01", kindPayTo, WithMinTimeout(30), WithMaxTimeout(120))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Requirements.MinTimeoutSeconds != 30 || tag.Requirements.MaxTimeoutSeconds != 120 || tag.Requirements.Network != types.NetworkBaseSepolia {
+		t.Errorf("price tag requirements %+v", tag.Requirements)
+	}
+}
+
+func TestRequirementsFromKindRefusals(t *testing.T) {
+	kinds := supportedFixture(t)
+	usdc := kinds["base-sepolia/exact"]
+	badFee := usdc
+	badFee.Fee = &types.FacilitatorFee{FlatAmount: "lots"}
+
+	for _, tc := range []struct {
+		name    string
+		kind    types.SupportedPaymentKind
+		amount  string
+		payTo   string
+		opts    []RequirementsOption
+		wantErr string
+	}{
+		{"solana kind", kinds["solana-devnet/exact"], "1", kindPayTo.Address, nil, "EVM networks only"},
+		{"kind without a token", kinds["base-sepolia/permit2"], "1", kindPayTo.Address, nil, "invalid token address"},
+		{"kind without decimals", func() types.SupportedPaymentKind { k := usdc; k.Decimals = 0; return k }(), "1", kindPayTo.Address, nil, "decimals"},
+		{"malformed payTo", usdc, "1", "0xb0", nil, "invalid payTo"},
+		{"zero payTo", usdc, "1", "0x0000000000000000000000000000000000000000", nil, "zero address"},
+		{"zero amount", usdc, "0.000", kindPayTo.Address, nil, "is zero"},
+		{"too many decimals", usdc, "0.0000001", kindPayTo.Address, nil, "decimal"},
+		{"negative amount", usdc, "-1", kindPayTo.Address, nil, ""},
+		{"min above max timeout", usdc, "1", kindPayTo.Address, []RequirementsOption{WithMinTimeout(600)}, "exceeds maxTimeoutSeconds"},
+		{"malformed fee", badFee, "1", kindPayTo.Address, nil, "invalid flat fee"},
+	} {
+		_, err := RequirementsFromKind(tc.kind, tc.amount, types.MixedAddress{Type: "evm", Address: tc.payTo}, tc.opts...)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: error %v, want one mentioning %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRequirementsFromKindPassFacilitatorValidation(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := facilitator.NewBuilder().
+		WithEVMNetwork(testchain.Network, chain.Options()).
+		WithFeePolicy(testchain.Network, types.FeePolicy{FlatAmount: big.NewInt(100)}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	supported, err := fac.SupportedFiltered(context.Background(), types.SupportedFilter{Network: testchain.Network, Scheme: types.SchemeExact})
+	if err != nil || len(supported.Kinds) != 1 {
+		t.Fatalf("supported %+v (%v), want the test chain's token", supported, err)
+	}
+
+	requirements, err := RequirementsFromKind(supported.Kinds[0], "0.001", kindPayTo, WithResourceURL("https://testchain.local/resource"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requirements.MaxAmountRequired != "1100" {
+		t.Errorf("amount %s, want 1000 plus the flat fee", requirements.MaxAmountRequired)
+	}
+	payload, err := chain.Authorize(chain.Accounts[0], *requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := fac.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: *requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsValid {
+		t.Errorf("payment for requirements built from /supported refused as %s (%s)", resp.ReasonCode, resp.Reason)
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// PaymentMessage is the user-facing copy of a 402: Title and Description
+// join the JSON body and head the payment page, and Description fills in
+// the offered requirements' description when the price tag has none
+type PaymentMessage struct {
+	Title       string // e.g. "Subscribe for 0.03 USDC per article"
+	Description string
+}
+
+// MessageProvider returns the copy of the 402 for a request to the route of
+// priceTag; empty fields leave the default 402 as is
+type MessageProvider func(r *http.Request, priceTag *PriceTag) PaymentMessage
+
+// WithMessageProvider sets the copy of 402 responses, e.g. from
+// LocalizedMessages; without one 402s carry no title or description of
+// their own
+func WithMessageProvider(provider MessageProvider) Option {
+	return func(m *X402Middleware) {
+		m.messages = provider
+	}
+}
+
+// MessageData is what the templates of LocalizedMessages render
+type MessageData struct {
+	PriceTag *PriceTag
+	Price    string // Amount in whole tokens with the symbol, e.g. "0.03 USDC" (see PaymentPageData)
+	Language string // Key of the chosen message
+}
+
+// LocalizedMessages returns a MessageProvider choosing among messages,
+// keyed by language tag (e.g. "en", "pt-BR"), by the request's
+// Accept-Language; requests accepting none of them get messages[fallback].
+// Titles and descriptions are text/template templates executed with a
+// MessageData, e.g. "Subscribe for {{.Price}} per article".
+func LocalizedMessages(messages map[string]PaymentMessage, fallback string) (MessageProvider, error) {
+	if _, ok := messages[fallback]; !ok {
+		return nil, fmt.Errorf("no message for fallback language %q", fallback)
+	}
+	type localized struct {
+		language           string
+		title, description *template.Template
+	}
+	set := make(map[string]localized, len(messages))
+	for language, message := range messages {
+		title, err := template.New(language + " title").Parse(message.Title)
+		if err != nil {
+			return nil, fmt.Errorf("message %q: %w", language, err)
+		}
+		description, err := template.New(language + " description").Parse(message.Description)
+		if err != nil {
+			return nil, fmt.Errorf("message %q: %w", language, err)
+		}
+		set[strings.ToLower(language)] = localized{language: language, title: title, description: description}
+	}
+
+	return func(r *http.Request, priceTag *PriceTag) PaymentMessage {
+		chosen, ok := set[negotiateLanguage(r.Header.Get("Accept-Language"), func(tag string) bool {
+			_, ok := set[tag]
+			return ok
+		})]
+		if !ok {
+			chosen = set[strings.ToLower(fallback)]
+		}
+		data := MessageData{PriceTag: priceTag, Price: displayPrice(&priceTag.Requirements), Language: chosen.language}
+		return PaymentMessage{
+			Title:       executeMessage(chosen.title, data),
+			Description: executeMessage(chosen.description, data),
+		}
+	}, nil
+}
+
+// executeMessage renders a message template, "" if it fails
+func executeMessage(tmpl *template.Template, data MessageData) string {
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return ""
+	}
+	return out.String()
+}
+
+// negotiateLanguage returns the lowercased language tag of header (an
+// Accept-Language value) with the highest weight that available reports,
+// trying each tag and then its shorter prefixes ("pt-br", then "pt"); ""
+// if there is none or the best is "*"
+func negotiateLanguage(header string, available func(tag string) bool) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, candidate := range tags {
+		if candidate.tag == "*" {
+			return ""
+		}
+		for tag := candidate.tag; tag != ""; {
+			if available(tag) {
+				return tag
+			}
+			i := strings.LastIndexByte(tag, '-')
+			if i < 0 {
+				break
+			}
+			tag = tag[:i]
+		}
+	}
+	return ""
+}
+
+// paymentMessage returns the copy of the 402 for r (none without
+// WithMessageProvider)
+func (m *X402Middleware) paymentMessage(r *http.Request, priceTag *PriceTag) PaymentMessage {
+	if m.messages == nil {
+		return PaymentMessage{}
+	}
+	return m.messages(r, priceTag)
+}
+
+// describe returns requirements with the message's description if they
+// have none
+func (msg PaymentMessage) describe(requirements *types.PaymentRequirements) *types.PaymentRequirements {
+	if msg.Description == "" || requirements.Description != "" {
+		return requirements
+	}
+	described := *requirements
+	described.Description = msg.Description
+	return &described
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// testMessages are English and Brazilian Portuguese copy, English by default
+func testMessages(t *testing.T) MessageProvider {
+	t.Helper()
+	provider, err := LocalizedMessages(map[string]PaymentMessage{
+		"en":    {Title: "Subscribe for {{.Price}} per article", Description: "One article ({{.Language}})"},
+		"pt-BR": {Title: "Assine por {{.Price}} por artigo", Description: "Um artigo ({{.Language}})"},
+	}, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	available := func(tag string) bool { return tag == "en" || tag == "pt-br" }
+	for header, want := range map[string]string{
+		"":                            "",
+		"pt-BR":                       "pt-br",
+		"pt-BR-x-custom":              "pt-br",
+		"en-GB,en;q=0.9":              "en",
+		"fr, pt-BR;q=0.8, en;q=0.5":   "pt-br",
+		"en;q=0.4, pt-BR;q=0.6":       "pt-br",
+		"pt;q=0.9, en;q=0.8":          "en",
+		"fr, de":                      "",
+		"*":                           "",
+		"pt-BR;q=0, en":               "en",
+		"pt-BR;q=high, en;q=0.1":      "en",
+		"en-US;q=0.5, *;q=0.9, pt-BR": "pt-br",
+	} {
+		if got := negotiateLanguage(header, available); got != want {
+			t.Errorf("Accept-Language %q: got %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestLocalizedMessagesRejectsBadSets(t *testing.T) {
+	if _, err := LocalizedMessages(map[string]PaymentMessage{"en": {Title: "Pay"}}, "de"); err == nil {
+		t.Error("accepted a fallback without a message")
+	}
+	if _, err := LocalizedMessages(map[string]PaymentMessage{"en": {Title: "Pay {{.Price"}}, "en"); err == nil {
+		t.Error("accepted a malformed template")
+	}
+}
+
+func TestProtectLocalizes402(t *testing.T) {
+	tag := testPriceTag(0, 300)
+	price := displayPrice(&tag.Requirements)
+	handler := NewX402Middleware("http://facilitator.test", WithMessageProvider(testMessages(t))).Protect(http.NotFoundHandler(), tag)
+
+	for _, tc := range []struct {
+		acceptLanguage     string
+		title, description string
+	}{
+		{"pt-BR,pt;q=0.9,en;q=0.8", "Assine por " + price + " por artigo", "Um artigo (pt-BR)"},
+		{"fr-FR, en-GB;q=0.7", "Subscribe for " + price + " per article", "One article (en)"},
+		{"fr", "Subscribe for " + price + " per article", "One article (en)"},
+		{"", "Subscribe for " + price + " per article", "One article (en)"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		if tc.acceptLanguage != "" {
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var body struct {
+			Title        string                    `json:"title"`
+			Description  string                    `json:"description"`
+			Requirements types.PaymentRequirements `json:"payment_requirements"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Title != tc.title || body.Description != tc.description {
+			t.Errorf("Accept-Language %q: title %q, description %q; want %q, %q", tc.acceptLanguage, body.Title, body.Description, tc.title, tc.description)
+		}
+		if body.Requirements.Description != tc.description {
+			t.Errorf("Accept-Language %q: requirements described as %q, want the message's description", tc.acceptLanguage, body.Requirements.Description)
+		}
+		if !strings.Contains(strings.Join(rec.Header().Values("Vary"), ","), "Accept-Language") {
+			t.Errorf("Accept-Language %q: Vary %v, want Accept-Language", tc.acceptLanguage, rec.Header().Values("Vary"))
+		}
+	}
+}
+
+func TestMessageKeepsPriceTagDescription(t *testing.T) {
+	handler := NewX402Middleware("http://facilitator.test", WithMessageProvider(testMessages(t))).Protect(http.NotFoundHandler(), fixturePriceTag())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resource", nil))
+
+	var body struct {
+		Description  string                    `json:"description"`
+		Requirements types.PaymentRequirements `json:"payment_requirements"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Description != "One article (en)" || body.Requirements.Description != "x402test fixture" {
+		t.Errorf("description %q, requirements %q; want the price tag's own description kept", body.Description, body.Requirements.Description)
+	}
+}
+
+func TestDefault402CarriesNoMessage(t *testing.T) {
+	handler := NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), testPriceTag(0, 300))
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set("Accept-Language", "pt-BR")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["title"]; ok {
+		t.Errorf("402 without a provider has a title: %s", rec.Body.String())
+	}
+	if _, ok := body["description"]; ok {
+		t.Errorf("402 without a provider has a description: %s", rec.Body.String())
+	}
+	if strings.Contains(strings.Join(rec.Header().Values("Vary"), ","), "Accept-Language") {
+		t.Errorf("Vary %v, want no Accept-Language without a provider", rec.Header().Values("Vary"))
+	}
+}
+
+func TestPaymentPageTakesMessageTitle(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"default":   {WithPaymentPage(PaymentPage{})},
+		"localized": {WithPaymentPage(PaymentPage{}), WithMessageProvider(testMessages(t))},
+	} {
+		req := pageRequest("/resource", browserAccept)
+		req.Header.Set("Accept-Language", "pt-BR")
+		rec := httptest.NewRecorder()
+		NewX402Middleware("http://facilitator.test", opts...).Protect(http.NotFoundHandler(), fixturePriceTag()).ServeHTTP(rec, req)
+
+		want := "<h1>Payment required</h1>"
+		if name == "localized" {
+			want = "<h1>Assine por 0.01 USDC por artigo</h1>"
+		}
+		if body := rec.Body.String(); !strings.Contains(body, want) {
+			t.Errorf("%s: page lacks %q", name, want)
+		}
+	}
+}
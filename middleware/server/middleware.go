@@ -1,40 +1,232 @@
 package server
 
 import (
-	"bytes"
+	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/transport"
 	"github.com/x402-rs/x402-go/pkg/types"
 )
 
 // X402Middleware provides payment protection for HTTP handlers
 type X402Middleware struct {
-	facilitatorURL string
-	client         *http.Client
+	facilitators  []*facilitatorEndpoint // Primary first (see WithFallbackFacilitators)
+	client        *http.Client
+	unpaidMethods map[string]bool // Methods served without payment
+
+	// Settlement after the protected handler succeeds (see WithSettleAfterSuccess)
+	settleAfterSuccess bool
+	onPaymentSettled   func(r *http.Request, resp *types.SettleResponse, err error)
+
+	// Single-use 402 challenges (nil unless WithChallenge)
+	challenges *challengeStore
+
+	// Largest X-Payment-Required value before it is replaced by a body reference
+	requirementsHeaderLimit int
+
+	// Add Vary on the payment headers to paid responses (see WithVaryOnPaidResponses)
+	varyOnPaid bool
+
+	// Key signing outbound requirements (nil unless WithRequirementsSigner)
+	requirementsKey *ecdsa.PrivateKey
+
+	// Inbound payment header names, tried in order, and the outbound
+	// requirements header (see WithPaymentHeader, WithRequirementsHeader)
+	paymentHeaders     []string
+	requirementsHeader string
+
+	// Largest accepted payment header value (see WithPaymentHeaderLimit)
+	paymentHeaderLimit int
+
+	// Facilitator connection tuning and TLS (see WithTransport, tls.go)
+	transportConfig transport.Config
+	facilitatorTLS  []transport.TLSOption
+
+	// Largest accepted payment query parameter; 0 unless WithQueryPayment
+	queryPaymentLimit int
+
+	// HTML 402 for browsers and its outstanding poll tokens (nil unless WithPaymentPage)
+	page  *PaymentPage
+	pages *pageStore
+
+	// Title and description of 402s (nil unless WithMessageProvider)
+	messages MessageProvider
+
+	// Paid response checks against the output schema (see WithResponseValidation)
+	responseValidation ResponseValidation
+
+	// Testnet/mainnet pin checked by Protect (see WithEnvironmentProfile)
+	profile EnvironmentProfile
+
+	// Price quote lifetime and ID key (zero unless WithQuoteTTL)
+	quoteTTL time.Duration
+	quoteKey []byte
+
+	// Verify retries and facilitator circuit breaker (see breaker.go)
+	verifyRetries    int
+	verifyRetryDelay time.Duration
+	verifyDeadline   time.Duration
+	breaker          *circuitBreaker // nil unless WithCircuitBreaker
+	failOpen         bool
+	onBreakerChange  func(from, to BreakerState)
+	servedOpen       atomic.Uint64
+}
+
+// DefaultRequirementsHeaderLimit keeps X-Payment-Required well under the
+// 8KB header limits common in proxies
+const DefaultRequirementsHeaderLimit = 4096
+
+// Option configures an X402Middleware
+type Option func(*X402Middleware)
+
+// WithAllowUnpaidMethods lets requests with the given HTTP methods through
+// without payment, in addition to OPTIONS (e.g. serve GET for free, charge POST)
+func WithAllowUnpaidMethods(methods ...string) Option {
+	return func(m *X402Middleware) {
+		for _, method := range methods {
+			m.unpaidMethods[strings.ToUpper(method)] = true
+		}
+	}
+}
+
+// WithSettleAfterSuccess settles each verified payment once the protected
+// handler has responded with a status below 400; failed handlers are not
+// charged and their authorization simply expires
+func WithSettleAfterSuccess() Option {
+	return func(m *X402Middleware) {
+		m.settleAfterSuccess = true
+	}
+}
+
+// WithOnPaymentSettled registers a callback invoked with every settlement
+// outcome, including those that can no longer be reported in a header
+// because the handler already flushed its response
+func WithOnPaymentSettled(fn func(r *http.Request, resp *types.SettleResponse, err error)) Option {
+	return func(m *X402Middleware) {
+		m.onPaymentSettled = fn
+	}
+}
+
+// WithRequirementsHeaderLimit sets the largest X-Payment-Required header value
+// in bytes; above it the header only says "see body"
+func WithRequirementsHeaderLimit(bytes int) Option {
+	return func(m *X402Middleware) {
+		m.requirementsHeaderLimit = bytes
+	}
+}
+
+// WithPaymentHeaderLimit sets the largest payment header value in bytes
+// (default types.DefaultPaymentHeaderLimit); larger ones are refused with a
+// 400 before they are parsed
+func WithPaymentHeaderLimit(bytes int) Option {
+	return func(m *X402Middleware) {
+		m.paymentHeaderLimit = bytes
+	}
+}
+
+// WithTransport tunes connection pooling for requests to the facilitator
+func WithTransport(cfg transport.Config) Option {
+	return func(m *X402Middleware) {
+		m.transportConfig = cfg
+		m.client.Transport = cfg.RoundTripper()
+	}
+}
+
+// WithVaryOnPaidResponses adds Vary on the payment headers to successful paid
+// responses so shared caches never serve paid content to non-payers
+func WithVaryOnPaidResponses() Option {
+	return func(m *X402Middleware) {
+		m.varyOnPaid = true
+	}
+}
+
+// WithPaymentHeader accepts payments only under the given header names, tried
+// in order (default: every name in types.PaymentHeaderAliases)
+func WithPaymentHeader(names ...string) Option {
+	return func(m *X402Middleware) {
+		if len(names) > 0 {
+			m.paymentHeaders = names
+		}
+	}
+}
+
+// WithRequirementsHeader sends 402 requirements under name instead of
+// X-Payment-Required
+func WithRequirementsHeader(name string) Option {
+	return func(m *X402Middleware) {
+		if name != "" {
+			m.requirementsHeader = name
+		}
+	}
 }
 
 // NewX402Middleware creates a new middleware instance
-func NewX402Middleware(facilitatorURL string) *X402Middleware {
-	return &X402Middleware{
-		facilitatorURL: strings.TrimSuffix(facilitatorURL, "/"),
+// By default every method except OPTIONS requires payment
+func NewX402Middleware(facilitatorURL string, opts ...Option) *X402Middleware {
+	m := &X402Middleware{
+		facilitators: []*facilitatorEndpoint{newFacilitatorEndpoint(facilitatorURL)},
 		client: &http.Client{
-			Timeout: 30 * time.Second, // Prevent indefinite hangs
+			Timeout:   30 * time.Second, // Prevent indefinite hangs
+			Transport: transport.DefaultConfig().RoundTripper(),
+		},
+		unpaidMethods: map[string]bool{
+			http.MethodOptions: true, // CORS preflights never carry payment
 		},
+		requirementsHeaderLimit: DefaultRequirementsHeaderLimit,
+		paymentHeaders:          types.PaymentHeaderAliases,
+		paymentHeaderLimit:      types.DefaultPaymentHeaderLimit,
+		requirementsHeader:      types.HeaderPaymentRequired,
+		verifyRetries:           DefaultVerifyRetries,
+		verifyRetryDelay:        DefaultVerifyRetryDelay,
+		verifyDeadline:          DefaultVerifyDeadline,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.applyFacilitatorTLS()
+	return m
+}
+
+// PaymentHeaders returns the request headers a payment is accepted under,
+// for CORS allow-lists (Access-Control-Allow-Headers)
+func (m *X402Middleware) PaymentHeaders() []string {
+	return append([]string(nil), m.paymentHeaders...)
+}
+
+// RequirementsHeader returns the 402 requirements header name, for CORS
+// Access-Control-Expose-Headers
+func (m *X402Middleware) RequirementsHeader() string {
+	return m.requirementsHeader
 }
 
 // PriceTag represents payment requirements for a route
 type PriceTag struct {
 	Requirements types.PaymentRequirements
+
+	// Caps payment verification for this route, below the middleware's
+	// verify deadline (0 uses the deadline alone)
+	VerifyTimeout time.Duration
+
+	err error // Builder failure, reported by CheckPriceTag
 }
 
 // NewPriceTag creates a new price tag
+// EVM addresses may be given with the xdc prefix; asset defaults to token
 func NewPriceTag(network types.Network, amount, tokenSymbol string, payTo, token types.MixedAddress, resource, description, mimeType string, maxTimeoutSeconds int, asset types.MixedAddress, outputSchema json.RawMessage) *PriceTag {
+	if asset.Address == "" {
+		asset = token
+	}
+	if payTo.Type != "solana" {
+		payTo.Address = types.NormalizeEVMAddress(payTo.Address)
+	}
 	return &PriceTag{
 		Requirements: types.PaymentRequirements{
 			Version:           types.X402VersionV1,
@@ -46,104 +238,309 @@ func NewPriceTag(network types.Network, amount, tokenSymbol string, payTo, token
 			Description:       description,
 			MimeType:          mimeType,
 			MaxTimeoutSeconds: maxTimeoutSeconds,
-			Asset:             common.HexToAddress(asset.Address),
+			Asset:             common.HexToAddress(types.NormalizeEVMAddress(asset.Address)),
 			OutputSchema:      outputSchema,
 		},
 	}
 }
 
 // Protect wraps an HTTP handler with payment verification
+// It panics if priceTag does not fit the environment profile (see
+// WithEnvironmentProfile), so a misconfigured route fails at startup.
 func (m *X402Middleware) Protect(next http.Handler, priceTag *PriceTag) http.Handler {
+	if err := m.CheckPriceTag(priceTag); err != nil {
+		panic(fmt.Sprintf("x402: refusing price tag: %v", err))
+	}
+	unpaid := next
+	outputSchema, _ := priceTag.Requirements.ParseOutputSchema()
+	next = m.validateResponses(next, outputSchema)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check for payment header
-		paymentHeader := r.Header.Get("X-Payment-Payload")
+		// Unpaid methods (OPTIONS preflights by default) pass straight through
+		if m.unpaidMethods[r.Method] {
+			unpaid.ServeHTTP(w, r)
+			return
+		}
+
+		// 402s are rendered in the wire version the client asks for
+		version := requestedVersion(r)
+
+		// Requirements name the requested resource so a payment made for
+		// this route cannot be presented to another
+		baseRequirements := resourceRequirements(r, &priceTag.Requirements)
+
+		// 402s carry the route's copy, whose description the offered
+		// requirements take if they have none
+		message := m.paymentMessage(r, priceTag)
+		offer := func() *types.PaymentRequirements {
+			return message.describe(m.offerRequirements(r, baseRequirements))
+		}
+
+		// Check for payment header, then the query parameter if
+		// WithQueryPayment is on (which strips it from the URL in any case)
+		// Repeated or oversized payment headers are refused unparsed
+		if err := types.CheckPaymentHeaders(r.Header, m.paymentHeaders, m.paymentHeaderLimit); err != nil {
+			sendPaymentHeaderError(w, err)
+			return
+		}
+		paymentHeader, _ := types.PaymentHeaderValue(r.Header, m.paymentHeaders)
+		queryPayment, err := m.takeQueryPayment(r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid payment payload: %v", err), http.StatusBadRequest)
+			return
+		}
+		fromQuery := paymentHeader == "" && queryPayment != ""
+		if fromQuery {
+			paymentHeader = queryPayment
+		}
+
+		// A payment page polling or reloading after a wallet payment
+		if m.pages != nil {
+			if token := takeQueryParam(r, types.PaymentPageParam); token != "" && paymentHeader == "" {
+				if m.servePagePoll(w, r, next, version, message, token) {
+					return
+				}
+			}
+		}
+
 		if paymentHeader == "" {
 			// No payment provided, return 402 Payment Required
-			m.send402(w, &priceTag.Requirements)
+			// HEAD probes get the 402 headers without a body, browsers
+			// the payment page if there is one
+			requirements := offer()
+			setPaymentAuthenticate(w, requirements)
+			if r.Method == http.MethodHead {
+				m.send402Headers(w, version, requirements)
+				return
+			}
+			if m.page != nil && r.Method == http.MethodGet && wantsHTML(r) {
+				m.offerPaymentPage(w, r, version, requirements, message)
+				return
+			}
+			m.send402(w, version, requirements, message)
 			return
 		}
 
 		// Parse payment payload
-		var payload types.PaymentPayload
-		if err := json.Unmarshal([]byte(paymentHeader), &payload); err != nil {
+		payload, err := parsePaymentPayload([]byte(paymentHeader))
+		if err != nil {
 			http.Error(w, fmt.Sprintf("invalid payment payload: %v", err), http.StatusBadRequest)
 			return
 		}
 
+		// A payment URL can be copied, so it must commit to this resource
+		if fromQuery {
+			if err := types.CheckResourceBinding(payload, baseRequirements); err != nil {
+				m.send402WithReason(w, version, offer(), message, fmt.Sprintf("query payment is not bound to the resource: %v", err), types.ReasonResourceMismatch)
+				return
+			}
+		}
+
+		// A payment against a lapsed quote must be re-priced
+		if reason := m.checkQuote(payload, baseRequirements); reason != "" {
+			m.send402WithReason(w, version, offer(), message, reason, types.ReasonQuoteExpired)
+			return
+		}
+
+		// In challenge mode the payment must answer a fresh challenge for this resource
+		if reason := m.checkChallenge(r, payload); reason != "" {
+			m.send402WithReason(w, version, offer(), message, reason, types.ReasonChallengeFailed)
+			return
+		}
+
 		// Verify payment with facilitator
 		verifyReq := types.VerifyRequest{
-			PaymentPayload:      payload,
-			PaymentRequirements: priceTag.Requirements,
+			PaymentPayload:      *payload,
+			PaymentRequirements: *baseRequirements,
 		}
 
-		verifyResp, err := m.verifyPayment(&verifyReq)
+		// A settlement ID the client chose goes to the facilitator, which
+		// assigns one otherwise
+		ctx := r.Context()
+		if id := r.Header.Get(types.HeaderIdempotencyKey); id != "" {
+			if err := types.ValidateSettlementID(id); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			ctx = types.WithSettlementID(ctx, id)
+		}
+
+		verified, err := m.verifyPayment(ctx, &verifyReq, priceTag.VerifyTimeout)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("payment verification failed: %v", err), http.StatusInternalServerError)
+			m.facilitatorUnavailable(w, r, next, err)
 			return
 		}
 
-		if !verifyResp.IsValid {
+		if !verified.IsValid {
 			// Payment invalid, return 402 with reason
-			m.send402WithReason(w, &priceTag.Requirements, verifyResp.Reason)
+			m.send402WithReason(w, version, offer(), message, verified.Reason, verified.ReasonCode)
 			return
 		}
 
 		// Payment valid, call next handler
-		next.ServeHTTP(w, r)
+		if m.varyOnPaid {
+			w.Header().Add("Vary", strings.Join(m.paymentHeaders, ", "))
+		}
+		if !m.settleAfterSuccess {
+			next.ServeHTTP(w, r)
+			return
+		}
+		m.serveAndSettle(w, r, next, *payload, baseRequirements, message, verified)
 	})
 }
 
-// verifyPayment calls the facilitator to verify a payment
-func (m *X402Middleware) verifyPayment(req *types.VerifyRequest) (*types.VerifyResponse, error) {
-	// Marshal request
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+// resourceRequirements returns requirements with Resource set to the request
+// URL, unless the price tag already names its resource
+func resourceRequirements(r *http.Request, requirements *types.PaymentRequirements) *types.PaymentRequirements {
+	if requirements.Resource != "" {
+		return requirements
 	}
+	withResource := *requirements
+	withResource.Resource = requestResource(r)
+	return &withResource
+}
 
-	// Call facilitator
-	resp, err := m.client.Post(
-		m.facilitatorURL+"/verify",
-		"application/json",
-		bytes.NewReader(body),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("facilitator request failed: %w", err)
+// requestResource returns the request URL without its query (scheme, host and path)
+// X-Forwarded-Proto is honored so resources behind a TLS proxy read https
+func requestResource(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.EscapedPath()
+}
+
+// requestedVersion returns the x402 wire version named in X-Payment-Version (default 1)
+func requestedVersion(r *http.Request) int {
+	if strings.TrimSpace(r.Header.Get("X-Payment-Version")) == "2" {
+		return 2
+	}
+	return 1
+}
+
+// parsePaymentPayload decodes a v1 or v2 payment header value
+func parsePaymentPayload(data []byte) (*types.PaymentPayload, error) {
+	if types.DetectX402Version(data) == 2 {
+		var v2 types.PaymentPayloadV2
+		if err := json.Unmarshal(data, &v2); err != nil {
+			return nil, err
+		}
+		payload, err := v2.ToV1()
+		if err != nil {
+			return nil, err
+		}
+		return &payload, nil
 	}
-	defer resp.Body.Close()
 
-	// Parse response
-	var verifyResp types.VerifyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&verifyResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	var payload types.PaymentPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
 	}
+	return &payload, nil
+}
 
-	return &verifyResp, nil
+// wireRequirements renders requirements in the given wire version
+func wireRequirements(version int, requirements *types.PaymentRequirements) interface{} {
+	if version == 2 {
+		return requirements.ToV2()
+	}
+	return requirements
 }
 
 // send402 sends a 402 Payment Required response
-func (m *X402Middleware) send402(w http.ResponseWriter, requirements *types.PaymentRequirements) {
-	m.send402WithReason(w, requirements, "")
+func (m *X402Middleware) send402(w http.ResponseWriter, version int, requirements *types.PaymentRequirements, message PaymentMessage) {
+	m.send402WithReason(w, version, requirements, message, "", "")
+}
+
+// send402Headers sends a bodyless 402 Payment Required response (for HEAD)
+// Without a body the header carries the full requirements if they fit
+func (m *X402Middleware) send402Headers(w http.ResponseWriter, version int, requirements *types.PaymentRequirements) {
+	requirements = m.signRequirements(w, requirements)
+	m.set402Headers(w, version, wireRequirements(version, requirements))
+	setQuoteHeaders(w, requirements)
+	w.WriteHeader(http.StatusPaymentRequired)
+}
+
+// set402Headers sets the headers shared by all 402 responses
+// header is the X-Payment-Required document (the full requirements or a summary)
+func (m *X402Middleware) set402Headers(w http.ResponseWriter, version int, header interface{}) {
+	value := types.RequirementsHeaderRef
+	if headerJSON, err := json.Marshal(header); err == nil && len(headerJSON) <= m.requirementsHeaderLimit {
+		value = string(headerJSON)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(m.requirementsHeader, value)
+	w.Header().Set("X-Payment-Version", fmt.Sprintf("%d", version))
+
+	// A 402 depends on the payment header and must never be served from cache
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Add("Vary", strings.Join(m.paymentHeaders, ", "))
+	if m.messages != nil {
+		w.Header().Add("Vary", "Accept-Language")
+	}
 }
 
-// send402WithReason sends a 402 Payment Required response with a reason
-func (m *X402Middleware) send402WithReason(w http.ResponseWriter, requirements *types.PaymentRequirements, reason string) {
-	// Marshal requirements
-	reqJSON, _ := json.Marshal(requirements)
+// setPaymentAuthenticate advertises the accepted scheme on a bare 402, in the
+// style of WWW-Authenticate (e.g. X-Payment: x402 scheme="exact", network="base")
+func setPaymentAuthenticate(w http.ResponseWriter, requirements *types.PaymentRequirements) {
+	w.Header().Set("X-Payment", fmt.Sprintf(`x402 scheme="%s", network="%s"`, requirements.Scheme, requirements.Network))
+}
 
-	// Set headers
+// sendPaymentHeaderError answers a payment header rejected by
+// types.CheckPaymentHeaders with a 400 carrying its reason code
+func sendPaymentHeaderError(w http.ResponseWriter, err error) {
+	response := map[string]interface{}{"error": fmt.Sprintf("invalid payment header: %v", err)}
+	var headerErr *types.PaymentHeaderError
+	if errors.As(err, &headerErr) {
+		response["reasonCode"] = headerErr.Code
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("X-Payment-Required", string(reqJSON))
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+}
+
+// send402WithReason sends a 402 Payment Required response with the route's
+// copy, a reason and its code
+func (m *X402Middleware) send402WithReason(w http.ResponseWriter, version int, requirements *types.PaymentRequirements, message PaymentMessage, reason string, code types.ReasonCode) {
+	// Set headers; the full document is in the body, the header has a summary
+	requirements = m.signRequirements(w, requirements)
+	m.set402Headers(w, version, requirements.Summary(version))
+	quote := setQuoteHeaders(w, requirements)
 	w.WriteHeader(http.StatusPaymentRequired)
 
 	// Response body
 	response := map[string]interface{}{
 		"error":                "payment required",
-		"payment_requirements": requirements,
+		"payment_requirements": wireRequirements(version, requirements),
+	}
+	if extra, err := types.ParseExtra(requirements.Extra); err == nil && extra.FeeBreakdown != nil {
+		response["fee_breakdown"] = extra.FeeBreakdown
+	}
+	if version == 2 {
+		response["x402Version"] = 2
+		if resource := requirements.ResourceInfoV2(); resource != nil {
+			response["resource"] = resource
+		}
+	}
+	if message.Title != "" {
+		response["title"] = message.Title
+	}
+	if message.Description != "" {
+		response["description"] = message.Description
+	}
+	if quote != nil {
+		response["quoteId"] = quote.ID
+		response["quoteExpiresAt"] = quote.ExpiresAt
 	}
 	if reason != "" {
 		response["reason"] = reason
 	}
+	if code != "" {
+		response["reasonCode"] = code
+	}
 
 	json.NewEncoder(w).Encode(response)
 }
@@ -161,8 +558,13 @@ type PriceTagBuilder struct {
 	description       string
 	mimeType          string
 	maxTimeoutSeconds int
+	minTimeoutSeconds int
 	asset             types.MixedAddress
-	extra             json.RawMessage
+	outputSchema      json.RawMessage
+	schemaErr         error
+	fee               *types.FeePolicy
+	splits            []types.PayoutSplit
+	verifyTimeout     time.Duration
 }
 
 // NewPriceTagBuilder creates a new builder
@@ -200,7 +602,104 @@ func (b *PriceTagBuilder) Token(addr types.MixedAddress) *PriceTagBuilder {
 	return b
 }
 
+// Resource sets the URL of the paid resource
+// Left empty, each request's URL (scheme, host and path) is used
+func (b *PriceTagBuilder) Resource(url string) *PriceTagBuilder {
+	b.resource = url
+	return b
+}
+
+// Splits divides the payment between several recipients (bps must sum to 10000)
+// PayTo must be set to the facilitator's splitter contract for the network
+func (b *PriceTagBuilder) Splits(splits ...types.PayoutSplit) *PriceTagBuilder {
+	b.splits = splits
+	return b
+}
+
+// WithFacilitatorFee adds the facilitator's surcharge on top of the amount
+// The fee breakdown is carried in the requirements' extra field
+func (b *PriceTagBuilder) WithFacilitatorFee(policy types.FeePolicy) *PriceTagBuilder {
+	b.fee = &policy
+	return b
+}
+
+// OutputSchema sets the JSON Schema (draft-07) of the resource's response
+// body, advertised in the 402 so clients know what they are paying for.
+// schema is a JSON document (json.RawMessage or []byte) or a value that
+// marshals to one, e.g. a map[string]interface{}; a schema that is not
+// well-formed makes Protect refuse the price tag.
+func (b *PriceTagBuilder) OutputSchema(schema interface{}) *PriceTagBuilder {
+	var data []byte
+	switch v := schema.(type) {
+	case json.RawMessage:
+		data = v
+	case []byte:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(schema); err != nil {
+			b.outputSchema, b.schemaErr = nil, fmt.Errorf("output schema: %w", err)
+			return b
+		}
+	}
+	parsed, err := types.ParseJSONSchema(data)
+	if err != nil {
+		b.outputSchema, b.schemaErr = nil, fmt.Errorf("output schema: %w", err)
+		return b
+	}
+	b.outputSchema, b.schemaErr = parsed.Raw(), nil
+	return b
+}
+
+// MaxTimeout sets the longest validity window, in seconds, payers may sign
+// (0 for no limit)
+func (b *PriceTagBuilder) MaxTimeout(seconds int) *PriceTagBuilder {
+	b.maxTimeoutSeconds = seconds
+	return b
+}
+
+// MinTimeout sets the shortest validity window, in seconds, payers may sign
+// (0 leaves it to the facilitator's default); shorter payments are refused
+// with validity_too_short. Protect refuses a tag whose minimum exceeds its
+// MaxTimeout.
+func (b *PriceTagBuilder) MinTimeout(seconds int) *PriceTagBuilder {
+	b.minTimeoutSeconds = seconds
+	return b
+}
+
+// WithVerifyTimeout caps payment verification for the route at d, e.g. to
+// stay under an upstream gateway's timeout; a request that runs out of time
+// gets a 504
+func (b *PriceTagBuilder) WithVerifyTimeout(d time.Duration) *PriceTagBuilder {
+	b.verifyTimeout = d
+	return b
+}
+
 // Build creates the price tag
 func (b *PriceTagBuilder) Build() *PriceTag {
-	return NewPriceTag(b.network, b.amount, b.tokenSymbol, b.payTo, b.token, b.resource, b.description, b.mimeType, b.maxTimeoutSeconds, b.asset, b.extra)
+	tag := NewPriceTag(b.network, b.amount, b.tokenSymbol, b.payTo, b.token, b.resource, b.description, b.mimeType, b.maxTimeoutSeconds, b.asset, b.outputSchema)
+	if len(b.splits) > 0 {
+		if extra, err := types.MergeExtra(tag.Requirements.Extra, types.RequirementsExtra{Splits: b.splits}); err == nil {
+			tag.Requirements.Extra = extra
+		}
+	}
+	var feeErr error
+	if b.fee != nil {
+		if err := b.fee.ApplyFee(&tag.Requirements); err != nil {
+			feeErr = fmt.Errorf("price tag for %s: facilitator fee: %w", b.network, err)
+		}
+	}
+	tag.Requirements.MinTimeoutSeconds = b.minTimeoutSeconds
+	tag.VerifyTimeout = b.verifyTimeout
+	tag.err = b.schemaErr
+	if tag.err == nil {
+		tag.err = feeErr
+	}
+	if tag.err == nil {
+		tag.err = checkTimeouts(&tag.Requirements)
+	}
+	if b.payTo.Type != "solana" && common.HexToAddress(tag.Requirements.PayTo) == (common.Address{}) {
+		log.Printf("x402: warning: price tag for %s pays the zero address; payments to it are burned", b.network)
+	}
+	return tag
 }
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// served records whether a request reached the protected handler
+func served(hit *bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hit = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func TestProtectLetsPreflightsThrough(t *testing.T) {
+	var hit bool
+	handler := NewX402Middleware("http://facilitator.test").Protect(served(&hit), testPriceTag(0, 300))
+
+	req := httptest.NewRequest(http.MethodOptions, "/resource", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	req.Header.Set("Access-Control-Request-Headers", "X-Payment, Content-Type")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !hit || rec.Code != http.StatusNoContent {
+		t.Errorf("preflight: status %d, reached %v; want it served unpaid", rec.Code, hit)
+	}
+}
+
+func TestProtectAnswersHeadWithBodyless402(t *testing.T) {
+	var hit bool
+	handler := NewX402Middleware("http://facilitator.test").Protect(served(&hit), testPriceTag(0, 300))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/resource", nil))
+	if hit {
+		t.Error("unpaid HEAD reached the handler")
+	}
+	if rec.Code != http.StatusPaymentRequired {
+		t.Errorf("HEAD: status %d, want %d", rec.Code, http.StatusPaymentRequired)
+	}
+	if rec.Header().Get("X-Payment-Required") == "" {
+		t.Error("HEAD 402 carries no X-Payment-Required header")
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("HEAD 402 has a %d byte body", rec.Body.Len())
+	}
+
+	// GET still gets the full 402
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resource", nil))
+	if rec.Code != http.StatusPaymentRequired || rec.Body.Len() == 0 {
+		t.Errorf("GET: status %d with a %d byte body, want a 402 with a body", rec.Code, rec.Body.Len())
+	}
+}
+
+func TestWithAllowUnpaidMethods(t *testing.T) {
+	m := NewX402Middleware("http://facilitator.test", WithAllowUnpaidMethods("get"))
+	for method, free := range map[string]bool{
+		http.MethodGet:     true,
+		http.MethodOptions: true,
+		http.MethodPost:    false,
+		http.MethodHead:    false,
+	} {
+		var hit bool
+		rec := httptest.NewRecorder()
+		m.Protect(served(&hit), testPriceTag(0, 300)).ServeHTTP(rec, httptest.NewRequest(method, "/resource", nil))
+		if hit != free {
+			t.Errorf("%s: reached %v (status %d), want %v", method, hit, rec.Code, free)
+		}
+		if !free && rec.Code != http.StatusPaymentRequired {
+			t.Errorf("%s: status %d, want %d", method, rec.Code, http.StatusPaymentRequired)
+		}
+	}
+}
+
+func TestProtectRenders402InRequestedVersion(t *testing.T) {
+	handler := NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), fixturePriceTag())
+	want := x402test.Requirements()
+
+	for _, requested := range []string{"", "1", "2", "3"} {
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		if requested != "" {
+			req.Header.Set("X-Payment-Version", requested)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var body struct {
+			X402Version  int             `json:"x402Version"`
+			Requirements json.RawMessage `json:"payment_requirements"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		version := rec.Header().Get("X-Payment-Version")
+		if requested == "2" {
+			var v2 types.PaymentRequirementsV2
+			if err := json.Unmarshal(body.Requirements, &v2); err != nil {
+				t.Fatal(err)
+			}
+			if version != "2" || body.X402Version != 2 || v2.Network != want.Network.CAIP2() || v2.Amount != want.MaxAmountRequired {
+				t.Errorf("v2 requested: version %s/%d, network %q, amount %q", version, body.X402Version, v2.Network, v2.Amount)
+			}
+			continue
+		}
+		var v1 types.PaymentRequirements
+		if err := json.Unmarshal(body.Requirements, &v1); err != nil {
+			t.Fatal(err)
+		}
+		if version != "1" || v1.Network != want.Network || v1.MaxAmountRequired != want.MaxAmountRequired {
+			t.Errorf("version %q requested: got version %s, network %q, amount %q; want v1", requested, version, v1.Network, v1.MaxAmountRequired)
+		}
+	}
+}
+
+func TestPriceTagFacilitatorFeeIn402(t *testing.T) {
+	tag := NewPriceTagBuilder().
+		Network("base-sepolia").
+		Amount("10000").
+		PayTo(types.MixedAddress{Type: "evm", Address: "0x00000000000000000000000000000000000000b0"}).
+		WithFacilitatorFee(types.FeePolicy{FlatAmount: big.NewInt(100)}).
+		Build()
+	if tag.Requirements.MaxAmountRequired != "10100" {
+		t.Errorf("MaxAmountRequired = %s, want the price plus the fee", tag.Requirements.MaxAmountRequired)
+	}
+
+	rec := httptest.NewRecorder()
+	NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), tag).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resource", nil))
+	var body struct {
+		FeeBreakdown *types.FeeBreakdown `json:"fee_breakdown"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if b := body.FeeBreakdown; b == nil || b.ResourceAmount != "10000" || b.FacilitatorFee != "100" || b.Total != "10100" {
+		t.Errorf("402 fee breakdown %+v", body.FeeBreakdown)
+	}
+}
+
+func TestPriceTagRefusesFeeOnInvalidAmount(t *testing.T) {
+	tag := NewPriceTagBuilder().
+		Network("base-sepolia").
+		Amount("1.5").
+		PayTo(types.MixedAddress{Type: "evm", Address: "0x00000000000000000000000000000000000000b0"}).
+		WithFacilitatorFee(types.FeePolicy{FlatAmount: big.NewInt(100)}).
+		Build()
+	err := NewX402Middleware("http://facilitator.test").CheckPriceTag(tag)
+	if err == nil || !strings.Contains(err.Error(), "facilitator fee") {
+		t.Errorf("CheckPriceTag = %v, want a facilitator fee error", err)
+	}
+}
+
+func TestPriceTagWarnsOfZeroPayTo(t *testing.T) {
+	for payTo, warned := range map[string]bool{
+		"0x0000000000000000000000000000000000000000": true,
+		"0x00000000000000000000000000000000000000b0": false,
+	} {
+		logs := captureLog(t)
+		NewPriceTagBuilder().
+			Network("base-sepolia").
+			Amount("10000").
+			PayTo(types.MixedAddress{Type: "evm", Address: payTo}).
+			Build()
+		if got := strings.Contains(logs.String(), "pays the zero address"); got != warned {
+			t.Errorf("payTo %s: warned %v (%q), want %v", payTo, got, logs.String(), warned)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package server
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"log"
+	"math/big"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/qr"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultPaymentPageTTL is how long a payment page keeps polling
+const DefaultPaymentPageTTL = 15 * time.Minute
+
+// maxPaymentPages bounds the outstanding page tokens; the oldest is dropped
+// when browsers keep opening pages they never pay
+const maxPaymentPages = 10000
+
+// PaymentObserver reports the transaction hash of a direct wallet payment
+// of requirements made since the page with token was shown, or "" if none
+// has been seen yet. Payments from a wallet link are plain token transfers
+// the facilitator never sees, so the server has to find them itself (e.g. a
+// Transfer log to PayTo of the amount); one observer call is made per poll.
+// A transaction pays for one page only: a hash already credited to another
+// token is ignored, so observers that can tell payments apart (e.g. by a
+// reference recorded per token) should report the one made for token.
+type PaymentObserver func(ctx context.Context, token string, requirements *types.PaymentRequirements, since time.Time) (txHash string, err error)
+
+// PaymentPage configures the HTML 402 shown to browsers (see WithPaymentPage)
+type PaymentPage struct {
+	// Page template, executed with a PaymentPageData (default
+	// DefaultPaymentPageTemplate)
+	Template *template.Template
+	// Finds wallet payments; without one the page shows how to pay but
+	// cannot tell when it is done
+	Observer PaymentObserver
+	// How long a page polls (default DefaultPaymentPageTTL)
+	TTL time.Duration
+}
+
+// PaymentPageData is what a payment page template renders
+type PaymentPageData struct {
+	Requirements *types.PaymentRequirements
+	Price        string // Amount in whole tokens with the symbol, e.g. "0.01 USDC"
+	Network      types.Network
+	ChainID      uint64
+	PayTo        string
+	Asset        string
+	Resource     string
+	Title        string // "Payment required" unless the route's copy has a title (see WithMessageProvider)
+	Description  string
+	// EIP-681 wallet link ("" if the network has none), and its QR code
+	// as an inline SVG
+	WalletLink template.URL
+	QRCode     template.HTML
+	// URL the page polls with Accept: application/json for {"status":
+	// "pending"|"paid"|"expired"} and reloads once paid, until ExpiresAt;
+	// "" without an observer
+	PollURL   string
+	ExpiresAt time.Time
+}
+
+// WithPaymentPage answers GET requests preferring text/html over JSON with
+// a payment page instead of the JSON 402: the price, network and
+// recipient, a wallet link (see PaymentRequirements.WalletLink) and its QR
+// code. With an Observer the page polls the resource under a single-use
+// token (types.PaymentPageParam) and reloads it once the payment is seen,
+// which serves the resource to that page without an x402 payment header.
+// API clients, which ask for JSON or anything, keep the JSON 402.
+func WithPaymentPage(page PaymentPage) Option {
+	return func(m *X402Middleware) {
+		if page.Template == nil {
+			page.Template = DefaultPaymentPageTemplate
+		}
+		if page.TTL <= 0 {
+			page.TTL = DefaultPaymentPageTTL
+		}
+		m.page = &page
+		m.pages = newPageStore(page.TTL)
+	}
+}
+
+// pageStore tracks outstanding payment page tokens until they are redeemed
+// or expire; tokens share a TTL, so issue order is also expiry order
+// It also remembers the transactions credited to a token for a TTL, by
+// when every page shown before one was paid has expired, so one payment
+// never unlocks two pages.
+type pageStore struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	order   *list.List // *pageEntry, oldest first
+	entries map[string]*list.Element
+	spent   *list.List        // *spentTx, oldest first
+	txs     map[string]string // Token credited with each spent transaction, by lowercased hash
+}
+
+// spentTx is a transaction credited to a page
+type spentTx struct {
+	hash      string // Lowercased
+	expiresAt time.Time
+}
+
+func newPageStore(ttl time.Duration) *pageStore {
+	return &pageStore{
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		spent:   list.New(),
+		txs:     make(map[string]string),
+	}
+}
+
+type pageEntry struct {
+	token        string
+	resource     string // challengeResource of the page's request
+	requirements *types.PaymentRequirements
+	issuedAt     time.Time
+	expiresAt    time.Time
+	txHash       string // Set once the observer has seen the payment
+}
+
+// issue records a page for requirements shown at resource
+func (s *pageStore) issue(resource string, requirements *types.PaymentRequirements) (*pageEntry, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entry := &pageEntry{
+		token:        hex.EncodeToString(buf),
+		resource:     resource,
+		requirements: requirements,
+		issuedAt:     now,
+		expiresAt:    now.Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for front := s.order.Front(); front != nil; front = s.order.Front() {
+		if now.Before(front.Value.(*pageEntry).expiresAt) && s.order.Len() < maxPaymentPages {
+			break
+		}
+		s.order.Remove(front)
+		delete(s.entries, front.Value.(*pageEntry).token)
+	}
+	s.entries[entry.token] = s.order.PushBack(entry)
+	return entry, nil
+}
+
+// lookup returns the live page with token issued for resource, or nil
+func (s *pageStore) lookup(token, resource string) *pageEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[token]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*pageEntry)
+	if entry.resource != resource || !time.Now().Before(entry.expiresAt) {
+		return nil
+	}
+	return entry
+}
+
+// paid reports the transaction hash of entry's payment, asking the
+// observer if it has not been seen yet
+func (s *pageStore) paid(ctx context.Context, entry *pageEntry, observer PaymentObserver) string {
+	s.mu.Lock()
+	txHash := entry.txHash
+	s.mu.Unlock()
+	if txHash != "" || observer == nil {
+		return txHash
+	}
+
+	txHash, err := observer(ctx, entry.token, entry.requirements, entry.issuedAt)
+	if err != nil {
+		log.Printf("x402: payment observer failed: %v", err)
+		return ""
+	}
+	if txHash == "" {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry.txHash != "" {
+		return entry.txHash // A concurrent poll credited it first
+	}
+	now := time.Now()
+	for front := s.spent.Front(); front != nil && !now.Before(front.Value.(*spentTx).expiresAt); front = s.spent.Front() {
+		s.spent.Remove(front)
+		delete(s.txs, front.Value.(*spentTx).hash)
+	}
+	hash := strings.ToLower(txHash)
+	if token, ok := s.txs[hash]; ok && token != entry.token {
+		log.Printf("x402: payment page ignored transaction %s, already credited to another page", txHash)
+		return ""
+	}
+	s.txs[hash] = entry.token
+	s.spent.PushBack(&spentTx{hash: hash, expiresAt: now.Add(s.ttl)})
+	entry.txHash = txHash
+	return txHash
+}
+
+// redeem removes entry, reporting whether it was still outstanding
+func (s *pageStore) redeem(entry *pageEntry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[entry.token]
+	if !ok {
+		return false
+	}
+	s.order.Remove(elem)
+	delete(s.entries, entry.token)
+	return true
+}
+
+// servePagePoll answers a request carrying a payment page token: a JSON
+// status for polls, the resource for a page reload once paid, or the page
+// again while unpaid. It reports false if the token is unknown or expired
+// and the request should be treated as unpaid.
+func (m *X402Middleware) servePagePoll(w http.ResponseWriter, r *http.Request, next http.Handler, version int, message PaymentMessage, token string) bool {
+	entry := m.pages.lookup(token, challengeResource(r))
+	html := wantsHTML(r)
+	if entry == nil {
+		if html {
+			return false
+		}
+		respondPollStatus(w, "expired", "")
+		return true
+	}
+
+	txHash := m.pages.paid(r.Context(), entry, m.page.Observer)
+	switch {
+	case !html && txHash != "":
+		respondPollStatus(w, "paid", txHash)
+	case !html:
+		respondPollStatus(w, "pending", "")
+	case txHash != "" && m.pages.redeem(entry):
+		next.ServeHTTP(w, r)
+	default:
+		m.sendPaymentPage(w, r, version, entry.requirements, message, entry)
+	}
+	return true
+}
+
+func respondPollStatus(w http.ResponseWriter, status, txHash string) {
+	response := map[string]string{"status": status}
+	if txHash != "" {
+		response["transaction"] = txHash
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(response)
+}
+
+// offerPaymentPage renders the HTML 402 for requirements, issuing a page
+// token to poll with when there is an observer
+func (m *X402Middleware) offerPaymentPage(w http.ResponseWriter, r *http.Request, version int, requirements *types.PaymentRequirements, message PaymentMessage) {
+	var entry *pageEntry
+	if m.page.Observer != nil {
+		var err error
+		if entry, err = m.pages.issue(challengeResource(r), requirements); err != nil {
+			m.send402(w, version, requirements, message)
+			return
+		}
+	}
+	m.sendPaymentPage(w, r, version, requirements, message, entry)
+}
+
+// sendPaymentPage renders the HTML 402 for requirements, polling under
+// entry if not nil; it falls back to the JSON 402 if the template fails
+func (m *X402Middleware) sendPaymentPage(w http.ResponseWriter, r *http.Request, version int, requirements *types.PaymentRequirements, message PaymentMessage, entry *pageEntry) {
+	signed := m.signRequirements(w, requirements)
+
+	data := paymentPageData(signed)
+	if message.Title != "" {
+		data.Title = message.Title
+	}
+	if entry != nil {
+		query := r.URL.Query()
+		query.Set(types.PaymentPageParam, entry.token)
+		data.PollURL = r.URL.EscapedPath() + "?" + query.Encode()
+		data.ExpiresAt = entry.expiresAt
+	}
+
+	var body bytes.Buffer
+	if err := m.page.Template.Execute(&body, data); err != nil {
+		log.Printf("x402: payment page template failed: %v", err)
+		m.send402(w, version, signed, message)
+		return
+	}
+
+	m.set402Headers(w, version, signed.Summary(version))
+	setQuoteHeaders(w, signed)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(http.StatusPaymentRequired)
+	w.Write(body.Bytes())
+}
+
+// paymentPageData fills the template data of requirements
+func paymentPageData(requirements *types.PaymentRequirements) PaymentPageData {
+	data := PaymentPageData{
+		Requirements: requirements,
+		Price:        displayPrice(requirements),
+		Network:      requirements.Network,
+		PayTo:        requirements.PayTo,
+		Asset:        requirements.Asset.Hex(),
+		Resource:     requirements.Resource,
+		Title:        "Payment required",
+		Description:  requirements.Description,
+	}
+	data.ChainID, _ = requirements.Network.ChainID()
+	if link, err := requirements.WalletLink(); err == nil {
+		// Built from validated addresses and numbers only, so safe as a URL
+		data.WalletLink = template.URL(link)
+		if code, err := qr.Encode([]byte(link)); err == nil {
+			data.QRCode = template.HTML(code.SVG())
+		}
+	}
+	return data
+}
+
+// displayPrice formats the amount in whole tokens when the asset is a
+// registered USDC deployment, in the asset's smallest units otherwise
+func displayPrice(requirements *types.PaymentRequirements) string {
+	deployment, err := network.GetUSDCDeployment(requirements.Network)
+	if err != nil || deployment.TokenAddress != requirements.Asset {
+		return requirements.MaxAmountRequired + " (smallest units)"
+	}
+	amount, ok := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+	if !ok {
+		return requirements.MaxAmountRequired + " (smallest units)"
+	}
+	return types.FormatDecimalAmount(amount, deployment.Decimals) + " " + deployment.TokenSymbol
+}
+
+// wantsHTML reports whether r prefers an HTML page to JSON, as browser
+// navigations do; a tie, */* or no Accept header means JSON
+func wantsHTML(r *http.Request) bool {
+	htmlQ, jsonQ := 0.0, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case "text/html", "application/xhtml+xml":
+			htmlQ = max(htmlQ, q)
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return htmlQ > jsonQ
+}
+
+// DefaultPaymentPageTemplate is the payment page used unless
+// PaymentPage.Template is set; custom templates receive the same
+// PaymentPageData
+var DefaultPaymentPageTemplate = template.Must(template.New("payment").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 28rem; margin: 3rem auto; padding: 0 1rem; color: #222; }
+h1 { font-size: 1.4rem; }
+dl { display: grid; grid-template-columns: auto 1fr; gap: .3rem 1rem; }
+dt { color: #666; }
+dd { margin: 0; word-break: break-all; }
+.qr { width: 16rem; margin: 1.5rem auto; }
+.pay { display: block; text-align: center; padding: .8rem; background: #1652f0; color: #fff; border-radius: .4rem; text-decoration: none; }
+#status { text-align: center; color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Description}}<p>{{.}}</p>{{end}}
+<dl>
+<dt>Price</dt><dd>{{.Price}}</dd>
+<dt>Network</dt><dd>{{.Network}}{{with .ChainID}} (chain {{.}}){{end}}</dd>
+<dt>Recipient</dt><dd>{{.PayTo}}</dd>
+<dt>Token</dt><dd>{{.Asset}}</dd>
+</dl>
+{{if .WalletLink}}
+<div class="qr">{{.QRCode}}</div>
+<a class="pay" href="{{.WalletLink}}">Pay with wallet</a>
+{{else}}
+<p>This resource can only be paid with an x402 client.</p>
+{{end}}
+{{if .PollURL}}
+<p id="status">Waiting for payment…</p>
+<script>
+(function () {
+  var url = {{.PollURL}};
+  var timer = setInterval(function () {
+    fetch(url, {headers: {Accept: "application/json"}}).then(function (r) { return r.json(); }).then(function (s) {
+      if (s.status === "paid") { clearInterval(timer); location.href = url; }
+      if (s.status === "expired") { clearInterval(timer); document.getElementById("status").textContent = "This page expired; reload to pay."; }
+    }).catch(function () {});
+  }, 3000);
+})();
+</script>
+{{end}}
+</body>
+</html>
+`))
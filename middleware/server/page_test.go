@@ -0,0 +1,328 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// browserAccept is the Accept header of a browser navigation
+const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+// pageRequest is a GET of target accepting accept
+func pageRequest(target, accept string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	return req
+}
+
+// fixtureWalletLink is the wallet link of the x402test requirements
+func fixtureWalletLink(t *testing.T) string {
+	t.Helper()
+	requirements := x402test.Requirements()
+	link, err := requirements.WalletLink()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link
+}
+
+func TestPaymentPageForBrowsersOnly(t *testing.T) {
+	var hit bool
+	handler := NewX402Middleware("http://facilitator.test", WithPaymentPage(PaymentPage{})).Protect(served(&hit), fixturePriceTag())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, pageRequest("/resource", browserAccept))
+	body := rec.Body.String()
+	if hit || rec.Code != http.StatusPaymentRequired || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("browser: status %d, type %q, reached %v; want an HTML 402", rec.Code, rec.Header().Get("Content-Type"), hit)
+	}
+	for _, want := range []string{"0.01 USDC", "base-sepolia", "chain 84532", x402test.PayTo.Hex(), "<svg", "Pay with wallet"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("payment page lacks %q", want)
+		}
+	}
+	if !strings.Contains(body, template.HTMLEscapeString(fixtureWalletLink(t))) {
+		t.Errorf("payment page lacks the wallet link %s", fixtureWalletLink(t))
+	}
+	if strings.Contains(body, "Waiting for payment") {
+		t.Error("a page without an observer polls")
+	}
+	if rec.Header().Get(types.HeaderPaymentRequired) == "" || !strings.Contains(strings.Join(rec.Header().Values("Vary"), ","), "Accept") {
+		t.Errorf("headers %v, want the 402 summary and Vary: Accept", rec.Header())
+	}
+
+	// API clients keep the JSON 402
+	for _, accept := range []string{"", "*/*", "application/json", "text/html;q=0.5, application/json", "text/html, application/json"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, pageRequest("/resource", accept))
+		var challenge map[string]interface{}
+		if rec.Code != http.StatusPaymentRequired || json.Unmarshal(rec.Body.Bytes(), &challenge) != nil {
+			t.Errorf("Accept %q: status %d, body %.60q; want the JSON 402", accept, rec.Code, rec.Body.String())
+		}
+	}
+	rec = httptest.NewRecorder()
+	req := pageRequest("/resource", browserAccept)
+	req.Method = http.MethodPost
+	handler.ServeHTTP(rec, req)
+	if strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Error("a POST got the payment page")
+	}
+
+	// Without WithPaymentPage browsers get JSON too
+	rec = httptest.NewRecorder()
+	NewX402Middleware("http://facilitator.test").Protect(served(&hit), fixturePriceTag()).ServeHTTP(rec, pageRequest("/resource", browserAccept))
+	if strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Error("the payment page is on by default")
+	}
+}
+
+func TestWantsHTML(t *testing.T) {
+	for accept, want := range map[string]bool{
+		"":                                  false,
+		"*/*":                               false,
+		"application/json":                  false,
+		"text/html":                         true,
+		"application/xhtml+xml":             true,
+		browserAccept:                       true,
+		"text/html, application/json":       false, // A tie is JSON
+		"text/html;q=0.9, application/json": false,
+		"application/json;q=0.1, text/html": true,
+		"text/html;q=bogus":                 false,
+		"not a media type":                  false,
+	} {
+		req := pageRequest("/resource", accept)
+		if got := wantsHTML(req); got != want {
+			t.Errorf("Accept %q: wantsHTML %v, want %v", accept, got, want)
+		}
+	}
+}
+
+func TestPaymentPageCustomTemplate(t *testing.T) {
+	page := PaymentPage{Template: template.Must(template.New("page").Parse(`{{.Title}}|{{.Price}}|{{.ChainID}}|{{.WalletLink}}`))}
+	handler := NewX402Middleware("http://facilitator.test", WithPaymentPage(page)).Protect(http.NotFoundHandler(), fixturePriceTag())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, pageRequest("/resource", browserAccept))
+	if want := "Payment required|0.01 USDC|84532|" + template.HTMLEscapeString(fixtureWalletLink(t)); rec.Body.String() != want {
+		t.Errorf("page %q, want %q", rec.Body.String(), want)
+	}
+
+	// A template failing to execute falls back to the JSON 402
+	logs := captureLog(t)
+	page.Template = template.Must(template.New("page").Parse(`{{.Missing}}`))
+	handler = NewX402Middleware("http://facilitator.test", WithPaymentPage(page)).Protect(http.NotFoundHandler(), fixturePriceTag())
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, pageRequest("/resource", browserAccept))
+	var challenge map[string]interface{}
+	if rec.Code != http.StatusPaymentRequired || json.Unmarshal(rec.Body.Bytes(), &challenge) != nil {
+		t.Errorf("failing template: status %d, body %.60q; want the JSON 402", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(logs.String(), "payment page template failed") {
+		t.Errorf("log %q, want the template failure", logs.String())
+	}
+}
+
+// walletPayment is a PaymentObserver that sees a payment once marked paid
+type walletPayment struct {
+	mu     sync.Mutex
+	tx     string
+	calls  int
+	since  time.Time
+	tokens []string // Asked about, in order
+}
+
+func (p *walletPayment) observe(ctx context.Context, token string, requirements *types.PaymentRequirements, since time.Time) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	p.since = since
+	p.tokens = append(p.tokens, token)
+	return p.tx, nil
+}
+
+func (p *walletPayment) pay(tx string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tx = tx
+}
+
+// pageToken extracts the poll token from a payment page
+var pageToken = regexp.MustCompile(types.PaymentPageParam + `(?:=|\\u003d)([0-9a-f]{32})`)
+
+// pollStatus is a poll's JSON answer
+func pollStatus(t *testing.T, handler http.Handler, target string) map[string]string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, pageRequest(target, "application/json"))
+	var status map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("poll: status %d: %s", rec.Code, rec.Body.String())
+	}
+	return status
+}
+
+func TestPaymentPagePollsUntilPaid(t *testing.T) {
+	wallet := &walletPayment{}
+	var hit bool
+	handler := NewX402Middleware("http://facilitator.test", WithPaymentPage(PaymentPage{Observer: wallet.observe})).
+		Protect(served(&hit), fixturePriceTag())
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, pageRequest("/resource", browserAccept))
+	match := pageToken.FindStringSubmatch(rec.Body.String())
+	if match == nil || !strings.Contains(rec.Body.String(), "Waiting for payment") {
+		t.Fatalf("page with an observer does not poll: %s", rec.Body.String())
+	}
+	poll := "/resource?" + types.PaymentPageParam + "=" + match[1]
+
+	if status := pollStatus(t, handler, poll); status["status"] != "pending" {
+		t.Errorf("before paying: %v, want pending", status)
+	}
+	if wallet.calls != 1 || wallet.since.Before(start.Add(-time.Second)) || wallet.since.After(time.Now()) {
+		t.Errorf("observer called %d times since %v, want once since the page was shown", wallet.calls, wallet.since)
+	}
+	// An unpaid reload shows the page again
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, pageRequest(poll, browserAccept))
+	if hit || rec.Code != http.StatusPaymentRequired {
+		t.Errorf("unpaid reload: status %d, reached %v; want the page again", rec.Code, hit)
+	}
+
+	wallet.pay("0xabc123")
+	if status := pollStatus(t, handler, poll); status["status"] != "paid" || status["transaction"] != "0xabc123" {
+		t.Errorf("after paying: %v, want paid with the transaction", status)
+	}
+	calls := wallet.calls
+	pollStatus(t, handler, poll)
+	if wallet.calls != calls {
+		t.Error("the observer was asked again after it saw the payment")
+	}
+
+	// A poll for another resource does not match the token
+	if status := pollStatus(t, handler, "/other?"+types.PaymentPageParam+"="+match[1]); status["status"] != "expired" {
+		t.Errorf("token on another resource: %v, want expired", status)
+	}
+
+	// The paid reload is served once, and the query token never reaches the handler
+	var query string
+	handler = NewX402Middleware("http://facilitator.test", WithPaymentPage(PaymentPage{Observer: wallet.observe})).
+		Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hit, query = true, r.URL.RawQuery
+		}), fixturePriceTag())
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, pageRequest("/resource?page=2", browserAccept))
+	match = pageToken.FindStringSubmatch(rec.Body.String())
+	if match == nil {
+		t.Fatalf("no poll token in %s", rec.Body.String())
+	}
+	reload := "/resource?page=2&" + types.PaymentPageParam + "=" + match[1]
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, pageRequest(reload, browserAccept))
+	if !hit || query != "page=2" {
+		t.Errorf("paid reload: status %d, reached %v with query %q; want it served without the token", rec.Code, hit, query)
+	}
+	hit = false
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, pageRequest(reload, browserAccept))
+	if hit || rec.Code != http.StatusPaymentRequired {
+		t.Errorf("second reload: status %d, reached %v; want the token spent", rec.Code, hit)
+	}
+	if status := pollStatus(t, handler, reload); status["status"] != "expired" {
+		t.Errorf("poll after redeeming: %v, want expired", status)
+	}
+}
+
+func TestPaymentPageCreditsTransactionOnce(t *testing.T) {
+	wallet := &walletPayment{}
+	var served int
+	handler := NewX402Middleware("http://facilitator.test", WithPaymentPage(PaymentPage{Observer: wallet.observe})).
+		Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served++ }), fixturePriceTag())
+
+	var polls []string
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, pageRequest("/resource", browserAccept))
+		match := pageToken.FindStringSubmatch(rec.Body.String())
+		if match == nil {
+			t.Fatalf("no poll token in %s", rec.Body.String())
+		}
+		polls = append(polls, "/resource?"+types.PaymentPageParam+"="+match[1])
+	}
+
+	// One transfer, which an observer matching by amount reports to both pages
+	wallet.pay("0xABC123")
+	if status := pollStatus(t, handler, polls[0]); status["status"] != "paid" {
+		t.Errorf("first page: %v, want paid", status)
+	}
+	if status := pollStatus(t, handler, polls[1]); status["status"] != "pending" {
+		t.Errorf("second page: %v, want pending; the transaction paid the first", status)
+	}
+	wallet.pay("0xabc123") // The same hash in another case
+	for _, poll := range polls {
+		handler.ServeHTTP(httptest.NewRecorder(), pageRequest(poll, browserAccept))
+	}
+	if served != 1 {
+		t.Errorf("one transaction served %d pages, want 1", served)
+	}
+	if len(wallet.tokens) < 2 || wallet.tokens[0] == wallet.tokens[1] {
+		t.Errorf("observer asked about tokens %v, want each page's own", wallet.tokens)
+	}
+
+	// A new transaction pays the second page
+	wallet.pay("0xdef456")
+	if status := pollStatus(t, handler, polls[1]); status["status"] != "paid" || status["transaction"] != "0xdef456" {
+		t.Errorf("second page after its own payment: %v, want paid", status)
+	}
+}
+
+func TestPaymentPageTokensExpire(t *testing.T) {
+	wallet := &walletPayment{tx: "0xabc123"}
+	handler := NewX402Middleware("http://facilitator.test", WithPaymentPage(PaymentPage{Observer: wallet.observe, TTL: 20 * time.Millisecond})).
+		Protect(http.NotFoundHandler(), fixturePriceTag())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, pageRequest("/resource", browserAccept))
+	match := pageToken.FindStringSubmatch(rec.Body.String())
+	if match == nil {
+		t.Fatalf("no poll token in %s", rec.Body.String())
+	}
+	time.Sleep(30 * time.Millisecond)
+	if status := pollStatus(t, handler, "/resource?"+types.PaymentPageParam+"="+match[1]); status["status"] != "expired" {
+		t.Errorf("after the TTL: %v, want expired", status)
+	}
+	if wallet.calls != 0 {
+		t.Error("the observer was asked about an expired page")
+	}
+}
+
+func TestPageStoreBounded(t *testing.T) {
+	store := newPageStore(time.Hour)
+	requirements := x402test.Requirements()
+	first, err := store.issue("shop.test/resource", &requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxPaymentPages; i++ {
+		if _, err := store.issue("shop.test/resource", &requirements); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(store.entries) != maxPaymentPages || store.order.Len() != maxPaymentPages {
+		t.Errorf("%d tokens kept, want at most %d", len(store.entries), maxPaymentPages)
+	}
+	if store.lookup(first.token, "shop.test/resource") != nil {
+		t.Error("the oldest token survived the store filling up")
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+var profilePayTo = types.MixedAddress{Type: "evm", Address: "0x00000000000000000000000000000000000000b0"}
+
+func TestRequirementsForNetworkEveryDeployment(t *testing.T) {
+	testnet := NewX402Middleware("http://facilitator.test", WithEnvironmentProfile(ProfileTestnet))
+	mainnet := NewX402Middleware("http://facilitator.test", WithEnvironmentProfile(ProfileMainnet))
+	var evmDeployments int
+	for _, deployment := range network.USDCDeployments() {
+		if !deployment.Network.IsEVM() {
+			continue
+		}
+		evmDeployments++
+		tag, err := RequirementsForNetwork(deployment.Network, "0.025", profilePayTo)
+		if err != nil {
+			t.Errorf("%s: %v", deployment.Network, err)
+			continue
+		}
+		want := new(big.Int).Mul(big.NewInt(25), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(deployment.Decimals)-3), nil))
+		if r := tag.Requirements; r.Network != deployment.Network || r.Asset != deployment.TokenAddress || r.MaxAmountRequired != want.String() || r.PayTo != profilePayTo.Address {
+			t.Errorf("%s: requirements %+v, want %s of %s", deployment.Network, r, want, deployment.TokenAddress.Hex())
+		}
+
+		// Accepted by the matching profile only
+		matching, other := mainnet, testnet
+		if deployment.Network.IsTestnet() {
+			matching, other = testnet, mainnet
+		}
+		if err := matching.CheckPriceTag(tag); err != nil {
+			t.Errorf("%s: refused by the %s profile: %v", deployment.Network, matching.Profile(), err)
+		}
+		if err := other.CheckPriceTag(tag); err == nil {
+			t.Errorf("%s: accepted by the %s profile", deployment.Network, other.Profile())
+		}
+	}
+	if evmDeployments == 0 {
+		t.Fatal("no EVM USDC deployments registered")
+	}
+}
+
+func TestRequirementsForNetworkRejects(t *testing.T) {
+	for _, tc := range []struct {
+		network types.Network
+		amount  string
+		err     string
+	}{
+		{types.NetworkSolanaDevnet, "1", "EVM networks only"},
+		{"base-goerli", "1", "base-goerli"},
+		{types.NetworkBase, "0.0000001", "more than 6 decimal places"},
+		{types.NetworkBase, "1.2.3", "invalid amount"},
+		{types.NetworkBase, "-1", "invalid amount"},
+		{types.NetworkBase, "", "invalid amount"},
+	} {
+		if _, err := RequirementsForNetwork(tc.network, tc.amount, profilePayTo); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s %q: error %v, want one mentioning %q", tc.network, tc.amount, err, tc.err)
+		}
+	}
+	for amount, want := range map[string]string{"1": "1000000", "0.5": "500000", ".25": "250000", "2.": "2000000", "0.000001": "1"} {
+		tag, err := RequirementsForNetwork(types.NetworkBase, amount, profilePayTo)
+		if err != nil || tag.Requirements.MaxAmountRequired != want {
+			t.Errorf("amount %q: %v, %v; want %s", amount, tag, err, want)
+		}
+	}
+}
+
+func TestPriceTagBuilderValidateAssetNetwork(t *testing.T) {
+	base, err := network.GetUSDCDeployment(types.NetworkBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sepolia, err := network.GetUSDCDeployment(types.NetworkBaseSepolia)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := func(d network.USDCDeployment) types.MixedAddress { return types.NewEvmAddress(d.TokenAddress) }
+
+	for _, tc := range []struct {
+		name    string
+		builder *PriceTagBuilder
+		err     string
+	}{
+		{"mainnet asset on mainnet", NewPriceTagBuilder().Network(types.NetworkBase).Token(token(base)), ""},
+		{"testnet asset on testnet", NewPriceTagBuilder().Network(types.NetworkBaseSepolia).Token(token(sepolia)), ""},
+		{"unregistered token", NewPriceTagBuilder().Network(types.NetworkBase).Token(profilePayTo), ""},
+		{"testnet asset on mainnet", NewPriceTagBuilder().Network(types.NetworkBase).Token(token(sepolia)), "on base-sepolia, not base"},
+		{"mainnet asset on testnet", NewPriceTagBuilder().Network(types.NetworkBaseSepolia).Token(token(base)), "on base, not base-sepolia"},
+		{"no asset", NewPriceTagBuilder().Network(types.NetworkBase), "no asset set"},
+		{"unknown network", NewPriceTagBuilder().Network("base-goerli").Token(token(base)), "base-goerli"},
+	} {
+		err := tc.builder.Amount("1000").PayTo(profilePayTo).Validate()
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: error %v, want one mentioning %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestProtectRefusesPriceTagOutsideProfile(t *testing.T) {
+	sepolia, err := RequirementsForNetwork(types.NetworkBaseSepolia, "0.01", profilePayTo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := network.GetUSDCDeployment(types.NetworkBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A testnet route whose asset was switched to mainnet USDC
+	crossed := NewPriceTagBuilder().Network(types.NetworkBaseSepolia).Amount("1000").PayTo(profilePayTo).Token(types.NewEvmAddress(base.TokenAddress)).Build()
+
+	for _, tc := range []struct {
+		name    string
+		profile EnvironmentProfile
+		tag     *PriceTag
+		panics  bool
+	}{
+		{"testnet tag, no profile", ProfileAny, sepolia, false},
+		{"testnet tag, testnet profile", ProfileTestnet, sepolia, false},
+		{"testnet tag, mainnet profile", ProfileMainnet, sepolia, true},
+		{"mainnet asset on a testnet", ProfileTestnet, crossed, true},
+		{"unknown profile", EnvironmentProfile("staging"), sepolia, true},
+	} {
+		func() {
+			defer func() {
+				if recovered := recover(); (recovered != nil) != tc.panics {
+					t.Errorf("%s: panic %v, want a panic %v", tc.name, recovered, tc.panics)
+				}
+			}()
+			NewX402Middleware("http://facilitator.test", WithEnvironmentProfile(tc.profile)).Protect(http.NotFoundHandler(), tc.tag)
+		}()
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultQueryPaymentLimit caps the encoded x402_payment query parameter
+const DefaultQueryPaymentLimit = 8192
+
+// WithQueryPayment also accepts the payment as a base64url-encoded
+// types.QueryPaymentParam query parameter, for clients that cannot set
+// headers (signed download URLs, simple webhook senders). The parameter is
+// removed from the request URL before the protected handler sees it, so it
+// stays out of the handler's logs. A URL can be copied, so such payments
+// must be bound to the requested resource (see types.CheckResourceBinding);
+// PayingClient.BuildPaidURL signs them that way. maxBytes caps the encoded
+// parameter (DefaultQueryPaymentLimit if <= 0).
+func WithQueryPayment(maxBytes int) Option {
+	return func(m *X402Middleware) {
+		if maxBytes <= 0 {
+			maxBytes = DefaultQueryPaymentLimit
+		}
+		m.queryPaymentLimit = maxBytes
+	}
+}
+
+// takeQueryPayment removes the payment query parameter from r and returns
+// its decoded value ("" if absent or query payments are off)
+func (m *X402Middleware) takeQueryPayment(r *http.Request) (string, error) {
+	if m.queryPaymentLimit == 0 {
+		return "", nil
+	}
+	encoded := takeQueryParam(r, types.QueryPaymentParam)
+	if encoded == "" {
+		return "", nil
+	}
+	if len(encoded) > m.queryPaymentLimit {
+		return "", fmt.Errorf("%s exceeds %d bytes", types.QueryPaymentParam, m.queryPaymentLimit)
+	}
+	decoded, err := types.DecodeQueryPayment(encoded)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// takeQueryParam removes every param parameter from r's query, keeping the
+// rest as the client wrote it, and returns the first one's value
+func takeQueryParam(r *http.Request, param string) string {
+	if !strings.Contains(r.URL.RawQuery, param) {
+		return ""
+	}
+	var found string
+	kept := make([]string, 0, strings.Count(r.URL.RawQuery, "&")+1)
+	for _, part := range strings.Split(r.URL.RawQuery, "&") {
+		key, value, _ := strings.Cut(part, "=")
+		if name, err := url.QueryUnescape(key); err == nil && name == param {
+			if found == "" {
+				found, _ = url.QueryUnescape(value)
+			}
+			continue
+		}
+		kept = append(kept, part)
+	}
+	r.URL.RawQuery = strings.Join(kept, "&")
+	r.RequestURI = r.URL.RequestURI()
+	r.Form, r.PostForm = nil, nil
+	return found
+}
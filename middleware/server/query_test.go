@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// queryPaidURL returns target with a payment in the x402_payment parameter,
+// bound to resource unless it is empty
+func queryPaidURL(t *testing.T, target, resource string) string {
+	t.Helper()
+	requirements := x402test.Requirements()
+	requirements.Resource = resource
+	payload, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paid, err := types.AppendQueryPayment(target, payloadJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paid
+}
+
+// accessLog is a protected handler writing the URL it was served, as an
+// access log would
+func accessLog(log *bytes.Buffer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(log, "%s %s %s\n", r.Method, r.RequestURI, r.URL)
+		w.Write([]byte("download"))
+	})
+}
+
+func TestQueryPaymentServesAndLeavesNoTrace(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorVerify)
+	var log bytes.Buffer
+	handler := NewX402Middleware(facilitator.URL, WithQueryPayment(0)).Protect(accessLog(&log), fixturePriceTag())
+
+	target := queryPaidURL(t, "http://shop.test/files/report.pdf?version=2", "http://shop.test/files/report.pdf")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "download" {
+		t.Fatalf("query payment: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(log.String(), types.QueryPaymentParam) {
+		t.Errorf("the payment reached the handler's log: %s", log.String())
+	}
+	if !strings.Contains(log.String(), "/files/report.pdf?version=2") {
+		t.Errorf("handler log %q, want the rest of the query kept", log.String())
+	}
+	if n := facilitator.VerifyCount(); n != 1 {
+		t.Errorf("%d verifications, want 1", n)
+	}
+}
+
+func TestQueryPaymentMustBindResource(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	var log bytes.Buffer
+	handler := NewX402Middleware(facilitator.URL, WithQueryPayment(0)).Protect(accessLog(&log), fixturePriceTag())
+
+	for name, target := range map[string]string{
+		"unbound":        queryPaidURL(t, "http://shop.test/files/report.pdf", ""),
+		"other resource": queryPaidURL(t, "http://shop.test/files/report.pdf", "http://shop.test/files/cheap.txt"),
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusPaymentRequired || !strings.Contains(rec.Body.String(), string(types.ReasonResourceMismatch)) {
+			t.Errorf("%s: status %d: %s; want a 402 for the resource", name, rec.Code, rec.Body.String())
+		}
+	}
+	if log.Len() != 0 || facilitator.VerifyCount() != 0 {
+		t.Errorf("an unbound query payment was served (%q) or verified (%d)", log.String(), facilitator.VerifyCount())
+	}
+}
+
+func TestQueryPaymentOffByDefault(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	var log bytes.Buffer
+	handler := NewX402Middleware(facilitator.URL).Protect(accessLog(&log), fixturePriceTag())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, queryPaidURL(t, "http://shop.test/files/report.pdf", "http://shop.test/files/report.pdf"), nil))
+	if rec.Code != http.StatusPaymentRequired || log.Len() != 0 || facilitator.VerifyCount() != 0 {
+		t.Errorf("query payment with the mode off: status %d, served %q, %d verifications; want a 402", rec.Code, log.String(), facilitator.VerifyCount())
+	}
+}
+
+func TestQueryPaymentLimitAndEncoding(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	var log bytes.Buffer
+	handler := NewX402Middleware(facilitator.URL, WithQueryPayment(64)).Protect(accessLog(&log), fixturePriceTag())
+
+	for name, target := range map[string]string{
+		"oversized":     queryPaidURL(t, "http://shop.test/files/report.pdf", "http://shop.test/files/report.pdf"),
+		"not base64url": "http://shop.test/files/report.pdf?" + types.QueryPaymentParam + "=not*base64",
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", name, rec.Code, http.StatusBadRequest)
+		}
+	}
+	if log.Len() != 0 {
+		t.Errorf("a bad query payment was served: %s", log.String())
+	}
+}
+
+func TestTakeQueryParamKeepsOtherParameters(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/files?a=1&x402_payment=first&b=%2F&x402%5Fpayment=second", nil)
+	if got := takeQueryParam(req, types.QueryPaymentParam); got != "first" {
+		t.Errorf("took %q, want the first value", got)
+	}
+	if req.URL.RawQuery != "a=1&b=%2F" || req.RequestURI != "/files?a=1&b=%2F" {
+		t.Errorf("query %q, request URI %q; want every payment parameter removed", req.URL.RawQuery, req.RequestURI)
+	}
+}
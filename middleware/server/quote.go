@@ -0,0 +1,101 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// WithQuoteTTL makes every 402 a price quote valid for ttl: requirements
+// carry a quote (types.PaymentQuote) in Extra and the X-Payment-Quote-*
+// headers, and payments echoing a lapsed or foreign quote are refused with
+// ReasonQuoteExpired. Quote IDs are derived from the requirements and
+// expiry with a per-middleware key, so the same price quoted in the same
+// second gets the same ID and nothing is stored.
+func WithQuoteTTL(ttl time.Duration) Option {
+	return func(m *X402Middleware) {
+		if ttl <= 0 {
+			return
+		}
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("x402: failed to generate quote key: %v", err))
+		}
+		m.quoteTTL = ttl
+		m.quoteKey = key
+	}
+}
+
+// offerRequirements returns the requirements to put in a 402 for r: a fresh
+// challenge (in challenge mode) and a quote (with WithQuoteTTL) on top of base
+func (m *X402Middleware) offerRequirements(r *http.Request, base *types.PaymentRequirements) *types.PaymentRequirements {
+	requirements := m.challengeRequirements(r, base)
+	if m.quoteTTL <= 0 {
+		return requirements
+	}
+	expiresAt := time.Now().UTC().Truncate(time.Second).Add(m.quoteTTL).Format(time.RFC3339)
+	id, err := m.quoteID(base, expiresAt)
+	if err != nil {
+		return requirements
+	}
+	extra, err := types.MergeExtra(requirements.Extra, types.RequirementsExtra{Quote: &types.PaymentQuote{ID: id, ExpiresAt: expiresAt}})
+	if err != nil {
+		return requirements
+	}
+	quoted := *requirements
+	quoted.Extra = extra
+	return &quoted
+}
+
+// quoteID derives the ID of a quote for base expiring at expiresAt
+func (m *X402Middleware) quoteID(base *types.PaymentRequirements, expiresAt string) (string, error) {
+	hash, err := types.HashRequirements(base)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, m.quoteKey)
+	mac.Write(hash[:])
+	mac.Write([]byte(expiresAt))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// checkQuote validates the quote echoed by payload against base
+// Returns a rejection reason, or "" if the payment may proceed; payments
+// echoing no quote are accepted (clients predating quotes)
+func (m *X402Middleware) checkQuote(payload *types.PaymentPayload, base *types.PaymentRequirements) string {
+	if m.quoteTTL <= 0 {
+		return ""
+	}
+	quote, err := types.ParseQuote(payload.Extra)
+	if err != nil {
+		return "invalid payment quote"
+	}
+	if quote == nil {
+		return ""
+	}
+	id, err := m.quoteID(base, quote.ExpiresAt)
+	if err != nil || !hmac.Equal([]byte(id), []byte(quote.ID)) {
+		return "payment quote was not issued for these requirements"
+	}
+	if quote.Expired(time.Now()) {
+		return fmt.Sprintf("payment quote expired at %s", quote.ExpiresAt)
+	}
+	return ""
+}
+
+// setQuoteHeaders mirrors the requirements' quote, if any, in the 402 headers
+func setQuoteHeaders(w http.ResponseWriter, requirements *types.PaymentRequirements) *types.PaymentQuote {
+	quote, err := types.ParseQuote(requirements.Extra)
+	if err != nil || quote == nil {
+		return nil
+	}
+	w.Header().Set(types.HeaderQuoteID, quote.ID)
+	w.Header().Set(types.HeaderQuoteExpiresAt, quote.ExpiresAt)
+	return quote
+}
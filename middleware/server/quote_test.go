@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// quoteOffer returns the quote of the 402 answering an unpaid GET
+func quoteOffer(t *testing.T, handler http.Handler) *types.PaymentQuote {
+	t.Helper()
+	quote, err := types.ParseQuote(challengeOffer(t, handler, "/resource").Extra)
+	if err != nil || quote == nil {
+		t.Fatalf("402 carries no quote (%v)", err)
+	}
+	return quote
+}
+
+// payQuote sends a GET paying the x402test requirements and echoing quote
+func payQuote(t *testing.T, handler http.Handler, quote *types.PaymentQuote) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := x402test.GenerateValidPayload(x402test.Requirements(), x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote != nil {
+		if payload.Extra, err = types.SetExtraField(payload.Extra, types.QuoteExtraKey, quote); err != nil {
+			t.Fatal(err)
+		}
+	}
+	header, err := x402test.PaymentHeader(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+	req.Header.Set(types.HeaderXPayment, header)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func quoteHandler(t *testing.T, ttl time.Duration) (http.Handler, *x402test.FakeFacilitator) {
+	t.Helper()
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	var hit bool
+	return NewX402Middleware(facilitator.URL, WithQuoteTTL(ttl)).Protect(served(&hit), fixturePriceTag()), facilitator
+}
+
+func TestQuote402CarriesExpiry(t *testing.T) {
+	handler, _ := quoteHandler(t, time.Minute)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resource", nil))
+	var body struct {
+		Requirements   types.PaymentRequirements `json:"payment_requirements"`
+		QuoteID        string                    `json:"quoteId"`
+		QuoteExpiresAt string                    `json:"quoteExpiresAt"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	quote, err := types.ParseQuote(body.Requirements.Extra)
+	if err != nil || quote == nil {
+		t.Fatalf("402 requirements carry no quote (%v)", err)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, quote.ExpiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if until := time.Until(expiresAt); until <= 58*time.Second || until > time.Minute {
+		t.Errorf("quote expires in %v, want about a minute", until)
+	}
+	if body.QuoteID != quote.ID || body.QuoteExpiresAt != quote.ExpiresAt {
+		t.Errorf("402 body quotes %s until %s, requirements %+v", body.QuoteID, body.QuoteExpiresAt, quote)
+	}
+	if rec.Header().Get(types.HeaderQuoteID) != quote.ID || rec.Header().Get(types.HeaderQuoteExpiresAt) != quote.ExpiresAt {
+		t.Errorf("quote headers %q/%q, want %+v", rec.Header().Get(types.HeaderQuoteID), rec.Header().Get(types.HeaderQuoteExpiresAt), quote)
+	}
+
+	// Without a quote TTL the requirements are served as configured
+	plain := NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), fixturePriceTag())
+	if quote, _ := types.ParseQuote(challengeOffer(t, plain, "/resource").Extra); quote != nil {
+		t.Errorf("quote %+v issued without a quote TTL", quote)
+	}
+}
+
+func TestQuoteAcceptsPaymentWithinTTL(t *testing.T) {
+	handler, facilitator := quoteHandler(t, time.Minute)
+	if rec := payQuote(t, handler, quoteOffer(t, handler)); rec.Code != http.StatusNoContent {
+		t.Fatalf("quoted payment: status %d: %s", rec.Code, rec.Body.String())
+	}
+	// Clients predating quotes echo none
+	if rec := payQuote(t, handler, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("unquoted payment: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if facilitator.VerifyCount() != 2 {
+		t.Errorf("%d verifications, want 2", facilitator.VerifyCount())
+	}
+}
+
+func TestQuoteRejectsPaymentAfterExpiry(t *testing.T) {
+	handler, facilitator := quoteHandler(t, time.Second)
+	quote := quoteOffer(t, handler)
+	for !quote.Expired(time.Now()) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	rec := payQuote(t, handler, quote)
+	rejection := quoteRejectionCode(t, rec)
+	if rejection != types.ReasonQuoteExpired {
+		t.Errorf("reason code %q, want %q", rejection, types.ReasonQuoteExpired)
+	}
+	if facilitator.VerifyCount() != 0 {
+		t.Errorf("%d verifications of a lapsed quote, want none", facilitator.VerifyCount())
+	}
+
+	// The rejection quotes afresh so the client can retry
+	var body struct {
+		Requirements types.PaymentRequirements `json:"payment_requirements"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	fresh, _ := types.ParseQuote(body.Requirements.Extra)
+	if fresh == nil || fresh.Expired(time.Now()) {
+		t.Fatalf("rejection quotes %+v, want a live quote", fresh)
+	}
+	if rec := payQuote(t, handler, fresh); rec.Code != http.StatusNoContent {
+		t.Errorf("payment against the fresh quote: status %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestQuoteRejectsForeignQuotes(t *testing.T) {
+	handler, facilitator := quoteHandler(t, time.Minute)
+	other, _ := quoteHandler(t, time.Minute)
+	quote := quoteOffer(t, handler)
+
+	extended := *quote
+	extended.ExpiresAt = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	for name, forged := range map[string]*types.PaymentQuote{
+		"another server's quote": quoteOffer(t, other),
+		"extended expiry":        &extended,
+		"unknown ID":             {ID: "00000000000000000000000000000000", ExpiresAt: quote.ExpiresAt},
+	} {
+		if code := quoteRejectionCode(t, payQuote(t, handler, forged)); code != types.ReasonQuoteExpired {
+			t.Errorf("%s: reason code %q, want %q", name, code, types.ReasonQuoteExpired)
+		}
+	}
+	if facilitator.VerifyCount() != 0 {
+		t.Errorf("%d verifications of forged quotes, want none", facilitator.VerifyCount())
+	}
+}
+
+// quoteRejectionCode returns the reason code of a 402 answering a quoted payment
+func quoteRejectionCode(t *testing.T, rec *httptest.ResponseRecorder) types.ReasonCode {
+	t.Helper()
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusPaymentRequired)
+	}
+	var body struct {
+		ReasonCode types.ReasonCode `json:"reasonCode"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.ReasonCode
+}
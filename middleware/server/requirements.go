@@ -0,0 +1,144 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// EnvironmentProfile pins a middleware to testnets or mainnets so a price tag
+// for the wrong environment is caught when the route is set up
+type EnvironmentProfile string
+
+const (
+	ProfileAny     EnvironmentProfile = ""        // No restriction (default)
+	ProfileTestnet EnvironmentProfile = "testnet" // Only testnet networks and assets
+	ProfileMainnet EnvironmentProfile = "mainnet" // Only mainnet networks and assets
+)
+
+// WithEnvironmentProfile makes Protect panic on price tags whose network is
+// outside profile or whose asset belongs to another network, e.g. a mainnet
+// tag still pointing at testnet USDC
+func WithEnvironmentProfile(profile EnvironmentProfile) Option {
+	return func(m *X402Middleware) {
+		m.profile = profile
+	}
+}
+
+// Profile returns the environment profile (ProfileAny unless set)
+func (m *X402Middleware) Profile() EnvironmentProfile {
+	return m.profile
+}
+
+// CheckPriceTag reports why Protect would refuse priceTag under the
+// middleware's environment profile, because its output schema is not a
+// valid JSON Schema, or because no validity window fits both its minimum
+// and maximum (nil if it is acceptable)
+func (m *X402Middleware) CheckPriceTag(priceTag *PriceTag) error {
+	if priceTag.err != nil {
+		return priceTag.err
+	}
+	requirements := &priceTag.Requirements
+	if _, err := requirements.ParseOutputSchema(); err != nil {
+		return fmt.Errorf("output schema: %w", err)
+	}
+	if err := checkTimeouts(requirements); err != nil {
+		return err
+	}
+	if m.profile == ProfileAny {
+		return nil
+	}
+	if err := checkProfile(m.profile, requirements.Network); err != nil {
+		return err
+	}
+	return checkAsset(requirements.Network, requirements.Asset)
+}
+
+// checkTimeouts rejects a negative minimum validity window and one above
+// the maximum (when there is one), which no payment could satisfy
+func checkTimeouts(requirements *types.PaymentRequirements) error {
+	if requirements.MinTimeoutSeconds < 0 {
+		return fmt.Errorf("minTimeoutSeconds (%d) is negative", requirements.MinTimeoutSeconds)
+	}
+	if requirements.MaxTimeoutSeconds > 0 && requirements.MinTimeoutSeconds > requirements.MaxTimeoutSeconds {
+		return fmt.Errorf("minTimeoutSeconds (%d) exceeds maxTimeoutSeconds (%d)", requirements.MinTimeoutSeconds, requirements.MaxTimeoutSeconds)
+	}
+	return nil
+}
+
+// checkProfile rejects networks outside profile
+func checkProfile(profile EnvironmentProfile, net types.Network) error {
+	switch profile {
+	case ProfileAny:
+		return nil
+	case ProfileTestnet:
+		if !net.IsTestnet() {
+			return fmt.Errorf("network %s is not a testnet (environment profile %s)", net, profile)
+		}
+	case ProfileMainnet:
+		if net.IsTestnet() {
+			return fmt.Errorf("network %s is a testnet (environment profile %s)", net, profile)
+		}
+	default:
+		return fmt.Errorf("unknown environment profile %q", profile)
+	}
+	return nil
+}
+
+// checkAsset rejects EVM assets that are another network's registered USDC
+// deployment; unregistered tokens are left to the facilitator's whitelist
+func checkAsset(net types.Network, asset common.Address) error {
+	if _, err := network.GetNetworkInfo(net); err != nil {
+		return err
+	}
+	if !net.IsEVM() {
+		return nil
+	}
+	if asset == (common.Address{}) {
+		return fmt.Errorf("no asset set for %s", net)
+	}
+	if deployment, err := network.GetUSDCDeployment(net); err == nil && deployment.TokenAddress == asset {
+		return nil
+	}
+	for _, deployment := range network.USDCDeployments() {
+		if deployment.Network != net && deployment.TokenAddress == asset {
+			return fmt.Errorf("asset %s is %s on %s, not %s", asset.Hex(), deployment.TokenSymbol, deployment.Network, net)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the network is known and the asset (or token) is not
+// registered to a different network
+func (b *PriceTagBuilder) Validate() error {
+	asset := b.asset
+	if asset.Address == "" {
+		asset = b.token
+	}
+	if b.network.IsSolana() {
+		_, err := network.GetNetworkInfo(b.network)
+		return err
+	}
+	return checkAsset(b.network, common.HexToAddress(types.NormalizeEVMAddress(asset.Address)))
+}
+
+// RequirementsForNetwork returns a price tag for amountDecimal (e.g. "0.025")
+// of the network's registered USDC deployment payable to payTo, taking the
+// asset address and decimals from pkg/network instead of hardcoding them
+func RequirementsForNetwork(net types.Network, amountDecimal string, payTo types.MixedAddress) (*PriceTag, error) {
+	if !net.IsEVM() {
+		return nil, fmt.Errorf("RequirementsForNetwork supports EVM networks only, not %s", net)
+	}
+	deployment, err := network.GetUSDCDeployment(net)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := types.ParseDecimalAmount(amountDecimal, deployment.Decimals)
+	if err != nil {
+		return nil, err
+	}
+	token := types.NewEvmAddress(deployment.TokenAddress)
+	return NewPriceTag(net, amount.String(), deployment.TokenSymbol, payTo, token, "", "", "", 0, token, nil), nil
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func testPriceTag(minTimeout, maxTimeout int) *PriceTag {
+	return NewPriceTagBuilder().
+		Network("base-sepolia").
+		Amount("1000").
+		PayTo(types.MixedAddress{Type: "evm", Address: "0x00000000000000000000000000000000000000b0"}).
+		Resource("https://example.com/resource").
+		MinTimeout(minTimeout).
+		MaxTimeout(maxTimeout).
+		Build()
+}
+
+func TestCheckPriceTagValidityWindow(t *testing.T) {
+	m := NewX402Middleware("http://facilitator.test")
+	for _, tc := range []struct {
+		min, max int
+		wantErr  string
+	}{
+		{0, 0, ""},
+		{60, 0, ""}, // No maximum to exceed
+		{60, 300, ""},
+		{300, 300, ""},
+		{301, 300, "exceeds maxTimeoutSeconds"},
+		{-1, 300, "negative"},
+	} {
+		err := m.CheckPriceTag(testPriceTag(tc.min, tc.max))
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("min %d, max %d: %v", tc.min, tc.max, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("min %d, max %d: error %v, want one mentioning %q", tc.min, tc.max, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRequirementsFromKindValidityWindow(t *testing.T) {
+	kind := types.SupportedPaymentKind{
+		Version:  types.X402VersionV1,
+		Scheme:   types.SchemeExact,
+		Network:  "base-sepolia",
+		Token:    types.MixedAddress{Type: "evm", Address: "0x036CbD53842c5426634e7929541eC2318f3dCF7e"},
+		Decimals: 6,
+	}
+	payTo := types.MixedAddress{Type: "evm", Address: "0x00000000000000000000000000000000000000b0"}
+
+	requirements, err := RequirementsFromKind(kind, "0.01", payTo, WithMinTimeout(DefaultMaxTimeoutSeconds))
+	if err != nil {
+		t.Fatalf("minimum at the default maximum: %v", err)
+	}
+	if requirements.MinTimeoutSeconds != DefaultMaxTimeoutSeconds {
+		t.Errorf("MinTimeoutSeconds = %d, want %d", requirements.MinTimeoutSeconds, DefaultMaxTimeoutSeconds)
+	}
+	if _, err := RequirementsFromKind(kind, "0.01", payTo, WithMinTimeout(DefaultMaxTimeoutSeconds+1)); err == nil {
+		t.Error("minimum above the default maximum was accepted")
+	}
+	if _, err := RequirementsFromKind(kind, "0.01", payTo, WithMaxTimeout(0), WithMinTimeout(3600)); err != nil {
+		t.Errorf("minimum without a maximum: %v", err)
+	}
+}
+
+func TestPriceTagBuilderNormalizesXDCAddresses(t *testing.T) {
+	tag := NewPriceTagBuilder().
+		Network("xdc").
+		Amount("1000").
+		PayTo(types.MixedAddress{Type: "evm", Address: "xdc00000000000000000000000000000000000000b0"}).
+		Token(types.MixedAddress{Type: "evm", Address: "xdcD4B5f10D61916Bd6E0860144a91Ac658dE8a1437"}).
+		Build()
+	if tag.Requirements.PayTo != "0x00000000000000000000000000000000000000b0" {
+		t.Errorf("payTo %q, want the 0x form", tag.Requirements.PayTo)
+	}
+	if got := tag.Requirements.Asset.Hex(); got != "0xD4B5f10D61916Bd6E0860144a91Ac658dE8a1437" {
+		t.Errorf("asset %s, want the xdc token", got)
+	}
+}
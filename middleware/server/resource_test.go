@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// strictFacilitator checks resource bindings on /verify, as a facilitator in
+// strict mode does, before passing requests on to a fake facilitator; it
+// records the resources it was asked to verify for
+type strictFacilitator struct {
+	*httptest.Server
+	mu        sync.Mutex
+	resources []string
+}
+
+func newStrictFacilitator(t *testing.T) *strictFacilitator {
+	t.Helper()
+	fake := x402test.NewFakeFacilitator(t, x402test.BehaviorVerify)
+	f := &strictFacilitator{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/verify" {
+			var req types.VerifyRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			f.mu.Lock()
+			f.resources = append(f.resources, req.PaymentRequirements.Resource)
+			f.mu.Unlock()
+			if err := types.CheckResourceBinding(&req.PaymentPayload, &req.PaymentRequirements); err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(types.VerifyResponse{Reason: err.Error(), ReasonCode: types.ReasonResourceMismatch})
+				return
+			}
+		}
+		resp, err := http.Post(fake.URL+r.URL.Path, "application/json", bytes.NewReader(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestProtectFillsResourceFromRequest(t *testing.T) {
+	handler := NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), fixturePriceTag())
+	if got := challengeOffer(t, handler, "http://shop.test/cheap-endpoint?size=large").Resource; got != "http://shop.test/cheap-endpoint" {
+		t.Errorf("402 resource %q, want the request URL without its query", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://shop.test/cheap-endpoint", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var body struct {
+		Requirements types.PaymentRequirements `json:"payment_requirements"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if got := body.Requirements.Resource; got != "https://shop.test/cheap-endpoint" {
+		t.Errorf("402 resource behind a TLS proxy %q, want https", got)
+	}
+
+	// A price tag naming its resource keeps it
+	tag := NewPriceTagBuilder().
+		Network(x402test.Network).
+		Amount(x402test.Amount).
+		PayTo(types.MixedAddress{Type: "evm", Address: x402test.PayTo.Hex()}).
+		Resource("https://api.shop.test/catalog").
+		Build()
+	named := NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), tag)
+	if got := challengeOffer(t, named, "http://shop.test/cheap-endpoint").Resource; got != "https://api.shop.test/catalog" {
+		t.Errorf("402 resource %q, want the price tag's", got)
+	}
+}
+
+func TestProtectRejectsPaymentReplayedOnAnotherRoute(t *testing.T) {
+	facilitator := newStrictFacilitator(t)
+	var hit bool
+	handler := NewX402Middleware(facilitator.URL).Protect(served(&hit), fixturePriceTag())
+
+	// Both routes share price and payTo; only the resource differs
+	offer := challengeOffer(t, handler, "http://shop.test/cheap-endpoint")
+	payload, err := x402test.GenerateValidPayload(offer, x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := x402test.PaymentHeader(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pay := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(types.HeaderXPayment, header)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := pay("http://shop.test/expensive-endpoint")
+	if rec.Code != http.StatusPaymentRequired || hit {
+		t.Fatalf("replay on another route: status %d, served %v", rec.Code, hit)
+	}
+	var rejection struct {
+		ReasonCode types.ReasonCode `json:"reasonCode"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &rejection); err != nil || rejection.ReasonCode != types.ReasonResourceMismatch {
+		t.Errorf("replay rejected with %q (%v), want %q", rejection.ReasonCode, err, types.ReasonResourceMismatch)
+	}
+
+	if rec := pay("http://shop.test/cheap-endpoint"); rec.Code != http.StatusNoContent || !hit {
+		t.Errorf("payment on its own route: status %d: %s", rec.Code, rec.Body.String())
+	}
+	facilitator.mu.Lock()
+	defer facilitator.mu.Unlock()
+	want := []string{"http://shop.test/expensive-endpoint", "http://shop.test/cheap-endpoint"}
+	if len(facilitator.resources) != 2 || facilitator.resources[0] != want[0] || facilitator.resources[1] != want[1] {
+		t.Errorf("facilitator verified for %q, want %q", facilitator.resources, want)
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bytes"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// ResponseValidation selects what WithResponseValidation does with a paid
+// response that does not match its price tag's output schema
+type ResponseValidation string
+
+const (
+	ResponseValidationLog    ResponseValidation = "log"    // Log the mismatch and send the response as is
+	ResponseValidationReject ResponseValidation = "reject" // Log it and answer 500 instead (nothing is settled)
+)
+
+// maxValidatedResponse is the largest response body checked against an
+// output schema; larger ones are passed through unchecked
+const maxValidatedResponse = 1 << 20
+
+// WithResponseValidation checks the JSON body of every successful paid
+// response against the price tag's output schema (see
+// PriceTagBuilder.OutputSchema), to catch handlers drifting from what the
+// 402 advertises; meant for staging. Routes without a schema, non-JSON
+// responses and error statuses are not checked. ResponseValidationReject
+// holds each response until it is checked, so it cannot stream: a handler
+// that flushes is passed through and only logged.
+func WithResponseValidation(mode ResponseValidation) Option {
+	return func(m *X402Middleware) {
+		m.responseValidation = mode
+	}
+}
+
+// validateResponses wraps next to check its responses against schema
+// (next itself if validation is off or the route has no schema)
+func (m *X402Middleware) validateResponses(next http.Handler, schema *types.JSONSchema) http.Handler {
+	if schema == nil || (m.responseValidation != ResponseValidationLog && m.responseValidation != ResponseValidationReject) {
+		return next
+	}
+	hold := m.responseValidation == ResponseValidationReject
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &schemaWriter{ResponseWriter: w, status: http.StatusOK, hold: hold}
+		next.ServeHTTP(sw, r)
+
+		if sw.checkable() {
+			if err := schema.Validate(sw.body.Bytes()); err != nil {
+				log.Printf("x402: %s response does not match its output schema: %v", r.URL.Path, err)
+				if hold && !sw.flushed {
+					w.Header().Del("Content-Length")
+					http.Error(w, "response does not match the advertised output schema", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+		sw.commit()
+	})
+}
+
+// schemaWriter keeps a copy of a response body for schema validation,
+// holding the response back entirely when hold is set
+type schemaWriter struct {
+	http.ResponseWriter
+	hold        bool
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	skipped     bool // Body too large to check
+	flushed     bool // Response passed through (not held)
+}
+
+func (sw *schemaWriter) WriteHeader(statusCode int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+	sw.status = statusCode
+	if !sw.hold {
+		sw.flushed = true
+		sw.ResponseWriter.WriteHeader(statusCode)
+	}
+}
+
+func (sw *schemaWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if !sw.skipped {
+		if sw.body.Len()+len(b) > maxValidatedResponse {
+			sw.skipped = true
+			if !sw.flushed {
+				sw.commit()
+			}
+			sw.body.Reset()
+		} else {
+			sw.body.Write(b)
+		}
+	}
+	if sw.flushed {
+		return sw.ResponseWriter.Write(b)
+	}
+	return len(b), nil
+}
+
+// Flush passes the response through from here on
+func (sw *schemaWriter) Flush() {
+	if !sw.flushed {
+		sw.commit()
+	}
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// checkable reports whether the response is a complete successful JSON body
+func (sw *schemaWriter) checkable() bool {
+	if sw.skipped || sw.status < 200 || sw.status > 299 || sw.status == http.StatusNoContent {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(sw.Header().Get("Content-Type"))
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// commit writes the held status and body to the underlying writer
+func (sw *schemaWriter) commit() {
+	if sw.flushed {
+		return
+	}
+	sw.flushed = true
+	sw.wroteHeader = true
+	sw.ResponseWriter.WriteHeader(sw.status)
+	if sw.body.Len() > 0 {
+		sw.ResponseWriter.Write(sw.body.Bytes())
+	}
+}
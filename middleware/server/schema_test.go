@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// forecastSchema is the output schema of the test resource
+var forecastSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []string{"city", "temperature"},
+	"properties": map[string]interface{}{
+		"city":        map[string]interface{}{"type": "string"},
+		"temperature": map[string]interface{}{"type": "number"},
+	},
+}
+
+// schemaPriceTag prices the x402test requirements, with schema set
+// through the builder
+func schemaPriceTag(schema interface{}) *PriceTag {
+	tag := NewPriceTagBuilder().OutputSchema(schema).Build()
+	outputSchema := tag.Requirements.OutputSchema
+	tag.Requirements = x402test.Requirements()
+	tag.Requirements.OutputSchema = outputSchema
+	return tag
+}
+
+// jsonBody answers with a JSON body
+func jsonBody(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(body))
+	})
+}
+
+// captureLog redirects the standard logger for the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	out := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(out) })
+	return &buf
+}
+
+func TestProtectAdvertisesOutputSchema(t *testing.T) {
+	handler := NewX402Middleware("http://facilitator.test").Protect(http.NotFoundHandler(), schemaPriceTag(forecastSchema))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forecast", nil))
+	var body struct {
+		Requirements types.PaymentRequirements `json:"payment_requirements"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := body.Requirements.ParseOutputSchema()
+	if err != nil || schema == nil {
+		t.Fatalf("402 output schema %s (%v)", body.Requirements.OutputSchema, err)
+	}
+	if schema.Validate([]byte(`{"city": "Oslo", "temperature": 4}`)) != nil || schema.Validate([]byte(`{"city": "Oslo"}`)) == nil {
+		t.Errorf("advertised schema %s is not the price tag's", schema.Raw())
+	}
+}
+
+func TestProtectRefusesInvalidOutputSchema(t *testing.T) {
+	m := NewX402Middleware("http://facilitator.test")
+	for name, schema := range map[string]interface{}{
+		"unknown type":  map[string]interface{}{"type": "text"},
+		"not JSON":      json.RawMessage(`{"type":`),
+		"unmarshalable": map[string]interface{}{"type": make(chan int)},
+	} {
+		tag := schemaPriceTag(schema)
+		if err := m.CheckPriceTag(tag); err == nil || !strings.Contains(err.Error(), "output schema") {
+			t.Errorf("%s: CheckPriceTag = %v, want an output schema error", name, err)
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: Protect accepted the price tag", name)
+				}
+			}()
+			m.Protect(http.NotFoundHandler(), tag)
+		}()
+	}
+	if err := m.CheckPriceTag(schemaPriceTag(forecastSchema)); err != nil {
+		t.Errorf("valid schema refused: %v", err)
+	}
+}
+
+func TestResponseValidation(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	const valid, invalid = `{"city": "Oslo", "temperature": 4}`, `{"city": "Oslo", "temperature": "cold"}`
+
+	for _, tc := range []struct {
+		name   string
+		mode   ResponseValidation
+		body   string
+		status int
+		logged bool
+	}{
+		{"off", "", invalid, http.StatusOK, false},
+		{"log, matching", ResponseValidationLog, valid, http.StatusOK, false},
+		{"log, mismatch", ResponseValidationLog, invalid, http.StatusOK, true},
+		{"reject, matching", ResponseValidationReject, valid, http.StatusOK, false},
+		{"reject, mismatch", ResponseValidationReject, invalid, http.StatusInternalServerError, true},
+	} {
+		buf := captureLog(t)
+		handler := NewX402Middleware(facilitator.URL, WithResponseValidation(tc.mode)).Protect(jsonBody(tc.body), schemaPriceTag(forecastSchema))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/forecast"))
+		if rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.status)
+		}
+		if tc.status == http.StatusOK && rec.Body.String() != tc.body {
+			t.Errorf("%s: body %q, want the handler's", tc.name, rec.Body.String())
+		}
+		if logged := strings.Contains(buf.String(), "does not match its output schema: /temperature"); logged != tc.logged {
+			t.Errorf("%s: mismatch logged %v, want %v: %s", tc.name, logged, tc.logged, buf.String())
+		}
+	}
+}
+
+func TestResponseValidationSkipsUncheckableResponses(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	for name, next := range map[string]http.Handler{
+		"plain text": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("not json"))
+		}),
+		"error status": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error": "busy"}`))
+		}),
+		"streamed": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"city": `))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(`7}`))
+		}),
+	} {
+		handler := NewX402Middleware(facilitator.URL, WithResponseValidation(ResponseValidationReject)).Protect(next, schemaPriceTag(forecastSchema))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/forecast"))
+		if rec.Code == http.StatusInternalServerError {
+			t.Errorf("%s: replaced by a 500: %s", name, rec.Body.String())
+		}
+	}
+}
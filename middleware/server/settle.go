@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// serveAndSettle runs the protected handler with its response held back, then
+// settles the payment if the handler succeeded, with the facilitator that
+// verified it
+//
+// While the response is buffered the settlement result is attached as the
+// X-Payment-Response header (or turned into a 402 if settlement fails). Once
+// the handler flushes, the result can only be reported via OnPaymentSettled.
+// If the handler panics nothing is settled.
+func (m *X402Middleware) serveAndSettle(w http.ResponseWriter, r *http.Request, next http.Handler, payload types.PaymentPayload, requirements *types.PaymentRequirements, message PaymentMessage, verified *verification) {
+	sw := &settleWriter{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(sw, r)
+
+	// Handler failed: skip settlement so the authorization simply expires
+	if sw.status >= http.StatusBadRequest {
+		sw.commit()
+		return
+	}
+
+	// Settled under the ID the facilitator verified the payment under
+	ctx := r.Context()
+	if verified.SettlementID != "" {
+		ctx = types.WithSettlementID(ctx, verified.SettlementID)
+	}
+	settleResp, err := m.settlePayment(ctx, verified.facilitatorURL, &types.SettleRequest{
+		PaymentPayload:      payload,
+		PaymentRequirements: *requirements,
+		VerificationID:      verified.VerificationID,
+	})
+	if err == nil && !settleResp.Success {
+		err = fmt.Errorf("settlement failed: %s", settleResp.Error)
+	}
+	if m.onPaymentSettled != nil {
+		m.onPaymentSettled(r, settleResp, err)
+	}
+
+	if sw.flushed {
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		http.Error(w, "payment settlement timed out: the facilitator did not answer in time", http.StatusGatewayTimeout)
+		return
+	}
+	if err != nil {
+		var code types.ReasonCode
+		if settleResp != nil {
+			code = settleResp.ReasonCode
+		}
+		m.send402WithReason(w, requestedVersion(r), message.describe(m.offerRequirements(r, requirements)), message, err.Error(), code)
+		return
+	}
+	if respJSON, marshalErr := json.Marshal(settleResp); marshalErr == nil {
+		w.Header().Set("X-Payment-Response", string(respJSON))
+	}
+	sw.commit()
+}
+
+// settlePayment calls the facilitator at url to settle a payment under the
+// settlement ID of ctx, giving up when ctx (the paid request's context) is done
+func (m *X402Middleware) settlePayment(ctx context.Context, url string, req *types.SettleRequest) (*types.SettleResponse, error) {
+	// Marshal request
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Call facilitator
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/settle", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if id := types.SettlementIDFromContext(ctx); id != "" {
+		httpReq.Header.Set(types.HeaderIdempotencyKey, id)
+	}
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("facilitator request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Parse response
+	var settleResp types.SettleResponse
+	if err := json.NewDecoder(resp.Body).Decode(&settleResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &settleResp, nil
+}
+
+// settleWriter buffers a handler's response until settlement has run,
+// switching to pass-through once the handler explicitly flushes
+type settleWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	flushed     bool
+}
+
+func (sw *settleWriter) WriteHeader(statusCode int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+	sw.status = statusCode
+	if sw.flushed {
+		sw.ResponseWriter.WriteHeader(statusCode)
+	}
+}
+
+func (sw *settleWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if sw.flushed {
+		return sw.ResponseWriter.Write(b)
+	}
+	return sw.body.Write(b)
+}
+
+// Flush commits the buffered response and streams everything after it
+func (sw *settleWriter) Flush() {
+	if !sw.flushed {
+		sw.commit()
+	}
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// commit writes the buffered status and body to the underlying writer
+func (sw *settleWriter) commit() {
+	if sw.flushed {
+		return
+	}
+	sw.flushed = true
+	sw.wroteHeader = true
+	sw.ResponseWriter.WriteHeader(sw.status)
+	if sw.body.Len() > 0 {
+		sw.ResponseWriter.Write(sw.body.Bytes())
+		sw.body.Reset()
+	}
+}
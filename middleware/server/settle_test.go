@@ -0,0 +1,247 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// fixturePriceTag charges the x402test requirements
+func fixturePriceTag() *PriceTag {
+	return &PriceTag{Requirements: x402test.Requirements()}
+}
+
+// paidRequest returns a request paying the x402test requirements
+func paidRequest(t *testing.T, method, target string) *http.Request {
+	t.Helper()
+	payload, err := x402test.GenerateValidPayload(x402test.Requirements(), x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := x402test.PaymentHeader(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set(types.HeaderXPayment, header)
+	return req
+}
+
+// settledOutcome records what OnPaymentSettled was called with
+type settledOutcome struct {
+	calls int
+	resp  *types.SettleResponse
+	err   error
+}
+
+func settleAfterSuccess(t *testing.T, behavior x402test.Behavior) (*X402Middleware, *x402test.FakeFacilitator, *settledOutcome) {
+	t.Helper()
+	facilitator := x402test.NewFakeFacilitator(t, behavior)
+	outcome := &settledOutcome{}
+	m := NewX402Middleware(facilitator.URL,
+		WithSettleAfterSuccess(),
+		WithOnPaymentSettled(func(r *http.Request, resp *types.SettleResponse, err error) {
+			outcome.calls++
+			outcome.resp, outcome.err = resp, err
+		}),
+	)
+	return m, facilitator, outcome
+}
+
+func TestSettleAfterSuccessSettlesSuccessfulResponses(t *testing.T) {
+	m, facilitator, outcome := settleAfterSuccess(t, x402test.BehaviorAccept)
+	handler := m.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("content"))
+	}), fixturePriceTag())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusOK || rec.Body.String() != "content" {
+		t.Fatalf("status %d, body %q; want the handler's response", rec.Code, rec.Body.String())
+	}
+	if n := len(facilitator.Settled()); n != 1 {
+		t.Errorf("%d settlements, want 1", n)
+	}
+	if rec.Header().Get("X-Payment-Response") == "" {
+		t.Error("settled response carries no X-Payment-Response")
+	}
+	if outcome.calls != 1 || outcome.err != nil || outcome.resp == nil || !outcome.resp.Success {
+		t.Errorf("OnPaymentSettled: %d calls, resp %+v, err %v", outcome.calls, outcome.resp, outcome.err)
+	}
+}
+
+func TestSettleAfterSuccessSkipsFailedHandlers(t *testing.T) {
+	m, facilitator, outcome := settleAfterSuccess(t, x402test.BehaviorAccept)
+	handler := m.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "backend down", http.StatusInternalServerError)
+	}), fixturePriceTag())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want the handler's %d", rec.Code, http.StatusInternalServerError)
+	}
+	if n := len(facilitator.Settled()); n != 0 || outcome.calls != 0 {
+		t.Errorf("a failed handler was charged: %d settlements, %d callbacks", n, outcome.calls)
+	}
+}
+
+func TestSettleAfterSuccessSkipsPanickingHandlers(t *testing.T) {
+	m, facilitator, outcome := settleAfterSuccess(t, x402test.BehaviorAccept)
+	handler := m.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("handler bug")
+	}), fixturePriceTag())
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("the handler's panic was swallowed")
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), paidRequest(t, http.MethodGet, "/resource"))
+	}()
+	if n := len(facilitator.Settled()); n != 0 || outcome.calls != 0 {
+		t.Errorf("a panicking handler was charged: %d settlements, %d callbacks", n, outcome.calls)
+	}
+}
+
+func TestSettleAfterSuccessReportsFlushedResponsesThroughCallback(t *testing.T) {
+	m, facilitator, outcome := settleAfterSuccess(t, x402test.BehaviorAccept)
+	handler := m.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("chunk"))
+		w.(http.Flusher).Flush()
+		// The stream fails after the status went out
+		w.Write([]byte(`{"error":"stream broke"}`))
+	}), fixturePriceTag())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusOK || !rec.Flushed {
+		t.Fatalf("status %d, flushed %v; want the streamed 200", rec.Code, rec.Flushed)
+	}
+	if n := len(facilitator.Settled()); n != 1 {
+		t.Errorf("%d settlements, want 1 for the 200", n)
+	}
+	if rec.Header().Get("X-Payment-Response") != "" {
+		t.Error("X-Payment-Response was added after the headers were flushed")
+	}
+	if outcome.calls != 1 || outcome.err != nil || !outcome.resp.Success {
+		t.Errorf("OnPaymentSettled: %d calls, err %v", outcome.calls, outcome.err)
+	}
+}
+
+func TestSettleAfterSuccessTurnsFailedSettlementInto402(t *testing.T) {
+	m, _, outcome := settleAfterSuccess(t, x402test.BehaviorSettleFails)
+	handler := m.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("content"))
+	}), fixturePriceTag())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusPaymentRequired {
+		t.Errorf("status %d, want %d", rec.Code, http.StatusPaymentRequired)
+	}
+	if rec.Body.String() == "content" {
+		t.Error("the content was served although settlement failed")
+	}
+	if outcome.calls != 1 || outcome.err == nil {
+		t.Errorf("OnPaymentSettled: %d calls, err %v; want the failure", outcome.calls, outcome.err)
+	}
+}
+
+func TestSettleAfterSuccessForwardsVerificationID(t *testing.T) {
+	var settled types.SettleRequest
+	facilitator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/verify":
+			json.NewEncoder(w).Encode(types.VerifyResponse{IsValid: true, VerificationID: "0xverified"})
+		case "/settle":
+			json.NewDecoder(r.Body).Decode(&settled)
+			json.NewEncoder(w).Encode(types.SettleResponse{Success: true})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer facilitator.Close()
+
+	handler := NewX402Middleware(facilitator.URL, WithSettleAfterSuccess()).Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("content"))
+	}), fixturePriceTag())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if settled.VerificationID != "0xverified" {
+		t.Errorf("settle sent verification ID %q, want the one /verify returned", settled.VerificationID)
+	}
+}
+
+func TestSettleAfterSuccessCarriesSettlementID(t *testing.T) {
+	const assigned = "1b4e28ba-2fa1-4d2e-883f-0016d3cca427"
+	var verifyKey, settleKey string
+	facilitator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/verify":
+			verifyKey = r.Header.Get(types.HeaderIdempotencyKey)
+			id := verifyKey
+			if id == "" {
+				id = assigned
+			}
+			json.NewEncoder(w).Encode(types.VerifyResponse{IsValid: true, SettlementID: id})
+		case "/settle":
+			settleKey = r.Header.Get(types.HeaderIdempotencyKey)
+			json.NewEncoder(w).Encode(types.SettleResponse{Success: true, SettlementID: settleKey})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer facilitator.Close()
+	handler := NewX402Middleware(facilitator.URL, WithSettleAfterSuccess()).Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("content"))
+	}), fixturePriceTag())
+
+	for _, tc := range []struct {
+		name, clientKey, want string
+	}{
+		{"assigned by the facilitator", "", assigned},
+		{"chosen by the client", "order-2026-10-14_0001", "order-2026-10-14_0001"},
+	} {
+		verifyKey, settleKey = "", ""
+		req := paidRequest(t, http.MethodGet, "/resource")
+		if tc.clientKey != "" {
+			req.Header.Set(types.HeaderIdempotencyKey, tc.clientKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tc.name, rec.Code, rec.Body.String())
+		}
+		if verifyKey != tc.clientKey || settleKey != tc.want {
+			t.Errorf("%s: /verify sent key %q, /settle %q; want %q and %q", tc.name, verifyKey, settleKey, tc.clientKey, tc.want)
+		}
+		var settlement types.SettleResponse
+		if err := json.Unmarshal([]byte(rec.Header().Get("X-Payment-Response")), &settlement); err != nil {
+			t.Fatal(err)
+		}
+		if settlement.SettlementID != tc.want {
+			t.Errorf("%s: X-Payment-Response carries settlement ID %q, want %q", tc.name, settlement.SettlementID, tc.want)
+		}
+	}
+
+	// A malformed key is refused before anything is verified
+	verifyKey = "unset"
+	req := paidRequest(t, http.MethodGet, "/resource")
+	req.Header.Set(types.HeaderIdempotencyKey, "too short")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || verifyKey != "unset" {
+		t.Errorf("malformed key: status %d, verified %v; want a 400", rec.Code, verifyKey != "unset")
+	}
+}
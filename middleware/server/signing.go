@@ -0,0 +1,32 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"net/http"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// WithRequirementsSigner signs every 402's requirements with key so clients
+// can detect a tampered PayTo (see types.SignRequirements). The signer address
+// goes in requirements.Extra and the signature in X-Payment-Required-Signature.
+// The signature always covers the v1 form of the requirements.
+func WithRequirementsSigner(key *ecdsa.PrivateKey) Option {
+	return func(m *X402Middleware) {
+		m.requirementsKey = key
+	}
+}
+
+// signRequirements returns the requirements to send in a 402, signed when a
+// signer is configured; the signature header is set on w
+func (m *X402Middleware) signRequirements(w http.ResponseWriter, requirements *types.PaymentRequirements) *types.PaymentRequirements {
+	if m.requirementsKey == nil {
+		return requirements
+	}
+	signed, signature, err := types.SignRequirements(requirements, m.requirementsKey)
+	if err != nil {
+		return requirements
+	}
+	w.Header().Set("X-Payment-Required-Signature", signature)
+	return signed
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestWithRequirementsSignerSigns402(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, signed := range []bool{false, true} {
+		var opts []Option
+		if signed {
+			opts = append(opts, WithRequirementsSigner(key))
+		}
+		rec := httptest.NewRecorder()
+		NewX402Middleware("http://facilitator.test", opts...).Protect(http.NotFoundHandler(), fixturePriceTag()).
+			ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resource", nil))
+
+		signature := rec.Header().Get("X-Payment-Required-Signature")
+		if !signed {
+			if signature != "" {
+				t.Errorf("unsigned middleware sent signature %q", signature)
+			}
+			continue
+		}
+		var body struct {
+			Requirements types.PaymentRequirements `json:"payment_requirements"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		signer, err := types.VerifyRequirementsSignature(&body.Requirements, signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signer != crypto.PubkeyToAddress(key.PublicKey) {
+			t.Errorf("402 signed by %s, want the configured key", signer.Hex())
+		}
+	}
+}
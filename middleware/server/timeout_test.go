@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// slowFacilitator answers like an accepting facilitator, after stalling
+// the given path until its delay passes or the caller gives up
+func slowFacilitator(t *testing.T, path string, delay time.Duration) string {
+	t.Helper()
+	fake := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	target, err := url.Parse(fake.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == path {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+			}
+			if r.Context().Err() != nil {
+				return
+			}
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestRouteVerifyTimeoutAnswers504(t *testing.T) {
+	m := NewX402Middleware(slowFacilitator(t, "/verify", 200*time.Millisecond))
+	capped := fixturePriceTag()
+	capped.VerifyTimeout = 20 * time.Millisecond
+
+	var hit atomic.Bool
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	m.Protect(content(&hit), capped).ServeHTTP(rec, paidRequest(t, http.MethodGet, "/capped"))
+	if rec.Code != http.StatusGatewayTimeout || hit.Load() {
+		t.Fatalf("status %d, reached %v; want a 504", rec.Code, hit.Load())
+	}
+	if !strings.Contains(rec.Body.String(), "verification timed out") {
+		t.Errorf("504 body %q, want the timeout named", rec.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Errorf("answered after %v, want the route's 20ms cap", elapsed)
+	}
+
+	// Routes without a cap wait for the slow facilitator
+	rec = httptest.NewRecorder()
+	m.Protect(content(&hit), fixturePriceTag()).ServeHTTP(rec, paidRequest(t, http.MethodGet, "/uncapped"))
+	if rec.Code != http.StatusOK || !hit.Load() {
+		t.Errorf("uncapped route: status %d, reached %v", rec.Code, hit.Load())
+	}
+}
+
+func TestVerifyFollowsRequestContext(t *testing.T) {
+	m := NewX402Middleware(slowFacilitator(t, "/verify", 300*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var hit atomic.Bool
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	m.Protect(content(&hit), fixturePriceTag()).ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource").WithContext(ctx))
+	if rec.Code != http.StatusGatewayTimeout || hit.Load() {
+		t.Errorf("status %d, reached %v; want a 504 once the request's deadline passes", rec.Code, hit.Load())
+	}
+	if elapsed := time.Since(start); elapsed >= 300*time.Millisecond {
+		t.Errorf("answered after %v, want the request's deadline", elapsed)
+	}
+}
+
+func TestSettleFollowsRequestContext(t *testing.T) {
+	m := NewX402Middleware(slowFacilitator(t, "/settle", 300*time.Millisecond), WithSettleAfterSuccess())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var hit atomic.Bool
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	m.Protect(content(&hit), fixturePriceTag()).ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource").WithContext(ctx))
+	if !hit.Load() {
+		t.Fatal("the handler did not run before settlement")
+	}
+	if rec.Code != http.StatusGatewayTimeout || !strings.Contains(rec.Body.String(), "settlement timed out") {
+		t.Errorf("status %d, body %q; want a settlement 504", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "content") {
+		t.Error("unpaid content was released")
+	}
+	if elapsed := time.Since(start); elapsed >= 300*time.Millisecond {
+		t.Errorf("answered after %v, want the request's deadline", elapsed)
+	}
+}
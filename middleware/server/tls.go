@@ -0,0 +1,47 @@
+package server
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/x402-rs/x402-go/pkg/transport"
+)
+
+// WithClientCertificate presents the PEM certificate and key in certFile
+// and keyFile to the facilitator, for facilitators requiring mutual TLS
+// NewX402Middleware panics if they cannot be loaded, so a misconfigured
+// server fails at startup.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(m *X402Middleware) {
+		m.facilitatorTLS = append(m.facilitatorTLS, transport.WithClientCertificate(certFile, keyFile))
+	}
+}
+
+// WithRootCAs trusts pool instead of the system roots for the facilitator's
+// certificate, e.g. an internal CA (see transport.LoadCertPool)
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(m *X402Middleware) {
+		m.facilitatorTLS = append(m.facilitatorTLS, transport.WithRootCAs(pool))
+	}
+}
+
+// WithServerName verifies the facilitator's certificate against name
+// instead of the host in the facilitator URL
+func WithServerName(name string) Option {
+	return func(m *X402Middleware) {
+		m.facilitatorTLS = append(m.facilitatorTLS, transport.WithServerName(name))
+	}
+}
+
+// applyFacilitatorTLS replaces the facilitator transport with a dedicated
+// one when TLS options are set, keeping the WithTransport tuning
+func (m *X402Middleware) applyFacilitatorTLS() {
+	if len(m.facilitatorTLS) == 0 {
+		return
+	}
+	rt, err := transport.AuthorizedTransport(m.transportConfig, m.facilitatorTLS...)
+	if err != nil {
+		panic(fmt.Sprintf("x402: invalid facilitator TLS configuration: %v", err))
+	}
+	m.client.Transport = rt
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"github.com/x402-rs/x402-go/internal/testcerts"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// mutualTLSFacilitator puts a fake facilitator behind a TLS server that
+// requires client certificates from ca
+func mutualTLSFacilitator(t *testing.T, ca *testcerts.Authority) (*x402test.FakeFacilitator, string) {
+	t.Helper()
+	fake := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	target, err := url.Parse(fake.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(httputil.NewSingleHostReverseProxy(target))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.Issue(t, "facilitator", testcerts.Server, "facilitator.internal").Certificate},
+		ClientCAs:    ca.Pool(),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Refused handshakes are expected
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return fake, server.URL
+}
+
+func TestProtectVerifiesOverMutualTLS(t *testing.T) {
+	ca := testcerts.NewAuthority(t, "Internal CA")
+	fake, facilitatorURL := mutualTLSFacilitator(t, ca)
+	shop := ca.Issue(t, "shop", testcerts.Client)
+
+	// The certificate names the facilitator's internal host, not the IP dialed
+	var hit bool
+	m := NewX402Middleware(facilitatorURL,
+		WithClientCertificate(shop.CertFile, shop.KeyFile),
+		WithRootCAs(ca.Pool()),
+		WithServerName("facilitator.internal"))
+	rec := httptest.NewRecorder()
+	m.Protect(served(&hit), fixturePriceTag()).ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+	if !hit || fake.VerifyCount() != 1 {
+		t.Errorf("with a client certificate: status %d, reached %v, %d verifies; want it served", rec.Code, hit, fake.VerifyCount())
+	}
+
+	for name, opts := range map[string][]Option{
+		"no client certificate": {WithRootCAs(ca.Pool()), WithServerName("facilitator.internal")},
+		"untrusted server":      {WithClientCertificate(shop.CertFile, shop.KeyFile), WithServerName("facilitator.internal")},
+		"wrong server name":     {WithClientCertificate(shop.CertFile, shop.KeyFile), WithRootCAs(ca.Pool())},
+	} {
+		hit = false
+		rec := httptest.NewRecorder()
+		NewX402Middleware(facilitatorURL, opts...).Protect(served(&hit), fixturePriceTag()).ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+		if hit || rec.Code == http.StatusOK {
+			t.Errorf("%s: status %d, reached %v; want the payment unverified", name, rec.Code, hit)
+		}
+	}
+	if fake.VerifyCount() != 1 {
+		t.Errorf("%d verifies reached the facilitator, want only the authenticated one", fake.VerifyCount())
+	}
+}
+
+func TestClientCertificateMustLoad(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewX402Middleware accepted a missing client certificate")
+		}
+	}()
+	NewX402Middleware("https://facilitator.internal", WithClientCertificate("missing.pem", "missing.key"))
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/transport"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+func TestVerificationsReuseFacilitatorConnection(t *testing.T) {
+	facilitator := x402test.NewFakeFacilitator(t, x402test.BehaviorAccept)
+	var stats transport.ConnStats
+	m := NewX402Middleware(facilitator.URL, WithTransport(transport.Config{OnConn: stats.Observe}))
+	handler := m.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("content"))
+	}), fixturePriceTag())
+
+	const requests = 50
+	for i := 0; i < requests; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, paidRequest(t, http.MethodGet, "/resource"))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d: %s", i, rec.Code, rec.Body.String())
+		}
+	}
+	if n := facilitator.VerifyCount(); n != requests {
+		t.Errorf("%d verifications, want %d", n, requests)
+	}
+	if stats.Opened() != 1 || stats.Reused() != requests-1 {
+		t.Errorf("%d connections opened, %d reused; want 1 and %d", stats.Opened(), stats.Reused(), requests-1)
+	}
+}
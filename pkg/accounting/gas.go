@@ -0,0 +1,166 @@
+// Package accounting records the gas the facilitator spends settling
+// payments so it can be billed back to the receiving merchants.
+package accounting
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// dayFormat is the layout of daily bucket keys (UTC)
+const dayFormat = "2006-01-02"
+
+// PriceSource converts a network's native token to USD
+type PriceSource interface {
+	NativeUSDPrice(ctx context.Context, network types.Network) (float64, error)
+}
+
+// GasRecord is the gas spent on one successful settlement
+type GasRecord struct {
+	PayTo             string
+	Network           types.Network
+	TransactionHash   string
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int // wei
+	Time              time.Time
+}
+
+// Fee returns the settlement cost in wei of the native token
+func (r *GasRecord) Fee() *big.Int {
+	if r.EffectiveGasPrice == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(r.GasUsed), r.EffectiveGasPrice)
+}
+
+// GasTotal is the aggregated gas spend for one payTo on one network and day
+type GasTotal struct {
+	PayTo       string        `json:"payTo"`
+	Network     types.Network `json:"network"`
+	Date        string        `json:"date"` // YYYY-MM-DD (UTC)
+	Settlements int           `json:"settlements"`
+	GasUsed     uint64        `json:"gas_used"`
+	FeeNative   string        `json:"fee_native"` // wei of the native token
+	FeeUSD      float64       `json:"fee_usd,omitempty"`
+
+	feeNative *big.Int
+}
+
+type bucketKey struct {
+	payTo   string
+	network types.Network
+	day     string
+}
+
+// GasLedger aggregates gas spend per payTo, network and day in memory
+type GasLedger struct {
+	prices PriceSource
+
+	mu      sync.RWMutex
+	buckets map[bucketKey]*GasTotal
+}
+
+// NewGasLedger creates a ledger; prices may be nil to skip USD conversion
+func NewGasLedger(prices PriceSource) *GasLedger {
+	return &GasLedger{
+		prices:  prices,
+		buckets: make(map[bucketKey]*GasTotal),
+	}
+}
+
+// Record adds a settlement to its daily bucket
+func (l *GasLedger) Record(ctx context.Context, record GasRecord) {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	fee := record.Fee()
+
+	// Price lookups happen outside the lock; a failed lookup only loses the USD figure
+	var feeUSD float64
+	if l.prices != nil && fee.Sign() > 0 {
+		price, err := l.prices.NativeUSDPrice(ctx, record.Network)
+		if err != nil {
+			log.Printf("accounting: native price lookup failed for %s: %v", record.Network, err)
+		} else {
+			// Native tokens of supported EVM chains all use 18 decimals
+			native, _ := new(big.Float).Quo(new(big.Float).SetInt(fee), big.NewFloat(1e18)).Float64()
+			feeUSD = native * price
+		}
+	}
+
+	key := bucketKey{
+		payTo:   strings.ToLower(record.PayTo),
+		network: record.Network,
+		day:     record.Time.UTC().Format(dayFormat),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	total, ok := l.buckets[key]
+	if !ok {
+		total = &GasTotal{
+			PayTo:     key.payTo,
+			Network:   key.network,
+			Date:      key.day,
+			feeNative: new(big.Int),
+		}
+		l.buckets[key] = total
+	}
+	total.Settlements++
+	total.GasUsed += record.GasUsed
+	total.feeNative.Add(total.feeNative, fee)
+	total.FeeUSD += feeUSD
+}
+
+// Totals returns the daily totals for payTo (all payTos when empty) with
+// dates in [from, to]; zero bounds are open. Results are ordered by payTo,
+// date and network.
+func (l *GasLedger) Totals(payTo string, from, to time.Time) []GasTotal {
+	payTo = strings.ToLower(payTo)
+	var fromDay, toDay string
+	if !from.IsZero() {
+		fromDay = from.UTC().Format(dayFormat)
+	}
+	if !to.IsZero() {
+		toDay = to.UTC().Format(dayFormat)
+	}
+
+	l.mu.RLock()
+	totals := make([]GasTotal, 0, len(l.buckets))
+	for key, total := range l.buckets {
+		if payTo != "" && key.payTo != payTo {
+			continue
+		}
+		// Day keys sort lexically in date order
+		if fromDay != "" && key.day < fromDay {
+			continue
+		}
+		if toDay != "" && key.day > toDay {
+			continue
+		}
+		t := *total
+		t.FeeNative = total.feeNative.String()
+		t.feeNative = nil
+		totals = append(totals, t)
+	}
+	l.mu.RUnlock()
+
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].PayTo != totals[j].PayTo {
+			return totals[i].PayTo < totals[j].PayTo
+		}
+		if totals[i].Date != totals[j].Date {
+			return totals[i].Date < totals[j].Date
+		}
+		return totals[i].Network < totals[j].Network
+	})
+	return totals
+}
@@ -0,0 +1,97 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// fixedPrice quotes every native token at the same USD price
+type fixedPrice struct {
+	usd float64
+	err error
+}
+
+func (p fixedPrice) NativeUSDPrice(ctx context.Context, network types.Network) (float64, error) {
+	return p.usd, p.err
+}
+
+const (
+	shop   = "0x00000000000000000000000000000000000000B0"
+	market = "0x00000000000000000000000000000000000000b1"
+)
+
+func at(t *testing.T, value string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestGasLedgerBucketsPerPayToPerDay(t *testing.T) {
+	ledger := NewGasLedger(nil)
+	gwei := big.NewInt(1_000_000_000)
+	for _, record := range []GasRecord{
+		{PayTo: shop, Network: "base", GasUsed: 60000, EffectiveGasPrice: gwei, Time: at(t, "2026-03-01T00:00:00Z")},
+		{PayTo: shop, Network: "base", GasUsed: 50000, EffectiveGasPrice: big.NewInt(2_000_000_000), Time: at(t, "2026-03-01T23:59:59Z")},
+		// 01:30 in UTC+02:00 is still 1 March in UTC
+		{PayTo: "0x00000000000000000000000000000000000000b0", Network: "base", GasUsed: 40000, EffectiveGasPrice: gwei, Time: at(t, "2026-03-02T01:30:00+02:00")},
+		{PayTo: shop, Network: "base", GasUsed: 70000, EffectiveGasPrice: gwei, Time: at(t, "2026-03-02T00:00:00Z")},
+		{PayTo: shop, Network: "polygon", GasUsed: 80000, EffectiveGasPrice: gwei, Time: at(t, "2026-03-01T12:00:00Z")},
+		{PayTo: market, Network: "base", GasUsed: 65000, EffectiveGasPrice: gwei, Time: at(t, "2026-03-01T12:00:00Z")},
+	} {
+		ledger.Record(context.Background(), record)
+	}
+
+	totals := ledger.Totals(shop, time.Time{}, time.Time{})
+	want := []GasTotal{
+		{PayTo: "0x00000000000000000000000000000000000000b0", Network: "base", Date: "2026-03-01", Settlements: 3, GasUsed: 150000, FeeNative: "200000000000000"},
+		{PayTo: "0x00000000000000000000000000000000000000b0", Network: "polygon", Date: "2026-03-01", Settlements: 1, GasUsed: 80000, FeeNative: "80000000000000"},
+		{PayTo: "0x00000000000000000000000000000000000000b0", Network: "base", Date: "2026-03-02", Settlements: 1, GasUsed: 70000, FeeNative: "70000000000000"},
+	}
+	if len(totals) != len(want) {
+		t.Fatalf("%d totals for the shop, want %d: %+v", len(totals), len(want), totals)
+	}
+	for i := range want {
+		if totals[i] != want[i] {
+			t.Errorf("total %d = %+v, want %+v", i, totals[i], want[i])
+		}
+	}
+
+	if all := ledger.Totals("", time.Time{}, time.Time{}); len(all) != 4 || all[3].PayTo != market {
+		t.Errorf("all payTos: %+v, want the shop's three buckets then the market's", all)
+	}
+	day := at(t, "2026-03-02T00:00:00Z")
+	if second := ledger.Totals(shop, day, day); len(second) != 1 || second[0].Date != "2026-03-02" {
+		t.Errorf("2 March only: %+v", second)
+	}
+	if before := ledger.Totals(shop, time.Time{}, at(t, "2026-03-01T18:00:00Z")); len(before) != 2 {
+		t.Errorf("up to 1 March: %d totals, want both networks' buckets", len(before))
+	}
+}
+
+func TestGasLedgerConvertsFeesToUSD(t *testing.T) {
+	ledger := NewGasLedger(fixedPrice{usd: 3000})
+	// 100000 gas at 10 gwei is 0.001 ETH
+	ledger.Record(context.Background(), GasRecord{PayTo: shop, Network: "base", GasUsed: 100000, EffectiveGasPrice: big.NewInt(10_000_000_000)})
+	ledger.Record(context.Background(), GasRecord{PayTo: shop, Network: "base", GasUsed: 100000, EffectiveGasPrice: big.NewInt(10_000_000_000)})
+	totals := ledger.Totals(shop, time.Time{}, time.Time{})
+	if len(totals) != 1 || math.Abs(totals[0].FeeUSD-6) > 1e-9 {
+		t.Errorf("totals %+v, want $6 of gas", totals)
+	}
+
+	// A failing price source drops only the USD figure
+	ledger = NewGasLedger(fixedPrice{err: errors.New("price feed down")})
+	ledger.Record(context.Background(), GasRecord{PayTo: shop, Network: "base", GasUsed: 100000, EffectiveGasPrice: big.NewInt(10_000_000_000)})
+	totals = ledger.Totals(shop, time.Time{}, time.Time{})
+	if len(totals) != 1 || totals[0].FeeUSD != 0 || totals[0].FeeNative != "1000000000000000" {
+		t.Errorf("totals %+v with the price feed down", totals)
+	}
+}
@@ -0,0 +1,412 @@
+package accounting
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultJournalSize is the number of settlements a journal keeps
+const DefaultJournalSize = 10000
+
+// JournalStatus is where a settlement stands
+type JournalStatus string
+
+const (
+	JournalSubmitted JournalStatus = "submitted" // Transaction sent, outcome not yet known
+	JournalUnknown   JournalStatus = "unknown"   // Gave up waiting for the receipt
+	JournalConfirmed JournalStatus = "confirmed"
+	JournalReverted  JournalStatus = "reverted"
+	JournalExpired   JournalStatus = "expired" // Never mined before validBefore
+	JournalFailed    JournalStatus = "failed"  // Rejected before anything was sent
+	JournalReorged   JournalStatus = "reorged" // Confirmed, then dropped by a reorg after validBefore
+)
+
+// InFlight reports whether the settlement's transaction may still land
+func (s JournalStatus) InFlight() bool {
+	return s == JournalSubmitted || s == JournalUnknown
+}
+
+// Final reports whether the status can no longer change
+func (s JournalStatus) Final() bool {
+	return s == JournalConfirmed || s == JournalReverted || s == JournalExpired || s == JournalReorged
+}
+
+// JournalEntry is one settlement attempt
+type JournalEntry struct {
+	ID              string        `json:"id"`
+	Time            time.Time     `json:"time"`
+	Network         types.Network `json:"network"`
+	Payer           string        `json:"payer"`
+	PayTo           string        `json:"payTo"`
+	Amount          string        `json:"amount"`
+	FacilitatorFee  string        `json:"facilitator_fee,omitempty"` // Part of Amount that is the facilitator's fee (see types.FeeBreakdown)
+	Nonce           string        `json:"nonce,omitempty"`
+	ValidBefore     int64         `json:"validBefore,omitempty"`
+	Status          JournalStatus `json:"status"`
+	Success         bool          `json:"success"`
+	TransactionHash string        `json:"transaction_hash,omitempty"`
+	BlockNumber     uint64        `json:"block_number,omitempty"`
+	BlockHash       string        `json:"block_hash,omitempty"`
+	ReasonCode      string        `json:"reason_code,omitempty"`
+	APIKey          string        `json:"api_key,omitempty"`       // Label of the key that initiated the settlement
+	ClientCert      string        `json:"client_cert,omitempty"`   // Identity of the TLS client certificate that initiated it
+	Tenant          string        `json:"tenant,omitempty"`        // Tenant the settlement was made for
+	SettlementID    string        `json:"settlement_id,omitempty"` // See types.HeaderIdempotencyKey
+}
+
+// merge folds a later record of the same settlement into e
+// Fields left empty in update are kept, and an in-flight transaction is
+// never marked failed (only the chain, via reconciliation, can settle it)
+func (e *JournalEntry) merge(update JournalEntry) {
+	if update.Network != "" {
+		e.Network = update.Network
+	}
+	if update.Payer != "" {
+		e.Payer = update.Payer
+	}
+	if update.PayTo != "" {
+		e.PayTo = update.PayTo
+	}
+	if update.Amount != "" {
+		e.Amount = update.Amount
+	}
+	if update.FacilitatorFee != "" {
+		e.FacilitatorFee = update.FacilitatorFee
+	}
+	if update.Nonce != "" {
+		e.Nonce = update.Nonce
+	}
+	if update.ValidBefore != 0 {
+		e.ValidBefore = update.ValidBefore
+	}
+	if update.TransactionHash != "" {
+		e.TransactionHash = update.TransactionHash
+	}
+	if update.BlockNumber != 0 {
+		e.BlockNumber = update.BlockNumber
+	}
+	if update.BlockHash != "" {
+		e.BlockHash = update.BlockHash
+	}
+	if update.ReasonCode != "" {
+		e.ReasonCode = update.ReasonCode
+	}
+	if update.APIKey != "" {
+		e.APIKey = update.APIKey
+	}
+	if update.ClientCert != "" {
+		e.ClientCert = update.ClientCert
+	}
+	if update.Tenant != "" {
+		e.Tenant = update.Tenant
+	}
+	if update.SettlementID != "" {
+		e.SettlementID = update.SettlementID
+	}
+	switch {
+	case update.Status == "" || (e.Status.Final() && !update.Status.Final()):
+	case e.Status.InFlight() && update.Status == JournalFailed:
+		e.Status = JournalUnknown
+	default:
+		e.Status = update.Status
+	}
+	e.Success = e.Status == JournalConfirmed
+}
+
+// SettlementJournal keeps the most recent settlement attempts in memory,
+// optionally backed by an append-only file so in-flight settlements survive
+// a restart (see OpenSettlementJournal)
+type SettlementJournal struct {
+	mu      sync.RWMutex
+	entries []JournalEntry        // ring buffer
+	index   map[string]int        // entry ID -> position in entries
+	byNonce map[nonceKey][]string // (payer, nonce) -> IDs of its entries, oldest first
+	next    int
+	full    bool
+	file    *os.File // nil for memory-only journals
+}
+
+// NewSettlementJournal creates a memory-only journal holding up to size
+// entries (DefaultJournalSize if size <= 0)
+func NewSettlementJournal(size int) *SettlementJournal {
+	if size <= 0 {
+		size = DefaultJournalSize
+	}
+	return &SettlementJournal{
+		entries: make([]JournalEntry, size),
+		index:   make(map[string]int),
+		byNonce: make(map[nonceKey][]string),
+	}
+}
+
+// OpenSettlementJournal loads the journal at path (one JSON entry per line,
+// later lines updating earlier ones) and appends every change to it
+// The file is compacted to the retained entries on open.
+func OpenSettlementJournal(path string, size int) (*SettlementJournal, error) {
+	j := NewSettlementJournal(size)
+
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var entry JournalEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.ID == "" {
+				continue // torn write from a crash
+			}
+			j.apply(entry)
+		}
+		err := scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading settlement journal: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// Compact: rewrite the retained entries, oldest first, then append
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	entries := j.Entries("", 0)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := writeEntry(f, entries[i]); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+
+	if j.file, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Close closes the journal file, if any
+func (j *SettlementJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// Record adds an entry, or merges it into the entry with the same ID, and
+// returns the ID (generated if entry.ID is empty)
+// The oldest entry is evicted when the journal is full.
+func (j *SettlementJournal) Record(entry JournalEntry) string {
+	if entry.ID == "" {
+		entry.ID = NewJournalID()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	merged := j.apply(entry)
+	j.persist(merged)
+	return entry.ID
+}
+
+// SetStatus overrides the status of an entry (for reconciliation)
+func (j *SettlementJournal) SetStatus(id string, status JournalStatus) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	pos, ok := j.index[id]
+	if !ok {
+		return false
+	}
+	j.entries[pos].Status = status
+	j.entries[pos].Success = status == JournalConfirmed
+	j.persist(j.entries[pos])
+	return true
+}
+
+// apply merges entry into the ring; callers hold mu (or own j exclusively)
+func (j *SettlementJournal) apply(entry JournalEntry) JournalEntry {
+	if pos, ok := j.index[entry.ID]; ok {
+		before := keyOf(&j.entries[pos])
+		j.entries[pos].merge(entry)
+		if after := keyOf(&j.entries[pos]); after != before {
+			j.indexNonce(before, entry.ID, false)
+			j.indexNonce(after, entry.ID, true)
+		}
+		return j.entries[pos]
+	}
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	fresh := JournalEntry{ID: entry.ID, Time: entry.Time}
+	fresh.merge(entry)
+
+	if j.full {
+		evicted := &j.entries[j.next]
+		delete(j.index, evicted.ID)
+		j.indexNonce(keyOf(evicted), evicted.ID, false)
+	}
+	j.entries[j.next] = fresh
+	j.index[fresh.ID] = j.next
+	j.indexNonce(keyOf(&fresh), fresh.ID, true)
+	j.next = (j.next + 1) % len(j.entries)
+	if j.next == 0 {
+		j.full = true
+	}
+	return fresh
+}
+
+// nonceKey identifies an authorization across the entries settling it;
+// both parts are lowercased so checksummed and lowercase forms match
+type nonceKey struct {
+	payer, nonce string
+}
+
+func keyOf(entry *JournalEntry) nonceKey {
+	return nonceKey{payer: strings.ToLower(entry.Payer), nonce: strings.ToLower(entry.Nonce)}
+}
+
+// indexNonce adds (or, with add false, removes) id under key; callers hold mu
+func (j *SettlementJournal) indexNonce(key nonceKey, id string, add bool) {
+	if key.nonce == "" {
+		return
+	}
+	ids := j.byNonce[key]
+	for i, existing := range ids {
+		if existing == id {
+			if add {
+				return
+			}
+			ids = append(ids[:i:i], ids[i+1:]...)
+			break
+		}
+	}
+	if add {
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		delete(j.byNonce, key)
+		return
+	}
+	j.byNonce[key] = ids
+}
+
+// persist appends entry to the journal file; callers hold mu
+// A failed write only costs durability, so it is not returned
+func (j *SettlementJournal) persist(entry JournalEntry) {
+	if j.file == nil {
+		return
+	}
+	_ = writeEntry(j.file, entry)
+}
+
+func writeEntry(f *os.File, entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Entries returns up to limit entries (all if limit <= 0), newest first,
+// optionally only those initiated by apiKey
+func (j *SettlementJournal) Entries(apiKey string, limit int) []JournalEntry {
+	return j.filter(limit, func(entry *JournalEntry) bool {
+		return apiKey == "" || entry.APIKey == apiKey
+	})
+}
+
+// BySettlementID returns the entries recorded under a settlement ID, newest
+// first (a retried settlement has several)
+func (j *SettlementJournal) BySettlementID(id string) []JournalEntry {
+	return j.filter(0, func(entry *JournalEntry) bool {
+		return entry.SettlementID == id
+	})
+}
+
+// ByNonce returns the entries settling the authorization of payer with
+// nonce, newest first; payer and nonce are matched case-insensitively
+func (j *SettlementJournal) ByNonce(payer, nonce string) []JournalEntry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	ids := j.byNonce[nonceKey{payer: strings.ToLower(payer), nonce: strings.ToLower(nonce)}]
+	entries := make([]JournalEntry, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		entries = append(entries, j.entries[j.index[ids[i]]])
+	}
+	return entries
+}
+
+// InFlight returns the entries whose transaction was sent but whose outcome
+// is not known (submitted or unknown), newest first
+func (j *SettlementJournal) InFlight() []JournalEntry {
+	return j.filter(0, func(entry *JournalEntry) bool {
+		return entry.Status.InFlight() && entry.TransactionHash != ""
+	})
+}
+
+func (j *SettlementJournal) filter(limit int, keep func(*JournalEntry) bool) []JournalEntry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	count := j.next
+	if j.full {
+		count = len(j.entries)
+	}
+
+	var entries []JournalEntry
+	for i := 1; i <= count; i++ {
+		entry := j.entries[(j.next-i+len(j.entries))%len(j.entries)]
+		if !keep(&entry) {
+			continue
+		}
+		entries = append(entries, entry)
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+	}
+	return entries
+}
+
+// NewJournalID returns a random journal entry ID
+func NewJournalID() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+type journalIDContextKey struct{}
+
+// WithJournalID tags ctx with the journal entry of the settlement it carries,
+// so every layer that records the settlement updates the same entry
+func WithJournalID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, journalIDContextKey{}, id)
+}
+
+// JournalIDFromContext returns the journal entry ID set by WithJournalID, or ""
+func JournalIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(journalIDContextKey{}).(string)
+	return id
+}
@@ -0,0 +1,85 @@
+package accounting
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const journalPayer = "0x00000000000000000000000000000000000000A1"
+
+var journalNonce = "0x" + strings.Repeat("ab", 32)
+
+// entryIDs returns the IDs of entries in order
+func entryIDs(entries []JournalEntry) []string {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	return ids
+}
+
+func TestJournalByNonce(t *testing.T) {
+	j := NewSettlementJournal(3)
+	first := j.Record(JournalEntry{Payer: journalPayer, Nonce: journalNonce, Status: JournalFailed})
+	second := j.Record(JournalEntry{Payer: journalPayer, Nonce: journalNonce, Status: JournalSubmitted})
+	j.Record(JournalEntry{Payer: journalPayer, Nonce: "0x22", Status: JournalConfirmed})
+
+	// Newest first, whatever the case of payer and nonce
+	got := entryIDs(j.ByNonce(strings.ToLower(journalPayer), "0x"+strings.Repeat("AB", 32)))
+	if len(got) != 2 || got[0] != second || got[1] != first {
+		t.Errorf("entries %v, want %s then %s", got, second, first)
+	}
+	if got := j.ByNonce(journalPayer, "0x33"); len(got) != 0 {
+		t.Errorf("unknown nonce matched %v", entryIDs(got))
+	}
+
+	// An update keeps the entry indexed once, with its new status
+	j.Record(JournalEntry{ID: second, Status: JournalConfirmed, TransactionHash: "0xabc"})
+	if got := j.ByNonce(journalPayer, journalNonce); len(got) != 2 || got[0].Status != JournalConfirmed || got[0].TransactionHash != "0xabc" {
+		t.Errorf("after the update: %+v", got)
+	}
+
+	// Evicted entries leave the index
+	j.Record(JournalEntry{Payer: journalPayer, Nonce: "0x44"})
+	if got := entryIDs(j.ByNonce(journalPayer, journalNonce)); len(got) != 1 || got[0] != second {
+		t.Errorf("after evicting the oldest entry: %v, want only %s", got, second)
+	}
+	j.Record(JournalEntry{Payer: journalPayer, Nonce: "0x55"})
+	if got := j.ByNonce(journalPayer, journalNonce); len(got) != 0 || len(j.byNonce) != 3 {
+		t.Errorf("after evicting both: %v, index %v", entryIDs(got), j.byNonce)
+	}
+}
+
+func TestJournalByNonceFollowsLateNonce(t *testing.T) {
+	j := NewSettlementJournal(4)
+	id := j.Record(JournalEntry{Payer: journalPayer, Status: JournalSubmitted})
+	if len(j.byNonce) != 0 {
+		t.Errorf("entry without a nonce indexed: %v", j.byNonce)
+	}
+	j.Record(JournalEntry{ID: id, Nonce: journalNonce})
+	if got := entryIDs(j.ByNonce(journalPayer, journalNonce)); len(got) != 1 || got[0] != id {
+		t.Errorf("entries %v, want %s once its nonce is known", got, id)
+	}
+}
+
+func TestJournalByNonceSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := OpenSettlementJournal(path, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := j.Record(JournalEntry{Payer: journalPayer, Nonce: journalNonce, Status: JournalConfirmed})
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenSettlementJournal(path, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if got := entryIDs(reopened.ByNonce(journalPayer, journalNonce)); len(got) != 1 || got[0] != id {
+		t.Errorf("reopened journal: entries %v, want %s", got, id)
+	}
+}
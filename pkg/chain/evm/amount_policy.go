@@ -0,0 +1,99 @@
+package evm
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// AmountPolicy decides which signed amounts a provider settles. An ERC-3009
+// authorization moves exactly its signed value, so a payment above
+// maxAmountRequired is either settled in full or refused; the policy picks
+// which. The zero value is SettleSignedAmount.
+type AmountPolicy struct {
+	mode         amountMode
+	toleranceBps uint64
+}
+
+type amountMode int
+
+const (
+	amountSigned amountMode = iota
+	amountRequiredOnly
+	amountTolerance
+)
+
+var (
+	// SettleSignedAmount settles whatever was signed, as long as it covers
+	// the requirement (the default)
+	SettleSignedAmount = AmountPolicy{mode: amountSigned}
+
+	// SettleRequiredAmountOnly settles only authorizations for exactly
+	// maxAmountRequired
+	SettleRequiredAmountOnly = AmountPolicy{mode: amountRequiredOnly}
+)
+
+// SettleWithTolerance settles overpayments of up to bps basis points of
+// maxAmountRequired, e.g. from wallets that round up to the cent, and
+// refuses larger ones
+func SettleWithTolerance(bps uint64) AmountPolicy {
+	return AmountPolicy{mode: amountTolerance, toleranceBps: bps}
+}
+
+// ParseAmountPolicy parses "signed", "required" or "tolerance:<bps>"
+// (empty is signed)
+func ParseAmountPolicy(s string) (AmountPolicy, error) {
+	switch s = strings.TrimSpace(s); {
+	case s == "" || s == "signed":
+		return SettleSignedAmount, nil
+	case s == "required":
+		return SettleRequiredAmountOnly, nil
+	case strings.HasPrefix(s, "tolerance:"):
+		bps, err := strconv.ParseUint(strings.TrimPrefix(s, "tolerance:"), 10, 64)
+		if err != nil || bps > 10000 {
+			return AmountPolicy{}, fmt.Errorf("tolerance in %q must be 0-10000 basis points", s)
+		}
+		return SettleWithTolerance(bps), nil
+	default:
+		return AmountPolicy{}, fmt.Errorf("unknown amount policy %q (want signed, required or tolerance:<bps>)", s)
+	}
+}
+
+// String names the policy as ParseAmountPolicy accepts it
+func (a AmountPolicy) String() string {
+	switch a.mode {
+	case amountRequiredOnly:
+		return "required"
+	case amountTolerance:
+		return fmt.Sprintf("tolerance:%d", a.toleranceBps)
+	default:
+		return "signed"
+	}
+}
+
+// check returns why value may not settle a requirement of required (empty
+// if it may); value is known to cover required
+func (a AmountPolicy) check(value, required *big.Int) string {
+	switch a.mode {
+	case amountRequiredOnly:
+		if value.Cmp(required) != 0 {
+			return fmt.Sprintf("authorization value %s differs from the required amount %s", value, required)
+		}
+	case amountTolerance:
+		// value * 10000 <= required * (10000 + bps)
+		limit := new(big.Int).Mul(required, new(big.Int).SetUint64(10000+a.toleranceBps))
+		if new(big.Int).Mul(value, big.NewInt(10000)).Cmp(limit) > 0 {
+			return fmt.Sprintf("authorization value %s exceeds the required amount %s by more than %d bps", value, required, a.toleranceBps)
+		}
+	}
+	return ""
+}
+
+// WithAmountPolicy sets which overpayments the provider settles (default
+// SettleSignedAmount)
+func WithAmountPolicy(policy AmountPolicy) ProviderOption {
+	return func(o *providerOptions) {
+		o.amountPolicy = policy
+	}
+}
@@ -0,0 +1,102 @@
+package evm_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// overpayment signs value for requirements asking for required
+func overpayment(t *testing.T, chain *testchain.Chain, required, value int64) *types.SettleRequest {
+	t.Helper()
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(required))
+	signed := requirements
+	signed.MaxAmountRequired = big.NewInt(value).String()
+	payload, err := chain.Authorize(chain.Accounts[0], signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements}
+}
+
+func TestAmountPolicies(t *testing.T) {
+	const required = 10000
+	for _, tc := range []struct {
+		policy evm.AmountPolicy
+		value  int64
+		settle bool
+	}{
+		{evm.SettleSignedAmount, required, true},
+		{evm.SettleSignedAmount, 2 * required, true},
+		{evm.SettleRequiredAmountOnly, required, true},
+		{evm.SettleRequiredAmountOnly, required + 1, false},
+		{evm.SettleWithTolerance(100), required, true},
+		{evm.SettleWithTolerance(100), required + 100, true}, // Exactly 1%
+		{evm.SettleWithTolerance(100), required + 101, false},
+		{evm.SettleWithTolerance(0), required + 1, false},
+	} {
+		name := tc.policy.String() + " paying " + big.NewInt(tc.value).String()
+		chain := newTestChain(t)
+		provider, err := chain.Provider(evm.WithAmountPolicy(tc.policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		request := overpayment(t, chain, required, tc.value)
+
+		resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if resp.IsValid != tc.settle {
+			t.Errorf("%s: valid %v (%s), want %v", name, resp.IsValid, resp.Reason, tc.settle)
+		}
+		if !tc.settle && resp.ReasonCode != types.ReasonAmountNotAccepted {
+			t.Errorf("%s: reason code %q, want %q", name, resp.ReasonCode, types.ReasonAmountNotAccepted)
+		}
+
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		settled, err := provider.Settle(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%s: settle: %v", name, err)
+		}
+		if settled.Success != tc.settle || settled.AmountPolicy != tc.policy.String() {
+			t.Errorf("%s: settled %v under %q (%s), want %v under %q", name, settled.Success, settled.AmountPolicy, settled.Error, tc.settle, tc.policy)
+		}
+		balance, err := chain.BalanceOf(common.HexToAddress(request.PaymentRequirements.PayTo))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[bool]int64{true: tc.value, false: 0}[tc.settle]; balance.Int64() != want {
+			t.Errorf("%s: payee received %s, want %d", name, balance, want)
+		}
+	}
+}
+
+func TestParseAmountPolicy(t *testing.T) {
+	for s, want := range map[string]evm.AmountPolicy{
+		"":              evm.SettleSignedAmount,
+		"signed":        evm.SettleSignedAmount,
+		" required ":    evm.SettleRequiredAmountOnly,
+		"tolerance:100": evm.SettleWithTolerance(100),
+		"tolerance:0":   evm.SettleWithTolerance(0),
+	} {
+		policy, err := evm.ParseAmountPolicy(s)
+		if err != nil || policy != want {
+			t.Errorf("%q parsed as %v (%v), want %v", s, policy, err, want)
+		}
+	}
+	for _, s := range []string{"exact", "tolerance:", "tolerance:-1", "tolerance:10001"} {
+		if _, err := evm.ParseAmountPolicy(s); err == nil {
+			t.Errorf("%q parsed", s)
+		}
+	}
+}
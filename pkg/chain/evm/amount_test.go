@@ -0,0 +1,52 @@
+package evm_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestProviderRejectsMalformedAmounts(t *testing.T) {
+	chain := newTestChain(t)
+	provider, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, amount := range []string{
+		"-1",
+		"0",
+		"00",
+		"1e6",
+		"0x3e8",
+		"115792089237316195423570985008687907853269984665640564039457584007913129639936", // 2^256
+	} {
+		required := settleRequest(t, chain)
+		required.PaymentRequirements.MaxAmountRequired = amount
+		resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: required.PaymentPayload, PaymentRequirements: required.PaymentRequirements})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.IsValid || !strings.Contains(resp.Reason, "maxAmountRequired") {
+			t.Errorf("verify with maxAmountRequired %q: valid %v, reason %q", amount, resp.IsValid, resp.Reason)
+		}
+
+		authorized := settleRequest(t, chain)
+		authorized.PaymentPayload.Payload.Authorization.Value = amount
+		resp, err = provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: authorized.PaymentPayload, PaymentRequirements: authorized.PaymentRequirements})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.IsValid || !strings.Contains(resp.Reason, "authorization value") {
+			t.Errorf("verify with authorization value %q: valid %v, reason %q", amount, resp.IsValid, resp.Reason)
+		}
+		settled, err := provider.Settle(context.Background(), authorized)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if settled.Success || settled.TransactionHash != nil {
+			t.Errorf("settle with authorization value %q: %+v, want it refused before submission", amount, settled)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/network"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// AuthorizationCheck is the outcome of one check of an authorization
+type AuthorizationCheck struct {
+	Passed     bool                 `json:"passed"`
+	Skipped    bool                 `json:"skipped,omitempty"` // Not run (see VerifyAuthorizationOffline)
+	Reason     string               `json:"reason,omitempty"`
+	ReasonCode x402types.ReasonCode `json:"reasonCode,omitempty"`
+}
+
+var passedCheck = AuthorizationCheck{Passed: true}
+
+func failedCheck(reason string, code x402types.ReasonCode) AuthorizationCheck {
+	return AuthorizationCheck{Reason: reason, ReasonCode: code}
+}
+
+// VerificationResult reports each check of an EIP-3009 authorization on its
+// own, so callers can tell e.g. an expired payment from an unfunded one
+type VerificationResult struct {
+	Payer     common.Address     `json:"payer"`
+	Timing    AuthorizationCheck `json:"timing"`    // Validity window against the clock and min/maxTimeoutSeconds
+	Amount    AuthorizationCheck `json:"amount"`    // Value well-formed and covering maxAmountRequired
+	Signature AuthorizationCheck `json:"signature"` // EIP-712 signature of the payer
+	Nonce     AuthorizationCheck `json:"nonce"`     // Nonce well-formed and unused on the token
+	Balance   AuthorizationCheck `json:"balance"`   // Payer holds the value
+}
+
+// Valid reports whether every check that ran passed
+func (r *VerificationResult) Valid() bool {
+	return r.Failure() == nil
+}
+
+// Failure returns the first failed check in field order, nil if none failed
+func (r *VerificationResult) Failure() *AuthorizationCheck {
+	for _, check := range []*AuthorizationCheck{&r.Timing, &r.Amount, &r.Signature, &r.Nonce, &r.Balance} {
+		if !check.Passed && !check.Skipped {
+			return check
+		}
+	}
+	return nil
+}
+
+// VerifyOption tunes VerifyAuthorization and VerifyAuthorizationOffline
+type VerifyOption func(*authorizationVerifier)
+
+// VerifyNetwork takes the token's EIP-712 domain from network's registered
+// deployment (default: the registered network with the chain ID)
+func VerifyNetwork(net x402types.Network) VerifyOption {
+	return func(v *authorizationVerifier) {
+		v.network = net
+	}
+}
+
+// VerifyClock checks the validity window against clock (default: the system clock)
+func VerifyClock(clock x402types.Clock) VerifyOption {
+	return func(v *authorizationVerifier) {
+		v.clock = clock
+	}
+}
+
+// VerifyClockSkew accepts authorizations whose validAfter is up to skew in
+// the future (see WithClockSkewTolerance)
+func VerifyClockSkew(skew time.Duration) VerifyOption {
+	return func(v *authorizationVerifier) {
+		v.skew = skew
+	}
+}
+
+// VerifyBalanceAt reads the payer's balance at block (default: latest)
+func VerifyBalanceAt(block *big.Int) VerifyOption {
+	return func(v *authorizationVerifier) {
+		v.balanceBlock = block
+	}
+}
+
+// VerifyAuthorization checks an EIP-3009 authorization of asset against
+// requirements, without a Provider or any signer key: timing, amount and
+// signature offline, then the nonce and balance with caller. Every check
+// runs, whatever the others found. The error is set only when a call timed
+// out (a typed Timeout error); other call failures fail their check.
+func VerifyAuthorization(ctx context.Context, caller bind.ContractCaller, chainID *big.Int, auth *x402types.ExactEvmPayloadAuthorization, signature []byte, asset common.Address, requirements *x402types.PaymentRequirements, opts ...VerifyOption) (*VerificationResult, error) {
+	tokenABI, err := loadUSDABI()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token ABI: %w", err)
+	}
+	v := newAuthorizationVerifier(chainID, opts)
+	result := v.offline(auth, signature, asset, requirements)
+
+	nonce, ok := checkNonceFormat(auth, &result.Nonce)
+	if ok {
+		used, err := callAuthorizationState(ctx, caller, tokenABI, asset, auth.From, nonce, nil)
+		if result.Nonce, err = nonceStateCheck(used, err); err != nil {
+			return nil, err
+		}
+	}
+
+	value, err := x402types.ParseTokenAmount(auth.Value)
+	if err != nil {
+		result.Balance = AuthorizationCheck{Skipped: true, Reason: "no valid amount to compare the balance with"}
+		return result, nil
+	}
+	balance, err := callBalanceOf(ctx, caller, tokenABI, asset, auth.From, v.balanceBlock)
+	if result.Balance, err = balanceCheck(auth.From, balance, value, err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// VerifyAuthorizationOffline is VerifyAuthorization without the chain:
+// the nonce is parsed, and it and the balance are reported skipped
+func VerifyAuthorizationOffline(chainID *big.Int, auth *x402types.ExactEvmPayloadAuthorization, signature []byte, asset common.Address, requirements *x402types.PaymentRequirements, opts ...VerifyOption) *VerificationResult {
+	v := newAuthorizationVerifier(chainID, opts)
+	result := v.offline(auth, signature, asset, requirements)
+	if _, ok := checkNonceFormat(auth, &result.Nonce); ok {
+		result.Nonce = AuthorizationCheck{Skipped: true, Reason: "offline verification"}
+	}
+	result.Balance = AuthorizationCheck{Skipped: true, Reason: "offline verification"}
+	return result
+}
+
+// checkNonceFormat parses the nonce, failing check if it is malformed
+func checkNonceFormat(auth *x402types.ExactEvmPayloadAuthorization, check *AuthorizationCheck) (x402types.Nonce, bool) {
+	nonce, err := auth.Nonce.Nonce()
+	if err != nil {
+		decodeErr := x402types.NewDecodingError(err.Error())
+		*check = failedCheck(decodeErr.Message, decodeErr.Code)
+		return nonce, false
+	}
+	return nonce, true
+}
+
+// authorizationVerifier runs the checks shared by VerifyAuthorization and
+// Provider.Verify
+type authorizationVerifier struct {
+	chainID      *big.Int
+	network      x402types.Network
+	clock        x402types.Clock
+	skew         time.Duration
+	minTimeout   time.Duration // Shortest window of requirements without MinTimeoutSeconds
+	balanceBlock *big.Int
+}
+
+func newAuthorizationVerifier(chainID *big.Int, opts []VerifyOption) *authorizationVerifier {
+	v := &authorizationVerifier{chainID: chainID, clock: x402types.SystemClock{}}
+	for _, opt := range opts {
+		opt(v)
+	}
+	if v.network == "" {
+		v.network = networkForChainID(chainID)
+	}
+	return v
+}
+
+// networkForChainID returns the registered network with chainID, "" if none
+func networkForChainID(chainID *big.Int) x402types.Network {
+	if chainID == nil || !chainID.IsUint64() {
+		return ""
+	}
+	for _, info := range network.Networks() {
+		if info.IsEVM && uint64(info.ChainID) == chainID.Uint64() {
+			return info.Network
+		}
+	}
+	return ""
+}
+
+// offline runs the timing, amount and signature checks
+func (v *authorizationVerifier) offline(auth *x402types.ExactEvmPayloadAuthorization, signature []byte, asset common.Address, requirements *x402types.PaymentRequirements) *VerificationResult {
+	return &VerificationResult{
+		Payer:     auth.From,
+		Timing:    v.timing(auth, requirements),
+		Amount:    v.amount(auth, requirements),
+		Signature: v.signature(auth, signature, asset),
+	}
+}
+
+// timing checks the validity window: well-formed, open now (validAfter
+// with the clock skew, validBefore strictly since the chain enforces it),
+// no longer than the requirements' maxTimeoutSeconds (if set) and no
+// shorter than their minimum (see minTimeoutSeconds)
+func (v *authorizationVerifier) timing(auth *x402types.ExactEvmPayloadAuthorization, requirements *x402types.PaymentRequirements) AuthorizationCheck {
+	payer := x402types.NewEvmAddress(auth.From)
+	validAfter, err := strconv.ParseUint(auth.ValidAfter, 10, 64)
+	if err != nil {
+		return failedCheck(fmt.Sprintf("invalid validAfter: %v", err), x402types.ReasonInvalidTiming)
+	}
+	validBefore, err := strconv.ParseUint(auth.ValidBefore, 10, 64)
+	if err != nil {
+		return failedCheck(fmt.Sprintf("invalid validBefore: %v", err), x402types.ReasonInvalidTiming)
+	}
+
+	// validBefore > validAfter also prevents integer underflow below
+	if validBefore <= validAfter {
+		return failedCheck(fmt.Sprintf("invalid validity window: validBefore (%d) must be greater than validAfter (%d)", validBefore, validAfter), x402types.ReasonInvalidTiming)
+	}
+
+	now := uint64(v.clock.Now().Unix())
+	if now+uint64(v.skew.Seconds()) < validAfter {
+		err := x402types.NewNotYetValidError(payer, fmt.Sprintf("payment not yet valid (validAfter: %s, now: %d)", auth.ValidAfter, now))
+		return failedCheck(err.Message, err.Code)
+	}
+	if now >= validBefore {
+		err := x402types.NewExpiredError(payer, fmt.Sprintf("payment expired (validBefore: %s, now: %d)", auth.ValidBefore, now))
+		return failedCheck(err.Message, err.Code)
+	}
+
+	timeoutWindow := validBefore - validAfter
+	if maxTimeoutSeconds := requirements.MaxTimeoutSeconds; maxTimeoutSeconds > 0 {
+		maxTimeout := uint64(maxTimeoutSeconds)
+		if timeoutWindow > maxTimeout {
+			return failedCheck(fmt.Sprintf("payment validity window too long: %d seconds (max allowed: %d seconds)", timeoutWindow, maxTimeout), x402types.ReasonInvalidTiming)
+		}
+	}
+	if minTimeout := v.minTimeoutSeconds(requirements); minTimeout > 0 && timeoutWindow < minTimeout {
+		return failedCheck(fmt.Sprintf("payment validity window too short: %d seconds (min allowed: %d seconds)", timeoutWindow, minTimeout), x402types.ReasonValidityTooShort)
+	}
+	return passedCheck
+}
+
+// minTimeoutSeconds returns the shortest validity window requirements
+// accept: their MinTimeoutSeconds, else the verifier's default, capped at
+// their MaxTimeoutSeconds so that a short maximum still admits payments
+func (v *authorizationVerifier) minTimeoutSeconds(requirements *x402types.PaymentRequirements) uint64 {
+	if requirements.MinTimeoutSeconds > 0 {
+		return uint64(requirements.MinTimeoutSeconds)
+	}
+	minTimeout := uint64(max(v.minTimeout, 0) / time.Second)
+	if requirements.MaxTimeoutSeconds > 0 {
+		minTimeout = min(minTimeout, uint64(requirements.MaxTimeoutSeconds))
+	}
+	return minTimeout
+}
+
+// amount checks the value and maxAmountRequired are amounts within uint256
+// (no negatives, hex or exponents) and the value covers the requirement
+func (v *authorizationVerifier) amount(auth *x402types.ExactEvmPayloadAuthorization, requirements *x402types.PaymentRequirements) AuthorizationCheck {
+	payload := &x402types.PaymentPayload{Payload: x402types.ExactEvmPayload{Authorization: *auth}}
+	if err := x402types.ValidateAmounts(payload, requirements); err != nil {
+		return failedCheck(err.Message, err.Code)
+	}
+	value, _ := x402types.ParseTokenAmount(auth.Value)
+	required, _ := x402types.ParseTokenAmount(requirements.MaxAmountRequired)
+	if value.Cmp(required) < 0 {
+		err := x402types.NewInsufficientValueError(x402types.NewEvmAddress(auth.From))
+		return failedCheck(err.Message, err.Code)
+	}
+	return passedCheck
+}
+
+// signature checks the EIP-712 signature against the token's domain
+func (v *authorizationVerifier) signature(auth *x402types.ExactEvmPayloadAuthorization, signature []byte, asset common.Address) AuthorizationCheck {
+	domain := eip712.TokenDomain(v.network, v.chainID, asset)
+	valid, err := eip712.VerifySignature(auth, signature, domain)
+	return signatureCheck(auth, valid, err)
+}
+
+// signatureCheck turns the outcome of a signature verification into a check
+func signatureCheck(auth *x402types.ExactEvmPayloadAuthorization, valid bool, err error) AuthorizationCheck {
+	if err != nil {
+		return failedCheck(fmt.Sprintf("signature verification failed: %v", err), x402types.ReasonInvalidSignature)
+	}
+	if !valid {
+		err := x402types.NewInvalidSignatureError(x402types.NewEvmAddress(auth.From), "signature verification failed")
+		return failedCheck(err.Message, err.Code)
+	}
+	return passedCheck
+}
+
+// nonceStateCheck turns the outcome of an on-chain nonce lookup into a
+// check; a timed-out lookup is returned as the error
+func nonceStateCheck(used bool, err error) (AuthorizationCheck, error) {
+	if err != nil {
+		log.Printf("evm.Verify: authorization state check failed err=%v", err)
+		if timeoutErr := timeoutError("authorization state check", err); timeoutErr != nil {
+			return AuthorizationCheck{}, timeoutErr
+		}
+		return failedCheck(fmt.Sprintf("authorization state check failed: %v", err), x402types.ReasonRPCError), nil
+	}
+	if used {
+		return failedCheck("authorization is used or cancelled", x402types.ReasonNonceReused), nil
+	}
+	return passedCheck, nil
+}
+
+// balanceCheck turns the outcome of a balance lookup into a check; a
+// timed-out lookup is returned as the error
+func balanceCheck(payer common.Address, balance, value *big.Int, err error) (AuthorizationCheck, error) {
+	if err != nil {
+		log.Printf("evm.Verify: balance check failed err=%v", err)
+		if timeoutErr := timeoutError("balance check", err); timeoutErr != nil {
+			return AuthorizationCheck{}, timeoutErr
+		}
+		return failedCheck(fmt.Sprintf("balance check failed: %v", err), x402types.ReasonRPCError), nil
+	}
+	if balance.Cmp(value) < 0 {
+		err := x402types.NewInsufficientFundsError(x402types.NewEvmAddress(payer))
+		return failedCheck(err.Message, err.Code), nil
+	}
+	return passedCheck, nil
+}
+
+// callAuthorizationState asks token whether nonce of authorizer was used or
+// cancelled, as of block (nil: latest)
+func callAuthorizationState(ctx context.Context, caller bind.ContractCaller, tokenABI abi.ABI, token, authorizer common.Address, nonce [32]byte, block *big.Int) (bool, error) {
+	data, err := tokenABI.Pack("authorizationState", authorizer, nonce)
+	if err != nil {
+		return false, fmt.Errorf("failed to pack authorizationState: %w", err)
+	}
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, block)
+	if err != nil {
+		return false, fmt.Errorf("authorizationState call failed: %w", err)
+	}
+	var used bool
+	if err := tokenABI.UnpackIntoInterface(&used, "authorizationState", result); err != nil {
+		return false, fmt.Errorf("failed to unpack authorizationState result: %w", err)
+	}
+	return used, nil
+}
+
+// callBalanceOf queries the token balance of account at block (nil: latest)
+func callBalanceOf(ctx context.Context, caller bind.ContractCaller, tokenABI abi.ABI, token, account common.Address, block *big.Int) (*big.Int, error) {
+	data, err := tokenABI.Pack("balanceOf", account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack balanceOf: %w", err)
+	}
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, block)
+	if err != nil {
+		return nil, fmt.Errorf("balanceOf call failed: %w", err)
+	}
+	var balance *big.Int
+	if err := tokenABI.UnpackIntoInterface(&balance, "balanceOf", result); err != nil {
+		return nil, fmt.Errorf("failed to unpack balanceOf result: %w", err)
+	}
+	return balance, nil
+}
@@ -0,0 +1,180 @@
+package evm_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+var chainID = big.NewInt(testchain.ChainID)
+
+// failingCaller answers every contract call with err
+type failingCaller struct{ err error }
+
+func (c failingCaller) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return nil, c.err
+}
+
+func (c failingCaller) CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+	return nil, c.err
+}
+
+// outcomes summarises a result's checks in field order: "pass", "skip" or
+// the failed check's reason code
+func outcomes(r *evm.VerificationResult) [5]string {
+	var got [5]string
+	for i, check := range []evm.AuthorizationCheck{r.Timing, r.Amount, r.Signature, r.Nonce, r.Balance} {
+		switch {
+		case check.Skipped:
+			got[i] = "skip"
+		case check.Passed:
+			got[i] = "pass"
+		default:
+			got[i] = string(check.ReasonCode)
+		}
+	}
+	return got
+}
+
+func TestVerifyAuthorizationReportsEachCheck(t *testing.T) {
+	chain := newTestChain(t)
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	authorize := func(amount int64) (*types.PaymentPayload, types.PaymentRequirements) {
+		t.Helper()
+		requirements := chain.Requirements(payTo, big.NewInt(amount))
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return payload, requirements
+	}
+
+	// A payment already settled, for the nonce check
+	settled, settledRequirements := authorize(1000)
+	provider, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := provider.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *settled, PaymentRequirements: settledRequirements}); err != nil || !resp.Success {
+		t.Fatalf("settle: %v %+v", err, resp)
+	}
+
+	pass := "pass"
+	for _, tc := range []struct {
+		name   string
+		amount int64
+		mutate func(*types.PaymentPayload, *types.PaymentRequirements)
+		clock  time.Duration // After validAfter
+		want   [5]string
+	}{
+		{"valid", 1000, nil, 30 * time.Second, [5]string{pass, pass, pass, pass, pass}},
+		{"expired", 1000, nil, time.Hour, [5]string{string(types.ReasonExpired), pass, pass, pass, pass}},
+		{"underpaid", 1000, func(_ *types.PaymentPayload, r *types.PaymentRequirements) { r.MaxAmountRequired = "1001" }, 30 * time.Second,
+			[5]string{pass, string(types.ReasonInsufficientValue), pass, pass, pass}},
+		{"bad signature", 1000, func(p *types.PaymentPayload, _ *types.PaymentRequirements) { p.Payload.Signature[5] ^= 0xff }, 30 * time.Second,
+			[5]string{pass, pass, string(types.ReasonInvalidSignature), pass, pass}},
+		{"unfunded", 20_000_000, nil, 30 * time.Second, [5]string{pass, pass, pass, pass, string(types.ReasonInsufficientFunds)}},
+		{"expired and unfunded", 20_000_000, nil, time.Hour,
+			[5]string{string(types.ReasonExpired), pass, pass, pass, string(types.ReasonInsufficientFunds)}},
+		// The hex value still signs as 1000, but is no amount to compare the balance with
+		{"malformed value", 1000, func(p *types.PaymentPayload, _ *types.PaymentRequirements) { p.Payload.Authorization.Value = "0x3e8" }, 30 * time.Second,
+			[5]string{pass, string(types.ReasonInvalidAmount), pass, pass, "skip"}},
+	} {
+		payload, requirements := authorize(tc.amount)
+		if tc.mutate != nil {
+			tc.mutate(payload, &requirements)
+		}
+		auth := &payload.Payload.Authorization
+		clock := fixedClock(unixField(t, auth.ValidAfter).Add(tc.clock))
+		result, err := evm.VerifyAuthorization(context.Background(), chain.Client(), chainID, auth, payload.Payload.Signature, testchain.TokenAddress, &requirements, evm.VerifyClock(clock))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := outcomes(result); got != tc.want {
+			t.Errorf("%s: checks %v, want %v", tc.name, got, tc.want)
+		}
+		if result.Payer != chain.Accounts[0].Address {
+			t.Errorf("%s: payer %s", tc.name, result.Payer.Hex())
+		}
+		wantValid := tc.name == "valid"
+		if result.Valid() != wantValid || (result.Failure() == nil) != wantValid {
+			t.Errorf("%s: valid %v, failure %+v", tc.name, result.Valid(), result.Failure())
+		}
+		if tc.name == "expired and unfunded" && result.Failure().ReasonCode != types.ReasonExpired {
+			t.Errorf("%s: first failure %q, want the timing", tc.name, result.Failure().ReasonCode)
+		}
+	}
+
+	auth := &settled.Payload.Authorization
+	result, err := evm.VerifyAuthorization(context.Background(), chain.Client(), chainID, auth, settled.Payload.Signature, testchain.TokenAddress, &settledRequirements,
+		evm.VerifyClock(fixedClock(unixField(t, auth.ValidAfter).Add(30*time.Second))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := outcomes(result), [5]string{pass, pass, pass, string(types.ReasonNonceReused), pass}; got != want {
+		t.Errorf("settled payment: checks %v, want %v", got, want)
+	}
+}
+
+func TestVerifyAuthorizationCallFailures(t *testing.T) {
+	chain := newTestChain(t)
+	request := settleRequest(t, chain)
+	auth := &request.PaymentPayload.Payload.Authorization
+	clock := evm.VerifyClock(fixedClock(unixField(t, auth.ValidAfter).Add(30 * time.Second)))
+
+	// A failing RPC fails the on-chain checks and leaves the others alone
+	result, err := evm.VerifyAuthorization(context.Background(), failingCaller{errors.New("connection refused")}, chainID, auth, request.PaymentPayload.Payload.Signature, testchain.TokenAddress, &request.PaymentRequirements, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := outcomes(result), [5]string{"pass", "pass", "pass", string(types.ReasonRPCError), string(types.ReasonRPCError)}; got != want {
+		t.Errorf("failing RPC: checks %v, want %v", got, want)
+	}
+
+	// A timed-out one is the error
+	_, err = evm.VerifyAuthorization(context.Background(), failingCaller{context.DeadlineExceeded}, chainID, auth, request.PaymentPayload.Payload.Signature, testchain.TokenAddress, &request.PaymentRequirements, clock)
+	var facErr *types.FacilitatorError
+	if !errors.As(err, &facErr) || facErr.Code != types.ReasonTimeout {
+		t.Errorf("timed-out RPC: error %v, want a Timeout", err)
+	}
+}
+
+func TestVerifyAuthorizationOffline(t *testing.T) {
+	chain := newTestChain(t)
+	request := settleRequest(t, chain)
+	auth := request.PaymentPayload.Payload.Authorization
+	clock := evm.VerifyClock(fixedClock(unixField(t, auth.ValidAfter).Add(30 * time.Second)))
+
+	result := evm.VerifyAuthorizationOffline(chainID, &auth, request.PaymentPayload.Payload.Signature, testchain.TokenAddress, &request.PaymentRequirements, clock)
+	if got, want := outcomes(result), [5]string{"pass", "pass", "pass", "skip", "skip"}; got != want || !result.Valid() {
+		t.Errorf("offline: checks %v (valid %v), want %v", got, result.Valid(), want)
+	}
+
+	// The nonce is still parsed
+	auth.Nonce = auth.Nonce[:16]
+	result = evm.VerifyAuthorizationOffline(chainID, &auth, request.PaymentPayload.Payload.Signature, testchain.TokenAddress, &request.PaymentRequirements, clock)
+	if result.Nonce.Passed || result.Nonce.Skipped || result.Nonce.ReasonCode != types.ReasonDecodingError || result.Valid() {
+		t.Errorf("short nonce: nonce check %+v, want a decoding failure", result.Nonce)
+	}
+
+	// The skew lets a payment through just before validAfter
+	early := evm.VerifyClock(fixedClock(unixField(t, auth.ValidAfter).Add(-30 * time.Second)))
+	auth = request.PaymentPayload.Payload.Authorization
+	for skew, want := range map[time.Duration]string{0: string(types.ReasonNotYetValid), time.Minute: "pass"} {
+		result := evm.VerifyAuthorizationOffline(chainID, &auth, request.PaymentPayload.Payload.Signature, testchain.TokenAddress, &request.PaymentRequirements, early, evm.VerifyClockSkew(skew))
+		if got := outcomes(result)[0]; got != want {
+			t.Errorf("skew %v: timing %s, want %s", skew, got, want)
+		}
+	}
+}
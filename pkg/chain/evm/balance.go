@@ -0,0 +1,213 @@
+package evm
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultBalanceCheckInterval is how often signer balances are checked when
+// StartBalanceMonitor is given no interval
+const DefaultBalanceCheckInterval = 5 * time.Minute
+
+// WithMinSignerBalance marks the provider degraded whenever a signer's native
+// balance is below minWei, since its settlements will fail for lack of gas.
+// With skipLow, low signers are left out of the round-robin as long as at
+// least one signer is above the threshold.
+func WithMinSignerBalance(minWei *big.Int, skipLow bool) ProviderOption {
+	return func(o *providerOptions) {
+		if minWei != nil && minWei.Sign() > 0 {
+			o.minSignerBalance = minWei
+			o.skipLowSigners = skipLow
+		}
+	}
+}
+
+// SignerBalance is the last observed native balance of a settlement signer
+type SignerBalance struct {
+	Address   common.Address `json:"address"`
+	Balance   string         `json:"balance,omitempty"` // wei; empty until checked
+	Low       bool           `json:"low"`
+	CheckedAt time.Time      `json:"checkedAt,omitempty"`
+	Error     string         `json:"error,omitempty"` // Last check failure, if any
+}
+
+// MinSignerBalance returns the low-balance threshold in wei (nil if unset)
+func (p *Provider) MinSignerBalance() *big.Int {
+	return p.minSignerBalance
+}
+
+// Degraded reports whether any signer was below the balance threshold at the
+// last check
+func (p *Provider) Degraded() bool {
+	for _, signer := range p.signers {
+		if signer.low.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// SignerBalances returns the result of the last balance check per signer
+func (p *Provider) SignerBalances() []SignerBalance {
+	p.balanceMu.Lock()
+	defer p.balanceMu.Unlock()
+	out := make([]SignerBalance, len(p.signers))
+	for i, signer := range p.signers {
+		out[i] = p.balances[i]
+		out[i].Address = signer.Address()
+		out[i].Low = signer.low.Load()
+	}
+	return out
+}
+
+// CheckSignerBalances fetches every signer's native balance once and updates
+// the low-balance flags; a signer whose balance cannot be fetched keeps its
+// previous state. Without a threshold it only records the balances.
+func (p *Provider) CheckSignerBalances(ctx context.Context) []SignerBalance {
+	for i, signer := range p.signers {
+		rpcCtx, cancel := p.rpcContext(ctx)
+		balance, err := p.client.BalanceAt(rpcCtx, signer.Address(), nil)
+		cancel()
+
+		p.balanceMu.Lock()
+		p.balances[i].CheckedAt = p.clock.Now()
+		if err != nil {
+			p.balances[i].Error = err.Error()
+			p.balanceMu.Unlock()
+			log.Printf("evm: %s signer %s balance check failed: %v", p.network, signer.Address().Hex(), err)
+			continue
+		}
+		p.balances[i].Balance = balance.String()
+		p.balances[i].Error = ""
+		p.balanceMu.Unlock()
+
+		low := p.minSignerBalance != nil && balance.Cmp(p.minSignerBalance) < 0
+		if was := signer.low.Swap(low); low && !was {
+			log.Printf("evm: %s signer %s is low on gas: balance %s wei below %s", p.network, signer.Address().Hex(), balance, p.minSignerBalance)
+		} else if was && !low {
+			log.Printf("evm: %s signer %s balance recovered: %s wei", p.network, signer.Address().Hex(), balance)
+		}
+	}
+	return p.SignerBalances()
+}
+
+// nextSignerIndex picks the signer for the next transaction in round-robin
+// order, skipping low-balance signers if configured; with advance false it
+// only peeks at the choice (for simulations)
+func (p *Provider) nextSignerIndex(advance bool) int {
+	var n uint64
+	if advance {
+		n = p.signerIndex.Add(1)
+	} else {
+		n = p.signerIndex.Load() + 1
+	}
+	idx := int(n % uint64(len(p.signers)))
+	if !p.skipLowSigners {
+		return idx
+	}
+	for i := range p.signers {
+		candidate := (idx + i) % len(p.signers)
+		if !p.signers[candidate].low.Load() {
+			return candidate
+		}
+	}
+	// Every signer is low; try them in turn anyway
+	return idx
+}
+
+type signerSetContextKey struct{}
+
+// WithSignerSet limits the settlements made with ctx to the signers with the
+// given addresses (e.g. the keys of one tenant); settling fails on networks
+// whose provider has none of them
+func WithSignerSet(ctx context.Context, signers []common.Address) context.Context {
+	set := make(map[common.Address]bool, len(signers))
+	for _, signer := range signers {
+		set[signer] = true
+	}
+	return context.WithValue(ctx, signerSetContextKey{}, set)
+}
+
+// signerAllowed reports whether ctx lets addr settle (see WithSignerSet)
+func signerAllowed(ctx context.Context, addr common.Address) bool {
+	set, ok := ctx.Value(signerSetContextKey{}).(map[common.Address]bool)
+	return !ok || set[addr]
+}
+
+// pickSigner is nextSignerIndex among the signers ctx allows, -1 if it
+// allows none of the provider's signers
+func (p *Provider) pickSigner(ctx context.Context, advance bool) int {
+	if _, ok := ctx.Value(signerSetContextKey{}).(map[common.Address]bool); !ok {
+		return p.nextSignerIndex(advance)
+	}
+	var n uint64
+	if advance {
+		n = p.signerIndex.Add(1)
+	} else {
+		n = p.signerIndex.Load() + 1
+	}
+	idx := int(n % uint64(len(p.signers)))
+	fallback := -1
+	for i := range p.signers {
+		candidate := (idx + i) % len(p.signers)
+		if !signerAllowed(ctx, p.signerAddresses[candidate]) {
+			continue
+		}
+		if !p.skipLowSigners || !p.signers[candidate].low.Load() {
+			return candidate
+		}
+		if fallback < 0 {
+			fallback = candidate
+		}
+	}
+	return fallback
+}
+
+// BalanceMonitor checks a provider's signer balances in the background
+type BalanceMonitor struct {
+	provider *Provider
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// StartBalanceMonitor checks signer balances immediately and then every
+// interval (DefaultBalanceCheckInterval if interval <= 0) until Stop
+func (p *Provider) StartBalanceMonitor(interval time.Duration) *BalanceMonitor {
+	if interval <= 0 {
+		interval = DefaultBalanceCheckInterval
+	}
+	m := &BalanceMonitor{
+		provider: p,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			p.CheckSignerBalances(context.Background())
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return m
+}
+
+// Stop ends the background checks and waits for the current one to finish
+func (m *BalanceMonitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+		<-m.done
+	})
+}
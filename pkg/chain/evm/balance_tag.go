@@ -0,0 +1,71 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BalanceBlockTag selects the block payer balances are checked at. Checking
+// the latest block can count funds a reorg later takes away; the safe and
+// finalized blocks trade that risk for lag.
+type BalanceBlockTag string
+
+const (
+	BalanceLatest    BalanceBlockTag = "latest"    // Newest block (the default)
+	BalanceSafe      BalanceBlockTag = "safe"      // Newest block the chain considers safe from reorgs
+	BalanceFinalized BalanceBlockTag = "finalized" // Newest finalized block
+)
+
+// ParseBalanceBlockTag parses "latest", "safe" or "finalized" (empty is latest)
+func ParseBalanceBlockTag(s string) (BalanceBlockTag, error) {
+	switch tag := BalanceBlockTag(strings.ToLower(strings.TrimSpace(s))); tag {
+	case "":
+		return BalanceLatest, nil
+	case BalanceLatest, BalanceSafe, BalanceFinalized:
+		return tag, nil
+	}
+	return "", fmt.Errorf("unknown balance block tag %q (want %s, %s or %s)", s, BalanceLatest, BalanceSafe, BalanceFinalized)
+}
+
+// WithBalanceBlockTag checks payer balances at the block tag names instead
+// of the latest block. An RPC that does not know the tag (e.g. a pre-merge
+// chain) falls back to the latest block, with a logged warning.
+func WithBalanceBlockTag(tag BalanceBlockTag) ProviderOption {
+	return func(o *providerOptions) {
+		o.balanceTag = tag
+	}
+}
+
+// balanceBlock returns the block number balance checks read from, nil being
+// the latest block
+func (p *Provider) balanceBlock(ctx context.Context) (*big.Int, error) {
+	var number rpc.BlockNumber
+	switch p.balanceTag {
+	case BalanceSafe:
+		number = rpc.SafeBlockNumber
+	case BalanceFinalized:
+		number = rpc.FinalizedBlockNumber
+	default:
+		return nil, nil
+	}
+
+	callCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	header, err := p.client.HeaderByNumber(callCtx, big.NewInt(number.Int64()))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return nil, fmt.Errorf("%s block lookup failed: %w", p.balanceTag, err)
+		}
+		p.balanceTagWarning.Do(func() {
+			log.Printf("evm: %s RPC cannot resolve the %s block, checking balances at latest: %v", p.network, p.balanceTag, err)
+		})
+		return nil, nil
+	}
+	return header.Number, nil
+}
@@ -0,0 +1,141 @@
+package evm_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// balanceOfSelector is the selector of balanceOf(address)
+var balanceOfSelector = []byte{0x70, 0xa0, 0x82, 0x31}
+
+// taggedClient is the chain's client resolving the safe and finalized
+// blocks to fixed numbers, recording the block of every balanceOf call
+type taggedClient struct {
+	evm.Client
+	safe, finalized int64
+	tagErr          error // Fails safe and finalized lookups when set
+
+	mu     sync.Mutex
+	blocks []*big.Int
+}
+
+func (c *taggedClient) HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error) {
+	if number != nil {
+		switch number.Int64() {
+		case rpc.SafeBlockNumber.Int64():
+			return c.tagged(c.safe)
+		case rpc.FinalizedBlockNumber.Int64():
+			return c.tagged(c.finalized)
+		}
+	}
+	return c.Client.HeaderByNumber(ctx, number)
+}
+
+func (c *taggedClient) tagged(number int64) (*ethtypes.Header, error) {
+	if c.tagErr != nil {
+		return nil, c.tagErr
+	}
+	return &ethtypes.Header{Number: big.NewInt(number)}, nil
+}
+
+// CallContract records balanceOf blocks, then reads the latest state: the
+// simulated chain keeps no history to read older blocks from
+func (c *taggedClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	if bytes.HasPrefix(msg.Data, balanceOfSelector) {
+		c.mu.Lock()
+		c.blocks = append(c.blocks, block)
+		c.mu.Unlock()
+		block = nil
+	}
+	return c.Client.CallContract(ctx, msg, block)
+}
+
+func (c *taggedClient) balanceBlocks() []*big.Int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*big.Int(nil), c.blocks...)
+}
+
+func TestBalanceCheckUsesConfiguredBlockTag(t *testing.T) {
+	chain := newTestChain(t)
+	request := settleRequest(t, chain)
+	verify := &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements}
+
+	for _, tc := range []struct {
+		tag    evm.BalanceBlockTag
+		tagErr error
+		want   *big.Int // nil is the latest block
+	}{
+		{"", nil, nil},
+		{evm.BalanceLatest, nil, nil},
+		{evm.BalanceSafe, nil, big.NewInt(90)},
+		{evm.BalanceFinalized, nil, big.NewInt(64)},
+		{evm.BalanceSafe, errors.New("unknown block tag"), nil}, // Falls back to latest
+		{evm.BalanceFinalized, errors.New("invalid block number"), nil},
+	} {
+		client := &taggedClient{Client: chain.Client(), safe: 90, finalized: 64, tagErr: tc.tagErr}
+		options := chain.Options()
+		options.Client = client
+		provider, err := evm.New(testchain.Network, options, evm.WithBalanceBlockTag(tc.tag))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := provider.Verify(context.Background(), verify)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.IsValid {
+			t.Fatalf("%q: rejected as %s (%s)", tc.tag, resp.ReasonCode, resp.Reason)
+		}
+		blocks := client.balanceBlocks()
+		if len(blocks) == 0 {
+			t.Fatalf("%q: no balanceOf call", tc.tag)
+		}
+		for _, block := range blocks {
+			if (block == nil) != (tc.want == nil) || block != nil && block.Cmp(tc.want) != 0 {
+				t.Errorf("%q (lookup error %v): balanceOf at block %v, want %v", tc.tag, tc.tagErr, block, tc.want)
+			}
+		}
+	}
+}
+
+func TestBalanceBlockLookupHonoursCancellation(t *testing.T) {
+	chain := newTestChain(t)
+	request := settleRequest(t, chain)
+	client := &taggedClient{Client: chain.Client(), tagErr: context.DeadlineExceeded}
+	options := chain.Options()
+	options.Client = client
+	provider, err := evm.New(testchain.Network, options, evm.WithBalanceBlockTag(evm.BalanceSafe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+	if err == nil && resp.IsValid {
+		t.Error("verified against the latest block after the safe lookup timed out")
+	}
+	if blocks := client.balanceBlocks(); len(blocks) != 0 {
+		t.Errorf("balanceOf called at %v after the lookup timed out", blocks)
+	}
+}
+
+func TestParseBalanceBlockTag(t *testing.T) {
+	for input, want := range map[string]evm.BalanceBlockTag{"": evm.BalanceLatest, "latest": evm.BalanceLatest, " Safe ": evm.BalanceSafe, "FINALIZED": evm.BalanceFinalized} {
+		if got, err := evm.ParseBalanceBlockTag(input); err != nil || got != want {
+			t.Errorf("ParseBalanceBlockTag(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := evm.ParseBalanceBlockTag("pending"); err == nil {
+		t.Error("pending parsed as a balance block tag")
+	}
+}
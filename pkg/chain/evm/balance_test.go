@@ -0,0 +1,174 @@
+package evm_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+)
+
+// balanceClient is the chain's client with native balances read from a
+// settable table (addresses missing from it read the chain)
+type balanceClient struct {
+	evm.Client
+	mu       sync.Mutex
+	balances map[common.Address]*big.Int
+	err      error // Fails every balance lookup when set
+}
+
+func (c *balanceClient) BalanceAt(ctx context.Context, account common.Address, block *big.Int) (*big.Int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	if balance, ok := c.balances[account]; ok {
+		return balance, nil
+	}
+	return c.Client.BalanceAt(ctx, account, block)
+}
+
+func (c *balanceClient) set(account common.Address, wei int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.balances[account] = big.NewInt(wei)
+}
+
+// balanceProvider settles on chain with its signer and an unfunded second
+// key, reading balances through the returned client
+func balanceProvider(t *testing.T, chain *testchain.Chain, opts ...evm.ProviderOption) (*evm.Provider, *balanceClient, common.Address) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &balanceClient{Client: chain.Client(), balances: make(map[common.Address]*big.Int)}
+	options := chain.Options()
+	options.Keys = append(options.Keys, testchain.Account{Key: key}.KeyHex())
+	options.Client = client
+	provider, err := evm.New(testchain.Network, options, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, client, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestSignerBalanceThresholdCrossing(t *testing.T) {
+	chain := newTestChain(t)
+	provider, client, unfunded := balanceProvider(t, chain, evm.WithMinSignerBalance(big.NewInt(1000), false))
+	signer := chain.Signer.Address
+	client.set(unfunded, 5000)
+
+	low := func(balances []evm.SignerBalance) map[common.Address]bool {
+		out := make(map[common.Address]bool)
+		for _, b := range balances {
+			out[b.Address] = b.Low
+		}
+		return out
+	}
+
+	balances := provider.CheckSignerBalances(context.Background())
+	if provider.Degraded() || len(balances) != 2 || low(balances)[signer] || low(balances)[unfunded] {
+		t.Fatalf("funded signers: degraded %v, balances %+v", provider.Degraded(), balances)
+	}
+
+	// Dropping below the threshold degrades the provider; exactly at it does not
+	client.set(unfunded, 999)
+	if balances := provider.CheckSignerBalances(context.Background()); !provider.Degraded() || !low(balances)[unfunded] || low(balances)[signer] {
+		t.Errorf("one signer below the threshold: degraded %v, balances %+v", provider.Degraded(), balances)
+	}
+	client.set(unfunded, 1000)
+	if provider.CheckSignerBalances(context.Background()); provider.Degraded() {
+		t.Error("a signer at the threshold degraded the provider")
+	}
+
+	// A failed lookup keeps the last state and reports the error
+	client.set(unfunded, 1)
+	provider.CheckSignerBalances(context.Background())
+	client.mu.Lock()
+	client.err = errors.New("connection refused")
+	client.mu.Unlock()
+	for _, b := range provider.CheckSignerBalances(context.Background()) {
+		if b.Address == unfunded && (!b.Low || b.Error == "" || b.Balance != "1") {
+			t.Errorf("failed check: %+v, want the last balance kept with the error", b)
+		}
+	}
+	if !provider.Degraded() {
+		t.Error("a failed check cleared the low flag")
+	}
+
+	// Without a threshold balances are only recorded
+	plain, plainClient, other := balanceProvider(t, chain)
+	plainClient.set(other, 0)
+	if plain.CheckSignerBalances(context.Background()); plain.Degraded() || plain.MinSignerBalance() != nil {
+		t.Error("provider without a threshold reports degraded")
+	}
+}
+
+func TestLowBalanceSignersSkippedInRoundRobin(t *testing.T) {
+	chain := newTestChain(t)
+	provider, client, unfunded := balanceProvider(t, chain, evm.WithMinSignerBalance(big.NewInt(1), true))
+	client.set(unfunded, 0)
+	provider.CheckSignerBalances(context.Background())
+
+	sender := ethtypes.LatestSignerForChainID(big.NewInt(testchain.ChainID))
+	for i := 0; i < 3; i++ {
+		request := settleRequest(t, chain)
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := provider.Settle(context.Background(), request)
+		if err != nil || !resp.Success {
+			t.Fatalf("settle %d: %+v, %v", i, resp, err)
+		}
+		tx, _, err := chain.Client().TransactionByHash(context.Background(), common.HexToHash(resp.TransactionHash.Hash))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from, err := ethtypes.Sender(sender, tx); err != nil || from != chain.Signer.Address {
+			t.Errorf("settle %d sent by %s (%v), want the funded signer", i, from.Hex(), err)
+		}
+	}
+
+	// When every signer is low they are still tried in turn
+	client.set(chain.Signer.Address, 0)
+	provider.CheckSignerBalances(context.Background())
+	used := make(map[bool]bool)
+	for i := 0; i < 2; i++ {
+		request := settleRequest(t, chain)
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := provider.Settle(context.Background(), request)
+		used[err == nil && resp.Success] = true
+	}
+	if !used[true] || !used[false] {
+		t.Errorf("with every signer low, settles succeeded %v; want both signers tried", used)
+	}
+}
+
+func TestStartBalanceMonitorChecksImmediately(t *testing.T) {
+	chain := newTestChain(t)
+	provider, client, unfunded := balanceProvider(t, chain, evm.WithMinSignerBalance(big.NewInt(1000), false))
+	client.set(unfunded, 10)
+	monitor := provider.StartBalanceMonitor(time.Hour)
+	defer monitor.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !provider.Degraded() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !provider.Degraded() {
+		t.Fatal("the monitor did not check balances on start")
+	}
+	monitor.Stop()
+	monitor.Stop() // Stopping twice is harmless
+}
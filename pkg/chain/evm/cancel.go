@@ -0,0 +1,239 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// cancelGasLimit covers cancelAuthorization (~40-50k) with headroom
+const cancelGasLimit = 80000
+
+// CancelledNonceRetention is how long a nonce store remembers a cancelled
+// nonce; the cancellation itself is permanent, and full verification asks the
+// token once the store has forgotten it
+const CancelledNonceRetention = 24 * time.Hour
+
+// Cancel submits cancelAuthorization for an unsettled authorization, so the
+// payer can revoke a payment that was signed but never settled. The
+// authorizer's CancelAuthorization signature is checked before anything is
+// sent, and nonces the token already reports used or cancelled are refused.
+func (p *Provider) Cancel(ctx context.Context, request *x402types.CancelRequest) (*x402types.CancelResponse, error) {
+	if p.verifyOnly {
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      errSettlementDisabled,
+			ReasonCode: x402types.ReasonSettlementDisabled,
+		}, nil
+	}
+
+	authorizer := request.Authorization.Authorizer
+	payer := x402types.NewEvmAddress(authorizer)
+
+	// Validate asset is a whitelisted token contract
+	if !p.assetWhitelist[request.Asset] {
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("unsupported asset: %s (only whitelisted USDC contracts are accepted)", request.Asset.Hex()),
+			ReasonCode: x402types.ReasonUnsupportedAsset,
+		}, nil
+	}
+
+	nonce, err := request.Authorization.Nonce.Nonce()
+	if err != nil {
+		decodeErr := x402types.NewDecodingError(err.Error())
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      decodeErr.Message,
+			ReasonCode: decodeErr.Code,
+		}, nil
+	}
+	sigBytes := []byte(request.Signature)
+
+	// Only the authorizer may cancel; checking here saves a reverted transaction
+	domain := eip712.TokenDomain(p.network, p.chainID, request.Asset)
+	typedData := eip712.TypedDataForCancelAuthorization(authorizer, nonce.String(), domain)
+	signer, err := eip712.RecoverTypedDataSigner(typedData, request.Signature)
+	if err != nil || signer != authorizer {
+		err := x402types.NewInvalidSignatureError(payer, "cancel signature is not the authorizer's")
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      err.Message,
+			ReasonCode: err.Code,
+		}, nil
+	}
+
+	// A settled or cancelled authorization cannot be cancelled again
+	authorizerAddress := authorizer.Hex()
+	if p.nonceStore.IsNonceUsed(authorizerAddress, nonce.String()) {
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      "authorization is already settled or cancelled",
+			ReasonCode: x402types.ReasonNonceReused,
+		}, nil
+	}
+	used, err := p.authorizationUsed(ctx, request.Asset, authorizer, nonce)
+	if err != nil {
+		if timeoutErr := timeoutError("authorization state check", err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("authorization state check failed: %v", err),
+			ReasonCode: x402types.ReasonRPCError,
+		}, nil
+	}
+	if used {
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      "authorization is already settled or cancelled",
+			ReasonCode: x402types.ReasonNonceReused,
+		}, nil
+	}
+
+	// The facilitator pays the gas; an empty account has nothing an
+	// authorization could move, so it is not worth a transaction
+	balance, err := p.getBalance(ctx, request.Asset, authorizer)
+	if err != nil {
+		if timeoutErr := timeoutError("balance check", err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("balance check failed: %v", err),
+			ReasonCode: x402types.ReasonRPCError,
+		}, nil
+	}
+	if balance.Sign() == 0 {
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      "authorizer holds no balance of the asset; there is nothing to cancel",
+			ReasonCode: x402types.ReasonInsufficientFunds,
+		}, nil
+	}
+
+	data, err := p.packCancelCall(request.Asset, authorizer, nonce, sigBytes)
+	if err != nil {
+		return nil, err
+	}
+	idx := p.pickSigner(ctx, true)
+	if idx < 0 {
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("no signer this request may use cancels on %s", p.network),
+			ReasonCode: x402types.ReasonPolicyViolation,
+		}, nil
+	}
+	txSigner := p.signers[idx]
+	tx, err := p.sendContractTx(ctx, txSigner, request.Asset, data, cancelGasLimit)
+	if err != nil {
+		if timeoutErr := timeoutError("submitting transaction", err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		return &x402types.CancelResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("transaction failed: %v", err),
+			ReasonCode: x402types.ReasonSettlementFailed,
+		}, nil
+	}
+	txHash := &x402types.TransactionHash{Type: "evm", Hash: tx.Hash().Hex()}
+
+	// Wait for receipt (aborts promptly if the caller's request is cancelled)
+	confirmCtx, cancel := p.confirmContext(ctx)
+	defer cancel()
+	receipt, err := bind.WaitMined(confirmCtx, p.client, tx)
+	if err != nil {
+		if timeoutErr := timeoutError(fmt.Sprintf("waiting for tx %s", tx.Hash().Hex()), err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		return &x402types.CancelResponse{
+			Success:         false,
+			TransactionHash: txHash,
+			Error:           fmt.Sprintf("waiting for tx failed: %v", err),
+			ReasonCode:      x402types.ReasonSettlementFailed,
+		}, nil
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		reason := p.revertReason(ctx, txSigner.Address(), tx, receipt.BlockNumber)
+		log.Printf("evm.Cancel: transaction %s reverted reason=%q", tx.Hash().Hex(), reason)
+		if reason == "" {
+			return &x402types.CancelResponse{
+				Success:         false,
+				TransactionHash: txHash,
+				Error:           "transaction reverted",
+				ReasonCode:      x402types.ReasonContractCallError,
+			}, nil
+		}
+		facErr := classifyRevert(reason, payer)
+		return &x402types.CancelResponse{
+			Success:         false,
+			TransactionHash: txHash,
+			Error:           fmt.Sprintf("transaction reverted: %s", reason),
+			ReasonCode:      facErr.Code,
+			RevertCode:      facErr.Type,
+		}, nil
+	}
+
+	// Pending settlements of the nonce now fail verification without an RPC
+	p.nonceStore.MarkNonceUsed(authorizerAddress, nonce.String(), p.clock.Now().Add(CancelledNonceRetention).Unix())
+	return &x402types.CancelResponse{
+		Success:         true,
+		TransactionHash: txHash,
+	}, nil
+}
+
+// EstimateCancelFee returns the most Cancel could spend on gas (wei of the
+// native token): its gas limit at the current gas price
+func (p *Provider) EstimateCancelFee(ctx context.Context) (*big.Int, error) {
+	gasCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	gasPrice, err := p.client.SuggestGasPrice(gasCtx)
+	if err != nil {
+		if timeoutErr := timeoutError("getting gas price", err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	return new(big.Int).Mul(gasPrice, big.NewInt(cancelGasLimit)), nil
+}
+
+// authorizationUsed asks token whether nonce of authorizer was used or cancelled
+func (p *Provider) authorizationUsed(ctx context.Context, token, authorizer common.Address, nonce [32]byte) (bool, error) {
+	callCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	return callAuthorizationState(callCtx, p.client, p.usdcABI, token, authorizer, nonce, nil)
+}
+
+// AuthorizationState reports whether nonce of authorizer was used or
+// cancelled on token, read at the latest block, whose number it returns so
+// callers know how fresh the answer is
+func (p *Provider) AuthorizationState(ctx context.Context, token, authorizer common.Address, nonce x402types.Nonce) (bool, uint64, error) {
+	if !p.assetWhitelist[token] {
+		return false, 0, x402types.NewUnsupportedAssetError(token.Hex())
+	}
+	callCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	block, err := p.client.BlockNumber(callCtx)
+	if err != nil {
+		if timeoutErr := timeoutError("block number", err); timeoutErr != nil {
+			return false, 0, timeoutErr
+		}
+		return false, 0, fmt.Errorf("block number: %w", err)
+	}
+	used, err := callAuthorizationState(callCtx, p.client, p.usdcABI, token, authorizer, nonce, new(big.Int).SetUint64(block))
+	if err != nil {
+		if timeoutErr := timeoutError("authorization state", err); timeoutErr != nil {
+			return false, 0, timeoutErr
+		}
+		return false, 0, err
+	}
+	return used, block, nil
+}
@@ -0,0 +1,157 @@
+package evm_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestCancelThenVerifyRejects(t *testing.T) {
+	chain := newTestChain(t)
+	provider, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := settleRequest(t, chain)
+	auth := request.PaymentPayload.Payload.Authorization
+	nonce, err := auth.Nonce.Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel, err := chain.CancelRequest(chain.Accounts[0], nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := provider.Cancel(context.Background(), cancel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Success || resp.TransactionHash == nil {
+		t.Fatalf("cancel refused: %s (%s)", resp.Error, resp.ReasonCode)
+	}
+	if used, err := chain.AuthorizationUsed(auth.From, nonce); err != nil || !used {
+		t.Fatalf("token reports the nonce used %v (%v), want it cancelled", used, err)
+	}
+
+	// Both the provider that cancelled and one that only asks the token refuse it
+	fresh, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify := &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements}
+	for name, p := range map[string]interface {
+		Verify(context.Context, *types.VerifyRequest) (*types.VerifyResponse, error)
+	}{"cancelling provider": provider, "fresh provider": fresh} {
+		verified, err := p.Verify(context.Background(), verify)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verified.IsValid || verified.ReasonCode != types.ReasonNonceReused {
+			t.Errorf("%s: valid %v, reason code %q; want %q", name, verified.IsValid, verified.ReasonCode, types.ReasonNonceReused)
+		}
+	}
+
+	if again, err := provider.Cancel(context.Background(), cancel); err != nil || again.Success || again.ReasonCode != types.ReasonNonceReused {
+		t.Errorf("second cancel: %+v (%v), want it refused as %q", again, err, types.ReasonNonceReused)
+	}
+}
+
+func TestCancelChecksSignatureDomain(t *testing.T) {
+	chain, err := testchain.New(2, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	provider, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizer := chain.Accounts[0]
+	var nonce types.Nonce
+	nonce[31] = 7
+	domain := eip712.TokenDomain(testchain.Network, big.NewInt(testchain.ChainID), testchain.TokenAddress)
+
+	signedIn := func(domain eip712.Domain) *types.CancelRequest {
+		request, err := chain.CancelRequest(authorizer, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := eip712.SignTypedData(eip712.TypedDataForCancelAuthorization(authorizer.Address, nonce.String(), domain), authorizer.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Signature = signature
+		return request
+	}
+	otherSigner, err := chain.CancelRequest(chain.Accounts[1], nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSigner.Authorization.Authorizer = authorizer.Address
+
+	for name, request := range map[string]*types.CancelRequest{
+		"another chain": signedIn(eip712.Domain{Name: domain.Name, Version: domain.Version, ChainID: big.NewInt(1), VerifyingContract: domain.VerifyingContract}),
+		"another token": signedIn(eip712.Domain{Name: domain.Name, Version: domain.Version, ChainID: domain.ChainID, VerifyingContract: common.HexToAddress("0x00000000000000000000000000000000000000c3")}),
+		"another name":  signedIn(eip712.Domain{Name: "Not USDC", Version: domain.Version, ChainID: domain.ChainID, VerifyingContract: domain.VerifyingContract}),
+		"another's key": otherSigner,
+	} {
+		resp, err := provider.Cancel(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Success || resp.ReasonCode != types.ReasonInvalidSignature {
+			t.Errorf("%s: success %v, reason code %q; want %q", name, resp.Success, resp.ReasonCode, types.ReasonInvalidSignature)
+		}
+	}
+	if used, _ := chain.AuthorizationUsed(authorizer.Address, nonce); used {
+		t.Error("a badly signed cancel reached the token")
+	}
+
+	// The token's own domain is accepted
+	if resp, err := provider.Cancel(context.Background(), signedIn(domain)); err != nil || !resp.Success {
+		t.Errorf("cancel in the token's domain: %+v (%v)", resp, err)
+	}
+}
+
+func TestAuthorizationStateReportsObservedBlock(t *testing.T) {
+	chain := newTestChain(t)
+	provider, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := settleRequest(t, chain)
+	auth := request.PaymentPayload.Payload.Authorization
+	nonce, err := auth.Nonce.Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	used, before, err := provider.AuthorizationState(context.Background(), testchain.TokenAddress, auth.From, nonce)
+	if err != nil || used || before == 0 {
+		t.Fatalf("before settling: used %v at block %d (%v)", used, before, err)
+	}
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := provider.Settle(context.Background(), request); err != nil || !resp.Success {
+		t.Fatalf("settle: %+v (%v)", resp, err)
+	}
+	used, after, err := provider.AuthorizationState(context.Background(), testchain.TokenAddress, auth.From, nonce)
+	if err != nil || !used || after <= before {
+		t.Errorf("after settling: used %v at block %d (%v), want it used at a block after %d", used, after, err, before)
+	}
+
+	other := common.HexToAddress("0x00000000000000000000000000000000000000c0")
+	var facErr *types.FacilitatorError
+	if _, _, err := provider.AuthorizationState(context.Background(), other, auth.From, nonce); !errors.As(err, &facErr) || facErr.Code != types.ReasonUnsupportedAsset {
+		t.Errorf("asset off the whitelist: error %v, want %s", err, types.ReasonUnsupportedAsset)
+	}
+}
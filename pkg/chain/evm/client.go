@@ -0,0 +1,31 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Client is the part of an Ethereum RPC client a Provider uses
+// *ethclient.Client implements it, and so does the client of a go-ethereum
+// simulated backend, which lets a Provider run against an in-process chain
+// (see Options.Client)
+type Client interface {
+	ethereum.ContractCaller    // CallContract
+	ethereum.TransactionReader // TransactionByHash, TransactionReceipt
+	ethereum.GasEstimator
+	ethereum.GasPricer
+	ethereum.TransactionSender
+
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) // For bind.WaitMined
+}
+
+var _ Client = (*ethclient.Client)(nil)
@@ -0,0 +1,67 @@
+package evm_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// fixedClock always reads the same instant
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// unixField parses an authorization's validAfter or validBefore
+func unixField(t *testing.T, field string) time.Time {
+	t.Helper()
+	sec, err := strconv.ParseInt(field, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return time.Unix(sec, 0)
+}
+
+func TestVerifyTimingFollowsInjectedClock(t *testing.T) {
+	chain := newTestChain(t)
+	request := settleRequest(t, chain)
+	auth := request.PaymentPayload.Payload.Authorization
+	validAfter, validBefore := unixField(t, auth.ValidAfter), unixField(t, auth.ValidBefore)
+
+	for _, tc := range []struct {
+		name string
+		now  time.Time
+		skew time.Duration
+		code types.ReasonCode // Empty when the payment is valid
+	}{
+		{"before validAfter", validAfter.Add(-30 * time.Second), 0, types.ReasonNotYetValid},
+		{"before validAfter within the skew", validAfter.Add(-30 * time.Second), time.Minute, ""},
+		{"before validAfter beyond the skew", validAfter.Add(-90 * time.Second), time.Minute, types.ReasonNotYetValid},
+		{"at validAfter", validAfter, 0, ""},
+		{"just before validBefore", validBefore.Add(-time.Second), 0, ""},
+		{"at validBefore", validBefore, 0, types.ReasonExpired},
+		{"at validBefore with a skew", validBefore, time.Minute, types.ReasonExpired},
+		{"long after validBefore", validBefore.Add(time.Hour), 0, types.ReasonExpired},
+	} {
+		provider, err := chain.Provider(evm.WithClock(fixedClock(tc.now)), evm.WithClockSkewTolerance(tc.skew))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if tc.code == "" {
+			if !resp.IsValid {
+				t.Errorf("%s: rejected as %s (%s), want it valid", tc.name, resp.ReasonCode, resp.Reason)
+			}
+			continue
+		}
+		if resp.IsValid || resp.ReasonCode != tc.code {
+			t.Errorf("%s: valid %v, reason code %q; want %q", tc.name, resp.IsValid, resp.ReasonCode, tc.code)
+		}
+	}
+}
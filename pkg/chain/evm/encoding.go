@@ -0,0 +1,65 @@
+package evm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/network"
+)
+
+// packTransferCall builds the transferWithAuthorization calldata of token,
+// passing the signature the way the token takes it (see
+// network.SignatureEncoding)
+func (p *Provider) packTransferCall(token, from, to common.Address, value, validAfter, validBefore *big.Int, nonce [32]byte, signature []byte) ([]byte, error) {
+	var data []byte
+	var err error
+	if network.TokenSignatureEncoding(p.network, token) == network.SignatureVRS {
+		v, r, s, splitErr := splitSignature(signature)
+		if splitErr != nil {
+			return nil, splitErr
+		}
+		data, err = p.usdcVRSABI.Pack("transferWithAuthorization", from, to, value, validAfter, validBefore, nonce, v, r, s)
+	} else {
+		data, err = p.usdcABI.Pack("transferWithAuthorization", from, to, value, validAfter, validBefore, nonce, signature)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack transferWithAuthorization: %w", err)
+	}
+	return data, nil
+}
+
+// packCancelCall builds the cancelAuthorization calldata of token in its
+// signature encoding
+func (p *Provider) packCancelCall(token, authorizer common.Address, nonce [32]byte, signature []byte) ([]byte, error) {
+	var data []byte
+	var err error
+	if network.TokenSignatureEncoding(p.network, token) == network.SignatureVRS {
+		v, r, s, splitErr := splitSignature(signature)
+		if splitErr != nil {
+			return nil, splitErr
+		}
+		data, err = p.usdcVRSABI.Pack("cancelAuthorization", authorizer, nonce, v, r, s)
+	} else {
+		data, err = p.usdcABI.Pack("cancelAuthorization", authorizer, nonce, signature)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack cancelAuthorization: %w", err)
+	}
+	return data, nil
+}
+
+// splitSignature splits a 65-byte r || s || v signature; v is returned as
+// 27 or 28, which ecrecover-based tokens require
+func splitSignature(signature []byte) (v uint8, r, s [32]byte, err error) {
+	if len(signature) != 65 {
+		return 0, r, s, fmt.Errorf("token takes a (v, r, s) signature, which needs 65 bytes, got %d", len(signature))
+	}
+	copy(r[:], signature[:32])
+	copy(s[:], signature[32:64])
+	v = signature[64]
+	if v < 27 {
+		v += 27
+	}
+	return v, r, s, nil
+}
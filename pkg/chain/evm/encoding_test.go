@@ -0,0 +1,144 @@
+package evm_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Reference words of the calldata below, each 32 bytes
+const (
+	wordFrom        = "00000000000000000000000000000000000000000000000000000000000000a1"
+	wordTo          = "00000000000000000000000000000000000000000000000000000000000000b0"
+	wordValue       = "00000000000000000000000000000000000000000000000000000000000003e8"
+	wordValidAfter  = "0000000000000000000000000000000000000000000000000000000000000001"
+	wordValidBefore = "00000000000000000000000000000000000000000000000000000000000000ff"
+	wordNonce       = "1111111111111111111111111111111111111111111111111111111111111111"
+	wordR           = "2222222222222222222222222222222222222222222222222222222222222222"
+	wordS           = "3333333333333333333333333333333333333333333333333333333333333333"
+)
+
+// encodingProvider creates a verify-only provider for a network whose USDC
+// takes signatures in encoding
+func encodingProvider(t *testing.T, net types.Network, chainID uint64, token common.Address, encoding network.SignatureEncoding) *evm.Provider {
+	t.Helper()
+	if err := network.RegisterNetwork(net, network.ChainID(chainID), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.RegisterUSDCDeployment(network.USDCDeployment{Network: net, TokenAddress: token, SignatureEncoding: encoding}); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := evm.New(net, evm.Options{RPCURL: "http://127.0.0.1:1", ChainID: new(big.Int).SetUint64(chainID)}, evm.WithVerifyOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+// signatureOf joins r, s and v into a 65-byte signature
+func signatureOf(v byte) []byte {
+	return append(append(bytes.Repeat([]byte{0x22}, 32), bytes.Repeat([]byte{0x33}, 32)...), v)
+}
+
+func reference(t *testing.T, words ...string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(strings.Join(words, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestTransferCalldataFollowsSignatureEncoding(t *testing.T) {
+	token := common.HexToAddress("0x00000000000000000000000000000000000000c4")
+	bytesProvider := encodingProvider(t, "encoding-bytes", 990_831, token, network.SignatureBytes)
+	vrsProvider := encodingProvider(t, "encoding-vrs", 990_832, token, network.SignatureVRS)
+	from := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	to := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	var nonce [32]byte
+	copy(nonce[:], bytes.Repeat([]byte{0x11}, 32))
+
+	// One bytes argument: its offset (7 words), length (65) and the
+	// signature padded to 3 words
+	wantBytes := reference(t, "cf092995",
+		wordFrom, wordTo, wordValue, wordValidAfter, wordValidBefore, wordNonce,
+		"00000000000000000000000000000000000000000000000000000000000000e0",
+		"0000000000000000000000000000000000000000000000000000000000000041",
+		wordR, wordS, "1b00000000000000000000000000000000000000000000000000000000000000",
+	)
+	// v, r and s as static words
+	wantVRS := reference(t, "e3ee160e",
+		wordFrom, wordTo, wordValue, wordValidAfter, wordValidBefore, wordNonce,
+		"000000000000000000000000000000000000000000000000000000000000001b", wordR, wordS,
+	)
+
+	got, err := bytesProvider.PackTransferCall(token, from, to, big.NewInt(1000), big.NewInt(1), big.NewInt(255), nonce, signatureOf(27))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, wantBytes) {
+		t.Errorf("bytes encoding:\n got %x\nwant %x", got, wantBytes)
+	}
+	// A 0/1 recovery id is raised to the 27/28 ecrecover expects
+	for _, v := range []byte{0, 27} {
+		got, err := vrsProvider.PackTransferCall(token, from, to, big.NewInt(1000), big.NewInt(1), big.NewInt(255), nonce, signatureOf(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, wantVRS) {
+			t.Errorf("vrs encoding with v=%d:\n got %x\nwant %x", v, got, wantVRS)
+		}
+	}
+
+	// Other tokens on the vrs network take the standard encoding
+	other := common.HexToAddress("0x00000000000000000000000000000000000000c5")
+	if got, err := vrsProvider.PackTransferCall(other, from, to, big.NewInt(1000), big.NewInt(1), big.NewInt(255), nonce, signatureOf(27)); err != nil || !bytes.Equal(got[:4], wantBytes[:4]) {
+		t.Errorf("unregistered token packed with selector %x (%v), want the bytes encoding", got[:4], err)
+	}
+	if _, err := vrsProvider.PackTransferCall(token, from, to, big.NewInt(1000), big.NewInt(1), big.NewInt(255), nonce, signatureOf(27)[:64]); err == nil {
+		t.Error("a 64-byte signature was split into (v, r, s)")
+	}
+}
+
+func TestCancelCalldataFollowsSignatureEncoding(t *testing.T) {
+	token := common.HexToAddress("0x00000000000000000000000000000000000000c6")
+	bytesProvider := encodingProvider(t, "encoding-cancel-bytes", 990_833, token, network.SignatureBytes)
+	vrsProvider := encodingProvider(t, "encoding-cancel-vrs", 990_834, token, network.SignatureVRS)
+	authorizer := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	var nonce [32]byte
+	copy(nonce[:], bytes.Repeat([]byte{0x11}, 32))
+
+	wantBytes := reference(t, "b7b72899", wordFrom, wordNonce,
+		"0000000000000000000000000000000000000000000000000000000000000060",
+		"0000000000000000000000000000000000000000000000000000000000000041",
+		wordR, wordS, "1c00000000000000000000000000000000000000000000000000000000000000",
+	)
+	wantVRS := reference(t, "5a049a70", wordFrom, wordNonce,
+		"000000000000000000000000000000000000000000000000000000000000001c", wordR, wordS,
+	)
+
+	for name, tc := range map[string]struct {
+		provider *evm.Provider
+		v        byte
+		want     []byte
+	}{
+		"bytes":      {bytesProvider, 28, wantBytes},
+		"vrs":        {vrsProvider, 28, wantVRS},
+		"vrs from 1": {vrsProvider, 1, wantVRS},
+	} {
+		got, err := tc.provider.PackCancelCall(token, authorizer, nonce, signatureOf(tc.v))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(got, tc.want) {
+			t.Errorf("%s:\n got %x\nwant %x", name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package evm
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// OutstandingVerifications counts the verification IDs p has issued and not
+// yet seen consumed
+func (p *Provider) OutstandingVerifications() int {
+	p.verifications.mu.Lock()
+	defer p.verifications.mu.Unlock()
+	return len(p.verifications.entries)
+}
+
+// PackTransferCall exposes the transferWithAuthorization calldata p builds
+func (p *Provider) PackTransferCall(token, from, to common.Address, value, validAfter, validBefore *big.Int, nonce [32]byte, signature []byte) ([]byte, error) {
+	return p.packTransferCall(token, from, to, value, validAfter, validBefore, nonce, signature)
+}
+
+// PackCancelCall exposes the cancelAuthorization calldata p builds
+func (p *Provider) PackCancelCall(token, authorizer common.Address, nonce [32]byte, signature []byte) ([]byte, error) {
+	return p.packCancelCall(token, authorizer, nonce, signature)
+}
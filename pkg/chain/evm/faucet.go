@@ -0,0 +1,50 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrMainnetTransfer is returned by Transfer on mainnet networks
+var ErrMainnetTransfer = errors.New("token transfers from the settlement signers are only allowed on testnets")
+
+// transferGasLimit covers a plain ERC-20 transfer (~35-55k) with headroom
+const transferGasLimit = 80000
+
+// Transfer sends amount of token from one of the settlement signers to to
+// with a plain ERC-20 transfer, without waiting for it to be mined. It backs
+// the development faucet and refuses unconditionally on mainnets.
+func (p *Provider) Transfer(ctx context.Context, token, to common.Address, amount *big.Int) (common.Hash, error) {
+	if !p.network.IsTestnet() {
+		return common.Hash{}, ErrMainnetTransfer
+	}
+	if p.verifyOnly {
+		return common.Hash{}, errors.New("provider is verify-only and cannot send transactions")
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return common.Hash{}, errors.New("transfer amount must be positive")
+	}
+	data, err := p.packTransfer(to, amount)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signer := p.signers[p.nextSignerIndex(true)]
+	tx, err := p.sendContractTx(ctx, signer, token, data, transferGasLimit)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// packTransfer builds the calldata of transfer(to, amount)
+func (p *Provider) packTransfer(to common.Address, amount *big.Int) ([]byte, error) {
+	data, err := p.usdcABI.Pack("transfer", to, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack transfer: %w", err)
+	}
+	return data, nil
+}
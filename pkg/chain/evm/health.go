@@ -0,0 +1,242 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// RPC circuit breaker defaults (see WithRPCCircuitBreaker)
+const (
+	DefaultRPCBreakerErrorRate     = 0.5
+	DefaultRPCBreakerProbeInterval = 5 * time.Second
+)
+
+const (
+	// rpcHealthWindow is how many recent RPC-backed checks the error rate
+	// is taken over
+	rpcHealthWindow = 20
+	// rpcHealthMinSamples is how many checks the window needs before the
+	// breaker may open, so a single early failure does not trip it
+	rpcHealthMinSamples = 10
+)
+
+// CircuitState is the state of a provider's RPC circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed CircuitState = "closed" // Verifications reach the RPC
+	CircuitOpen   CircuitState = "open"   // Verifications fail fast until a probe succeeds
+)
+
+// RPCHealth summarizes the RPC-backed checks of recent verifications
+type RPCHealth struct {
+	State          CircuitState `json:"state"`
+	ErrorRate      float64      `json:"errorRate"` // Failed or slow checks in the window, 0 to 1
+	Samples        int          `json:"samples"`
+	AvgLatencyMs   int64        `json:"avgLatencyMs"`
+	Opens          uint64       `json:"opens"`
+	ShortCircuited uint64       `json:"shortCircuited"` // Verifications refused while open
+	OpenedAt       *time.Time   `json:"openedAt,omitempty"`
+	LastProbeError string       `json:"lastProbeError,omitempty"`
+}
+
+// WithRPCCircuitBreaker makes Verify fail fast with a ServiceDegraded error,
+// rather than wait out the RPC timeout, while the network's RPC is failing:
+// once at least errorRate (0 to 1) of the recent nonce, balance and
+// allowance checks failed or took over half the RPC timeout, the breaker
+// opens, and every probeInterval a cheap call probes the RPC until it
+// answers promptly again, closing it. Zero values keep the defaults.
+func WithRPCCircuitBreaker(errorRate float64, probeInterval time.Duration) ProviderOption {
+	return func(o *providerOptions) {
+		o.rpcBreaker = true
+		if errorRate > 0 && errorRate <= 1 {
+			o.rpcBreakerErrorRate = errorRate
+		}
+		if probeInterval > 0 {
+			o.rpcBreakerProbeInterval = probeInterval
+		}
+	}
+}
+
+// rpcSample is the outcome of one RPC-backed check
+type rpcSample struct {
+	failed  bool
+	latency time.Duration
+}
+
+// rpcBreaker scores the provider's RPC health from recent checks
+type rpcBreaker struct {
+	errorRate     float64
+	probeInterval time.Duration
+	slowCall      time.Duration // Slower checks count as failures
+
+	mu             sync.Mutex
+	samples        [rpcHealthWindow]rpcSample
+	count, next    int
+	state          CircuitState
+	openedAt       time.Time
+	opens          uint64
+	shortCircuited uint64
+	lastProbeError string
+}
+
+func newRPCBreaker(errorRate float64, probeInterval, rpcTimeout time.Duration) *rpcBreaker {
+	if errorRate <= 0 {
+		errorRate = DefaultRPCBreakerErrorRate
+	}
+	if probeInterval <= 0 {
+		probeInterval = DefaultRPCBreakerProbeInterval
+	}
+	return &rpcBreaker{
+		errorRate:     errorRate,
+		probeInterval: probeInterval,
+		slowCall:      rpcTimeout / 2,
+		state:         CircuitClosed,
+	}
+}
+
+// RPCHealth returns the state of the RPC circuit breaker and the health
+// score behind it (nil without WithRPCCircuitBreaker)
+func (p *Provider) RPCHealth() *RPCHealth {
+	if p.rpcBreaker == nil {
+		return nil
+	}
+	return p.rpcBreaker.snapshot()
+}
+
+// RPCDegraded reports whether the RPC circuit breaker is open
+func (p *Provider) RPCDegraded() bool {
+	return p.rpcBreaker != nil && p.rpcBreaker.open()
+}
+
+// checkRPCHealth returns the error refusing a verification while the
+// breaker is open (nil if the RPC may be called)
+func (p *Provider) checkRPCHealth() error {
+	if p.rpcBreaker == nil || !p.rpcBreaker.refuse() {
+		return nil
+	}
+	return x402types.NewServiceDegradedError(fmt.Sprintf("RPC for %s is failing; verification is paused until it recovers", p.network))
+}
+
+// recordRPC adds the outcome of a check that started at start to the
+// health score; checks abandoned by the caller are left out
+func (p *Provider) recordRPC(ctx context.Context, start time.Time, err error) {
+	if p.rpcBreaker == nil || ctx.Err() != nil {
+		return
+	}
+	if p.rpcBreaker.record(time.Since(start), err) {
+		log.Printf("evm: %s RPC circuit breaker opened (error rate above %.0f%%); verifications fail fast until the RPC recovers", p.network, p.rpcBreaker.errorRate*100)
+		go p.probeRPC()
+	}
+}
+
+// probeRPC probes the RPC every probe interval until it answers promptly,
+// then closes the breaker
+func (p *Provider) probeRPC() {
+	ticker := time.NewTicker(p.rpcBreaker.probeInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := p.rpcContext(context.Background())
+		start := time.Now()
+		_, err := p.client.BlockNumber(ctx)
+		cancel()
+		if err == nil && time.Since(start) > p.rpcBreaker.slowCall {
+			err = fmt.Errorf("probe took %s", time.Since(start).Round(time.Millisecond))
+		}
+		if p.rpcBreaker.probed(err) {
+			log.Printf("evm: %s RPC circuit breaker closed; the RPC recovered", p.network)
+			return
+		}
+	}
+}
+
+// record adds a sample, reporting whether it opened the breaker
+func (b *rpcBreaker) record(latency time.Duration, err error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.samples[b.next] = rpcSample{failed: err != nil || latency > b.slowCall, latency: latency}
+	b.next = (b.next + 1) % rpcHealthWindow
+	if b.count < rpcHealthWindow {
+		b.count++
+	}
+	if b.state == CircuitOpen || b.count < rpcHealthMinSamples || b.failureRate() < b.errorRate {
+		return false
+	}
+	b.state, b.openedAt, b.lastProbeError = CircuitOpen, time.Now(), ""
+	b.opens++
+	return true
+}
+
+// refuse reports whether the breaker is open, counting the refusal
+func (b *rpcBreaker) refuse() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != CircuitOpen {
+		return false
+	}
+	b.shortCircuited++
+	return true
+}
+
+// probed records a probe outcome, closing the breaker with a fresh window
+// on success; it reports whether the breaker closed
+func (b *rpcBreaker) probed(err error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.lastProbeError = err.Error()
+		return false
+	}
+	b.state, b.count, b.next, b.lastProbeError = CircuitClosed, 0, 0, ""
+	return true
+}
+
+func (b *rpcBreaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == CircuitOpen
+}
+
+// failureRate returns the share of failed samples in the window
+func (b *rpcBreaker) failureRate() float64 {
+	if b.count == 0 {
+		return 0
+	}
+	failed := 0
+	for _, sample := range b.samples[:b.count] {
+		if sample.failed {
+			failed++
+		}
+	}
+	return float64(failed) / float64(b.count)
+}
+
+func (b *rpcBreaker) snapshot() *RPCHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	health := &RPCHealth{
+		State:          b.state,
+		ErrorRate:      b.failureRate(),
+		Samples:        b.count,
+		Opens:          b.opens,
+		ShortCircuited: b.shortCircuited,
+		LastProbeError: b.lastProbeError,
+	}
+	if b.state == CircuitOpen {
+		openedAt := b.openedAt
+		health.OpenedAt = &openedAt
+	}
+	if b.count > 0 {
+		var total time.Duration
+		for _, sample := range b.samples[:b.count] {
+			total += sample.latency
+		}
+		health.AvgLatencyMs = (total / time.Duration(b.count)).Milliseconds()
+	}
+	return health
+}
@@ -0,0 +1,147 @@
+package evm_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// flakyClient is the chain's client with reads failing while failing is set
+type flakyClient struct {
+	evm.Client
+	failing atomic.Bool
+}
+
+func (c *flakyClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	if c.failing.Load() {
+		return nil, errors.New("503 service unavailable")
+	}
+	return c.Client.CallContract(ctx, msg, block)
+}
+
+func (c *flakyClient) BlockNumber(ctx context.Context) (uint64, error) {
+	if c.failing.Load() {
+		return 0, errors.New("503 service unavailable")
+	}
+	return c.Client.BlockNumber(ctx)
+}
+
+func flakyProvider(t *testing.T, chain *testchain.Chain, opts ...evm.ProviderOption) (*evm.Provider, *flakyClient) {
+	t.Helper()
+	options := chain.Options()
+	client := &flakyClient{Client: options.Client}
+	options.Client = client
+	provider, err := evm.New(testchain.Network, options, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, client
+}
+
+func TestRPCCircuitBreakerFailsFastThenRecovers(t *testing.T) {
+	chain := newTestChain(t)
+	provider, client := flakyProvider(t, chain, evm.WithRPCCircuitBreaker(0.5, 20*time.Millisecond))
+	request := settleRequest(t, chain)
+	verify := func() (*types.VerifyResponse, error) {
+		return provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+	}
+
+	// A healthy RPC keeps the breaker closed
+	for i := 0; i < 10; i++ {
+		if resp, err := verify(); err != nil || !resp.IsValid {
+			t.Fatalf("healthy verify %d: %+v (%v)", i+1, resp, err)
+		}
+	}
+	if health := provider.RPCHealth(); health.State != evm.CircuitClosed || health.Samples != 20 || health.ErrorRate != 0 {
+		t.Fatalf("after healthy verifies: %+v, want a closed breaker over a full window", health)
+	}
+
+	// Failures are refused as RPC errors until they make up half the window
+	client.failing.Store(true)
+	for i := 0; i < 10; i++ {
+		resp, err := verify()
+		if err != nil || resp.IsValid || resp.ReasonCode != types.ReasonRPCError {
+			t.Fatalf("failing verify %d: %+v (%v), want an rpc_error refusal", i+1, resp, err)
+		}
+	}
+	if !provider.RPCDegraded() {
+		t.Fatalf("breaker closed at %+v", provider.RPCHealth())
+	}
+
+	// Open, verification fails fast without calling the RPC
+	start := time.Now()
+	_, err := verify()
+	var facErr *types.FacilitatorError
+	if !errors.As(err, &facErr) || facErr.Code != types.ReasonServiceDegraded {
+		t.Fatalf("verify while open: error %v, want ServiceDegraded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("verify while open took %v, want it to fail fast", elapsed)
+	}
+	if _, err := provider.Settle(context.Background(), request); !errors.As(err, &facErr) || facErr.Code != types.ReasonServiceDegraded {
+		t.Errorf("settle while open: error %v, want ServiceDegraded", err)
+	}
+	health := provider.RPCHealth()
+	if health.State != evm.CircuitOpen || health.Opens != 1 || health.ShortCircuited != 2 || health.OpenedAt == nil {
+		t.Errorf("while open: %+v", health)
+	}
+
+	// Failed probes keep it open
+	time.Sleep(60 * time.Millisecond)
+	if health := provider.RPCHealth(); health.State != evm.CircuitOpen || health.LastProbeError == "" {
+		t.Errorf("after failed probes: %+v, want it open with the probe error", health)
+	}
+
+	// The first prompt probe closes it with a fresh window
+	client.failing.Store(false)
+	deadline := time.Now().Add(2 * time.Second)
+	for provider.RPCDegraded() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if health := provider.RPCHealth(); health.State != evm.CircuitClosed || health.Samples != 0 || health.Opens != 1 {
+		t.Fatalf("after recovery: %+v, want a closed breaker with a fresh window", health)
+	}
+	if resp, err := verify(); err != nil || !resp.IsValid {
+		t.Errorf("verify after recovery: %+v (%v)", resp, err)
+	}
+}
+
+func TestRPCCircuitBreakerNeedsMinimumSamples(t *testing.T) {
+	chain := newTestChain(t)
+	provider, client := flakyProvider(t, chain, evm.WithRPCCircuitBreaker(0, 0))
+	request := settleRequest(t, chain)
+	client.failing.Store(true)
+
+	// Every check fails, but nine are too few to judge the RPC by
+	for i := 0; i < 9; i++ {
+		provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+	}
+	if health := provider.RPCHealth(); health.State != evm.CircuitClosed || health.Samples != 9 || health.ErrorRate != 1 {
+		t.Errorf("after nine failures: %+v, want it still closed", health)
+	}
+}
+
+func TestRPCCircuitBreakerIsOptIn(t *testing.T) {
+	chain := newTestChain(t)
+	provider, client := flakyProvider(t, chain)
+	request := settleRequest(t, chain)
+	client.failing.Store(true)
+
+	for i := 0; i < 20; i++ {
+		resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+		if err != nil || resp.ReasonCode != types.ReasonRPCError {
+			t.Fatalf("verify %d: %+v (%v), want the RPC tried every time", i+1, resp, err)
+		}
+	}
+	if provider.RPCHealth() != nil || provider.RPCDegraded() {
+		t.Errorf("provider without a breaker reports %+v", provider.RPCHealth())
+	}
+}
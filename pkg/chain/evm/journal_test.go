@@ -0,0 +1,75 @@
+package evm_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestSettleJournalsTransaction(t *testing.T) {
+	chain := newTestChain(t)
+	journal := accounting.NewSettlementJournal(16)
+	provider, err := chain.Provider(evm.WithJournal(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := settleRequest(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	ctx := accounting.WithJournalID(context.Background(), "settle-1")
+	resp, err := provider.Settle(ctx, request)
+	if err != nil || !resp.Success {
+		t.Fatalf("settle: %+v, %v", resp, err)
+	}
+
+	entries := journal.Entries("", 0)
+	if len(entries) != 1 {
+		t.Fatalf("%d journal entries, want the submission and its outcome in one", len(entries))
+	}
+	e, auth := entries[0], request.PaymentPayload.Payload.Authorization
+	if e.ID != "settle-1" || e.Status != accounting.JournalConfirmed || e.TransactionHash != resp.TransactionHash.Hash {
+		t.Errorf("journal entry %+v, want settle-1 confirmed in %s", e, resp.TransactionHash.Hash)
+	}
+	if e.Payer != auth.From.Hex() || e.Nonce != auth.Nonce.String() || e.ValidBefore != unixField(t, auth.ValidBefore).Unix() || e.FacilitatorFee != "" {
+		t.Errorf("journal entry %+v does not identify the authorization", e)
+	}
+	if inFlight := journal.InFlight(); len(inFlight) != 0 {
+		t.Errorf("%d entries left in flight after the receipt", len(inFlight))
+	}
+}
+
+func TestSettleJournalsFacilitatorFee(t *testing.T) {
+	chain := newTestChain(t)
+	journal := accounting.NewSettlementJournal(16)
+	provider, err := chain.Provider(evm.WithJournal(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+	if err := (types.FeePolicy{FlatAmount: big.NewInt(100)}).ApplyFee(&requirements); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := provider.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil || !resp.Success {
+		t.Fatalf("settle: %+v, %v", resp, err)
+	}
+
+	entries := journal.Entries("", 0)
+	if len(entries) != 1 || entries[0].Amount != "1100" || entries[0].FacilitatorFee != "100" || entries[0].Status != accounting.JournalConfirmed {
+		t.Errorf("journal entries %+v, want one confirmed settlement of 1100 with a fee of 100", entries)
+	}
+}
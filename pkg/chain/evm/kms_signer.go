@@ -0,0 +1,231 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// KMSConfig selects an AWS KMS key and the credentials used to reach it
+// The key must be an asymmetric ECC_SECG_P256K1 key with SIGN_VERIFY usage
+type KMSConfig struct {
+	KeyID           string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // optional, for temporary credentials
+	Endpoint        string // optional, defaults to https://kms.<region>.amazonaws.com
+	HTTPClient      *http.Client
+}
+
+// KMSSigner signs with a secp256k1 key held in AWS KMS
+// The private key never leaves KMS; each signature is one kms:Sign call
+type KMSSigner struct {
+	cfg     KMSConfig
+	client  *http.Client
+	pubKey  []byte // uncompressed, 65 bytes
+	address common.Address
+}
+
+// secp256k1HalfN is used to normalize KMS signatures to low-s form
+var secp256k1HalfN = new(big.Int).Rsh(crypto.S256().Params().N, 1)
+
+// NewKMSSigner fetches the public key of cfg.KeyID and derives its address
+func NewKMSSigner(ctx context.Context, cfg KMSConfig) (*KMSSigner, error) {
+	if cfg.KeyID == "" || cfg.Region == "" {
+		return nil, fmt.Errorf("KMS key ID and region are required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("KMS credentials are required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", cfg.Region)
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: DefaultRemoteSignerTimeout}
+	}
+	s := &KMSSigner{cfg: cfg, client: client}
+
+	var resp struct {
+		PublicKey []byte `json:"PublicKey"`
+	}
+	if err := s.call(ctx, "GetPublicKey", map[string]string{"KeyId": cfg.KeyID}, &resp); err != nil {
+		return nil, fmt.Errorf("KMS key %s: %w", cfg.KeyID, err)
+	}
+
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(resp.PublicKey, &spki); err != nil {
+		return nil, fmt.Errorf("KMS key %s: invalid public key: %w", cfg.KeyID, err)
+	}
+	pubKey, err := crypto.UnmarshalPubkey(spki.PublicKey.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("KMS key %s is not a secp256k1 key: %w", cfg.KeyID, err)
+	}
+	s.pubKey = crypto.FromECDSAPub(pubKey)
+	s.address = crypto.PubkeyToAddress(*pubKey)
+	return s, nil
+}
+
+// Address implements Signer
+func (s *KMSSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx implements Signer
+func (s *KMSSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return signTxWithHash(ctx, s.SignHash, tx, chainID)
+}
+
+// SignHash implements Signer
+// KMS returns a DER signature without a recovery id, so the low-s form is
+// computed and V is found by recovering against the known public key
+func (s *KMSSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	var resp struct {
+		Signature []byte `json:"Signature"`
+	}
+	req := map[string]interface{}{
+		"KeyId":            s.cfg.KeyID,
+		"Message":          hash,
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": "ECDSA_SHA_256",
+	}
+	if err := s.call(ctx, "Sign", req, &resp); err != nil {
+		return nil, fmt.Errorf("KMS sign: %w", err)
+	}
+
+	var der struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(resp.Signature, &der); err != nil {
+		return nil, fmt.Errorf("KMS returned an invalid signature: %w", err)
+	}
+	if der.S.Cmp(secp256k1HalfN) > 0 {
+		der.S.Sub(crypto.S256().Params().N, der.S)
+	}
+
+	sig := make([]byte, 65)
+	der.R.FillBytes(sig[:32])
+	der.S.FillBytes(sig[32:64])
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		if pub, err := crypto.Ecrecover(hash, sig); err == nil && bytes.Equal(pub, s.pubKey) {
+			return sig, nil
+		}
+	}
+	return nil, fmt.Errorf("KMS signature does not recover to %s", s.address.Hex())
+}
+
+// call invokes one KMS JSON API action, signed with SigV4
+func (s *KMSSigner) call(ctx context.Context, action string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	s.signRequest(req, payload, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		var kmsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(raw, &kmsErr) == nil && kmsErr.Type != "" {
+			return fmt.Errorf("%s: %s %s", action, kmsErr.Type, kmsErr.Message)
+		}
+		return fmt.Errorf("%s: status %d: %s", action, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// signRequest adds AWS Signature Version 4 headers for the kms service
+func (s *KMSSigner) signRequest(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.cfg.SessionToken)
+	}
+
+	headers := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	if s.cfg.SessionToken != "" {
+		headers = append(headers, "x-amz-security-token")
+	}
+	sort.Strings(headers)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headers {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexSHA256(payload),
+	}, "\n")
+
+	scope := date + "/" + s.cfg.Region + "/kms/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "kms")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
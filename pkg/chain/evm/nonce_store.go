@@ -1,8 +1,24 @@
 package evm
 
 import (
+	"container/list"
 	"sync"
 	"time"
+
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+const (
+	// DefaultNonceStoreMaxEntries caps the total number of tracked nonces
+	DefaultNonceStoreMaxEntries = 100000
+	// DefaultNonceStoreMaxPerAddress caps the nonces tracked for a single payer
+	DefaultNonceStoreMaxPerAddress = 1000
+
+	// nonceExpiryBuffer keeps nonces a little past validBefore to absorb clock skew
+	// (the token contract rejects expired authorizations, so replay is impossible after that)
+	nonceExpiryBuffer = 5 * time.Minute
+	// expiredSweepInterval bounds how often a full expired-entry sweep runs on insert
+	expiredSweepInterval = time.Second
 )
 
 // NonceEntry tracks when a nonce was first seen and its expiration
@@ -11,26 +27,74 @@ type NonceEntry struct {
 	ExpiresAt time.Time
 }
 
+// nonceItem is a stored nonce, linked into both the global and per-address insertion order
+type nonceItem struct {
+	key     string
+	address string
+	entry   NonceEntry
+	global  *list.Element
+	byAddr  *list.Element
+}
+
 // NonceStore tracks used ERC-3009 nonces to prevent replay attacks
 // This is an optimization layer - the smart contract also enforces nonce uniqueness
+//
+// Memory is bounded: each payer may hold at most maxPerAddress nonces (their own
+// oldest are evicted first), and once maxEntries is reached expired entries are
+// evicted before falling back to the globally oldest entry.
 type NonceStore struct {
-	mu     sync.RWMutex
-	nonces map[string]NonceEntry // key: "from_address:nonce_hex"
+	mu        sync.RWMutex
+	nonces    map[string]*nonceItem // key: "from_address:nonce_hex"
+	order     *list.List            // all items, oldest first
+	byAddress map[string]*list.List // per-address items, oldest first
+
+	maxEntries    int
+	maxPerAddress int
+	lastSweep     time.Time
+	clock         x402types.Clock
+
+	// Eviction counters (for GetStats)
+	expiredEvictions    uint64
+	addressCapEvictions uint64
+	globalCapEvictions  uint64
 
 	// Cleanup ticker
 	cleanupTicker *time.Ticker
 	stopCleanup   chan bool
 }
 
-// NewNonceStore creates a new nonce tracking store
+// NewNonceStore creates a new nonce tracking store with the default limits
 func NewNonceStore() *NonceStore {
+	return NewNonceStoreWithLimits(DefaultNonceStoreMaxEntries, DefaultNonceStoreMaxPerAddress)
+}
+
+// NewNonceStoreWithLimits creates a nonce store with explicit memory caps
+// Non-positive values fall back to the defaults
+func NewNonceStoreWithLimits(maxEntries, maxPerAddress int) *NonceStore {
+	return NewNonceStoreWithClock(maxEntries, maxPerAddress, x402types.SystemClock{})
+}
+
+// NewNonceStoreWithClock creates a nonce store whose expiry follows clock
+func NewNonceStoreWithClock(maxEntries, maxPerAddress int, clock x402types.Clock) *NonceStore {
+	if maxEntries <= 0 {
+		maxEntries = DefaultNonceStoreMaxEntries
+	}
+	if maxPerAddress <= 0 {
+		maxPerAddress = DefaultNonceStoreMaxPerAddress
+	}
+
 	ns := &NonceStore{
-		nonces:      make(map[string]NonceEntry),
-		stopCleanup: make(chan bool),
+		nonces:        make(map[string]*nonceItem),
+		order:         list.New(),
+		byAddress:     make(map[string]*list.List),
+		maxEntries:    maxEntries,
+		maxPerAddress: maxPerAddress,
+		clock:         clock,
+		stopCleanup:   make(chan bool),
 	}
 
-	// Start cleanup goroutine (runs every 5 minutes)
-	ns.cleanupTicker = time.NewTicker(5 * time.Minute)
+	// Start cleanup goroutine (runs every minute)
+	ns.cleanupTicker = time.NewTicker(1 * time.Minute)
 	go ns.cleanupExpiredNonces()
 
 	return ns
@@ -42,14 +106,14 @@ func (ns *NonceStore) IsNonceUsed(fromAddress, nonce string) bool {
 	defer ns.mu.RUnlock()
 
 	key := fromAddress + ":" + nonce
-	entry, exists := ns.nonces[key]
+	item, exists := ns.nonces[key]
 
 	if !exists {
 		return false
 	}
 
 	// Check if expired
-	if time.Now().After(entry.ExpiresAt) {
+	if ns.clock.Now().After(item.entry.ExpiresAt) {
 		return false
 	}
 
@@ -63,14 +127,94 @@ func (ns *NonceStore) MarkNonceUsed(fromAddress, nonce string, validBefore int64
 	defer ns.mu.Unlock()
 
 	key := fromAddress + ":" + nonce
+	now := ns.clock.Now()
+
+	// Store nonce with expiration = validBefore + skew buffer
+	entry := NonceEntry{
+		FirstSeen: now,
+		ExpiresAt: time.Unix(validBefore, 0).Add(nonceExpiryBuffer),
+	}
+
+	if existing, ok := ns.nonces[key]; ok {
+		existing.entry.ExpiresAt = entry.ExpiresAt
+		return
+	}
 
-	// Store nonce with expiration = validBefore + 1 hour buffer
-	expiresAt := time.Unix(validBefore, 0).Add(1 * time.Hour)
+	// Enforce per-address cap by evicting this payer's own oldest nonces
+	addrList := ns.byAddress[fromAddress]
+	if addrList == nil {
+		addrList = list.New()
+		ns.byAddress[fromAddress] = addrList
+	}
+	for addrList.Len() >= ns.maxPerAddress {
+		ns.remove(addrList.Front().Value.(*nonceItem))
+		ns.addressCapEvictions++
+	}
+	// remove may have dropped the now-empty list
+	ns.byAddress[fromAddress] = addrList
 
-	ns.nonces[key] = NonceEntry{
-		FirstSeen: time.Now(),
-		ExpiresAt: expiresAt,
+	// Enforce global cap: expired entries go first, then the oldest entry
+	if len(ns.nonces) >= ns.maxEntries {
+		ns.evictForInsert(now)
+	}
+
+	item := &nonceItem{key: key, address: fromAddress, entry: entry}
+	item.global = ns.order.PushBack(item)
+	item.byAddr = addrList.PushBack(item)
+	ns.nonces[key] = item
+}
+
+// evictForInsert frees one slot when the store is at its global cap
+func (ns *NonceStore) evictForInsert(now time.Time) {
+	// Cheap check: the oldest entry is usually the first to expire
+	if front := ns.order.Front(); front != nil {
+		if oldest := front.Value.(*nonceItem); now.After(oldest.entry.ExpiresAt) {
+			ns.remove(oldest)
+			ns.expiredEvictions++
+			return
+		}
+	}
+
+	// Full sweep of expired entries, rate limited so floods stay O(1) amortized
+	if now.Sub(ns.lastSweep) >= expiredSweepInterval {
+		ns.lastSweep = now
+		if ns.removeExpired(now) > 0 {
+			return
+		}
+	}
+
+	// Still full of live nonces: drop the globally oldest one
+	if front := ns.order.Front(); front != nil {
+		ns.remove(front.Value.(*nonceItem))
+		ns.globalCapEvictions++
+	}
+}
+
+// remove unlinks an item from all indexes; caller must hold the write lock
+func (ns *NonceStore) remove(item *nonceItem) {
+	delete(ns.nonces, item.key)
+	ns.order.Remove(item.global)
+	if addrList := ns.byAddress[item.address]; addrList != nil {
+		addrList.Remove(item.byAddr)
+		if addrList.Len() == 0 {
+			delete(ns.byAddress, item.address)
+		}
+	}
+}
+
+// removeExpired deletes all expired entries; caller must hold the write lock
+func (ns *NonceStore) removeExpired(now time.Time) int {
+	removed := 0
+	for e := ns.order.Front(); e != nil; {
+		next := e.Next()
+		if item := e.Value.(*nonceItem); now.After(item.entry.ExpiresAt) {
+			ns.remove(item)
+			removed++
+		}
+		e = next
 	}
+	ns.expiredEvictions += uint64(removed)
+	return removed
 }
 
 // cleanupExpiredNonces removes expired nonces from the store periodically
@@ -79,12 +223,7 @@ func (ns *NonceStore) cleanupExpiredNonces() {
 		select {
 		case <-ns.cleanupTicker.C:
 			ns.mu.Lock()
-			now := time.Now()
-			for key, entry := range ns.nonces {
-				if now.After(entry.ExpiresAt) {
-					delete(ns.nonces, key)
-				}
-			}
+			ns.removeExpired(ns.clock.Now())
 			ns.mu.Unlock()
 		case <-ns.stopCleanup:
 			return
@@ -105,17 +244,23 @@ func (ns *NonceStore) GetStats() map[string]interface{} {
 
 	total := len(ns.nonces)
 	expired := 0
-	now := time.Now()
+	now := ns.clock.Now()
 
-	for _, entry := range ns.nonces {
-		if now.After(entry.ExpiresAt) {
+	for _, item := range ns.nonces {
+		if now.After(item.entry.ExpiresAt) {
 			expired++
 		}
 	}
 
 	return map[string]interface{}{
-		"total_nonces":   total,
-		"active_nonces":  total - expired,
-		"expired_nonces": expired,
+		"total_nonces":          total,
+		"active_nonces":         total - expired,
+		"expired_nonces":        expired,
+		"tracked_addresses":     len(ns.byAddress),
+		"max_entries":           ns.maxEntries,
+		"max_per_address":       ns.maxPerAddress,
+		"expired_evictions":     ns.expiredEvictions,
+		"address_cap_evictions": ns.addressCapEvictions,
+		"global_cap_evictions":  ns.globalCapEvictions,
 	}
 }
@@ -0,0 +1,183 @@
+package evm
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock is a settable clock
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestNonceStore(t *testing.T, maxEntries, maxPerAddress int) (*NonceStore, *testClock) {
+	t.Helper()
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	ns := NewNonceStoreWithClock(maxEntries, maxPerAddress, clock)
+	t.Cleanup(ns.Stop)
+	return ns, clock
+}
+
+func stat(ns *NonceStore, name string) uint64 {
+	switch v := ns.GetStats()[name].(type) {
+	case int:
+		return uint64(v)
+	case uint64:
+		return v
+	}
+	return 0
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
+
+func TestNonceStoreStaysBoundedUnderFlood(t *testing.T) {
+	if testing.Short() {
+		t.Skip("inserts millions of nonces")
+	}
+	const (
+		maxEntries = 100_000
+		flood      = 2_000_000
+		payers     = 5_000
+	)
+	ns, clock := newTestNonceStore(t, maxEntries, 1000)
+	validBefore := clock.Now().Add(time.Hour).Unix()
+
+	insert := func(from, to int) {
+		for i := from; i < to; i++ {
+			ns.MarkNonceUsed(fmt.Sprintf("0x%040x", i%payers), fmt.Sprintf("0x%064x", i), validBefore)
+		}
+	}
+	insert(0, maxEntries)
+	full := heapInUse()
+	insert(maxEntries, flood)
+	after := heapInUse()
+
+	if total := stat(ns, "total_nonces"); total != maxEntries {
+		t.Errorf("%d nonces tracked after a flood of %d, want the cap of %d", total, flood, maxEntries)
+	}
+	if addresses := stat(ns, "tracked_addresses"); addresses > payers {
+		t.Errorf("%d addresses tracked, want at most %d", addresses, payers)
+	}
+	if evicted := stat(ns, "global_cap_evictions"); evicted != flood-maxEntries {
+		t.Errorf("%d global cap evictions, want %d", evicted, flood-maxEntries)
+	}
+	// Allow for allocator slack; unbounded growth would be ~20x
+	if after > 2*full {
+		t.Errorf("heap grew from %d to %d bytes while the store stayed at its cap", full, after)
+	}
+
+	// The newest nonces survive, the oldest were evicted
+	if !ns.IsNonceUsed(fmt.Sprintf("0x%040x", (flood-1)%payers), fmt.Sprintf("0x%064x", flood-1)) {
+		t.Error("the newest nonce was evicted")
+	}
+	if ns.IsNonceUsed(fmt.Sprintf("0x%040x", 0), fmt.Sprintf("0x%064x", 0)) {
+		t.Error("the oldest nonce survived the flood")
+	}
+
+	// Lookups in the full store cost about what they cost in a small one
+	small, _ := newTestNonceStore(t, maxEntries, 1000)
+	for i := 0; i < 1000; i++ {
+		small.MarkNonceUsed(fmt.Sprintf("0x%040x", i%payers), fmt.Sprintf("0x%064x", i), validBefore)
+	}
+	lookup := func(store *NonceStore) time.Duration {
+		const lookups = 200_000
+		start := time.Now()
+		for i := 0; i < lookups; i++ {
+			store.IsNonceUsed("0x00000000000000000000000000000000000000aa", "0xmissing")
+		}
+		return time.Since(start) / lookups
+	}
+	lookup(small) // Warm up
+	if smallCost, fullCost := lookup(small), lookup(ns); fullCost > 20*smallCost+time.Microsecond {
+		t.Errorf("lookup takes %v in a full store, %v in a small one", fullCost, smallCost)
+	}
+}
+
+func TestNonceStorePerAddressCapSparesOtherPayers(t *testing.T) {
+	ns, clock := newTestNonceStore(t, 1000, 10)
+	validBefore := clock.Now().Add(time.Hour).Unix()
+
+	ns.MarkNonceUsed("0xvictim", "0x01", validBefore)
+	for i := 0; i < 500; i++ {
+		ns.MarkNonceUsed("0xspammer", fmt.Sprintf("0x%x", i), validBefore)
+	}
+
+	if !ns.IsNonceUsed("0xvictim", "0x01") {
+		t.Error("a spammy payer evicted another payer's nonce")
+	}
+	if total := stat(ns, "total_nonces"); total != 11 {
+		t.Errorf("%d nonces tracked, want the victim's one plus the spammer's cap of 10", total)
+	}
+	if evicted := stat(ns, "address_cap_evictions"); evicted != 490 {
+		t.Errorf("%d address cap evictions, want 490", evicted)
+	}
+	// The spammer keeps its newest nonces
+	if !ns.IsNonceUsed("0xspammer", fmt.Sprintf("0x%x", 499)) || ns.IsNonceUsed("0xspammer", "0x0") {
+		t.Error("the per-address cap did not evict the payer's oldest nonces")
+	}
+}
+
+func TestNonceStoreEvictsExpiredEntriesFirst(t *testing.T) {
+	ns, clock := newTestNonceStore(t, 10, 10)
+	soon := clock.Now().Add(time.Minute).Unix()
+	later := clock.Now().Add(time.Hour).Unix()
+
+	// Live nonces first, so oldest-first eviction would take them
+	for i := 0; i < 5; i++ {
+		ns.MarkNonceUsed("0xlive", fmt.Sprintf("0x%x", i), later)
+	}
+	for i := 0; i < 5; i++ {
+		ns.MarkNonceUsed("0xshort", fmt.Sprintf("0x%x", i), soon)
+	}
+	clock.Advance(time.Minute + nonceExpiryBuffer + time.Second)
+
+	for i := 0; i < 5; i++ {
+		ns.MarkNonceUsed("0xnew", fmt.Sprintf("0x%x", i), later)
+	}
+	for i := 0; i < 5; i++ {
+		if !ns.IsNonceUsed("0xlive", fmt.Sprintf("0x%x", i)) {
+			t.Errorf("unexpired nonce %d was evicted while expired ones remained", i)
+		}
+	}
+	if evicted := stat(ns, "global_cap_evictions"); evicted != 0 {
+		t.Errorf("%d live nonces evicted, want only expired ones", evicted)
+	}
+	if evicted := stat(ns, "expired_evictions"); evicted != 5 {
+		t.Errorf("%d expired evictions, want 5", evicted)
+	}
+}
+
+func TestNonceStoreExpiry(t *testing.T) {
+	ns, clock := newTestNonceStore(t, 10, 10)
+	ns.MarkNonceUsed("0xpayer", "0x01", clock.Now().Add(time.Minute).Unix())
+
+	clock.Advance(time.Minute + nonceExpiryBuffer - time.Second)
+	if !ns.IsNonceUsed("0xpayer", "0x01") {
+		t.Error("nonce forgotten within the skew buffer")
+	}
+	clock.Advance(2 * time.Second)
+	if ns.IsNonceUsed("0xpayer", "0x01") {
+		t.Error("nonce still reported used after expiry")
+	}
+}
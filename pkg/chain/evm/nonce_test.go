@@ -0,0 +1,65 @@
+package evm_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestVerifyCatchesReplayOfNonceVariants(t *testing.T) {
+	chain := newTestChain(t)
+	provider, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := settleRequest(t, chain)
+	verify := &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements}
+	auth := request.PaymentPayload.Payload.Authorization
+
+	// Recorded in another case and prefix, e.g. by reconciliation
+	provider.MarkNonceUsed(auth.From.Hex(), strings.ToUpper(auth.Nonce.String()), 1<<40)
+	resp, err := provider.Verify(context.Background(), verify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.IsValid || resp.ReasonCode != types.ReasonNonceReused {
+		t.Errorf("replayed nonce: valid %v, reason code %q; want %q", resp.IsValid, resp.ReasonCode, types.ReasonNonceReused)
+	}
+}
+
+func TestVerifyRejectsMalformedNonces(t *testing.T) {
+	chain := newTestChain(t)
+	provider, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := settleRequest(t, chain)
+	nonce := request.PaymentPayload.Payload.Authorization.Nonce
+
+	for name, malformed := range map[string]types.HexBytes{
+		"short": nonce[:31],
+		"long":  append(append(types.HexBytes{}, nonce...), 0x01),
+		"empty": {},
+	} {
+		payload := request.PaymentPayload
+		payload.Payload.Authorization.Nonce = malformed
+
+		resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: payload, PaymentRequirements: request.PaymentRequirements})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if resp.IsValid || resp.ReasonCode != types.ReasonDecodingError {
+			t.Errorf("%s nonce: valid %v, reason code %q; want %q", name, resp.IsValid, resp.ReasonCode, types.ReasonDecodingError)
+		}
+
+		settled, err := provider.Settle(context.Background(), &types.SettleRequest{PaymentPayload: payload, PaymentRequirements: request.PaymentRequirements})
+		if err != nil {
+			t.Fatalf("%s: settle: %v", name, err)
+		}
+		if settled.Success {
+			t.Errorf("%s nonce settled", name)
+		}
+	}
+}
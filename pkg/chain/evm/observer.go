@@ -0,0 +1,50 @@
+package evm
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// SettlementUpdate reports a settlement transaction reaching a journal status
+type SettlementUpdate struct {
+	JournalID string // Empty without a journal (see WithJournal)
+	Status    accounting.JournalStatus
+	Receipt   x402types.PaymentReceipt // The payment and the transaction carrying it
+	Reorg     *ReorgEvent              // Set when a reorg recheck made the update
+	Time      time.Time
+}
+
+// WithSettlementObserver calls observe every time a settlement transaction
+// changes status: on submission, when it confirms, reverts or its receipt
+// never arrives (unknown), and when a reorg recheck moves or drops it (see
+// WithReorgWatch). observe runs on the settlement path and must not block.
+func WithSettlementObserver(observe func(SettlementUpdate)) ProviderOption {
+	return func(o *providerOptions) {
+		o.onSettlement = observe
+	}
+}
+
+// observeSettlement reports a status change of the settlement of auth in
+// txHash to the settlement observer, if there is one
+func (p *Provider) observeSettlement(journalID string, auth *x402types.ExactEvmPayloadAuthorization, txHash common.Hash, status accounting.JournalStatus, reorg *ReorgEvent) {
+	if p.onSettlement == nil {
+		return
+	}
+	p.onSettlement(SettlementUpdate{
+		JournalID: journalID,
+		Status:    status,
+		Receipt: x402types.PaymentReceipt{
+			Network:         p.network,
+			Payer:           auth.From.Hex(),
+			PayTo:           auth.To.Hex(),
+			Amount:          auth.Value,
+			Nonce:           auth.Nonce.String(),
+			TransactionHash: txHash.Hex(),
+		},
+		Reorg: reorg,
+		Time:  p.clock.Now(),
+	})
+}
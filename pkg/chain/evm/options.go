@@ -0,0 +1,94 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Options is the core configuration of a Provider (see New)
+type Options struct {
+	RPCURL  string
+	ChainID *big.Int
+	Keys    []string // Hex private keys of the settlement signers (0x optional)
+
+	// RPC client used instead of dialing RPCURL, e.g. the client of a
+	// simulated backend (see internal/testchain)
+	Client Client
+
+	// Additional settlement signers, e.g. KMSSigner or RemoteSigner; they
+	// join the round-robin after Keys
+	Signers []Signer
+
+	// Blocks a settlement must be buried under before it is reported as
+	// successful (0 or 1: mined is enough)
+	Confirmations uint64
+
+	// Token contracts accepted for payment (empty uses DefaultAssetWhitelist)
+	AssetWhitelist []common.Address
+
+	// Replay protection backend (nil uses an in-memory NonceStore)
+	NonceBackend NonceBackend
+}
+
+// NonceBackend records used ERC-3009 nonces for replay protection
+// NonceStore is the in-memory implementation
+type NonceBackend interface {
+	IsNonceUsed(fromAddress, nonce string) bool
+	MarkNonceUsed(fromAddress, nonce string, validBefore int64)
+}
+
+// DefaultAssetWhitelist lists the USDC contracts accepted when none are configured
+var DefaultAssetWhitelist = []common.Address{
+	common.HexToAddress("0x833589fcd6edb6e08f4c7c32d4f71b54bda02913"), // USDC on Base mainnet
+	// Add more mainnet USDC addresses here as needed:
+	// common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"), // USDC on Ethereum mainnet
+	// common.HexToAddress("0x3c499c542cef5e3811e1192ce70d8cc03d5c3359"), // USDC on Polygon mainnet
+	// common.HexToAddress("0xb97ef9ef8734c71904d8002f8b6bc66dd9c48a6e"), // USDC on Avalanche mainnet
+}
+
+// confirmationPollInterval is how often the chain head is checked while
+// waiting for confirmations
+const confirmationPollInterval = time.Second
+
+// NewProvider creates a new EVM provider
+//
+// Deprecated: use New, which takes an Options struct
+func NewProvider(rpcURL string, chainID *big.Int, network x402types.Network, privateKeys []string, opts ...ProviderOption) (*Provider, error) {
+	return New(network, Options{
+		RPCURL:  rpcURL,
+		ChainID: chainID,
+		Keys:    privateKeys,
+	}, opts...)
+}
+
+// waitConfirmations blocks until the block holding receipt has the
+// configured number of confirmations, or ctx ends
+func (p *Provider) waitConfirmations(ctx context.Context, receipt *types.Receipt) error {
+	if p.confirmations <= 1 {
+		return nil
+	}
+	target := new(big.Int).Add(receipt.BlockNumber, new(big.Int).SetUint64(p.confirmations-1)).Uint64()
+
+	ticker := time.NewTicker(confirmationPollInterval)
+	defer ticker.Stop()
+	for {
+		head, err := p.client.BlockNumber(ctx)
+		if err == nil && head >= target {
+			return nil
+		}
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// WithPermit2 accepts payments in the permit2 scheme: any ERC-20 the payer
+// approved to the canonical Permit2 contract, transferred with a signed
+// PermitWitnessTransferFrom. The token whitelist does not apply to them.
+// The permit names one of the provider's signers as spender, which submits
+// the transfer; such settlements are not watched for reorgs.
+func WithPermit2() ProviderOption {
+	return func(o *providerOptions) {
+		o.permit2 = true
+	}
+}
+
+// Permit2 reports whether the provider accepts permit2 payments
+func (p *Provider) Permit2() bool {
+	return p.permit2
+}
+
+// Permit2Spender returns the spender permit2 payments should be signed for
+// (the first signer), or the zero address on a verify-only provider
+func (p *Provider) Permit2Spender() common.Address {
+	if len(p.signerAddresses) == 0 {
+		return common.Address{}
+	}
+	return p.signerAddresses[0]
+}
+
+// checkPermit2 runs the structural checks of a permit2 payload, returning
+// the reason and code it is refused with ("" if it passes)
+func (p *Provider) checkPermit2(payload *x402types.ExactEvmPayload) (string, x402types.ReasonCode) {
+	if !p.permit2 {
+		return "permit2 payments are not accepted by this facilitator", x402types.ReasonUnsupportedScheme
+	}
+	if payload.Permit2 == nil {
+		return "permit2 payload carries no spender", x402types.ReasonDecodingError
+	}
+	// Verify-only providers check permits settled by someone else
+	if !p.verifyOnly && p.signerFor(payload.Permit2.Spender) == nil {
+		return fmt.Sprintf("permit2 spender %s is not a signer of this facilitator", payload.Permit2.Spender.Hex()), x402types.ReasonInvalidSpender
+	}
+	return "", ""
+}
+
+// signerFor returns the signer with address addr, nil if there is none
+func (p *Provider) signerFor(addr common.Address) *signerSlot {
+	for i, signer := range p.signerAddresses {
+		if signer == addr {
+			return p.signers[i]
+		}
+	}
+	return nil
+}
+
+// verifyPermit2Signature validates the EIP-712 signature of a permit2 payload
+func (p *Provider) verifyPermit2Signature(payload *x402types.ExactEvmPayload, token common.Address) (bool, error) {
+	auth := &payload.Authorization
+	typedData := eip712.TypedDataForPermit2(auth, token, payload.Permit2.Spender, eip712.Permit2Domain(p.chainID))
+	signer, err := eip712.RecoverTypedDataSigner(typedData, payload.Signature)
+	if err != nil {
+		return false, err
+	}
+	return signer == auth.From, nil
+}
+
+// permit2NonceUsed asks Permit2 whether owner's unordered nonce is spent
+// (bit nonce&0xff of word nonce>>8 in its nonce bitmap)
+func (p *Provider) permit2NonceUsed(ctx context.Context, owner common.Address, nonce [32]byte) (bool, error) {
+	n := new(big.Int).SetBytes(nonce[:])
+	word := new(big.Int).Rsh(n, 8)
+	bit := uint(n.Uint64() & 0xff)
+
+	data, err := p.permit2ABI.Pack("nonceBitmap", owner, word)
+	if err != nil {
+		return false, fmt.Errorf("failed to pack nonceBitmap: %w", err)
+	}
+	callCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	permit2 := x402types.Permit2Address
+	result, err := p.client.CallContract(callCtx, ethereum.CallMsg{To: &permit2, Data: data}, nil)
+	if err != nil {
+		return false, fmt.Errorf("nonceBitmap call failed: %w", err)
+	}
+	var bitmap *big.Int
+	if err := p.permit2ABI.UnpackIntoInterface(&bitmap, "nonceBitmap", result); err != nil {
+		return false, fmt.Errorf("failed to unpack nonceBitmap result: %w", err)
+	}
+	return bitmap.Bit(int(bit)) == 1, nil
+}
+
+// permit2Allowance returns how much of token owner approved to Permit2
+func (p *Provider) permit2Allowance(ctx context.Context, token, owner common.Address) (*big.Int, error) {
+	data, err := p.erc20ABI.Pack("allowance", owner, x402types.Permit2Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack allowance: %w", err)
+	}
+	block, err := p.balanceBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	callCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	result, err := p.client.CallContract(callCtx, ethereum.CallMsg{To: &token, Data: data}, block)
+	if err != nil {
+		return nil, fmt.Errorf("allowance call failed: %w", err)
+	}
+	var allowance *big.Int
+	if err := p.erc20ABI.UnpackIntoInterface(&allowance, "allowance", result); err != nil {
+		return nil, fmt.Errorf("failed to unpack allowance result: %w", err)
+	}
+	return allowance, nil
+}
+
+// permit2TransferFrom is the PermitTransferFrom argument of permitWitnessTransferFrom
+type permit2TransferFrom struct {
+	Permitted struct {
+		Token  common.Address
+		Amount *big.Int
+	}
+	Nonce    *big.Int
+	Deadline *big.Int
+}
+
+// permit2TransferDetails is the SignatureTransferDetails argument
+type permit2TransferDetails struct {
+	To              common.Address
+	RequestedAmount *big.Int
+}
+
+// packPermit2Call builds the permitWitnessTransferFrom calldata moving the
+// permitted amount of token to the payee
+func (p *Provider) packPermit2Call(token common.Address, payload *x402types.ExactEvmPayload) ([]byte, error) {
+	auth := &payload.Authorization
+
+	nonce32, err := auth.Nonce.Nonce()
+	if err != nil {
+		return nil, err
+	}
+	value, ok := new(big.Int).SetString(auth.Value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid value")
+	}
+	deadline, ok := new(big.Int).SetString(auth.ValidBefore, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid validBefore")
+	}
+	sigBytes := []byte(payload.Signature)
+	witness, err := eip712.Permit2WitnessHash(auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash witness: %w", err)
+	}
+
+	var permit permit2TransferFrom
+	permit.Permitted.Token = token
+	permit.Permitted.Amount = value
+	permit.Nonce = new(big.Int).SetBytes(nonce32[:])
+	permit.Deadline = deadline
+	details := permit2TransferDetails{To: auth.To, RequestedAmount: value}
+
+	data, err := p.permit2ABI.Pack("permitWitnessTransferFrom", permit, details, auth.From, witness, eip712.Permit2WitnessTypeString, sigBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack permitWitnessTransferFrom: %w", err)
+	}
+	return data, nil
+}
+
+// permitWitnessTransferFrom submits a permit2 transfer from signer, which
+// must be the permit's spender
+func (p *Provider) permitWitnessTransferFrom(ctx context.Context, signer *signerSlot, token common.Address, payload *x402types.ExactEvmPayload) (*types.Transaction, error) {
+	data, err := p.packPermit2Call(token, payload)
+	if err != nil {
+		return nil, err
+	}
+	return p.sendContractTx(ctx, signer, x402types.Permit2Address, data, permit2GasLimit)
+}
+
+// permit2GasLimit is the gas limit of permitWitnessTransferFrom
+// Permit2 transfers use ~80-100k gas, depending on the token
+const permit2GasLimit = 150000
+
+// loadPermit2ABI loads the Permit2 SignatureTransfer functions used here
+func loadPermit2ABI() (abi.ABI, error) {
+	const permit2ABIJSON = `[{"inputs":[{"components":[{"components":[{"internalType":"address","name":"token","type":"address"},{"internalType":"uint256","name":"amount","type":"uint256"}],"internalType":"struct ISignatureTransfer.TokenPermissions","name":"permitted","type":"tuple"},{"internalType":"uint256","name":"nonce","type":"uint256"},{"internalType":"uint256","name":"deadline","type":"uint256"}],"internalType":"struct ISignatureTransfer.PermitTransferFrom","name":"permit","type":"tuple"},{"components":[{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"requestedAmount","type":"uint256"}],"internalType":"struct ISignatureTransfer.SignatureTransferDetails","name":"transferDetails","type":"tuple"},{"internalType":"address","name":"owner","type":"address"},{"internalType":"bytes32","name":"witness","type":"bytes32"},{"internalType":"string","name":"witnessTypeString","type":"string"},{"internalType":"bytes","name":"signature","type":"bytes"}],"name":"permitWitnessTransferFrom","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"address","name":"","type":"address"},{"internalType":"uint256","name":"","type":"uint256"}],"name":"nonceBitmap","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+	return abi.JSON(strings.NewReader(permit2ABIJSON))
+}
+
+// loadERC20ABI loads the ERC-20 allowance function and the decimals and
+// symbol metadata
+func loadERC20ABI() (abi.ABI, error) {
+	const erc20ABIJSON = `[{"inputs":[{"internalType":"address","name":"owner","type":"address"},{"internalType":"address","name":"spender","type":"address"}],"name":"allowance","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"symbol","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"}]`
+	return abi.JSON(strings.NewReader(erc20ABIJSON))
+}
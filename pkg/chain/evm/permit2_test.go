@@ -0,0 +1,90 @@
+package evm_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// permit2Request re-signs an exact payment of chain's token as a permit2
+// payment for spender
+func permit2Request(t *testing.T, chain *testchain.Chain, spender common.Address) *types.VerifyRequest {
+	t.Helper()
+	request := settleRequest(t, chain)
+	request.PaymentRequirements.Scheme = types.SchemePermit2
+	payload := &request.PaymentPayload
+	payload.Scheme = types.SchemePermit2
+	auth := &payload.Payload.Authorization
+	typedData := eip712.TypedDataForPermit2(auth, testchain.TokenAddress, spender, eip712.Permit2Domain(big.NewInt(testchain.ChainID)))
+	signature, err := eip712.SignTypedData(typedData, chain.Accounts[0].Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload.Payload.Signature = signature
+	payload.Payload.Permit2 = &types.Permit2Payload{Spender: spender}
+	return &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements}
+}
+
+func TestVerifyPermit2(t *testing.T) {
+	chain := newTestChain(t)
+	provider, err := chain.Provider(evm.WithPermit2())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !provider.Permit2() || provider.Permit2Spender() != chain.Signer.Address {
+		t.Fatalf("permit2 %v with spender %s, want it on for %s", provider.Permit2(), provider.Permit2Spender().Hex(), chain.Signer.Address.Hex())
+	}
+	disabled, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stranger := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+
+	noSpender := permit2Request(t, chain, chain.Signer.Address)
+	noSpender.PaymentPayload.Payload.Permit2 = nil
+	otherSpender := permit2Request(t, chain, chain.Signer.Address)
+	otherSpender.PaymentPayload.Payload.Permit2.Spender = stranger
+	swapped := permit2Request(t, chain, stranger)
+	swapped.PaymentPayload.Payload.Permit2.Spender = chain.Signer.Address
+	unlisted := permit2Request(t, chain, chain.Signer.Address)
+	unlisted.PaymentRequirements.Asset = stranger
+
+	for _, tc := range []struct {
+		name     string
+		provider *evm.Provider
+		request  *types.VerifyRequest
+		code     types.ReasonCode
+	}{
+		{"permit2 not accepted", disabled, permit2Request(t, chain, chain.Signer.Address), types.ReasonUnsupportedScheme},
+		{"no spender", provider, noSpender, types.ReasonDecodingError},
+		{"spender not a signer", provider, otherSpender, types.ReasonInvalidSpender},
+		{"signed for another spender", provider, swapped, types.ReasonInvalidSignature},
+		// The token whitelist does not apply: an unlisted asset gets as far as the signature
+		{"signed for another token", provider, unlisted, types.ReasonInvalidSignature},
+	} {
+		resp, err := tc.provider.Verify(context.Background(), tc.request)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if resp.IsValid || resp.ReasonCode != tc.code {
+			t.Errorf("%s: valid %v, reason code %q (%s); want %q", tc.name, resp.IsValid, resp.ReasonCode, resp.Reason, tc.code)
+		}
+	}
+
+	// A well-signed permit is checked against Permit2's nonce bitmap, which
+	// the simulated chain (with no Permit2 deployed) cannot answer
+	resp, err := provider.Verify(context.Background(), permit2Request(t, chain, chain.Signer.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.IsValid || resp.ReasonCode != types.ReasonRPCError || !strings.Contains(resp.Reason, "nonceBitmap") {
+		t.Errorf("valid permit: valid %v, reason code %q (%s); want the nonce bitmap lookup to fail", resp.IsValid, resp.ReasonCode, resp.Reason)
+	}
+}
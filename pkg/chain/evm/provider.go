@@ -2,73 +2,257 @@ package evm
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"encoding/hex"
 	"fmt"
 	"log"
 	"math/big"
 	"net/http"
-	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
-	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	"github.com/x402-rs/x402-go/pkg/transport"
 	x402types "github.com/x402-rs/x402-go/pkg/types"
 )
 
+// errSettlementDisabled is the error of Settle on a verify-only provider
+const errSettlementDisabled = "settlement disabled on this facilitator"
+
 // Provider handles EVM-based payment verification and settlement
 type Provider struct {
-	client          *ethclient.Client
+	client          Client
 	chainID         *big.Int
-	signers         []*ecdsa.PrivateKey
+	signers         []*signerSlot
 	signerAddresses []common.Address
 	signerIndex     atomic.Uint64
 	usdcABI         abi.ABI
+	usdcVRSABI      abi.ABI // ERC-3009 functions of tokens taking (v, r, s) signatures
 	validatorABI    abi.ABI
+	splitterABI     abi.ABI
+	permit2ABI      abi.ABI        // Permit2 SignatureTransfer functions
+	erc20ABI        abi.ABI        // ERC-20 allowance (for Permit2 approvals) and metadata
+	splitter        common.Address // Payment splitter contract (zero if unsupported)
 	network         x402types.Network
-	nonceStore      *NonceStore // Tracks used ERC-3009 nonces to prevent replay
+	nonceStore      NonceBackend // Tracks used ERC-3009 nonces to prevent replay
+	assetWhitelist  map[common.Address]bool
+	customAssets    bool // assetWhitelist came from Options.AssetWhitelist
+	confirmations   uint64
+	rpcTimeout      time.Duration
+	confirmTimeout  time.Duration
+	clock           x402types.Clock
+	clockSkew       time.Duration // Tolerance applied to validAfter
+	strictResource  bool          // Require payments bound to requirements.Resource
+	allowSelfPayTo  bool          // Accept payTo set to a signer address (see WithAllowSelfPayTo)
+	journal         *accounting.SettlementJournal
+	verifyOnly      bool // No signers; Settle is refused (see WithVerifyOnly)
+	amountPolicy    AmountPolicy
+
+	// Block payer balances are checked at (see WithBalanceBlockTag)
+	balanceTag        BalanceBlockTag
+	balanceTagWarning sync.Once // Logs the first fallback to latest
+
+	// Recent verifications Settle may rely on (see WithVerificationTTL)
+	verificationTTL time.Duration
+	verifications   *verificationCache
+
+	// Settlements rechecked for reorgs after confirming (see WithReorgWatch)
+	reorgWindow time.Duration
+	onReorg     func(ReorgEvent)
+	reorgs      reorgWatch
+
+	// Called on every settlement status change (see WithSettlementObserver)
+	onSettlement func(SettlementUpdate)
+
+	// Accept payments in the permit2 scheme (see WithPermit2)
+	permit2 bool
+
+	// Metadata of tokens outside the registry (see TokenInfo)
+	tokenInfoTTL time.Duration
+	tokenInfo    tokenInfoCache
+
+	// Health score of RPC-backed checks (see WithRPCCircuitBreaker)
+	rpcBreaker *rpcBreaker
+
+	// Time left before validBefore below which Settle refuses to submit
+	// (see WithInclusionMargin)
+	inclusionMargin time.Duration
+
+	// Shortest validity window Verify accepts from requirements without
+	// MinTimeoutSeconds (see WithMinTimeout)
+	minTimeout time.Duration
+
+	// Signer gas balances (see WithMinSignerBalance)
+	minSignerBalance *big.Int
+	skipLowSigners   bool
+	balanceMu        sync.Mutex
+	balances         []SignerBalance // Indexed like signers
+}
+
+// ProviderOption configures optional Provider behaviour
+type ProviderOption func(*providerOptions)
+
+// providerOptions collects settings applied by ProviderOption
+type providerOptions struct {
+	nonceMaxEntries         int
+	nonceMaxPerAddress      int
+	rpcTimeout              time.Duration
+	confirmTimeout          time.Duration
+	splitter                common.Address
+	clock                   x402types.Clock
+	clockSkew               time.Duration
+	transport               transport.Config
+	strictResource          bool
+	allowSelfPayTo          bool
+	journal                 *accounting.SettlementJournal
+	minSignerBalance        *big.Int
+	skipLowSigners          bool
+	reorgWindow             time.Duration
+	onReorg                 func(ReorgEvent)
+	onSettlement            func(SettlementUpdate)
+	verifyOnly              bool
+	verificationTTL         time.Duration
+	amountPolicy            AmountPolicy
+	balanceTag              BalanceBlockTag
+	permit2                 bool
+	tokenInfoTTL            time.Duration
+	rpcBreaker              bool
+	rpcBreakerErrorRate     float64
+	rpcBreakerProbeInterval time.Duration
+	inclusionMargin         time.Duration
+	minTimeout              time.Duration
 }
 
-// NewProvider creates a new EVM provider
-func NewProvider(rpcURL string, chainID *big.Int, network x402types.Network, privateKeys []string) (*Provider, error) {
-	// Create RPC client with timeout
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second, // Prevent indefinite hangs on RPC calls
+// WithNonceStoreLimits caps the replay-protection nonce store
+// maxEntries bounds the total tracked nonces, maxPerAddress the nonces per payer
+func WithNonceStoreLimits(maxEntries, maxPerAddress int) ProviderOption {
+	return func(o *providerOptions) {
+		o.nonceMaxEntries = maxEntries
+		o.nonceMaxPerAddress = maxPerAddress
 	}
-	rpcClient, err := rpc.DialHTTPWithClient(rpcURL, httpClient)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RPC: %w", err)
+}
+
+// WithClock sets the time source for timing validation and nonce expiry
+func WithClock(clock x402types.Clock) ProviderOption {
+	return func(o *providerOptions) {
+		if clock != nil {
+			o.clock = clock
+		}
 	}
-	client := ethclient.NewClient(rpcClient)
+}
 
-	// Parse private keys
-	var signers []*ecdsa.PrivateKey
-	var addresses []common.Address
-	for _, keyHex := range privateKeys {
-		keyHex = strings.TrimPrefix(keyHex, "0x")
-		privateKey, err := crypto.HexToECDSA(keyHex)
+// WithClockSkewTolerance accepts authorizations whose validAfter is up to
+// skew in the future, for payers whose clocks run slightly ahead
+func WithClockSkewTolerance(skew time.Duration) ProviderOption {
+	return func(o *providerOptions) {
+		if skew > 0 {
+			o.clockSkew = skew
+		}
+	}
+}
+
+// WithStrictResourceBinding rejects payments whose authorization nonce is not
+// bound to requirements.Resource (see types.CheckResourceBinding), so a
+// payment for one route cannot be replayed against another
+func WithStrictResourceBinding() ProviderOption {
+	return func(o *providerOptions) {
+		o.strictResource = true
+	}
+}
+
+// WithAllowSelfPayTo accepts requirements paying one of the provider's own
+// signer addresses, which are refused by default: a resource server
+// pointed at the facilitator's hot wallet mixes its revenue with gas funds
+func WithAllowSelfPayTo() ProviderOption {
+	return func(o *providerOptions) {
+		o.allowSelfPayTo = true
+	}
+}
+
+// WithJournal records each settlement transaction in journal as it is
+// submitted and resolved, so in-flight settlements can be reconciled after
+// a restart (see facilitator.Reconciler)
+func WithJournal(journal *accounting.SettlementJournal) ProviderOption {
+	return func(o *providerOptions) {
+		o.journal = journal
+	}
+}
+
+// WithVerifyOnly creates a provider that only verifies payments, for
+// resource servers that leave settlement to another facilitator; it needs
+// no signer keys, and Settle and Simulate are refused
+func WithVerifyOnly() ProviderOption {
+	return func(o *providerOptions) {
+		o.verifyOnly = true
+	}
+}
+
+// WithTransport tunes connection pooling for the RPC client
+func WithTransport(cfg transport.Config) ProviderOption {
+	return func(o *providerOptions) {
+		o.transport = cfg
+	}
+}
+
+// New creates an EVM provider for network from explicit options, without
+// reading any environment; opts tune timeouts, nonce limits and the like
+func New(network x402types.Network, config Options, opts ...ProviderOption) (*Provider, error) {
+	if config.ChainID == nil {
+		return nil, fmt.Errorf("chain ID is required")
+	}
+	options := providerOptions{
+		rpcTimeout:     DefaultVerifyRPCTimeout,
+		confirmTimeout: DefaultSettleConfirmTimeout,
+		clock:          x402types.SystemClock{},
+
+		verificationTTL: DefaultVerificationTTL,
+		tokenInfoTTL:    DefaultTokenInfoTTL,
+		minTimeout:      DefaultMinTimeout,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := config.Client
+	if client == nil {
+		// Create RPC client with timeout
+		httpClient := &http.Client{
+			Timeout:   30 * time.Second, // Prevent indefinite hangs on RPC calls
+			Transport: options.transport.RoundTripper(),
+		}
+		rpcClient, err := rpc.DialHTTPWithClient(config.RPCURL, httpClient)
 		if err != nil {
-			return nil, fmt.Errorf("invalid private key: %w", err)
+			return nil, fmt.Errorf("failed to connect to RPC: %w", err)
 		}
-		signers = append(signers, privateKey)
+		client = ethclient.NewClient(rpcClient)
+	}
 
-		publicKey := privateKey.Public()
-		publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-		if !ok {
-			return nil, fmt.Errorf("error casting public key to ECDSA")
+	// Parse private keys, then append any external signers
+	var signers []*signerSlot
+	var addresses []common.Address
+	for _, keyHex := range config.Keys {
+		signer, err := ParseLocalSigner(keyHex)
+		if err != nil {
+			return nil, err
 		}
-		address := crypto.PubkeyToAddress(*publicKeyECDSA)
-		addresses = append(addresses, address)
+		signers = append(signers, &signerSlot{Signer: signer})
+		addresses = append(addresses, signer.Address())
+	}
+	for _, signer := range config.Signers {
+		signers = append(signers, &signerSlot{Signer: signer})
+		addresses = append(addresses, signer.Address())
+	}
+
+	if options.verifyOnly {
+		signers, addresses = nil, nil
+	} else if len(signers) == 0 {
+		return nil, fmt.Errorf("no settlement signers configured (use WithVerifyOnly for a verify-only provider)")
 	}
 
 	// Load ABIs (embedded as strings for simplicity, or load from file)
@@ -77,31 +261,167 @@ func NewProvider(rpcURL string, chainID *big.Int, network x402types.Network, pri
 		return nil, fmt.Errorf("failed to load USDC ABI: %w", err)
 	}
 
+	usdcVRSABI, err := loadUSDCVRSABI()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load USDC (v, r, s) ABI: %w", err)
+	}
+
 	validatorABI, err := loadValidatorABI()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load Validator ABI: %w", err)
 	}
 
+	splitterABI, err := loadSplitterABI()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load splitter ABI: %w", err)
+	}
+
+	permit2ABI, err := loadPermit2ABI()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Permit2 ABI: %w", err)
+	}
+
+	erc20ABI, err := loadERC20ABI()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ERC-20 ABI: %w", err)
+	}
+
+	nonceStore := config.NonceBackend
+	if nonceStore == nil {
+		nonceStore = NewNonceStoreWithClock(options.nonceMaxEntries, options.nonceMaxPerAddress, options.clock)
+	}
+
+	assets := config.AssetWhitelist
+	if len(assets) == 0 {
+		assets = DefaultAssetWhitelist
+	}
+	assetWhitelist := make(map[common.Address]bool, len(assets))
+	for _, asset := range assets {
+		assetWhitelist[asset] = true
+	}
+
+	inclusionMargin := options.inclusionMargin
+	if inclusionMargin == 0 {
+		inclusionMargin = DefaultInclusionMargin(network)
+	}
+
+	var breaker *rpcBreaker
+	if options.rpcBreaker {
+		breaker = newRPCBreaker(options.rpcBreakerErrorRate, options.rpcBreakerProbeInterval, options.rpcTimeout)
+	}
+
 	return &Provider{
 		client:          client,
-		chainID:         chainID,
+		chainID:         config.ChainID,
 		signers:         signers,
 		signerAddresses: addresses,
 		usdcABI:         usdcABI,
+		usdcVRSABI:      usdcVRSABI,
 		validatorABI:    validatorABI,
+		splitterABI:     splitterABI,
+		permit2ABI:      permit2ABI,
+		erc20ABI:        erc20ABI,
+		splitter:        options.splitter,
 		network:         network,
-		nonceStore:      NewNonceStore(),
+		nonceStore:      nonceStore,
+		assetWhitelist:  assetWhitelist,
+		customAssets:    len(config.AssetWhitelist) > 0,
+		confirmations:   config.Confirmations,
+		rpcTimeout:      options.rpcTimeout,
+		confirmTimeout:  options.confirmTimeout,
+		clock:           options.clock,
+		clockSkew:       options.clockSkew,
+		strictResource:  options.strictResource,
+		allowSelfPayTo:  options.allowSelfPayTo,
+		journal:         options.journal,
+		verifyOnly:      options.verifyOnly,
+		amountPolicy:    options.amountPolicy,
+		balanceTag:      options.balanceTag,
+
+		verificationTTL: options.verificationTTL,
+		verifications:   newVerificationCache(),
+
+		reorgWindow: options.reorgWindow,
+		onReorg:     options.onReorg,
+		reorgs:      reorgWatch{watched: make(map[common.Hash]*watchedSettlement)},
+
+		onSettlement: options.onSettlement,
+
+		permit2: options.permit2,
+
+		tokenInfoTTL: options.tokenInfoTTL,
+		tokenInfo:    tokenInfoCache{entries: make(map[common.Address]tokenInfoEntry)},
+
+		rpcBreaker:      breaker,
+		inclusionMargin: inclusionMargin,
+		minTimeout:      options.minTimeout,
+
+		minSignerBalance: options.minSignerBalance,
+		skipLowSigners:   options.skipLowSigners,
+		balances:         make([]SignerBalance, len(signers)),
 	}, nil
 }
 
+// VerifyOnly reports whether the provider was created without signers and
+// refuses to settle
+func (p *Provider) VerifyOnly() bool {
+	return p.verifyOnly
+}
+
+// SignerAddresses returns the addresses of the keys settling on this network
+func (p *Provider) SignerAddresses() []common.Address {
+	return append([]common.Address(nil), p.signerAddresses...)
+}
+
+// isSigner reports whether addr is one of the provider's signer addresses
+func (p *Provider) isSigner(addr string) bool {
+	normalized := x402types.NormalizeEVMAddress(addr)
+	if !common.IsHexAddress(normalized) {
+		return false
+	}
+	target := common.HexToAddress(normalized)
+	for _, signer := range p.signerAddresses {
+		if signer == target {
+			return true
+		}
+	}
+	return false
+}
+
 // Verify validates an EVM payment without submitting a transaction
+// A valid response carries a VerificationID that lets a prompt Settle of the
+// same payload skip the signature and balance checks
 func (p *Provider) Verify(ctx context.Context, request *x402types.VerifyRequest) (*x402types.VerifyResponse, error) {
+	resp, err := p.verify(ctx, request, true)
+	if err == nil && resp.IsValid {
+		resp.VerificationID = p.issueVerification(request)
+	}
+	return resp, err
+}
+
+// verify runs the payment checks; full adds the expensive ones (signature
+// recovery and the balance RPC) to the structural, timing and nonce checks
+func (p *Provider) verify(ctx context.Context, request *x402types.VerifyRequest, full bool) (*x402types.VerifyResponse, error) {
 	payload := request.PaymentPayload.Payload
 	requirements := &request.PaymentRequirements
 
 	// Parse authorization
 	auth := &payload.Authorization
 
+	// Permit2 payments move any approved token through the Permit2 contract
+	permit2 := request.PaymentPayload.Scheme == x402types.SchemePermit2
+	if permit2 {
+		if reason, code := p.checkPermit2(&payload); reason != "" {
+			payer := x402types.NewEvmAddress(auth.From)
+			return &x402types.VerifyResponse{
+				IsValid:    false,
+				Reason:     reason,
+				ReasonCode: code,
+				Payer:      &payer,
+			}, nil
+		}
+	}
+
 	// Validate receiver address
 	expectedReceiver := requirements.PayTo
 	actualReceiver := auth.To.Hex()
@@ -109,169 +429,172 @@ func (p *Provider) Verify(ctx context.Context, request *x402types.VerifyRequest)
 		payer := x402types.NewEvmAddress(auth.From)
 		err := x402types.NewReceiverMismatchError(expectedReceiver, actualReceiver, payer)
 		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  err.Message,
-			Payer:   &payer,
+			IsValid:    false,
+			Reason:     err.Message,
+			ReasonCode: err.Code,
+			Payer:      &payer,
 		}, nil
 	}
 
-	// Validate asset is whitelisted USDC (mainnet only)
-	// Whitelist of accepted USDC mainnet addresses (case-insensitive)
-	whitelistedAssets := map[string]bool{
-		"0x833589fcd6edb6e08f4c7c32d4f71b54bda02913": true, // USDC on Base mainnet
-		// Add more mainnet USDC addresses here as needed (use lowercase):
-		// "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48": true, // USDC on Ethereum mainnet
-		// "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359": true, // USDC on Polygon mainnet
-		// "0xb97ef9ef8734c71904d8002f8b6bc66dd9c48a6e": true, // USDC on Avalanche mainnet
-	}
-
-	assetAddr := strings.ToLower(requirements.Asset.Hex())
-	if !whitelistedAssets[assetAddr] {
+	// Refuse to move payments into our own signer wallets (Settle verifies
+	// first, so this guards settlement too)
+	if !p.allowSelfPayTo && p.isSigner(requirements.PayTo) {
 		payer := x402types.NewEvmAddress(auth.From)
 		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  fmt.Sprintf("unsupported asset: %s (only whitelisted USDC contracts are accepted)", requirements.Asset.Hex()),
-			Payer:   &payer,
+			IsValid:    false,
+			Reason:     fmt.Sprintf("payTo %s is a signer address of this facilitator", requirements.PayTo),
+			ReasonCode: x402types.ReasonSelfPayTo,
+			Payer:      &payer,
 		}, nil
 	}
 
-	// Validate timing
-	now := x402types.UnixTimestamp()
-	validAfter, err := strconv.ParseUint(auth.ValidAfter, 10, 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid validAfter: %w", err)
-	}
-	validBefore, err := strconv.ParseUint(auth.ValidBefore, 10, 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid validBefore: %w", err)
-	}
-
-	// Validate validBefore > validAfter (prevents integer underflow)
-	if validBefore <= validAfter {
-		payer := x402types.NewEvmAddress(auth.From)
-		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  fmt.Sprintf("invalid validity window: validBefore (%d) must be greater than validAfter (%d)", validBefore, validAfter),
-			Payer:   &payer,
-		}, nil
-	}
-
-	if now < validAfter {
+	// Validate payout splits route through the splitter contract
+	if reason := p.verifySplits(requirements); reason != "" {
 		payer := x402types.NewEvmAddress(auth.From)
-		err := x402types.NewInvalidTimingError(payer, fmt.Sprintf("payment not yet valid (validAfter: %s, now: %d)", auth.ValidAfter, now))
 		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  err.Message,
-			Payer:   &payer,
-		}, nil
-	}
-	if now >= validBefore {
-		payer := x402types.NewEvmAddress(auth.From)
-		err := x402types.NewInvalidTimingError(payer, fmt.Sprintf("payment expired (validBefore: %s, now: %d)", auth.ValidBefore, now))
-		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  err.Message,
-			Payer:   &payer,
+			IsValid:    false,
+			Reason:     reason,
+			ReasonCode: x402types.ReasonInvalidSplits,
+			Payer:      &payer,
 		}, nil
 	}
 
-	// Validate timeout window doesn't exceed MaxTimeoutSeconds
-	if requirements.MaxTimeoutSeconds > 0 {
-		timeoutWindow := validBefore - validAfter
-		maxTimeout := uint64(requirements.MaxTimeoutSeconds)
-		if timeoutWindow > maxTimeout {
+	// In strict mode the signature must commit to the requested resource
+	if p.strictResource {
+		if err := x402types.CheckResourceBinding(&request.PaymentPayload, requirements); err != nil {
 			payer := x402types.NewEvmAddress(auth.From)
 			return &x402types.VerifyResponse{
-				IsValid: false,
-				Reason:  fmt.Sprintf("payment validity window too long: %d seconds (max allowed: %d seconds)", timeoutWindow, maxTimeout),
-				Payer:   &payer,
+				IsValid:    false,
+				Reason:     err.Error(),
+				ReasonCode: x402types.ReasonResourceMismatch,
+				Payer:      &payer,
 			}, nil
 		}
 	}
 
-	// Check for nonce replay
-	fromAddress := auth.From.Hex()
-	if p.nonceStore.IsNonceUsed(fromAddress, auth.Nonce) {
+	// Validate asset is a whitelisted token contract (permit2 takes any ERC-20)
+	if !permit2 && !p.assetWhitelist[requirements.Asset] {
 		payer := x402types.NewEvmAddress(auth.From)
 		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  "nonce already used (replay attack detected)",
-			Payer:   &payer,
+			IsValid:    false,
+			Reason:     fmt.Sprintf("unsupported asset: %s (only whitelisted USDC contracts are accepted)", requirements.Asset.Hex()),
+			ReasonCode: x402types.ReasonUnsupportedAsset,
+			Payer:      &payer,
 		}, nil
 	}
 
-	// Parse amount
-	value := new(big.Int)
-	value, ok := value.SetString(auth.Value, 10)
-	if !ok {
-		payer := x402types.NewEvmAddress(auth.From)
-		err := x402types.NewDecodingError("invalid value format")
-		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  err.Message,
-			Payer:   &payer,
-		}, nil
+	// Timing, amount, signature and on-chain checks are shared with
+	// VerifyAuthorization; the first failure refuses the payment
+	v := p.authorizationVerifier()
+	if check := v.timing(auth, requirements); !check.Passed {
+		return refusal(auth, check), nil
 	}
 
-	// Parse required amount
-	requiredAmount := new(big.Int)
-	requiredAmount, ok = requiredAmount.SetString(requirements.MaxAmountRequired, 10)
+	// Check for nonce replay, keyed on the canonical nonce so that case or
+	// prefix variants of a spent nonce are caught too
+	var check AuthorizationCheck
+	nonce, ok := checkNonceFormat(auth, &check)
 	if !ok {
-		return nil, x402types.NewDecodingError("invalid required amount")
+		return refusal(auth, check), nil
 	}
+	fromAddress := auth.From.Hex()
+	if p.nonceStore.IsNonceUsed(fromAddress, nonce.String()) {
+		return refusal(auth, failedCheck("nonce already used (replay attack detected)", x402types.ReasonNonceReused)), nil
+	}
+
+	// Parse and bound amounts (rejects negatives, zero, hex, exponents, > uint256)
+	if check := v.amount(auth, requirements); !check.Passed {
+		return refusal(auth, check), nil
+	}
+	value, _ := x402types.ParseTokenAmount(auth.Value)
+	requiredAmount, _ := x402types.ParseTokenAmount(requirements.MaxAmountRequired)
 
-	// Check amount sufficiency
-	if value.Cmp(requiredAmount) < 0 {
+	// Overpayments settle only as far as the amount policy allows
+	if reason := p.amountPolicy.check(value, requiredAmount); reason != "" {
+		return refusal(auth, failedCheck(reason, x402types.ReasonAmountNotAccepted)), nil
+	}
+
+	if !full {
 		payer := x402types.NewEvmAddress(auth.From)
-		err := x402types.NewInsufficientValueError(payer)
 		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  err.Message,
+			IsValid: true,
 			Payer:   &payer,
 		}, nil
 	}
 
 	// Verify EIP-712 signature
-	valid, err := p.verifySignature(ctx, auth, payload.Signature, requirements.Asset.Hex())
-	if err != nil {
-		payer := x402types.NewEvmAddress(auth.From)
-		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  fmt.Sprintf("signature verification failed: %v", err),
-			Payer:   &payer,
-		}, nil
+	if permit2 {
+		valid, err := p.verifyPermit2Signature(&payload, requirements.Asset)
+		check = signatureCheck(auth, valid, err)
+	} else {
+		check = v.signature(auth, payload.Signature, requirements.Asset)
 	}
-	if !valid {
-		payer := x402types.NewEvmAddress(auth.From)
-		err := x402types.NewInvalidSignatureError(payer, "signature verification failed")
-		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  err.Message,
-			Payer:   &payer,
-		}, nil
+	if !check.Passed {
+		return refusal(auth, check), nil
 	}
 
-	// Check balance
+	// Fail fast rather than wait on an RPC that is failing anyway
+	if err := p.checkRPCHealth(); err != nil {
+		return nil, err
+	}
+
+	// The token (or Permit2's nonce bitmap) remembers nonces settled
+	// elsewhere or cancelled by the payer
 	tokenAddr := requirements.Asset
+	var used bool
+	var err error
+	start := time.Now()
+	if permit2 {
+		used, err = p.permit2NonceUsed(ctx, auth.From, nonce)
+	} else {
+		used, err = p.authorizationUsed(ctx, tokenAddr, auth.From, nonce)
+	}
+	p.recordRPC(ctx, start, err)
+	if check, err = nonceStateCheck(used, err); err != nil {
+		return nil, err
+	}
+	if !check.Passed {
+		return refusal(auth, check), nil
+	}
+
+	// Check balance
+	start = time.Now()
 	balance, err := p.getBalance(ctx, tokenAddr, auth.From)
-	if err != nil {
-		log.Printf("evm.Verify: balance check failed err=%v", err)
-		payer := x402types.NewEvmAddress(auth.From)
-		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  fmt.Sprintf("balance check failed: %v", err),
-			Payer:   &payer,
-		}, nil
+	p.recordRPC(ctx, start, err)
+	if check, err = balanceCheck(auth.From, balance, value, err); err != nil {
+		return nil, err
+	}
+	if !check.Passed {
+		return refusal(auth, check), nil
 	}
 
-	if balance.Cmp(value) < 0 {
-		payer := x402types.NewEvmAddress(auth.From)
-		err := x402types.NewInsufficientFundsError(payer)
-		return &x402types.VerifyResponse{
-			IsValid: false,
-			Reason:  err.Message,
-			Payer:   &payer,
-		}, nil
+	// Permit2 can only move what the payer approved to it
+	if permit2 {
+		start = time.Now()
+		allowance, err := p.permit2Allowance(ctx, tokenAddr, auth.From)
+		p.recordRPC(ctx, start, err)
+		if err != nil {
+			log.Printf("evm.Verify: permit2 allowance check failed err=%v", err)
+			if timeoutErr := timeoutError("permit2 allowance check", err); timeoutErr != nil {
+				return nil, timeoutErr
+			}
+			payer := x402types.NewEvmAddress(auth.From)
+			return &x402types.VerifyResponse{
+				IsValid:    false,
+				Reason:     fmt.Sprintf("permit2 allowance check failed: %v", err),
+				ReasonCode: x402types.ReasonRPCError,
+				Payer:      &payer,
+			}, nil
+		}
+		if allowance.Cmp(value) < 0 {
+			payer := x402types.NewEvmAddress(auth.From)
+			return &x402types.VerifyResponse{
+				IsValid:    false,
+				Reason:     fmt.Sprintf("insufficient Permit2 allowance: %s approved, %s required", allowance, value),
+				ReasonCode: x402types.ReasonInsufficientAllowance,
+				Payer:      &payer,
+			}, nil
+		}
 	}
 
 	// All checks passed
@@ -282,24 +605,48 @@ func (p *Provider) Verify(ctx context.Context, request *x402types.VerifyRequest)
 	}, nil
 }
 
-// Settle executes an EVM payment on-chain
+// Settle executes an EVM payment on-chain; the response names the amount
+// policy it was settled (or refused) under
 func (p *Provider) Settle(ctx context.Context, request *x402types.SettleRequest) (*x402types.SettleResponse, error) {
-	// First verify
+	resp, err := p.settle(ctx, request)
+	if resp != nil {
+		resp.AmountPolicy = p.amountPolicy.String()
+	}
+	return resp, err
+}
+
+// settle is Settle without the policy report
+func (p *Provider) settle(ctx context.Context, request *x402types.SettleRequest) (*x402types.SettleResponse, error) {
+	if p.verifyOnly {
+		return &x402types.SettleResponse{
+			Success:    false,
+			Error:      errSettlementDisabled,
+			ReasonCode: x402types.ReasonSettlementDisabled,
+		}, nil
+	}
+
+	// First verify; a fresh verification ID for this exact payload leaves
+	// only the cheap checks to repeat
 	verifyReq := &x402types.VerifyRequest{
 		PaymentPayload:      request.PaymentPayload,
 		PaymentRequirements: request.PaymentRequirements,
 	}
-	verifyResp, err := p.Verify(ctx, verifyReq)
+	verifyResp, err := p.verify(ctx, verifyReq, !p.consumeVerification(request))
 	if err != nil {
+		if facErr, ok := err.(*x402types.FacilitatorError); ok && (facErr.Type == "Timeout" || facErr.Type == "ServiceDegraded") {
+			return nil, facErr
+		}
 		return &x402types.SettleResponse{
-			Success: false,
-			Error:   fmt.Sprintf("verification failed: %v", err),
+			Success:    false,
+			Error:      fmt.Sprintf("verification failed: %v", err),
+			ReasonCode: x402types.ReasonRPCError,
 		}, nil
 	}
 	if !verifyResp.IsValid {
 		return &x402types.SettleResponse{
-			Success: false,
-			Error:   verifyResp.Reason,
+			Success:    false,
+			Error:      verifyResp.Reason,
+			ReasonCode: verifyResp.ReasonCode,
 		}, nil
 	}
 
@@ -307,283 +654,339 @@ func (p *Provider) Settle(ctx context.Context, request *x402types.SettleRequest)
 	payload := request.PaymentPayload.Payload
 	auth := &payload.Authorization
 
-	// Select signer (round-robin)
-	signerIdx := int(p.signerIndex.Add(1) % uint64(len(p.signers)))
-	signer := p.signers[signerIdx]
+	// Select signer (round-robin); a permit2 transfer must come from the
+	// spender the payer signed for
+	permit2 := request.PaymentPayload.Scheme == x402types.SchemePermit2
+	var signer *signerSlot
+	if permit2 {
+		if signerAllowed(ctx, payload.Permit2.Spender) {
+			signer = p.signerFor(payload.Permit2.Spender)
+		}
+	} else if idx := p.pickSigner(ctx, true); idx >= 0 {
+		signer = p.signers[idx]
+	}
+	if signer == nil {
+		return &x402types.SettleResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("no signer this request may use settles on %s", p.network),
+			ReasonCode: x402types.ReasonPolicyViolation,
+		}, nil
+	}
 
 	// Create transaction
 	tokenAddr := request.PaymentRequirements.Asset
 
 	// Parse nonce
-	nonceHex := strings.TrimPrefix(auth.Nonce, "0x")
-	nonceBytes, err := hex.DecodeString(nonceHex)
+	nonce32, err := auth.Nonce.Nonce()
 	if err != nil {
 		return &x402types.SettleResponse{
-			Success: false,
-			Error:   fmt.Sprintf("invalid nonce: %v", err),
+			Success:    false,
+			Error:      err.Error(),
+			ReasonCode: x402types.ReasonDecodingError,
 		}, nil
 	}
-	var nonce32 [32]byte
-	copy(nonce32[:], nonceBytes)
 
-	// Parse signature
-	sigHex := strings.TrimPrefix(payload.Signature, "0x")
-	sigBytes, err := hex.DecodeString(sigHex)
-	if err != nil {
-		return &x402types.SettleResponse{
-			Success: false,
-			Error:   fmt.Sprintf("invalid signature: %v", err),
-		}, nil
-	}
+	sigBytes := []byte(payload.Signature)
 
-	// Parse value
-	value := new(big.Int)
-	value, ok := value.SetString(auth.Value, 10)
-	if !ok {
+	// Parse value (zero-amount payments are verify-only, never submitted on-chain)
+	value, err := x402types.ParseTokenAmount(auth.Value)
+	if err != nil || value.Sign() == 0 {
 		return &x402types.SettleResponse{
-			Success: false,
-			Error:   "invalid value",
+			Success:    false,
+			Error:      "invalid value: zero or malformed amounts cannot be settled",
+			ReasonCode: x402types.ReasonInvalidAmount,
 		}, nil
 	}
 
 	validAfter, ok := new(big.Int).SetString(auth.ValidAfter, 10)
 	if !ok {
 		return &x402types.SettleResponse{
-			Success: false,
-			Error:   "invalid validAfter",
+			Success:    false,
+			Error:      "invalid validAfter",
+			ReasonCode: x402types.ReasonInvalidTiming,
 		}, nil
 	}
 	validBefore, ok := new(big.Int).SetString(auth.ValidBefore, 10)
 	if !ok {
 		return &x402types.SettleResponse{
-			Success: false,
-			Error:   "invalid validBefore",
+			Success:    false,
+			Error:      "invalid validBefore",
+			ReasonCode: x402types.ReasonInvalidTiming,
 		}, nil
 	}
 
-	// Call transferWithAuthorization
-	tx, err := p.transferWithAuthorization(
-		ctx,
-		signer,
-		tokenAddr,
-		auth.From,
-		auth.To,
-		value,
-		validAfter,
-		validBefore,
-		nonce32,
-		sigBytes,
-	)
+	// A transaction landing after validBefore reverts, gas spent
+	if refused := p.checkSettleWindow(request, validBefore); refused != nil {
+		return refused, nil
+	}
+
+	// Call transferWithAuthorization, or Permit2's permitWitnessTransferFrom
+	var tx *types.Transaction
+	if permit2 {
+		tx, err = p.permitWitnessTransferFrom(ctx, signer, tokenAddr, &payload)
+	} else {
+		tx, err = p.transferWithAuthorization(
+			ctx,
+			signer,
+			tokenAddr,
+			auth.From,
+			auth.To,
+			value,
+			validAfter,
+			validBefore,
+			nonce32,
+			sigBytes,
+		)
+	}
 	if err != nil {
+		if timeoutErr := timeoutError("submitting transaction", err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
 		return &x402types.SettleResponse{
-			Success: false,
-			Error:   fmt.Sprintf("transaction failed: %v", err),
+			Success:    false,
+			Error:      fmt.Sprintf("transaction failed: %v", err),
+			ReasonCode: x402types.ReasonSettlementFailed,
 		}, nil
 	}
 
-	// Wait for receipt
-	receipt, err := bind.WaitMined(ctx, p.client, tx)
+	// Journal the submission first so a crash before the receipt arrives
+	// leaves a hash to reconcile
+	journalID := p.journalSettlement(ctx, "", auth, &request.PaymentRequirements, validBefore, tx, accounting.JournalSubmitted)
+
+	// Wait for receipt (aborts promptly if the caller's request is cancelled)
+	confirmCtx, cancel := p.confirmContext(ctx)
+	defer cancel()
+	receipt, err := bind.WaitMined(confirmCtx, p.client, tx)
 	if err != nil {
+		p.journalSettlement(ctx, journalID, auth, nil, validBefore, tx, accounting.JournalUnknown)
+		if timeoutErr := timeoutError(fmt.Sprintf("waiting for tx %s", tx.Hash().Hex()), err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
 		return &x402types.SettleResponse{
-			Success: false,
-			Error:   fmt.Sprintf("waiting for tx failed: %v", err),
+			Success:    false,
+			Error:      fmt.Sprintf("waiting for tx failed: %v", err),
+			ReasonCode: x402types.ReasonSettlementFailed,
 		}, nil
 	}
 
 	if receipt.Status != types.ReceiptStatusSuccessful {
-		return &x402types.SettleResponse{
-			Success: false,
-			Error:   "transaction reverted",
-		}, nil
+		// Replay the call at the failing block to recover the revert reason
+		signerAddr := signer.Address()
+		reason := p.revertReason(ctx, signerAddr, tx, receipt.BlockNumber)
+		log.Printf("evm.Settle: transaction %s reverted reason=%q", tx.Hash().Hex(), reason)
+		p.journalSettlement(ctx, journalID, auth, nil, validBefore, tx, accounting.JournalReverted)
+		return revertedSettleResponse(reason, x402types.NewEvmAddress(auth.From)), nil
 	}
 
 	// Mark nonce as used after successful settlement
 	fromAddress := auth.From.Hex()
-	p.nonceStore.MarkNonceUsed(fromAddress, auth.Nonce, validBefore.Int64())
+	p.nonceStore.MarkNonceUsed(fromAddress, nonce32.String(), validBefore.Int64())
+	p.journalSettlement(ctx, journalID, auth, nil, validBefore, tx, accounting.JournalConfirmed)
+	// Reorg resubmission replays ERC-3009 authorizations only
+	if !permit2 {
+		p.watchSettlement(journalID, receipt, tokenAddr, auth, value, validAfter, validBefore, nonce32, sigBytes)
+	}
 
-	return &x402types.SettleResponse{
+	// Hold the result back until the settlement is buried deep enough
+	if err := p.waitConfirmations(confirmCtx, receipt); err != nil {
+		if timeoutErr := timeoutError(fmt.Sprintf("waiting for %d confirmations of tx %s", p.confirmations, tx.Hash().Hex()), err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		return &x402types.SettleResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("waiting for confirmations failed: %v", err),
+			ReasonCode: x402types.ReasonRPCError,
+		}, nil
+	}
+
+	resp := &x402types.SettleResponse{
 		Success: true,
 		TransactionHash: &x402types.TransactionHash{
 			Type: "evm",
 			Hash: tx.Hash().Hex(),
 		},
-	}, nil
-}
-
-// verifySignature validates the EIP-712 signature
-func (p *Provider) verifySignature(ctx context.Context, auth *x402types.ExactEvmPayloadAuthorization, signature, tokenAddress string) (bool, error) {
-	// Create EIP-712 typed data
-	typedData := apitypes.TypedData{
-		Types: apitypes.Types{
-			"EIP712Domain": []apitypes.Type{
-				{Name: "name", Type: "string"},
-				{Name: "version", Type: "string"},
-				{Name: "chainId", Type: "uint256"},
-				{Name: "verifyingContract", Type: "address"},
-			},
-			"TransferWithAuthorization": []apitypes.Type{
-				{Name: "from", Type: "address"},
-				{Name: "to", Type: "address"},
-				{Name: "value", Type: "uint256"},
-				{Name: "validAfter", Type: "uint256"},
-				{Name: "validBefore", Type: "uint256"},
-				{Name: "nonce", Type: "bytes32"},
-			},
-		},
-		PrimaryType: "TransferWithAuthorization",
-		Domain: apitypes.TypedDataDomain{
-			Name:              "USD Coin",
-			Version:           "2",
-			ChainId:           (*math.HexOrDecimal256)(p.chainID),
-			VerifyingContract: tokenAddress,
-		},
-		Message: apitypes.TypedDataMessage{
-			"from":        auth.From.Hex(),
-			"to":          auth.To.Hex(),
-			"value":       auth.Value,
-			"validAfter":  auth.ValidAfter,
-			"validBefore": auth.ValidBefore,
-			"nonce":       auth.Nonce,
-		},
+		GasUsed: receipt.GasUsed,
 	}
-
-	// Hash the typed data
-	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
-	if err != nil {
-		return false, fmt.Errorf("failed to hash domain: %w", err)
+	if receipt.EffectiveGasPrice != nil {
+		resp.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
 	}
 
-	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
-	if err != nil {
-		return false, fmt.Errorf("failed to hash message: %w", err)
-	}
-
-	rawData := []byte(fmt.Sprintf("\x19\x01%s%s", string(domainSeparator), string(typedDataHash)))
-	hash := crypto.Keccak256Hash(rawData)
-
-	// Parse signature
-	sigHex := strings.TrimPrefix(signature, "0x")
-	sigBytes, err := hex.DecodeString(sigHex)
-	if err != nil {
-		return false, fmt.Errorf("invalid signature hex: %w", err)
+	// Split payments: funds now sit in the splitter, distribute them
+	// (Verify already validated the splits)
+	if extra, _ := x402types.ParseExtra(request.PaymentRequirements.Extra); extra != nil && extra.Splits != nil {
+		splitTx, err := p.distributeSplits(ctx, signer, tokenAddr, value, extra.Splits)
+		if splitTx != nil {
+			resp.SplitTransactionHash = &x402types.TransactionHash{
+				Type: "evm",
+				Hash: splitTx.Hash().Hex(),
+			}
+		}
+		if err != nil {
+			// The payment itself settled; surface the distribution failure for follow-up
+			log.Printf("evm.Settle: split distribution failed tx=%s err=%v", tx.Hash().Hex(), err)
+			resp.Error = fmt.Sprintf("split distribution failed: %v", err)
+		}
 	}
 
-	if len(sigBytes) != 65 {
-		return false, fmt.Errorf("invalid signature length: %d", len(sigBytes))
-	}
+	return resp, nil
+}
 
-	// Adjust V value
-	if sigBytes[64] >= 27 {
-		sigBytes[64] -= 27
+// authorizationVerifier runs the shared authorization checks with the
+// provider's network, clock, skew and minimum validity window
+func (p *Provider) authorizationVerifier() *authorizationVerifier {
+	return &authorizationVerifier{
+		chainID:    p.chainID,
+		network:    p.network,
+		clock:      p.clock,
+		skew:       p.clockSkew,
+		minTimeout: p.minTimeout,
 	}
+}
 
-	// Recover public key
-	pubKey, err := crypto.SigToPub(hash.Bytes(), sigBytes)
-	if err != nil {
-		return false, fmt.Errorf("failed to recover pubkey: %w", err)
+// refusal is the response refusing a payment for a failed check
+func refusal(auth *x402types.ExactEvmPayloadAuthorization, check AuthorizationCheck) *x402types.VerifyResponse {
+	payer := x402types.NewEvmAddress(auth.From)
+	return &x402types.VerifyResponse{
+		IsValid:    false,
+		Reason:     check.Reason,
+		ReasonCode: check.ReasonCode,
+		Payer:      &payer,
 	}
-
-	recoveredAddr := crypto.PubkeyToAddress(*pubKey)
-
-	// Check if recovered address matches expected from address
-	return strings.EqualFold(recoveredAddr.Hex(), auth.From.Hex()), nil
 }
 
-// getBalance queries the token balance of an address
+// getBalance queries the token balance of an address at the provider's
+// balance block
 func (p *Provider) getBalance(ctx context.Context, token, account common.Address) (*big.Int, error) {
-	// Pack balanceOf call
-	data, err := p.usdcABI.Pack("balanceOf", account)
+	block, err := p.balanceBlock(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to pack balanceOf: %w", err)
+		return nil, err
 	}
+	callCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	return callBalanceOf(callCtx, p.client, p.usdcABI, token, account, block)
+}
 
-	// Call contract
-	msg := ethereum.CallMsg{
-		To:   &token,
-		Data: data,
-	}
-	result, err := p.client.CallContract(ctx, msg, nil)
-	if err != nil {
-		return nil, fmt.Errorf("balanceOf call failed: %w", err)
+// journalSettlement records a settlement transaction's progress and reports
+// it to the settlement observer, returning the journal entry ID (taken from
+// ctx, see accounting.WithJournalID, when id is empty); without a journal
+// only the observer sees it and the ID is empty. requirements, if not nil,
+// supply the facilitator fee; later records keep it.
+func (p *Provider) journalSettlement(ctx context.Context, id string, auth *x402types.ExactEvmPayloadAuthorization, requirements *x402types.PaymentRequirements, validBefore *big.Int, tx *types.Transaction, status accounting.JournalStatus) string {
+	if p.journal != nil {
+		if id == "" {
+			id = accounting.JournalIDFromContext(ctx)
+		}
+		entry := accounting.JournalEntry{
+			ID:              id,
+			Network:         p.network,
+			Payer:           auth.From.Hex(),
+			PayTo:           auth.To.Hex(),
+			Amount:          auth.Value,
+			Nonce:           auth.Nonce.String(),
+			ValidBefore:     validBefore.Int64(),
+			Status:          status,
+			TransactionHash: tx.Hash().Hex(),
+		}
+		if requirements != nil {
+			entry.FacilitatorFee = requirements.FacilitatorFee()
+		}
+		id = p.journal.Record(entry)
 	}
+	p.observeSettlement(id, auth, tx.Hash(), status, nil)
+	return id
+}
 
-	// Unpack result
-	var balance *big.Int
-	err = p.usdcABI.UnpackIntoInterface(&balance, "balanceOf", result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unpack balanceOf result: %w", err)
+// canonicalNonce normalizes a nonce for records, keeping malformed ones as sent
+func canonicalNonce(nonce string) string {
+	if canonical, err := x402types.NormalizeNonce(nonce); err == nil {
+		return canonical
 	}
+	return nonce
+}
+
+// TransactionReceipt returns the receipt of a settlement transaction
+// (ethereum.NotFound while it is pending or unknown to the node)
+func (p *Provider) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	rpcCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	return p.client.TransactionReceipt(rpcCtx, txHash)
+}
 
-	return balance, nil
+// MarkNonceUsed records an authorization nonce as spent (for settlements
+// confirmed out of band, e.g. by reconciliation)
+func (p *Provider) MarkNonceUsed(from, nonce string, validBefore int64) {
+	p.nonceStore.MarkNonceUsed(from, canonicalNonce(nonce), validBefore)
 }
 
 // transferWithAuthorization submits a transferWithAuthorization transaction
 func (p *Provider) transferWithAuthorization(
 	ctx context.Context,
-	signer *ecdsa.PrivateKey,
+	signer *signerSlot,
 	token, from, to common.Address,
 	value, validAfter, validBefore *big.Int,
 	nonce [32]byte,
 	signature []byte,
 ) (*types.Transaction, error) {
-	// Create auth
-	auth, err := bind.NewKeyedTransactorWithChainID(signer, p.chainID)
+	// Pack the function call in the token's signature encoding
+	data, err := p.packTransferCall(token, from, to, value, validAfter, validBefore, nonce, signature)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create transactor: %w", err)
+		return nil, err
 	}
 
+	return p.sendContractTx(ctx, signer, token, data, settleGasLimit)
+}
+
+// settleGasLimit is the gas limit of transferWithAuthorization
+// Fixed at 100,000 (typical usage: ~50-70k, provides safe buffer)
+const settleGasLimit = 100000
+
+// sendContractTx signs and submits a contract call from the given signer
+// The signer's lock is held from nonce lookup to broadcast so concurrent
+// settlements on one signer never reuse a pending nonce; signers are
+// independent of each other
+func (p *Provider) sendContractTx(ctx context.Context, signer *signerSlot, to common.Address, data []byte, gasLimit uint64) (*types.Transaction, error) {
+	signer.mu.Lock()
+	defer signer.mu.Unlock()
+
 	// Get nonce
-	signerAddr := crypto.PubkeyToAddress(*signer.Public().(*ecdsa.PublicKey))
-	nonceVal, err := p.client.PendingNonceAt(ctx, signerAddr)
+	signerAddr := signer.Address()
+	nonceCtx, cancelNonce := p.rpcContext(ctx)
+	defer cancelNonce()
+	nonceVal, err := p.client.PendingNonceAt(nonceCtx, signerAddr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get nonce: %w", err)
 	}
-	auth.Nonce = big.NewInt(int64(nonceVal))
-
-	// Set gas limit for transferWithAuthorization
-	// Fixed at 100,000 (typical usage: ~50-70k, provides safe buffer)
-	auth.GasLimit = 100000
 
 	// Get gas price
-	gasPrice, err := p.client.SuggestGasPrice(ctx)
+	gasCtx, cancelGas := p.rpcContext(ctx)
+	defer cancelGas()
+	gasPrice, err := p.client.SuggestGasPrice(gasCtx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gas price: %w", err)
 	}
-	auth.GasPrice = gasPrice
-
-	// Pack the function call
-	data, err := p.usdcABI.Pack(
-		"transferWithAuthorization",
-		from,
-		to,
-		value,
-		validAfter,
-		validBefore,
-		nonce,
-		signature,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to pack transferWithAuthorization: %w", err)
-	}
 
 	// Create raw transaction
 	tx := types.NewTransaction(
-		auth.Nonce.Uint64(),
-		token,
+		nonceVal,
+		to,
 		big.NewInt(0), // value
-		auth.GasLimit,
-		auth.GasPrice,
+		gasLimit,
+		gasPrice,
 		data,
 	)
 
-	// Sign transaction
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(p.chainID), signer)
+	// Sign transaction (may call out to KMS or a remote signer)
+	signCtx, cancelSign := p.rpcContext(ctx)
+	defer cancelSign()
+	signedTx, err := signer.SignTx(signCtx, tx, p.chainID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign tx: %w", err)
 	}
 
 	// Send transaction
-	err = p.client.SendTransaction(ctx, signedTx)
+	sendCtx, cancelSend := p.rpcContext(ctx)
+	defer cancelSend()
+	err = p.client.SendTransaction(sendCtx, signedTx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send tx: %w", err)
 	}
@@ -594,10 +997,17 @@ func (p *Provider) transferWithAuthorization(
 // loadUSDABI loads the USDC ABI
 func loadUSDABI() (abi.ABI, error) {
 	// Simplified - in production, load from file or embed
-	const usdcABIJSON = `[{"inputs":[{"internalType":"address","name":"account","type":"address"}],"name":"balanceOf","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"from","type":"address"},{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"value","type":"uint256"},{"internalType":"uint256","name":"validAfter","type":"uint256"},{"internalType":"uint256","name":"validBefore","type":"uint256"},{"internalType":"bytes32","name":"nonce","type":"bytes32"},{"internalType":"bytes","name":"signature","type":"bytes"}],"name":"transferWithAuthorization","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+	const usdcABIJSON = `[{"inputs":[{"internalType":"address","name":"account","type":"address"}],"name":"balanceOf","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"from","type":"address"},{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"value","type":"uint256"},{"internalType":"uint256","name":"validAfter","type":"uint256"},{"internalType":"uint256","name":"validBefore","type":"uint256"},{"internalType":"bytes32","name":"nonce","type":"bytes32"},{"internalType":"bytes","name":"signature","type":"bytes"}],"name":"transferWithAuthorization","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"value","type":"uint256"}],"name":"transfer","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"address","name":"authorizer","type":"address"},{"internalType":"bytes32","name":"nonce","type":"bytes32"}],"name":"authorizationState","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"authorizer","type":"address"},{"internalType":"bytes32","name":"nonce","type":"bytes32"},{"internalType":"bytes","name":"signature","type":"bytes"}],"name":"cancelAuthorization","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
 	return abi.JSON(strings.NewReader(usdcABIJSON))
 }
 
+// loadUSDCVRSABI loads the ERC-3009 functions of tokens that take the
+// signature as separate v, r and s (see network.SignatureVRS)
+func loadUSDCVRSABI() (abi.ABI, error) {
+	const usdcVRSABIJSON = `[{"inputs":[{"internalType":"address","name":"from","type":"address"},{"internalType":"address","name":"to","type":"address"},{"internalType":"uint256","name":"value","type":"uint256"},{"internalType":"uint256","name":"validAfter","type":"uint256"},{"internalType":"uint256","name":"validBefore","type":"uint256"},{"internalType":"bytes32","name":"nonce","type":"bytes32"},{"internalType":"uint8","name":"v","type":"uint8"},{"internalType":"bytes32","name":"r","type":"bytes32"},{"internalType":"bytes32","name":"s","type":"bytes32"}],"name":"transferWithAuthorization","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"address","name":"authorizer","type":"address"},{"internalType":"bytes32","name":"nonce","type":"bytes32"},{"internalType":"uint8","name":"v","type":"uint8"},{"internalType":"bytes32","name":"r","type":"bytes32"},{"internalType":"bytes32","name":"s","type":"bytes32"}],"name":"cancelAuthorization","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+	return abi.JSON(strings.NewReader(usdcVRSABIJSON))
+}
+
 // loadValidatorABI loads the Validator6492 ABI
 func loadValidatorABI() (abi.ABI, error) {
 	// Simplified - in production, load from file
@@ -0,0 +1,123 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// RemoteSigner signs through an HTTP JSON signing service
+//
+// The service exposes:
+//
+//	GET  {url}/address  -> {"address": "0x..."}
+//	POST {url}/sign     {"address": "0x...", "hash": "0x..."} -> {"signature": "0x..."}
+//
+// where signature is 65 bytes [R || S || V] (V of 0/1 or 27/28) over the
+// 32-byte hash. Every signature is checked against the address.
+type RemoteSigner struct {
+	url     string
+	client  *http.Client
+	address common.Address
+}
+
+// DefaultRemoteSignerTimeout bounds each call to a remote signer
+const DefaultRemoteSignerTimeout = 10 * time.Second
+
+// NewRemoteSigner connects to the signing service at url and fetches its address
+// A nil httpClient uses one with DefaultRemoteSignerTimeout
+func NewRemoteSigner(ctx context.Context, url string, httpClient *http.Client) (*RemoteSigner, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultRemoteSignerTimeout}
+	}
+	s := &RemoteSigner{url: strings.TrimSuffix(url, "/"), client: httpClient}
+
+	var resp struct {
+		Address string `json:"address"`
+	}
+	if err := s.do(ctx, http.MethodGet, "/address", nil, &resp); err != nil {
+		return nil, fmt.Errorf("remote signer %s: %w", url, err)
+	}
+	if !common.IsHexAddress(resp.Address) {
+		return nil, fmt.Errorf("remote signer %s returned invalid address %q", url, resp.Address)
+	}
+	s.address = common.HexToAddress(resp.Address)
+	return s, nil
+}
+
+// Address implements Signer
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx implements Signer
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return signTxWithHash(ctx, s.SignHash, tx, chainID)
+}
+
+// SignHash implements Signer
+func (s *RemoteSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	req := map[string]string{
+		"address": s.address.Hex(),
+		"hash":    "0x" + hex.EncodeToString(hash),
+	}
+	var resp struct {
+		Signature string `json:"signature"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/sign", req, &resp); err != nil {
+		return nil, fmt.Errorf("remote signer: %w", err)
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(resp.Signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return nil, fmt.Errorf("remote signer returned a malformed signature")
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if err := checkRecovered(hash, sig, s.address); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// do sends one JSON request to the signing service
+func (s *RemoteSigner) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.url+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,243 @@
+package evm_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+)
+
+// fakeSigner is a remote signing service over key with injectable faults
+type fakeSigner struct {
+	*httptest.Server
+	key *ecdsa.PrivateKey
+
+	mu        sync.Mutex
+	delay     time.Duration     // Before answering /sign
+	status    int               // Non-zero fails /sign with this status
+	wrongKey  *ecdsa.PrivateKey // Signs with this key instead
+	malformed bool              // Answers a truncated signature
+	signs     int
+}
+
+func newFakeSigner(t *testing.T, key *ecdsa.PrivateKey) *fakeSigner {
+	t.Helper()
+	s := &fakeSigner{key: key}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// set applies fn to the faults; reset first clears them
+func (s *fakeSigner) set(fn func(s *fakeSigner)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s)
+}
+
+func reset(s *fakeSigner) {
+	s.delay, s.status, s.wrongKey, s.malformed = 0, 0, nil, false
+}
+
+func (s *fakeSigner) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	delay, status, key, malformed := s.delay, s.status, s.key, s.malformed
+	if s.wrongKey != nil {
+		key = s.wrongKey
+	}
+	s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/address":
+		json.NewEncoder(w).Encode(map[string]string{"address": crypto.PubkeyToAddress(s.key.PublicKey).Hex()})
+	case "/sign":
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		if status != 0 {
+			http.Error(w, "signing backend unavailable", status)
+			return
+		}
+		var req struct {
+			Address, Hash string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hash, _ := hex.DecodeString(strings.TrimPrefix(req.Hash, "0x"))
+		sig, err := crypto.Sign(hash, key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sig[64] += 27 // Services commonly answer V of 27/28
+		if malformed {
+			sig = sig[:64]
+		}
+		s.mu.Lock()
+		s.signs++
+		s.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"signature": "0x" + hex.EncodeToString(sig)})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestRemoteSignerSignsHashes(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	service := newFakeSigner(t, key)
+	signer, err := evm.NewRemoteSigner(context.Background(), service.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.Address() != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("address %s, want the service's", signer.Address().Hex())
+	}
+
+	hash := crypto.Keccak256([]byte("settlement"))
+	sig, err := signer.SignHash(context.Background(), hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[64] > 1 {
+		t.Errorf("V = %d, want it normalized to 0 or 1", sig[64])
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != signer.Address() {
+		t.Errorf("signature recovers to %v (%v), want the signer", pub, err)
+	}
+}
+
+func TestRemoteSignerFaults(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	service := newFakeSigner(t, key)
+	signer, err := evm.NewRemoteSigner(context.Background(), service.URL, &http.Client{Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := crypto.Keccak256([]byte("settlement"))
+
+	for _, tc := range []struct {
+		name  string
+		fault func(s *fakeSigner)
+		err   string
+	}{
+		{"backend error", func(s *fakeSigner) { s.status = http.StatusServiceUnavailable }, "status 503"},
+		{"signature by another key", func(s *fakeSigner) { s.wrongKey = other }, "expected " + signer.Address().Hex()},
+		{"malformed signature", func(s *fakeSigner) { s.malformed = true }, "malformed signature"},
+		{"slower than the client timeout", func(s *fakeSigner) { s.delay = 300 * time.Millisecond }, "remote signer"},
+	} {
+		service.set(reset)
+		service.set(tc.fault)
+		start := time.Now()
+		_, err := signer.SignHash(context.Background(), hash)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: error %v, want %q", tc.name, err, tc.err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("%s: took %v, want the timeout to cut it short", tc.name, elapsed)
+		}
+	}
+
+	// A slow signer within the timeout still signs
+	service.set(reset)
+	service.set(func(s *fakeSigner) { s.delay = 20 * time.Millisecond })
+	if _, err := signer.SignHash(context.Background(), hash); err != nil {
+		t.Errorf("slow signer: %v", err)
+	}
+
+	// The caller's context bounds the call too
+	service.set(func(s *fakeSigner) { s.delay = 300 * time.Millisecond })
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := signer.SignHash(ctx, hash); err == nil {
+		t.Error("signing outlived the caller's context")
+	}
+}
+
+func TestNewRemoteSignerRejectsBadServices(t *testing.T) {
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"address": "not-an-address"})
+	}))
+	defer bad.Close()
+	if _, err := evm.NewRemoteSigner(context.Background(), bad.URL, nil); err == nil || !strings.Contains(err.Error(), "invalid address") {
+		t.Errorf("invalid address: error %v", err)
+	}
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	if _, err := evm.NewRemoteSigner(context.Background(), down.URL, nil); err == nil {
+		t.Error("connected to a signer that is down")
+	}
+}
+
+func TestSettleWithRemoteSigner(t *testing.T) {
+	chain := newTestChain(t)
+	service := newFakeSigner(t, chain.Signer.Key)
+	service.set(func(s *fakeSigner) { s.delay = 10 * time.Millisecond })
+	remote, err := evm.NewRemoteSigner(context.Background(), service.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := chain.Options()
+	options.Keys = nil
+	options.Signers = []evm.Signer{remote}
+	provider, err := evm.New(testchain.Network, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	request := settleRequest(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := provider.Settle(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Success {
+		t.Fatalf("settle through the remote signer failed: %+v", resp)
+	}
+	service.mu.Lock()
+	signs := service.signs
+	service.mu.Unlock()
+	if signs == 0 {
+		t.Error("the settlement was not signed remotely")
+	}
+
+	// A failing signer fails the settlement without sending anything
+	service.set(func(s *fakeSigner) { s.status = http.StatusInternalServerError })
+	request = settleRequest(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = provider.Settle(context.Background(), request)
+	if err == nil && resp.Success {
+		t.Error("settled although the signer failed")
+	}
+	auth := request.PaymentPayload.Payload.Authorization
+	nonce, err := auth.Nonce.Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used, err := chain.AuthorizationUsed(auth.From, nonce); err != nil || used {
+		t.Errorf("authorization used %v (%v) after a failed signing, want it unspent", used, err)
+	}
+}
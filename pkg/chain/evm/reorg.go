@@ -0,0 +1,345 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultReorgCheckInterval is how often watched settlements are rechecked
+// when StartReorgWatcher is given no interval
+const DefaultReorgCheckInterval = 15 * time.Second
+
+// ReorgOutcome is what a recheck found for a watched settlement
+type ReorgOutcome string
+
+const (
+	ReorgMoved       ReorgOutcome = "moved"       // Re-included in another block; still settled
+	ReorgReconfirmed ReorgOutcome = "reconfirmed" // A resubmission was mined
+	ReorgResubmitted ReorgOutcome = "resubmitted" // Dropped and sent again before validBefore
+	ReorgReverted    ReorgOutcome = "reverted"    // Re-included, but the transfer reverted
+	ReorgLost        ReorgOutcome = "reorged"     // Dropped after validBefore; the payment is gone
+)
+
+// ReorgEvent reports a settlement whose confirmation was undone by a reorg
+type ReorgEvent struct {
+	Network            x402types.Network        `json:"network"`
+	JournalID          string                   `json:"journalId,omitempty"`
+	TransactionHash    string                   `json:"transactionHash"`
+	NewTransactionHash string                   `json:"newTransactionHash,omitempty"` // Resubmitted transaction
+	Outcome            ReorgOutcome             `json:"outcome"`
+	Status             accounting.JournalStatus `json:"status"` // Journal status after the event
+	Payer              string                   `json:"payer"`
+	Amount             string                   `json:"amount"`
+	Time               time.Time                `json:"time"`
+}
+
+// ReorgStats summarizes reorg watching so far
+type ReorgStats struct {
+	Watching    int    `json:"watching"`
+	Checks      uint64 `json:"checks"`
+	Moved       uint64 `json:"moved"` // Including resubmissions mined again
+	Resubmitted uint64 `json:"resubmitted"`
+	Reverted    uint64 `json:"reverted"`
+	Reorged     uint64 `json:"reorged"`
+	Errors      uint64 `json:"errors"`
+}
+
+// WithReorgWatch keeps checking, for window after a settlement confirms,
+// that its block is still canonical (see StartReorgWatcher); onReorg, if
+// set, is called for every settlement a reorg moved or dropped
+func WithReorgWatch(window time.Duration, onReorg func(ReorgEvent)) ProviderOption {
+	return func(o *providerOptions) {
+		if window > 0 {
+			o.reorgWindow = window
+			o.onReorg = onReorg
+		}
+	}
+}
+
+// watchedSettlement is a confirmed settlement kept under reorg watch, with
+// what is needed to submit its authorization again
+type watchedSettlement struct {
+	journalID   string
+	txHash      common.Hash
+	blockNumber *big.Int
+	blockHash   common.Hash
+	until       time.Time
+	pending     bool // Off chain after a reorg, waiting to be mined (again)
+	resubmitted bool // txHash is a resubmission of the original
+
+	token                   common.Address
+	auth                    x402types.ExactEvmPayloadAuthorization
+	value                   *big.Int
+	validAfter, validBefore *big.Int
+	nonce                   [32]byte
+	signature               []byte
+}
+
+// reorgWatch holds a provider's watched settlements
+type reorgWatch struct {
+	checkMu sync.Mutex // Serializes CheckReorgs
+	mu      sync.Mutex
+	watched map[common.Hash]*watchedSettlement
+	stats   ReorgStats
+}
+
+// watchSettlement puts a settlement confirmed in receipt under reorg watch
+func (p *Provider) watchSettlement(journalID string, receipt *types.Receipt, token common.Address, auth *x402types.ExactEvmPayloadAuthorization, value, validAfter, validBefore *big.Int, nonce [32]byte, signature []byte) {
+	if p.reorgWindow <= 0 {
+		return
+	}
+	w := &watchedSettlement{
+		journalID:   journalID,
+		txHash:      receipt.TxHash,
+		blockNumber: receipt.BlockNumber,
+		blockHash:   receipt.BlockHash,
+		until:       p.clock.Now().Add(p.reorgWindow),
+		token:       token,
+		auth:        *auth,
+		value:       value,
+		validAfter:  validAfter,
+		validBefore: validBefore,
+		nonce:       nonce,
+		signature:   signature,
+	}
+	p.reorgs.mu.Lock()
+	p.reorgs.watched[w.txHash] = w
+	p.reorgs.mu.Unlock()
+	p.recordBlock(w)
+}
+
+// ReorgWindow returns how long settlements are watched for reorgs (0 if not)
+func (p *Provider) ReorgWindow() time.Duration {
+	return p.reorgWindow
+}
+
+// ReorgStats returns the reorg watch totals
+func (p *Provider) ReorgStats() ReorgStats {
+	p.reorgs.mu.Lock()
+	defer p.reorgs.mu.Unlock()
+	stats := p.reorgs.stats
+	stats.Watching = len(p.reorgs.watched)
+	return stats
+}
+
+// CheckReorgs rechecks every watched settlement once
+//
+// A settlement whose block is no longer canonical is looked up again: if it
+// was re-included elsewhere the journal follows it to the new block; if it is
+// gone from the chain and the mempool, its authorization is submitted again
+// while validBefore allows, and otherwise the journal entry is marked
+// reorged. Settlements leave the watch once their window ends and they are
+// back on chain.
+func (p *Provider) CheckReorgs(ctx context.Context) []ReorgEvent {
+	p.reorgs.checkMu.Lock()
+	defer p.reorgs.checkMu.Unlock()
+
+	p.reorgs.mu.Lock()
+	watched := make([]*watchedSettlement, 0, len(p.reorgs.watched))
+	for _, w := range p.reorgs.watched {
+		watched = append(watched, w)
+	}
+	p.reorgs.mu.Unlock()
+
+	var events []ReorgEvent
+	for _, w := range watched {
+		oldHash := w.txHash
+		event, err := p.checkReorg(ctx, w)
+
+		p.reorgs.mu.Lock()
+		p.reorgs.stats.Checks++
+		if err != nil {
+			p.reorgs.stats.Errors++
+			log.Printf("evm: %s reorg check of %s failed: %v", p.network, w.txHash.Hex(), err)
+		}
+		if event != nil {
+			switch event.Outcome {
+			case ReorgMoved, ReorgReconfirmed:
+				p.reorgs.stats.Moved++
+			case ReorgResubmitted:
+				p.reorgs.stats.Resubmitted++
+			case ReorgReverted:
+				p.reorgs.stats.Reverted++
+			case ReorgLost:
+				p.reorgs.stats.Reorged++
+			}
+		}
+		delete(p.reorgs.watched, oldHash)
+		final := event != nil && (event.Outcome == ReorgReverted || event.Outcome == ReorgLost)
+		if !final && (w.pending || !p.clock.Now().After(w.until)) {
+			p.reorgs.watched[w.txHash] = w
+		}
+		p.reorgs.mu.Unlock()
+
+		if event != nil {
+			log.Printf("evm: %s settlement %s %s after reorg (journal: %s)", p.network, event.TransactionHash, event.Outcome, event.Status)
+			events = append(events, *event)
+			if p.onReorg != nil {
+				p.onReorg(*event)
+			}
+		}
+	}
+	return events
+}
+
+// checkReorg rechecks one watched settlement (callers hold checkMu),
+// returning an event if a reorg moved or dropped it
+func (p *Provider) checkReorg(ctx context.Context, w *watchedSettlement) (*ReorgEvent, error) {
+	if !w.pending {
+		rpcCtx, cancel := p.rpcContext(ctx)
+		header, err := p.client.HeaderByNumber(rpcCtx, w.blockNumber)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		if header.Hash() == w.blockHash {
+			return nil, nil // Still canonical
+		}
+	}
+
+	receipt, err := p.TransactionReceipt(ctx, w.txHash)
+	switch {
+	case err == nil:
+		if !w.pending && receipt.BlockHash == w.blockHash {
+			return nil, nil // Node has not caught up with the new head yet
+		}
+		// Re-included in another block, or the resubmission landed
+		w.pending = false
+		w.blockNumber, w.blockHash = receipt.BlockNumber, receipt.BlockHash
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return p.reorgEvent(w, ReorgReverted, accounting.JournalReverted, ""), nil
+		}
+		p.recordBlock(w)
+		if w.resubmitted {
+			return p.reorgEvent(w, ReorgReconfirmed, accounting.JournalConfirmed, ""), nil
+		}
+		return p.reorgEvent(w, ReorgMoved, accounting.JournalConfirmed, ""), nil
+	case !errors.Is(err, ethereum.NotFound):
+		return nil, err
+	}
+
+	// Not on chain; past validBefore the authorization can no longer execute
+	if p.clock.Now().Unix() >= w.validBefore.Int64() {
+		return p.reorgEvent(w, ReorgLost, accounting.JournalReorged, ""), nil
+	}
+
+	// Leave a transaction still in the mempool to be mined again
+	rpcCtx, cancel := p.rpcContext(ctx)
+	_, inMempool, err := p.client.TransactionByHash(rpcCtx, w.txHash)
+	cancel()
+	if err == nil && inMempool {
+		if !w.pending {
+			w.pending = true
+			p.setJournalStatus(w.journalID, accounting.JournalSubmitted)
+			log.Printf("evm: %s settlement %s back in the mempool after reorg", p.network, w.txHash.Hex())
+		}
+		return nil, nil
+	}
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return nil, err
+	}
+
+	// Dropped with time left on the authorization: submit it again
+	signer := p.signers[p.nextSignerIndex(true)]
+	tx, err := p.transferWithAuthorization(ctx, signer, w.token, w.auth.From, w.auth.To, w.value, w.validAfter, w.validBefore, w.nonce, w.signature)
+	if err != nil {
+		return nil, err
+	}
+	event := p.reorgEvent(w, ReorgResubmitted, accounting.JournalSubmitted, tx.Hash().Hex())
+	w.txHash, w.pending, w.resubmitted = tx.Hash(), true, true
+	if p.journal != nil && w.journalID != "" {
+		p.journalSettlement(ctx, w.journalID, &w.auth, nil, w.validBefore, tx, accounting.JournalSubmitted)
+	}
+	return event, nil
+}
+
+// reorgEvent builds the event for w and applies status to its journal entry
+func (p *Provider) reorgEvent(w *watchedSettlement, outcome ReorgOutcome, status accounting.JournalStatus, newTxHash string) *ReorgEvent {
+	p.setJournalStatus(w.journalID, status)
+	event := &ReorgEvent{
+		Network:            p.network,
+		JournalID:          w.journalID,
+		TransactionHash:    w.txHash.Hex(),
+		NewTransactionHash: newTxHash,
+		Outcome:            outcome,
+		Status:             status,
+		Payer:              w.auth.From.Hex(),
+		Amount:             w.auth.Value,
+		Time:               p.clock.Now(),
+	}
+	p.observeSettlement(w.journalID, &w.auth, w.txHash, status, event)
+	return event
+}
+
+// setJournalStatus overrides a watched settlement's journal status
+func (p *Provider) setJournalStatus(journalID string, status accounting.JournalStatus) {
+	if p.journal == nil || journalID == "" {
+		return
+	}
+	p.journal.SetStatus(journalID, status)
+}
+
+// recordBlock stores the block currently holding w in its journal entry
+func (p *Provider) recordBlock(w *watchedSettlement) {
+	if p.journal == nil || w.journalID == "" {
+		return
+	}
+	p.journal.Record(accounting.JournalEntry{
+		ID:              w.journalID,
+		TransactionHash: w.txHash.Hex(),
+		BlockNumber:     w.blockNumber.Uint64(),
+		BlockHash:       w.blockHash.Hex(),
+	})
+}
+
+// ReorgWatcher rechecks a provider's recent settlements in the background
+type ReorgWatcher struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// StartReorgWatcher rechecks watched settlements every interval
+// (DefaultReorgCheckInterval if interval <= 0) until Stop; settlements are
+// only watched with WithReorgWatch
+func (p *Provider) StartReorgWatcher(interval time.Duration) *ReorgWatcher {
+	if interval <= 0 {
+		interval = DefaultReorgCheckInterval
+	}
+	w := &ReorgWatcher{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				p.CheckReorgs(context.Background())
+			}
+		}
+	}()
+	return w
+}
+
+// Stop ends the background checks and waits for the current one to finish
+func (w *ReorgWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+	})
+}
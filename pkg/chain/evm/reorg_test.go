@@ -0,0 +1,261 @@
+package evm_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+)
+
+// reorgClient is the chain's client with a reorg staged on top: replaced
+// blocks read back with another hash, and receipts and transactions can be
+// moved or dropped
+type reorgClient struct {
+	evm.Client
+	mu       sync.Mutex
+	headers  map[uint64]*ethtypes.Header       // Replacement canonical blocks
+	receipts map[common.Hash]*ethtypes.Receipt // nil drops the transaction
+	mempool  map[common.Hash]bool              // Dropped transactions still pending
+}
+
+func (c *reorgClient) HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error) {
+	if number != nil {
+		c.mu.Lock()
+		header, ok := c.headers[number.Uint64()]
+		c.mu.Unlock()
+		if ok {
+			return header, nil
+		}
+	}
+	return c.Client.HeaderByNumber(ctx, number)
+}
+
+func (c *reorgClient) TransactionReceipt(ctx context.Context, hash common.Hash) (*ethtypes.Receipt, error) {
+	c.mu.Lock()
+	receipt, ok := c.receipts[hash]
+	c.mu.Unlock()
+	if !ok {
+		return c.Client.TransactionReceipt(ctx, hash)
+	}
+	if receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (c *reorgClient) TransactionByHash(ctx context.Context, hash common.Hash) (*ethtypes.Transaction, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if receipt, ok := c.receipts[hash]; ok && receipt == nil {
+		if c.mempool[hash] {
+			return ethtypes.NewTx(&ethtypes.LegacyTx{}), true, nil
+		}
+		return nil, false, ethereum.NotFound
+	}
+	return c.Client.TransactionByHash(ctx, hash)
+}
+
+// replaceBlock reorgs out the block holding receipt, returning the hash of the
+// block now canonical at its height
+func (c *reorgClient) replaceBlock(t *testing.T, receipt *ethtypes.Receipt) common.Hash {
+	t.Helper()
+	header, err := c.Client.HeaderByNumber(context.Background(), receipt.BlockNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement := ethtypes.CopyHeader(header)
+	replacement.Extra = []byte("reorg")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers[receipt.BlockNumber.Uint64()] = replacement
+	return replacement.Hash()
+}
+
+// setReceipt makes hash read back with receipt (nil: not on chain)
+func (c *reorgClient) setReceipt(hash common.Hash, receipt *ethtypes.Receipt, inMempool bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.receipts[hash] = receipt
+	c.mempool[hash] = inMempool
+}
+
+// offsetClock reads the current time moved by a settable offset
+type offsetClock struct{ offset atomic.Int64 }
+
+func (c *offsetClock) Now() time.Time { return time.Now().Add(time.Duration(c.offset.Load())) }
+
+// reorgSettlement settles a payment on a provider watching for reorgs,
+// returning its receipt as first mined
+func reorgSettlement(t *testing.T, opts ...evm.ProviderOption) (*evm.Provider, *reorgClient, *accounting.SettlementJournal, *ethtypes.Receipt, *[]evm.ReorgEvent) {
+	t.Helper()
+	chain := newTestChain(t)
+	client := &reorgClient{
+		Client:   chain.Client(),
+		headers:  make(map[uint64]*ethtypes.Header),
+		receipts: make(map[common.Hash]*ethtypes.Receipt),
+		mempool:  make(map[common.Hash]bool),
+	}
+	journal := accounting.NewSettlementJournal(16)
+	var events []evm.ReorgEvent
+	options := chain.Options()
+	options.Client = client
+	provider, err := evm.New(testchain.Network, options, append([]evm.ProviderOption{
+		evm.WithJournal(journal),
+		evm.WithReorgWatch(time.Hour, func(event evm.ReorgEvent) { events = append(events, event) }),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := settleRequest(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := provider.Settle(accounting.WithJournalID(context.Background(), "settle-1"), request)
+	if err != nil || !resp.Success {
+		t.Fatalf("settle: %+v, %v", resp, err)
+	}
+	receipt, err := chain.Client().TransactionReceipt(context.Background(), common.HexToHash(resp.TransactionHash.Hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, client, journal, receipt, &events
+}
+
+// journalEntry returns the journal's only entry
+func journalEntry(t *testing.T, journal *accounting.SettlementJournal) accounting.JournalEntry {
+	t.Helper()
+	entries := journal.Entries("", 0)
+	if len(entries) != 1 {
+		t.Fatalf("%d journal entries, want 1", len(entries))
+	}
+	return entries[0]
+}
+
+func TestReorgWatchIgnoresCanonicalSettlement(t *testing.T) {
+	provider, _, journal, receipt, events := reorgSettlement(t)
+	if got := provider.CheckReorgs(context.Background()); len(got) != 0 || len(*events) != 0 {
+		t.Errorf("events %v for a settlement still canonical", got)
+	}
+	if e := journalEntry(t, journal); e.BlockHash != receipt.BlockHash.Hex() || e.BlockNumber != receipt.BlockNumber.Uint64() {
+		t.Errorf("journal entry %+v, want block %d %s", e, receipt.BlockNumber, receipt.BlockHash.Hex())
+	}
+	if stats := provider.ReorgStats(); stats.Watching != 1 || stats.Checks != 1 {
+		t.Errorf("stats %+v, want one settlement watched and checked", stats)
+	}
+}
+
+func TestReorgWatchFollowsMovedSettlement(t *testing.T) {
+	provider, client, journal, receipt, events := reorgSettlement(t)
+	newBlock := client.replaceBlock(t, receipt)
+	moved := *receipt
+	moved.BlockHash = newBlock
+	client.setReceipt(receipt.TxHash, &moved, false)
+
+	got := provider.CheckReorgs(context.Background())
+	if len(got) != 1 || got[0].Outcome != evm.ReorgMoved || got[0].Status != accounting.JournalConfirmed || got[0].JournalID != "settle-1" {
+		t.Fatalf("events %+v, want the settlement moved", got)
+	}
+	if len(*events) != 1 || (*events)[0].TransactionHash != receipt.TxHash.Hex() {
+		t.Errorf("callback saw %+v", *events)
+	}
+	if e := journalEntry(t, journal); e.Status != accounting.JournalConfirmed || e.BlockHash != newBlock.Hex() {
+		t.Errorf("journal entry %+v, want it confirmed in block %s", e, newBlock.Hex())
+	}
+
+	// The new block is canonical from now on
+	if got := provider.CheckReorgs(context.Background()); len(got) != 0 {
+		t.Errorf("events %+v on the second check", got)
+	}
+	if stats := provider.ReorgStats(); stats.Moved != 1 || stats.Checks != 2 || stats.Watching != 1 {
+		t.Errorf("stats %+v", stats)
+	}
+}
+
+func TestReorgWatchResubmitsDroppedSettlement(t *testing.T) {
+	provider, client, journal, receipt, events := reorgSettlement(t)
+	client.replaceBlock(t, receipt)
+
+	// Back in the mempool: wait for it rather than resubmit
+	client.setReceipt(receipt.TxHash, nil, true)
+	if got := provider.CheckReorgs(context.Background()); len(got) != 0 {
+		t.Fatalf("events %+v for a settlement back in the mempool", got)
+	}
+	if e := journalEntry(t, journal); e.Status != accounting.JournalSubmitted {
+		t.Errorf("journal status %s, want submitted while pending again", e.Status)
+	}
+
+	// Dropped from the mempool too: the authorization is sent again
+	client.setReceipt(receipt.TxHash, nil, false)
+	got := provider.CheckReorgs(context.Background())
+	if len(got) != 1 || got[0].Outcome != evm.ReorgResubmitted || got[0].NewTransactionHash == "" || got[0].NewTransactionHash == receipt.TxHash.Hex() {
+		t.Fatalf("events %+v, want the settlement resubmitted", got)
+	}
+	resubmitted := common.HexToHash(got[0].NewTransactionHash)
+	if e := journalEntry(t, journal); e.Status != accounting.JournalSubmitted || e.TransactionHash != resubmitted.Hex() {
+		t.Errorf("journal entry %+v, want the resubmission pending", e)
+	}
+
+	// On the simulated chain the original still holds the nonce, so the
+	// resubmission reverts there; stage it as mined instead
+	mined, err := client.Client.TransactionReceipt(context.Background(), resubmitted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	success := *mined
+	success.Status = ethtypes.ReceiptStatusSuccessful
+	client.setReceipt(resubmitted, &success, false)
+	got = provider.CheckReorgs(context.Background())
+	if len(got) != 1 || got[0].Outcome != evm.ReorgReconfirmed || got[0].Status != accounting.JournalConfirmed {
+		t.Fatalf("events %+v, want the resubmission confirmed", got)
+	}
+	if e := journalEntry(t, journal); e.Status != accounting.JournalConfirmed || e.BlockHash != mined.BlockHash.Hex() {
+		t.Errorf("journal entry %+v, want it confirmed in block %s", e, mined.BlockHash.Hex())
+	}
+	if len(*events) != 2 {
+		t.Errorf("callback saw %d events, want the resubmission and its confirmation", len(*events))
+	}
+	if stats := provider.ReorgStats(); stats.Resubmitted != 1 || stats.Moved != 1 || stats.Errors != 0 {
+		t.Errorf("stats %+v", stats)
+	}
+}
+
+func TestReorgWatchMarksExpiredSettlementReorged(t *testing.T) {
+	clock := &offsetClock{}
+	provider, client, journal, receipt, events := reorgSettlement(t, evm.WithClock(clock))
+	client.replaceBlock(t, receipt)
+	client.setReceipt(receipt.TxHash, nil, false)
+	clock.offset.Store(int64(24 * time.Hour)) // Past validBefore
+
+	got := provider.CheckReorgs(context.Background())
+	if len(got) != 1 || got[0].Outcome != evm.ReorgLost || got[0].Status != accounting.JournalReorged {
+		t.Fatalf("events %+v, want the settlement reorged", got)
+	}
+	if e := journalEntry(t, journal); e.Status != accounting.JournalReorged || !e.Status.Final() {
+		t.Errorf("journal status %s, want reorged", e.Status)
+	}
+	if len(*events) != 1 {
+		t.Errorf("callback saw %d events", len(*events))
+	}
+	if stats := provider.ReorgStats(); stats.Reorged != 1 || stats.Watching != 0 {
+		t.Errorf("stats %+v, want the lost settlement no longer watched", stats)
+	}
+}
+
+func TestReorgWatchEndsAfterWindow(t *testing.T) {
+	clock := &offsetClock{}
+	provider, _, _, _, _ := reorgSettlement(t, evm.WithClock(clock))
+	clock.offset.Store(int64(2 * time.Hour))
+	provider.CheckReorgs(context.Background())
+	if stats := provider.ReorgStats(); stats.Watching != 0 || stats.Checks != 1 {
+		t.Errorf("stats %+v, want the settlement checked once more and dropped", stats)
+	}
+}
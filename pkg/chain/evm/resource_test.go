@@ -0,0 +1,89 @@
+package evm_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// boundRequest returns a payment of 1000 from the first account for
+// resource, its nonce derived from a resource binding as PayingClient signs it
+func boundRequest(t *testing.T, chain *testchain.Chain, resource string) *types.VerifyRequest {
+	t.Helper()
+	request := settleRequest(t, chain)
+	request.PaymentRequirements.Resource = resource
+	binding, err := types.NewResourceBinding(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := &request.PaymentPayload
+	if payload.Extra, err = types.SetExtraField(nil, types.ResourceBindingExtraKey, binding); err != nil {
+		t.Fatal(err)
+	}
+	auth := &payload.Payload.Authorization
+	if auth.Nonce, err = types.DecodeHex(binding.AuthorizationNonce()); err != nil {
+		t.Fatal(err)
+	}
+	domain := eip712.TokenDomain(testchain.Network, big.NewInt(testchain.ChainID), request.PaymentRequirements.Asset)
+	if payload.Payload.Signature, err = eip712.SignTransferWithAuthorization(auth, domain, chain.Accounts[0].Key); err != nil {
+		t.Fatal(err)
+	}
+	return &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: request.PaymentRequirements}
+}
+
+func TestStrictResourceBindingRejectsReplayAcrossRoutes(t *testing.T) {
+	chain := newTestChain(t)
+	strict, err := chain.Provider(evm.WithStrictResourceBinding())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lenient, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const cheap, expensive = "https://shop.test/cheap-endpoint", "https://shop.test/expensive-endpoint"
+	paid := boundRequest(t, chain, cheap)
+	replayed := *paid
+	replayed.PaymentRequirements.Resource = expensive
+
+	verify := func(provider *evm.Provider, request *types.VerifyRequest) *types.VerifyResponse {
+		t.Helper()
+		resp, err := provider.Verify(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	if resp := verify(strict, paid); !resp.IsValid {
+		t.Errorf("strict: payment for its own route rejected: %s", resp.Reason)
+	}
+	resp := verify(strict, &replayed)
+	if resp.IsValid || resp.ReasonCode != types.ReasonResourceMismatch || !strings.Contains(resp.Reason, expensive) {
+		t.Errorf("strict: replay on another route: valid %v, %s (%s); want %s", resp.IsValid, resp.ReasonCode, resp.Reason, types.ReasonResourceMismatch)
+	}
+	if resp.Payer == nil || !strings.EqualFold(resp.Payer.Address, chain.Accounts[0].Address.Hex()) {
+		t.Errorf("strict: rejection names payer %v", resp.Payer)
+	}
+
+	// Without strict mode the routes cannot be told apart
+	if resp := verify(lenient, &replayed); !resp.IsValid {
+		t.Errorf("lenient: replay rejected: %s", resp.Reason)
+	}
+
+	// An unbound payment (random nonce) is refused in strict mode only
+	unbound := settleRequest(t, chain)
+	unbound.PaymentRequirements.Resource = cheap
+	request := &types.VerifyRequest{PaymentPayload: unbound.PaymentPayload, PaymentRequirements: unbound.PaymentRequirements}
+	if resp := verify(strict, request); resp.IsValid || resp.ReasonCode != types.ReasonResourceMismatch {
+		t.Errorf("strict: unbound payment: valid %v, %s", resp.IsValid, resp.ReasonCode)
+	}
+	if resp := verify(lenient, request); !resp.IsValid {
+		t.Errorf("lenient: unbound payment rejected: %s", resp.Reason)
+	}
+}
@@ -0,0 +1,100 @@
+package evm
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// revertReason replays a reverted transaction via eth_call at the block it was
+// mined in and returns the decoded revert reason (empty if none could be found)
+func (p *Provider) revertReason(ctx context.Context, from common.Address, tx *types.Transaction, blockNumber *big.Int) string {
+	msg := ethereum.CallMsg{
+		From:     from,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}
+	callCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	_, err := p.client.CallContract(callCtx, msg, blockNumber)
+	if err == nil {
+		return ""
+	}
+	return decodeRevertError(err)
+}
+
+// decodeRevertError extracts a revert reason from an eth_call error
+// Nodes return the raw revert data as JSON-RPC error data; fall back to the message
+func decodeRevertError(err error) string {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			raw, decodeErr := hex.DecodeString(strings.TrimPrefix(data, "0x"))
+			if decodeErr == nil {
+				if reason, unpackErr := abi.UnpackRevert(raw); unpackErr == nil {
+					return reason
+				}
+			}
+		}
+	}
+	return strings.TrimPrefix(err.Error(), "execution reverted: ")
+}
+
+// classifyRevert maps well-known FiatToken revert reasons to facilitator errors
+func classifyRevert(reason string, payer x402types.MixedAddress) *x402types.FacilitatorError {
+	lower := strings.ToLower(reason)
+	switch {
+	case strings.Contains(lower, "authorization is used"):
+		return x402types.NewNonceAlreadyUsedError(payer)
+	case strings.Contains(lower, "invalid signature"):
+		return x402types.NewInvalidSignatureError(payer, reason)
+	case strings.Contains(lower, "authorization is expired"):
+		return x402types.NewExpiredError(payer, reason)
+	case strings.Contains(lower, "authorization is not yet valid"):
+		return x402types.NewNotYetValidError(payer, reason)
+	case strings.Contains(lower, "caller must be the payee"):
+		return &x402types.FacilitatorError{
+			Type:    "ReceiverMismatch",
+			Code:    x402types.ReasonReceiverMismatch,
+			Message: reason,
+			Payer:   &payer,
+		}
+	case strings.Contains(lower, "exceeds balance"):
+		return x402types.NewInsufficientFundsError(payer)
+	default:
+		err := x402types.NewContractCallError(reason)
+		err.Payer = &payer
+		return err
+	}
+}
+
+// revertedSettleResponse builds the failure response for a reverted settlement
+func revertedSettleResponse(reason string, payer x402types.MixedAddress) *x402types.SettleResponse {
+	if reason == "" {
+		return &x402types.SettleResponse{
+			Success:    false,
+			Error:      "transaction reverted",
+			ReasonCode: x402types.ReasonContractCallError,
+		}
+	}
+	facErr := classifyRevert(reason, payer)
+	return &x402types.SettleResponse{
+		Success:    false,
+		Error:      fmt.Sprintf("transaction reverted: %s", reason),
+		ReasonCode: facErr.Code,
+		RevertCode: facErr.Type,
+	}
+}
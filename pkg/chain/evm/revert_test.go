@@ -0,0 +1,92 @@
+package evm
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// callError is an eth_call failure carrying revert data, as nodes return it
+type callError struct {
+	message string
+	data    interface{}
+}
+
+func (e *callError) Error() string          { return e.message }
+func (e *callError) ErrorData() interface{} { return e.data }
+
+// revertData ABI-encodes a revert with the given signature and argument
+func revertData(t *testing.T, signature, typ string, value interface{}) string {
+	t.Helper()
+	argType, err := abi.NewType(typ, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := abi.Arguments{{Type: argType}}.Pack(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "0x" + hex.EncodeToString(append(crypto.Keccak256([]byte(signature))[:4], args...))
+}
+
+func TestDecodeRevertError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			"Error(string)",
+			&callError{"execution reverted", revertData(t, "Error(string)", "string", "FiatTokenV2: authorization is used or canceled")},
+			"FiatTokenV2: authorization is used or canceled",
+		},
+		{
+			"Panic(uint256)",
+			&callError{"execution reverted", revertData(t, "Panic(uint256)", "uint256", big.NewInt(0x11))},
+			"arithmetic underflow or overflow",
+		},
+		{
+			"no revert data",
+			errors.New("execution reverted: caller must be the payee"),
+			"caller must be the payee",
+		},
+		{
+			"undecodable revert data",
+			&callError{"execution reverted: custom error", "0xdeadbeef"},
+			"custom error",
+		},
+	} {
+		if got := decodeRevertError(tc.err); got != tc.want {
+			t.Errorf("%s: decoded %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRevertedSettleResponseClassifiesFiatTokenErrors(t *testing.T) {
+	payer := x402types.NewEvmAddress(common.HexToAddress("0x00000000000000000000000000000000000000a1"))
+	for reason, want := range map[string]x402types.ReasonCode{
+		"FiatTokenV2: authorization is used or canceled": x402types.ReasonNonceReused,
+		"FiatTokenV2: caller must be the payee":          x402types.ReasonReceiverMismatch,
+		"FiatTokenV2: invalid signature":                 x402types.ReasonInvalidSignature,
+		"ERC20: transfer amount exceeds balance":         x402types.ReasonInsufficientFunds,
+		"something else entirely":                        x402types.ReasonContractCallError,
+	} {
+		resp := revertedSettleResponse(reason, payer)
+		if resp.Success || resp.ReasonCode != want {
+			t.Errorf("%q: success %v, reason %q; want %q", reason, resp.Success, resp.ReasonCode, want)
+		}
+		if resp.RevertCode == "" || resp.Error != "transaction reverted: "+reason {
+			t.Errorf("%q: revert code %q, error %q", reason, resp.RevertCode, resp.Error)
+		}
+	}
+
+	if resp := revertedSettleResponse("", payer); resp.ReasonCode != x402types.ReasonContractCallError || resp.Error != "transaction reverted" {
+		t.Errorf("no reason: reason %q, error %q", resp.ReasonCode, resp.Error)
+	}
+}
@@ -0,0 +1,75 @@
+package evm_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestRefusesPayToSignerAddress(t *testing.T) {
+	chain := newTestChain(t)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	signer := chain.Signer.Address
+	requirements := chain.Requirements(signer, big.NewInt(1000))
+
+	for name, payTo := range map[string]string{
+		"checksummed": signer.Hex(),
+		"lower case":  strings.ToLower(signer.Hex()),
+	} {
+		provider, err := chain.Provider()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := requirements
+		r.PayTo = payTo
+		payload, err := chain.Authorize(chain.Accounts[0], r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: r})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.IsValid || resp.ReasonCode != types.ReasonSelfPayTo || resp.Payer == nil {
+			t.Errorf("%s: verify valid %v, reason code %q; want %q with the payer", name, resp.IsValid, resp.ReasonCode, types.ReasonSelfPayTo)
+		}
+		settled, err := provider.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: r})
+		if err == nil && settled.Success {
+			t.Errorf("%s: settled a payment to the signer", name)
+		}
+	}
+	if got, err := chain.BalanceOf(signer); err != nil || got.Sign() != 0 {
+		t.Errorf("signer holds %v USDC (%v), want the refused payments unsettled", got, err)
+	}
+}
+
+func TestAllowSelfPayToSettles(t *testing.T) {
+	chain := newTestChain(t)
+	signer := chain.Signer.Address
+	provider, err := chain.Provider(evm.WithAllowSelfPayTo())
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirements := chain.Requirements(signer, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	settled, err := provider.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil || !settled.Success {
+		t.Fatalf("settle with the override: %+v, %v", settled, err)
+	}
+	if got, err := chain.BalanceOf(signer); err != nil || got.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("signer holds %v (%v), want the 1000 paid", got, err)
+	}
+}
@@ -0,0 +1,95 @@
+package evm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs settlement transactions for one address
+//
+// Implementations may keep the key in memory (LocalSigner), in AWS KMS
+// (KMSSigner) or behind any HTTP signing service (RemoteSigner). A Signer
+// also satisfies types.HashSigner, so it can sign settlement receipts.
+type Signer interface {
+	Address() common.Address
+	// SignTx returns tx signed for chainID (EIP-155)
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	// SignHash signs a 32-byte digest, returning [R || S || V] with V of 0 or 1
+	SignHash(ctx context.Context, hash []byte) ([]byte, error)
+}
+
+// LocalSigner signs with an in-memory private key
+type LocalSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewLocalSigner wraps a private key as a Signer
+func NewLocalSigner(key *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// ParseLocalSigner creates a LocalSigner from a hex private key (0x optional)
+func ParseLocalSigner(keyHex string) (*LocalSigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(keyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return NewLocalSigner(key), nil
+}
+
+// Address implements Signer
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx implements Signer
+func (s *LocalSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), s.key)
+}
+
+// SignHash implements Signer
+func (s *LocalSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.key)
+}
+
+// signTxWithHash signs tx by having signHash sign its EIP-155 signing hash
+// (for signers that only expose digest signing)
+func signTxWithHash(ctx context.Context, signHash func(context.Context, []byte) ([]byte, error), tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	txSigner := types.NewEIP155Signer(chainID)
+	sig, err := signHash(ctx, txSigner.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(txSigner, sig)
+}
+
+// checkRecovered confirms sig over hash was produced by address
+// Remote signers are not trusted to sign with the key they claim
+func checkRecovered(hash, sig []byte, address common.Address) error {
+	pubKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature from signer: %w", err)
+	}
+	if got := crypto.PubkeyToAddress(*pubKey); got != address {
+		return fmt.Errorf("signer returned a signature by %s, expected %s", got.Hex(), address.Hex())
+	}
+	return nil
+}
+
+// signerSlot is a settlement signer plus the lock that keeps its
+// transactions' nonces in order
+type signerSlot struct {
+	Signer
+	mu  sync.Mutex
+	low atomic.Bool // Native balance below the provider's threshold
+}
@@ -0,0 +1,175 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Simulate verifies a payment and dry-runs its settlement without changing state
+//
+// The transferWithAuthorization call is gas-estimated from the signer that
+// would settle next (permit2 payments: Permit2's permitWitnessTransferFrom,
+// from the spender); no transaction is sent and no nonce is reserved.
+func (p *Provider) Simulate(ctx context.Context, request *x402types.SettleRequest) (*x402types.SimulateResponse, error) {
+	if p.verifyOnly {
+		return &x402types.SimulateResponse{
+			Valid:      false,
+			Reason:     errSettlementDisabled,
+			ReasonCode: x402types.ReasonSettlementDisabled,
+		}, nil
+	}
+
+	verifyReq := &x402types.VerifyRequest{
+		PaymentPayload:      request.PaymentPayload,
+		PaymentRequirements: request.PaymentRequirements,
+	}
+	// A simulation is not a verification Settle may skip to, so no ID is issued
+	verifyResp, err := p.verify(ctx, verifyReq, true)
+	if err != nil {
+		if facErr, ok := err.(*x402types.FacilitatorError); ok && (facErr.Type == "Timeout" || facErr.Type == "ServiceDegraded") {
+			return nil, facErr
+		}
+		return &x402types.SimulateResponse{
+			Valid:      false,
+			Reason:     fmt.Sprintf("verification failed: %v", err),
+			ReasonCode: x402types.ReasonRPCError,
+		}, nil
+	}
+	if !verifyResp.IsValid {
+		return &x402types.SimulateResponse{
+			Valid:      false,
+			Reason:     verifyResp.Reason,
+			ReasonCode: verifyResp.ReasonCode,
+			Payer:      verifyResp.Payer,
+		}, nil
+	}
+
+	payload := request.PaymentPayload.Payload
+	permit2 := request.PaymentPayload.Scheme == x402types.SchemePermit2
+	var data []byte
+	if permit2 {
+		data, err = p.packPermit2Call(request.PaymentRequirements.Asset, &payload)
+	} else {
+		data, err = p.packTransferWithAuthorization(request.PaymentRequirements.Asset, &payload)
+	}
+	if err != nil {
+		return &x402types.SimulateResponse{
+			Valid:  false,
+			Reason: err.Error(),
+			Payer:  verifyResp.Payer,
+		}, nil
+	}
+
+	// Peek at the signer the next Settle would use without advancing the index
+	signerIdx := p.pickSigner(ctx, false)
+	if signerIdx < 0 || (permit2 && !signerAllowed(ctx, payload.Permit2.Spender)) {
+		return &x402types.SimulateResponse{
+			Valid:      false,
+			Reason:     fmt.Sprintf("no signer this request may use settles on %s", p.network),
+			ReasonCode: x402types.ReasonPolicyViolation,
+			Payer:      verifyResp.Payer,
+		}, nil
+	}
+	tokenAddr := request.PaymentRequirements.Asset
+	msg := ethereum.CallMsg{
+		From: p.signerAddresses[signerIdx],
+		To:   &tokenAddr,
+		Data: data,
+	}
+	if permit2 {
+		permit2Addr := x402types.Permit2Address
+		msg.From = payload.Permit2.Spender
+		msg.To = &permit2Addr
+	}
+
+	resp := &x402types.SimulateResponse{
+		Valid: true,
+		Payer: verifyResp.Payer,
+	}
+
+	estimateCtx, cancelEstimate := p.rpcContext(ctx)
+	defer cancelEstimate()
+	gas, err := p.client.EstimateGas(estimateCtx, msg)
+	if err != nil {
+		if timeoutErr := timeoutError("estimating gas", err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		reason := decodeRevertError(err)
+		resp.Reverted = true
+		resp.RevertReason = reason
+		resp.RevertCode = classifyRevert(reason, *verifyResp.Payer).Type
+		return resp, nil
+	}
+
+	gasCtx, cancelGas := p.rpcContext(ctx)
+	defer cancelGas()
+	gasPrice, err := p.client.SuggestGasPrice(gasCtx)
+	if err != nil {
+		if timeoutErr := timeoutError("getting gas price", err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+
+	resp.EstimatedGas = gas
+	resp.GasPrice = gasPrice.String()
+	resp.EstimatedFee = new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas)).String()
+	return resp, nil
+}
+
+// EstimateSettlementFee returns the most Settle could spend on gas for
+// request (wei of the native token): the gas limits of the transactions it
+// would send at the current gas price. Nothing is simulated, so an invalid
+// payment still gets an estimate.
+func (p *Provider) EstimateSettlementFee(ctx context.Context, request *x402types.SettleRequest) (*big.Int, error) {
+	gasLimit := uint64(settleGasLimit)
+	if request.PaymentPayload.Scheme == x402types.SchemePermit2 {
+		gasLimit = permit2GasLimit
+	}
+	if extra, _ := x402types.ParseExtra(request.PaymentRequirements.Extra); extra != nil && extra.Splits != nil {
+		gasLimit += distributeGasLimit(len(extra.Splits))
+	}
+
+	gasCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	gasPrice, err := p.client.SuggestGasPrice(gasCtx)
+	if err != nil {
+		if timeoutErr := timeoutError("getting gas price", err); timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	return new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit)), nil
+}
+
+// packTransferWithAuthorization builds the calldata Settle would submit
+func (p *Provider) packTransferWithAuthorization(token common.Address, payload *x402types.ExactEvmPayload) ([]byte, error) {
+	auth := &payload.Authorization
+
+	nonce32, err := auth.Nonce.Nonce()
+	if err != nil {
+		return nil, err
+	}
+
+	sigBytes := []byte(payload.Signature)
+
+	value, ok := new(big.Int).SetString(auth.Value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid value")
+	}
+	validAfter, ok := new(big.Int).SetString(auth.ValidAfter, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid validAfter")
+	}
+	validBefore, ok := new(big.Int).SetString(auth.ValidBefore, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid validBefore")
+	}
+
+	return p.packTransferCall(token, auth.From, auth.To, value, validAfter, validBefore, nonce32, sigBytes)
+}
@@ -0,0 +1,187 @@
+package evm_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func newTestChain(t *testing.T) *testchain.Chain {
+	t.Helper()
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	return chain
+}
+
+func TestSimulateIssuesNoVerificationID(t *testing.T) {
+	chain := newTestChain(t)
+	provider, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := provider.Simulate(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Valid {
+		t.Fatalf("simulation failed: %s (%s)", resp.Reason, resp.ReasonCode)
+	}
+	if n := provider.OutstandingVerifications(); n != 0 {
+		t.Errorf("simulation left %d verification IDs outstanding, want none", n)
+	}
+
+	// Verify still issues one
+	verified, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.VerificationID == "" || provider.OutstandingVerifications() != 1 {
+		t.Errorf("verify issued ID %q, %d outstanding; want one", verified.VerificationID, provider.OutstandingVerifications())
+	}
+}
+
+// revertError is a node's answer to a call that reverted with data
+type revertError struct{ data string }
+
+func (e *revertError) Error() string          { return "execution reverted" }
+func (e *revertError) ErrorData() interface{} { return e.data }
+
+// revertingClient is the chain's client with gas estimation reverting with reason
+type revertingClient struct {
+	evm.Client
+	reason string
+}
+
+func (c *revertingClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	stringType, _ := abi.NewType("string", "", nil)
+	args, err := abi.Arguments{{Type: stringType}}.Pack(c.reason)
+	if err != nil {
+		return 0, err
+	}
+	data := append(crypto.Keccak256([]byte("Error(string)"))[:4], args...)
+	return 0, &revertError{data: "0x" + hex.EncodeToString(data)}
+}
+
+// jsonFields returns the top-level fields v marshals to
+func jsonFields(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	return fields
+}
+
+func TestSimulateEstimatesSettlementFee(t *testing.T) {
+	chain := newTestChain(t)
+	provider, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := settleRequest(t, chain)
+	// The token wants validAfter strictly before the block's timestamp
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	balance, err := chain.BalanceOf(chain.Accounts[0].Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := provider.Simulate(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Valid || resp.Reverted {
+		t.Fatalf("simulation: valid %v, reverted %v (%s)", resp.Valid, resp.Reverted, resp.Reason+resp.RevertReason)
+	}
+	gasPrice, _ := new(big.Int).SetString(resp.GasPrice, 10)
+	fee, _ := new(big.Int).SetString(resp.EstimatedFee, 10)
+	if resp.EstimatedGas == 0 || gasPrice == nil || fee == nil || fee.Cmp(new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(resp.EstimatedGas))) != 0 {
+		t.Errorf("estimate: gas %d, gas price %q, fee %q; want fee = gas x gas price", resp.EstimatedGas, resp.GasPrice, resp.EstimatedFee)
+	}
+	fields := jsonFields(t, resp)
+	for _, field := range []string{"valid", "payer", "estimated_gas", "gas_price", "estimated_fee", "reverted"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("simulation JSON lacks %q: %v", field, fields)
+		}
+	}
+
+	// Nothing moved and the nonce is still free
+	after, err := chain.BalanceOf(chain.Accounts[0].Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Cmp(balance) != 0 {
+		t.Errorf("payer balance went from %s to %s during a simulation", balance, after)
+	}
+	nonce, err := request.PaymentPayload.Payload.Authorization.Nonce.Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used, err := chain.AuthorizationUsed(chain.Accounts[0].Address, nonce); err != nil || used {
+		t.Errorf("simulation used the nonce (err %v)", err)
+	}
+	settled, err := provider.Settle(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settled.Success {
+		t.Errorf("settling a simulated payment failed: %s", settled.Error)
+	}
+}
+
+func TestSimulateReportsDecodedRevert(t *testing.T) {
+	chain := newTestChain(t)
+	options := chain.Options()
+	options.Client = &revertingClient{Client: options.Client, reason: "FiatTokenV2: authorization is used or canceled"}
+	provider, err := evm.New(testchain.Network, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := provider.Simulate(context.Background(), settleRequest(t, chain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Valid || !resp.Reverted {
+		t.Fatalf("simulation: valid %v, reverted %v; want a valid payment whose transfer reverts", resp.Valid, resp.Reverted)
+	}
+	if resp.RevertReason != "FiatTokenV2: authorization is used or canceled" || resp.RevertCode == "" {
+		t.Errorf("revert reason %q, code %q", resp.RevertReason, resp.RevertCode)
+	}
+	fields := jsonFields(t, resp)
+	for _, field := range []string{"valid", "reverted", "revert_reason", "revert_code"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("simulation JSON lacks %q: %v", field, fields)
+		}
+	}
+	if _, ok := fields["estimated_fee"]; ok {
+		t.Error("a reverted simulation carries a fee estimate")
+	}
+}
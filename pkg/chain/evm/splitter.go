@@ -0,0 +1,84 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// WithSplitterContract sets the facilitator-operated payment splitter for the network
+// Payments whose requirements carry splits must be addressed to this contract
+func WithSplitterContract(addr common.Address) ProviderOption {
+	return func(o *providerOptions) {
+		o.splitter = addr
+	}
+}
+
+// verifySplits checks a split payment is routed through the splitter contract
+// Returns a non-empty reason if the payment must be rejected
+func (p *Provider) verifySplits(requirements *x402types.PaymentRequirements) string {
+	splits, err := x402types.ParseSplits(requirements.Extra)
+	if err != nil {
+		return fmt.Sprintf("invalid splits: %v", err)
+	}
+	if splits == nil {
+		return ""
+	}
+	if p.splitter == (common.Address{}) {
+		return "payout splits not supported on this network"
+	}
+	if !strings.EqualFold(requirements.PayTo, p.splitter.Hex()) {
+		return fmt.Sprintf("split payments must be paid to the splitter contract %s", p.splitter.Hex())
+	}
+	return ""
+}
+
+// distributeSplits pays out funds held by the splitter after a settlement
+// Must only be called once the transferWithAuthorization has been mined
+func (p *Provider) distributeSplits(ctx context.Context, signer *signerSlot, token common.Address, value *big.Int, splits []x402types.PayoutSplit) (*types.Transaction, error) {
+	recipients := make([]common.Address, len(splits))
+	for i, split := range splits {
+		recipients[i] = split.To
+	}
+	amounts := x402types.SplitAmounts(value, splits)
+
+	data, err := p.splitterABI.Pack("distribute", token, recipients, amounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack distribute: %w", err)
+	}
+
+	tx, err := p.sendContractTx(ctx, signer, p.splitter, data, distributeGasLimit(len(splits)))
+	if err != nil {
+		return nil, err
+	}
+
+	confirmCtx, cancel := p.confirmContext(ctx)
+	defer cancel()
+	receipt, err := bind.WaitMined(confirmCtx, p.client, tx)
+	if err != nil {
+		return tx, fmt.Errorf("waiting for distribute tx failed: %w", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return tx, fmt.Errorf("distribute transaction reverted")
+	}
+	return tx, nil
+}
+
+// distributeGasLimit is the gas limit of distributing to n recipients:
+// ~35k per ERC-20 transfer plus call overhead
+func distributeGasLimit(n int) uint64 {
+	return uint64(60000 + 40000*n)
+}
+
+// loadSplitterABI loads the payment splitter ABI
+func loadSplitterABI() (abi.ABI, error) {
+	const splitterABIJSON = `[{"inputs":[{"internalType":"address","name":"token","type":"address"},{"internalType":"address[]","name":"recipients","type":"address[]"},{"internalType":"uint256[]","name":"amounts","type":"uint256[]"}],"name":"distribute","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+	return abi.JSON(strings.NewReader(splitterABIJSON))
+}
@@ -0,0 +1,119 @@
+package evm_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// sendRecorder is the chain's client recording the transactions sent through it
+type sendRecorder struct {
+	evm.Client
+
+	mu   sync.Mutex
+	sent []*ethtypes.Transaction
+}
+
+func (c *sendRecorder) SendTransaction(ctx context.Context, tx *ethtypes.Transaction) error {
+	c.mu.Lock()
+	c.sent = append(c.sent, tx)
+	c.mu.Unlock()
+	return c.Client.SendTransaction(ctx, tx)
+}
+
+var splitter = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+
+// splitRequirements charges 1000 paid to the splitter and shared 30/70
+func splitRequirements(t *testing.T, chain *testchain.Chain) types.PaymentRequirements {
+	t.Helper()
+	requirements := chain.Requirements(splitter, big.NewInt(1000))
+	extra, err := types.MergeExtra(requirements.Extra, types.RequirementsExtra{Splits: []types.PayoutSplit{
+		{To: common.HexToAddress("0x00000000000000000000000000000000000000a1"), Bps: 3000},
+		{To: common.HexToAddress("0x00000000000000000000000000000000000000a2"), Bps: 7000},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirements.Extra = extra
+	return requirements
+}
+
+func TestSettleDistributesSplitsAfterTransfer(t *testing.T) {
+	chain := newTestChain(t)
+	options := chain.Options()
+	recorder := &sendRecorder{Client: options.Client}
+	options.Client = recorder
+	provider, err := evm.New(testchain.Network, options, evm.WithSplitterContract(splitter))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requirements := splitRequirements(t, chain)
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := provider.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Success || resp.SplitTransactionHash == nil {
+		t.Fatalf("settle: %+v, want a success with a split transaction", resp)
+	}
+
+	transfer := crypto.Keccak256([]byte("transferWithAuthorization(address,address,uint256,uint256,uint256,bytes32,bytes)"))[:4]
+	distribute := crypto.Keccak256([]byte("distribute(address,address[],uint256[])"))[:4]
+	if len(recorder.sent) != 2 {
+		t.Fatalf("%d transactions sent, want the transfer then the distribution", len(recorder.sent))
+	}
+	first, second := recorder.sent[0], recorder.sent[1]
+	if *first.To() != testchain.TokenAddress || !bytes.HasPrefix(first.Data(), transfer) {
+		t.Errorf("first transaction to %s, want transferWithAuthorization on the token", first.To().Hex())
+	}
+	if *second.To() != splitter || !bytes.HasPrefix(second.Data(), distribute) {
+		t.Errorf("second transaction to %s, want distribute on the splitter", second.To().Hex())
+	}
+	if resp.SplitTransactionHash.Hash != second.Hash().Hex() {
+		t.Errorf("split transaction %s, want %s", resp.SplitTransactionHash.Hash, second.Hash().Hex())
+	}
+}
+
+func TestVerifyRequiresSplitsPaidToSplitter(t *testing.T) {
+	chain := newTestChain(t)
+	requirements := splitRequirements(t, chain)
+	requirements.PayTo = "0x00000000000000000000000000000000000000b0"
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, opts := range map[string][]evm.ProviderOption{
+		"no splitter":    nil,
+		"other splitter": {evm.WithSplitterContract(splitter)},
+	} {
+		provider, err := chain.Provider(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.IsValid || resp.ReasonCode != types.ReasonInvalidSplits {
+			t.Errorf("%s: valid %v, reason %s; want %s", name, resp.IsValid, resp.ReasonCode, types.ReasonInvalidSplits)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+const (
+	// DefaultVerifyRPCTimeout bounds each individual RPC call made while verifying
+	DefaultVerifyRPCTimeout = 5 * time.Second
+	// DefaultSettleConfirmTimeout bounds waiting for a settlement to be mined
+	DefaultSettleConfirmTimeout = 10 * time.Second
+)
+
+// WithRPCTimeouts sets per-operation deadlines for RPC calls
+// rpcTimeout applies to each single call (balance, gas price, send, ...),
+// confirmTimeout to waiting for the settlement receipt. Zero keeps the default.
+func WithRPCTimeouts(rpcTimeout, confirmTimeout time.Duration) ProviderOption {
+	return func(o *providerOptions) {
+		if rpcTimeout > 0 {
+			o.rpcTimeout = rpcTimeout
+		}
+		if confirmTimeout > 0 {
+			o.confirmTimeout = confirmTimeout
+		}
+	}
+}
+
+// rpcContext derives a per-call deadline from the caller's context
+func (p *Provider) rpcContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, p.rpcTimeout)
+}
+
+// confirmContext derives the deadline for waiting on a receipt
+func (p *Provider) confirmContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, p.confirmTimeout)
+}
+
+// timeoutError converts deadline/cancellation failures into a typed Timeout
+// error so callers can tell a slow RPC apart from an invalid payment
+func timeoutError(op string, err error) *x402types.FacilitatorError {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return x402types.NewTimeoutError(fmt.Sprintf("%s: %v", op, err))
+	}
+	return nil
+}
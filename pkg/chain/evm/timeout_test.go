@@ -0,0 +1,121 @@
+package evm_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// hangingClient is the chain's client with reads (or receipts only) never
+// answering until the caller gives up
+type hangingClient struct {
+	evm.Client
+	receiptsOnly bool
+}
+
+func (c *hangingClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	if c.receiptsOnly {
+		return c.Client.CallContract(ctx, msg, block)
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *hangingClient) BalanceAt(ctx context.Context, account common.Address, block *big.Int) (*big.Int, error) {
+	if c.receiptsOnly {
+		return c.Client.BalanceAt(ctx, account, block)
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *hangingClient) TransactionReceipt(ctx context.Context, hash common.Hash) (*ethtypes.Receipt, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func hangingProvider(t *testing.T, chain *testchain.Chain, receiptsOnly bool, opts ...evm.ProviderOption) *evm.Provider {
+	t.Helper()
+	options := chain.Options()
+	options.Client = &hangingClient{Client: options.Client, receiptsOnly: receiptsOnly}
+	provider, err := evm.New(testchain.Network, options, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+// settleRequest returns a payment of 1000 from the first account
+func settleRequest(t *testing.T, chain *testchain.Chain) *types.SettleRequest {
+	t.Helper()
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements}
+}
+
+// checkTimeout fails unless err is a typed Timeout returned within limit of start
+func checkTimeout(t *testing.T, op string, err error, start time.Time, limit time.Duration) {
+	t.Helper()
+	if elapsed := time.Since(start); elapsed > limit {
+		t.Errorf("%s took %v, want it abandoned within %v", op, elapsed, limit)
+	}
+	var facErr *types.FacilitatorError
+	if !errors.As(err, &facErr) || facErr.Code != types.ReasonTimeout {
+		t.Errorf("%s: error %v, want a Timeout", op, err)
+	}
+}
+
+func TestVerifyTimesOutHangingRPC(t *testing.T) {
+	chain := newTestChain(t)
+	provider := hangingProvider(t, chain, false, evm.WithRPCTimeouts(50*time.Millisecond, 0))
+	request := settleRequest(t, chain)
+
+	start := time.Now()
+	_, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+	checkTimeout(t, "verify against a hanging node", err, start, 2*time.Second)
+}
+
+func TestVerifyStopsWhenCallerCancels(t *testing.T) {
+	chain := newTestChain(t)
+	provider := hangingProvider(t, chain, false) // Default per-call timeout of seconds
+	request := settleRequest(t, chain)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := provider.Verify(ctx, &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+	checkTimeout(t, "verify with a cancelled request", err, start, time.Second)
+}
+
+func TestSettleTimesOutWaitingForReceipt(t *testing.T) {
+	chain := newTestChain(t)
+	provider := hangingProvider(t, chain, true, evm.WithRPCTimeouts(0, 100*time.Millisecond))
+
+	start := time.Now()
+	_, err := provider.Settle(context.Background(), settleRequest(t, chain))
+	checkTimeout(t, "settle whose receipt never arrives", err, start, 2*time.Second)
+}
+
+func TestSettleStopsWaitingWhenCallerCancels(t *testing.T) {
+	chain := newTestChain(t)
+	provider := hangingProvider(t, chain, true) // Default confirm timeout of seconds
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := provider.Settle(ctx, settleRequest(t, chain))
+	checkTimeout(t, "settle with a cancelled request", err, start, time.Second)
+}
@@ -0,0 +1,159 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/network"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultTokenInfoTTL is how long token metadata read from the chain is cached
+const DefaultTokenInfoTTL = 24 * time.Hour
+
+// tokenInfoRetry is how long a failed metadata lookup is remembered before
+// the token is asked again
+const tokenInfoRetry = time.Minute
+
+// TokenInfo is the metadata amounts of a token are displayed and parsed with
+type TokenInfo struct {
+	Symbol   string
+	Decimals uint8
+}
+
+// WithTokenInfoTTL sets how long token metadata read from the chain is
+// cached (default: DefaultTokenInfoTTL)
+func WithTokenInfoTTL(ttl time.Duration) ProviderOption {
+	return func(o *providerOptions) {
+		if ttl > 0 {
+			o.tokenInfoTTL = ttl
+		}
+	}
+}
+
+// tokenInfoCache holds the metadata of tokens outside the registry
+type tokenInfoCache struct {
+	mu      sync.Mutex
+	entries map[common.Address]tokenInfoEntry
+}
+
+type tokenInfoEntry struct {
+	info      TokenInfo
+	err       error // Lookup failure, remembered for tokenInfoRetry
+	expiresAt time.Time
+}
+
+// TokenInfo returns the symbol and decimals of token: those of the
+// network's registered USDC deployment, or else read with the token's
+// symbol() and decimals() on first use and cached
+func (p *Provider) TokenInfo(ctx context.Context, token common.Address) (TokenInfo, error) {
+	if deployment, err := network.GetUSDCDeployment(p.network); err == nil && deployment.TokenAddress == token {
+		return TokenInfo{Symbol: deployment.TokenSymbol, Decimals: deployment.Decimals}, nil
+	}
+
+	now := p.clock.Now()
+	cache := &p.tokenInfo
+	cache.mu.Lock()
+	entry, ok := cache.entries[token]
+	cache.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.info, entry.err
+	}
+
+	info, err := p.fetchTokenInfo(ctx, token)
+	entry = tokenInfoEntry{info: info, err: err, expiresAt: now.Add(p.tokenInfoTTL)}
+	if err != nil {
+		// Not remembered if the caller gave up: the token was never asked
+		if ctx.Err() != nil {
+			return TokenInfo{}, err
+		}
+		log.Printf("evm: cannot read metadata of token %s on %s, showing raw units: %v", token.Hex(), p.network, err)
+		entry.expiresAt = now.Add(tokenInfoRetry)
+	}
+	cache.mu.Lock()
+	cache.entries[token] = entry
+	cache.mu.Unlock()
+	return info, err
+}
+
+// fetchTokenInfo reads token's decimals() and symbol()
+func (p *Provider) fetchTokenInfo(ctx context.Context, token common.Address) (TokenInfo, error) {
+	decimals, err := p.callToken(ctx, token, "decimals")
+	if err != nil {
+		return TokenInfo{}, err
+	}
+	var info TokenInfo
+	if err := p.erc20ABI.UnpackIntoInterface(&info.Decimals, "decimals", decimals); err != nil {
+		return TokenInfo{}, fmt.Errorf("failed to unpack decimals result: %w", err)
+	}
+
+	symbol, err := p.callToken(ctx, token, "symbol")
+	if err != nil {
+		return TokenInfo{}, err
+	}
+	if err := p.erc20ABI.UnpackIntoInterface(&info.Symbol, "symbol", symbol); err != nil {
+		// Some early tokens (e.g. MKR) return the symbol as bytes32
+		if len(symbol) != 32 {
+			return TokenInfo{}, fmt.Errorf("failed to unpack symbol result: %w", err)
+		}
+		info.Symbol = string(bytes.TrimRight(symbol, "\x00"))
+	}
+	return info, nil
+}
+
+// callToken calls the argument-less ERC-20 view method on token
+func (p *Provider) callToken(ctx context.Context, token common.Address, method string) ([]byte, error) {
+	data, err := p.erc20ABI.Pack(method)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s: %w", method, err)
+	}
+	callCtx, cancel := p.rpcContext(ctx)
+	defer cancel()
+	result, err := p.client.CallContract(callCtx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s call failed: %w", method, err)
+	}
+	return result, nil
+}
+
+// FormatAmount writes amount of token in whole tokens, e.g. "1.5 DAI", or
+// in smallest units if the token's metadata cannot be read
+func (p *Provider) FormatAmount(ctx context.Context, token common.Address, amount *big.Int) string {
+	info, err := p.TokenInfo(ctx, token)
+	if err != nil {
+		return amount.String() + " (smallest units)"
+	}
+	return x402types.FormatDecimalAmount(amount, info.Decimals) + " " + info.Symbol
+}
+
+// ParseAmount converts a decimal amount of token (e.g. "0.025") to smallest
+// units with the token's decimals
+func (p *Provider) ParseAmount(ctx context.Context, token common.Address, amount string) (*big.Int, error) {
+	info, err := p.TokenInfo(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("decimals of token %s unknown: %w", token.Hex(), err)
+	}
+	return x402types.ParseDecimalAmount(amount, info.Decimals)
+}
+
+// WhitelistedAssets returns the tokens of Options.AssetWhitelist, sorted
+// (nil if the provider uses DefaultAssetWhitelist)
+func (p *Provider) WhitelistedAssets() []common.Address {
+	if !p.customAssets {
+		return nil
+	}
+	assets := make([]common.Address, 0, len(p.assetWhitelist))
+	for asset := range p.assetWhitelist {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return bytes.Compare(assets[i][:], assets[j][:]) < 0 })
+	return assets
+}
@@ -0,0 +1,169 @@
+package evm_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+)
+
+// Tokens outside the registry, as the metadata client answers for them
+var (
+	daiToken    = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F") // string symbol, 18 decimals
+	mkrToken    = common.HexToAddress("0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2") // bytes32 symbol
+	brokenToken = common.HexToAddress("0x00000000000000000000000000000000000000ee") // reverts
+)
+
+// metadataClient answers decimals() and symbol() of the test tokens,
+// counting the calls per token, and passes other calls on to the chain
+type metadataClient struct {
+	evm.Client
+	mu    sync.Mutex
+	calls map[common.Address]int
+}
+
+func (c *metadataClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	if msg.To == nil || (*msg.To != daiToken && *msg.To != mkrToken && *msg.To != brokenToken) {
+		return c.Client.CallContract(ctx, msg, block)
+	}
+	c.mu.Lock()
+	c.calls[*msg.To]++
+	c.mu.Unlock()
+
+	decimals, symbol := crypto.Keccak256([]byte("decimals()"))[:4], crypto.Keccak256([]byte("symbol()"))[:4]
+	word := func(v int64) []byte { return math.U256Bytes(big.NewInt(v)) }
+	switch {
+	case *msg.To == brokenToken:
+		return nil, errors.New("execution reverted")
+	case bytes.Equal(msg.Data, decimals) && *msg.To == daiToken:
+		return word(18), nil
+	case bytes.Equal(msg.Data, decimals):
+		return word(8), nil
+	case bytes.Equal(msg.Data, symbol) && *msg.To == daiToken:
+		return append(append(word(32), word(3)...), common.RightPadBytes([]byte("DAI"), 32)...), nil
+	case bytes.Equal(msg.Data, symbol):
+		return common.RightPadBytes([]byte("MKR"), 32), nil
+	}
+	return nil, errors.New("unexpected call")
+}
+
+func (c *metadataClient) callsTo(token common.Address) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[token]
+}
+
+// metadataProvider whitelists the test tokens beside the chain's own
+func metadataProvider(t *testing.T, clock *offsetClock) (*evm.Provider, *metadataClient) {
+	t.Helper()
+	chain := newTestChain(t)
+	client := &metadataClient{Client: chain.Client(), calls: make(map[common.Address]int)}
+	options := chain.Options()
+	options.Client = client
+	options.AssetWhitelist = []common.Address{testchain.TokenAddress, mkrToken, daiToken, brokenToken}
+	provider, err := evm.New(testchain.Network, options, evm.WithClock(clock), evm.WithTokenInfoTTL(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, client
+}
+
+func TestTokenInfoReadsAndCachesMetadata(t *testing.T) {
+	clock := &offsetClock{}
+	provider, client := metadataProvider(t, clock)
+	ctx := context.Background()
+
+	for token, want := range map[common.Address]evm.TokenInfo{
+		daiToken: {Symbol: "DAI", Decimals: 18},
+		mkrToken: {Symbol: "MKR", Decimals: 8},
+	} {
+		for i := 0; i < 3; i++ {
+			info, err := provider.TokenInfo(ctx, token)
+			if err != nil || info != want {
+				t.Fatalf("%s lookup %d: %+v (%v), want %+v", token.Hex(), i+1, info, err, want)
+			}
+		}
+		// decimals() and symbol(), once
+		if n := client.callsTo(token); n != 2 {
+			t.Errorf("%s: %d calls for three lookups, want the two of the first", token.Hex(), n)
+		}
+	}
+
+	// Until the TTL runs out
+	clock.offset.Store(int64(time.Hour + time.Second))
+	if _, err := provider.TokenInfo(ctx, daiToken); err != nil {
+		t.Fatal(err)
+	}
+	if n := client.callsTo(daiToken); n != 4 {
+		t.Errorf("%d calls after the TTL, want the metadata read again", n)
+	}
+
+	got, err := provider.ParseAmount(ctx, daiToken, "0.025")
+	if err != nil || got.String() != "25000000000000000" {
+		t.Errorf("ParseAmount 0.025 DAI = %v (%v)", got, err)
+	}
+	if s := provider.FormatAmount(ctx, daiToken, big.NewInt(1_500_000_000_000_000_000)); s != "1.5 DAI" {
+		t.Errorf("FormatAmount = %q, want 1.5 DAI", s)
+	}
+}
+
+func TestTokenInfoFallsBackToRawUnits(t *testing.T) {
+	clock := &offsetClock{}
+	provider, client := metadataProvider(t, clock)
+	ctx := context.Background()
+
+	var logs strings.Builder
+	out := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(out) })
+
+	for i := 0; i < 3; i++ {
+		if _, err := provider.TokenInfo(ctx, brokenToken); err == nil {
+			t.Fatal("metadata of a reverting token was read")
+		}
+	}
+	if s := provider.FormatAmount(ctx, brokenToken, big.NewInt(1500)); s != "1500 (smallest units)" {
+		t.Errorf("FormatAmount = %q, want raw units", s)
+	}
+	if _, err := provider.ParseAmount(ctx, brokenToken, "1"); err == nil {
+		t.Error("parsed an amount of a token with unknown decimals")
+	}
+	// The failure is remembered (and warned about) for a minute, then retried
+	if n := client.callsTo(brokenToken); n != 1 || strings.Count(logs.String(), "showing raw units") != 1 {
+		t.Errorf("%d calls and warnings %q for repeated lookups, want one of each", n, logs.String())
+	}
+	clock.offset.Store(int64(time.Minute + time.Second))
+	provider.TokenInfo(ctx, brokenToken)
+	if n := client.callsTo(brokenToken); n != 2 {
+		t.Errorf("%d calls a minute later, want the lookup retried", n)
+	}
+
+	// A lookup the caller gave up on is not remembered
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	provider.TokenInfo(cancelled, daiToken)
+	if info, err := provider.TokenInfo(ctx, daiToken); err != nil || info.Symbol != "DAI" {
+		t.Errorf("after a cancelled lookup: %+v (%v)", info, err)
+	}
+
+	// Registered tokens need no call
+	if info, err := provider.TokenInfo(ctx, testchain.TokenAddress); err != nil || info.Decimals != 6 || client.callsTo(testchain.TokenAddress) != 0 {
+		t.Errorf("registered token: %+v (%v) after %d calls", info, err, client.callsTo(testchain.TokenAddress))
+	}
+
+	if assets := provider.WhitelistedAssets(); len(assets) != 4 || bytes.Compare(assets[0][:], assets[1][:]) >= 0 {
+		t.Errorf("whitelisted assets %v, want all four, sorted", assets)
+	}
+}
@@ -0,0 +1,58 @@
+package evm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/transport"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// nullRPC answers every JSON-RPC call with a null result
+func nullRPC(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": nil})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRPCClientReusesConnections(t *testing.T) {
+	server := nullRPC(t)
+	var stats transport.ConnStats
+	provider, err := evm.New(types.NetworkBaseSepolia,
+		evm.Options{RPCURL: server.URL, ChainID: big.NewInt(84532)},
+		evm.WithVerifyOnly(),
+		evm.WithTransport(transport.Config{OnConn: stats.Observe}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const calls = 100
+	for i := 0; i < calls; i++ {
+		if _, err := provider.TransactionReceipt(context.Background(), common.Hash{byte(i)}); !errors.Is(err, ethereum.NotFound) {
+			t.Fatalf("call %d: %v, want NotFound", i, err)
+		}
+	}
+	if stats.Opened() != 1 || stats.Reused() != calls-1 {
+		t.Errorf("%d connections opened, %d reused; want 1 and %d", stats.Opened(), stats.Reused(), calls-1)
+	}
+}
@@ -0,0 +1,121 @@
+package evm
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultVerificationTTL is how long a verification ID returned by Verify
+// lets Settle skip the signature and balance checks
+const DefaultVerificationTTL = 30 * time.Second
+
+// maxVerifications bounds the outstanding verification IDs per provider
+const maxVerifications = 10000
+
+// WithVerificationTTL sets how long verification IDs stay valid (default:
+// DefaultVerificationTTL); a negative ttl stops issuing them, so every
+// Settle re-verifies in full
+func WithVerificationTTL(ttl time.Duration) ProviderOption {
+	return func(o *providerOptions) {
+		if ttl != 0 {
+			o.verificationTTL = ttl
+		}
+	}
+}
+
+// verificationCache remembers successful verifications so a settle that
+// follows shortly after needs no second signature recovery or balance RPC.
+// IDs are only honored if issued here: a client cannot mint one.
+type verificationCache struct {
+	secret  [32]byte
+	mu      sync.Mutex
+	entries map[string]verificationEntry
+}
+
+// verificationEntry binds an ID to the payload and requirements it was issued for
+type verificationEntry struct {
+	binding   common.Hash
+	expiresAt time.Time
+}
+
+func newVerificationCache() *verificationCache {
+	cache := &verificationCache{entries: make(map[string]verificationEntry)}
+	rand.Read(cache.secret[:])
+	return cache
+}
+
+// verificationBinding hashes the canonical payload and requirements of a request
+func verificationBinding(payload *x402types.PaymentPayload, requirements *x402types.PaymentRequirements) (common.Hash, bool) {
+	payloadHash, err := x402types.HashPayload(payload)
+	if err != nil {
+		return common.Hash{}, false
+	}
+	requirementsHash, err := x402types.HashRequirements(requirements)
+	if err != nil {
+		return common.Hash{}, false
+	}
+	return crypto.Keccak256Hash(payloadHash[:], requirementsHash[:]), true
+}
+
+// issueVerification returns a fresh verification ID for a request that just
+// verified ("" if IDs are disabled or this provider cannot settle)
+func (p *Provider) issueVerification(request *x402types.VerifyRequest) string {
+	if p.verificationTTL <= 0 || p.verifyOnly {
+		return ""
+	}
+	binding, ok := verificationBinding(&request.PaymentPayload, &request.PaymentRequirements)
+	if !ok {
+		return ""
+	}
+	expiresAt := p.clock.Now().Add(p.verificationTTL)
+
+	cache := p.verifications
+	var expiry [8]byte
+	binary.BigEndian.PutUint64(expiry[:], uint64(expiresAt.UnixNano()))
+	id := hexutil.Encode(crypto.Keccak256(binding[:], expiry[:], cache.secret[:]))
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if len(cache.entries) >= maxVerifications {
+		now := p.clock.Now()
+		for key, entry := range cache.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(cache.entries, key)
+			}
+		}
+		// Still full: forget an arbitrary entry rather than grow unbounded
+		for key := range cache.entries {
+			if len(cache.entries) < maxVerifications {
+				break
+			}
+			delete(cache.entries, key)
+		}
+	}
+	cache.entries[id] = verificationEntry{binding: binding, expiresAt: expiresAt}
+	return id
+}
+
+// consumeVerification reports whether request carries a fresh verification ID
+// issued for exactly its payload and requirements; IDs are single-use
+func (p *Provider) consumeVerification(request *x402types.SettleRequest) bool {
+	if request.VerificationID == "" || p.verificationTTL <= 0 {
+		return false
+	}
+	cache := p.verifications
+	cache.mu.Lock()
+	entry, ok := cache.entries[request.VerificationID]
+	delete(cache.entries, request.VerificationID)
+	cache.mu.Unlock()
+	if !ok || !p.clock.Now().Before(entry.expiresAt) {
+		return false
+	}
+	binding, ok := verificationBinding(&request.PaymentPayload, &request.PaymentRequirements)
+	return ok && binding == entry.binding
+}
@@ -0,0 +1,208 @@
+package evm_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// callCountingClient is the chain's client counting contract calls (the
+// balance and authorization-state reads a full verification makes)
+type callCountingClient struct {
+	evm.Client
+	calls atomic.Int64
+}
+
+func (c *callCountingClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	c.calls.Add(1)
+	return c.Client.CallContract(ctx, msg, block)
+}
+
+// verificationProvider settles on chain through a call-counting client
+func verificationProvider(t *testing.T, chain *testchain.Chain, opts ...evm.ProviderOption) (*evm.Provider, *callCountingClient) {
+	t.Helper()
+	client := &callCountingClient{Client: chain.Client()}
+	options := chain.Options()
+	options.Client = client
+	provider, err := evm.New(testchain.Network, options, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, client
+}
+
+// settleCalls settles request, returning the response and the contract calls it made
+func settleCalls(t *testing.T, provider *evm.Provider, client *callCountingClient, request *types.SettleRequest) (*types.SettleResponse, int64) {
+	t.Helper()
+	before := client.calls.Load()
+	resp, err := provider.Settle(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, client.calls.Load() - before
+}
+
+// verifyForID verifies request and returns the verification ID issued
+func verifyForID(t *testing.T, provider *evm.Provider, request *types.SettleRequest) string {
+	t.Helper()
+	resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+	if err != nil || !resp.IsValid {
+		t.Fatalf("verify: %+v, %v", resp, err)
+	}
+	if resp.VerificationID == "" {
+		t.Fatal("a valid verification carries no verification ID")
+	}
+	return resp.VerificationID
+}
+
+func TestSettleWithVerificationIDSkipsFullVerify(t *testing.T) {
+	chain := newTestChain(t)
+	provider, client := verificationProvider(t, chain)
+
+	// Baseline: a settle without an ID re-verifies in full
+	plain := settleRequest(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	resp, full := settleCalls(t, provider, client, plain)
+	if !resp.Success {
+		t.Fatalf("settle: %+v", resp)
+	}
+
+	request := settleRequest(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	request.VerificationID = verifyForID(t, provider, request)
+	resp, skipped := settleCalls(t, provider, client, request)
+	if !resp.Success {
+		t.Fatalf("settle with a verification ID: %+v", resp)
+	}
+	if skipped >= full {
+		t.Errorf("settle with a verification ID made %d contract calls, settle without %d; want fewer", skipped, full)
+	}
+	if n := provider.OutstandingVerifications(); n != 0 {
+		t.Errorf("%d verification IDs outstanding, want the used one consumed", n)
+	}
+
+	// The nonce is still checked: replaying the settled payload fails
+	replay := *request
+	replay.VerificationID = verifyForReplay(t, provider, request)
+	if resp, _ := settleCalls(t, provider, client, &replay); resp.Success {
+		t.Error("settled the same authorization twice")
+	}
+}
+
+// verifyForReplay returns the ID of a verification of request, or "" when
+// the provider already refuses it (as it should once it settled)
+func verifyForReplay(t *testing.T, provider *evm.Provider, request *types.SettleRequest) string {
+	t.Helper()
+	resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.VerificationID
+}
+
+func TestVerificationIDFallsBackToFullVerify(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		stale func(t *testing.T, chain *testchain.Chain, provider *evm.Provider, clock *offsetClock, request *types.SettleRequest)
+	}{
+		{"expired", func(t *testing.T, chain *testchain.Chain, provider *evm.Provider, clock *offsetClock, request *types.SettleRequest) {
+			request.VerificationID = verifyForID(t, provider, request)
+			clock.offset.Store(int64(2 * time.Second))
+		}},
+		{"reused", func(t *testing.T, chain *testchain.Chain, provider *evm.Provider, clock *offsetClock, request *types.SettleRequest) {
+			// A failed settle consumes the ID all the same
+			id := verifyForID(t, provider, request)
+			underpaid := *request
+			underpaid.VerificationID = id
+			underpaid.PaymentRequirements.MaxAmountRequired = "2000"
+			if resp, err := provider.Settle(context.Background(), &underpaid); err != nil || resp.Success {
+				t.Fatalf("underpaid settle: %+v, %v", resp, err)
+			}
+			request.VerificationID = id
+		}},
+		{"forged", func(t *testing.T, chain *testchain.Chain, provider *evm.Provider, clock *offsetClock, request *types.SettleRequest) {
+			request.VerificationID = "0x" + strings.Repeat("ab", 32)
+		}},
+		{"issued for another payload", func(t *testing.T, chain *testchain.Chain, provider *evm.Provider, clock *offsetClock, request *types.SettleRequest) {
+			request.VerificationID = verifyForID(t, provider, settleRequest(t, chain))
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := newTestChain(t)
+			clock := &offsetClock{}
+			provider, client := verificationProvider(t, chain, evm.WithClock(clock), evm.WithVerificationTTL(time.Second))
+			baseline := settleRequest(t, chain)
+			request := settleRequest(t, chain)
+			if err := chain.AdjustTime(time.Second); err != nil {
+				t.Fatal(err)
+			}
+			_, full := settleCalls(t, provider, client, baseline)
+
+			tc.stale(t, chain, provider, clock, request)
+			resp, calls := settleCalls(t, provider, client, request)
+			if !resp.Success {
+				t.Fatalf("settle: %+v", resp)
+			}
+			if calls != full {
+				t.Errorf("settle made %d contract calls, want the %d of a full verification", calls, full)
+			}
+		})
+	}
+}
+
+func TestVerificationIDRejectsMutatedPayload(t *testing.T) {
+	chain := newTestChain(t)
+	provider, _ := verificationProvider(t, chain)
+	request := settleRequest(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	request.VerificationID = verifyForID(t, provider, request)
+
+	// Pay someone else with the ID of the honest payment
+	mutated := *request
+	mutated.PaymentPayload.Payload.Authorization.Value = "999"
+	mutated.PaymentRequirements.MaxAmountRequired = "999"
+	resp, err := provider.Settle(context.Background(), &mutated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Success || resp.ReasonCode != types.ReasonInvalidSignature {
+		t.Errorf("mutated payload with a verification ID: %+v, want invalid_signature", resp)
+	}
+	nonce, err := request.PaymentPayload.Payload.Authorization.Nonce.Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used, err := chain.AuthorizationUsed(chain.Accounts[0].Address, nonce); err != nil || used {
+		t.Errorf("authorization used %v (%v) by a mutated payload", used, err)
+	}
+}
+
+func TestVerificationTTLNegativeDisablesIDs(t *testing.T) {
+	chain := newTestChain(t)
+	provider, err := chain.Provider(evm.WithVerificationTTL(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := settleRequest(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+	if err != nil || !resp.IsValid || resp.VerificationID != "" {
+		t.Errorf("verify: %+v, %v; want it valid without an ID", resp, err)
+	}
+}
@@ -0,0 +1,74 @@
+package evm_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestVerifyOnlyProviderNeedsNoKeys(t *testing.T) {
+	chain := newTestChain(t)
+	options := chain.Options()
+	options.Keys = nil
+	if _, err := evm.New(testchain.Network, options); err == nil || !strings.Contains(err.Error(), "WithVerifyOnly") {
+		t.Errorf("provider without keys: error %v, want one pointing at WithVerifyOnly", err)
+	}
+
+	provider, err := evm.New(testchain.Network, options, evm.WithVerifyOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !provider.VerifyOnly() || len(provider.SignerAddresses()) != 0 {
+		t.Errorf("verify-only %v with signers %v", provider.VerifyOnly(), provider.SignerAddresses())
+	}
+
+	// Keys passed anyway are not used
+	options.Keys = chain.Options().Keys
+	if provider, err := evm.New(testchain.Network, options, evm.WithVerifyOnly()); err != nil || len(provider.SignerAddresses()) != 0 {
+		t.Errorf("verify-only provider given keys: signers %v, %v", provider.SignerAddresses(), err)
+	}
+}
+
+func TestVerifyOnlyProviderVerifiesButRefusesToSettle(t *testing.T) {
+	chain := newTestChain(t)
+	options := chain.Options()
+	options.Keys = nil
+	provider, err := evm.New(testchain.Network, options, evm.WithVerifyOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := settleRequest(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	verified, err := provider.Verify(ctx, &types.VerifyRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements})
+	if err != nil || !verified.IsValid {
+		t.Fatalf("verify: %+v, %v", verified, err)
+	}
+	settled, err := provider.Settle(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settled.Success || settled.ReasonCode != types.ReasonSettlementDisabled || settled.Error != "settlement disabled on this facilitator" {
+		t.Errorf("settle: %+v, want it refused as settlement_disabled", settled)
+	}
+	simulated, err := provider.Simulate(ctx, request)
+	if err != nil || simulated.Valid || simulated.ReasonCode != types.ReasonSettlementDisabled {
+		t.Errorf("simulate: %+v, %v; want it refused", simulated, err)
+	}
+	auth := request.PaymentPayload.Payload.Authorization
+	nonce, err := auth.Nonce.Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used, err := chain.AuthorizationUsed(auth.From, nonce); err != nil || used {
+		t.Errorf("authorization used %v (%v) after a refused settle", used, err)
+	}
+}
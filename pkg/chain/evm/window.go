@@ -0,0 +1,105 @@
+package evm
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	x402types "github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Time a settlement is expected to take to land, by kind of network (see
+// WithInclusionMargin)
+const (
+	DefaultL2InclusionMargin = 30 * time.Second
+	DefaultL1InclusionMargin = 90 * time.Second
+)
+
+// DefaultMinTimeout is the shortest validity window Verify accepts from
+// requirements that set no MinTimeoutSeconds (see WithMinTimeout)
+const DefaultMinTimeout = 60 * time.Second
+
+// rollups are the networks whose sequencer includes transactions within
+// seconds; every other network is taken for an L1
+var rollups = map[x402types.Network]bool{
+	x402types.NetworkBase:            true,
+	x402types.NetworkBaseSepolia:     true,
+	x402types.NetworkArbitrum:        true,
+	x402types.NetworkArbitrumSepolia: true,
+	x402types.NetworkOptimism:        true,
+	x402types.NetworkOptimismSepolia: true,
+}
+
+// DefaultInclusionMargin returns the inclusion margin of net unless
+// WithInclusionMargin sets one
+func DefaultInclusionMargin(net x402types.Network) time.Duration {
+	if rollups[net] {
+		return DefaultL2InclusionMargin
+	}
+	return DefaultL1InclusionMargin
+}
+
+// WithInclusionMargin sets how long a settlement is expected to take to be
+// included: Settle refuses authorizations expiring sooner, with
+// ReasonWindowTooShort, rather than pay gas for a transaction that lands
+// after validBefore and reverts. It is capped at half the requirements'
+// MaxTimeoutSeconds, since payers sign windows no longer than that and need
+// time to hand the payment over. 0 keeps DefaultInclusionMargin; a negative
+// margin submits whatever is still valid. SettleRequest.InclusionMarginSeconds
+// overrides it per request, uncapped.
+func WithInclusionMargin(margin time.Duration) ProviderOption {
+	return func(o *providerOptions) {
+		o.inclusionMargin = margin
+	}
+}
+
+// WithMinTimeout sets the shortest validity window (validBefore minus
+// validAfter) Verify accepts, with ReasonValidityTooShort, from requirements
+// that set no MinTimeoutSeconds, so payers cannot sign windows that expire
+// before the payment is forwarded; it is capped at the requirements'
+// MaxTimeoutSeconds. 0 keeps DefaultMinTimeout; a negative value accepts any
+// window.
+func WithMinTimeout(minTimeout time.Duration) ProviderOption {
+	return func(o *providerOptions) {
+		if minTimeout != 0 {
+			o.minTimeout = minTimeout
+		}
+	}
+}
+
+// MinTimeout returns the shortest validity window Verify accepts from
+// requirements without MinTimeoutSeconds (0 if it accepts any)
+func (p *Provider) MinTimeout() time.Duration {
+	return max(p.minTimeout, 0)
+}
+
+// InclusionMargin returns the time left before validBefore below which
+// Settle refuses an authorization (0 if it never does)
+func (p *Provider) InclusionMargin() time.Duration {
+	return max(p.inclusionMargin, 0)
+}
+
+// checkSettleWindow refuses an authorization expiring at validBefore that
+// cannot be included in time, under the request's margin if it sets one
+// (nil if it may be submitted)
+func (p *Provider) checkSettleWindow(request *x402types.SettleRequest, validBefore *big.Int) *x402types.SettleResponse {
+	margin := p.InclusionMargin()
+	if maxTimeout := request.PaymentRequirements.MaxTimeoutSeconds; maxTimeout > 0 {
+		margin = min(margin, time.Duration(maxTimeout)*time.Second/2)
+	}
+	if request.InclusionMarginSeconds != nil {
+		margin = time.Duration(max(*request.InclusionMarginSeconds, 0)) * time.Second
+	}
+	if margin <= 0 || !validBefore.IsInt64() {
+		return nil
+	}
+	left := time.Unix(validBefore.Int64(), 0).Sub(p.clock.Now())
+	if left >= margin {
+		return nil
+	}
+	return &x402types.SettleResponse{
+		Success:    false,
+		Error:      fmt.Sprintf("authorization expires in %s, under the %s a settlement on %s takes to be included; re-sign with a later validBefore", left.Round(time.Second), margin, p.network),
+		ReasonCode: x402types.ReasonWindowTooShort,
+	}
+}
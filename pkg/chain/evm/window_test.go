@@ -0,0 +1,184 @@
+package evm_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestDefaultInclusionMargin(t *testing.T) {
+	for net, want := range map[types.Network]time.Duration{
+		types.NetworkBase:     evm.DefaultL2InclusionMargin,
+		types.NetworkOptimism: evm.DefaultL2InclusionMargin,
+		types.NetworkPolygon:  evm.DefaultL1InclusionMargin,
+		testchain.Network:     evm.DefaultL1InclusionMargin,
+	} {
+		if got := evm.DefaultInclusionMargin(net); got != want {
+			t.Errorf("%s: margin %v, want %v", net, got, want)
+		}
+	}
+}
+
+func TestSettleRefusesWindowsTooShortToConfirm(t *testing.T) {
+	chain := newTestChain(t)
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	seconds := func(n int64) *int64 { return &n }
+
+	for _, tc := range []struct {
+		name    string
+		opts    []evm.ProviderOption
+		window  int           // Requirements' MaxTimeoutSeconds, which the payer signs for
+		left    time.Duration // Before validBefore when settling
+		request *int64        // SettleRequest.InclusionMarginSeconds
+		refused bool
+	}{
+		{"at the default margin", nil, 300, evm.DefaultL1InclusionMargin, nil, false},
+		{"a second under the default margin", nil, 300, evm.DefaultL1InclusionMargin - time.Second, nil, true},
+		{"under a configured margin", []evm.ProviderOption{evm.WithInclusionMargin(20 * time.Second)}, 300, 19 * time.Second, nil, true},
+		{"at a configured margin", []evm.ProviderOption{evm.WithInclusionMargin(20 * time.Second)}, 300, 20 * time.Second, nil, false},
+		{"margin disabled", []evm.ProviderOption{evm.WithInclusionMargin(-1)}, 300, 3 * time.Second, nil, false},
+		{"request accepting the risk", nil, 300, 3 * time.Second, seconds(0), false},
+		{"request with a negative margin", nil, 300, 3 * time.Second, seconds(-5), false},
+		{"request with a wider margin", nil, 300, 150 * time.Second, seconds(200), true},
+		{"request with a narrower margin", nil, 300, 15 * time.Second, seconds(10), false},
+		// The default L1 margin exceeds a 60s window, so half the window applies
+		{"60s window settled at once", nil, 60, 59 * time.Second, nil, false},
+		{"60s window at half the window", nil, 60, 30 * time.Second, nil, false},
+		{"60s window under half the window", nil, 60, 29 * time.Second, nil, true},
+		{"60s window under a configured margin", []evm.ProviderOption{evm.WithInclusionMargin(2 * time.Minute)}, 60, 45 * time.Second, nil, false},
+		{"60s window under a request's margin", nil, 60, 45 * time.Second, seconds(50), true},
+	} {
+		requirements := chain.Requirements(payTo, big.NewInt(1000))
+		requirements.MaxTimeoutSeconds = tc.window
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request := &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements, InclusionMarginSeconds: tc.request}
+		auth := request.PaymentPayload.Payload.Authorization
+		now := unixField(t, auth.ValidBefore).Add(-tc.left)
+		provider, err := chain.Provider(append(tc.opts, evm.WithClock(fixedClock(now)))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := provider.Settle(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !tc.refused {
+			if !resp.Success {
+				t.Errorf("%s: refused as %s (%s), want it settled", tc.name, resp.ReasonCode, resp.Error)
+			}
+			continue
+		}
+		if resp.Success || resp.ReasonCode != types.ReasonWindowTooShort || !strings.Contains(resp.Error, "re-sign") {
+			t.Errorf("%s: success %v, reason %s (%s); want %s", tc.name, resp.Success, resp.ReasonCode, resp.Error, types.ReasonWindowTooShort)
+		}
+		nonce, err := auth.Nonce.Nonce()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if used, err := chain.AuthorizationUsed(auth.From, nonce); err != nil || used {
+			t.Errorf("%s: authorization used %v (%v), want nothing submitted", tc.name, used, err)
+		}
+	}
+}
+
+func TestInclusionMarginAccessor(t *testing.T) {
+	chain := newTestChain(t)
+	for _, tc := range []struct {
+		margin, want time.Duration
+	}{
+		{0, evm.DefaultL1InclusionMargin},
+		{45 * time.Second, 45 * time.Second},
+		{-time.Second, 0},
+	} {
+		provider, err := chain.Provider(evm.WithInclusionMargin(tc.margin))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := provider.InclusionMargin(); got != tc.want {
+			t.Errorf("WithInclusionMargin(%v): margin %v, want %v", tc.margin, got, tc.want)
+		}
+	}
+}
+
+func TestVerifyEnforcesMinimumValidityWindow(t *testing.T) {
+	chain := newTestChain(t)
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+
+	for _, tc := range []struct {
+		name       string
+		opts       []evm.ProviderOption
+		window     int // Seconds the payer signs for
+		minTimeout int // Requirements' MinTimeoutSeconds
+		maxTimeout int // Requirements' MaxTimeoutSeconds
+		tooShort   bool
+	}{
+		{"below the requirements' minimum", nil, 119, 120, 300, true},
+		{"at the requirements' minimum", nil, 120, 120, 300, false},
+		{"above the requirements' minimum", nil, 121, 120, 300, false},
+		{"requirements' minimum under the default", nil, 30, 20, 300, false},
+		{"below the default minimum", nil, 59, 0, 300, true},
+		{"at the default minimum", nil, 60, 0, 300, false},
+		{"default capped at a shorter maximum", nil, 30, 0, 30, false},
+		{"below a configured minimum", []evm.ProviderOption{evm.WithMinTimeout(90 * time.Second)}, 89, 0, 300, true},
+		{"at a configured minimum", []evm.ProviderOption{evm.WithMinTimeout(90 * time.Second)}, 90, 0, 300, false},
+		{"minimum disabled", []evm.ProviderOption{evm.WithMinTimeout(-1)}, 5, 0, 300, false},
+		{"requirements' minimum with the default disabled", []evm.ProviderOption{evm.WithMinTimeout(-1)}, 5, 10, 300, true},
+	} {
+		provider, err := chain.Provider(tc.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signed := chain.Requirements(payTo, big.NewInt(1000))
+		signed.MaxTimeoutSeconds = tc.window
+		payload, err := chain.Authorize(chain.Accounts[0], signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		requirements := chain.Requirements(payTo, big.NewInt(1000))
+		requirements.MinTimeoutSeconds, requirements.MaxTimeoutSeconds = tc.minTimeout, tc.maxTimeout
+
+		resp, err := provider.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if tc.tooShort {
+			if resp.IsValid || resp.ReasonCode != types.ReasonValidityTooShort {
+				t.Errorf("%s: valid %v, reason %s (%s); want %s", tc.name, resp.IsValid, resp.ReasonCode, resp.Reason, types.ReasonValidityTooShort)
+			}
+			continue
+		}
+		if !resp.IsValid {
+			t.Errorf("%s: refused as %s (%s), want it valid", tc.name, resp.ReasonCode, resp.Reason)
+		}
+	}
+}
+
+func TestMinTimeoutAccessor(t *testing.T) {
+	chain := newTestChain(t)
+	for _, tc := range []struct {
+		minTimeout, want time.Duration
+	}{
+		{0, evm.DefaultMinTimeout},
+		{2 * time.Minute, 2 * time.Minute},
+		{-time.Second, 0},
+	} {
+		provider, err := chain.Provider(evm.WithMinTimeout(tc.minTimeout))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := provider.MinTimeout(); got != tc.want {
+			t.Errorf("WithMinTimeout(%v): minimum %v, want %v", tc.minTimeout, got, tc.want)
+		}
+	}
+}
@@ -75,7 +75,7 @@ package solana
 // 		}, nil
 // 	}
 
-// 	payer := x402types.NewSolanaAddress(tx.Message.AccountKeys[0].String())
+// 	payer, _ := x402types.NewSolanaAddress(tx.Message.AccountKeys[0].String())
 
 // 	// Simulate the transaction
 // 	simResult, err := p.client.SimulateTransaction(ctx, tx)
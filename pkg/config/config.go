@@ -1,25 +1,241 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"math/big"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/joho/godotenv"
+	"github.com/x402-rs/x402-go/pkg/accounting"
 	"github.com/x402-rs/x402-go/pkg/chain/evm"
 	"github.com/x402-rs/x402-go/pkg/facilitator"
 	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/transport"
 	"github.com/x402-rs/x402-go/pkg/types"
 )
 
+// Facilitator modes (FACILITATOR_MODE)
+const (
+	FacilitatorModeFull = "full"
+	// FacilitatorModeVerifyOnly serves /verify and /supported without any
+	// signer keys, for resource servers relying on an external settler
+	FacilitatorModeVerifyOnly = "verify-only"
+)
+
+// Client certificate requirements (TLS_CLIENT_AUTH)
+const (
+	TLSClientAuthNone = "none"
+	// TLSClientAuthRequire requires a certificate from TLS_CLIENT_CA_FILE
+	// on /settle, /settle/simulate and /cancel; other endpoints stay open
+	TLSClientAuthRequire = "require"
+)
+
+// Settlement event sinks (EVENT_SINK)
+const (
+	EventSinkNone    = "none"
+	EventSinkNATS    = "nats"
+	EventSinkWebhook = "webhook"
+)
+
 // Config holds the application configuration
 type Config struct {
 	Host             string
 	Port             string
+	RoutePrefix      string // Path the HTTP server is mounted under ("" for the root)
 	EVMPrivateKeys   []string
 	SolanaPrivateKey string
 	RPCURLs          map[types.Network]string
+
+	// HTTPS serving (TLS_CERT_FILE / TLS_KEY_FILE) and client certificate
+	// auth for settlement (TLS_CLIENT_AUTH=require, CAs in TLS_CLIENT_CA_FILE)
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientAuth   string
+	TLSClientCAFile string
+
+	// Per-network key overrides (EVM_PRIVATE_KEYS_<NETWORK>); see PrivateKeysFor
+	NetworkPrivateKeys map[types.Network][]string
+
+	// Upstream facilitator for proxy mode (empty runs the local facilitator)
+	UpstreamURL string
+
+	// full (default) or verify-only, which needs no signer keys and refuses
+	// to settle (see FacilitatorModeVerifyOnly)
+	Mode string
+
+	// Key signing settlement receipts (empty leaves receipts unsigned)
+	ReceiptSigningKey string
+
+	// Where settlement keys live: local (EVM_PRIVATE_KEY(S)), kms or remote
+	// (see SettlementSigners)
+	SignerBackend string
+
+	// AWS KMS key IDs (or ARNs) for the kms backend, and the KMS settings
+	// shared by every key (KeyID left empty)
+	KMSKeyIDs []string
+	KMS       evm.KMSConfig
+
+	// Signing service base URLs for the remote backend, one signer each
+	RemoteSignerURLs    []string
+	RemoteSignerTimeout time.Duration
+
+	// Nonce store limits (0 uses the evm package defaults)
+	NonceStoreMaxEntries    int
+	NonceStoreMaxPerAddress int
+
+	// Payment splitter contract per network (for payout splitting)
+	SplitterContracts map[types.Network]common.Address
+
+	// Block payer balances are checked at per network (latest if unset)
+	BalanceBlockTags map[types.Network]evm.BalanceBlockTag
+
+	// Time a settlement takes to be included, per network (unset networks
+	// use evm.DefaultInclusionMargin)
+	InclusionMargins map[types.Network]time.Duration
+
+	// Facilitator fee surcharge per network
+	FeePolicies map[types.Network]types.FeePolicy
+
+	// RPC deadlines (0 uses the evm package defaults)
+	VerifyRPCTimeout     time.Duration
+	SettleConfirmTimeout time.Duration
+
+	// Fail verifications fast while a network's RPC is failing (0 values
+	// use the evm package defaults)
+	RPCCircuitBreaker       bool
+	RPCBreakerErrorRate     float64
+	RPCBreakerProbeInterval time.Duration
+
+	// Accepted clock drift on validAfter (0 means none)
+	ClockSkewTolerance time.Duration
+
+	// Shortest validity window accepted from requirements without
+	// minTimeoutSeconds (0 uses evm.DefaultMinTimeout, negative disables)
+	MinTimeout time.Duration
+
+	// EVM networks registered from CUSTOM_NETWORKS (RPC URL in
+	// RPC_URL_CUSTOM_<NAME>)
+	CustomNetworks []types.Network
+
+	// Reject payments not bound to requirements.Resource
+	StrictResourceBinding bool
+
+	// Accept requirements paying a facilitator signer address
+	AllowSelfPayTo bool
+
+	// Accept permit2 payments of any ERC-20 approved to Permit2
+	Permit2Enabled bool
+
+	// Which overpayments settle: in full, not at all, or within a tolerance
+	SettlementAmountPolicy evm.AmountPolicy
+
+	// Reject /verify and /settle bodies with unknown fields
+	StrictDecoding bool
+
+	// Reject signatures and nonces that are not 0x-prefixed hex, instead of
+	// normalizing them with a deprecation warning (see types.SetStrictHex)
+	StrictHex bool
+
+	// Development mode: enables the testnet faucet (POST /dev/fund) granting
+	// DevFundAmount (atomic units, 0 uses the default) DevFundDailyLimit
+	// times per address per day
+	DevMode           bool
+	DevFundAmount     *big.Int
+	DevFundDailyLimit int
+
+	// Lifetime of the verification IDs that let Settle skip re-verifying
+	// (0 uses the evm package default, negative disables them)
+	VerificationTTL time.Duration
+
+	// Prefix of XDC addresses in /supported and receipts: 0x or xdc
+	XDCAddressPrefix string
+
+	// Signer gas balance below which a network is reported degraded
+	// (MIN_SIGNER_BALANCE_WEI_<NETWORK>), how often it is checked, and
+	// whether low signers are skipped while a healthy one remains
+	MinSignerBalances     map[types.Network]*big.Int
+	BalanceCheckInterval  time.Duration
+	SkipLowBalanceSigners bool
+
+	// Payers whose payments are refused (PAYER_DENYLIST)
+	PayerDenylist []string
+
+	// Daily gas sponsored per payTo and network, in wei (nil: no default),
+	// and per-payTo budgets overriding it (GAS_BUDGET_FILE)
+	GasBudgetDaily     *big.Int
+	GasBudgetOverrides map[string]*big.Int
+
+	// Teams sharing the facilitator, with their policies, keys and rate
+	// limits (TENANTS_FILE; nil serves everyone alike)
+	Tenants *TenantRegistry
+
+	// File keeping networks disabled via the admin API across restarts, and
+	// how long payloads verified before a disable may still settle
+	NetworkStateFile    string
+	NetworkDisableGrace time.Duration
+
+	// How long confirmed settlements are rechecked for reorgs (0 disables
+	// it) and how often
+	ReorgWatchWindow   time.Duration
+	ReorgCheckInterval time.Duration
+
+	// JSONL audit log of every verify/settle decision (empty disables it)
+	// and the size at which it rotates (it also rotates daily)
+	AuditLogPath     string
+	AuditLogMaxBytes int64
+
+	// Settlement journal file (empty keeps the journal in memory only), its
+	// capacity, and how often in-flight entries are reconciled
+	SettlementJournalPath string
+	SettlementJournalSize int
+	ReconcileInterval     time.Duration
+
+	// Where settlement lifecycle events are published: none (default), nats
+	// or webhook; the sink's settings; and how many undelivered events are
+	// buffered for retry
+	EventSink          string
+	NATSURL            string
+	NATSSubject        string
+	EventWebhookURL    string
+	EventWebhookSecret string
+	EventBufferSize    int
+
+	// Connection pooling for RPC clients (zero values use transport defaults)
+	RPCTransport transport.Config
+
+	// Variables the config was loaded from (for Validate)
+	env env
+}
+
+// rpcURLEnv maps each network to its RPC URL variable
+var rpcURLEnv = map[types.Network]string{
+	types.NetworkBaseSepolia:     "RPC_URL_BASE_SEPOLIA",
+	types.NetworkBase:            "RPC_URL_BASE",
+	types.NetworkAvalancheFuji:   "RPC_URL_AVALANCHE_FUJI",
+	types.NetworkAvalanche:       "RPC_URL_AVALANCHE",
+	types.NetworkPolygonAmoy:     "RPC_URL_POLYGON_AMOY",
+	types.NetworkPolygon:         "RPC_URL_POLYGON",
+	types.NetworkSei:             "RPC_URL_SEI",
+	types.NetworkSeiTestnet:      "RPC_URL_SEI_TESTNET",
+	types.NetworkXDC:             "RPC_URL_XDC",
+	types.NetworkArbitrum:        "RPC_URL_ARBITRUM",
+	types.NetworkArbitrumSepolia: "RPC_URL_ARBITRUM_SEPOLIA",
+	types.NetworkOptimism:        "RPC_URL_OPTIMISM",
+	types.NetworkOptimismSepolia: "RPC_URL_OPTIMISM_SEPOLIA",
+	types.NetworkSolana:          "RPC_URL_SOLANA",
+	types.NetworkSolanaDevnet:    "RPC_URL_SOLANA_DEVNET",
+}
+
+// customRPCURLEnv returns the RPC URL variable of a CUSTOM_NETWORKS entry,
+// e.g. RPC_URL_CUSTOM_MYCHAIN
+func customRPCURLEnv(net types.Network) string {
+	return "RPC_URL_CUSTOM_" + networkEnvSuffix(net)
 }
 
 // LoadConfig loads configuration from environment variables
@@ -27,59 +243,427 @@ func LoadConfig() (*Config, error) {
 	// Try to load .env file (ignore error if not found)
 	_ = godotenv.Load()
 
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			vars[key] = value
+		}
+	}
+	return LoadConfigFrom(vars)
+}
+
+// LoadConfigFrom loads configuration from a map of environment variables
+func LoadConfigFrom(vars map[string]string) (*Config, error) {
+	e := env(vars)
 	cfg := &Config{
-		Host:    getEnvOrDefault("HOST", "0.0.0.0"),
-		Port:    getEnvOrDefault("PORT", "8080"),
+		Host:    e.getOrDefault("HOST", "0.0.0.0"),
+		Port:    e.getOrDefault("PORT", "8080"),
 		RPCURLs: make(map[types.Network]string),
+		env:     e,
 	}
+	cfg.RoutePrefix = strings.TrimRight(strings.TrimSpace(e.get("ROUTE_PREFIX")), "/")
+	cfg.TLSCertFile = e.get("TLS_CERT_FILE")
+	cfg.TLSKeyFile = e.get("TLS_KEY_FILE")
+	cfg.TLSClientAuth = e.getOrDefault("TLS_CLIENT_AUTH", TLSClientAuthNone)
+	cfg.TLSClientCAFile = e.get("TLS_CLIENT_CA_FILE")
+
+	// Register custom EVM networks first so the per-network variables below
+	// see them ("name:chainID[:0xUSDC],...")
+	customNetworks, err := registerCustomNetworks(e.get("CUSTOM_NETWORKS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CUSTOM_NETWORKS: %w", err)
+	}
+	cfg.CustomNetworks = customNetworks
 
 	// Load private keys
-	evmKey := os.Getenv("EVM_PRIVATE_KEY")
+	evmKey := e.get("EVM_PRIVATE_KEY")
 	if evmKey != "" {
 		cfg.EVMPrivateKeys = []string{evmKey}
 	}
 
 	// Support multiple EVM private keys
-	evmKeys := os.Getenv("EVM_PRIVATE_KEYS")
+	evmKeys := e.get("EVM_PRIVATE_KEYS")
 	if evmKeys != "" {
-		cfg.EVMPrivateKeys = strings.Split(evmKeys, ",")
+		cfg.EVMPrivateKeys = splitKeys(evmKeys)
+	}
+
+	// Per-network overrides, e.g. EVM_PRIVATE_KEYS_BASE_SEPOLIA
+	cfg.NetworkPrivateKeys = make(map[types.Network][]string)
+	for _, info := range network.Networks() {
+		net := info.Network
+		if !net.IsEVM() {
+			continue
+		}
+		if keys := e.get(networkKeysEnv(net)); keys != "" {
+			cfg.NetworkPrivateKeys[net] = splitKeys(keys)
+		}
+	}
+
+	cfg.SolanaPrivateKey = e.get("SOLANA_PRIVATE_KEY")
+
+	// Proxy mode forwards verify/settle to an upstream facilitator
+	cfg.UpstreamURL = e.get("FACILITATOR_UPSTREAM_URL")
+	cfg.Mode = e.getOrDefault("FACILITATOR_MODE", FacilitatorModeFull)
+
+	cfg.ReceiptSigningKey = e.get("RECEIPT_SIGNING_KEY")
+
+	// Settlement signer backend
+	cfg.SignerBackend = e.getOrDefault("SIGNER_BACKEND", SignerBackendLocal)
+	cfg.KMSKeyIDs = splitKeys(e.get("KMS_KEY_IDS"))
+	cfg.KMS = evm.KMSConfig{
+		Region:          e.get("AWS_REGION"),
+		AccessKeyID:     e.get("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: e.get("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    e.get("AWS_SESSION_TOKEN"),
+		Endpoint:        e.get("KMS_ENDPOINT"),
+	}
+	cfg.RemoteSignerURLs = splitKeys(e.get("REMOTE_SIGNER_URLS"))
+
+	// Load nonce store limits
+	cfg.NonceStoreMaxEntries = e.getInt("NONCE_STORE_MAX_ENTRIES", 0)
+	cfg.NonceStoreMaxPerAddress = e.getInt("NONCE_STORE_MAX_PER_ADDRESS", 0)
+
+	// Load facilitator fees ("network:flat:bps,...")
+	feePolicies, err := types.ParseFeePolicies(e.get("FACILITATOR_FEES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FACILITATOR_FEES: %w", err)
+	}
+	cfg.FeePolicies = feePolicies
+
+	// Load splitter contracts ("network:0xaddress,...")
+	cfg.SplitterContracts = make(map[types.Network]common.Address)
+	for _, entry := range strings.Split(e.get("SPLITTER_CONTRACTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		net, addr, ok := strings.Cut(entry, ":")
+		if !ok || !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid SPLITTER_CONTRACTS entry %q (want network:0xaddress)", entry)
+		}
+		cfg.SplitterContracts[types.Network(net)] = common.HexToAddress(addr)
+	}
+
+	// Load balance block tags ("network:latest|safe|finalized,...")
+	cfg.BalanceBlockTags = make(map[types.Network]evm.BalanceBlockTag)
+	for _, entry := range strings.Split(e.get("BALANCE_BLOCK_TAGS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		net, raw, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid BALANCE_BLOCK_TAGS entry %q (want network:latest, network:safe or network:finalized)", entry)
+		}
+		tag, err := evm.ParseBalanceBlockTag(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BALANCE_BLOCK_TAGS entry %q: %w", entry, err)
+		}
+		cfg.BalanceBlockTags[types.Network(net)] = tag
+	}
+
+	// Load inclusion margins ("network:duration,...")
+	cfg.InclusionMargins = make(map[types.Network]time.Duration)
+	for _, entry := range strings.Split(e.get("INCLUSION_MARGINS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		net, raw, ok := strings.Cut(entry, ":")
+		margin, err := time.ParseDuration(raw)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid INCLUSION_MARGINS entry %q (want network:duration, e.g. base:30s)", entry)
+		}
+		cfg.InclusionMargins[types.Network(net)] = margin
+	}
+
+	// Load RPC timeouts (Go duration strings, e.g. "5s")
+	if cfg.VerifyRPCTimeout, err = e.getDuration("VERIFY_RPC_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	if cfg.SettleConfirmTimeout, err = e.getDuration("SETTLE_CONFIRM_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	cfg.RPCCircuitBreaker = e.get("RPC_CIRCUIT_BREAKER") == "true"
+	if raw := e.get("RPC_BREAKER_ERROR_RATE"); raw != "" {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate <= 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid RPC_BREAKER_ERROR_RATE %q (want a fraction above 0, up to 1)", raw)
+		}
+		cfg.RPCBreakerErrorRate = rate
+	}
+	if cfg.RPCBreakerProbeInterval, err = e.getDuration("RPC_BREAKER_PROBE_INTERVAL"); err != nil {
+		return nil, err
+	}
+	if cfg.ClockSkewTolerance, err = e.getDuration("CLOCK_SKEW_TOLERANCE"); err != nil {
+		return nil, err
+	}
+	if cfg.MinTimeout, err = e.getDuration("MIN_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	if cfg.RemoteSignerTimeout, err = e.getDuration("REMOTE_SIGNER_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	if cfg.VerificationTTL, err = e.getDuration("VERIFICATION_TTL"); err != nil {
+		return nil, err
+	}
+
+	cfg.StrictResourceBinding = e.get("STRICT_RESOURCE_BINDING") == "true"
+	cfg.AllowSelfPayTo = e.get("ALLOW_SELF_PAYTO") == "true"
+	for _, payer := range strings.Split(e.get("PAYER_DENYLIST"), ",") {
+		if payer = strings.TrimSpace(payer); payer != "" {
+			cfg.PayerDenylist = append(cfg.PayerDenylist, payer)
+		}
+	}
+	if _, err := facilitator.NewPayerDenylist(cfg.PayerDenylist); err != nil {
+		return nil, fmt.Errorf("invalid PAYER_DENYLIST: %w", err)
+	}
+	cfg.Permit2Enabled = e.get("PERMIT2_ENABLED") == "true"
+	if cfg.SettlementAmountPolicy, err = evm.ParseAmountPolicy(e.get("SETTLEMENT_AMOUNT_POLICY")); err != nil {
+		return nil, fmt.Errorf("invalid SETTLEMENT_AMOUNT_POLICY: %w", err)
+	}
+	cfg.StrictDecoding = e.get("STRICT_DECODING") == "true"
+	cfg.StrictHex = e.get("STRICT_HEX") == "true"
+
+	cfg.DevMode = e.get("DEV_MODE") == "true"
+	if raw := e.get("DEV_FUND_AMOUNT"); raw != "" {
+		amount, ok := new(big.Int).SetString(raw, 10)
+		if !ok || amount.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid DEV_FUND_AMOUNT %q (want a positive amount in atomic units)", raw)
+		}
+		cfg.DevFundAmount = amount
+	}
+	cfg.DevFundDailyLimit = e.getInt("DEV_FUND_DAILY_LIMIT", facilitator.DefaultFaucetDailyLimit)
+	cfg.XDCAddressPrefix = e.getOrDefault("XDC_ADDRESS_PREFIX", "0x")
+
+	// Load signer balance thresholds (wei)
+	cfg.MinSignerBalances = make(map[types.Network]*big.Int)
+	for _, info := range network.Networks() {
+		net := info.Network
+		if !net.IsEVM() {
+			continue
+		}
+		name := minSignerBalanceEnv(net)
+		raw := e.get(name)
+		if raw == "" {
+			continue
+		}
+		wei, ok := new(big.Int).SetString(raw, 10)
+		if !ok || wei.Sign() < 0 {
+			return nil, fmt.Errorf("invalid %s %q (want an amount in wei)", name, raw)
+		}
+		cfg.MinSignerBalances[net] = wei
+	}
+	if cfg.BalanceCheckInterval, err = e.getDuration("BALANCE_CHECK_INTERVAL"); err != nil {
+		return nil, err
+	}
+	cfg.SkipLowBalanceSigners = e.get("SKIP_LOW_BALANCE_SIGNERS") == "true"
+
+	if raw := e.get("GAS_BUDGET_DAILY_WEI"); raw != "" {
+		wei, ok := new(big.Int).SetString(raw, 10)
+		if !ok || wei.Sign() < 0 {
+			return nil, fmt.Errorf("invalid GAS_BUDGET_DAILY_WEI %q (want an amount in wei)", raw)
+		}
+		cfg.GasBudgetDaily = wei
+	}
+	if path := e.get("GAS_BUDGET_FILE"); path != "" {
+		if cfg.GasBudgetOverrides, err = facilitator.LoadGasBudgetOverrides(path); err != nil {
+			return nil, err
+		}
+	}
+	if path := e.get("TENANTS_FILE"); path != "" {
+		if cfg.Tenants, err = LoadTenants(path); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.NetworkStateFile = e.get("NETWORK_STATE_FILE")
+	if cfg.NetworkDisableGrace, err = e.getDuration("NETWORK_DISABLE_GRACE"); err != nil {
+		return nil, err
+	}
+
+	if cfg.ReorgWatchWindow, err = e.getDuration("REORG_WATCH_WINDOW"); err != nil {
+		return nil, err
+	}
+	if cfg.ReorgCheckInterval, err = e.getDuration("REORG_CHECK_INTERVAL"); err != nil {
+		return nil, err
 	}
 
-	cfg.SolanaPrivateKey = os.Getenv("SOLANA_PRIVATE_KEY")
+	cfg.AuditLogPath = e.get("AUDIT_LOG_PATH")
+	cfg.AuditLogMaxBytes = int64(e.getInt("AUDIT_LOG_MAX_BYTES", facilitator.DefaultAuditLogMaxBytes))
+
+	cfg.SettlementJournalPath = e.get("SETTLEMENT_JOURNAL_PATH")
+	cfg.SettlementJournalSize = e.getInt("SETTLEMENT_JOURNAL_SIZE", accounting.DefaultJournalSize)
+	if cfg.ReconcileInterval, err = e.getDuration("RECONCILE_INTERVAL"); err != nil {
+		return nil, err
+	}
+
+	cfg.EventSink = e.getOrDefault("EVENT_SINK", EventSinkNone)
+	cfg.NATSURL = e.get("NATS_URL")
+	cfg.NATSSubject = e.getOrDefault("NATS_SUBJECT", facilitator.DefaultNATSSubject)
+	cfg.EventWebhookURL = e.get("EVENT_WEBHOOK_URL")
+	cfg.EventWebhookSecret = e.get("EVENT_WEBHOOK_SECRET")
+	cfg.EventBufferSize = e.getInt("EVENT_BUFFER_SIZE", facilitator.DefaultEventBufferSize)
+
+	// Load RPC connection pooling
+	cfg.RPCTransport.MaxIdleConnsPerHost = e.getInt("RPC_MAX_IDLE_CONNS_PER_HOST", 0)
+	if cfg.RPCTransport.IdleConnTimeout, err = e.getDuration("RPC_IDLE_CONN_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	if cfg.RPCTransport.TLSHandshakeTimeout, err = e.getDuration("RPC_TLS_HANDSHAKE_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	cfg.RPCTransport.DisableHTTP2 = e.get("RPC_DISABLE_HTTP2") == "true"
 
 	// Load RPC URLs
-	rpcMapping := map[types.Network]string{
-		types.NetworkBaseSepolia:   "RPC_URL_BASE_SEPOLIA",
-		types.NetworkBase:          "RPC_URL_BASE",
-		types.NetworkAvalancheFuji: "RPC_URL_AVALANCHE_FUJI",
-		types.NetworkAvalanche:     "RPC_URL_AVALANCHE",
-		types.NetworkPolygonAmoy:   "RPC_URL_POLYGON_AMOY",
-		types.NetworkPolygon:       "RPC_URL_POLYGON",
-		types.NetworkSei:           "RPC_URL_SEI",
-		types.NetworkSeiTestnet:    "RPC_URL_SEI_TESTNET",
-		types.NetworkXDC:           "RPC_URL_XDC",
-		types.NetworkSolana:        "RPC_URL_SOLANA",
-		types.NetworkSolanaDevnet:  "RPC_URL_SOLANA_DEVNET",
-	}
-
-	for network, envKey := range rpcMapping {
-		if url := os.Getenv(envKey); url != "" {
+	for network, envKey := range rpcURLEnv {
+		if url := e.get(envKey); url != "" {
 			cfg.RPCURLs[network] = url
 		}
 	}
+	for _, net := range cfg.CustomNetworks {
+		if url := e.get(customRPCURLEnv(net)); url != "" {
+			cfg.RPCURLs[net] = url
+		}
+	}
 
 	return cfg, nil
 }
 
+// registerCustomNetworks registers the EVM networks of a CUSTOM_NETWORKS
+// list, with their USDC deployment (and its signature encoding) when given
+func registerCustomNetworks(list string) ([]types.Network, error) {
+	var registered []types.Network
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 4 || parts[0] == "" {
+			return nil, fmt.Errorf("entry %q (want name:chainID, name:chainID:0xUSDC or name:chainID:0xUSDC:vrs)", entry)
+		}
+		chainID, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil || chainID == 0 {
+			return nil, fmt.Errorf("entry %q: invalid chain ID %q", entry, parts[1])
+		}
+		net := types.Network(strings.ToLower(parts[0]))
+		if err := network.RegisterNetwork(net, network.ChainID(chainID), ""); err != nil {
+			return nil, err
+		}
+		if len(parts) >= 3 {
+			if !common.IsHexAddress(parts[2]) {
+				return nil, fmt.Errorf("entry %q: invalid USDC address %q", entry, parts[2])
+			}
+			var encoding network.SignatureEncoding
+			if len(parts) == 4 {
+				if encoding, err = network.ParseSignatureEncoding(parts[3]); err != nil {
+					return nil, fmt.Errorf("entry %q: %w", entry, err)
+				}
+			}
+			if err := network.RegisterUSDCDeployment(network.USDCDeployment{
+				Network:           net,
+				TokenAddress:      common.HexToAddress(parts[2]),
+				SignatureEncoding: encoding,
+			}); err != nil {
+				return nil, err
+			}
+		}
+		registered = append(registered, net)
+	}
+	return registered, nil
+}
+
 // InitializeFacilitator creates a facilitator from the configuration
+// It only translates the environment into a facilitator.Builder
 func (c *Config) InitializeFacilitator() (*facilitator.LocalFacilitator, error) {
-	fac := facilitator.NewLocalFacilitator()
+	verifyOnly := c.VerifyOnly()
+	if !verifyOnly && c.SignerBackend == SignerBackendLocal && len(c.EVMPrivateKeys) == 0 && len(c.NetworkPrivateKeys) == 0 {
+		return nil, fmt.Errorf("no EVM private keys configured (set FACILITATOR_MODE=%s to run without settlement)", FacilitatorModeVerifyOnly)
+	}
+
+	// External signers are shared by every network (nonces are per chain)
+	var signers []evm.Signer
+	var err error
+	if !verifyOnly {
+		if signers, err = c.SettlementSigners(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	for _, signer := range signers {
+		fmt.Printf("Using %s signer %s\n", c.SignerBackend, signer.Address().Hex())
+	}
+
+	builder := facilitator.NewBuilder()
+	if verifyOnly {
+		builder.WithVerifyOnly()
+		log.Println("Verify-only mode: settlement is disabled")
+	}
+
+	// Settlement journal; on disk it survives restarts so in-flight
+	// settlements can be reconciled
+	journal := accounting.NewSettlementJournal(c.SettlementJournalSize)
+	if c.SettlementJournalPath != "" {
+		if journal, err = accounting.OpenSettlementJournal(c.SettlementJournalPath, c.SettlementJournalSize); err != nil {
+			return nil, fmt.Errorf("failed to open settlement journal: %w", err)
+		}
+		fmt.Printf("Settlement journal at %s (%d in flight)\n", c.SettlementJournalPath, len(journal.InFlight()))
+	}
+	builder.WithJournal(journal)
+
+	if c.AuditLogPath != "" {
+		auditLog, err := facilitator.OpenAuditLog(c.AuditLogPath, c.AuditLogMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		builder.WithAuditLogger(auditLog)
+		fmt.Printf("Audit log at %s\n", c.AuditLogPath)
+	}
+
+	publisher, err := c.EventPublisher()
+	if err != nil {
+		return nil, err
+	}
+	if publisher != nil {
+		builder.WithEventPublisher(publisher, c.EventBufferSize)
+		fmt.Printf("Publishing settlement events to %s\n", c.EventSink)
+	}
+
+	if c.XDCAddressPrefix == types.XDCAddressPrefix {
+		builder.WithXDCAddressPrefix()
+	}
+	builder.WithNetworkStateFile(c.NetworkStateFile).WithDisableGrace(c.NetworkDisableGrace)
+	if len(c.PayerDenylist) > 0 {
+		denylist, err := facilitator.NewPayerDenylist(c.PayerDenylist)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PAYER_DENYLIST: %w", err)
+		}
+		builder.WithVerifyHook(denylist.VerifyHook()).WithSettleHook(denylist.SettleHook())
+		fmt.Printf("Refusing payments from %d denylisted payer(s)\n", len(c.PayerDenylist))
+	}
+	if c.GasBudgetDaily != nil || len(c.GasBudgetOverrides) > 0 {
+		builder.WithGasBudget(facilitator.NewGasBudget(c.GasBudgetDaily, c.GasBudgetOverrides))
+		fmt.Printf("Capping sponsored gas per payTo and day (%d payTo override(s))\n", len(c.GasBudgetOverrides))
+	}
+	if c.Tenants != nil {
+		builder.WithTenants(c.Tenants.Policies())
+		fmt.Printf("Serving %d tenant(s) with their own policies\n", c.Tenants.Len())
+	}
+	if c.ReorgWatchWindow > 0 {
+		builder.WithReorgWatch(c.ReorgWatchWindow, nil)
+	}
 
-	if len(c.EVMPrivateKeys) == 0 {
-		return nil, fmt.Errorf("no EVM private keys configured")
+	if c.ReceiptSigningKey != "" {
+		signer, err := evm.ParseLocalSigner(c.ReceiptSigningKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RECEIPT_SIGNING_KEY: %w", err)
+		}
+		builder.WithReceiptSigner(signer)
+		fmt.Printf("Signing settlement receipts as %s\n", signer.Address().Hex())
 	}
 
-	// Initialize EVM providers
+	// EVM networks
+	var initialized []types.Network
 	for net, rpcURL := range c.RPCURLs {
 		if !net.IsEVM() {
 			continue
@@ -90,14 +674,57 @@ func (c *Config) InitializeFacilitator() (*facilitator.LocalFacilitator, error)
 			return nil, fmt.Errorf("failed to get network info for %s: %w", net, err)
 		}
 
-		chainID := big.NewInt(int64(netInfo.ChainID))
-		provider, err := evm.NewProvider(rpcURL, chainID, net, c.EVMPrivateKeys)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create EVM provider for %s: %w", net, err)
+		var keys []string
+		if signers == nil && !verifyOnly {
+			keys = c.PrivateKeysFor(net)
+		}
+		if len(keys) == 0 && len(signers) == 0 && !verifyOnly {
+			// Mainnets must be able to settle; testnets without keys are skipped
+			if !net.IsTestnet() {
+				return nil, fmt.Errorf("no EVM private keys configured for %s (set %s or EVM_PRIVATE_KEYS)", net, networkKeysEnv(net))
+			}
+			fmt.Printf("Skipping %s: no EVM private keys configured\n", netInfo.Name)
+			continue
+		}
+
+		options := evm.Options{
+			RPCURL:  rpcURL,
+			ChainID: big.NewInt(int64(netInfo.ChainID)),
+			Keys:    keys,
+			Signers: signers,
+		}
+		// Custom networks accept the USDC they were registered with
+		if c.isCustomNetwork(net) {
+			if deployment, err := network.GetUSDCDeployment(net); err == nil {
+				options.AssetWhitelist = []common.Address{deployment.TokenAddress}
+			}
 		}
+		builder.WithEVMNetwork(net, options, c.providerOptions(net)...)
+		if policy, ok := c.FeePolicies[net]; ok {
+			builder.WithFeePolicy(net, policy)
+		}
+		initialized = append(initialized, net)
+	}
+
+	// Solana networks are advertised only (settlement is not implemented yet)
+	if c.SolanaPrivateKey != "" {
+		for net := range c.RPCURLs {
+			if net.IsSolana() {
+				builder.WithSolanaNetwork(net)
+			}
+		}
+	}
 
-		fac.AddEVMProvider(net, provider)
-		fmt.Printf("Initialized EVM provider for %s (chain ID: %d) at %s\n", netInfo.Name, chainID, rpcURL)
+	fac, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	for _, net := range initialized {
+		netInfo, _ := network.GetNetworkInfo(net)
+		fmt.Printf("Initialized EVM provider for %s (chain ID: %d) at %s\n", netInfo.Name, netInfo.ChainID, c.RPCURLs[net])
+	}
+	for net := range fac.DisabledNetworks() {
+		fmt.Printf("%s is disabled (POST /admin/networks/%s/enable to resume)\n", net, net)
 	}
 
 	// // Initialize Solana providers
@@ -120,9 +747,120 @@ func (c *Config) InitializeFacilitator() (*facilitator.LocalFacilitator, error)
 	return fac, nil
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+// providerOptions returns the provider tuning shared by every EVM network
+func (c *Config) providerOptions(net types.Network) []evm.ProviderOption {
+	opts := []evm.ProviderOption{
+		evm.WithNonceStoreLimits(c.NonceStoreMaxEntries, c.NonceStoreMaxPerAddress),
+		evm.WithRPCTimeouts(c.VerifyRPCTimeout, c.SettleConfirmTimeout),
+		evm.WithSplitterContract(c.SplitterContracts[net]),
+		evm.WithClockSkewTolerance(c.ClockSkewTolerance),
+		evm.WithMinTimeout(c.MinTimeout),
+		evm.WithVerificationTTL(c.VerificationTTL),
+		evm.WithTransport(c.RPCTransport),
+		evm.WithAmountPolicy(c.SettlementAmountPolicy),
+		evm.WithBalanceBlockTag(c.BalanceBlockTags[net]),
+		evm.WithInclusionMargin(c.InclusionMargins[net]),
+	}
+	if c.StrictResourceBinding {
+		opts = append(opts, evm.WithStrictResourceBinding())
+	}
+	if c.AllowSelfPayTo {
+		opts = append(opts, evm.WithAllowSelfPayTo())
+	}
+	if c.Permit2Enabled {
+		opts = append(opts, evm.WithPermit2())
+	}
+	if c.RPCCircuitBreaker {
+		opts = append(opts, evm.WithRPCCircuitBreaker(c.RPCBreakerErrorRate, c.RPCBreakerProbeInterval))
+	}
+	if minBalance, ok := c.MinSignerBalances[net]; ok {
+		opts = append(opts, evm.WithMinSignerBalance(minBalance, c.SkipLowBalanceSigners))
+	}
+	return opts
+}
+
+// VerifyOnly reports whether the facilitator runs without settlement
+func (c *Config) VerifyOnly() bool {
+	return c.Mode == FacilitatorModeVerifyOnly
+}
+
+// PrivateKeysFor returns the EVM keys for a network: its override if set,
+// otherwise the global EVM_PRIVATE_KEY(S) list
+func (c *Config) PrivateKeysFor(net types.Network) []string {
+	if keys, ok := c.NetworkPrivateKeys[net]; ok {
+		return keys
+	}
+	return c.EVMPrivateKeys
+}
+
+// networkKeysEnv returns the per-network key variable, e.g. EVM_PRIVATE_KEYS_BASE_SEPOLIA
+func networkKeysEnv(net types.Network) string {
+	return "EVM_PRIVATE_KEYS_" + networkEnvSuffix(net)
+}
+
+// minSignerBalanceEnv returns the per-network balance threshold variable,
+// e.g. MIN_SIGNER_BALANCE_WEI_BASE
+func minSignerBalanceEnv(net types.Network) string {
+	return "MIN_SIGNER_BALANCE_WEI_" + networkEnvSuffix(net)
+}
+
+// isCustomNetwork reports whether net was registered from CUSTOM_NETWORKS
+func (c *Config) isCustomNetwork(net types.Network) bool {
+	for _, custom := range c.CustomNetworks {
+		if custom == net {
+			return true
+		}
+	}
+	return false
+}
+
+// networkEnvSuffix returns a network name as used in variable names
+func networkEnvSuffix(net types.Network) string {
+	return strings.ToUpper(strings.ReplaceAll(string(net), "-", "_"))
+}
+
+// splitKeys parses a comma-separated key list, dropping blanks
+func splitKeys(list string) []string {
+	var keys []string
+	for _, key := range strings.Split(list, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// env is a snapshot of environment variables
+type env map[string]string
+
+func (e env) get(key string) string {
+	return e[key]
+}
+
+func (e env) getOrDefault(key, defaultValue string) string {
+	if value := e.get(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func (e env) getInt(key string, defaultValue int) int {
+	if value := e.get(key); value != "" {
+		if result, err := strconv.Atoi(value); err == nil {
+			return result
+		}
+	}
+	return defaultValue
+}
+
+func (e env) getDuration(key string) (time.Duration, error) {
+	value := e.get(key)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,483 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/transport"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+const (
+	globalKey  = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	sepoliaKey = "0x8da4ef21b864d2cc526dbdb2a120bd2874c36c9d0a1fb7f8c63d7f7a8b41de8f"
+	amoyKey    = "0x59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+)
+
+// unreachableRPC is never dialled: providers connect lazily
+const unreachableRPC = "http://127.0.0.1:1"
+
+func TestPrivateKeysForNetwork(t *testing.T) {
+	cfg, err := LoadConfigFrom(map[string]string{
+		"EVM_PRIVATE_KEYS":               globalKey,
+		"EVM_PRIVATE_KEYS_BASE_SEPOLIA":  sepoliaKey + ", " + amoyKey + ",",
+		"EVM_PRIVATE_KEYS_NOT_A_NETWORK": amoyKey,
+		"EVM_PRIVATE_KEYS_POLYGON_AMOY":  amoyKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for net, want := range map[types.Network][]string{
+		types.NetworkBaseSepolia: {sepoliaKey, amoyKey},
+		types.NetworkPolygonAmoy: {amoyKey},
+		types.NetworkBase:        {globalKey}, // No override: the global list
+	} {
+		if got := cfg.PrivateKeysFor(net); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: keys %v, want %v", net, got, want)
+		}
+	}
+
+	// EVM_PRIVATE_KEY is the single-key form of the global list
+	cfg, err = LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEY": globalKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.PrivateKeysFor(types.NetworkBase); !reflect.DeepEqual(got, []string{globalKey}) {
+		t.Errorf("EVM_PRIVATE_KEY: keys %v", got)
+	}
+}
+
+func TestInitializeFacilitatorAssignsSignersPerNetwork(t *testing.T) {
+	cfg, err := LoadConfigFrom(map[string]string{
+		"EVM_PRIVATE_KEYS":              globalKey,
+		"EVM_PRIVATE_KEYS_BASE_SEPOLIA": sepoliaKey,
+		"RPC_URL_BASE_SEPOLIA":          unreachableRPC,
+		"RPC_URL_POLYGON_AMOY":          unreachableRPC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fac, err := cfg.InitializeFacilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := fac.Stats()["signers"].(map[types.Network][]string)
+	for net, key := range map[types.Network]string{types.NetworkBaseSepolia: sepoliaKey, types.NetworkPolygonAmoy: globalKey} {
+		want, err := KeyAddress(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := signers[net]; len(got) != 1 || got[0] != want.Hex() {
+			t.Errorf("%s signed by %v, want %s", net, got, want.Hex())
+		}
+	}
+}
+
+func TestInitializeFacilitatorRequiresMainnetKeys(t *testing.T) {
+	// Only Base Sepolia has keys, so Base mainnet would be unable to settle
+	cfg, err := LoadConfigFrom(map[string]string{
+		"EVM_PRIVATE_KEYS_BASE_SEPOLIA": sepoliaKey,
+		"RPC_URL_BASE_SEPOLIA":          unreachableRPC,
+		"RPC_URL_BASE":                  unreachableRPC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.InitializeFacilitator(); err == nil || !strings.Contains(err.Error(), "EVM_PRIVATE_KEYS_BASE") {
+		t.Errorf("mainnet without keys: error %v, want one naming EVM_PRIVATE_KEYS_BASE", err)
+	}
+
+	// A testnet without keys is skipped instead
+	cfg, err = LoadConfigFrom(map[string]string{
+		"EVM_PRIVATE_KEYS_BASE_SEPOLIA": sepoliaKey,
+		"RPC_URL_BASE_SEPOLIA":          unreachableRPC,
+		"RPC_URL_POLYGON_AMOY":          unreachableRPC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fac, err := cfg.InitializeFacilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := fac.Stats()["signers"].(map[types.Network][]string)
+	if _, ok := signers[types.NetworkPolygonAmoy]; ok || len(signers[types.NetworkBaseSepolia]) != 1 {
+		t.Errorf("signers %v, want Base Sepolia served and Polygon Amoy skipped", signers)
+	}
+
+	// No keys at all fails outright
+	cfg, err = LoadConfigFrom(map[string]string{"RPC_URL_BASE_SEPOLIA": unreachableRPC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.InitializeFacilitator(); err == nil {
+		t.Error("initialized a settling facilitator without any keys")
+	}
+}
+
+func TestLoadRPCTransport(t *testing.T) {
+	cfg, err := LoadConfigFrom(map[string]string{
+		"EVM_PRIVATE_KEYS":            globalKey,
+		"RPC_MAX_IDLE_CONNS_PER_HOST": "25",
+		"RPC_IDLE_CONN_TIMEOUT":       "45s",
+		"RPC_TLS_HANDSHAKE_TIMEOUT":   "3s",
+		"RPC_DISABLE_HTTP2":           "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := transport.Config{MaxIdleConnsPerHost: 25, IdleConnTimeout: 45 * time.Second, TLSHandshakeTimeout: 3 * time.Second, DisableHTTP2: true}
+	if got := cfg.RPCTransport; got.MaxIdleConnsPerHost != want.MaxIdleConnsPerHost || got.IdleConnTimeout != want.IdleConnTimeout || got.TLSHandshakeTimeout != want.TLSHandshakeTimeout || got.DisableHTTP2 != want.DisableHTTP2 {
+		t.Errorf("RPC transport %+v, want %+v", got, want)
+	}
+
+	if _, err := LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEYS": globalKey, "RPC_IDLE_CONN_TIMEOUT": "soon"}); err == nil || !strings.Contains(err.Error(), "RPC_IDLE_CONN_TIMEOUT") {
+		t.Errorf("malformed timeout: error %v, want it named", err)
+	}
+}
+
+func TestLoadMinSignerBalances(t *testing.T) {
+	cfg, err := LoadConfigFrom(map[string]string{
+		"EVM_PRIVATE_KEYS":                    globalKey,
+		"MIN_SIGNER_BALANCE_WEI_BASE_SEPOLIA": "5000000000000000",
+		"BALANCE_CHECK_INTERVAL":              "2m",
+		"SKIP_LOW_BALANCE_SIGNERS":            "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.MinSignerBalances[types.NetworkBaseSepolia]; got == nil || got.String() != "5000000000000000" || len(cfg.MinSignerBalances) != 1 {
+		t.Errorf("thresholds %v, want only base-sepolia's", cfg.MinSignerBalances)
+	}
+	if cfg.BalanceCheckInterval != 2*time.Minute || !cfg.SkipLowBalanceSigners {
+		t.Errorf("interval %s, skip %v", cfg.BalanceCheckInterval, cfg.SkipLowBalanceSigners)
+	}
+
+	for _, raw := range []string{"-1", "0.5", "lots"} {
+		if _, err := LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEYS": globalKey, "MIN_SIGNER_BALANCE_WEI_BASE": raw}); err == nil || !strings.Contains(err.Error(), "MIN_SIGNER_BALANCE_WEI_BASE") {
+			t.Errorf("threshold %q: error %v, want it named", raw, err)
+		}
+	}
+}
+
+func TestInitializeFacilitatorVerifyOnly(t *testing.T) {
+	cfg, err := LoadConfigFrom(map[string]string{
+		"FACILITATOR_MODE":     FacilitatorModeVerifyOnly,
+		"RPC_URL_BASE_SEPOLIA": unreachableRPC,
+		"RPC_URL_BASE":         unreachableRPC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.VerifyOnly() {
+		t.Fatal("FACILITATOR_MODE=verify-only did not select verify-only")
+	}
+	fac, err := cfg.InitializeFacilitator()
+	if err != nil {
+		t.Fatalf("verify-only startup without keys: %v", err)
+	}
+	supported, err := fac.Supported(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	networks := make(map[types.Network]bool)
+	for _, kind := range supported.Kinds {
+		networks[kind.Network] = true
+		if kind.Settlement {
+			t.Errorf("%s is advertised as settling", kind.Network)
+		}
+	}
+	if !networks[types.NetworkBase] || !networks[types.NetworkBaseSepolia] {
+		t.Errorf("supported networks %v, want Base and Base Sepolia served without keys", networks)
+	}
+
+	// The default mode still needs keys, and says how to go without
+	cfg, err = LoadConfigFrom(map[string]string{"RPC_URL_BASE_SEPOLIA": unreachableRPC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Mode != FacilitatorModeFull {
+		t.Errorf("default mode %q, want %q", cfg.Mode, FacilitatorModeFull)
+	}
+	if _, err := cfg.InitializeFacilitator(); err == nil || !strings.Contains(err.Error(), "FACILITATOR_MODE=verify-only") {
+		t.Errorf("full mode without keys: error %v", err)
+	}
+}
+
+func TestLoadCustomNetworks(t *testing.T) {
+	usdc := "0x00000000000000000000000000000000000000c2"
+	cfg, err := LoadConfigFrom(map[string]string{
+		"EVM_PRIVATE_KEYS":           globalKey,
+		"CUSTOM_NETWORKS":            "ConfigChain:990401:" + usdc + ", config-other:990402",
+		"RPC_URL_CUSTOM_CONFIGCHAIN": unreachableRPC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []types.Network{"configchain", "config-other"}
+	if !reflect.DeepEqual(cfg.CustomNetworks, want) {
+		t.Fatalf("custom networks %v, want %v", cfg.CustomNetworks, want)
+	}
+	if chainID, ok := types.Network("configchain").ChainID(); !ok || chainID != 990401 {
+		t.Errorf("configchain chain ID %d (%v)", chainID, ok)
+	}
+	if cfg.RPCURLs["configchain"] != unreachableRPC || cfg.RPCURLs["config-other"] != "" {
+		t.Errorf("RPC URLs %v, want only configchain's", cfg.RPCURLs)
+	}
+
+	fac, err := cfg.InitializeFacilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	supported, err := fac.Supported(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := false
+	for _, kind := range supported.Kinds {
+		served = served || kind.Network == "configchain"
+	}
+	if !served {
+		t.Error("the custom network with an RPC URL is not served")
+	}
+
+	for _, list := range []string{
+		"nochain",
+		"bad:chain",
+		"zero:0",
+		"badusdc:990403:0xnot",
+		"too:990404:" + usdc + ":many",
+		"base-again:8453",
+	} {
+		if _, err := LoadConfigFrom(map[string]string{"CUSTOM_NETWORKS": list}); err == nil || !strings.Contains(err.Error(), "CUSTOM_NETWORKS") {
+			t.Errorf("CUSTOM_NETWORKS=%q: error %v, want it named", list, err)
+		}
+	}
+}
+
+func TestLoadCustomNetworkSignatureEncoding(t *testing.T) {
+	usdc := "0x00000000000000000000000000000000000000c9"
+	if _, err := LoadConfigFrom(map[string]string{"CUSTOM_NETWORKS": "vrschain:990836:" + usdc + ":vrs, byteschain:990837:" + usdc + ":bytes"}); err != nil {
+		t.Fatal(err)
+	}
+	for net, want := range map[types.Network]network.SignatureEncoding{"vrschain": network.SignatureVRS, "byteschain": network.SignatureBytes} {
+		if got := network.TokenSignatureEncoding(net, common.HexToAddress(usdc)); got != want {
+			t.Errorf("%s: encoding %q, want %q", net, got, want)
+		}
+	}
+}
+
+func TestEventPublisher(t *testing.T) {
+	for env, want := range map[string]string{
+		"":        "",
+		"none":    "",
+		"webhook": "*facilitator.WebhookPublisher",
+		"nats":    "*facilitator.NATSPublisher",
+	} {
+		cfg, err := LoadConfigFrom(map[string]string{
+			"EVENT_SINK":        env,
+			"NATS_URL":          "nats://127.0.0.1:4222",
+			"EVENT_WEBHOOK_URL": "https://billing.example.com/x402",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		publisher, err := cfg.EventPublisher()
+		if err != nil {
+			t.Fatalf("EVENT_SINK=%q: %v", env, err)
+		}
+		if got := fmt.Sprintf("%T", publisher); want == "" && publisher != nil || want != "" && got != want {
+			t.Errorf("EVENT_SINK=%q: publisher %s, want %q", env, got, want)
+		}
+	}
+
+	for _, sink := range []string{"nats", "webhook"} {
+		cfg, err := LoadConfigFrom(map[string]string{"EVENT_SINK": sink})
+		if err != nil {
+			continue // Rejected while loading is as good
+		}
+		if _, err := cfg.EventPublisher(); err == nil {
+			t.Errorf("EVENT_SINK=%s without a destination was accepted", sink)
+		}
+	}
+}
+
+func TestLoadBalanceBlockTags(t *testing.T) {
+	cfg, err := LoadConfigFrom(map[string]string{"BALANCE_BLOCK_TAGS": "base:safe, polygon:finalized"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[types.Network]evm.BalanceBlockTag{types.NetworkBase: evm.BalanceSafe, types.NetworkPolygon: evm.BalanceFinalized}
+	if !reflect.DeepEqual(cfg.BalanceBlockTags, want) {
+		t.Errorf("balance block tags %v, want %v", cfg.BalanceBlockTags, want)
+	}
+	for _, list := range []string{"base", "base:pending"} {
+		if _, err := LoadConfigFrom(map[string]string{"BALANCE_BLOCK_TAGS": list}); err == nil || !strings.Contains(err.Error(), "BALANCE_BLOCK_TAGS") {
+			t.Errorf("BALANCE_BLOCK_TAGS=%q: error %v, want it named", list, err)
+		}
+	}
+}
+
+func TestLoadPayerDenylist(t *testing.T) {
+	cfg, err := LoadConfigFrom(map[string]string{
+		"EVM_PRIVATE_KEYS": globalKey,
+		"PAYER_DENYLIST":   " 0x00000000000000000000000000000000000000a1, ,xdc00000000000000000000000000000000000000a2,",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"0x00000000000000000000000000000000000000a1", "xdc00000000000000000000000000000000000000a2"}; !reflect.DeepEqual(cfg.PayerDenylist, want) {
+		t.Errorf("denylist %q, want %q", cfg.PayerDenylist, want)
+	}
+
+	if _, err := LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEYS": globalKey, "PAYER_DENYLIST": "0x00000000000000000000000000000000000000a1,0xnope"}); err == nil || !strings.Contains(err.Error(), "PAYER_DENYLIST") {
+		t.Errorf("invalid entry: error %v, want PAYER_DENYLIST named", err)
+	}
+}
+
+func TestLoadTenants(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	if err := os.WriteFile(path, []byte(`{"tenants": [
+		{"id": "checkout", "apiKeys": ["sk_checkout"], "networks": ["Base", "base-sepolia"], "payTo": ["xdc00000000000000000000000000000000000000b0"], "rateLimit": {"requestsPerMinute": 600}},
+		{"id": "payouts", "networks": ["base"], "signers": ["0x00000000000000000000000000000000000000e1"]}
+	]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEYS": globalKey, "TENANTS_FILE": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := cfg.Tenants
+	defer registry.Stop()
+	if registry.Len() != 2 || registry.APIKeys().Len() != 1 {
+		t.Fatalf("%d tenants with %d keys, want 2 with 1", registry.Len(), registry.APIKeys().Len())
+	}
+	policies := registry.Policies()
+	if checkout := policies[0]; !reflect.DeepEqual(checkout.Networks, []types.Network{types.NetworkBase, types.NetworkBaseSepolia}) || !reflect.DeepEqual(checkout.PayTo, []string{"xdc00000000000000000000000000000000000000b0"}) {
+		t.Errorf("checkout policy %+v", checkout)
+	}
+	if payouts := policies[1]; len(payouts.Signers) != 1 || payouts.Signers[0] != common.HexToAddress("0x00000000000000000000000000000000000000e1") {
+		t.Errorf("payouts policy %+v", payouts)
+	}
+
+	for _, tc := range []struct {
+		id, key, want string // want "" for a refusal
+		limited       bool
+	}{
+		{"", "sk_checkout", "checkout", true},
+		{"checkout", "sk_checkout", "checkout", true},
+		{"payouts", "sk_checkout", "", false},      // Another tenant's key
+		{"checkout", "", "", false},                // A keyed tenant must present its key
+		{"payouts", "sk_settle", "payouts", false}, // Not a tenant key: the header decides
+		{"", "sk_settle", "", false},
+		{"unknown", "", "", false},
+	} {
+		tenant, limiter, err := registry.ResolveTenant(tc.id, tc.key)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("ResolveTenant(%q, %q) = %q, want a refusal", tc.id, tc.key, tenant)
+			}
+			continue
+		}
+		if err != nil || tenant != tc.want || (limiter != nil) != tc.limited {
+			t.Errorf("ResolveTenant(%q, %q) = %q, limiter %v, %v; want %q", tc.id, tc.key, tenant, limiter != nil, err, tc.want)
+		}
+	}
+
+	for contents, wantErr := range map[string]string{
+		`{"tenants": [{"networks": ["base"]}]}`:                                       "needs an id",
+		`{"tenants": [{"id": "a"}, {"id": "a"}]}`:                                     "listed twice",
+		`{"tenants": [{"id": "a", "networks": ["nowhere"]}]}`:                         "unknown network",
+		`{"tenants": [{"id": "a", "payTo": ["0xnope"]}]}`:                             "invalid payTo",
+		`{"tenants": [{"id": "a", "signers": ["0xnope"]}]}`:                           "invalid signer",
+		`{"tenants": [{"id": "a", "apiKeys": ["k"]}, {"id": "b", "apiKeys": ["k"]}]}`: "tenant \"b\"",
+		`{"tenants": `: "invalid tenants file",
+	} {
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadTenants(path); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s: error %v, want one mentioning %q", contents, err, wantErr)
+		}
+	}
+}
+
+func TestLoadStrictHex(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "false": false, "true": true} {
+		cfg, err := LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEYS": globalKey, "STRICT_HEX": value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.StrictHex != want {
+			t.Errorf("STRICT_HEX=%q: strict %v, want %v", value, cfg.StrictHex, want)
+		}
+	}
+}
+
+func TestLoadRPCCircuitBreaker(t *testing.T) {
+	cfg, err := LoadConfigFrom(map[string]string{
+		"EVM_PRIVATE_KEYS":           globalKey,
+		"RPC_CIRCUIT_BREAKER":        "true",
+		"RPC_BREAKER_ERROR_RATE":     "0.25",
+		"RPC_BREAKER_PROBE_INTERVAL": "2s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.RPCCircuitBreaker || cfg.RPCBreakerErrorRate != 0.25 || cfg.RPCBreakerProbeInterval != 2*time.Second {
+		t.Errorf("breaker %v at %v, probing every %v", cfg.RPCCircuitBreaker, cfg.RPCBreakerErrorRate, cfg.RPCBreakerProbeInterval)
+	}
+
+	if cfg, err := LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEYS": globalKey}); err != nil || cfg.RPCCircuitBreaker {
+		t.Errorf("default: breaker %v (%v), want it off", cfg.RPCCircuitBreaker, err)
+	}
+	for _, rate := range []string{"0", "1.5", "half"} {
+		if _, err := LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEYS": globalKey, "RPC_BREAKER_ERROR_RATE": rate}); err == nil || !strings.Contains(err.Error(), "RPC_BREAKER_ERROR_RATE") {
+			t.Errorf("RPC_BREAKER_ERROR_RATE=%s: error %v, want it named", rate, err)
+		}
+	}
+}
+
+func TestLoadInclusionMargins(t *testing.T) {
+	cfg, err := LoadConfigFrom(map[string]string{
+		"EVM_PRIVATE_KEYS":  globalKey,
+		"INCLUSION_MARGINS": "base:10s, polygon:2m,xdc:-1s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[types.Network]time.Duration{types.NetworkBase: 10 * time.Second, types.NetworkPolygon: 2 * time.Minute, types.NetworkXDC: -time.Second}
+	if !reflect.DeepEqual(cfg.InclusionMargins, want) {
+		t.Errorf("margins %v, want %v", cfg.InclusionMargins, want)
+	}
+
+	for _, raw := range []string{"base", "base:soon", "30s"} {
+		if _, err := LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEYS": globalKey, "INCLUSION_MARGINS": raw}); err == nil || !strings.Contains(err.Error(), "INCLUSION_MARGINS") {
+			t.Errorf("INCLUSION_MARGINS=%s: error %v, want it named", raw, err)
+		}
+	}
+}
+
+func TestLoadMinTimeout(t *testing.T) {
+	for value, want := range map[string]time.Duration{"": 0, "2m": 2 * time.Minute, "-1s": -time.Second} {
+		cfg, err := LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEYS": globalKey, "MIN_TIMEOUT": value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.MinTimeout != want {
+			t.Errorf("MIN_TIMEOUT=%q: minimum %v, want %v", value, cfg.MinTimeout, want)
+		}
+	}
+	if _, err := LoadConfigFrom(map[string]string{"EVM_PRIVATE_KEYS": globalKey, "MIN_TIMEOUT": "a minute"}); err == nil || !strings.Contains(err.Error(), "MIN_TIMEOUT") {
+		t.Errorf("malformed MIN_TIMEOUT: error %v, want it named", err)
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+)
+
+// EventPublisher creates the publisher of the configured EVENT_SINK
+// Returns nil for none, which publishes no settlement events
+func (c *Config) EventPublisher() (facilitator.EventPublisher, error) {
+	switch c.EventSink {
+	case EventSinkNone, "":
+		return nil, nil
+
+	case EventSinkNATS:
+		if c.NATSURL == "" {
+			return nil, fmt.Errorf("EVENT_SINK=nats requires NATS_URL")
+		}
+		publisher, err := facilitator.NewNATSPublisher(facilitator.NATSConfig{
+			URL:     c.NATSURL,
+			Subject: c.NATSSubject,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid NATS settings: %w", err)
+		}
+		return publisher, nil
+
+	case EventSinkWebhook:
+		if c.EventWebhookURL == "" {
+			return nil, fmt.Errorf("EVENT_SINK=webhook requires EVENT_WEBHOOK_URL")
+		}
+		publisher, err := facilitator.NewWebhookPublisher(c.EventWebhookURL, c.EventWebhookSecret, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EVENT_WEBHOOK_URL: %w", err)
+		}
+		return publisher, nil
+
+	default:
+		return nil, fmt.Errorf("unknown EVENT_SINK %q (want %s, %s or %s)", c.EventSink, EventSinkNone, EventSinkNATS, EventSinkWebhook)
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+)
+
+// Settlement signer backends (SIGNER_BACKEND)
+const (
+	SignerBackendLocal  = "local"
+	SignerBackendKMS    = "kms"
+	SignerBackendRemote = "remote"
+)
+
+// SettlementSigners connects the kms or remote settlement signers, fetching
+// each signer's address
+// Returns nil for the local backend, whose keys are passed per network
+func (c *Config) SettlementSigners(ctx context.Context) ([]evm.Signer, error) {
+	var signers []evm.Signer
+	switch c.SignerBackend {
+	case SignerBackendLocal:
+		return nil, nil
+
+	case SignerBackendKMS:
+		if len(c.KMSKeyIDs) == 0 {
+			return nil, fmt.Errorf("SIGNER_BACKEND=kms requires KMS_KEY_IDS")
+		}
+		for _, keyID := range c.KMSKeyIDs {
+			kmsConfig := c.KMS
+			kmsConfig.KeyID = keyID
+			signer, err := evm.NewKMSSigner(ctx, kmsConfig)
+			if err != nil {
+				return nil, err
+			}
+			signers = append(signers, signer)
+		}
+
+	case SignerBackendRemote:
+		if len(c.RemoteSignerURLs) == 0 {
+			return nil, fmt.Errorf("SIGNER_BACKEND=remote requires REMOTE_SIGNER_URLS")
+		}
+		timeout := c.RemoteSignerTimeout
+		if timeout <= 0 {
+			timeout = evm.DefaultRemoteSignerTimeout
+		}
+		httpClient := &http.Client{Timeout: timeout}
+		for _, url := range c.RemoteSignerURLs {
+			signer, err := evm.NewRemoteSigner(ctx, url, httpClient)
+			if err != nil {
+				return nil, err
+			}
+			signers = append(signers, signer)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown SIGNER_BACKEND %q (want %s, %s or %s)", c.SignerBackend, SignerBackendLocal, SignerBackendKMS, SignerBackendRemote)
+	}
+
+	seen := make(map[string]bool)
+	for _, signer := range signers {
+		addr := signer.Address().Hex()
+		if seen[addr] {
+			return nil, fmt.Errorf("%s signers include %s twice; duplicate signers race on the same nonce", c.SignerBackend, addr)
+		}
+		seen[addr] = true
+	}
+	return signers, nil
+}
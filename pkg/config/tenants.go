@@ -0,0 +1,208 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/middleware"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// TenantsFile is the JSON file of the teams sharing one facilitator
+// (TENANTS_FILE), e.g.
+//
+//	{"tenants": [{
+//		"id": "checkout",
+//		"apiKeys": ["..."],
+//		"networks": ["base", "base-sepolia"],
+//		"payTo": ["0x..."],
+//		"assets": ["0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"],
+//		"signers": ["0x..."],
+//		"rateLimit": {"requestsPerMinute": 600, "burst": 50}
+//	}]}
+type TenantsFile struct {
+	Tenants []TenantConfig `json:"tenants"`
+}
+
+// TenantConfig is one tenant of a TenantsFile; empty lists leave that
+// dimension open
+type TenantConfig struct {
+	ID string `json:"id"`
+
+	// Bearer tokens identifying the tenant; a tenant with keys must present
+	// one, a tenant without is identified by the X-Tenant-ID header alone
+	APIKeys []string `json:"apiKeys,omitempty"`
+
+	Networks []string `json:"networks,omitempty"` // Networks the tenant may pay on
+	PayTo    []string `json:"payTo,omitempty"`    // Addresses its payments may go to
+	Assets   []string `json:"assets,omitempty"`   // Tokens it accepts, within each network's whitelist
+
+	// Addresses of the settlement signers (configured as usual, e.g. with
+	// EVM_PRIVATE_KEYS or KMS_KEY_IDS) reserved for the tenant
+	Signers []string `json:"signers,omitempty"`
+
+	RateLimit *TenantRateLimit `json:"rateLimit,omitempty"`
+}
+
+// TenantRateLimit throttles all requests of one tenant
+type TenantRateLimit struct {
+	RequestsPerMinute int `json:"requestsPerMinute"`
+	Burst             int `json:"burst"`
+}
+
+// TenantRegistry holds the tenants of a TenantsFile: their policies for the
+// facilitator, and their keys and rate limits for middleware.TenantMiddleware
+type TenantRegistry struct {
+	policies []facilitator.TenantPolicy
+	keyed    map[string]bool // Tenants that must present an API key
+	keys     *middleware.APIKeys
+	limiters map[string]*middleware.RateLimiter
+}
+
+// LoadTenants reads the tenants file at path
+func LoadTenants(path string) (*TenantRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+	var file TenantsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid tenants file %s: %w", path, err)
+	}
+	registry, err := NewTenantRegistry(file.Tenants)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenants file %s: %w", path, err)
+	}
+	return registry, nil
+}
+
+// NewTenantRegistry checks and indexes the given tenants
+func NewTenantRegistry(tenants []TenantConfig) (*TenantRegistry, error) {
+	r := &TenantRegistry{
+		keyed:    make(map[string]bool),
+		keys:     &middleware.APIKeys{},
+		limiters: make(map[string]*middleware.RateLimiter),
+	}
+	seen := make(map[string]bool, len(tenants))
+	for _, tenant := range tenants {
+		if tenant.ID == "" {
+			return nil, fmt.Errorf("every tenant needs an id")
+		}
+		if seen[tenant.ID] {
+			return nil, fmt.Errorf("tenant %q is listed twice", tenant.ID)
+		}
+		seen[tenant.ID] = true
+
+		policy, err := tenant.policy()
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", tenant.ID, err)
+		}
+		r.policies = append(r.policies, policy)
+
+		for _, key := range tenant.APIKeys {
+			if err := r.keys.Add(tenant.ID, key); err != nil {
+				return nil, fmt.Errorf("tenant %q: %w", tenant.ID, err)
+			}
+			r.keyed[tenant.ID] = true
+		}
+		if limit := tenant.RateLimit; limit != nil && limit.RequestsPerMinute > 0 {
+			burst := limit.Burst
+			if burst <= 0 {
+				burst = limit.RequestsPerMinute
+			}
+			r.limiters[tenant.ID] = middleware.NewRateLimiter(limit.RequestsPerMinute, burst)
+		}
+	}
+	return r, nil
+}
+
+// policy converts the tenant's lists, checking every entry
+func (t TenantConfig) policy() (facilitator.TenantPolicy, error) {
+	policy := facilitator.TenantPolicy{ID: t.ID}
+	for _, name := range t.Networks {
+		net := types.Network(strings.ToLower(strings.TrimSpace(name)))
+		if _, err := network.GetNetworkInfo(net); err != nil {
+			return policy, fmt.Errorf("unknown network %q", name)
+		}
+		policy.Networks = append(policy.Networks, net)
+	}
+	for _, payTo := range t.PayTo {
+		if !common.IsHexAddress(types.NormalizeEVMAddress(payTo)) {
+			return policy, fmt.Errorf("invalid payTo address %q", payTo)
+		}
+		policy.PayTo = append(policy.PayTo, payTo)
+	}
+	for _, asset := range t.Assets {
+		if !common.IsHexAddress(types.NormalizeEVMAddress(asset)) {
+			return policy, fmt.Errorf("invalid asset address %q", asset)
+		}
+		policy.Assets = append(policy.Assets, common.HexToAddress(types.NormalizeEVMAddress(asset)))
+	}
+	for _, signer := range t.Signers {
+		if !common.IsHexAddress(types.NormalizeEVMAddress(signer)) {
+			return policy, fmt.Errorf("invalid signer address %q", signer)
+		}
+		policy.Signers = append(policy.Signers, common.HexToAddress(types.NormalizeEVMAddress(signer)))
+	}
+	return policy, nil
+}
+
+// Len returns the number of tenants
+func (r *TenantRegistry) Len() int {
+	return len(r.policies)
+}
+
+// Policies returns the tenants' policies (see facilitator.Builder.WithTenants)
+func (r *TenantRegistry) Policies() []facilitator.TenantPolicy {
+	return append([]facilitator.TenantPolicy(nil), r.policies...)
+}
+
+// APIKeys returns the tenants' keys, labelled with the tenant ID
+func (r *TenantRegistry) APIKeys() *middleware.APIKeys {
+	return r.keys
+}
+
+// ResolveTenant implements middleware.TenantResolver: an API key of a tenant
+// identifies it, and must match the X-Tenant-ID header if both are sent
+func (r *TenantRegistry) ResolveTenant(id, apiKey string) (string, *middleware.RateLimiter, error) {
+	if apiKey != "" {
+		if owner, ok := r.keys.Lookup(apiKey); ok {
+			if id != "" && id != owner {
+				return "", nil, fmt.Errorf("the API key does not belong to tenant %q", id)
+			}
+			return owner, r.limiters[owner], nil
+		}
+	}
+	// Not a tenant key (it may be a SETTLE_API_KEYS key): go by the header
+	if id == "" {
+		return "", nil, fmt.Errorf("unknown tenant API key (send the %s header or a tenant's key)", types.HeaderTenantID)
+	}
+	if !r.known(id) {
+		return "", nil, fmt.Errorf("unknown tenant %q", id)
+	}
+	if r.keyed[id] {
+		return "", nil, fmt.Errorf("tenant %q requires one of its API keys", id)
+	}
+	return id, r.limiters[id], nil
+}
+
+func (r *TenantRegistry) known(id string) bool {
+	for _, policy := range r.policies {
+		if policy.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop stops the tenants' rate limiters
+func (r *TenantRegistry) Stop() {
+	for _, limiter := range r.limiters {
+		limiter.Stop()
+	}
+}
@@ -0,0 +1,286 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Validation holds the problems found by Validate
+// Errors prevent the facilitator from starting; warnings are worth a look
+type Validation struct {
+	Errors   []string
+	Warnings []string
+}
+
+// OK reports whether validation found no errors
+func (v *Validation) OK() bool {
+	return len(v.Errors) == 0
+}
+
+func (v *Validation) errorf(format string, args ...interface{}) {
+	v.Errors = append(v.Errors, fmt.Sprintf(format, args...))
+}
+
+func (v *Validation) warnf(format string, args ...interface{}) {
+	v.Warnings = append(v.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Validate checks the configuration for mistakes that would otherwise only
+// surface at runtime (bad keys, networks that cannot settle, typoed variables)
+func (c *Config) Validate() *Validation {
+	v := &Validation{}
+
+	c.validateEnvNames(v)
+	c.validateKeyLists(v)
+	external := c.validateSignerBackend(v)
+
+	switch c.Mode {
+	case FacilitatorModeFull:
+	case FacilitatorModeVerifyOnly:
+		// Nothing is signed, so missing keys are fine
+		external = true
+		if len(c.EVMPrivateKeys) > 0 || len(c.NetworkPrivateKeys) > 0 {
+			v.warnf("FACILITATOR_MODE=%s; EVM private keys are ignored", FacilitatorModeVerifyOnly)
+		}
+	default:
+		v.errorf("unknown FACILITATOR_MODE %q (want %s or %s)", c.Mode, FacilitatorModeFull, FacilitatorModeVerifyOnly)
+	}
+
+	if c.ReceiptSigningKey != "" {
+		if _, err := KeyAddress(c.ReceiptSigningKey); err != nil {
+			v.errorf("RECEIPT_SIGNING_KEY is not a valid private key: %v", err)
+		}
+	}
+
+	if c.RoutePrefix != "" && (!strings.HasPrefix(c.RoutePrefix, "/") || strings.ContainsAny(c.RoutePrefix, " \t?#{}")) {
+		v.errorf("invalid ROUTE_PREFIX %q (want a path such as /x402)", c.RoutePrefix)
+	}
+
+	switch {
+	case (c.TLSCertFile == "") != (c.TLSKeyFile == ""):
+		v.errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	case c.TLSClientAuth == TLSClientAuthNone:
+	case c.TLSClientAuth != TLSClientAuthRequire:
+		v.errorf("unknown TLS_CLIENT_AUTH %q (want %s or %s)", c.TLSClientAuth, TLSClientAuthNone, TLSClientAuthRequire)
+	case c.TLSCertFile == "":
+		v.errorf("TLS_CLIENT_AUTH=%s needs TLS_CERT_FILE and TLS_KEY_FILE (the facilitator must terminate TLS)", TLSClientAuthRequire)
+	case c.TLSClientCAFile == "":
+		v.errorf("TLS_CLIENT_AUTH=%s needs TLS_CLIENT_CA_FILE", TLSClientAuthRequire)
+	}
+
+	if c.AllowSelfPayTo {
+		v.warnf("ALLOW_SELF_PAYTO=true; payments to the facilitator's own signer addresses are accepted")
+	}
+
+	if c.XDCAddressPrefix != "0x" && c.XDCAddressPrefix != types.XDCAddressPrefix {
+		v.errorf("unknown XDC_ADDRESS_PREFIX %q (want 0x or %s)", c.XDCAddressPrefix, types.XDCAddressPrefix)
+	}
+
+	if _, err := c.EventPublisher(); err != nil {
+		v.errorf("%v", err)
+	} else if c.EventSink != EventSinkNone && c.UpstreamURL != "" {
+		v.warnf("EVENT_SINK=%s is ignored in proxy mode; the upstream facilitator publishes settlement events", c.EventSink)
+	}
+
+	if len(c.PayerDenylist) > 0 && c.UpstreamURL != "" {
+		v.warnf("PAYER_DENYLIST is ignored in proxy mode")
+	}
+	if (c.GasBudgetDaily != nil || len(c.GasBudgetOverrides) > 0) && c.UpstreamURL != "" {
+		v.warnf("GAS_BUDGET_DAILY_WEI and GAS_BUDGET_FILE are ignored in proxy mode; the upstream facilitator pays for gas")
+	}
+	if c.Tenants != nil && c.UpstreamURL != "" {
+		v.warnf("TENANTS_FILE policies are not enforced in proxy mode; only tenant identification and rate limits apply")
+	}
+
+	// Proxy mode settles upstream, so local RPCs and keys are not needed
+	if c.UpstreamURL != "" {
+		return v
+	}
+
+	evmNetworks := 0
+	for _, net := range sortedNetworks(c.RPCURLs) {
+		switch {
+		case net.IsEVM():
+			evmNetworks++
+			if external || len(c.PrivateKeysFor(net)) > 0 {
+				continue
+			}
+			if net.IsTestnet() {
+				v.warnf("%s has an RPC URL but no keys and will be skipped (set %s or EVM_PRIVATE_KEYS)", net, networkKeysEnv(net))
+			} else {
+				v.errorf("%s has an RPC URL but no keys to settle with (set %s or EVM_PRIVATE_KEYS)", net, networkKeysEnv(net))
+			}
+		case net.IsSolana():
+			if c.SolanaPrivateKey == "" {
+				v.errorf("%s has an RPC URL but SOLANA_PRIVATE_KEY is not set", net)
+			} else {
+				v.warnf("%s is advertised in /supported but Solana settlement is not enabled in this build", net)
+			}
+		}
+	}
+	if evmNetworks == 0 {
+		v.errorf("no EVM networks configured (set at least one RPC_URL_<NETWORK>)")
+	}
+
+	for net := range c.NetworkPrivateKeys {
+		if _, ok := c.RPCURLs[net]; !ok {
+			v.warnf("%s is set but %s has no RPC URL, so its keys are unused", networkKeysEnv(net), net)
+		}
+	}
+	for net := range c.MinSignerBalances {
+		if _, ok := c.RPCURLs[net]; !ok {
+			v.warnf("%s is set but %s has no RPC URL, so it is unused", minSignerBalanceEnv(net), net)
+		}
+	}
+	for _, net := range sortedNetworks(c.BalanceBlockTags) {
+		if _, ok := c.RPCURLs[net]; !ok {
+			v.warnf("BALANCE_BLOCK_TAGS names %s, which has no RPC URL", net)
+		}
+	}
+
+	return v
+}
+
+// validateSignerBackend checks the settings of SIGNER_BACKEND and reports
+// whether settlement uses external (kms or remote) signers
+func (c *Config) validateSignerBackend(v *Validation) bool {
+	switch c.SignerBackend {
+	case SignerBackendLocal:
+		return false
+	case SignerBackendKMS:
+		if len(c.KMSKeyIDs) == 0 {
+			v.errorf("SIGNER_BACKEND=kms but KMS_KEY_IDS is not set")
+		}
+		if c.KMS.Region == "" {
+			v.errorf("SIGNER_BACKEND=kms but AWS_REGION is not set")
+		}
+		if c.KMS.AccessKeyID == "" || c.KMS.SecretAccessKey == "" {
+			v.errorf("SIGNER_BACKEND=kms but AWS_ACCESS_KEY_ID or AWS_SECRET_ACCESS_KEY is not set")
+		}
+	case SignerBackendRemote:
+		if len(c.RemoteSignerURLs) == 0 {
+			v.errorf("SIGNER_BACKEND=remote but REMOTE_SIGNER_URLS is not set")
+		}
+		for i, raw := range c.RemoteSignerURLs {
+			if u, err := url.Parse(raw); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				v.errorf("REMOTE_SIGNER_URLS entry %d (%q) is not an http(s) URL", i+1, raw)
+			}
+		}
+	default:
+		v.errorf("unknown SIGNER_BACKEND %q (want %s, %s or %s)", c.SignerBackend, SignerBackendLocal, SignerBackendKMS, SignerBackendRemote)
+		return false
+	}
+
+	if len(c.EVMPrivateKeys) > 0 || len(c.NetworkPrivateKeys) > 0 {
+		v.warnf("SIGNER_BACKEND=%s; EVM_PRIVATE_KEY(S) settings are ignored", c.SignerBackend)
+	}
+	return true
+}
+
+// validateKeyLists checks every private key variable for malformed entries,
+// invalid keys and duplicates
+func (c *Config) validateKeyLists(v *Validation) {
+	vars := []string{"EVM_PRIVATE_KEY", "EVM_PRIVATE_KEYS"}
+	for _, net := range sortedNetworks(c.NetworkPrivateKeys) {
+		vars = append(vars, networkKeysEnv(net))
+	}
+
+	for _, name := range vars {
+		raw := c.env.get(name)
+		if raw == "" {
+			continue
+		}
+		seen := make(map[common.Address]int)
+		for i, key := range strings.Split(raw, ",") {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				v.warnf("%s has an empty entry at position %d (check for stray commas)", name, i+1)
+				continue
+			}
+			addr, err := KeyAddress(key)
+			if err != nil {
+				v.errorf("%s entry %d is not a valid private key: %v", name, i+1, err)
+				continue
+			}
+			if first, dup := seen[addr]; dup {
+				v.errorf("%s entry %d duplicates entry %d (signer %s); duplicate signers race on the same nonce", name, i+1, first, addr.Hex())
+				continue
+			}
+			seen[addr] = i + 1
+		}
+	}
+
+	if c.env.get("EVM_PRIVATE_KEY") != "" && c.env.get("EVM_PRIVATE_KEYS") != "" {
+		v.warnf("both EVM_PRIVATE_KEY and EVM_PRIVATE_KEYS are set; EVM_PRIVATE_KEY is ignored")
+	}
+}
+
+// validateEnvNames flags per-network variables that name no known network
+func (c *Config) validateEnvNames(v *Validation) {
+	knownRPC := make(map[string]bool)
+	for _, name := range rpcURLEnv {
+		knownRPC[name] = true
+	}
+	for _, net := range c.CustomNetworks {
+		knownRPC[customRPCURLEnv(net)] = true
+	}
+	knownKeys := make(map[string]bool)
+	knownBalances := make(map[string]bool)
+	for _, info := range network.Networks() {
+		if net := info.Network; net.IsEVM() {
+			knownKeys[networkKeysEnv(net)] = true
+			knownBalances[minSignerBalanceEnv(net)] = true
+		}
+	}
+
+	names := make([]string, 0, len(c.env))
+	for name := range c.env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch {
+		case strings.HasPrefix(name, "RPC_URL_") && !knownRPC[name]:
+			v.warnf("unknown network variable %s is ignored (known: %s)", name, strings.Join(sortedKeys(knownRPC), ", "))
+		case strings.HasPrefix(name, "EVM_PRIVATE_KEYS_") && !knownKeys[name]:
+			v.warnf("unknown network variable %s is ignored (known: %s)", name, strings.Join(sortedKeys(knownKeys), ", "))
+		case strings.HasPrefix(name, "MIN_SIGNER_BALANCE_WEI_") && !knownBalances[name]:
+			v.warnf("unknown network variable %s is ignored (known: %s)", name, strings.Join(sortedKeys(knownBalances), ", "))
+		}
+	}
+}
+
+// KeyAddress returns the signer address of a hex private key (0x optional)
+func KeyAddress(key string) (common.Address, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(key, "0x"))
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(privateKey.PublicKey), nil
+}
+
+func sortedNetworks[V any](m map[types.Network]V) []types.Network {
+	nets := make([]types.Network, 0, len(m))
+	for net := range m {
+		nets = append(nets, net)
+	}
+	sort.Slice(nets, func(i, j int) bool { return nets[i] < nets[j] })
+	return nets
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
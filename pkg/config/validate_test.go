@@ -0,0 +1,200 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// validEnv is a minimal configuration that passes validation
+func validEnv(extra map[string]string) map[string]string {
+	vars := map[string]string{
+		"RPC_URL_BASE_SEPOLIA": unreachableRPC,
+		"EVM_PRIVATE_KEYS":     globalKey,
+	}
+	for name, value := range extra {
+		vars[name] = value
+	}
+	return vars
+}
+
+// containing reports whether one of msgs contains substr
+func containing(msgs []string, substr string) bool {
+	for _, msg := range msgs {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateAcceptsMinimalConfig(t *testing.T) {
+	cfg, err := LoadConfigFrom(validEnv(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := cfg.Validate(); !v.OK() || len(v.Warnings) != 0 {
+		t.Errorf("errors %v, warnings %v; want none", v.Errors, v.Warnings)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		vars    map[string]string
+		err     string // Substring of an expected error
+		warning string // Substring of an expected warning
+	}{
+		{
+			name: "mainnet RPC without keys",
+			vars: map[string]string{"RPC_URL_BASE": unreachableRPC, "EVM_PRIVATE_KEYS_BASE_SEPOLIA": sepoliaKey, "RPC_URL_BASE_SEPOLIA": unreachableRPC},
+			err:  "base has an RPC URL but no keys",
+		},
+		{
+			name:    "testnet RPC without keys",
+			vars:    map[string]string{"RPC_URL_BASE": unreachableRPC, "EVM_PRIVATE_KEYS_BASE": globalKey, "RPC_URL_POLYGON_AMOY": unreachableRPC},
+			warning: "polygon-amoy has an RPC URL but no keys and will be skipped",
+		},
+		{
+			name: "no EVM networks",
+			vars: map[string]string{"EVM_PRIVATE_KEYS": globalKey},
+			err:  "no EVM networks configured",
+		},
+		{
+			name: "Solana RPC without a Solana key",
+			vars: validEnv(map[string]string{"RPC_URL_SOLANA_DEVNET": unreachableRPC}),
+			err:  "SOLANA_PRIVATE_KEY is not set",
+		},
+		{
+			name:    "balance threshold without an RPC URL",
+			vars:    validEnv(map[string]string{"MIN_SIGNER_BALANCE_WEI_POLYGON": "1"}),
+			warning: "MIN_SIGNER_BALANCE_WEI_POLYGON is set but polygon has no RPC URL",
+		},
+		{
+			name:    "balance threshold for an unknown network",
+			vars:    validEnv(map[string]string{"MIN_SIGNER_BALANCE_WEI_BASE_MAINNET": "1"}),
+			warning: "unknown network variable MIN_SIGNER_BALANCE_WEI_BASE_MAINNET is ignored",
+		},
+		{
+			name: "unknown XDC address prefix",
+			vars: validEnv(map[string]string{"XDC_ADDRESS_PREFIX": "XDC"}),
+			err:  `unknown XDC_ADDRESS_PREFIX "XDC"`,
+		},
+		{
+			name:    "empty key list entry",
+			vars:    validEnv(map[string]string{"EVM_PRIVATE_KEYS": globalKey + ",," + sepoliaKey}),
+			warning: "EVM_PRIVATE_KEYS has an empty entry at position 2",
+		},
+		{
+			name: "duplicate keys",
+			vars: validEnv(map[string]string{"EVM_PRIVATE_KEYS": globalKey + ", " + sepoliaKey + ", " + strings.TrimPrefix(globalKey, "0x")}),
+			err:  "EVM_PRIVATE_KEYS entry 3 duplicates entry 1",
+		},
+		{
+			name: "invalid key",
+			vars: validEnv(map[string]string{"EVM_PRIVATE_KEYS_BASE_SEPOLIA": "0x1234"}),
+			err:  "EVM_PRIVATE_KEYS_BASE_SEPOLIA entry 1 is not a valid private key",
+		},
+		{
+			name:    "both key variables",
+			vars:    validEnv(map[string]string{"EVM_PRIVATE_KEY": sepoliaKey}),
+			warning: "EVM_PRIVATE_KEY is ignored",
+		},
+		{
+			name:    "unknown RPC network variable",
+			vars:    validEnv(map[string]string{"RPC_URL_BASE_GOERLI": unreachableRPC}),
+			warning: "unknown network variable RPC_URL_BASE_GOERLI",
+		},
+		{
+			name:    "unknown key network variable",
+			vars:    validEnv(map[string]string{"EVM_PRIVATE_KEYS_NOT_A_NETWORK": amoyKey}),
+			warning: "unknown network variable EVM_PRIVATE_KEYS_NOT_A_NETWORK",
+		},
+		{
+			name:    "keys for a network without an RPC URL",
+			vars:    validEnv(map[string]string{"EVM_PRIVATE_KEYS_POLYGON_AMOY": amoyKey}),
+			warning: "EVM_PRIVATE_KEYS_POLYGON_AMOY is set but polygon-amoy has no RPC URL",
+		},
+		{
+			name: "unknown facilitator mode",
+			vars: validEnv(map[string]string{"FACILITATOR_MODE": "settle-only"}),
+			err:  `unknown FACILITATOR_MODE "settle-only"`,
+		},
+		{
+			name:    "keys in verify-only mode",
+			vars:    validEnv(map[string]string{"FACILITATOR_MODE": FacilitatorModeVerifyOnly}),
+			warning: "FACILITATOR_MODE=verify-only; EVM private keys are ignored",
+		},
+		{
+			name: "kms backend without key IDs",
+			vars: validEnv(map[string]string{"SIGNER_BACKEND": SignerBackendKMS}),
+			err:  "KMS_KEY_IDS is not set",
+		},
+		{
+			name: "remote signer URL that is not http",
+			vars: validEnv(map[string]string{"SIGNER_BACKEND": SignerBackendRemote, "REMOTE_SIGNER_URLS": "ftp://signer.internal"}),
+			err:  "REMOTE_SIGNER_URLS entry 1",
+		},
+		{
+			name: "TLS certificate without a key",
+			vars: validEnv(map[string]string{"TLS_CERT_FILE": "cert.pem"}),
+			err:  "TLS_CERT_FILE and TLS_KEY_FILE must be set together",
+		},
+		{
+			name: "route prefix that is not a path",
+			vars: validEnv(map[string]string{"ROUTE_PREFIX": "x402"}),
+			err:  `invalid ROUTE_PREFIX "x402"`,
+		},
+		{
+			name: "unknown client auth",
+			vars: validEnv(map[string]string{"TLS_CLIENT_AUTH": "optional"}),
+			err:  `unknown TLS_CLIENT_AUTH "optional"`,
+		},
+		{
+			name: "client auth without TLS",
+			vars: validEnv(map[string]string{"TLS_CLIENT_AUTH": "require", "TLS_CLIENT_CA_FILE": "ca.pem"}),
+			err:  "TLS_CLIENT_AUTH=require needs TLS_CERT_FILE and TLS_KEY_FILE",
+		},
+		{
+			name: "client auth without a CA",
+			vars: validEnv(map[string]string{"TLS_CLIENT_AUTH": "require", "TLS_CERT_FILE": "cert.pem", "TLS_KEY_FILE": "key.pem"}),
+			err:  "TLS_CLIENT_AUTH=require needs TLS_CLIENT_CA_FILE",
+		},
+		{
+			name: "client auth",
+			vars: validEnv(map[string]string{"TLS_CLIENT_AUTH": "require", "TLS_CERT_FILE": "cert.pem", "TLS_KEY_FILE": "key.pem", "TLS_CLIENT_CA_FILE": "ca.pem"}),
+		},
+		{
+			name:    "self payTo allowed",
+			vars:    validEnv(map[string]string{"ALLOW_SELF_PAYTO": "true"}),
+			warning: "ALLOW_SELF_PAYTO=true",
+		},
+	} {
+		cfg, err := LoadConfigFrom(tc.vars)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		v := cfg.Validate()
+		if tc.err != "" && !containing(v.Errors, tc.err) {
+			t.Errorf("%s: errors %v, want one containing %q", tc.name, v.Errors, tc.err)
+		}
+		if tc.err == "" && !v.OK() {
+			t.Errorf("%s: unexpected errors %v", tc.name, v.Errors)
+		}
+		if tc.warning != "" && !containing(v.Warnings, tc.warning) {
+			t.Errorf("%s: warnings %v, want one containing %q", tc.name, v.Warnings, tc.warning)
+		}
+	}
+}
+
+func TestValidateVerifyOnlyNeedsNoKeys(t *testing.T) {
+	cfg, err := LoadConfigFrom(map[string]string{
+		"FACILITATOR_MODE": FacilitatorModeVerifyOnly,
+		"RPC_URL_BASE":     unreachableRPC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := cfg.Validate(); !v.OK() || len(v.Warnings) != 0 {
+		t.Errorf("errors %v, warnings %v; want none", v.Errors, v.Warnings)
+	}
+}
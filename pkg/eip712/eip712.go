@@ -0,0 +1,227 @@
+// Package eip712 computes and checks the EIP-712 signatures of ERC-3009
+// authorizations (transfer, receive and cancel) and Permit2 permits without
+// an RPC connection, so services can validate x402 payments offline. It is
+// the one definition of the typed data the paying client signs and the
+// provider verifies.
+package eip712
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Domain is the EIP-712 domain of the token contract the authorization is for
+type Domain struct {
+	Name              string
+	Version           string
+	ChainID           *big.Int
+	VerifyingContract common.Address
+}
+
+// TokenDomain returns the domain of token on network, with the name and
+// version from the token registry (see network.TokenDomain)
+func TokenDomain(net types.Network, chainID *big.Int, token common.Address) Domain {
+	name, version := network.TokenDomain(net, token)
+	return Domain{
+		Name:              name,
+		Version:           version,
+		ChainID:           chainID,
+		VerifyingContract: token,
+	}
+}
+
+// Primary types of the ERC-3009 typed-data messages
+const (
+	PrimaryTransferWithAuthorization = "TransferWithAuthorization"
+	PrimaryReceiveWithAuthorization  = "ReceiveWithAuthorization"
+	PrimaryCancelAuthorization       = "CancelAuthorization"
+)
+
+// transferFields are the fields of both TransferWithAuthorization and
+// ReceiveWithAuthorization
+var transferFields = []apitypes.Type{
+	{Name: "from", Type: "address"},
+	{Name: "to", Type: "address"},
+	{Name: "value", Type: "uint256"},
+	{Name: "validAfter", Type: "uint256"},
+	{Name: "validBefore", Type: "uint256"},
+	{Name: "nonce", Type: "bytes32"},
+}
+
+// AuthorizationTypes are the ERC-3009 typed-data types, shared by every
+// signer and verifier so their definitions cannot drift apart. Typed data
+// built here carries only the domain and its own primary type, as strict
+// encoders (ethers.js) reject unused types.
+var AuthorizationTypes = apitypes.Types{
+	"EIP712Domain": []apitypes.Type{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	PrimaryTransferWithAuthorization: transferFields,
+	PrimaryReceiveWithAuthorization:  transferFields,
+	PrimaryCancelAuthorization: []apitypes.Type{
+		{Name: "authorizer", Type: "address"},
+		{Name: "nonce", Type: "bytes32"},
+	},
+}
+
+// typesFor returns the domain type and primaryType
+func typesFor(primaryType string) apitypes.Types {
+	return apitypes.Types{
+		"EIP712Domain": AuthorizationTypes["EIP712Domain"],
+		primaryType:    AuthorizationTypes[primaryType],
+	}
+}
+
+// typedDataDomain converts domain to its typed-data form
+func typedDataDomain(domain Domain) apitypes.TypedDataDomain {
+	return apitypes.TypedDataDomain{
+		Name:              domain.Name,
+		Version:           domain.Version,
+		ChainId:           (*math.HexOrDecimal256)(domain.ChainID),
+		VerifyingContract: domain.VerifyingContract.Hex(),
+	}
+}
+
+// transferMessage is the typed-data message of a transfer or receive authorization
+func transferMessage(auth *types.ExactEvmPayloadAuthorization) apitypes.TypedDataMessage {
+	return apitypes.TypedDataMessage{
+		"from":        auth.From.Hex(),
+		"to":          auth.To.Hex(),
+		"value":       auth.Value,
+		"validAfter":  auth.ValidAfter,
+		"validBefore": auth.ValidBefore,
+		"nonce":       auth.Nonce.String(),
+	}
+}
+
+// TypedDataForAuthorization returns the TransferWithAuthorization typed data
+// of auth in domain, as wallets (eth_signTypedData_v4) sign it
+func TypedDataForAuthorization(auth *types.ExactEvmPayloadAuthorization, domain Domain) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types:       typesFor(PrimaryTransferWithAuthorization),
+		PrimaryType: PrimaryTransferWithAuthorization,
+		Domain:      typedDataDomain(domain),
+		Message:     transferMessage(auth),
+	}
+}
+
+// TypedDataForReceiveAuthorization returns the ReceiveWithAuthorization
+// typed data of auth in domain (the variant only the payee may submit)
+func TypedDataForReceiveAuthorization(auth *types.ExactEvmPayloadAuthorization, domain Domain) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types:       typesFor(PrimaryReceiveWithAuthorization),
+		PrimaryType: PrimaryReceiveWithAuthorization,
+		Domain:      typedDataDomain(domain),
+		Message:     transferMessage(auth),
+	}
+}
+
+// TypedDataForCancelAuthorization returns the CancelAuthorization typed data
+// revoking authorizer's nonce in domain
+func TypedDataForCancelAuthorization(authorizer common.Address, nonce string, domain Domain) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types:       typesFor(PrimaryCancelAuthorization),
+		PrimaryType: PrimaryCancelAuthorization,
+		Domain:      typedDataDomain(domain),
+		Message: apitypes.TypedDataMessage{
+			"authorizer": authorizer.Hex(),
+			"nonce":      nonce,
+		},
+	}
+}
+
+// HashTypedData returns the EIP-712 digest of typedData:
+// keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+func HashTypedData(typedData apitypes.TypedData) (common.Hash, error) {
+	if typedData.Domain.ChainId == nil {
+		return common.Hash{}, errors.New("domain chain ID is required")
+	}
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to hash domain: %w", err)
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to hash message: %w", err)
+	}
+
+	rawData := []byte(fmt.Sprintf("\x19\x01%s%s", string(domainSeparator), string(messageHash)))
+	return crypto.Keccak256Hash(rawData), nil
+}
+
+// HashTransferWithAuthorization returns the EIP-712 digest a payer signs to
+// authorize auth: keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(auth))
+func HashTransferWithAuthorization(auth *types.ExactEvmPayloadAuthorization, domain Domain) (common.Hash, error) {
+	return HashTypedData(TypedDataForAuthorization(auth, domain))
+}
+
+// SignTransferWithAuthorization signs auth with key, returning the 65-byte
+// [R || S || V] signature with V of 27 or 28
+func SignTransferWithAuthorization(auth *types.ExactEvmPayloadAuthorization, domain Domain, key *ecdsa.PrivateKey) ([]byte, error) {
+	return SignTypedData(TypedDataForAuthorization(auth, domain), key)
+}
+
+// SignTypedData signs typedData with key (see SignTransferWithAuthorization)
+func SignTypedData(typedData apitypes.TypedData, key *ecdsa.PrivateKey) ([]byte, error) {
+	hash, err := HashTypedData(typedData)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	signature[64] += 27
+	return signature, nil
+}
+
+// RecoverSigner returns the address that produced signature (65 bytes, V of
+// 0/1 or 27/28) over auth in domain. The caller compares it with
+// auth.From; a well-formed signature by the wrong key recovers another address.
+func RecoverSigner(auth *types.ExactEvmPayloadAuthorization, signature []byte, domain Domain) (common.Address, error) {
+	return RecoverTypedDataSigner(TypedDataForAuthorization(auth, domain), signature)
+}
+
+// RecoverTypedDataSigner returns the address that produced signature over
+// typedData (see RecoverSigner)
+func RecoverTypedDataSigner(typedData apitypes.TypedData, signature []byte) (common.Address, error) {
+	hash, err := HashTypedData(typedData)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	if len(signature) != 65 {
+		return common.Address{}, fmt.Errorf("invalid signature length: %d", len(signature))
+	}
+	sigBytes := append([]byte(nil), signature...) // V is adjusted in place
+	if sigBytes[64] >= 27 {
+		sigBytes[64] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(hash.Bytes(), sigBytes)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover pubkey: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// VerifySignature reports whether signature over auth in domain was made by auth.From
+func VerifySignature(auth *types.ExactEvmPayloadAuthorization, signature []byte, domain Domain) (bool, error) {
+	signer, err := RecoverSigner(auth, signature, domain)
+	if err != nil {
+		return false, err
+	}
+	return signer == auth.From, nil
+}
@@ -0,0 +1,243 @@
+package eip712
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Type hashes as published in Circle's FiatTokenV2 (EIP3009.sol, EIP712.sol)
+var (
+	domainTypeHash   = common.HexToHash("0x8b73c3c69bb8fe3d512ecc4cf759cc79239f7b179b0ffacaa9a75d522b39400f")
+	transferTypeHash = common.HexToHash("0x7c7c6cdb67a18743f49ec6fa9b35f50d52ed05cbed4cc592e13b44501c1a2267")
+	receiveTypeHash  = common.HexToHash("0xd099cc98ef71107a616c4f0f941f04c322d8e254fe26b3c6668db87aae413de8")
+	cancelTypeHash   = common.HexToHash("0x158b0a9edf7a828aad02f63cd515c68ef2f50ba807396f6d12842833a1597429")
+)
+
+// word ABI-encodes v as a 32-byte word
+func word(v interface{}) []byte {
+	switch v := v.(type) {
+	case common.Address:
+		return common.LeftPadBytes(v.Bytes(), 32)
+	case common.Hash:
+		return v.Bytes()
+	case string:
+		n, ok := math.ParseBig256(v)
+		if !ok {
+			panic("invalid uint256 " + v)
+		}
+		return math.U256Bytes(n)
+	case *big.Int:
+		return math.U256Bytes(new(big.Int).Set(v))
+	}
+	panic("unsupported word")
+}
+
+// manualDigest hashes a message by hand, as a contract computes it:
+// keccak256("\x19\x01" ‖ domainSeparator ‖ keccak256(typeHash ‖ fields))
+func manualDigest(domain Domain, typeHash common.Hash, fields ...interface{}) common.Hash {
+	separator := crypto.Keccak256(
+		domainTypeHash.Bytes(),
+		crypto.Keccak256([]byte(domain.Name)),
+		crypto.Keccak256([]byte(domain.Version)),
+		word(domain.ChainID),
+		word(domain.VerifyingContract),
+	)
+	encoded := [][]byte{typeHash.Bytes()}
+	for _, field := range fields {
+		encoded = append(encoded, word(field))
+	}
+	return crypto.Keccak256Hash([]byte("\x19\x01"), separator, crypto.Keccak256(encoded...))
+}
+
+// vectorAuth is a fixed authorization for the digest vectors
+func vectorAuth(value, validAfter, validBefore, nonce string) *types.ExactEvmPayloadAuthorization {
+	decoded, err := types.DecodeHex(nonce)
+	if err != nil {
+		panic(err)
+	}
+	return &types.ExactEvmPayloadAuthorization{
+		From:        common.HexToAddress("0x857b06519E91e3A54538791bDbb0E22373e36b66"),
+		To:          common.HexToAddress("0x209693Bc6afc0C5328bA36FaF03C514EF312287C"),
+		Value:       value,
+		ValidAfter:  validAfter,
+		ValidBefore: validBefore,
+		Nonce:       decoded,
+	}
+}
+
+func TestTypeHashesMatchFiatToken(t *testing.T) {
+	for primary, want := range map[string]common.Hash{
+		"EIP712Domain":                   domainTypeHash,
+		PrimaryTransferWithAuthorization: transferTypeHash,
+		PrimaryReceiveWithAuthorization:  receiveTypeHash,
+		PrimaryCancelAuthorization:       cancelTypeHash,
+	} {
+		td := TypedDataForAuthorization(vectorAuth("1", "0", "1", "0x00"), Domain{ChainID: big.NewInt(1)})
+		td.Types = typesFor(primary)
+		if got := common.BytesToHash(td.TypeHash(primary)); got != want {
+			t.Errorf("%s type hash %s, want %s (%s)", primary, got, want, td.EncodeType(primary))
+		}
+	}
+}
+
+// The digests are checked against manualDigest, an encoding independent of
+// go-ethereum's typed-data code and anchored to the contract's type hashes
+func TestHashTransferWithAuthorizationVectors(t *testing.T) {
+	baseSepolia := Domain{Name: "USDC", Version: "2", ChainID: big.NewInt(84532), VerifyingContract: common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e")}
+	base := Domain{Name: "USD Coin", Version: "2", ChainID: big.NewInt(8453), VerifyingContract: common.HexToAddress("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913")}
+	for _, tc := range []struct {
+		name   string
+		auth   *types.ExactEvmPayloadAuthorization
+		domain Domain
+	}{
+		{"base-sepolia", vectorAuth("10000", "1740672089", "1740672154", "0xf3746613c2d920b5fdabc0856f2aeb2d4f88ee6037b8cc5d04a71a4462f13480"), baseSepolia},
+		{"base", vectorAuth("1", "0", "1893456000", "0x0000000000000000000000000000000000000000000000000000000000000001"), base},
+		{"max values", vectorAuth("115792089237316195423570985008687907853269984665640564039457584007913129639935", "0", "18446744073709551615", "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"), base},
+	} {
+		auth := tc.auth
+		want := manualDigest(tc.domain, transferTypeHash, auth.From, auth.To, auth.Value, auth.ValidAfter, auth.ValidBefore, common.BytesToHash(auth.Nonce))
+		got, err := HashTransferWithAuthorization(auth, tc.domain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: digest %s, want %s", tc.name, got, want)
+		}
+
+		receive, err := HashTypedData(TypedDataForReceiveAuthorization(auth, tc.domain))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := manualDigest(tc.domain, receiveTypeHash, auth.From, auth.To, auth.Value, auth.ValidAfter, auth.ValidBefore, common.BytesToHash(auth.Nonce)); receive != want {
+			t.Errorf("%s: receive digest %s, want %s", tc.name, receive, want)
+		}
+		cancel, err := HashTypedData(TypedDataForCancelAuthorization(auth.From, auth.Nonce.String(), tc.domain))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := manualDigest(tc.domain, cancelTypeHash, auth.From, common.BytesToHash(auth.Nonce)); cancel != want {
+			t.Errorf("%s: cancel digest %s, want %s", tc.name, cancel, want)
+		}
+	}
+
+	// Every domain field is part of the digest
+	auth := vectorAuth("10000", "0", "1", "0x0000000000000000000000000000000000000000000000000000000000000001")
+	reference, _ := HashTransferWithAuthorization(auth, base)
+	for name, domain := range map[string]Domain{
+		"name":     {Name: "USDC", Version: base.Version, ChainID: base.ChainID, VerifyingContract: base.VerifyingContract},
+		"version":  {Name: base.Name, Version: "1", ChainID: base.ChainID, VerifyingContract: base.VerifyingContract},
+		"chain":    {Name: base.Name, Version: base.Version, ChainID: big.NewInt(1), VerifyingContract: base.VerifyingContract},
+		"contract": {Name: base.Name, Version: base.Version, ChainID: base.ChainID, VerifyingContract: baseSepolia.VerifyingContract},
+	} {
+		if got, _ := HashTransferWithAuthorization(auth, domain); got == reference {
+			t.Errorf("changing the domain %s left the digest unchanged", name)
+		}
+	}
+	if _, err := HashTransferWithAuthorization(auth, Domain{Name: "USD Coin", Version: "2"}); err == nil {
+		t.Error("hashed without a chain ID")
+	}
+}
+
+func TestSignAndRecoverSigner(t *testing.T) {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain := TokenDomain(types.NetworkBase, big.NewInt(8453), common.HexToAddress("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"))
+	auth := vectorAuth("10000", "0", "1893456000", "0x0000000000000000000000000000000000000000000000000000000000000001")
+	auth.From = crypto.PubkeyToAddress(key.PublicKey)
+
+	signature, err := SignTransferWithAuthorization(auth, domain, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signature) != 65 || (signature[64] != 27 && signature[64] != 28) {
+		t.Fatalf("signature %x, want 65 bytes with V of 27 or 28", signature)
+	}
+	// RFC 6979 signing is deterministic
+	if again, _ := SignTransferWithAuthorization(auth, domain, key); !bytes.Equal(again, signature) {
+		t.Error("signing twice gave different signatures")
+	}
+
+	bareV := append([]byte(nil), signature...)
+	bareV[64] -= 27
+	for name, sig := range map[string][]byte{"V 27/28": signature, "V 0/1": bareV} {
+		signer, err := RecoverSigner(auth, sig, domain)
+		if err != nil || signer != auth.From {
+			t.Errorf("%s: recovered %s (%v), want %s", name, signer.Hex(), err, auth.From.Hex())
+		}
+	}
+	if signature[64] < 27 {
+		t.Error("RecoverSigner modified the caller's signature")
+	}
+
+	tampered := *auth
+	tampered.Value = "10001"
+	if ok, err := VerifySignature(&tampered, signature, domain); err != nil || ok {
+		t.Errorf("tampered value: valid %v (%v)", ok, err)
+	}
+	if ok, err := VerifySignature(auth, signature, Domain{Name: "USDC", Version: "2", ChainID: domain.ChainID, VerifyingContract: domain.VerifyingContract}); err != nil || ok {
+		t.Errorf("signature checked in another domain: valid %v (%v)", ok, err)
+	}
+	if _, err := RecoverSigner(auth, signature[:64], domain); err == nil {
+		t.Error("recovered from a 64-byte signature")
+	}
+}
+
+func TestTypedDataCarriesOnlyItsTypes(t *testing.T) {
+	auth := vectorAuth("1", "0", "1", "0x0000000000000000000000000000000000000000000000000000000000000001")
+	domain := Domain{Name: "USD Coin", Version: "2", ChainID: big.NewInt(8453)}
+	for primary, td := range map[string]apitypes.TypedData{
+		PrimaryTransferWithAuthorization: TypedDataForAuthorization(auth, domain),
+		PrimaryReceiveWithAuthorization:  TypedDataForReceiveAuthorization(auth, domain),
+		PrimaryCancelAuthorization:       TypedDataForCancelAuthorization(auth.From, auth.Nonce.String(), domain),
+	} {
+		_, hasDomain := td.Types["EIP712Domain"]
+		_, hasPrimary := td.Types[primary]
+		if len(td.Types) != 2 || !hasDomain || !hasPrimary || td.PrimaryType != primary {
+			t.Errorf("%s typed data defines %d types with primary %s, want only the domain and %s", primary, len(td.Types), td.PrimaryType, primary)
+		}
+	}
+}
+
+func TestSignAndRecoverReceiveAndCancel(t *testing.T) {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain := TokenDomain(types.NetworkBaseSepolia, big.NewInt(84532), common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e"))
+	auth := vectorAuth("10000", "0", "1893456000", "0x0000000000000000000000000000000000000000000000000000000000000002")
+	auth.From = crypto.PubkeyToAddress(key.PublicKey)
+
+	transfer, err := SignTransferWithAuthorization(auth, domain, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for primary, td := range map[string]apitypes.TypedData{
+		PrimaryReceiveWithAuthorization: TypedDataForReceiveAuthorization(auth, domain),
+		PrimaryCancelAuthorization:      TypedDataForCancelAuthorization(auth.From, auth.Nonce.String(), domain),
+	} {
+		signature, err := SignTypedData(td, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer, err := RecoverTypedDataSigner(td, signature)
+		if err != nil || signer != auth.From {
+			t.Errorf("%s: recovered %s (%v), want %s", primary, signer.Hex(), err, auth.From.Hex())
+		}
+		// The primary type is part of the digest: no signature works as another
+		if bytes.Equal(signature, transfer) {
+			t.Errorf("%s signature equals the transfer signature", primary)
+		}
+		if ok, _ := VerifySignature(auth, signature, domain); ok {
+			t.Errorf("%s signature verified as a transfer authorization", primary)
+		}
+	}
+}
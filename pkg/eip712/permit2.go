@@ -0,0 +1,95 @@
+package eip712
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// PrimaryPermitWitnessTransferFrom is the primary type of Permit2
+// SignatureTransfer permits carrying a witness
+const PrimaryPermitWitnessTransferFrom = "PermitWitnessTransferFrom"
+
+// Permit2WitnessTypeString is the witnessTypeString passed to Permit2's
+// permitWitnessTransferFrom: the witness field and every struct type the
+// permit references, in EIP-712 order
+const Permit2WitnessTypeString = "Witness witness)TokenPermissions(address token,uint256 amount)Witness(address payTo,uint256 amount)"
+
+// Permit2Types are the typed-data types of a permit2 payment; the witness
+// binds the payee and amount, so the spender cannot redirect the transfer
+var Permit2Types = apitypes.Types{
+	// Permit2's domain has no version
+	"EIP712Domain": []apitypes.Type{
+		{Name: "name", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	},
+	PrimaryPermitWitnessTransferFrom: []apitypes.Type{
+		{Name: "permitted", Type: "TokenPermissions"},
+		{Name: "spender", Type: "address"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "deadline", Type: "uint256"},
+		{Name: "witness", Type: "Witness"},
+	},
+	"TokenPermissions": []apitypes.Type{
+		{Name: "token", Type: "address"},
+		{Name: "amount", Type: "uint256"},
+	},
+	"Witness": []apitypes.Type{
+		{Name: "payTo", Type: "address"},
+		{Name: "amount", Type: "uint256"},
+	},
+}
+
+// Permit2Domain returns the domain of the canonical Permit2 contract on chainID
+func Permit2Domain(chainID *big.Int) Domain {
+	return Domain{
+		Name:              "Permit2",
+		ChainID:           chainID,
+		VerifyingContract: types.Permit2Address,
+	}
+}
+
+// permit2Witness is the typed-data witness of auth
+func permit2Witness(auth *types.ExactEvmPayloadAuthorization) apitypes.TypedDataMessage {
+	return apitypes.TypedDataMessage{
+		"payTo":  auth.To.Hex(),
+		"amount": auth.Value,
+	}
+}
+
+// TypedDataForPermit2 returns the PermitWitnessTransferFrom typed data
+// letting spender move auth.Value of token from auth.From to auth.To (see
+// types.Permit2Payload for how the authorization maps onto the permit)
+func TypedDataForPermit2(auth *types.ExactEvmPayloadAuthorization, token, spender common.Address, domain Domain) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types:       Permit2Types,
+		PrimaryType: PrimaryPermitWitnessTransferFrom,
+		Domain:      typedDataDomain(domain),
+		Message: apitypes.TypedDataMessage{
+			"permitted": map[string]interface{}{
+				"token":  token.Hex(),
+				"amount": auth.Value,
+			},
+			"spender":  spender.Hex(),
+			"nonce":    auth.Nonce.String(),
+			"deadline": auth.ValidBefore,
+			"witness":  map[string]interface{}(permit2Witness(auth)),
+		},
+	}
+}
+
+// Permit2WitnessHash returns the witness argument of permitWitnessTransferFrom
+// for auth: hashStruct(Witness)
+func Permit2WitnessHash(auth *types.ExactEvmPayloadAuthorization) (common.Hash, error) {
+	// The witness does not depend on the domain, but the encoder wants one
+	typedData := apitypes.TypedData{Types: Permit2Types, Domain: typedDataDomain(Permit2Domain(new(big.Int)))}
+	encoded, err := typedData.EncodeData("Witness", permit2Witness(auth), 1)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
@@ -0,0 +1,151 @@
+package eip712
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Constants of the canonical Permit2 deployment (EIP712.sol, PermitHash.sol)
+var (
+	permit2DomainTypeHash    = common.HexToHash("0x8cad95687ba82c2ce50e74f7b754645e5117c3a5bec8151c0726d5857980a866")
+	tokenPermissionsTypeHash = common.HexToHash("0x618358ac3db8dc274f0cd8829da7e234bd48cd73c4a740aede1adec9846d06a1")
+	// DOMAIN_SEPARATOR() of Permit2 on Ethereum mainnet
+	permit2MainnetSeparator = common.HexToHash("0x866a5aba21966af95d6c7ab78eb2b2fc913915c28be3b9aa07cc04ff903e3f28")
+)
+
+// permitWitnessStub is the prefix Permit2 prepends to a witnessTypeString
+const permitWitnessStub = "PermitWitnessTransferFrom(TokenPermissions permitted,address spender,uint256 nonce,uint256 deadline,"
+
+// manualPermit2Digest hashes a permit2 payment by hand, as Permit2 rebuilds
+// it in permitWitnessTransferFrom from the witness and its type string
+func manualPermit2Digest(chainID *big.Int, auth *types.ExactEvmPayloadAuthorization, token, spender common.Address) common.Hash {
+	separator := crypto.Keccak256(
+		permit2DomainTypeHash.Bytes(),
+		crypto.Keccak256([]byte("Permit2")),
+		word(chainID),
+		word(types.Permit2Address),
+	)
+	witness := crypto.Keccak256(
+		crypto.Keccak256([]byte("Witness(address payTo,uint256 amount)")),
+		word(auth.To),
+		word(auth.Value),
+	)
+	permitted := crypto.Keccak256(tokenPermissionsTypeHash.Bytes(), word(token), word(auth.Value))
+	permit := crypto.Keccak256(
+		crypto.Keccak256([]byte(permitWitnessStub+Permit2WitnessTypeString)),
+		permitted,
+		word(spender),
+		word(common.BytesToHash(auth.Nonce)),
+		word(auth.ValidBefore),
+		witness,
+	)
+	return crypto.Keccak256Hash([]byte("\x19\x01"), separator, permit)
+}
+
+func TestPermit2DomainMatchesDeployment(t *testing.T) {
+	td := TypedDataForPermit2(vectorAuth("1", "0", "1", "0x01"), common.Address{}, common.Address{}, Permit2Domain(big.NewInt(1)))
+	if got := common.BytesToHash(td.TypeHash("EIP712Domain")); got != permit2DomainTypeHash {
+		t.Errorf("domain type hash %s, want %s (%s)", got, permit2DomainTypeHash, td.EncodeType("EIP712Domain"))
+	}
+	if got := common.BytesToHash(td.TypeHash("TokenPermissions")); got != tokenPermissionsTypeHash {
+		t.Errorf("TokenPermissions type hash %s, want %s", got, tokenPermissionsTypeHash)
+	}
+	separator, err := td.HashStruct("EIP712Domain", td.Domain.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := common.BytesToHash(separator); got != permit2MainnetSeparator {
+		t.Errorf("mainnet domain separator %s, want %s", got, permit2MainnetSeparator)
+	}
+
+	// The witness type string completes Permit2's stub into the primary type
+	if got := string(td.EncodeType(PrimaryPermitWitnessTransferFrom)); got != permitWitnessStub+Permit2WitnessTypeString {
+		t.Errorf("encoded primary type %q, want the stub followed by %q", got, Permit2WitnessTypeString)
+	}
+}
+
+func TestHashPermit2Vectors(t *testing.T) {
+	token := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	spender := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	for _, tc := range []struct {
+		name    string
+		chainID *big.Int
+		auth    *types.ExactEvmPayloadAuthorization
+	}{
+		{"mainnet", big.NewInt(1), vectorAuth("10000", "0", "1893456000", "0x0000000000000000000000000000000000000000000000000000000000000001")},
+		{"base-sepolia", big.NewInt(84532), vectorAuth("1", "0", "1740672154", "0xf3746613c2d920b5fdabc0856f2aeb2d4f88ee6037b8cc5d04a71a4462f13480")},
+		{"max values", big.NewInt(8453), vectorAuth("115792089237316195423570985008687907853269984665640564039457584007913129639935", "0", "18446744073709551615", "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")},
+	} {
+		got, err := HashTypedData(TypedDataForPermit2(tc.auth, token, spender, Permit2Domain(tc.chainID)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := manualPermit2Digest(tc.chainID, tc.auth, token, spender); got != want {
+			t.Errorf("%s: digest %s, want %s", tc.name, got, want)
+		}
+
+		witness, err := Permit2WitnessHash(tc.auth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := crypto.Keccak256Hash(crypto.Keccak256([]byte("Witness(address payTo,uint256 amount)")), word(tc.auth.To), word(tc.auth.Value))
+		if witness != want {
+			t.Errorf("%s: witness hash %s, want %s", tc.name, witness, want)
+		}
+	}
+
+	// ValidAfter is not part of a permit; everything else is
+	auth := vectorAuth("10000", "0", "1893456000", "0x0000000000000000000000000000000000000000000000000000000000000001")
+	reference, _ := HashTypedData(TypedDataForPermit2(auth, token, spender, Permit2Domain(big.NewInt(1))))
+	validAfter := *auth
+	validAfter.ValidAfter = "1000"
+	if got, _ := HashTypedData(TypedDataForPermit2(&validAfter, token, spender, Permit2Domain(big.NewInt(1)))); got != reference {
+		t.Error("changing validAfter changed the permit digest")
+	}
+	payTo := *auth
+	payTo.To = spender
+	for name, td := range map[string]apitypes.TypedData{
+		"payTo":   TypedDataForPermit2(&payTo, token, spender, Permit2Domain(big.NewInt(1))),
+		"token":   TypedDataForPermit2(auth, spender, spender, Permit2Domain(big.NewInt(1))),
+		"spender": TypedDataForPermit2(auth, token, token, Permit2Domain(big.NewInt(1))),
+		"chain":   TypedDataForPermit2(auth, token, spender, Permit2Domain(big.NewInt(8453))),
+	} {
+		if got, _ := HashTypedData(td); got == reference {
+			t.Errorf("changing the %s left the digest unchanged", name)
+		}
+	}
+}
+
+func TestSignAndRecoverPermit2(t *testing.T) {
+	key, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	spender := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	auth := vectorAuth("10000", "0", "1893456000", "0x0000000000000000000000000000000000000000000000000000000000000003")
+	auth.From = crypto.PubkeyToAddress(key.PublicKey)
+
+	td := TypedDataForPermit2(auth, token, spender, Permit2Domain(big.NewInt(8453)))
+	signature, err := SignTypedData(td, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := RecoverTypedDataSigner(td, signature)
+	if err != nil || signer != auth.From {
+		t.Errorf("recovered %s (%v), want %s", signer.Hex(), err, auth.From.Hex())
+	}
+	// A permit is no transferWithAuthorization of the token
+	if ok, _ := VerifySignature(auth, signature, TokenDomain(types.NetworkBase, big.NewInt(8453), token)); ok {
+		t.Error("permit2 signature verified as a transfer authorization")
+	}
+	other := TypedDataForPermit2(auth, token, common.HexToAddress("0x00000000000000000000000000000000000000bb"), Permit2Domain(big.NewInt(8453)))
+	if signer, err := RecoverTypedDataSigner(other, signature); err == nil && signer == auth.From {
+		t.Error("signature recovered for another spender")
+	}
+}
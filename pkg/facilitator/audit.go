@@ -0,0 +1,277 @@
+package facilitator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultAuditLogMaxBytes is the size at which an audit log file is rotated
+// when OpenAuditLog is given no limit
+const DefaultAuditLogMaxBytes = 100 << 20
+
+// AuditAction is the facilitator operation an audit entry records
+type AuditAction string
+
+const (
+	AuditVerify AuditAction = "verify"
+	AuditSettle AuditAction = "settle"
+)
+
+// AuditEntry is one verify or settle decision
+// It never holds the raw signature, only its keccak256 hash.
+type AuditEntry struct {
+	Time             time.Time        `json:"time"`
+	RequestID        string           `json:"requestId,omitempty"`
+	Tenant           string           `json:"tenant,omitempty"`
+	SettlementID     string           `json:"settlementId,omitempty"`
+	Action           AuditAction      `json:"action"`
+	Network          types.Network    `json:"network"`
+	Scheme           types.Scheme     `json:"scheme"`
+	Payer            string           `json:"payer,omitempty"`
+	PayTo            string           `json:"payTo,omitempty"`
+	Asset            string           `json:"asset,omitempty"`
+	Amount           string           `json:"amount,omitempty"`
+	MaxAmount        string           `json:"maxAmountRequired,omitempty"`
+	Nonce            string           `json:"nonce,omitempty"`
+	SignatureHash    string           `json:"signatureHash,omitempty"`
+	RequirementsHash string           `json:"requirementsHash,omitempty"`
+	Accepted         bool             `json:"accepted"` // isValid for verify, success for settle
+	Reason           string           `json:"reason,omitempty"`
+	ReasonCode       types.ReasonCode `json:"reasonCode,omitempty"`
+	TransactionHash  string           `json:"transactionHash,omitempty"`
+	Error            string           `json:"error,omitempty"` // Internal error returned instead of a decision
+}
+
+// AuditLogger records every verify and settle decision of a LocalFacilitator
+// Log is called synchronously on the request path, so implementations
+// should not block for long; failures are theirs to report.
+type AuditLogger interface {
+	Log(ctx context.Context, entry AuditEntry)
+}
+
+// NopAuditLogger discards entries (the default)
+type NopAuditLogger struct{}
+
+// Log implements AuditLogger
+func (NopAuditLogger) Log(context.Context, AuditEntry) {}
+
+// SetAuditLogger records decisions with logger (nil restores the no-op default)
+func (f *LocalFacilitator) SetAuditLogger(logger AuditLogger) {
+	if logger == nil {
+		logger = NopAuditLogger{}
+	}
+	f.auditLogger = logger
+}
+
+// AuditLogger returns the logger recording decisions
+func (f *LocalFacilitator) AuditLogger() AuditLogger {
+	return f.auditLogger
+}
+
+// newAuditEntry fills the payment fields shared by verify and settle entries
+func newAuditEntry(ctx context.Context, action AuditAction, payload *types.PaymentPayload, requirements *types.PaymentRequirements) AuditEntry {
+	auth := payload.Payload.Authorization
+	entry := AuditEntry{
+		Time:          time.Now().UTC(),
+		RequestID:     types.RequestIDFromContext(ctx),
+		Tenant:        types.TenantIDFromContext(ctx),
+		SettlementID:  types.SettlementIDFromContext(ctx),
+		Action:        action,
+		Network:       payload.Network,
+		Scheme:        payload.Scheme,
+		Payer:         auth.From.Hex(),
+		PayTo:         requirements.PayTo,
+		Asset:         requirements.Asset.Hex(),
+		Amount:        auth.Value,
+		MaxAmount:     requirements.MaxAmountRequired,
+		Nonce:         auth.Nonce.String(),
+		SignatureHash: hashSignature(payload.Payload.Signature),
+	}
+	if hash, err := types.HashRequirements(requirements); err == nil {
+		entry.RequirementsHash = hash.Hex()
+	}
+	return entry
+}
+
+// hashSignature returns the keccak256 of the signature, so entries can be
+// matched without storing it
+func hashSignature(signature []byte) string {
+	if len(signature) == 0 {
+		return ""
+	}
+	return crypto.Keccak256Hash(signature).Hex()
+}
+
+// auditVerify records the outcome of Verify
+func (f *LocalFacilitator) auditVerify(ctx context.Context, request *types.VerifyRequest, resp *types.VerifyResponse, err error) {
+	entry := newAuditEntry(ctx, AuditVerify, &request.PaymentPayload, &request.PaymentRequirements)
+	switch {
+	case err != nil:
+		entry.Error = err.Error()
+	case resp != nil:
+		entry.Accepted = resp.IsValid
+		entry.Reason = resp.Reason
+		entry.ReasonCode = resp.ReasonCode
+	}
+	f.auditLogger.Log(ctx, entry)
+}
+
+// auditSettle records the outcome of Settle
+func (f *LocalFacilitator) auditSettle(ctx context.Context, request *types.SettleRequest, resp *types.SettleResponse, err error) {
+	entry := newAuditEntry(ctx, AuditSettle, &request.PaymentPayload, &request.PaymentRequirements)
+	switch {
+	case err != nil:
+		entry.Error = err.Error()
+	case resp != nil:
+		entry.Accepted = resp.Success
+		entry.Reason = resp.Error
+		entry.ReasonCode = resp.ReasonCode
+		if resp.TransactionHash != nil {
+			entry.TransactionHash = resp.TransactionHash.Hash
+		}
+	}
+	f.auditLogger.Log(ctx, entry)
+}
+
+// FileAuditLogger appends entries to a JSONL file, rotating it when it
+// reaches its size limit or the UTC day changes
+// Rotated files are renamed to <path>.<timestamp> and never written again.
+type FileAuditLogger struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	file     *os.File
+	size     int64
+	day      string // UTC date of the current file's first entry
+}
+
+// OpenAuditLog opens (or creates) the audit log at path, rotating at maxBytes
+// (DefaultAuditLogMaxBytes if maxBytes <= 0)
+func OpenAuditLog(path string, maxBytes int64) (*FileAuditLogger, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultAuditLogMaxBytes
+	}
+	l := &FileAuditLogger{path: path, maxBytes: maxBytes}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// open opens the current file for appending; callers hold mu (or own l)
+func (l *FileAuditLogger) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	l.file = f
+	l.size = info.Size()
+	l.day = ""
+	if l.size > 0 {
+		l.day = info.ModTime().UTC().Format(time.DateOnly)
+	}
+	return nil
+}
+
+// Log implements AuditLogger
+// A failed write is logged and otherwise ignored; audit logging never
+// changes a verify or settle outcome.
+func (l *FileAuditLogger) Log(_ context.Context, entry AuditEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("audit: failed to encode entry: %v", err)
+		return
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return
+	}
+
+	day := entry.Time.UTC().Format(time.DateOnly)
+	if l.size > 0 && (l.size+int64(len(line)) > l.maxBytes || (l.day != "" && l.day != day)) {
+		if err := l.rotate(); err != nil {
+			log.Printf("audit: %v", err)
+			return
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		log.Printf("audit: failed to write entry: %v", err)
+		return
+	}
+	if l.day == "" {
+		l.day = day
+	}
+}
+
+// rotate renames the current file aside and starts a new one; callers hold mu
+func (l *FileAuditLogger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+	l.file = nil
+	rotated := fmt.Sprintf("%s.%s", l.path, time.Now().UTC().Format("20060102T150405.000000000"))
+	if err := os.Rename(l.path, rotated); err != nil {
+		// Keep appending to the current file rather than lose entries
+		if openErr := l.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return l.open()
+}
+
+// Close closes the audit log file
+func (l *FileAuditLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// ReadAuditLog returns the entries of one audit log file, oldest first
+// (for tests and offline inspection; a torn last line is skipped)
+func ReadAuditLog(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	return entries, nil
+}
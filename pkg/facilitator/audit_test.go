@@ -0,0 +1,191 @@
+package facilitator_test
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestAuditLogRecordsDecisionsWithoutSignature(t *testing.T) {
+	chain := newTestChain(t)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := facilitator.OpenAuditLog(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fac.SetAuditLogger(auditLog)
+
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	underpaid := requirements
+	underpaid.MaxAmountRequired = "1001"
+
+	ctx := types.WithRequestID(context.Background(), "req-audit")
+	if resp, err := fac.Verify(ctx, &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements}); err != nil || !resp.IsValid {
+		t.Fatalf("verify: %+v, %v", resp, err)
+	}
+	if resp, err := fac.Verify(ctx, &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: underpaid}); err != nil || resp.IsValid {
+		t.Fatalf("underpaid verify: %+v, %v", resp, err)
+	}
+	settled, err := fac.Settle(ctx, &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil || !settled.Success {
+		t.Fatalf("settle: %+v, %v", settled, err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := payload.Payload.Signature.String()
+	if strings.Contains(strings.ToLower(string(raw)), strings.ToLower(strings.TrimPrefix(signature, "0x"))) {
+		t.Fatalf("the raw signature reached the audit log:\n%s", raw)
+	}
+
+	entries, err := facilitator.ReadAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("%d audit entries, want 3", len(entries))
+	}
+	digest, err := types.HashRequirements(&requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatureHash := crypto.Keccak256Hash(payload.Payload.Signature).Hex()
+	for i, entry := range entries {
+		if entry.RequestID != "req-audit" || entry.SignatureHash != signatureHash || entry.Payer != chain.Accounts[0].Address.Hex() ||
+			entry.Amount != "1000" || entry.Asset != testchain.TokenAddress.Hex() || entry.Network != testchain.Network || entry.Time.IsZero() {
+			t.Errorf("entry %d: %+v", i, entry)
+		}
+	}
+	if verified := entries[0]; verified.Action != facilitator.AuditVerify || !verified.Accepted || verified.RequirementsHash != digest.Hex() {
+		t.Errorf("accepted verify entry: %+v", verified)
+	}
+	if rejected := entries[1]; rejected.Action != facilitator.AuditVerify || rejected.Accepted || rejected.ReasonCode != types.ReasonInsufficientValue ||
+		rejected.Reason == "" || rejected.MaxAmount != "1001" || rejected.RequirementsHash == digest.Hex() {
+		t.Errorf("rejected verify entry: %+v", rejected)
+	}
+	if settle := entries[2]; settle.Action != facilitator.AuditSettle || !settle.Accepted || settle.TransactionHash != settled.TransactionHash.Hash {
+		t.Errorf("settle entry: %+v", settle)
+	}
+}
+
+// auditFiles returns the live audit log and its rotated files, oldest first
+func auditFiles(t *testing.T, path string) []string {
+	t.Helper()
+	rotated, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(rotated)
+	return append(rotated, path)
+}
+
+func TestFileAuditLoggerRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	const maxBytes = 512
+	auditLog, err := facilitator.OpenAuditLog(path, maxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for i := 0; i < 20; i++ {
+		auditLog.Log(context.Background(), facilitator.AuditEntry{Time: now, Action: facilitator.AuditVerify, Network: testchain.Network, Nonce: strings.Repeat("a", 64)})
+	}
+	if err := auditLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := auditFiles(t, path)
+	if len(files) < 3 {
+		t.Fatalf("%d audit files, want the log rotated by size", len(files))
+	}
+	var total int
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > maxBytes {
+			t.Errorf("%s is %d bytes, over the %d byte limit", file, info.Size(), maxBytes)
+		}
+		entries, err := facilitator.ReadAuditLog(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += len(entries)
+	}
+	if total != 20 {
+		t.Errorf("%d entries across the rotated files, want all 20", total)
+	}
+}
+
+func TestFileAuditLoggerRotatesByDay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := facilitator.OpenAuditLog(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().UTC()
+	auditLog.Log(context.Background(), facilitator.AuditEntry{Time: today, Action: facilitator.AuditVerify})
+	auditLog.Log(context.Background(), facilitator.AuditEntry{Time: today, Action: facilitator.AuditSettle})
+	auditLog.Log(context.Background(), facilitator.AuditEntry{Time: today.Add(24 * time.Hour), Action: facilitator.AuditVerify})
+	if err := auditLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := auditFiles(t, path)
+	if len(files) != 2 {
+		t.Fatalf("audit files %v, want one per day", files)
+	}
+	for i, want := range []int{2, 1} {
+		entries, err := facilitator.ReadAuditLog(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != want {
+			t.Errorf("%s: %d entries, want %d", files[i], len(entries), want)
+		}
+	}
+
+	// Reopening appends to the live file (dated by its modification time), and
+	// a torn last line is skipped
+	auditLog, err = facilitator.OpenAuditLog(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog.Log(context.Background(), facilitator.AuditEntry{Time: time.Now().UTC(), Action: facilitator.AuditSettle})
+	auditLog.Close()
+	torn, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	torn.WriteString(`{"time":"2`)
+	torn.Close()
+	if entries, err := facilitator.ReadAuditLog(path); err != nil || len(entries) != 2 {
+		t.Errorf("reopened log: %d entries, %v; want 2", len(entries), err)
+	}
+}
@@ -0,0 +1,262 @@
+package facilitator
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Builder assembles a LocalFacilitator entirely in code, for embedding the
+// facilitator in another service without going through environment variables
+//
+//	fac, err := facilitator.NewBuilder().
+//		WithEVMNetwork(types.NetworkBase, evm.Options{RPCURL: baseRPC, Keys: keys}).
+//		WithEVMNetwork(types.NetworkBaseSepolia, evm.Options{RPCURL: sepoliaRPC, Keys: keys}).
+//		Build()
+type Builder struct {
+	networks       []builderNetwork
+	solana         []types.Network
+	nonceBackend   func(network types.Network) evm.NonceBackend
+	feePolicies    map[types.Network]types.FeePolicy
+	gasLedger      *accounting.GasLedger
+	gasBudget      *GasBudget
+	receiptSigner  types.HashSigner
+	journal        *accounting.SettlementJournal
+	xdcPrefix      bool
+	networkState   string
+	disableGrace   time.Duration
+	auditLogger    AuditLogger
+	reorgWindow    time.Duration
+	onReorg        func(evm.ReorgEvent)
+	verifyOnly     bool
+	eventPublisher EventPublisher
+	eventBuffer    int
+
+	// Registered on the facilitator in this order
+	verifyHooks []verifyHook
+	settleHooks []settleHook
+
+	tenants []TenantPolicy
+}
+
+// builderNetwork is one EVM network queued for Build
+type builderNetwork struct {
+	network types.Network
+	options evm.Options
+	extra   []evm.ProviderOption
+}
+
+// NewBuilder creates an empty Builder
+func NewBuilder() *Builder {
+	return &Builder{
+		feePolicies: make(map[types.Network]types.FeePolicy),
+	}
+}
+
+// WithEVMNetwork adds an EVM network; a nil opts.ChainID is taken from the
+// network registry, and extra tunes the provider (timeouts, nonce limits, ...)
+func (b *Builder) WithEVMNetwork(network types.Network, opts evm.Options, extra ...evm.ProviderOption) *Builder {
+	b.networks = append(b.networks, builderNetwork{network: network, options: opts, extra: extra})
+	return b
+}
+
+// WithSolanaNetwork advertises a Solana network (see AddSolanaNetwork)
+func (b *Builder) WithSolanaNetwork(network types.Network) *Builder {
+	b.solana = append(b.solana, network)
+	return b
+}
+
+// WithNonceBackend supplies the replay protection backend of each network
+// that does not set evm.Options.NonceBackend itself (e.g. a shared database)
+// Backends are per network: the same nonce may legitimately be used on two chains
+func (b *Builder) WithNonceBackend(factory func(network types.Network) evm.NonceBackend) *Builder {
+	b.nonceBackend = factory
+	return b
+}
+
+// WithFeePolicy requires a facilitator fee on a network (see SetFeePolicy)
+func (b *Builder) WithFeePolicy(network types.Network, policy types.FeePolicy) *Builder {
+	b.feePolicies[network] = policy
+	return b
+}
+
+// WithGasLedger records settlement gas in ledger (see SetGasLedger)
+func (b *Builder) WithGasLedger(ledger *accounting.GasLedger) *Builder {
+	b.gasLedger = ledger
+	return b
+}
+
+// WithGasBudget caps the gas sponsored per payTo and day (see SetGasBudget)
+func (b *Builder) WithGasBudget(budget *GasBudget) *Builder {
+	b.gasBudget = budget
+	return b
+}
+
+// WithVerifyHook adds a rule run after verification (see RegisterVerifyHook)
+func (b *Builder) WithVerifyHook(hook VerifyHook, opts ...HookOption) *Builder {
+	b.verifyHooks = append(b.verifyHooks, verifyHook{hook: hook, timeout: newHookOptions(opts).timeout})
+	return b
+}
+
+// WithSettleHook adds a veto run before settlement (see RegisterSettleHook)
+func (b *Builder) WithSettleHook(hook SettleHook, opts ...HookOption) *Builder {
+	b.settleHooks = append(b.settleHooks, settleHook{hook: hook, timeout: newHookOptions(opts).timeout})
+	return b
+}
+
+// WithTenants restricts the requests of each tenant to its policy (see SetTenants)
+func (b *Builder) WithTenants(policies []TenantPolicy) *Builder {
+	b.tenants = append(b.tenants, policies...)
+	return b
+}
+
+// WithJournal records every settlement transaction in journal so in-flight
+// settlements can be reconciled (see LocalFacilitator.StartReconciler)
+func (b *Builder) WithJournal(journal *accounting.SettlementJournal) *Builder {
+	b.journal = journal
+	return b
+}
+
+// WithReceiptSigner signs settlement receipts with signer (see SetReceiptSigner)
+func (b *Builder) WithReceiptSigner(signer types.HashSigner) *Builder {
+	b.receiptSigner = signer
+	return b
+}
+
+// WithXDCAddressPrefix echoes XDC addresses with the xdc prefix (see SetXDCAddressPrefix)
+func (b *Builder) WithXDCAddressPrefix() *Builder {
+	b.xdcPrefix = true
+	return b
+}
+
+// WithNetworkStateFile persists runtime-disabled networks in path (see SetNetworkStateFile)
+func (b *Builder) WithNetworkStateFile(path string) *Builder {
+	b.networkState = path
+	return b
+}
+
+// WithDisableGrace sets the settle grace window of disabled networks (see SetDisableGrace)
+func (b *Builder) WithDisableGrace(grace time.Duration) *Builder {
+	b.disableGrace = grace
+	return b
+}
+
+// WithAuditLogger records every verify and settle decision (see SetAuditLogger)
+func (b *Builder) WithAuditLogger(logger AuditLogger) *Builder {
+	b.auditLogger = logger
+	return b
+}
+
+// WithVerifyOnly builds a facilitator that verifies but never settles: EVM
+// networks need no keys and Settle is refused (see evm.WithVerifyOnly)
+func (b *Builder) WithVerifyOnly() *Builder {
+	b.verifyOnly = true
+	return b
+}
+
+// WithReorgWatch rechecks settlements for reorgs for window after they
+// confirm on every EVM network, calling onReorg (if set) when one is moved
+// or dropped (see evm.WithReorgWatch and StartReorgWatchers)
+func (b *Builder) WithReorgWatch(window time.Duration, onReorg func(evm.ReorgEvent)) *Builder {
+	b.reorgWindow = window
+	b.onReorg = onReorg
+	return b
+}
+
+// WithEventPublisher publishes settlement lifecycle events to publisher,
+// buffering up to bufferSize undelivered ones (see SetEventPublisher)
+func (b *Builder) WithEventPublisher(publisher EventPublisher, bufferSize int) *Builder {
+	b.eventPublisher = publisher
+	b.eventBuffer = bufferSize
+	return b
+}
+
+// Build connects a provider for every network and returns the facilitator
+func (b *Builder) Build() (*LocalFacilitator, error) {
+	fac := NewLocalFacilitator()
+
+	for _, n := range b.networks {
+		if !n.network.IsEVM() {
+			return nil, fmt.Errorf("%s is not an EVM network", n.network)
+		}
+		if _, dup := fac.evmProviders[n.network]; dup {
+			return nil, fmt.Errorf("%s added more than once", n.network)
+		}
+		if len(n.options.Keys) == 0 && len(n.options.Signers) == 0 && !b.verifyOnly {
+			return nil, fmt.Errorf("no EVM private keys configured for %s", n.network)
+		}
+
+		opts := n.options
+		if opts.ChainID == nil {
+			netInfo, err := network.GetNetworkInfo(n.network)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get network info for %s: %w", n.network, err)
+			}
+			opts.ChainID = big.NewInt(int64(netInfo.ChainID))
+		}
+		if opts.NonceBackend == nil && b.nonceBackend != nil {
+			opts.NonceBackend = b.nonceBackend(n.network)
+		}
+
+		extra := n.extra
+		if b.journal != nil {
+			extra = append(extra[:len(extra):len(extra)], evm.WithJournal(b.journal))
+		}
+		if b.verifyOnly {
+			extra = append(extra[:len(extra):len(extra)], evm.WithVerifyOnly())
+		}
+		if b.reorgWindow > 0 && !b.verifyOnly {
+			extra = append(extra[:len(extra):len(extra)], evm.WithReorgWatch(b.reorgWindow, b.onReorg))
+		}
+		if b.eventPublisher != nil {
+			extra = append(extra[:len(extra):len(extra)], evm.WithSettlementObserver(fac.ObserveSettlement))
+		}
+		provider, err := evm.New(n.network, opts, extra...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create EVM provider for %s: %w", n.network, err)
+		}
+		fac.AddEVMProvider(n.network, provider)
+	}
+
+	for _, net := range b.solana {
+		if err := fac.AddSolanaNetwork(net); err != nil {
+			return nil, err
+		}
+	}
+
+	for net, policy := range b.feePolicies {
+		fac.SetFeePolicy(net, policy)
+	}
+	if b.gasLedger != nil {
+		fac.SetGasLedger(b.gasLedger)
+	}
+	fac.SetGasBudget(b.gasBudget)
+	fac.verifyHooks = append(fac.verifyHooks, b.verifyHooks...)
+	fac.settleHooks = append(fac.settleHooks, b.settleHooks...)
+	if len(b.tenants) > 0 {
+		if err := fac.SetTenants(b.tenants); err != nil {
+			return nil, err
+		}
+	}
+	if b.receiptSigner != nil {
+		fac.SetReceiptSigner(b.receiptSigner)
+	}
+	if b.journal != nil {
+		fac.SetJournal(b.journal)
+	}
+	fac.SetXDCAddressPrefix(b.xdcPrefix)
+	fac.SetAuditLogger(b.auditLogger)
+	fac.SetDisableGrace(b.disableGrace)
+	if b.networkState != "" {
+		if err := fac.SetNetworkStateFile(b.networkState); err != nil {
+			return nil, err
+		}
+	}
+	fac.SetEventPublisher(b.eventPublisher, b.eventBuffer)
+	return fac, nil
+}
@@ -0,0 +1,166 @@
+package facilitator_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// builderKey signs for the unreachable second network
+const builderKey = "0x8da4ef21b864d2cc526dbdb2a120bd2874c36c9d0a1fb7f8c63d7f7a8b41de8f"
+
+// recordingNonces is a NonceBackend noting the nonces marked used
+type recordingNonces struct {
+	mu     sync.Mutex
+	marked []string
+}
+
+func (n *recordingNonces) IsNonceUsed(from, nonce string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, marked := range n.marked {
+		if marked == strings.ToLower(from+nonce) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *recordingNonces) MarkNonceUsed(from, nonce string, validBefore int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.marked = append(n.marked, strings.ToLower(from+nonce))
+}
+
+func TestBuilderTwoNetworksInCode(t *testing.T) {
+	chain := newTestChain(t)
+	backends := make(map[types.Network]*recordingNonces)
+	fac, err := facilitator.NewBuilder().
+		WithEVMNetwork(testchain.Network, chain.Options()).
+		// Providers dial lazily, so nothing listens at the RPC URL; the chain ID
+		// comes from the network registry
+		WithEVMNetwork(types.NetworkBaseSepolia, evm.Options{RPCURL: "http://127.0.0.1:1", Keys: []string{builderKey}}).
+		WithNonceBackend(func(net types.Network) evm.NonceBackend {
+			backends[net] = &recordingNonces{}
+			return backends[net]
+		}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	supported, err := fac.Supported(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	networks := make(map[types.Network]bool)
+	for _, kind := range supported.Kinds {
+		networks[kind.Network] = true
+	}
+	if !networks[testchain.Network] || !networks[types.NetworkBaseSepolia] {
+		t.Errorf("supported networks %v, want both built networks", networks)
+	}
+	signers := fac.Stats()["signers"].(map[types.Network][]string)
+	key, _ := crypto.HexToECDSA(strings.TrimPrefix(builderKey, "0x"))
+	if got := signers[types.NetworkBaseSepolia]; len(got) != 1 || !strings.EqualFold(got[0], crypto.PubkeyToAddress(key.PublicKey).Hex()) {
+		t.Errorf("base-sepolia signers %v, want the configured key", got)
+	}
+	if len(backends) != 2 || backends[testchain.Network] == backends[types.NetworkBaseSepolia] {
+		t.Fatalf("nonce backends %v, want one per network", backends)
+	}
+
+	// The in-code facilitator settles, recording the nonce in its network's backend
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := fac.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Success {
+		t.Fatalf("settle failed: %+v", resp)
+	}
+	if len(backends[testchain.Network].marked) != 1 || len(backends[types.NetworkBaseSepolia].marked) != 0 {
+		t.Errorf("nonces marked %d on %s and %d on base-sepolia, want only the settled network's",
+			len(backends[testchain.Network].marked), testchain.Network, len(backends[types.NetworkBaseSepolia].marked))
+	}
+}
+
+func TestBuilderRejectsInvalidNetworks(t *testing.T) {
+	chain := newTestChain(t)
+	keys := evm.Options{RPCURL: "http://127.0.0.1:1", Keys: []string{builderKey}}
+	for name, tc := range map[string]struct {
+		builder *facilitator.Builder
+		err     string
+	}{
+		"duplicate network": {
+			facilitator.NewBuilder().WithEVMNetwork(testchain.Network, chain.Options()).WithEVMNetwork(testchain.Network, chain.Options()),
+			"added more than once",
+		},
+		"not an EVM network": {
+			facilitator.NewBuilder().WithEVMNetwork(types.NetworkSolanaDevnet, keys),
+			"not an EVM network",
+		},
+		"no keys": {
+			facilitator.NewBuilder().WithEVMNetwork(types.NetworkBaseSepolia, evm.Options{RPCURL: "http://127.0.0.1:1"}),
+			"no EVM private keys configured for base-sepolia",
+		},
+	} {
+		if _, err := tc.builder.Build(); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: error %v, want %q", name, err, tc.err)
+		}
+	}
+
+	// Verify-only facilitators need no keys
+	if _, err := facilitator.NewBuilder().WithEVMNetwork(types.NetworkBaseSepolia, evm.Options{RPCURL: "http://127.0.0.1:1"}).WithVerifyOnly().Build(); err != nil {
+		t.Errorf("verify-only without keys: %v", err)
+	}
+}
+
+func TestBuilderXDCAddressPrefix(t *testing.T) {
+	xdc := evm.Options{RPCURL: "http://127.0.0.1:1", Keys: []string{builderKey}}
+	tokens := func(b *facilitator.Builder) map[types.Network]string {
+		t.Helper()
+		fac, err := b.WithEVMNetwork(types.NetworkXDC, xdc).WithEVMNetwork(types.NetworkBaseSepolia, xdc).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		supported, err := fac.Supported(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens := make(map[types.Network]string)
+		for _, kind := range supported.Kinds {
+			tokens[kind.Network] = kind.Token.Address
+		}
+		return tokens
+	}
+
+	const token = "D4B5f10D61916Bd6E0860144a91Ac658dE8a1437"
+	if got := tokens(facilitator.NewBuilder())[types.NetworkXDC]; got != "0x"+token {
+		t.Errorf("default xdc token %q, want the 0x form", got)
+	}
+	prefixed := tokens(facilitator.NewBuilder().WithXDCAddressPrefix())
+	if got := prefixed[types.NetworkXDC]; got != "xdc"+token {
+		t.Errorf("xdc token %q, want the xdc prefix", got)
+	}
+	if got := prefixed[types.NetworkBaseSepolia]; !strings.HasPrefix(got, "0x") {
+		t.Errorf("base-sepolia token %q; only xdc addresses take the prefix", got)
+	}
+}
@@ -0,0 +1,154 @@
+package facilitator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/transport"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Client is a Facilitator backed by a remote facilitator's HTTP API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the facilitator at baseURL
+// A nil httpClient uses a default client with a 30s timeout
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   30 * time.Second, // Prevent indefinite hangs
+			Transport: transport.DefaultConfig().RoundTripper(),
+		}
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// NewTLSClient creates a client for the facilitator at baseURL whose
+// connections are set up by opts, e.g. a client certificate for mutual TLS
+// (see transport.WithClientCertificate)
+func NewTLSClient(baseURL string, opts ...transport.TLSOption) (*Client, error) {
+	rt, err := transport.AuthorizedTransport(transport.DefaultConfig(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(baseURL, &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: rt,
+	}), nil
+}
+
+// Verify implements Facilitator.Verify
+func (c *Client) Verify(ctx context.Context, request *types.VerifyRequest) (*types.VerifyResponse, error) {
+	var resp types.VerifyResponse
+	if err := c.do(ctx, http.MethodPost, "/verify", request, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Settle implements Facilitator.Settle
+func (c *Client) Settle(ctx context.Context, request *types.SettleRequest) (*types.SettleResponse, error) {
+	var resp types.SettleResponse
+	if err := c.do(ctx, http.MethodPost, "/settle", request, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Simulate implements Facilitator.Simulate
+func (c *Client) Simulate(ctx context.Context, request *types.SettleRequest) (*types.SimulateResponse, error) {
+	var resp types.SimulateResponse
+	if err := c.do(ctx, http.MethodPost, "/settle/simulate", request, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Cancel implements Facilitator.Cancel
+func (c *Client) Cancel(ctx context.Context, request *types.CancelRequest) (*types.CancelResponse, error) {
+	var resp types.CancelResponse
+	if err := c.do(ctx, http.MethodPost, "/cancel", request, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Supported implements Facilitator.Supported
+func (c *Client) Supported(ctx context.Context) (*types.SupportedPaymentKindsResponse, error) {
+	var resp types.SupportedPaymentKindsResponse
+	if err := c.do(ctx, http.MethodGet, "/supported", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SupportedFiltered returns the supported payment kinds passing filter,
+// filtered by the facilitator
+func (c *Client) SupportedFiltered(ctx context.Context, filter types.SupportedFilter) (*types.SupportedPaymentKindsResponse, error) {
+	path := "/supported"
+	if !filter.IsZero() {
+		path += "?" + filter.Query().Encode()
+	}
+	var resp types.SupportedPaymentKindsResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do sends a JSON request to the facilitator and decodes the JSON response
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if id := types.SettlementIDFromContext(ctx); id != "" {
+		req.Header.Set(types.HeaderIdempotencyKey, id)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("facilitator request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+			return fmt.Errorf("facilitator returned %d: %s", resp.StatusCode, errResp.Error)
+		}
+		return fmt.Errorf("facilitator returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package facilitator_test
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x402-rs/x402-go/internal/testcerts"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/transport"
+)
+
+func TestNewTLSClientPresentsCertificate(t *testing.T) {
+	ca := testcerts.NewAuthority(t, "Internal CA")
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kinds":[{"x402Version":1,"scheme":"exact","network":"base-sepolia"}]}`))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.Issue(t, "facilitator", testcerts.Server, "127.0.0.1").Certificate},
+		ClientCAs:    ca.Pool(),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	shop := ca.Issue(t, "shop", testcerts.Client)
+	client, err := facilitator.NewTLSClient(server.URL, transport.WithClientCertificate(shop.CertFile, shop.KeyFile), transport.WithRootCAs(ca.Pool()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	supported, err := client.Supported(context.Background())
+	if err != nil || len(supported.Kinds) != 1 {
+		t.Errorf("with a client certificate: %+v, %v; want the supported kinds", supported, err)
+	}
+
+	anonymous, err := facilitator.NewTLSClient(server.URL, transport.WithRootCAs(ca.Pool()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := anonymous.Supported(context.Background()); err == nil {
+		t.Error("reached a mutual TLS facilitator without a client certificate")
+	}
+
+	if _, err := facilitator.NewTLSClient(server.URL, transport.WithClientCertificate(shop.CertFile, "missing.key")); err == nil {
+		t.Error("NewTLSClient accepted a client certificate without its key")
+	}
+}
@@ -0,0 +1,75 @@
+package facilitator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+	"golang.org/x/sync/singleflight"
+)
+
+// verifyCoalescer shares one provider call between identical verify
+// requests in flight at the same time, e.g. from a client retrying
+// aggressively. Nothing outlives the call: a request arriving after it
+// returned starts a new one. Only requests for the same settlement ID
+// (see types.WithSettlementID) are identical: they share the call's
+// verification ID, which settles once, whereas requests for different
+// settlements each get their own.
+type verifyCoalescer struct {
+	group     singleflight.Group
+	coalesced atomic.Uint64 // Requests answered by another request's call
+}
+
+// verify answers request with verifyFn (a provider's Verify), joining an
+// identical call in flight
+func (c *verifyCoalescer) verify(ctx context.Context, request *types.VerifyRequest, verifyFn func(context.Context, *types.VerifyRequest) (*types.VerifyResponse, error)) (*types.VerifyResponse, error) {
+	key, err := verifyKey(ctx, request)
+	if err != nil {
+		return verifyFn(ctx, request)
+	}
+
+	ran := false
+	result := c.group.DoChan(key, func() (interface{}, error) {
+		ran = true
+		return verifyFn(ctx, request)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		resp, _ := res.Val.(*types.VerifyResponse)
+		if ran {
+			return resp, res.Err
+		}
+		c.coalesced.Add(1)
+
+		// The call ran under the first caller's context; if that was
+		// cancelled, callers still waiting verify on their own
+		if errors.Is(res.Err, context.Canceled) || errors.Is(res.Err, context.DeadlineExceeded) {
+			if ctx.Err() == nil {
+				return verifyFn(ctx, request)
+			}
+		}
+		if res.Err != nil || resp == nil {
+			return resp, res.Err
+		}
+		// Each caller gets its own copy of the response
+		shared := *resp
+		return &shared, nil
+	}
+}
+
+// verifyKey identifies a verify request by its settlement ID and the hash
+// of its canonical JSON
+func verifyKey(ctx context.Context, request *types.VerifyRequest) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return types.SettlementIDFromContext(ctx) + ":" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,149 @@
+package facilitator
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// countingVerify counts its calls and answers each, once release is
+// closed, with a verification ID of its own
+type countingVerify struct {
+	calls   atomic.Int64
+	release chan struct{}
+}
+
+func (v *countingVerify) verify(ctx context.Context, request *types.VerifyRequest) (*types.VerifyResponse, error) {
+	v.calls.Add(1)
+	<-v.release
+	return &types.VerifyResponse{IsValid: true, VerificationID: types.NewSettlementID()}, nil
+}
+
+// verifyConcurrently runs n verifies of request, the i-th under the
+// settlement ID idFor(i), and returns their verification IDs
+func verifyConcurrently(t *testing.T, c *verifyCoalescer, fn *countingVerify, n int, idFor func(int) string) []string {
+	t.Helper()
+	request := &types.VerifyRequest{PaymentPayload: types.PaymentPayload{X402Version: 1, Network: "base-sepolia"}}
+	ids := make([]string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := c.verify(types.WithSettlementID(context.Background(), idFor(i)), request, fn.verify)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			ids[i] = resp.VerificationID
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond) // Let every request join its call
+	close(fn.release)
+	wg.Wait()
+	return ids
+}
+
+func TestVerifyCoalescerSharesOneCallPerSettlement(t *testing.T) {
+	const n = 20
+	c := &verifyCoalescer{}
+	fn := &countingVerify{release: make(chan struct{})}
+	ids := verifyConcurrently(t, c, fn, n, func(int) string { return "settlement-aaaaaaaaaaaa" })
+
+	if calls := fn.calls.Load(); calls != 1 {
+		t.Errorf("%d identical verifies made %d provider calls, want 1", n, calls)
+	}
+	if coalesced := c.coalesced.Load(); coalesced != n-1 {
+		t.Errorf("coalesced = %d, want %d", coalesced, n-1)
+	}
+	for _, id := range ids {
+		if id != ids[0] {
+			t.Fatalf("verifies of one settlement got different verification IDs %q and %q", ids[0], id)
+		}
+	}
+}
+
+func TestVerifyCoalescerKeepsSettlementsApart(t *testing.T) {
+	const n = 5
+	c := &verifyCoalescer{}
+	fn := &countingVerify{release: make(chan struct{})}
+	ids := verifyConcurrently(t, c, fn, n, func(i int) string { return "settlement-" + string(rune('a'+i)) + "aaaaaaaaaaa" })
+
+	if calls := fn.calls.Load(); calls != n {
+		t.Errorf("verifies of %d settlements made %d provider calls, want %d", n, calls, n)
+	}
+	seen := make(map[string]bool, n)
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("verification ID %q was handed to two settlements", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestVerifyCoalescerDoesNotCacheResults(t *testing.T) {
+	c := &verifyCoalescer{}
+	fn := &countingVerify{release: make(chan struct{})}
+	close(fn.release)
+	ctx := types.WithSettlementID(context.Background(), "settlement-aaaaaaaaaaaa")
+	request := &types.VerifyRequest{}
+	for i := 0; i < 3; i++ {
+		if _, err := c.verify(ctx, request, fn.verify); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls := fn.calls.Load(); calls != 3 {
+		t.Errorf("3 sequential verifies made %d provider calls, want 3", calls)
+	}
+}
+
+func TestVerifyCoalescerRetriesAfterCancelledLeader(t *testing.T) {
+	c := &verifyCoalescer{}
+	var calls atomic.Int64
+	started := make(chan struct{}, 2)
+	verifyFn := func(ctx context.Context, request *types.VerifyRequest) (*types.VerifyResponse, error) {
+		calls.Add(1)
+		started <- struct{}{}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return &types.VerifyResponse{IsValid: true}, nil
+		}
+	}
+	request := &types.VerifyRequest{}
+	leaderCtx, cancel := context.WithCancel(types.WithSettlementID(context.Background(), "settlement-aaaaaaaaaaaa"))
+	go c.verify(leaderCtx, request, verifyFn)
+	<-started
+
+	done := make(chan *types.VerifyResponse, 1)
+	go func() {
+		resp, err := c.verify(types.WithSettlementID(context.Background(), "settlement-aaaaaaaaaaaa"), request, verifyFn)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- resp
+	}()
+	time.Sleep(10 * time.Millisecond) // Let the second request join the call
+	cancel()
+
+	if resp := <-done; resp == nil || !resp.IsValid {
+		t.Errorf("follower got %+v after the leader was cancelled, want its own verification", resp)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d provider calls, want the follower to verify again", n)
+	}
+}
+
+func TestLocalFacilitatorStatsCountCoalescedVerifies(t *testing.T) {
+	f := NewLocalFacilitator()
+	fn := &countingVerify{release: make(chan struct{})}
+	verifyConcurrently(t, &f.verifies, fn, 4, func(int) string { return "settlement-aaaaaaaaaaaa" })
+	if got := f.Stats()["coalesced_verifies"]; got != uint64(3) {
+		t.Errorf("coalesced_verifies = %v, want 3", got)
+	}
+}
@@ -0,0 +1,218 @@
+// Package conformance checks a facilitator.Facilitator implementation
+// against the contract the rest of x402-go relies on, without a chain:
+// requests that fail protocol checks (scheme or network mismatch, payload
+// version, networks the facilitator does not serve) and the shape of
+// Supported. A new implementation proves compliance from an external test
+// package:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.RunConformanceTests(t, func() facilitator.Facilitator { return NewMyFacilitator() })
+//	}
+//
+// The contract for a request failing a protocol check:
+//   - the failure is an invalid response (IsValid / Success / Valid false)
+//     or a *types.FacilitatorError, never another error: HTTP front-ends
+//     answer the latter as an invalid response, anything else as a 5xx
+//   - it carries the matching ReasonCode and a human-readable reason
+//   - a verification failure names the payer whenever the payload does
+package conformance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// RunConformanceTests runs the suite against facilitators from factory,
+// a fresh one per subtest
+func RunConformanceTests(t *testing.T, factory func() facilitator.Facilitator) {
+	t.Run("Supported", func(t *testing.T) { testSupported(t, factory()) })
+	t.Run("SchemeMismatch", func(t *testing.T) {
+		testRejected(t, factory(), types.ReasonSchemeMismatch, func(payload *types.PaymentPayload, _ *types.PaymentRequirements) {
+			payload.Scheme = "conformance"
+		})
+	})
+	t.Run("NetworkMismatch", func(t *testing.T) {
+		testRejected(t, factory(), types.ReasonNetworkMismatch, func(payload *types.PaymentPayload, requirements *types.PaymentRequirements) {
+			payload.Network = types.NetworkBase
+			if requirements.Network == types.NetworkBase {
+				payload.Network = types.NetworkBaseSepolia
+			}
+		})
+	})
+	t.Run("UnsupportedVersion", func(t *testing.T) {
+		testRejected(t, factory(), types.ReasonUnsupportedVersion, func(payload *types.PaymentPayload, _ *types.PaymentRequirements) {
+			payload.X402Version = 99
+		})
+	})
+	t.Run("UnsupportedNetwork", func(t *testing.T) { testUnsupportedNetwork(t, factory()) })
+}
+
+// testSupported checks every advertised kind is complete, accepted and listed once
+func testSupported(t *testing.T, fac facilitator.Facilitator) {
+	resp, err := fac.Supported(context.Background())
+	if err != nil {
+		t.Fatalf("Supported: %v", err)
+	}
+	if resp == nil {
+		t.Fatal("Supported returned neither a response nor an error")
+	}
+
+	seen := make(map[string]bool)
+	for i, kind := range resp.Kinds {
+		if kind.Scheme == "" || kind.Network == "" {
+			t.Errorf("kind %d: scheme %q, network %q; both are required", i, kind.Scheme, kind.Network)
+		}
+		// permit2 kinds take any token the payer approved to Permit2
+		if kind.Token.Address == "" && kind.Scheme != types.SchemePermit2 {
+			t.Errorf("kind %d (%s): no token address", i, kind.Network)
+		}
+		if len(kind.X402Versions) == 0 {
+			t.Errorf("kind %d (%s): no x402Versions", i, kind.Network)
+		}
+		for _, version := range kind.X402Versions {
+			if !types.IsSupportedX402Version(version) {
+				t.Errorf("kind %d (%s): advertises unknown x402 version %d", i, kind.Network, version)
+			}
+		}
+
+		key := string(kind.Scheme) + "/" + string(kind.Network) + "/" + strings.ToLower(kind.Token.Address)
+		if seen[key] {
+			t.Errorf("kind %d: %s listed twice", i, key)
+		}
+		seen[key] = true
+	}
+}
+
+// testRejected breaks a valid payment with tamper and checks Verify,
+// Settle and Simulate all reject it with code
+func testRejected(t *testing.T, fac facilitator.Facilitator, code types.ReasonCode, tamper func(*types.PaymentPayload, *types.PaymentRequirements)) {
+	requirements := x402test.Requirements()
+	payload, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tamper(payload, &requirements)
+	checkRejected(t, fac, payload, &requirements, code)
+}
+
+// testUnsupportedNetwork pays on a network the facilitator does not list in Supported
+func testUnsupportedNetwork(t *testing.T, fac facilitator.Facilitator) {
+	supported, err := fac.Supported(context.Background())
+	if err != nil {
+		t.Fatalf("Supported: %v", err)
+	}
+	served := make(map[types.Network]bool)
+	for _, kind := range supported.Kinds {
+		served[kind.Network] = true
+	}
+
+	for _, deployment := range network.USDCDeployments() {
+		if !deployment.Network.IsEVM() || served[deployment.Network] {
+			continue
+		}
+		requirements := x402test.Requirements()
+		requirements.Network = deployment.Network
+		requirements.Asset = deployment.TokenAddress
+		payload, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRejected(t, fac, payload, &requirements, types.ReasonUnsupportedNetwork)
+		return
+	}
+	t.Skip("facilitator serves every EVM network with a USDC deployment")
+}
+
+// checkRejected checks Verify, Settle and Simulate reject payload with code
+func checkRejected(t *testing.T, fac facilitator.Facilitator, payload *types.PaymentPayload, requirements *types.PaymentRequirements, code types.ReasonCode) {
+	t.Helper()
+	ctx := context.Background()
+
+	verified, err := fac.Verify(ctx, &types.VerifyRequest{
+		X402Version:         payload.X402Version,
+		PaymentPayload:      *payload,
+		PaymentRequirements: *requirements,
+	})
+	if facErr := protocolError(t, "Verify", verified == nil, err, code); facErr != nil {
+		checkPayer(t, "Verify", facErr.Payer)
+	} else if err == nil && verified != nil {
+		if verified.IsValid {
+			t.Errorf("Verify: accepted a payment failing %s", code)
+		} else {
+			checkReason(t, "Verify", verified.Reason, verified.ReasonCode, code)
+			checkPayer(t, "Verify", verified.Payer)
+		}
+	}
+
+	settleRequest := &types.SettleRequest{
+		PaymentPayload:      *payload,
+		PaymentRequirements: *requirements,
+	}
+	settled, err := fac.Settle(ctx, settleRequest)
+	if protocolError(t, "Settle", settled == nil, err, code) == nil && settled != nil {
+		if settled.Success {
+			t.Errorf("Settle: settled a payment failing %s", code)
+		} else {
+			checkReason(t, "Settle", settled.Error, settled.ReasonCode, code)
+		}
+	}
+
+	simulated, err := fac.Simulate(ctx, settleRequest)
+	if protocolError(t, "Simulate", simulated == nil, err, code) == nil && simulated != nil {
+		if simulated.Valid {
+			t.Errorf("Simulate: passed a payment failing %s", code)
+		} else {
+			checkReason(t, "Simulate", simulated.Reason, simulated.ReasonCode, code)
+		}
+	}
+}
+
+// protocolError checks a call failing a protocol check returned a
+// *types.FacilitatorError with code (returned) or a response; it reports
+// any other outcome and returns nil
+func protocolError(t *testing.T, call string, noResponse bool, err error, code types.ReasonCode) *types.FacilitatorError {
+	t.Helper()
+	if err == nil {
+		if noResponse {
+			t.Errorf("%s: returned neither a response nor an error", call)
+		}
+		return nil
+	}
+	var facErr *types.FacilitatorError
+	if !errors.As(err, &facErr) {
+		t.Errorf("%s: failed with %v; protocol failures must be an invalid response or a *types.FacilitatorError", call, err)
+		return nil
+	}
+	checkReason(t, call, facErr.Message, facErr.Code, code)
+	return facErr
+}
+
+// checkReason checks a rejection carries code and an explanation
+func checkReason(t *testing.T, call, reason string, got, code types.ReasonCode) {
+	t.Helper()
+	if got != code {
+		t.Errorf("%s: reason code %q, want %q", call, got, code)
+	}
+	if reason == "" {
+		t.Errorf("%s: rejected without a reason", call)
+	}
+}
+
+// checkPayer checks a verification failure names the fixture payer
+func checkPayer(t *testing.T, call string, payer *types.MixedAddress) {
+	t.Helper()
+	if payer == nil {
+		t.Errorf("%s: no payer, though the payload names %s", call, x402test.PayerAddress.Hex())
+		return
+	}
+	if !strings.EqualFold(payer.Address, x402test.PayerAddress.Hex()) {
+		t.Errorf("%s: payer %s, want %s", call, payer.Address, x402test.PayerAddress.Hex())
+	}
+}
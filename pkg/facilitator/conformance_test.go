@@ -0,0 +1,55 @@
+package facilitator_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/facilitator/conformance"
+	"github.com/x402-rs/x402-go/pkg/handlers"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// served runs fac behind the facilitator HTTP handlers
+func served(t *testing.T, fac facilitator.Facilitator) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	handlers.NewHandler(fac).SetupRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestLocalFacilitatorConformance(t *testing.T) {
+	conformance.RunConformanceTests(t, func() facilitator.Facilitator { return facilitator.NewLocalFacilitator() })
+}
+
+func TestLocalFacilitatorWithProvidersConformance(t *testing.T) {
+	chain := newTestChain(t)
+	conformance.RunConformanceTests(t, func() facilitator.Facilitator {
+		fac, err := chain.Facilitator()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fac
+	})
+}
+
+func TestClientConformance(t *testing.T) {
+	conformance.RunConformanceTests(t, func() facilitator.Facilitator {
+		return facilitator.NewClient(served(t, facilitator.NewLocalFacilitator()), nil)
+	})
+}
+
+func TestProxyFacilitatorConformance(t *testing.T) {
+	conformance.RunConformanceTests(t, func() facilitator.Facilitator {
+		return facilitator.NewProxyFacilitator(served(t, facilitator.NewLocalFacilitator()))
+	})
+}
+
+func TestFakeFacilitatorConformance(t *testing.T) {
+	conformance.RunConformanceTests(t, func() facilitator.Facilitator {
+		return facilitator.NewClient(x402test.NewFakeFacilitator(t, x402test.BehaviorVerify).URL, nil)
+	})
+}
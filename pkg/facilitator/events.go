@@ -0,0 +1,319 @@
+package facilitator
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultEventBufferSize is how many settlement events wait for delivery
+// before the oldest are dropped, when SetEventPublisher is given no size
+const DefaultEventBufferSize = 1024
+
+const (
+	eventPublishTimeout = 10 * time.Second
+	eventRetryMin       = time.Second
+	eventRetryMax       = 30 * time.Second
+)
+
+// SettlementEventType is the settlement transition an event reports
+type SettlementEventType string
+
+const (
+	SettlementSubmitted SettlementEventType = "submitted" // Transaction sent
+	SettlementConfirmed SettlementEventType = "confirmed" // Transfer mined
+	SettlementFailed    SettlementEventType = "failed"    // Reverted, or expired without being mined
+	SettlementReorged   SettlementEventType = "reorged"   // A reorg moved or dropped a confirmed settlement
+)
+
+// SettlementEvent is one settlement lifecycle transition
+// Delivery is at least once, so consumers should deduplicate on ID.
+type SettlementEvent struct {
+	ID        string                   `json:"id"`
+	Type      SettlementEventType      `json:"type"`
+	Time      time.Time                `json:"time"`
+	JournalID string                   `json:"journalId,omitempty"`
+	Status    accounting.JournalStatus `json:"status"` // Journal status after the transition
+	Receipt   types.PaymentReceipt     `json:"receipt"`
+	Reorg     *evm.ReorgEvent          `json:"reorg,omitempty"`
+}
+
+// EventPublisher delivers settlement events to a message queue or webhook
+// Publish returning an error makes the facilitator retry the event later.
+type EventPublisher interface {
+	Publish(ctx context.Context, event SettlementEvent) error
+}
+
+// EventStats summarizes event delivery so far
+type EventStats struct {
+	Published uint64 `json:"published"`
+	Retries   uint64 `json:"retries"`
+	Dropped   uint64 `json:"dropped"` // Evicted from a full buffer or undelivered at Stop
+	Pending   int    `json:"pending"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// EventQueue buffers settlement events in memory and delivers them to an
+// EventPublisher in order, retrying a failed delivery with backoff until it
+// succeeds; when the buffer is full the oldest event is dropped
+type EventQueue struct {
+	publisher EventPublisher
+	size      int
+
+	mu      sync.Mutex
+	pending []SettlementEvent
+	stats   EventStats
+
+	wake     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewEventQueue creates a queue holding up to size undelivered events
+// (DefaultEventBufferSize if size <= 0); call Start to begin delivery
+func NewEventQueue(publisher EventPublisher, size int) *EventQueue {
+	if size <= 0 {
+		size = DefaultEventBufferSize
+	}
+	return &EventQueue{
+		publisher: publisher,
+		size:      size,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start delivers queued events in the background until Stop
+func (q *EventQueue) Start() {
+	go q.run()
+}
+
+// Enqueue adds event for delivery without blocking
+func (q *EventQueue) Enqueue(event SettlementEvent) {
+	q.mu.Lock()
+	if len(q.pending) >= q.size {
+		dropped := q.pending[0]
+		q.pending = q.pending[1:]
+		q.stats.Dropped++
+		log.Printf("facilitator.Events: buffer full, dropped %s event %s (tx %s)", dropped.Type, dropped.ID, dropped.Receipt.TransactionHash)
+	}
+	q.pending = append(q.pending, event)
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop ends background delivery, then tries once more to deliver what is
+// still buffered until ctx ends (events left over are counted as dropped)
+// and closes the publisher if it is an io.Closer
+func (q *EventQueue) Stop(ctx context.Context) {
+	q.stopOnce.Do(func() {
+		close(q.stop)
+		<-q.done
+		q.flush(ctx)
+		if closer, ok := q.publisher.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Printf("facilitator.Events: failed to close publisher: %v", err)
+			}
+		}
+	})
+}
+
+// flush delivers the buffered events in order until one fails
+func (q *EventQueue) flush(ctx context.Context) {
+	for {
+		event, ok := q.next()
+		if !ok {
+			return
+		}
+		if err := q.publish(ctx, event); err != nil {
+			q.mu.Lock()
+			left := len(q.pending)
+			q.stats.Dropped += uint64(left)
+			q.pending = nil
+			q.mu.Unlock()
+			log.Printf("facilitator.Events: %d event(s) undelivered at shutdown: %v", left, err)
+			return
+		}
+		q.delivered(event)
+	}
+}
+
+// Stats returns the delivery totals
+func (q *EventQueue) Stats() EventStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats := q.stats
+	stats.Pending = len(q.pending)
+	return stats
+}
+
+// run delivers the oldest event until it succeeds, backing off between failures
+func (q *EventQueue) run() {
+	defer close(q.done)
+	var backoff time.Duration
+	for {
+		event, ok := q.next()
+		if !ok {
+			select {
+			case <-q.wake:
+				continue
+			case <-q.stop:
+				return
+			}
+		}
+
+		err := q.publish(context.Background(), event)
+		if err == nil {
+			q.delivered(event)
+			backoff = 0
+			continue
+		}
+		q.mu.Lock()
+		q.stats.Retries++
+		q.stats.LastError = err.Error()
+		q.mu.Unlock()
+		backoff = min(max(2*backoff, eventRetryMin), eventRetryMax)
+		log.Printf("facilitator.Events: publishing %s event %s failed (retrying in %s): %v", event.Type, event.ID, backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-q.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// next returns the oldest undelivered event
+func (q *EventQueue) next() (SettlementEvent, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return SettlementEvent{}, false
+	}
+	return q.pending[0], true
+}
+
+// publish delivers one event, bounded by eventPublishTimeout
+func (q *EventQueue) publish(ctx context.Context, event SettlementEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, eventPublishTimeout)
+	defer cancel()
+	return q.publisher.Publish(ctx, event)
+}
+
+// delivered removes event from the buffer, unless a full buffer already
+// dropped it while it was being published
+func (q *EventQueue) delivered(event SettlementEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats.Published++
+	if len(q.pending) > 0 && q.pending[0].ID == event.ID {
+		q.pending = q.pending[1:]
+	}
+}
+
+// SetEventPublisher publishes every settlement transition of the EVM
+// providers (submitted, confirmed, failed, reorged) to publisher through an
+// EventQueue of bufferSize events (see NewEventQueue). Providers report
+// transitions through evm.WithSettlementObserver(f.ObserveSettlement), which
+// Builder.WithEventPublisher adds; settlements resolved by the reconciler
+// are published too. Stop the queue returned by Events on shutdown.
+func (f *LocalFacilitator) SetEventPublisher(publisher EventPublisher, bufferSize int) {
+	if f.events != nil {
+		f.events.Stop(context.Background())
+		f.events = nil
+	}
+	if publisher == nil {
+		return
+	}
+	f.events = NewEventQueue(publisher, bufferSize)
+	f.events.Start()
+}
+
+// Events returns the queue delivering settlement events, or nil if no
+// publisher is set
+func (f *LocalFacilitator) Events() *EventQueue {
+	return f.events
+}
+
+// ObserveSettlement queues the event for a provider's settlement update; it
+// is the evm.WithSettlementObserver callback, and a no-op without a publisher
+func (f *LocalFacilitator) ObserveSettlement(update evm.SettlementUpdate) {
+	if f.events == nil {
+		return
+	}
+	eventType, ok := settlementEventType(update.Status)
+	if update.Reorg != nil {
+		eventType, ok = SettlementReorged, true
+	}
+	if !ok {
+		return
+	}
+	receipt := update.Receipt
+	receipt.Payer = f.formatAddress(receipt.Network, receipt.Payer)
+	receipt.PayTo = f.formatAddress(receipt.Network, receipt.PayTo)
+	f.events.Enqueue(SettlementEvent{
+		ID:        newEventID(),
+		Type:      eventType,
+		Time:      update.Time.UTC(),
+		JournalID: update.JournalID,
+		Status:    update.Status,
+		Receipt:   receipt,
+		Reorg:     update.Reorg,
+	})
+}
+
+// observeResolved publishes a journal entry the reconciler resolved
+func (f *LocalFacilitator) observeResolved(entry accounting.JournalEntry) {
+	f.ObserveSettlement(evm.SettlementUpdate{
+		JournalID: entry.ID,
+		Status:    entry.Status,
+		Receipt: types.PaymentReceipt{
+			Network:         entry.Network,
+			Payer:           entry.Payer,
+			PayTo:           entry.PayTo,
+			Amount:          entry.Amount,
+			Nonce:           entry.Nonce,
+			TransactionHash: entry.TransactionHash,
+		},
+		Time: time.Now(),
+	})
+}
+
+// settlementEventType maps a journal status to the event it publishes; an
+// unknown outcome publishes nothing until reconciliation resolves it
+func settlementEventType(status accounting.JournalStatus) (SettlementEventType, bool) {
+	switch status {
+	case accounting.JournalSubmitted:
+		return SettlementSubmitted, true
+	case accounting.JournalConfirmed:
+		return SettlementConfirmed, true
+	case accounting.JournalReverted, accounting.JournalExpired, accounting.JournalFailed:
+		return SettlementFailed, true
+	case accounting.JournalReorged:
+		return SettlementReorged, true
+	}
+	return "", false
+}
+
+// newEventID returns a random settlement event ID
+func newEventID() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,212 @@
+package facilitator_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// fakePublisher records delivered events, failing the first failures calls
+type fakePublisher struct {
+	mu        sync.Mutex
+	failures  int
+	attempts  int
+	delivered []facilitator.SettlementEvent
+	got       chan struct{}
+}
+
+func newFakePublisher(failures int) *fakePublisher {
+	return &fakePublisher{failures: failures, got: make(chan struct{}, 64)}
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, event facilitator.SettlementEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempts++
+	if p.attempts <= p.failures {
+		return errors.New("broker unavailable")
+	}
+	p.delivered = append(p.delivered, event)
+	p.got <- struct{}{}
+	return nil
+}
+
+func (p *fakePublisher) events() []facilitator.SettlementEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]facilitator.SettlementEvent(nil), p.delivered...)
+}
+
+// await waits for n more deliveries
+func (p *fakePublisher) await(t *testing.T, n int, timeout time.Duration) {
+	t.Helper()
+	deadline := time.After(timeout)
+	for i := 0; i < n; i++ {
+		select {
+		case <-p.got:
+		case <-deadline:
+			t.Fatalf("%d of %d events delivered in %s", i, n, timeout)
+		}
+	}
+}
+
+func queuedEvent(i int) facilitator.SettlementEvent {
+	return facilitator.SettlementEvent{
+		ID:      fmt.Sprintf("event-%d", i),
+		Type:    facilitator.SettlementSubmitted,
+		Receipt: types.PaymentReceipt{TransactionHash: fmt.Sprintf("0x%02x", i)},
+	}
+}
+
+func TestEventQueueRetriesInOrder(t *testing.T) {
+	publisher := newFakePublisher(1)
+	queue := facilitator.NewEventQueue(publisher, 0)
+	for i := 0; i < 5; i++ {
+		queue.Enqueue(queuedEvent(i))
+	}
+	queue.Start()
+	defer queue.Stop(context.Background())
+
+	// The first delivery fails; the queue backs off and keeps the order
+	publisher.await(t, 5, 5*time.Second)
+	for i, event := range publisher.events() {
+		if event.ID != queuedEvent(i).ID {
+			t.Errorf("delivery %d was %s, want %s", i, event.ID, queuedEvent(i).ID)
+		}
+	}
+	stats := queue.Stats()
+	if stats.Published != 5 || stats.Retries != 1 || stats.Pending != 0 || stats.Dropped != 0 || !strings.Contains(stats.LastError, "broker unavailable") {
+		t.Errorf("stats %+v, want 5 published after 1 retry", stats)
+	}
+}
+
+func TestEventQueueDropsOldestWhenFull(t *testing.T) {
+	publisher := newFakePublisher(0)
+	queue := facilitator.NewEventQueue(publisher, 2)
+	for i := 0; i < 4; i++ {
+		queue.Enqueue(queuedEvent(i))
+	}
+	if stats := queue.Stats(); stats.Pending != 2 || stats.Dropped != 2 {
+		t.Errorf("stats %+v, want 2 pending and 2 dropped", stats)
+	}
+
+	// Stop delivers what is still buffered
+	queue.Start()
+	queue.Stop(context.Background())
+	events := publisher.events()
+	if len(events) != 2 || events[0].ID != "event-2" || events[1].ID != "event-3" {
+		t.Errorf("delivered %v, want the two newest events", events)
+	}
+}
+
+func TestEventQueueCountsUndeliveredAtStop(t *testing.T) {
+	queue := facilitator.NewEventQueue(newFakePublisher(1000), 0)
+	for i := 0; i < 3; i++ {
+		queue.Enqueue(queuedEvent(i))
+	}
+	queue.Start()
+	queue.Stop(context.Background())
+	if stats := queue.Stats(); stats.Dropped != 3 || stats.Pending != 0 {
+		t.Errorf("stats %+v, want the 3 undelivered events dropped", stats)
+	}
+}
+
+func TestSettlementPublishesLifecycleEvents(t *testing.T) {
+	chain := newTestChain(t)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	publisher := newFakePublisher(0)
+	fac, err := facilitator.NewBuilder().
+		WithEVMNetwork(testchain.Network, chain.Options()).
+		WithEventPublisher(publisher, 0).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fac.Events().Stop(context.Background())
+
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settled, err := fac.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil || !settled.Success || settled.TransactionHash == nil {
+		t.Fatalf("settle: %+v (%v)", settled, err)
+	}
+
+	publisher.await(t, 2, 5*time.Second)
+	events := publisher.events()
+	if events[0].Type != facilitator.SettlementSubmitted || events[1].Type != facilitator.SettlementConfirmed {
+		t.Fatalf("events %s then %s, want submitted then confirmed", events[0].Type, events[1].Type)
+	}
+	for _, event := range events {
+		receipt := event.Receipt
+		if !strings.EqualFold(receipt.TransactionHash, settled.TransactionHash.Hash) || receipt.Network != testchain.Network ||
+			!strings.EqualFold(receipt.Payer, chain.Accounts[0].Address.Hex()) || !strings.EqualFold(receipt.PayTo, payTo.Hex()) || receipt.Amount != "1000" {
+			t.Errorf("%s receipt %+v, want the settled payment", event.Type, receipt)
+		}
+	}
+	if events[0].ID == events[1].ID {
+		t.Error("both events share an ID")
+	}
+}
+
+func TestWebhookPublisherSignsEvents(t *testing.T) {
+	const secret = "webhook secret"
+	var received facilitator.SettlementEvent
+	var signature, status string
+	fail := true
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		signature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		status = r.Header.Get(facilitator.HeaderEventSignature)
+	}))
+	defer hook.Close()
+
+	publisher, err := facilitator.NewWebhookPublisher(hook.URL, secret, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := queuedEvent(7)
+	if err := publisher.Publish(context.Background(), event); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("publish to a failing webhook: %v, want the status in the error", err)
+	}
+	fail = false
+	if err := publisher.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if received.ID != event.ID || status != signature {
+		t.Errorf("webhook received %s signed %q, want %s signed %q", received.ID, status, event.ID, signature)
+	}
+
+	if _, err := facilitator.NewWebhookPublisher("ftp://hooks.test", "", nil); err == nil {
+		t.Error("a non-http webhook URL was accepted")
+	}
+}
@@ -0,0 +1,31 @@
+package facilitator
+
+import (
+	"math/big"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// SetClock replaces the clock the faucet's daily cap is measured with
+func (f *Faucet) SetClock(clock types.Clock) {
+	f.clock = clock
+}
+
+// SetClock replaces the clock the gas budget's UTC days are measured with
+func (b *GasBudget) SetClock(clock types.Clock) {
+	b.clock = clock
+}
+
+// GasReservation is a fee held against a gas budget
+type GasReservation = gasReservation
+
+// Reserve holds fee against payTo's budget on network, as Settle does
+func (b *GasBudget) Reserve(payTo string, network types.Network, fee *big.Int) (*GasReservation, *big.Int, bool) {
+	return b.reserve(payTo, network, fee)
+}
+
+// Commit swaps the reservation for the fee actually paid
+func (r *GasReservation) Commit(fee *big.Int) { r.commit(fee) }
+
+// Release gives the reservation back
+func (r *GasReservation) Release() { r.release() }
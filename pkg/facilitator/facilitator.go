@@ -40,6 +40,23 @@ type Facilitator interface {
 	// This is an on-chain operation that consumes gas.
 	Settle(ctx context.Context, request *types.SettleRequest) (*types.SettleResponse, error)
 
+	// Simulate dry-runs a settlement without submitting anything.
+	//
+	// This performs Verify plus a gas estimate of the settlement call and
+	// reports the expected fee or the decoded revert reason.
+	// No state is changed and no nonce is reserved.
+	Simulate(ctx context.Context, request *types.SettleRequest) (*types.SimulateResponse, error)
+
+	// Cancel revokes an unsettled authorization on-chain.
+	//
+	// This:
+	// - Checks the authorizer signed the cancellation
+	// - Refuses authorizations already settled or cancelled
+	// - Submits cancelAuthorization and waits for confirmation
+	//
+	// Afterwards Verify rejects any payment carrying the nonce.
+	Cancel(ctx context.Context, request *types.CancelRequest) (*types.CancelResponse, error)
+
 	// Supported returns the payment kinds supported by this facilitator.
 	//
 	// This includes all configured networks and their token deployments.
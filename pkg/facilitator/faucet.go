@@ -0,0 +1,144 @@
+package facilitator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Faucet defaults
+const (
+	DefaultFaucetAmount     = 1000000 // 1 USDC (6 decimals)
+	DefaultFaucetDailyLimit = 1
+)
+
+// Faucet errors
+var (
+	ErrFaucetMainnet = errors.New("the faucet only funds testnet addresses")
+	ErrFaucetLimit   = errors.New("daily faucet limit reached for this address")
+)
+
+// Faucet sends small amounts of testnet USDC from the settlement signers so
+// developers can produce payments (POST /dev/fund in development mode).
+// Grants are recorded in memory and capped per address over a rolling day.
+type Faucet struct {
+	facilitator *LocalFacilitator
+	amount      *big.Int
+	dailyLimit  int
+	clock       types.Clock
+
+	mu     sync.Mutex
+	grants map[string][]time.Time // Lowercase address -> grant times in the last day
+}
+
+// FaucetGrant is the outcome of a successful Fund
+type FaucetGrant struct {
+	Network         types.Network `json:"network"`
+	Address         string        `json:"address"`
+	Token           string        `json:"token"`
+	Amount          string        `json:"amount"`
+	TransactionHash string        `json:"transactionHash"`
+	Remaining       int           `json:"remaining"` // Grants left today for the address
+}
+
+// NewFaucet creates a faucet granting amount (atomic units; <= 0 uses
+// DefaultFaucetAmount) up to dailyLimit times per address per day (<= 0 uses
+// DefaultFaucetDailyLimit)
+func NewFaucet(fac *LocalFacilitator, amount *big.Int, dailyLimit int) *Faucet {
+	if amount == nil || amount.Sign() <= 0 {
+		amount = big.NewInt(DefaultFaucetAmount)
+	}
+	if dailyLimit <= 0 {
+		dailyLimit = DefaultFaucetDailyLimit
+	}
+	return &Faucet{
+		facilitator: fac,
+		amount:      new(big.Int).Set(amount),
+		dailyLimit:  dailyLimit,
+		clock:       types.SystemClock{},
+		grants:      make(map[string][]time.Time),
+	}
+}
+
+// Fund sends the faucet amount of the network's USDC to address
+func (f *Faucet) Fund(ctx context.Context, net types.Network, address string) (*FaucetGrant, error) {
+	if !net.IsTestnet() {
+		return nil, ErrFaucetMainnet
+	}
+	if !common.IsHexAddress(types.NormalizeEVMAddress(address)) {
+		return nil, fmt.Errorf("invalid address %q", address)
+	}
+	to := common.HexToAddress(types.NormalizeEVMAddress(address))
+	provider, ok := f.facilitator.evmProviders[net]
+	if !ok || !f.facilitator.networkEnabled(net) {
+		return nil, fmt.Errorf("network %s is not served by this facilitator", net)
+	}
+	deployment, err := network.GetUSDCDeployment(net)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reserve the grant before sending so concurrent requests cannot exceed
+	// the cap; a failed transfer gives it back
+	key := strings.ToLower(to.Hex())
+	grantedAt, remaining, ok := f.reserve(key)
+	if !ok {
+		return nil, ErrFaucetLimit
+	}
+	txHash, err := provider.Transfer(ctx, deployment.TokenAddress, to, f.amount)
+	if err != nil {
+		f.release(key, grantedAt)
+		return nil, err
+	}
+	return &FaucetGrant{
+		Network:         net,
+		Address:         to.Hex(),
+		Token:           deployment.TokenAddress.Hex(),
+		Amount:          f.amount.String(),
+		TransactionHash: txHash.Hex(),
+		Remaining:       remaining,
+	}, nil
+}
+
+// reserve records a grant for key if it is under the daily limit
+func (f *Faucet) reserve(key string) (time.Time, int, bool) {
+	now := f.clock.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	recent := f.grants[key][:0]
+	for _, at := range f.grants[key] {
+		if now.Sub(at) < 24*time.Hour {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= f.dailyLimit {
+		f.grants[key] = recent
+		return time.Time{}, 0, false
+	}
+	f.grants[key] = append(recent, now)
+	return now, f.dailyLimit - len(recent) - 1, true
+}
+
+// release forgets a reserved grant
+func (f *Faucet) release(key string, grantedAt time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	grants := f.grants[key]
+	for i, at := range grants {
+		if at.Equal(grantedAt) {
+			f.grants[key] = append(grants[:i], grants[i+1:]...)
+			break
+		}
+	}
+	if len(f.grants[key]) == 0 {
+		delete(f.grants, key)
+	}
+}
@@ -0,0 +1,142 @@
+package facilitator_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// faucetClock is a settable clock for the faucet's daily cap
+type faucetClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *faucetClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *faucetClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// sepoliaFaucet serves Base Sepolia from a simulated chain, so the faucet's
+// transfers are mined there
+func sepoliaFaucet(t *testing.T, amount int64, dailyLimit int) (*facilitator.Faucet, *testchain.Chain) {
+	t.Helper()
+	chain := newTestChain(t)
+	fac, err := facilitator.NewBuilder().WithEVMNetwork(types.NetworkBaseSepolia, chain.Options()).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return facilitator.NewFaucet(fac, big.NewInt(amount), dailyLimit), chain
+}
+
+func TestFaucetRefusesMainnets(t *testing.T) {
+	chain := newTestChain(t)
+	fac, err := facilitator.NewBuilder().
+		WithEVMNetwork(types.NetworkBase, chain.Options()).
+		WithEVMNetwork(testchain.Network, chain.Options()).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	faucet := facilitator.NewFaucet(fac, nil, 0)
+	for _, net := range []types.Network{types.NetworkBase, types.NetworkPolygon, testchain.Network} {
+		if _, err := faucet.Fund(context.Background(), net, chain.Accounts[0].Address.Hex()); !errors.Is(err, facilitator.ErrFaucetMainnet) {
+			t.Errorf("%s: error %v, want %v", net, err, facilitator.ErrFaucetMainnet)
+		}
+	}
+}
+
+func TestFaucetPacksERC20Transfer(t *testing.T) {
+	faucet, chain := sepoliaFaucet(t, 2_500_000, 1)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000f1")
+	grant, err := faucet.Fund(context.Background(), types.NetworkBaseSepolia, strings.ToLower(to.Hex()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment, err := network.GetUSDCDeployment(types.NetworkBaseSepolia)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grant.Address != to.Hex() || grant.Token != deployment.TokenAddress.Hex() || grant.Amount != "2500000" || grant.Remaining != 0 {
+		t.Errorf("grant %+v", grant)
+	}
+
+	tx, _, err := chain.Client().TransactionByHash(context.Background(), common.HexToHash(grant.TransactionHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.To() == nil || *tx.To() != deployment.TokenAddress {
+		t.Fatalf("transfer sent to %v, want the USDC contract", tx.To())
+	}
+	// transfer(address,uint256): selector, then the two words
+	data := tx.Data()
+	want := append(common.FromHex("0xa9059cbb"), common.LeftPadBytes(to.Bytes(), 32)...)
+	want = append(want, common.LeftPadBytes(big.NewInt(2_500_000).Bytes(), 32)...)
+	if string(data) != string(want) {
+		t.Errorf("calldata %x, want %x", data, want)
+	}
+}
+
+func TestFaucetDailyCap(t *testing.T) {
+	faucet, _ := sepoliaFaucet(t, 1, 2)
+	clock := &faucetClock{now: time.Unix(1_700_000_000, 0)}
+	faucet.SetClock(clock)
+	address := "0x00000000000000000000000000000000000000F2"
+	fund := func(address string) (*facilitator.FaucetGrant, error) {
+		return faucet.Fund(context.Background(), types.NetworkBaseSepolia, address)
+	}
+
+	for want := 1; want >= 0; want-- {
+		grant, err := fund(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if grant.Remaining != want {
+			t.Errorf("%d grants remaining, want %d", grant.Remaining, want)
+		}
+	}
+	// The cap is per address, whatever its case
+	if _, err := fund(strings.ToLower(address)); !errors.Is(err, facilitator.ErrFaucetLimit) {
+		t.Errorf("third grant: error %v, want %v", err, facilitator.ErrFaucetLimit)
+	}
+	if _, err := fund("0x00000000000000000000000000000000000000f3"); err != nil {
+		t.Errorf("another address: %v", err)
+	}
+
+	clock.Advance(23 * time.Hour)
+	if _, err := fund(address); !errors.Is(err, facilitator.ErrFaucetLimit) {
+		t.Errorf("within the day: error %v, want %v", err, facilitator.ErrFaucetLimit)
+	}
+	clock.Advance(time.Hour)
+	if _, err := fund(address); err != nil {
+		t.Errorf("a day later: %v", err)
+	}
+}
+
+func TestFaucetRejectsBadRequests(t *testing.T) {
+	faucet, _ := sepoliaFaucet(t, 1, 1)
+	if _, err := faucet.Fund(context.Background(), types.NetworkBaseSepolia, "not-an-address"); err == nil {
+		t.Error("funded an invalid address")
+	}
+	// A testnet the facilitator does not serve
+	if _, err := faucet.Fund(context.Background(), types.NetworkPolygonAmoy, "0x00000000000000000000000000000000000000f4"); err == nil || errors.Is(err, facilitator.ErrFaucetLimit) {
+		t.Errorf("unserved network: error %v", err)
+	}
+}
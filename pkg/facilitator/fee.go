@@ -0,0 +1,51 @@
+package facilitator
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// SetFeePolicy configures the surcharge required for payments on a network
+func (f *LocalFacilitator) SetFeePolicy(network types.Network, policy types.FeePolicy) {
+	f.feePolicies[network] = policy
+}
+
+// checkFee enforces the network's fee policy on a payment
+// Returns a non-empty reason when the authorized value does not cover resource price + fee
+func (f *LocalFacilitator) checkFee(payload *types.PaymentPayload, requirements *types.PaymentRequirements) string {
+	policy, ok := f.feePolicies[requirements.Network]
+	if !ok {
+		return ""
+	}
+
+	// Resource price: from the breakdown when the server applied the fee,
+	// otherwise the whole requirement amount
+	amountStr := requirements.MaxAmountRequired
+	if extra, _ := types.ParseExtra(requirements.Extra); extra != nil && extra.FeeBreakdown != nil {
+		amountStr = extra.FeeBreakdown.ResourceAmount
+	}
+	resourceAmount, ok := new(big.Int).SetString(amountStr, 10)
+	if !ok {
+		return "invalid required amount"
+	}
+
+	required := new(big.Int).Add(resourceAmount, policy.Fee(resourceAmount))
+	value, ok := new(big.Int).SetString(payload.Payload.Authorization.Value, 10)
+	if !ok {
+		return "invalid value format"
+	}
+	if value.Cmp(required) < 0 {
+		return fmt.Sprintf("payment amount %s does not cover resource price plus facilitator fee (%s)", value, required)
+	}
+	return ""
+}
+
+// feeComponent returns the facilitator fee included in a payment (for logging)
+func (f *LocalFacilitator) feeComponent(requirements *types.PaymentRequirements) string {
+	if fee := requirements.FacilitatorFee(); fee != "" {
+		return fee
+	}
+	return "0"
+}
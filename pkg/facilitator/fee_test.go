@@ -0,0 +1,69 @@
+package facilitator_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestVerifyRejectsUnderpaidFeeByOneUnit(t *testing.T) {
+	chain := newTestChain(t)
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := types.FeePolicy{FlatAmount: big.NewInt(100)}
+	fac.SetFeePolicy(testchain.Network, policy)
+
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	if err := policy.ApplyFee(&requirements); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		value int64
+		want  types.ReasonCode // "" for a valid payment
+	}{
+		{1099, types.ReasonFeeNotCovered},
+		{1100, ""},
+	} {
+		signed := requirements
+		signed.MaxAmountRequired = big.NewInt(tc.value).String()
+		payload, err := chain.Authorize(chain.Accounts[0], signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := fac.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.want == "" {
+			if !resp.IsValid {
+				t.Errorf("value %d: %s (%s), want it valid", tc.value, resp.Reason, resp.ReasonCode)
+			}
+			continue
+		}
+		if resp.IsValid || resp.ReasonCode != tc.want {
+			t.Errorf("value %d: valid %v, reason %q; want %q", tc.value, resp.IsValid, resp.ReasonCode, tc.want)
+		}
+	}
+
+	supported, err := fac.Supported(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var advertised bool
+	for _, kind := range supported.Kinds {
+		if kind.Network == testchain.Network && kind.Fee != nil && kind.Fee.FlatAmount == "100" {
+			advertised = true
+		}
+	}
+	if !advertised {
+		t.Error("/supported does not advertise the network's fee")
+	}
+}
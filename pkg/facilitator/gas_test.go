@@ -0,0 +1,56 @@
+package facilitator_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestSettleRecordsGasSpend(t *testing.T) {
+	chain := newTestChain(t)
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger := accounting.NewGasLedger(nil)
+	fac.SetGasLedger(ledger)
+
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	var gasUsed uint64
+	fee := new(big.Int)
+	for i := 0; i < 2; i++ {
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := fac.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Success || resp.GasUsed == 0 || resp.EffectiveGasPrice == "" {
+			t.Fatalf("settle %d: %+v, want the receipt's gas reported", i, resp)
+		}
+		gasUsed += resp.GasUsed
+		price, _ := new(big.Int).SetString(resp.EffectiveGasPrice, 10)
+		fee.Add(fee, new(big.Int).Mul(price, new(big.Int).SetUint64(resp.GasUsed)))
+	}
+
+	totals := ledger.Totals(payTo.Hex(), time.Time{}, time.Time{})
+	if len(totals) != 1 {
+		t.Fatalf("%d buckets, want one for today: %+v", len(totals), totals)
+	}
+	total := totals[0]
+	if total.PayTo != strings.ToLower(payTo.Hex()) || total.Settlements != 2 || total.GasUsed != gasUsed || total.FeeNative != fee.String() {
+		t.Errorf("total %+v, want 2 settlements using %d gas for %s wei", total, gasUsed, fee)
+	}
+}
@@ -0,0 +1,258 @@
+package facilitator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// GasBudget caps the settlement gas the facilitator sponsors per payTo and
+// UTC day, in wei of the network's native token (each network has its own
+// budget, since wei of different chains are not comparable).
+//
+// Settle reserves its worst-case fee before submitting and refuses the
+// payment when the reservation does not fit; once the receipt is in, the
+// reservation is swapped for the gas actually recorded. Cancellations pay
+// no one and are charged their worst-case fee to the authorizer's budget.
+// Spend is kept in memory and resets at UTC midnight.
+type GasBudget struct {
+	daily     *big.Int            // Default budget (nil: payTos without an override are unlimited)
+	overrides map[string]*big.Int // Lowercase payTo -> budget
+	clock     types.Clock
+
+	mu       sync.Mutex
+	day      string // UTC day the figures below belong to
+	spent    map[gasBudgetKey]*big.Int
+	reserved map[gasBudgetKey]*big.Int
+}
+
+// gasBudgetKey identifies one budget
+type gasBudgetKey struct {
+	payTo   string
+	network types.Network
+}
+
+// GasBudgetRemaining is what is left of one payTo's budget today
+type GasBudgetRemaining struct {
+	Budget    string `json:"budget"`    // wei
+	Spent     string `json:"spent"`     // wei, recorded from receipts
+	Reserved  string `json:"reserved"`  // wei, held by settlements in flight
+	Remaining string `json:"remaining"` // wei
+}
+
+// gasReservation is the fee a settlement holds against its budget; nil when
+// the payTo has no budget
+type gasReservation struct {
+	budget *GasBudget
+	key    gasBudgetKey
+	day    string
+	fee    *big.Int
+}
+
+// NewGasBudget creates a budget of daily wei per payTo (nil for no default),
+// with overrides for individual payTos (see LoadGasBudgetOverrides)
+func NewGasBudget(daily *big.Int, overrides map[string]*big.Int) *GasBudget {
+	b := &GasBudget{
+		overrides: make(map[string]*big.Int, len(overrides)),
+		clock:     types.SystemClock{},
+		spent:     make(map[gasBudgetKey]*big.Int),
+		reserved:  make(map[gasBudgetKey]*big.Int),
+	}
+	if daily != nil {
+		b.daily = new(big.Int).Set(daily)
+	}
+	for payTo, budget := range overrides {
+		b.overrides[gasBudgetPayTo(payTo)] = new(big.Int).Set(budget)
+	}
+	return b
+}
+
+// LoadGasBudgetOverrides reads per-payTo budgets from a JSON file mapping
+// addresses to wei, e.g. {"0xabc...": "5000000000000000"}
+func LoadGasBudgetOverrides(path string) (map[string]*big.Int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gas budget file: %w", err)
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid gas budget file %s: %w", path, err)
+	}
+	overrides := make(map[string]*big.Int, len(raw))
+	for payTo, value := range raw {
+		if !common.IsHexAddress(types.NormalizeEVMAddress(payTo)) {
+			return nil, fmt.Errorf("invalid gas budget file %s: %q is not an address", path, payTo)
+		}
+		wei, ok := new(big.Int).SetString(value, 10)
+		if !ok || wei.Sign() < 0 {
+			return nil, fmt.Errorf("invalid gas budget file %s: budget %q of %s is not an amount in wei", path, value, payTo)
+		}
+		overrides[payTo] = wei
+	}
+	return overrides, nil
+}
+
+// gasBudgetPayTo is the form payTos are keyed by
+func gasBudgetPayTo(payTo string) string {
+	return strings.ToLower(types.NormalizeEVMAddress(payTo))
+}
+
+// limit returns payTo's daily budget, nil if it has none
+func (b *GasBudget) limit(payTo string) *big.Int {
+	if budget, ok := b.overrides[payTo]; ok {
+		return budget
+	}
+	return b.daily
+}
+
+// rollover starts a new day's figures at UTC midnight (b.mu held)
+func (b *GasBudget) rollover() string {
+	day := b.clock.Now().UTC().Format("2006-01-02")
+	if day != b.day {
+		b.day = day
+		b.spent = make(map[gasBudgetKey]*big.Int)
+		b.reserved = make(map[gasBudgetKey]*big.Int)
+	}
+	return day
+}
+
+// remaining returns what is left of key's budget of limit (b.mu held)
+func (b *GasBudget) remaining(key gasBudgetKey, limit *big.Int) *big.Int {
+	left := new(big.Int).Set(limit)
+	if spent := b.spent[key]; spent != nil {
+		left.Sub(left, spent)
+	}
+	if reserved := b.reserved[key]; reserved != nil {
+		left.Sub(left, reserved)
+	}
+	return left
+}
+
+// reserve holds fee against payTo's budget on network, reporting false
+// (and what remains) if it does not fit
+func (b *GasBudget) reserve(payTo string, network types.Network, fee *big.Int) (*gasReservation, *big.Int, bool) {
+	key := gasBudgetKey{payTo: gasBudgetPayTo(payTo), network: network}
+	limit := b.limit(key.payTo)
+	if limit == nil {
+		return nil, nil, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	day := b.rollover()
+	left := b.remaining(key, limit)
+	if fee.Cmp(left) > 0 {
+		if left.Sign() < 0 {
+			left.SetInt64(0)
+		}
+		return nil, left, false
+	}
+	addTo(b.reserved, key, fee)
+	return &gasReservation{budget: b, key: key, day: day, fee: new(big.Int).Set(fee)}, left.Sub(left, fee), true
+}
+
+// commit replaces the reservation with the fee the settlement actually paid
+func (r *gasReservation) commit(fee *big.Int) {
+	if r == nil {
+		return
+	}
+	b := r.budget
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.unreserve(r)
+	// A settlement straddling midnight counts towards the day of its receipt,
+	// like its gas ledger record
+	addTo(b.spent, r.key, fee)
+}
+
+// commitReserved charges the reserved fee itself, for transactions whose
+// receipt gas is not reported (cancellations)
+func (r *gasReservation) commitReserved() {
+	if r != nil {
+		r.commit(r.fee)
+	}
+}
+
+// release gives back the reservation of a settlement that sent nothing
+func (r *gasReservation) release() {
+	if r == nil {
+		return
+	}
+	b := r.budget
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.unreserve(r)
+}
+
+// unreserve drops r from today's reservations (b.mu held); reservations of
+// an earlier day went with its figures
+func (b *GasBudget) unreserve(r *gasReservation) {
+	if b.rollover() != r.day {
+		return
+	}
+	if reserved := b.reserved[r.key]; reserved != nil {
+		reserved.Sub(reserved, r.fee)
+		if reserved.Sign() <= 0 {
+			delete(b.reserved, r.key)
+		}
+	}
+}
+
+// addTo adds amount to m[key]
+func addTo(m map[gasBudgetKey]*big.Int, key gasBudgetKey, amount *big.Int) {
+	if total, ok := m[key]; ok {
+		total.Add(total, amount)
+		return
+	}
+	m[key] = new(big.Int).Set(amount)
+}
+
+// Remaining returns today's budget of every payTo that has spent or
+// reserved gas, by payTo and network
+func (b *GasBudget) Remaining() map[string]map[types.Network]GasBudgetRemaining {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover()
+
+	keys := make(map[gasBudgetKey]bool, len(b.spent)+len(b.reserved))
+	for key := range b.spent {
+		keys[key] = true
+	}
+	for key := range b.reserved {
+		keys[key] = true
+	}
+	budgets := make(map[string]map[types.Network]GasBudgetRemaining)
+	for key := range keys {
+		limit := b.limit(key.payTo)
+		if limit == nil {
+			continue
+		}
+		spent, reserved := new(big.Int), new(big.Int)
+		if v := b.spent[key]; v != nil {
+			spent.Set(v)
+		}
+		if v := b.reserved[key]; v != nil {
+			reserved.Set(v)
+		}
+		left := b.remaining(key, limit)
+		if left.Sign() < 0 {
+			left.SetInt64(0)
+		}
+		if budgets[key.payTo] == nil {
+			budgets[key.payTo] = make(map[types.Network]GasBudgetRemaining)
+		}
+		budgets[key.payTo][key.network] = GasBudgetRemaining{
+			Budget:    limit.String(),
+			Spent:     spent.String(),
+			Reserved:  reserved.String(),
+			Remaining: left.String(),
+		}
+	}
+	return budgets
+}
@@ -0,0 +1,223 @@
+package facilitator_test
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+const budgetPayTo = "0x00000000000000000000000000000000000000B0"
+
+// budgetLeft returns payTo's figures on base, the zero value if it has none
+func budgetLeft(budget *facilitator.GasBudget, payTo string) facilitator.GasBudgetRemaining {
+	return budget.Remaining()[strings.ToLower(payTo)][types.NetworkBase]
+}
+
+func TestGasBudgetReservesAndReleases(t *testing.T) {
+	budget := facilitator.NewGasBudget(big.NewInt(1000), nil)
+	budget.SetClock(&faucetClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)})
+
+	first, _, ok := budget.Reserve(budgetPayTo, types.NetworkBase, big.NewInt(600))
+	if !ok {
+		t.Fatal("a reservation within the budget was refused")
+	}
+	// Reservations hold the budget while settlements are in flight
+	if _, left, ok := budget.Reserve(budgetPayTo, types.NetworkBase, big.NewInt(500)); ok || left.Int64() != 400 {
+		t.Errorf("reservation past the held budget: ok %v with %v left, want it refused with 400 left", ok, left)
+	}
+	// Other networks and payTos have budgets of their own
+	if _, _, ok := budget.Reserve(budgetPayTo, types.NetworkBaseSepolia, big.NewInt(1000)); !ok {
+		t.Error("another network's budget was charged")
+	}
+	if _, _, ok := budget.Reserve("0x00000000000000000000000000000000000000b1", types.NetworkBase, big.NewInt(1000)); !ok {
+		t.Error("another payTo's budget was charged")
+	}
+
+	// The receipt's fee replaces the estimate
+	first.Commit(big.NewInt(250))
+	if got := budgetLeft(budget, budgetPayTo); got != (facilitator.GasBudgetRemaining{Budget: "1000", Spent: "250", Reserved: "0", Remaining: "750"}) {
+		t.Errorf("after committing: %+v", got)
+	}
+
+	// A settlement sending nothing gives its reservation back
+	second, _, ok := budget.Reserve(strings.ToLower(budgetPayTo), types.NetworkBase, big.NewInt(700))
+	if !ok {
+		t.Fatal("reservation within what is left was refused")
+	}
+	if got := budgetLeft(budget, budgetPayTo); got.Reserved != "700" || got.Remaining != "50" {
+		t.Errorf("while reserved: %+v", got)
+	}
+	second.Release()
+	if got := budgetLeft(budget, budgetPayTo); got.Reserved != "0" || got.Remaining != "750" {
+		t.Errorf("after release: %+v", got)
+	}
+
+	// Overspending (a receipt above its estimate) leaves nothing, not less
+	third, _, _ := budget.Reserve(budgetPayTo, types.NetworkBase, big.NewInt(750))
+	third.Commit(big.NewInt(900))
+	if got := budgetLeft(budget, budgetPayTo); got.Spent != "1150" || got.Remaining != "0" {
+		t.Errorf("after overspending: %+v", got)
+	}
+	if _, left, ok := budget.Reserve(budgetPayTo, types.NetworkBase, big.NewInt(1)); ok || left.Sign() != 0 {
+		t.Errorf("reservation of an overspent budget: ok %v with %v left", ok, left)
+	}
+}
+
+func TestGasBudgetResetsAtUTCMidnight(t *testing.T) {
+	// 23:30 UTC, already the next day in UTC+1: the budget counts UTC days
+	clock := &faucetClock{now: time.Date(2026, 3, 2, 0, 30, 0, 0, time.FixedZone("CET", 3600))}
+	budget := facilitator.NewGasBudget(big.NewInt(1000), map[string]*big.Int{budgetPayTo: big.NewInt(100)})
+	budget.SetClock(clock)
+
+	spent, _, _ := budget.Reserve(budgetPayTo, types.NetworkBase, big.NewInt(60))
+	spent.Commit(big.NewInt(60))
+	inFlight, _, ok := budget.Reserve(budgetPayTo, types.NetworkBase, big.NewInt(40))
+	if !ok {
+		t.Fatal("override budget refused a fitting reservation")
+	}
+	if _, _, ok := budget.Reserve(budgetPayTo, types.NetworkBase, big.NewInt(1)); ok {
+		t.Fatal("the override did not cap the payTo below the default")
+	}
+
+	clock.Advance(29 * time.Minute) // 23:59 UTC
+	if _, _, ok := budget.Reserve(budgetPayTo, types.NetworkBase, big.NewInt(1)); ok {
+		t.Error("budget reset before UTC midnight")
+	}
+
+	clock.Advance(2 * time.Minute) // 00:01 UTC
+	if got := budgetLeft(budget, budgetPayTo); got != (facilitator.GasBudgetRemaining{}) {
+		t.Errorf("after midnight: %+v, want yesterday's figures gone", got)
+	}
+	// Yesterday's reservation settles into today's figures without
+	// touching today's reservations
+	today, _, ok := budget.Reserve(budgetPayTo, types.NetworkBase, big.NewInt(70))
+	if !ok {
+		t.Fatal("reservation refused after the reset")
+	}
+	inFlight.Commit(big.NewInt(30))
+	if got := budgetLeft(budget, budgetPayTo); got.Spent != "30" || got.Reserved != "70" || got.Remaining != "0" {
+		t.Errorf("after yesterday's settlement committed: %+v", got)
+	}
+	inFlight.Release() // Already gone with yesterday's figures
+	today.Release()
+	if got := budgetLeft(budget, budgetPayTo); got.Spent != "30" || got.Reserved != "0" || got.Remaining != "70" {
+		t.Errorf("after releases: %+v", got)
+	}
+}
+
+func TestGasBudgetWithoutDefaultLimitsOnlyOverrides(t *testing.T) {
+	budget := facilitator.NewGasBudget(nil, map[string]*big.Int{budgetPayTo: big.NewInt(10)})
+	if reservation, _, ok := budget.Reserve("0x00000000000000000000000000000000000000b1", types.NetworkBase, big.NewInt(1e18)); !ok || reservation != nil {
+		t.Errorf("payTo without a budget: reservation %v, ok %v; want it unlimited and untracked", reservation, ok)
+	}
+	if _, _, ok := budget.Reserve(budgetPayTo, types.NetworkBase, big.NewInt(11)); ok {
+		t.Error("override exceeded")
+	}
+}
+
+func TestLoadGasBudgetOverrides(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	overrides, err := facilitator.LoadGasBudgetOverrides(write("ok.json", `{"`+budgetPayTo+`": "5000000000000000", "xdc00000000000000000000000000000000000000b1": "0"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overrides) != 2 || overrides[budgetPayTo].String() != "5000000000000000" {
+		t.Errorf("overrides %v", overrides)
+	}
+	// Keys match however the payTo is written
+	budget := facilitator.NewGasBudget(nil, overrides)
+	if _, _, ok := budget.Reserve("0x00000000000000000000000000000000000000b1", types.NetworkBase, big.NewInt(1)); ok {
+		t.Error("the xdc-prefixed zero budget did not apply to the 0x form")
+	}
+
+	for name, content := range map[string]string{
+		"not json":         `{`,
+		"not an address":   `{"merchant": "1"}`,
+		"negative budget":  `{"` + budgetPayTo + `": "-1"}`,
+		"not a wei amount": `{"` + budgetPayTo + `": "0.5"}`,
+	} {
+		if _, err := facilitator.LoadGasBudgetOverrides(write("bad.json", content)); err == nil {
+			t.Errorf("%s: loaded %s", name, content)
+		}
+	}
+	if _, err := facilitator.LoadGasBudgetOverrides(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loaded a missing file")
+	}
+}
+
+func TestSettleRefusesPastGasBudget(t *testing.T) {
+	chain := newTestChain(t)
+	payTo := common.HexToAddress(budgetPayTo)
+	settle := func(fac *facilitator.LocalFacilitator) *types.SettleResponse {
+		t.Helper()
+		requirements := chain.Requirements(payTo, big.NewInt(1000))
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := fac.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	provider, err := chain.Provider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimate, err := provider.EstimateSettlementFee(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A budget of one settlement at its worst case
+	budget := facilitator.NewGasBudget(nil, map[string]*big.Int{budgetPayTo: estimate})
+	fac, err := facilitator.NewBuilder().
+		WithEVMNetwork(testchain.Network, chain.Options()).
+		WithGasBudget(budget).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := settle(fac)
+	if !first.Success {
+		t.Fatalf("first settlement refused: %s (%s)", first.Error, first.ReasonCode)
+	}
+	// The receipt's fee is charged, and leaves less than a worst case
+	gasPrice, _ := new(big.Int).SetString(first.EffectiveGasPrice, 10)
+	fee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(first.GasUsed))
+	remaining := fac.Stats()["gasBudgets"].(map[string]map[types.Network]facilitator.GasBudgetRemaining)[strings.ToLower(budgetPayTo)][testchain.Network]
+	if remaining.Spent != fee.String() || remaining.Reserved != "0" {
+		t.Errorf("admin stats %+v, want %s spent and nothing held", remaining, fee)
+	}
+
+	if resp := settle(fac); resp.Success || resp.ReasonCode != types.ReasonGasBudgetExceeded {
+		t.Errorf("settlement past the budget: success %v, reason %q; want %q", resp.Success, resp.ReasonCode, types.ReasonGasBudgetExceeded)
+	}
+}
@@ -0,0 +1,197 @@
+package facilitator
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultHookTimeout bounds each hook that sets no timeout of its own
+const DefaultHookTimeout = 2 * time.Second
+
+// VerifyHook adds a deployment's own rule to verification (e.g. sanctions
+// screening). It sees every payment the provider found valid and returns the
+// response to answer with: response itself to accept, or an invalid response
+// with the hook's own reason code to refuse. An error fails the verification.
+type VerifyHook func(ctx context.Context, request *types.VerifyRequest, response *types.VerifyResponse) (*types.VerifyResponse, error)
+
+// SettleHook vetoes settlements before anything is submitted: a non-nil
+// response refuses the settlement with it, nil lets it proceed. An error
+// fails the settlement.
+//
+// Settle does not run verify hooks, since a client may settle without
+// verifying first; rules that must hold for settlement need a settle hook too.
+type SettleHook func(ctx context.Context, request *types.SettleRequest) (*types.SettleResponse, error)
+
+// HookOption tunes a registered hook
+type HookOption func(*hookOptions)
+
+type hookOptions struct {
+	timeout time.Duration
+}
+
+// WithHookTimeout fails the hook if it has not returned after timeout
+// (default: DefaultHookTimeout)
+func WithHookTimeout(timeout time.Duration) HookOption {
+	return func(o *hookOptions) {
+		o.timeout = timeout
+	}
+}
+
+type verifyHook struct {
+	hook    VerifyHook
+	timeout time.Duration
+}
+
+type settleHook struct {
+	hook    SettleHook
+	timeout time.Duration
+}
+
+func newHookOptions(opts []HookOption) hookOptions {
+	options := hookOptions{timeout: DefaultHookTimeout}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// RegisterVerifyHook runs hook after every successful provider verification;
+// hooks run in registration order until one refuses the payment. Register
+// hooks before serving requests.
+func (f *LocalFacilitator) RegisterVerifyHook(hook VerifyHook, opts ...HookOption) {
+	f.verifyHooks = append(f.verifyHooks, verifyHook{hook: hook, timeout: newHookOptions(opts).timeout})
+}
+
+// RegisterSettleHook runs hook before every settlement; hooks run in
+// registration order until one vetoes it. Register hooks before serving
+// requests.
+func (f *LocalFacilitator) RegisterSettleHook(hook SettleHook, opts ...HookOption) {
+	f.settleHooks = append(f.settleHooks, settleHook{hook: hook, timeout: newHookOptions(opts).timeout})
+}
+
+// runVerifyHooks passes a valid response through the verify hooks
+func (f *LocalFacilitator) runVerifyHooks(ctx context.Context, request *types.VerifyRequest, response *types.VerifyResponse) (*types.VerifyResponse, error) {
+	for i, h := range f.verifyHooks {
+		if response == nil || !response.IsValid {
+			break
+		}
+		next, err := runHook(ctx, h.timeout, func(ctx context.Context) (*types.VerifyResponse, error) {
+			return h.hook(ctx, request, response)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("verify hook %d: %w", i, err)
+		}
+		// A hook returning nothing leaves the result as it was
+		if next != nil {
+			response = next
+		}
+	}
+	return response, nil
+}
+
+// runSettleHooks returns the first veto of the settle hooks, nil if none objects
+func (f *LocalFacilitator) runSettleHooks(ctx context.Context, request *types.SettleRequest) (*types.SettleResponse, error) {
+	for i, h := range f.settleHooks {
+		veto, err := runHook(ctx, h.timeout, func(ctx context.Context) (*types.SettleResponse, error) {
+			return h.hook(ctx, request)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("settle hook %d: %w", i, err)
+		}
+		if veto != nil {
+			return veto, nil
+		}
+	}
+	return nil, nil
+}
+
+// runHook calls hook with a context ending after timeout, turning a panic or
+// an overrun into an error; a hook that ignores its context is abandoned,
+// not stopped
+func runHook[T any](ctx context.Context, timeout time.Duration, hook func(context.Context) (T, error)) (T, error) {
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				var zero T
+				done <- result{zero, fmt.Errorf("panic: %v\n%s", r, debug.Stack())}
+			}
+		}()
+		value, err := hook(hookCtx)
+		done <- result{value, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-hookCtx.Done():
+		var zero T
+		return zero, fmt.Errorf("no answer within %s: %w", timeout, hookCtx.Err())
+	}
+}
+
+// PayerDenylist refuses payments from a fixed set of payer addresses, e.g.
+// from a sanctions list exported by compliance (PAYER_DENYLIST)
+type PayerDenylist struct {
+	payers map[common.Address]bool
+}
+
+// NewPayerDenylist denies the given payer addresses (0x or xdc prefix)
+func NewPayerDenylist(payers []string) (*PayerDenylist, error) {
+	d := &PayerDenylist{payers: make(map[common.Address]bool, len(payers))}
+	for _, payer := range payers {
+		payer = types.NormalizeEVMAddress(strings.TrimSpace(payer))
+		if !common.IsHexAddress(payer) {
+			return nil, fmt.Errorf("invalid payer address %q", payer)
+		}
+		d.payers[common.HexToAddress(payer)] = true
+	}
+	return d, nil
+}
+
+// Register installs the denylist as a verify and a settle hook of f
+func (d *PayerDenylist) Register(f *LocalFacilitator) {
+	f.RegisterVerifyHook(d.VerifyHook())
+	f.RegisterSettleHook(d.SettleHook())
+}
+
+// VerifyHook refuses verification of denied payers
+func (d *PayerDenylist) VerifyHook() VerifyHook {
+	return func(ctx context.Context, request *types.VerifyRequest, response *types.VerifyResponse) (*types.VerifyResponse, error) {
+		payer := request.PaymentPayload.Payload.Authorization.From
+		if !d.payers[payer] {
+			return response, nil
+		}
+		refused := types.NewInvalidResponse(fmt.Sprintf("payer %s is not accepted by this facilitator", payer.Hex()), response.Payer)
+		refused.ReasonCode = types.ReasonPayerDenied
+		return &refused, nil
+	}
+}
+
+// SettleHook vetoes settlement for denied payers
+func (d *PayerDenylist) SettleHook() SettleHook {
+	return func(ctx context.Context, request *types.SettleRequest) (*types.SettleResponse, error) {
+		payer := request.PaymentPayload.Payload.Authorization.From
+		if !d.payers[payer] {
+			return nil, nil
+		}
+		return &types.SettleResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("payer %s is not accepted by this facilitator", payer.Hex()),
+			ReasonCode: types.ReasonPayerDenied,
+		}, nil
+	}
+}
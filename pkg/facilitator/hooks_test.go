@@ -0,0 +1,260 @@
+package facilitator_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// hookPayment returns a payment of 1000 from the chain's first account
+func hookPayment(t *testing.T, chain *testchain.Chain) (types.PaymentPayload, types.PaymentRequirements) {
+	t.Helper()
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *payload, requirements
+}
+
+// hookTrace records which hooks ran, in order
+type hookTrace struct {
+	mu  sync.Mutex
+	ran []string
+}
+
+func (h *hookTrace) verify(name string, verdict func(*types.VerifyResponse) *types.VerifyResponse) facilitator.VerifyHook {
+	return func(ctx context.Context, request *types.VerifyRequest, response *types.VerifyResponse) (*types.VerifyResponse, error) {
+		h.mu.Lock()
+		h.ran = append(h.ran, name)
+		h.mu.Unlock()
+		return verdict(response), nil
+	}
+}
+
+func (h *hookTrace) order() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return strings.Join(h.ran, ",")
+}
+
+// accept passes the response on; keep returns nothing, which also accepts
+func accept(response *types.VerifyResponse) *types.VerifyResponse { return response }
+func keep(*types.VerifyResponse) *types.VerifyResponse            { return nil }
+
+// refuse downgrades the response with a code of the hook's own
+func refuse(response *types.VerifyResponse) *types.VerifyResponse {
+	refused := types.NewInvalidResponse("payer account too new", response.Payer)
+	refused.ReasonCode = "account_too_new"
+	return &refused
+}
+
+func TestVerifyHooksRunInRegistrationOrder(t *testing.T) {
+	chain := newTestChain(t)
+	payload, requirements := hookPayment(t, chain)
+	request := &types.VerifyRequest{PaymentPayload: payload, PaymentRequirements: requirements}
+
+	var trace hookTrace
+	fac, err := facilitator.NewBuilder().
+		WithEVMNetwork(testchain.Network, chain.Options()).
+		WithVerifyHook(trace.verify("builder 1", accept)).
+		WithVerifyHook(trace.verify("builder 2", keep)).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fac.RegisterVerifyHook(trace.verify("registered", accept))
+
+	resp, err := fac.Verify(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsValid || trace.order() != "builder 1,builder 2,registered" {
+		t.Errorf("valid %v (%s) after hooks %q, want it valid after every hook in order", resp.IsValid, resp.Reason, trace.order())
+	}
+
+	// A refusal stops the chain and is answered with the hook's code
+	var refusing hookTrace
+	fac, err = chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fac.RegisterVerifyHook(refusing.verify("first", accept))
+	fac.RegisterVerifyHook(refusing.verify("refusing", refuse))
+	fac.RegisterVerifyHook(refusing.verify("after", accept))
+	resp, err = fac.Verify(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.IsValid || resp.ReasonCode != "account_too_new" || resp.Payer == nil || resp.Payer.Address != chain.Accounts[0].Address.Hex() {
+		t.Errorf("refused payment: valid %v, code %q, payer %v", resp.IsValid, resp.ReasonCode, resp.Payer)
+	}
+	if refusing.order() != "first,refusing" {
+		t.Errorf("hooks %q ran, want the chain to stop at the refusal", refusing.order())
+	}
+
+	// Payments the provider refused never reach the hooks
+	var skipped hookTrace
+	fac, err = chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fac.RegisterVerifyHook(skipped.verify("hook", accept))
+	underpaid := *request
+	underpaid.PaymentRequirements.MaxAmountRequired = "1001"
+	if resp, err := fac.Verify(context.Background(), &underpaid); err != nil || resp.ReasonCode != types.ReasonInsufficientValue {
+		t.Fatalf("underpaid: %+v (%v)", resp, err)
+	}
+	if skipped.order() != "" {
+		t.Errorf("hooks %q ran on an invalid payment", skipped.order())
+	}
+}
+
+func TestHookTimeoutsAndPanicsBecomeErrors(t *testing.T) {
+	chain := newTestChain(t)
+	payload, requirements := hookPayment(t, chain)
+	request := &types.VerifyRequest{PaymentPayload: payload, PaymentRequirements: requirements}
+
+	for name, tc := range map[string]struct {
+		hook facilitator.VerifyHook
+		err  string
+	}{
+		"blocks until cancelled": {func(ctx context.Context, _ *types.VerifyRequest, _ *types.VerifyResponse) (*types.VerifyResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}, "verify hook 0"},
+		// Hooks ignoring their context are abandoned at the timeout
+		"ignores its context": {func(context.Context, *types.VerifyRequest, *types.VerifyResponse) (*types.VerifyResponse, error) {
+			time.Sleep(time.Second)
+			return nil, nil
+		}, "no answer within 50ms"},
+		"panics": {func(context.Context, *types.VerifyRequest, *types.VerifyResponse) (*types.VerifyResponse, error) {
+			panic("screening list not loaded")
+		}, "panic: screening list not loaded"},
+	} {
+		fac, err := chain.Facilitator()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fac.RegisterVerifyHook(tc.hook, facilitator.WithHookTimeout(50*time.Millisecond))
+		start := time.Now()
+		resp, err := fac.Verify(context.Background(), request)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: response %+v, error %v; want an error mentioning %q", name, resp, err, tc.err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("%s: verify took %v with a 50ms hook timeout", name, elapsed)
+		}
+	}
+
+	// Settle hooks are isolated the same way, and nothing is submitted
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fac.RegisterSettleHook(func(context.Context, *types.SettleRequest) (*types.SettleResponse, error) {
+		panic("denylist not loaded")
+	})
+	before, err := chain.BalanceOf(chain.Accounts[0].Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fac.Settle(context.Background(), &types.SettleRequest{PaymentPayload: payload, PaymentRequirements: requirements}); err == nil || !strings.Contains(err.Error(), "settle hook 0: panic") {
+		t.Errorf("panicking settle hook: error %v", err)
+	}
+	if after, _ := chain.BalanceOf(chain.Accounts[0].Address); after.Cmp(before) != 0 {
+		t.Errorf("payer balance went from %s to %s despite the failed hook", before, after)
+	}
+}
+
+func TestSettleHooksVetoBeforeSubmitting(t *testing.T) {
+	chain := newTestChain(t)
+	payload, requirements := hookPayment(t, chain)
+	request := &types.SettleRequest{PaymentPayload: payload, PaymentRequirements: requirements}
+
+	var ran []string
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fac.RegisterSettleHook(func(context.Context, *types.SettleRequest) (*types.SettleResponse, error) {
+		ran = append(ran, "allow")
+		return nil, nil
+	})
+	fac.RegisterSettleHook(func(context.Context, *types.SettleRequest) (*types.SettleResponse, error) {
+		ran = append(ran, "veto")
+		return &types.SettleResponse{Error: "merchant suspended", ReasonCode: "merchant_suspended"}, nil
+	})
+	fac.RegisterSettleHook(func(context.Context, *types.SettleRequest) (*types.SettleResponse, error) {
+		ran = append(ran, "after")
+		return nil, nil
+	})
+
+	resp, err := fac.Settle(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Success || resp.ReasonCode != "merchant_suspended" || strings.Join(ran, ",") != "allow,veto" {
+		t.Errorf("vetoed settlement: success %v, code %q, hooks %v", resp.Success, resp.ReasonCode, ran)
+	}
+	if used, err := chain.AuthorizationUsed(chain.Accounts[0].Address, types.Nonce(payload.Payload.Authorization.Nonce)); err != nil || used {
+		t.Errorf("vetoed authorization used on-chain: %v (%v)", used, err)
+	}
+}
+
+func TestPayerDenylist(t *testing.T) {
+	chain := newTestChain(t)
+	payload, requirements := hookPayment(t, chain)
+
+	if _, err := facilitator.NewPayerDenylist([]string{"0x00000000000000000000000000000000000000a1", "not-an-address"}); err == nil {
+		t.Error("denylist accepted an invalid address")
+	}
+	// Entries may carry the xdc prefix or surrounding spaces
+	denied := strings.Replace(strings.ToLower(chain.Accounts[0].Address.Hex()), "0x", "xdc", 1)
+	denylist, err := facilitator.NewPayerDenylist([]string{" " + denied + " "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	denylist.Register(fac)
+
+	resp, err := fac.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.IsValid || resp.ReasonCode != types.ReasonPayerDenied {
+		t.Errorf("denied payer verified: valid %v, code %q", resp.IsValid, resp.ReasonCode)
+	}
+	settled, err := fac.Settle(context.Background(), &types.SettleRequest{PaymentPayload: payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settled.Success || settled.ReasonCode != types.ReasonPayerDenied {
+		t.Errorf("denied payer settled: success %v, code %q", settled.Success, settled.ReasonCode)
+	}
+
+	// Other payers are untouched
+	other, err := facilitator.NewPayerDenylist([]string{"0x00000000000000000000000000000000000000a1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fac, err = chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Register(fac)
+	if resp, err := fac.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: payload, PaymentRequirements: requirements}); err != nil || !resp.IsValid {
+		t.Errorf("payer off the denylist: %+v (%v)", resp, err)
+	}
+}
@@ -3,7 +3,13 @@ package facilitator
 import (
 	"context"
 	"fmt"
+	"log"
+	"math/big"
+	"sync/atomic"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/accounting"
 	"github.com/x402-rs/x402-go/pkg/chain/evm"
 	"github.com/x402-rs/x402-go/pkg/network"
 	"github.com/x402-rs/x402-go/pkg/types"
@@ -16,12 +22,63 @@ import (
 type LocalFacilitator struct {
 	evmProviders map[types.Network]*evm.Provider
 	// solanaProviders map[types.Network]*solana.Provider
+
+	// Solana networks listed in /supported ahead of Solana settlement
+	solanaNetworks map[types.Network]bool
+
+	// Surcharge required per network on top of the resource price
+	feePolicies map[types.Network]types.FeePolicy
+
+	// Gas spend per payTo for billing back subsidized settlements (optional)
+	gasLedger *accounting.GasLedger
+
+	// Daily cap on the gas sponsored per payTo (optional)
+	gasBudget *GasBudget
+
+	// Key signing settlement receipts (optional; receipts are unsigned without it)
+	receiptSigner types.HashSigner
+
+	// Echo XDC addresses in /supported and receipts with the xdc prefix
+	xdcAddressPrefix bool
+
+	// Networks disabled at runtime (see DisableNetwork)
+	networks *networkSwitch
+
+	// Record of every verify and settle decision (no-op by default)
+	auditLogger AuditLogger
+
+	// Settlement journal and the reconciler resolving its in-flight entries (optional)
+	journal    *accounting.SettlementJournal
+	reconciler *Reconciler
+
+	// Delivery of settlement lifecycle events (optional; see SetEventPublisher)
+	events *EventQueue
+
+	// Requests seen per x402 wire version (index is the version number)
+	versionCounts [3]atomic.Uint64
+
+	// Identical verify requests in flight share one provider call
+	verifies verifyCoalescer
+
+	// Deployment-specific rules run after verification and before
+	// settlement, in registration order
+	verifyHooks []verifyHook
+	settleHooks []settleHook
+
+	// Policies of the tenants sharing the facilitator, by ID, and the
+	// signers they keep to themselves (see SetTenants)
+	tenants         map[string]*tenant
+	reservedSigners map[common.Address]bool
 }
 
 // NewLocalFacilitator creates a new LocalFacilitator instance.
 func NewLocalFacilitator() *LocalFacilitator {
 	return &LocalFacilitator{
-		evmProviders: make(map[types.Network]*evm.Provider),
+		evmProviders:   make(map[types.Network]*evm.Provider),
+		solanaNetworks: make(map[types.Network]bool),
+		feePolicies:    make(map[types.Network]types.FeePolicy),
+		networks:       newNetworkSwitch(),
+		auditLogger:    NopAuditLogger{},
 		// solanaProviders: make(map[types.Network]*solana.Provider),
 	}
 }
@@ -31,29 +88,217 @@ func (f *LocalFacilitator) AddEVMProvider(network types.Network, provider *evm.P
 	f.evmProviders[network] = provider
 }
 
+// SetGasLedger records the gas of every successful settlement in ledger
+func (f *LocalFacilitator) SetGasLedger(ledger *accounting.GasLedger) {
+	f.gasLedger = ledger
+}
+
+// SetGasBudget refuses settlements whose gas would exceed the payTo's daily
+// budget (nil disables budgets)
+func (f *LocalFacilitator) SetGasBudget(budget *GasBudget) {
+	f.gasBudget = budget
+}
+
+// SetReceiptSigner signs the receipt of every successful settlement with
+// signer, so clients can check receipts came from this facilitator
+// Any evm.Signer works, including KMS and remote signers
+func (f *LocalFacilitator) SetReceiptSigner(signer types.HashSigner) {
+	f.receiptSigner = signer
+}
+
+// SetXDCAddressPrefix writes addresses on the xdc network with the xdc prefix
+// (instead of 0x) in /supported and settlement receipts; either prefix is
+// accepted on input
+func (f *LocalFacilitator) SetXDCAddressPrefix(enabled bool) {
+	f.xdcAddressPrefix = enabled
+}
+
+// formatAddress writes addr in the operator-preferred form for network
+func (f *LocalFacilitator) formatAddress(network types.Network, addr string) string {
+	if f.xdcAddressPrefix && network == types.NetworkXDC {
+		return types.FormatXDCAddress(addr)
+	}
+	return addr
+}
+
+// SetJournal sets the settlement journal reconciled by StartReconciler
+// Providers record into it through evm.WithJournal (Builder.WithJournal does both)
+func (f *LocalFacilitator) SetJournal(journal *accounting.SettlementJournal) {
+	f.journal = journal
+}
+
+// Journal returns the settlement journal, or nil if none is set
+func (f *LocalFacilitator) Journal() *accounting.SettlementJournal {
+	return f.journal
+}
+
+// StartReconciler starts resolving in-flight journal entries against the
+// EVM providers, now and every interval; a no-op without a journal
+func (f *LocalFacilitator) StartReconciler(interval time.Duration) *Reconciler {
+	if f.journal == nil {
+		return nil
+	}
+	targets := make(map[types.Network]ReconcileTarget, len(f.evmProviders))
+	for net, provider := range f.evmProviders {
+		targets[net] = provider
+	}
+	f.reconciler = NewReconciler(f.journal, targets, nil)
+	if f.events != nil {
+		f.reconciler.onResolve = f.observeResolved
+	}
+	f.reconciler.Start(interval)
+	return f.reconciler
+}
+
+// StartBalanceMonitors starts checking signer gas balances, now and every
+// interval, on each EVM network with a balance threshold (see
+// evm.WithMinSignerBalance); stop the returned monitors on shutdown
+func (f *LocalFacilitator) StartBalanceMonitors(interval time.Duration) []*evm.BalanceMonitor {
+	var monitors []*evm.BalanceMonitor
+	for _, provider := range f.evmProviders {
+		if provider.MinSignerBalance() != nil {
+			monitors = append(monitors, provider.StartBalanceMonitor(interval))
+		}
+	}
+	return monitors
+}
+
+// StartReorgWatchers starts rechecking recent settlements for reorgs every
+// interval on each EVM network that watches them (see evm.WithReorgWatch);
+// stop the returned watchers on shutdown
+func (f *LocalFacilitator) StartReorgWatchers(interval time.Duration) []*evm.ReorgWatcher {
+	var watchers []*evm.ReorgWatcher
+	for _, provider := range f.evmProviders {
+		if provider.ReorgWindow() > 0 {
+			watchers = append(watchers, provider.StartReorgWatcher(interval))
+		}
+	}
+	return watchers
+}
+
+// NetworkHealth reports, per EVM network, whether its signers can pay for
+// gas and its RPC circuit breaker is closed; networks without a balance
+// threshold or breaker are always healthy
+func (f *LocalFacilitator) NetworkHealth() map[types.Network]bool {
+	health := make(map[types.Network]bool, len(f.evmProviders))
+	for net, provider := range f.evmProviders {
+		health[net] = !provider.Degraded() && !provider.RPCDegraded()
+	}
+	return health
+}
+
+// RPCCircuits returns the RPC circuit breaker state per EVM network that
+// has one (see evm.WithRPCCircuitBreaker)
+func (f *LocalFacilitator) RPCCircuits() map[types.Network]string {
+	circuits := make(map[types.Network]string)
+	for net, provider := range f.evmProviders {
+		if health := provider.RPCHealth(); health != nil {
+			circuits[net] = string(health.State)
+		}
+	}
+	return circuits
+}
+
+// AuthorizationState reports whether nonce of from was used or cancelled on
+// asset (the network's USDC if empty) on an EVM network, and the block the
+// state was read at
+func (f *LocalFacilitator) AuthorizationState(ctx context.Context, net types.Network, asset string, from common.Address, nonce types.Nonce) (bool, uint64, error) {
+	provider, ok := f.evmProviders[net]
+	if !ok || !f.networkEnabled(net) {
+		return false, 0, types.NewUnsupportedNetworkError(nil)
+	}
+	var token common.Address
+	if asset == "" {
+		deployment, err := network.GetUSDCDeployment(net)
+		if err != nil {
+			return false, 0, types.NewUnsupportedAssetError("USDC")
+		}
+		token = deployment.TokenAddress
+	} else if common.IsHexAddress(asset) {
+		token = common.HexToAddress(asset)
+	} else {
+		return false, 0, types.NewUnsupportedAssetError(asset)
+	}
+	return provider.AuthorizationState(ctx, token, from, nonce)
+}
+
+// GasLedger returns the gas ledger, or nil if gas accounting is disabled
+func (f *LocalFacilitator) GasLedger() *accounting.GasLedger {
+	return f.gasLedger
+}
+
 // // AddSolanaProvider registers a Solana provider for a network.
 // func (f *LocalFacilitator) AddSolanaProvider(network types.Network, provider *solana.Provider) {
 // 	// f.solanaProviders[network] = provider
+// 	f.solanaNetworks[network] = true
 // }
 
+// AddSolanaNetwork lists a Solana network and its USDC mint in /supported so
+// clients can prepare; until Solana settlement lands, verify and settle on it
+// still report an unsupported network
+func (f *LocalFacilitator) AddSolanaNetwork(net types.Network) error {
+	if _, err := network.GetSolanaTokenDeployment(net); err != nil {
+		return err
+	}
+	f.solanaNetworks[net] = true
+	return nil
+}
+
 // Verify implements Facilitator.Verify
 func (f *LocalFacilitator) Verify(ctx context.Context, request *types.VerifyRequest) (*types.VerifyResponse, error) {
+	resp, err := f.verify(ctx, request)
+	f.auditVerify(ctx, request, resp, err)
+	return resp, err
+}
+
+func (f *LocalFacilitator) verify(ctx context.Context, request *types.VerifyRequest) (*types.VerifyResponse, error) {
 	// Basic validation
 	if err := f.validateRequest(&request.PaymentPayload, &request.PaymentRequirements); err != nil {
 		return nil, err
 	}
 
+	// The tenant's policy, before anything reaches the chain
+	tenant, violation := f.checkTenant(ctx, &request.PaymentPayload, &request.PaymentRequirements)
+	if violation != nil {
+		response := types.NewInvalidResponseFromError(violation)
+		return &response, nil
+	}
+
 	network := request.PaymentPayload.Network
 
+	// Operator switched the network off (e.g. during an RPC incident)
+	if !f.networkEnabled(network) {
+		payer := types.PayerFromPayload(&request.PaymentPayload)
+		response := types.NewInvalidResponse(fmt.Sprintf("network %s temporarily disabled", network), payer)
+		response.ReasonCode = types.ReasonNetworkDisabled
+		return &response, nil
+	}
+
+	// Enforce facilitator fee
+	if reason := f.checkFee(&request.PaymentPayload, &request.PaymentRequirements); reason != "" {
+		payer := types.PayerFromPayload(&request.PaymentPayload)
+		response := types.NewInvalidResponse(reason, payer)
+		response.ReasonCode = types.ReasonFeeNotCovered
+		return &response, nil
+	}
+
 	// Route to appropriate chain handler
 	if network.IsEVM() {
 		provider, ok := f.evmProviders[network]
 		if !ok {
-			err := types.NewUnsupportedNetworkError(nil)
-			response := types.NewInvalidResponse(err.Message, nil)
+			payer := types.PayerFromPayload(&request.PaymentPayload)
+			response := types.NewInvalidResponseFromError(types.NewUnsupportedNetworkError(payer))
 			return &response, nil
 		}
-		return provider.Verify(ctx, request)
+		resp, err := f.verifies.verify(ctx, request, provider.Verify)
+		if err == nil && resp.IsValid {
+			resp, err = f.runVerifyHooks(ctx, request, resp)
+		}
+		if err == nil && resp.IsValid {
+			f.networks.recordVerified(&request.PaymentPayload)
+			tenant.recordVerified()
+		}
+		return resp, err
 	}
 
 	// if network.IsSolana() {
@@ -66,30 +311,86 @@ func (f *LocalFacilitator) Verify(ctx context.Context, request *types.VerifyRequ
 	// 	return provider.Verify(ctx, request)
 	// }
 
-	err := types.NewUnsupportedNetworkError(nil)
-	response := types.NewInvalidResponse(err.Message, nil)
+	payer := types.PayerFromPayload(&request.PaymentPayload)
+	response := types.NewInvalidResponseFromError(types.NewUnsupportedNetworkError(payer))
 	return &response, nil
 }
 
 // Settle implements Facilitator.Settle
 func (f *LocalFacilitator) Settle(ctx context.Context, request *types.SettleRequest) (*types.SettleResponse, error) {
+	resp, err := f.settle(ctx, request)
+	f.auditSettle(ctx, request, resp, err)
+	return resp, err
+}
+
+func (f *LocalFacilitator) settle(ctx context.Context, request *types.SettleRequest) (*types.SettleResponse, error) {
 	// Basic validation
 	if err := f.validateRequest(&request.PaymentPayload, &request.PaymentRequirements); err != nil {
 		return nil, err
 	}
 
+	// The tenant's policy, before anything reaches the chain
+	tenant, violation := f.checkTenant(ctx, &request.PaymentPayload, &request.PaymentRequirements)
+	if violation != nil {
+		return &types.SettleResponse{
+			Success:    false,
+			Error:      violation.Message,
+			ReasonCode: violation.Code,
+		}, nil
+	}
+
 	network := request.PaymentPayload.Network
 
+	// A disabled network only settles payloads verified before the disable
+	if !f.networks.allowSettle(network, &request.PaymentPayload) {
+		return &types.SettleResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("network %s temporarily disabled", network),
+			ReasonCode: types.ReasonNetworkDisabled,
+		}, nil
+	}
+
+	// Enforce facilitator fee
+	if reason := f.checkFee(&request.PaymentPayload, &request.PaymentRequirements); reason != "" {
+		return &types.SettleResponse{
+			Success:    false,
+			Error:      reason,
+			ReasonCode: types.ReasonFeeNotCovered,
+		}, nil
+	}
+
+	// Deployment-specific vetoes (see RegisterSettleHook)
+	if veto, err := f.runSettleHooks(ctx, request); veto != nil || err != nil {
+		return veto, err
+	}
+
 	// Route to appropriate chain handler
 	if network.IsEVM() {
 		provider, ok := f.evmProviders[network]
 		if !ok {
 			return &types.SettleResponse{
-				Success: false,
-				Error:   "network not supported",
+				Success:    false,
+				Error:      "network not supported",
+				ReasonCode: types.ReasonUnsupportedNetwork,
 			}, nil
 		}
-		return provider.Settle(ctx, request)
+		reservation, refusal, err := f.reserveGas(ctx, provider, request)
+		if refusal != nil || err != nil {
+			return refusal, err
+		}
+		resp, err := provider.Settle(f.settleContext(ctx, tenant, provider), request)
+		if err == nil && resp.Success {
+			tenant.recordSettled()
+			value, _ := new(big.Int).SetString(request.PaymentPayload.Payload.Authorization.Value, 10)
+			log.Printf("facilitator.Settle: network=%s tx=%s amount=%q facilitator_fee=%s",
+				network, resp.TransactionHash.Hash, provider.FormatAmount(ctx, request.PaymentRequirements.Asset, value),
+				f.feeComponent(&request.PaymentRequirements))
+			f.recordGas(ctx, network, &request.PaymentRequirements, resp, reservation)
+			f.attachReceipt(ctx, &request.PaymentPayload, resp)
+		} else {
+			reservation.release()
+		}
+		return resp, err
 	}
 
 	// if network.IsSolana() {
@@ -104,63 +405,418 @@ func (f *LocalFacilitator) Settle(ctx context.Context, request *types.SettleRequ
 	// }
 
 	return &types.SettleResponse{
-		Success: false,
-		Error:   "network not supported",
+		Success:    false,
+		Error:      "network not supported",
+		ReasonCode: types.ReasonUnsupportedNetwork,
+	}, nil
+}
+
+// attachReceipt adds the (signed, if configured) receipt to a successful settlement
+func (f *LocalFacilitator) attachReceipt(ctx context.Context, payload *types.PaymentPayload, resp *types.SettleResponse) {
+	var txHash string
+	if resp.TransactionHash != nil {
+		txHash = resp.TransactionHash.Hash
+	}
+	resp.Receipt = types.NewPaymentReceipt(payload, txHash)
+	resp.Receipt.Payer = f.formatAddress(payload.Network, resp.Receipt.Payer)
+	resp.Receipt.PayTo = f.formatAddress(payload.Network, resp.Receipt.PayTo)
+	resp.Receipt.SettlementID = types.SettlementIDFromContext(ctx)
+	if f.receiptSigner == nil {
+		return
+	}
+	signature, err := resp.Receipt.Sign(ctx, f.receiptSigner)
+	if err != nil {
+		log.Printf("facilitator.Settle: failed to sign receipt: %v", err)
+		return
+	}
+	resp.ReceiptSignature = signature
+}
+
+// reserveGas holds the worst-case fee of settling request against the
+// payTo's gas budget, returning the response refusing it if the budget is
+// exhausted; a nil reservation means the payTo has no budget
+func (f *LocalFacilitator) reserveGas(ctx context.Context, provider *evm.Provider, request *types.SettleRequest) (*gasReservation, *types.SettleResponse, error) {
+	// Verify-only providers refuse to settle anyway
+	if f.gasBudget == nil || provider.VerifyOnly() {
+		return nil, nil, nil
+	}
+	fee, err := provider.EstimateSettlementFee(ctx, request)
+	if err != nil {
+		if facErr, ok := err.(*types.FacilitatorError); ok {
+			return nil, nil, facErr
+		}
+		return nil, &types.SettleResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("estimating settlement gas: %v", err),
+			ReasonCode: types.ReasonRPCError,
+		}, nil
+	}
+	payTo := request.PaymentRequirements.PayTo
+	reservation, remaining, ok := f.gasBudget.reserve(payTo, request.PaymentPayload.Network, fee)
+	if !ok {
+		return nil, &types.SettleResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("daily gas budget of %s exhausted (settlement needs up to %s wei, %s wei left)", payTo, fee, remaining),
+			ReasonCode: types.ReasonGasBudgetExceeded,
+		}, nil
+	}
+	return reservation, nil, nil
+}
+
+// reserveCancelGas holds the worst-case fee of request against the gas
+// budget of its authorizer (a cancellation pays no one), returning the
+// response refusing it if the budget is exhausted
+func (f *LocalFacilitator) reserveCancelGas(ctx context.Context, provider *evm.Provider, request *types.CancelRequest) (*gasReservation, *types.CancelResponse, error) {
+	if f.gasBudget == nil || provider.VerifyOnly() {
+		return nil, nil, nil
+	}
+	fee, err := provider.EstimateCancelFee(ctx)
+	if err != nil {
+		if facErr, ok := err.(*types.FacilitatorError); ok {
+			return nil, nil, facErr
+		}
+		return nil, &types.CancelResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("estimating cancellation gas: %v", err),
+			ReasonCode: types.ReasonRPCError,
+		}, nil
+	}
+	authorizer := request.Authorization.Authorizer.Hex()
+	reservation, remaining, ok := f.gasBudget.reserve(authorizer, request.Network, fee)
+	if !ok {
+		return nil, &types.CancelResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("daily gas budget of %s exhausted (cancellation needs up to %s wei, %s wei left)", authorizer, fee, remaining),
+			ReasonCode: types.ReasonGasBudgetExceeded,
+		}, nil
+	}
+	return reservation, nil, nil
+}
+
+// recordGas adds a successful settlement's gas spend to the ledger and
+// charges it to the payTo's gas budget in place of its reservation
+func (f *LocalFacilitator) recordGas(ctx context.Context, network types.Network, requirements *types.PaymentRequirements, resp *types.SettleResponse, reservation *gasReservation) {
+	gasPrice, _ := new(big.Int).SetString(resp.EffectiveGasPrice, 10)
+	record := accounting.GasRecord{
+		PayTo:             requirements.PayTo,
+		Network:           network,
+		TransactionHash:   resp.TransactionHash.Hash,
+		GasUsed:           resp.GasUsed,
+		EffectiveGasPrice: gasPrice,
+	}
+	reservation.commit(record.Fee())
+	if f.gasLedger != nil {
+		f.gasLedger.Record(ctx, record)
+	}
+}
+
+// Simulate implements Facilitator.Simulate
+func (f *LocalFacilitator) Simulate(ctx context.Context, request *types.SettleRequest) (*types.SimulateResponse, error) {
+	// Basic validation
+	if err := f.validateRequest(&request.PaymentPayload, &request.PaymentRequirements); err != nil {
+		return nil, err
+	}
+
+	tenant, violation := f.checkTenant(ctx, &request.PaymentPayload, &request.PaymentRequirements)
+	if violation != nil {
+		return &types.SimulateResponse{
+			Valid:      false,
+			Reason:     violation.Message,
+			ReasonCode: violation.Code,
+			Payer:      violation.Payer,
+		}, nil
+	}
+
+	network := request.PaymentPayload.Network
+
+	// Settle would refuse it (see allowSettle)
+	if !f.networks.allowSettle(network, &request.PaymentPayload) {
+		return &types.SimulateResponse{
+			Valid:      false,
+			Reason:     fmt.Sprintf("network %s temporarily disabled", network),
+			ReasonCode: types.ReasonNetworkDisabled,
+			Payer:      types.PayerFromPayload(&request.PaymentPayload),
+		}, nil
+	}
+
+	// Route to appropriate chain handler
+	if network.IsEVM() {
+		provider, ok := f.evmProviders[network]
+		if !ok {
+			return &types.SimulateResponse{
+				Valid:      false,
+				Reason:     "network not supported",
+				ReasonCode: types.ReasonUnsupportedNetwork,
+			}, nil
+		}
+		return provider.Simulate(f.settleContext(ctx, tenant, provider), request)
+	}
+
+	return &types.SimulateResponse{
+		Valid:      false,
+		Reason:     "network not supported",
+		ReasonCode: types.ReasonUnsupportedNetwork,
+	}, nil
+}
+
+// Cancel implements Facilitator.Cancel
+func (f *LocalFacilitator) Cancel(ctx context.Context, request *types.CancelRequest) (*types.CancelResponse, error) {
+	if !types.IsSupportedX402Version(request.X402Version) {
+		return nil, types.NewUnsupportedVersionError(request.X402Version)
+	}
+
+	// The tenant's policy, before anything reaches the chain
+	tenant, violation := f.checkTenantCancel(ctx, request)
+	if violation != nil {
+		return &types.CancelResponse{
+			Success:    false,
+			Error:      violation.Message,
+			ReasonCode: violation.Code,
+		}, nil
+	}
+
+	network := request.Network
+
+	// A cancellation is a new transaction, which a disabled network sends none of
+	if !f.networkEnabled(network) {
+		return &types.CancelResponse{
+			Success:    false,
+			Error:      fmt.Sprintf("network %s temporarily disabled", network),
+			ReasonCode: types.ReasonNetworkDisabled,
+		}, nil
+	}
+
+	// Route to appropriate chain handler
+	if network.IsEVM() {
+		provider, ok := f.evmProviders[network]
+		if !ok {
+			return &types.CancelResponse{
+				Success:    false,
+				Error:      "network not supported",
+				ReasonCode: types.ReasonUnsupportedNetwork,
+			}, nil
+		}
+		reservation, refusal, err := f.reserveCancelGas(ctx, provider, request)
+		if refusal != nil || err != nil {
+			return refusal, err
+		}
+		// The tenant's signers pay for its cancellations, as for settlements
+		resp, err := provider.Cancel(f.settleContext(ctx, tenant, provider), request)
+		if err == nil && resp.Success {
+			reservation.commitReserved()
+		} else {
+			reservation.release()
+		}
+		return resp, err
+	}
+
+	return &types.CancelResponse{
+		Success:    false,
+		Error:      "network not supported",
+		ReasonCode: types.ReasonUnsupportedNetwork,
 	}, nil
 }
 
 // Supported implements Facilitator.Supported
 func (f *LocalFacilitator) Supported(ctx context.Context) (*types.SupportedPaymentKindsResponse, error) {
+	return f.SupportedFiltered(ctx, types.SupportedFilter{})
+}
+
+// SupportedFiltered returns the supported payment kinds passing filter;
+// networks the filter rules out are skipped, token metadata reads included
+func (f *LocalFacilitator) SupportedFiltered(ctx context.Context, filter types.SupportedFilter) (*types.SupportedPaymentKindsResponse, error) {
 	kinds := []types.SupportedPaymentKind{}
 
 	// Add EVM networks with USDC
-	for net := range f.evmProviders {
+	for net, provider := range f.evmProviders {
+		if !f.networkEnabled(net) || !filter.MatchesNetwork(net) {
+			continue
+		}
 		deployment, err := network.GetUSDCDeployment(net)
 		if err != nil {
 			continue // Skip if no USDC deployment
 		}
 
+		kind := types.SupportedPaymentKind{
+			Version:      types.X402VersionV1,
+			Scheme:       types.SchemeExact,
+			Network:      net,
+			Token:        types.MixedAddress{Type: "evm", Address: f.formatAddress(net, deployment.TokenAddress.Hex())},
+			TokenSymbol:  deployment.TokenSymbol,
+			Decimals:     deployment.Decimals,
+			X402Versions: types.SupportedX402Versions,
+			Settlement:   !f.evmProviders[net].VerifyOnly(),
+		}
+		if policy, ok := f.feePolicies[net]; ok {
+			kind.Fee = policy.Advertise()
+		}
+		if provider := f.evmProviders[net]; provider.MinSignerBalance() != nil {
+			healthy := !provider.Degraded()
+			kind.Healthy = &healthy
+		}
+		kinds = append(kinds, kind)
+
+		// Other whitelisted tokens, with the metadata they report on-chain
+		// (left out if they cannot be read)
+		for _, asset := range provider.WhitelistedAssets() {
+			if asset == deployment.TokenAddress {
+				continue
+			}
+			other := kind
+			other.Token = types.MixedAddress{Type: "evm", Address: f.formatAddress(net, asset.Hex())}
+			other.TokenSymbol, other.Decimals = "", 0
+			if info, err := provider.TokenInfo(ctx, asset); err == nil {
+				other.TokenSymbol, other.Decimals = info.Symbol, info.Decimals
+			}
+			kinds = append(kinds, other)
+		}
+	}
+
+	// Add permit2 on EVM networks accepting it: any ERC-20 approved to
+	// Permit2, signed for the spender named in extra
+	for net, provider := range f.evmProviders {
+		if !f.networkEnabled(net) || !filter.MatchesNetwork(net) || !provider.Permit2() || provider.VerifyOnly() {
+			continue
+		}
+		extra, err := types.MergeExtra(nil, types.RequirementsExtra{Permit2: &types.Permit2Extra{Spender: provider.Permit2Spender()}})
+		if err != nil {
+			continue
+		}
 		kinds = append(kinds, types.SupportedPaymentKind{
-			Version:     types.X402VersionV1,
-			Scheme:      types.SchemeExact,
-			Network:     net,
-			Token:       types.NewEvmAddress(deployment.TokenAddress),
-			TokenSymbol: deployment.TokenSymbol,
+			Version:      types.X402VersionV1,
+			Scheme:       types.SchemePermit2,
+			Network:      net,
+			Token:        types.MixedAddress{Type: "evm"},
+			X402Versions: types.SupportedX402Versions,
+			Settlement:   true,
+			Extra:        extra,
 		})
 	}
 
-	// // Add Solana networks
-	// for net := range f.solanaProviders {
-	// 	// TODO: Add Solana USDC mint addresses
-	// 	kinds = append(kinds, types.SupportedPaymentKind{
-	// 		Version:     types.X402VersionV1,
-	// 		Scheme:      types.SchemeExact,
-	// 		Network:     net,
-	// 		TokenSymbol: "USDC",
-	// 	})
-	// }
+	// Add Solana networks with their USDC mints
+	for net := range f.solanaNetworks {
+		if !f.networkEnabled(net) || !filter.MatchesNetwork(net) {
+			continue
+		}
+		deployment, err := network.GetSolanaTokenDeployment(net)
+		if err != nil {
+			continue
+		}
+		kinds = append(kinds, types.SupportedPaymentKind{
+			Version:      types.X402VersionV1,
+			Scheme:       types.SchemeExact,
+			Network:      net,
+			Token:        types.MixedAddress{Type: "solana", Address: deployment.Mint},
+			TokenSymbol:  deployment.TokenSymbol,
+			Decimals:     deployment.Decimals,
+			X402Versions: types.SupportedX402Versions,
+		})
+	}
 
-	return &types.SupportedPaymentKindsResponse{
+	resp := &types.SupportedPaymentKindsResponse{
 		Kinds: kinds,
-	}, nil
+	}
+	return resp.Filter(filter), nil
 }
 
 // validateRequest performs basic validation on the request
 func (f *LocalFacilitator) validateRequest(payload *types.PaymentPayload, requirements *types.PaymentRequirements) error {
+	if err := validatePayment(payload, requirements); err != nil {
+		return err
+	}
+	f.versionCounts[payload.X402Version].Add(1)
+	return nil
+}
+
+// validatePayment performs the chain-independent checks shared by all facilitators
+func validatePayment(payload *types.PaymentPayload, requirements *types.PaymentRequirements) error {
+	payer := types.PayerFromPayload(payload)
+
 	// Check scheme match
 	if payload.Scheme != requirements.Scheme {
-		return types.NewSchemeMismatchError(requirements.Scheme, payload.Scheme, nil)
+		return types.NewSchemeMismatchError(requirements.Scheme, payload.Scheme, payer)
 	}
 
 	// Check network match
 	if payload.Network != requirements.Network {
-		return types.NewNetworkMismatchError(requirements.Network, payload.Network, nil)
+		return types.NewNetworkMismatchError(requirements.Network, payload.Network, payer)
+	}
+
+	// Check amounts are positive integers within uint256
+	if err := types.ValidateAmounts(payload, requirements); err != nil {
+		return err
 	}
 
 	// Check version
-	if payload.X402Version != 1 {
-		return fmt.Errorf("unsupported version: %d", payload.X402Version)
+	if !types.IsSupportedX402Version(payload.X402Version) {
+		err := types.NewUnsupportedVersionError(payload.X402Version)
+		err.Payer = payer
+		return err
 	}
 
 	return nil
 }
+
+// Stats returns operational statistics for the admin stats endpoint
+func (f *LocalFacilitator) Stats() map[string]interface{} {
+	signers := make(map[types.Network][]string, len(f.evmProviders))
+	for net, provider := range f.evmProviders {
+		for _, addr := range provider.SignerAddresses() {
+			signers[net] = append(signers[net], addr.Hex())
+		}
+	}
+	stats := map[string]interface{}{
+		"signers":             signers,
+		"requests_by_version": f.VersionStats(),
+		"coalesced_verifies":  f.verifies.coalesced.Load(),
+	}
+	if f.reconciler != nil {
+		stats["reconciliation"] = f.reconciler.Stats()
+	}
+	if f.gasBudget != nil {
+		stats["gasBudgets"] = f.gasBudget.Remaining()
+	}
+	if len(f.tenants) > 0 {
+		stats["tenants"] = f.TenantStats()
+	}
+	if disabled := f.DisabledNetworks(); len(disabled) > 0 {
+		stats["disabledNetworks"] = disabled
+	}
+	balances := make(map[types.Network][]evm.SignerBalance)
+	for net, provider := range f.evmProviders {
+		if provider.MinSignerBalance() != nil {
+			balances[net] = provider.SignerBalances()
+		}
+	}
+	if len(balances) > 0 {
+		stats["signerBalances"] = balances
+	}
+	reorgs := make(map[types.Network]evm.ReorgStats)
+	for net, provider := range f.evmProviders {
+		if provider.ReorgWindow() > 0 {
+			reorgs[net] = provider.ReorgStats()
+		}
+	}
+	if len(reorgs) > 0 {
+		stats["reorgs"] = reorgs
+	}
+	rpcHealth := make(map[types.Network]*evm.RPCHealth)
+	for net, provider := range f.evmProviders {
+		if health := provider.RPCHealth(); health != nil {
+			rpcHealth[net] = health
+		}
+	}
+	if len(rpcHealth) > 0 {
+		stats["rpcHealth"] = rpcHealth
+	}
+	return stats
+}
+
+// VersionStats returns how many requests were seen per x402 wire version
+func (f *LocalFacilitator) VersionStats() map[string]uint64 {
+	stats := make(map[string]uint64, len(types.SupportedX402Versions))
+	for _, v := range types.SupportedX402Versions {
+		stats[fmt.Sprintf("v%d", v)] = f.versionCounts[v].Load()
+	}
+	return stats
+}
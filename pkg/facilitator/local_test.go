@@ -0,0 +1,374 @@
+package facilitator_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestCancelChargesGasBudget(t *testing.T) {
+	chain := newTestChain(t)
+	gasPrice, err := chain.Client().SuggestGasPrice(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := new(big.Int).Mul(gasPrice, big.NewInt(80000)) // cancelGasLimit
+	budget := facilitator.NewGasBudget(fee, nil)         // Room for one cancellation
+	fac, err := facilitator.NewBuilder().
+		WithEVMNetwork(testchain.Network, chain.Options()).
+		WithGasBudget(budget).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorizer := chain.Accounts[0]
+	cancel := func() *types.CancelResponse {
+		t.Helper()
+		request, err := chain.CancelRequest(authorizer, randomNonce(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := fac.Cancel(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := cancel(); !resp.Success {
+		t.Fatalf("first cancel refused: %s (%s)", resp.Error, resp.ReasonCode)
+	}
+	remaining := budget.Remaining()[strings.ToLower(authorizer.Address.Hex())][testchain.Network]
+	if remaining.Spent != fee.String() || remaining.Reserved != "0" {
+		t.Errorf("after one cancel: spent %s, reserved %s; want %s spent and nothing reserved", remaining.Spent, remaining.Reserved, fee)
+	}
+
+	if resp := cancel(); resp.Success || resp.ReasonCode != types.ReasonGasBudgetExceeded {
+		t.Errorf("cancel past the budget: success %v, reason %q; want %q", resp.Success, resp.ReasonCode, types.ReasonGasBudgetExceeded)
+	}
+}
+
+func TestCancelReleasesGasOfRefusedCancellation(t *testing.T) {
+	chain := newTestChain(t)
+	budget := facilitator.NewGasBudget(new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e9)), nil)
+	fac, err := facilitator.NewBuilder().
+		WithEVMNetwork(testchain.Network, chain.Options()).
+		WithGasBudget(budget).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Signed by someone other than the authorizer, so nothing is sent
+	request, err := chain.CancelRequest(chain.Accounts[0], randomNonce(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Authorization.Authorizer = chain.Signer.Address
+	resp, err := fac.Cancel(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Success {
+		t.Fatal("cancel with a forged signature succeeded")
+	}
+	if remaining := budget.Remaining(); len(remaining) != 0 {
+		t.Errorf("refused cancel left gas held against the budget: %+v", remaining)
+	}
+}
+
+func TestSupportedReportsSignerGasHealth(t *testing.T) {
+	chain := newTestChain(t)
+	healthy := func(fac *facilitator.LocalFacilitator) *bool {
+		t.Helper()
+		supported, err := fac.Supported(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, kind := range supported.Kinds {
+			if kind.Network == testchain.Network {
+				return kind.Healthy
+			}
+		}
+		t.Fatal("testchain is not supported")
+		return nil
+	}
+
+	unmonitored, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := healthy(unmonitored); h != nil || len(unmonitored.StartBalanceMonitors(time.Hour)) != 0 {
+		t.Errorf("without a threshold: healthy %v, want it unreported and no monitors", h)
+	}
+
+	// The signer holds 1000 ETH
+	for _, tc := range []struct {
+		threshold *big.Int
+		healthy   bool
+	}{
+		{big.NewInt(1e18), true},
+		{new(big.Int).Mul(big.NewInt(2000), big.NewInt(1e18)), false},
+	} {
+		fac, err := chain.Facilitator(evm.WithMinSignerBalance(tc.threshold, false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		monitors := fac.StartBalanceMonitors(time.Hour)
+		if len(monitors) != 1 {
+			t.Fatalf("%d balance monitors, want one per monitored network", len(monitors))
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for fac.Stats()["signerBalances"].(map[types.Network][]evm.SignerBalance)[testchain.Network][0].Balance == "" && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		monitors[0].Stop()
+
+		if h := healthy(fac); h == nil || *h != tc.healthy {
+			t.Errorf("threshold %s: healthy %v, want %v", tc.threshold, h, tc.healthy)
+		}
+		if got := fac.NetworkHealth()[testchain.Network]; got != tc.healthy {
+			t.Errorf("threshold %s: NetworkHealth %v, want %v", tc.threshold, got, tc.healthy)
+		}
+	}
+}
+
+// downClient is the chain's client with every contract call failing
+type downClient struct{ evm.Client }
+
+func (downClient) CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestOpenRPCCircuitDegradesNetwork(t *testing.T) {
+	chain := newTestChain(t)
+	options := chain.Options()
+	options.Client = downClient{options.Client}
+	fac, err := facilitator.NewBuilder().WithEVMNetwork(testchain.Network, options, evm.WithRPCCircuitBreaker(0.5, time.Hour)).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fac.NetworkHealth()[testchain.Network] || fac.RPCCircuits()[testchain.Network] != string(evm.CircuitClosed) {
+		t.Fatalf("before any failure: health %v, circuits %v", fac.NetworkHealth(), fac.RPCCircuits())
+	}
+
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		fac.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	}
+	if fac.NetworkHealth()[testchain.Network] || fac.RPCCircuits()[testchain.Network] != string(evm.CircuitOpen) {
+		t.Errorf("after failing verifies: health %v, circuits %v; want the network degraded", fac.NetworkHealth(), fac.RPCCircuits())
+	}
+	if health, ok := fac.Stats()["rpcHealth"].(map[types.Network]*evm.RPCHealth); !ok || health[testchain.Network].State != evm.CircuitOpen {
+		t.Errorf("stats rpcHealth %v, want the open circuit", fac.Stats()["rpcHealth"])
+	}
+
+	unguarded, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unguarded.RPCCircuits()) != 0 || unguarded.Stats()["rpcHealth"] != nil {
+		t.Errorf("facilitator without breakers reports circuits %v", unguarded.RPCCircuits())
+	}
+}
+
+func TestSupportedAdvertisesPermit2Spender(t *testing.T) {
+	chain := newTestChain(t)
+	permit2Kinds := func(fac *facilitator.LocalFacilitator) []types.SupportedPaymentKind {
+		t.Helper()
+		supported, err := fac.Supported(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var kinds []types.SupportedPaymentKind
+		for _, kind := range supported.Kinds {
+			if kind.Scheme == types.SchemePermit2 {
+				kinds = append(kinds, kind)
+			}
+		}
+		return kinds
+	}
+
+	exactOnly, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kinds := permit2Kinds(exactOnly); len(kinds) != 0 {
+		t.Errorf("without WithPermit2: advertised %+v", kinds)
+	}
+
+	fac, err := chain.Facilitator(evm.WithPermit2())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := permit2Kinds(fac)
+	if len(kinds) != 1 || kinds[0].Network != testchain.Network || !kinds[0].Settlement {
+		t.Fatalf("permit2 kinds %+v, want one settling on %s", kinds, testchain.Network)
+	}
+	extra, err := types.ParsePermit2Extra(kinds[0].Extra)
+	if err != nil || extra == nil || extra.Spender != chain.Signer.Address {
+		t.Errorf("extra %s parsed as %+v (%v), want the spender %s", kinds[0].Extra, extra, err, chain.Signer.Address.Hex())
+	}
+}
+
+func TestSupportedListsWhitelistedTokens(t *testing.T) {
+	chain := newTestChain(t)
+	// No contract lives here, so its metadata cannot be read
+	unreadable := common.HexToAddress("0x00000000000000000000000000000000000000ee")
+	options := chain.Options()
+	options.AssetWhitelist = []common.Address{testchain.TokenAddress, unreadable}
+	fac, err := facilitator.NewBuilder().WithEVMNetwork(testchain.Network, options).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	supported, err := fac.Supported(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := make(map[string]types.SupportedPaymentKind)
+	for _, kind := range supported.Kinds {
+		if kind.Network == testchain.Network && kind.Scheme == types.SchemeExact {
+			tokens[kind.Token.Address] = kind
+		}
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("kinds for tokens %v, want the registered token and the whitelisted one", tokens)
+	}
+	if kind := tokens[testchain.TokenAddress.Hex()]; kind.Decimals != 6 {
+		t.Errorf("registered token listed with %d decimals", kind.Decimals)
+	}
+	if kind, ok := tokens[unreadable.Hex()]; !ok || kind.TokenSymbol != "" || kind.Decimals != 0 {
+		t.Errorf("unreadable token listed as %+v, want it without metadata", kind)
+	}
+}
+
+func TestSupportedFilteredNarrowsKinds(t *testing.T) {
+	chain := newTestChain(t)
+	unreadable := common.HexToAddress("0x00000000000000000000000000000000000000ee")
+	options := chain.Options()
+	options.AssetWhitelist = []common.Address{testchain.TokenAddress, unreadable}
+	fac, err := facilitator.NewBuilder().WithEVMNetwork(testchain.Network, options).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	yes, no := true, false
+	for _, tc := range []struct {
+		name   string
+		filter types.SupportedFilter
+		want   []string // Token addresses listed
+	}{
+		{"network", types.SupportedFilter{Network: testchain.Network}, []string{testchain.TokenAddress.Hex(), unreadable.Hex()}},
+		{"token symbol", types.SupportedFilter{Network: testchain.Network, TokenSymbol: "usdc"}, []string{testchain.TokenAddress.Hex()}},
+		{"settleable", types.SupportedFilter{Scheme: types.SchemeExact, Settleable: &yes}, []string{testchain.TokenAddress.Hex(), unreadable.Hex()}},
+		{"not settleable", types.SupportedFilter{Settleable: &no}, nil},
+		{"other network", types.SupportedFilter{Network: types.NetworkBase}, nil},
+	} {
+		resp, err := fac.SupportedFiltered(context.Background(), tc.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if resp.Filters == nil || *resp.Filters != tc.filter {
+			t.Errorf("%s: echoed filters %+v, want %+v", tc.name, resp.Filters, tc.filter)
+		}
+		var got []string
+		for _, kind := range resp.Kinds {
+			got = append(got, kind.Token.Address)
+		}
+		if resp.Kinds == nil || fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: listed tokens %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	all, err := fac.Supported(context.Background())
+	if err != nil || all.Filters != nil || len(all.Kinds) != 2 {
+		t.Errorf("unfiltered: %+v (%v), want both tokens without filters", all, err)
+	}
+}
+
+func TestRefusalsReportPayer(t *testing.T) {
+	chain := newTestChain(t)
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	from := chain.Accounts[0].Address
+
+	for _, tc := range []struct {
+		name   string
+		mutate func(*types.VerifyRequest)
+	}{
+		{"scheme mismatch", func(r *types.VerifyRequest) { r.PaymentPayload.Scheme = "upto" }},
+		{"network mismatch", func(r *types.VerifyRequest) { r.PaymentRequirements.Network = types.NetworkBase }},
+		{"invalid amount", func(r *types.VerifyRequest) { r.PaymentRequirements.MaxAmountRequired = "-1" }},
+		{"unsupported version", func(r *types.VerifyRequest) { r.PaymentPayload.X402Version = 99 }},
+		{"unsupported network", func(r *types.VerifyRequest) {
+			r.PaymentPayload.Network, r.PaymentRequirements.Network = types.NetworkBase, types.NetworkBase
+		}},
+		{"asset off the whitelist", func(r *types.VerifyRequest) {
+			r.PaymentRequirements.Asset = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+		}},
+		{"malformed validAfter", func(r *types.VerifyRequest) { r.PaymentPayload.Payload.Authorization.ValidAfter = "soon" }},
+		{"insufficient value", func(r *types.VerifyRequest) { r.PaymentRequirements.MaxAmountRequired = "1001" }},
+		{"bad signature", func(r *types.VerifyRequest) { r.PaymentPayload.Payload.Signature[5] ^= 0xff }},
+	} {
+		requirements := chain.Requirements(payTo, big.NewInt(1000))
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request := &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements}
+		tc.mutate(request)
+
+		var payer *types.MixedAddress
+		resp, err := fac.Verify(context.Background(), request)
+		var facErr *types.FacilitatorError
+		switch {
+		case errors.As(err, &facErr):
+			payer = facErr.Payer
+		case err != nil:
+			t.Fatalf("%s: %v", tc.name, err)
+		case resp.IsValid:
+			t.Fatalf("%s: accepted", tc.name)
+		default:
+			payer = resp.Payer
+		}
+		if payer == nil || payer.Address != from.Hex() {
+			t.Errorf("%s: payer %+v, want %s", tc.name, payer, from.Hex())
+		}
+	}
+
+	// A network switched off still names the payer
+	if err := fac.DisableNetwork(testchain.Network); err != nil {
+		t.Fatal(err)
+	}
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := fac.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReasonCode != types.ReasonNetworkDisabled || resp.Payer == nil || resp.Payer.Address != from.Hex() {
+		t.Errorf("disabled network: reason %q, payer %+v; want %q for %s", resp.ReasonCode, resp.Payer, types.ReasonNetworkDisabled, from.Hex())
+	}
+}
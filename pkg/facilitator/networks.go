@@ -0,0 +1,204 @@
+package facilitator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// verificationRetention is how long a successful verification is remembered
+// for the settle grace window of a disabled network
+const verificationRetention = time.Hour
+
+// maxTrackedVerifications bounds the remembered verifications
+const maxTrackedVerifications = 100000
+
+// networkSwitch tracks networks disabled at runtime and, for the grace
+// window, which payloads were verified before the disable
+type networkSwitch struct {
+	mu        sync.Mutex
+	disabled  map[types.Network]time.Time // Network -> when it was disabled
+	stateFile string                      // Where disabled networks persist ("" keeps them in memory)
+	grace     time.Duration               // Settle window for payloads verified before the disable
+	verified  map[common.Hash]time.Time   // Payload hash -> when it last verified
+}
+
+// networkState is the on-disk form of the disabled networks
+type networkState struct {
+	Disabled map[types.Network]time.Time `json:"disabled"`
+}
+
+func newNetworkSwitch() *networkSwitch {
+	return &networkSwitch{
+		disabled: make(map[types.Network]time.Time),
+		verified: make(map[common.Hash]time.Time),
+	}
+}
+
+// SetNetworkStateFile persists disabled networks in path, loading any
+// networks disabled by a previous run
+func (f *LocalFacilitator) SetNetworkStateFile(path string) error {
+	s := f.networks
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read network state: %w", err)
+	default:
+		var state networkState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("invalid network state file %s: %w", path, err)
+		}
+		for net, at := range state.Disabled {
+			s.disabled[net] = at
+		}
+	}
+	s.stateFile = path
+	return nil
+}
+
+// SetDisableGrace lets payloads verified before a network was disabled
+// still settle for grace after the disable (0, the default, rejects them)
+func (f *LocalFacilitator) SetDisableGrace(grace time.Duration) {
+	s := f.networks
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grace = grace
+}
+
+// DisableNetwork stops accepting new payments on network: it leaves
+// /supported, Verify and Cancel reject it and Settle (and Simulate) only
+// accept payloads verified before the disable, within the grace window
+func (f *LocalFacilitator) DisableNetwork(network types.Network) error {
+	if !f.hasNetwork(network) {
+		return fmt.Errorf("network %s is not configured", network)
+	}
+	s := f.networks
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.disabled[network]; ok {
+		return nil
+	}
+	s.disabled[network] = time.Now()
+	return s.save()
+}
+
+// EnableNetwork resumes accepting payments on a disabled network
+func (f *LocalFacilitator) EnableNetwork(network types.Network) error {
+	if !f.hasNetwork(network) {
+		return fmt.Errorf("network %s is not configured", network)
+	}
+	s := f.networks
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.disabled[network]; !ok {
+		return nil
+	}
+	delete(s.disabled, network)
+	return s.save()
+}
+
+// DisabledNetworks returns the disabled networks and when each was disabled
+func (f *LocalFacilitator) DisabledNetworks() map[types.Network]time.Time {
+	s := f.networks
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[types.Network]time.Time, len(s.disabled))
+	for net, at := range s.disabled {
+		out[net] = at
+	}
+	return out
+}
+
+// networkEnabled reports whether network accepts new payments
+func (f *LocalFacilitator) networkEnabled(network types.Network) bool {
+	s := f.networks
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, disabled := s.disabled[network]
+	return !disabled
+}
+
+// hasNetwork reports whether network is served by this facilitator
+func (f *LocalFacilitator) hasNetwork(network types.Network) bool {
+	_, evm := f.evmProviders[network]
+	return evm || f.solanaNetworks[network]
+}
+
+// recordVerified remembers that payload verified, for the grace window;
+// a no-op unless a grace window is set
+func (s *networkSwitch) recordVerified(payload *types.PaymentPayload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.grace <= 0 {
+		return
+	}
+	hash, err := types.HashPayload(payload)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	if len(s.verified) >= maxTrackedVerifications {
+		for h, at := range s.verified {
+			if now.Sub(at) > verificationRetention {
+				delete(s.verified, h)
+			}
+		}
+		// Still full: forget an arbitrary entry rather than grow unbounded
+		for h := range s.verified {
+			if len(s.verified) < maxTrackedVerifications {
+				break
+			}
+			delete(s.verified, h)
+		}
+	}
+	s.verified[hash] = now
+}
+
+// allowSettle reports whether payload may settle on a disabled network:
+// only within the grace window, and only if it verified before the disable
+func (s *networkSwitch) allowSettle(network types.Network, payload *types.PaymentPayload) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	disabledAt, ok := s.disabled[network]
+	if !ok {
+		return true
+	}
+	if s.grace <= 0 || time.Since(disabledAt) > s.grace {
+		return false
+	}
+	hash, err := types.HashPayload(payload)
+	if err != nil {
+		return false
+	}
+	verifiedAt, ok := s.verified[hash]
+	return ok && verifiedAt.Before(disabledAt)
+}
+
+// save writes the disabled networks to the state file (caller holds s.mu)
+func (s *networkSwitch) save() error {
+	if s.stateFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(networkState{Disabled: s.disabled}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.stateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write network state: %w", err)
+	}
+	if err := os.Rename(tmp, s.stateFile); err != nil {
+		return fmt.Errorf("failed to write network state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package facilitator_test
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestDisabledNetworkRefusesSimulateAndCancel(t *testing.T) {
+	chain := newTestChain(t)
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settle := &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements}
+	cancel, err := chain.CancelRequest(chain.Accounts[0], randomNonce(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := fac.DisableNetwork(testchain.Network); err != nil {
+		t.Fatal(err)
+	}
+	simulated, err := fac.Simulate(ctx, settle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if simulated.Valid || simulated.ReasonCode != types.ReasonNetworkDisabled {
+		t.Errorf("simulate on a disabled network: valid %v, reason %q", simulated.Valid, simulated.ReasonCode)
+	}
+	cancelled, err := fac.Cancel(ctx, cancel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Success || cancelled.ReasonCode != types.ReasonNetworkDisabled {
+		t.Errorf("cancel on a disabled network: success %v, reason %q", cancelled.Success, cancelled.ReasonCode)
+	}
+
+	if err := fac.EnableNetwork(testchain.Network); err != nil {
+		t.Fatal(err)
+	}
+	simulated, err = fac.Simulate(ctx, settle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !simulated.Valid {
+		t.Errorf("simulate once re-enabled: %s (%s)", simulated.Reason, simulated.ReasonCode)
+	}
+	cancelled, err = fac.Cancel(ctx, cancel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cancelled.Success {
+		t.Errorf("cancel once re-enabled: %s (%s)", cancelled.Error, cancelled.ReasonCode)
+	}
+}
+
+// networkPayment returns a fresh payment of 1000 on the chain
+func networkPayment(t *testing.T, chain *testchain.Chain) (*types.VerifyRequest, *types.SettleRequest) {
+	t.Helper()
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements},
+		&types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements}
+}
+
+// supportsNetwork reports whether fac lists network in /supported
+func supportsNetwork(t *testing.T, fac interface {
+	Supported(context.Context) (*types.SupportedPaymentKindsResponse, error)
+}, network types.Network) bool {
+	t.Helper()
+	supported, err := fac.Supported(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range supported.Kinds {
+		if kind.Network == network {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDisabledNetworkLeavesSupportedVerifyAndSettle(t *testing.T) {
+	chain := newTestChain(t)
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	verify, settle := networkPayment(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fac.DisableNetwork(testchain.Network); err != nil {
+		t.Fatal(err)
+	}
+	if supportsNetwork(t, fac, testchain.Network) {
+		t.Error("a disabled network is still in /supported")
+	}
+	verified, err := fac.Verify(ctx, verify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.IsValid || verified.ReasonCode != types.ReasonNetworkDisabled || !strings.Contains(verified.Reason, "temporarily disabled") {
+		t.Errorf("verify on a disabled network: valid %v, %s (%s)", verified.IsValid, verified.ReasonCode, verified.Reason)
+	}
+	settled, err := fac.Settle(ctx, settle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settled.Success || settled.ReasonCode != types.ReasonNetworkDisabled {
+		t.Errorf("settle on a disabled network: %+v", settled)
+	}
+	if disabled := fac.DisabledNetworks(); len(disabled) != 1 || disabled[testchain.Network].IsZero() {
+		t.Errorf("DisabledNetworks() = %v", disabled)
+	}
+	if err := fac.DisableNetwork(testchain.Network); err != nil {
+		t.Errorf("disabling twice: %v", err)
+	}
+
+	if err := fac.EnableNetwork(testchain.Network); err != nil {
+		t.Fatal(err)
+	}
+	if !supportsNetwork(t, fac, testchain.Network) {
+		t.Error("a re-enabled network is missing from /supported")
+	}
+	if settled, err := fac.Settle(ctx, settle); err != nil || !settled.Success {
+		t.Errorf("settle once re-enabled: %+v, %v", settled, err)
+	}
+
+	for _, toggle := range []func(types.Network) error{fac.DisableNetwork, fac.EnableNetwork} {
+		if err := toggle(types.NetworkBase); err == nil || !strings.Contains(err.Error(), "not configured") {
+			t.Errorf("toggling an unconfigured network: %v", err)
+		}
+	}
+}
+
+func TestDisableGraceWindow(t *testing.T) {
+	chain := newTestChain(t)
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const grace = 200 * time.Millisecond
+	fac.SetDisableGrace(grace)
+	ctx := context.Background()
+
+	verifiedFirst, settleVerified := networkPayment(t, chain)
+	_, settleUnverified := networkPayment(t, chain)
+	_, settleLate := networkPayment(t, chain)
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := fac.Verify(ctx, verifiedFirst); err != nil || !resp.IsValid {
+		t.Fatalf("verify: %+v, %v", resp, err)
+	}
+	if err := fac.DisableNetwork(testchain.Network); err != nil {
+		t.Fatal(err)
+	}
+
+	// Within the window only the payload verified before the disable settles
+	if resp, err := fac.Settle(ctx, settleUnverified); err != nil || resp.Success || resp.ReasonCode != types.ReasonNetworkDisabled {
+		t.Errorf("unverified payload within the grace window: %+v, %v", resp, err)
+	}
+	if resp, err := fac.Settle(ctx, settleVerified); err != nil || !resp.Success {
+		t.Errorf("payload verified before the disable: %+v, %v", resp, err)
+	}
+
+	// After the window nothing settles
+	verifyLate := &types.VerifyRequest{PaymentPayload: settleLate.PaymentPayload, PaymentRequirements: settleLate.PaymentRequirements}
+	if err := fac.EnableNetwork(testchain.Network); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := fac.Verify(ctx, verifyLate); err != nil || !resp.IsValid {
+		t.Fatalf("verify: %+v, %v", resp, err)
+	}
+	if err := fac.DisableNetwork(testchain.Network); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(grace + 50*time.Millisecond)
+	if resp, err := fac.Settle(ctx, settleLate); err != nil || resp.Success || resp.ReasonCode != types.ReasonNetworkDisabled {
+		t.Errorf("verified payload after the grace window: %+v, %v", resp, err)
+	}
+}
+
+func TestDisabledNetworksSurviveRestart(t *testing.T) {
+	chain := newTestChain(t)
+	path := filepath.Join(t.TempDir(), "networks.json")
+	build := func() *facilitator.LocalFacilitator {
+		t.Helper()
+		fac, err := facilitator.NewBuilder().
+			WithEVMNetwork(testchain.Network, chain.Options()).
+			WithNetworkStateFile(path).
+			Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fac
+	}
+
+	first := build()
+	if err := first.DisableNetwork(testchain.Network); err != nil {
+		t.Fatal(err)
+	}
+	restarted := build()
+	if supportsNetwork(t, restarted, testchain.Network) {
+		t.Error("the disable was lost on restart")
+	}
+	if !restarted.DisabledNetworks()[testchain.Network].Equal(first.DisabledNetworks()[testchain.Network]) {
+		t.Errorf("disabled at %v after restart, want %v", restarted.DisabledNetworks(), first.DisabledNetworks())
+	}
+
+	if err := restarted.EnableNetwork(testchain.Network); err != nil {
+		t.Fatal(err)
+	}
+	if !supportsNetwork(t, build(), testchain.Network) {
+		t.Error("the enable was lost on restart")
+	}
+
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := facilitator.NewBuilder().WithEVMNetwork(testchain.Network, chain.Options()).WithNetworkStateFile(path).Build(); err == nil {
+		t.Error("built with a corrupt network state file")
+	}
+}
+
+func TestRegisteredNetworkVerifiesEndToEnd(t *testing.T) {
+	// testchain registers its network the way CUSTOM_NETWORKS does
+	if chainID, ok := testchain.Network.ChainID(); !ok || chainID != testchain.ChainID || !testchain.Network.IsEVM() {
+		t.Fatalf("%s: chain ID %d (%v), want %d", testchain.Network, chainID, ok, testchain.ChainID)
+	}
+	chain := newTestChain(t)
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !supportsNetwork(t, fac, testchain.Network) {
+		t.Fatal("the registered network is not supported")
+	}
+	verify, _ := networkPayment(t, chain)
+	resp, err := fac.Verify(context.Background(), verify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsValid {
+		t.Errorf("payment on the registered network rejected: %s (%s)", resp.ReasonCode, resp.Reason)
+	}
+}
@@ -0,0 +1,214 @@
+package facilitator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultSupportedCacheTTL is how long a proxied /supported response is reused
+const DefaultSupportedCacheTTL = 5 * time.Minute
+
+// ProxyFacilitator is an edge Facilitator that runs the cheap checks locally
+// (structure, timing, nonce cache) and forwards the RPC-bound work to an
+// upstream facilitator.
+type ProxyFacilitator struct {
+	upstream   *Client
+	nonceStore *evm.NonceStore
+	clock      types.Clock
+	clockSkew  time.Duration // Tolerance applied to validAfter
+
+	supportedTTL     time.Duration
+	supportedMu      sync.Mutex
+	supported        *types.SupportedPaymentKindsResponse
+	supportedFetched time.Time
+}
+
+// NewProxyFacilitator creates a proxy forwarding to the facilitator at upstreamURL
+func NewProxyFacilitator(upstreamURL string) *ProxyFacilitator {
+	return &ProxyFacilitator{
+		upstream:     NewClient(upstreamURL, nil),
+		nonceStore:   evm.NewNonceStore(),
+		clock:        types.SystemClock{},
+		supportedTTL: DefaultSupportedCacheTTL,
+	}
+}
+
+// SetClock sets the time source for the timing precheck and nonce expiry,
+// as evm.WithClock does for a local facilitator; call it before serving
+func (f *ProxyFacilitator) SetClock(clock types.Clock) {
+	f.clock = clock
+	f.nonceStore = evm.NewNonceStoreWithClock(evm.DefaultNonceStoreMaxEntries, evm.DefaultNonceStoreMaxPerAddress, clock)
+}
+
+// SetClockSkewTolerance accepts authorizations whose validAfter is up to
+// skew in the future, matching the upstream's evm.WithClockSkewTolerance
+func (f *ProxyFacilitator) SetClockSkewTolerance(skew time.Duration) {
+	if skew > 0 {
+		f.clockSkew = skew
+	}
+}
+
+// SetSupportedCacheTTL changes how long the upstream /supported response is cached
+func (f *ProxyFacilitator) SetSupportedCacheTTL(ttl time.Duration) {
+	f.supportedMu.Lock()
+	defer f.supportedMu.Unlock()
+	f.supportedTTL = ttl
+}
+
+// Verify implements Facilitator.Verify
+func (f *ProxyFacilitator) Verify(ctx context.Context, request *types.VerifyRequest) (*types.VerifyResponse, error) {
+	if err := validatePayment(&request.PaymentPayload, &request.PaymentRequirements); err != nil {
+		return nil, err
+	}
+
+	// Reject locally when the answer is already known
+	if facErr := f.precheck(&request.PaymentPayload); facErr != nil {
+		response := types.NewInvalidResponseFromError(facErr)
+		return &response, nil
+	}
+
+	return f.upstream.Verify(ctx, request)
+}
+
+// Settle implements Facilitator.Settle
+func (f *ProxyFacilitator) Settle(ctx context.Context, request *types.SettleRequest) (*types.SettleResponse, error) {
+	if err := validatePayment(&request.PaymentPayload, &request.PaymentRequirements); err != nil {
+		return nil, err
+	}
+
+	if facErr := f.precheck(&request.PaymentPayload); facErr != nil {
+		return &types.SettleResponse{
+			Success:    false,
+			Error:      facErr.Message,
+			ReasonCode: facErr.Code,
+		}, nil
+	}
+
+	resp, err := f.upstream.Settle(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remember nonces the upstream has consumed so replays stop at the edge
+	if resp.Success || resp.RevertCode == "NonceAlreadyUsed" {
+		auth := request.PaymentPayload.Payload.Authorization
+		validBefore, _ := strconv.ParseInt(auth.ValidBefore, 10, 64)
+		if nonce, err := auth.Nonce.Nonce(); err == nil {
+			f.nonceStore.MarkNonceUsed(auth.From.Hex(), nonce.String(), validBefore)
+		}
+	}
+	return resp, nil
+}
+
+// Simulate implements Facilitator.Simulate
+func (f *ProxyFacilitator) Simulate(ctx context.Context, request *types.SettleRequest) (*types.SimulateResponse, error) {
+	if err := validatePayment(&request.PaymentPayload, &request.PaymentRequirements); err != nil {
+		return nil, err
+	}
+
+	if facErr := f.precheck(&request.PaymentPayload); facErr != nil {
+		return &types.SimulateResponse{
+			Valid:      false,
+			Reason:     facErr.Message,
+			ReasonCode: facErr.Code,
+			Payer:      facErr.Payer,
+		}, nil
+	}
+
+	return f.upstream.Simulate(ctx, request)
+}
+
+// Cancel implements Facilitator.Cancel
+func (f *ProxyFacilitator) Cancel(ctx context.Context, request *types.CancelRequest) (*types.CancelResponse, error) {
+	if !types.IsSupportedX402Version(request.X402Version) {
+		return nil, types.NewUnsupportedVersionError(request.X402Version)
+	}
+
+	resp, err := f.upstream.Cancel(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// A cancelled nonce can never settle; stop payments carrying it at the edge
+	if resp.Success {
+		if nonce, err := request.Authorization.Nonce.Nonce(); err == nil {
+			f.nonceStore.MarkNonceUsed(request.Authorization.Authorizer.Hex(), nonce.String(), time.Now().Add(evm.CancelledNonceRetention).Unix())
+		}
+	}
+	return resp, nil
+}
+
+// Supported implements Facilitator.Supported
+// The upstream response is cached for the TTL; if the upstream is unreachable
+// a stale cached response is served rather than failing
+func (f *ProxyFacilitator) Supported(ctx context.Context) (*types.SupportedPaymentKindsResponse, error) {
+	f.supportedMu.Lock()
+	defer f.supportedMu.Unlock()
+
+	if f.supported != nil && time.Since(f.supportedFetched) < f.supportedTTL {
+		return f.supported, nil
+	}
+
+	resp, err := f.upstream.Supported(ctx)
+	if err != nil {
+		if f.supported != nil {
+			log.Printf("facilitator.Proxy: upstream /supported failed, serving cached response: %v", err)
+			return f.supported, nil
+		}
+		return nil, err
+	}
+
+	f.supported = resp
+	f.supportedFetched = time.Now()
+	return resp, nil
+}
+
+// SupportedFiltered returns the supported payment kinds passing filter,
+// filtering the (cached) upstream response
+func (f *ProxyFacilitator) SupportedFiltered(ctx context.Context, filter types.SupportedFilter) (*types.SupportedPaymentKindsResponse, error) {
+	resp, err := f.Supported(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Filter(filter), nil
+}
+
+// precheck rejects payloads that are known to be invalid without asking the
+// upstream; nil means the request should be forwarded
+func (f *ProxyFacilitator) precheck(payload *types.PaymentPayload) *types.FacilitatorError {
+	auth := payload.Payload.Authorization
+	payer := types.NewEvmAddress(auth.From)
+
+	validAfter, err := strconv.ParseUint(auth.ValidAfter, 10, 64)
+	if err != nil {
+		return types.NewInvalidTimingError(payer, fmt.Sprintf("invalid validAfter: %v", err))
+	}
+	validBefore, err := strconv.ParseUint(auth.ValidBefore, 10, 64)
+	if err != nil {
+		return types.NewInvalidTimingError(payer, fmt.Sprintf("invalid validBefore: %v", err))
+	}
+
+	now := uint64(f.clock.Now().Unix())
+	if now+uint64(f.clockSkew.Seconds()) < validAfter {
+		return types.NewNotYetValidError(payer, fmt.Sprintf("payment not yet valid (validAfter: %s, now: %d)", auth.ValidAfter, now))
+	}
+	if now >= validBefore {
+		return types.NewExpiredError(payer, fmt.Sprintf("payment expired (validBefore: %s, now: %d)", auth.ValidBefore, now))
+	}
+
+	nonce, err := auth.Nonce.Nonce()
+	if err != nil {
+		return types.NewDecodingError(err.Error())
+	}
+	if f.nonceStore.IsNonceUsed(auth.From.Hex(), nonce.String()) {
+		return types.NewNonceAlreadyUsedError(payer)
+	}
+	return nil
+}
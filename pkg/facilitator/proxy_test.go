@@ -0,0 +1,234 @@
+package facilitator_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// upstream fronts a checking fake facilitator, counting the requests that
+// reach it per path; while down it answers everything with 502
+type upstream struct {
+	*httptest.Server
+	down atomic.Bool
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newUpstream(t *testing.T) *upstream {
+	t.Helper()
+	target, err := url.Parse(x402test.NewFakeFacilitator(t, x402test.BehaviorVerify).URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	u := &upstream{calls: make(map[string]int)}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.mu.Lock()
+		u.calls[r.URL.Path]++
+		u.mu.Unlock()
+		if u.down.Load() {
+			http.Error(w, "upstream down", http.StatusBadGateway)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *upstream) count(path string) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.calls[path]
+}
+
+func proxyPayment(t *testing.T, opts *x402test.PayloadOptions) (types.PaymentPayload, types.PaymentRequirements) {
+	t.Helper()
+	requirements := x402test.Requirements()
+	payload, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *payload, requirements
+}
+
+func TestProxyForwardsValidPayments(t *testing.T) {
+	up := newUpstream(t)
+	proxy := facilitator.NewProxyFacilitator(up.URL)
+	payload, requirements := proxyPayment(t, nil)
+
+	verified, err := proxy.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verified.IsValid || up.count("/verify") != 1 {
+		t.Errorf("verify: valid %v (%s), %d upstream calls; want the upstream's acceptance", verified.IsValid, verified.Reason, up.count("/verify"))
+	}
+	settled, err := proxy.Settle(context.Background(), &types.SettleRequest{PaymentPayload: payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settled.Success || settled.TransactionHash == nil || up.count("/settle") != 1 {
+		t.Errorf("settle: %+v, %d upstream calls", settled, up.count("/settle"))
+	}
+}
+
+func TestProxyRejectsLocallyWithoutAskingUpstream(t *testing.T) {
+	up := newUpstream(t)
+	proxy := facilitator.NewProxyFacilitator(up.URL)
+
+	expired, requirements := proxyPayment(t, &x402test.PayloadOptions{Now: time.Now().Add(-2 * time.Hour), ValidFor: time.Hour})
+	verified, err := proxy.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: expired, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.IsValid {
+		t.Error("an expired payment verified")
+	}
+	settled, err := proxy.Settle(context.Background(), &types.SettleRequest{PaymentPayload: expired, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settled.Success {
+		t.Error("an expired payment settled")
+	}
+
+	pending, requirements := proxyPayment(t, &x402test.PayloadOptions{Now: time.Now().Add(time.Hour)})
+	if verified, err := proxy.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: pending, PaymentRequirements: requirements}); err != nil || verified.IsValid {
+		t.Errorf("a not yet valid payment: %+v, %v; want it rejected", verified, err)
+	}
+
+	mismatched, requirements := proxyPayment(t, nil)
+	requirements.Network = types.Network("base")
+	if _, err := proxy.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: mismatched, PaymentRequirements: requirements}); err == nil {
+		t.Error("a payment for another network was forwarded")
+	}
+
+	if n := up.count("/verify") + up.count("/settle"); n != 0 {
+		t.Errorf("%d requests reached the upstream, want every rejection made at the edge", n)
+	}
+}
+
+func TestProxyPrecheckUsesClockAndSkew(t *testing.T) {
+	up := newUpstream(t)
+	proxy := facilitator.NewProxyFacilitator(up.URL)
+	now := time.Unix(1_700_000_000, 0)
+	proxy.SetClock(&faucetClock{now: now})
+	proxy.SetClockSkewTolerance(5 * time.Second)
+
+	// Signed 4s ahead of the proxy's clock: within the skew, so forwarded
+	// (and not expired, though validBefore is long past on the wall clock)
+	ahead, requirements := proxyPayment(t, &x402test.PayloadOptions{Now: now.Add(4 * time.Second)})
+	if _, err := proxy.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: ahead, PaymentRequirements: requirements}); err != nil {
+		t.Fatal(err)
+	}
+	if n := up.count("/verify"); n != 1 {
+		t.Errorf("a payment within the clock skew reached the upstream %d times, want 1", n)
+	}
+
+	tooFar, requirements := proxyPayment(t, &x402test.PayloadOptions{Now: now.Add(10 * time.Second)})
+	verified, err := proxy.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: tooFar, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.IsValid || verified.ReasonCode != types.ReasonNotYetValid || up.count("/verify") != 1 {
+		t.Errorf("a payment beyond the clock skew: %+v, %d upstream calls; want it rejected at the edge", verified, up.count("/verify"))
+	}
+}
+
+func TestProxyStopsReplaysAtTheEdge(t *testing.T) {
+	up := newUpstream(t)
+	proxy := facilitator.NewProxyFacilitator(up.URL)
+	payload, requirements := proxyPayment(t, nil)
+	request := &types.SettleRequest{PaymentPayload: payload, PaymentRequirements: requirements}
+
+	if settled, err := proxy.Settle(context.Background(), request); err != nil || !settled.Success {
+		t.Fatalf("first settle: %+v, %v", settled, err)
+	}
+	replayed, err := proxy.Settle(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Success || replayed.ReasonCode != types.ReasonNonceReused {
+		t.Errorf("replayed settle: %+v, want %s", replayed, types.ReasonNonceReused)
+	}
+	verified, err := proxy.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.IsValid || verified.ReasonCode != types.ReasonNonceReused {
+		t.Errorf("verify of the settled payment: %+v, want %s", verified, types.ReasonNonceReused)
+	}
+	if up.count("/settle") != 1 || up.count("/verify") != 0 {
+		t.Errorf("upstream saw %d settles and %d verifies, want the first settle only", up.count("/settle"), up.count("/verify"))
+	}
+}
+
+func TestProxyReportsUpstreamFailure(t *testing.T) {
+	up := newUpstream(t)
+	up.down.Store(true)
+	proxy := facilitator.NewProxyFacilitator(up.URL)
+	payload, requirements := proxyPayment(t, nil)
+
+	if _, err := proxy.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: payload, PaymentRequirements: requirements}); err == nil {
+		t.Error("verify succeeded with the upstream down")
+	}
+	if _, err := proxy.Settle(context.Background(), &types.SettleRequest{PaymentPayload: payload, PaymentRequirements: requirements}); err == nil {
+		t.Error("settle succeeded with the upstream down")
+	}
+
+	// A settlement that never happened leaves the nonce usable
+	up.down.Store(false)
+	settled, err := proxy.Settle(context.Background(), &types.SettleRequest{PaymentPayload: payload, PaymentRequirements: requirements})
+	if err != nil || !settled.Success {
+		t.Errorf("settle after recovery: %+v, %v", settled, err)
+	}
+}
+
+func TestProxyCachesSupported(t *testing.T) {
+	up := newUpstream(t)
+	proxy := facilitator.NewProxyFacilitator(up.URL)
+
+	first, err := proxy.Supported(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Kinds) == 0 {
+		t.Fatal("no supported kinds proxied")
+	}
+	if _, err := proxy.Supported(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := up.count("/supported"); n != 1 {
+		t.Errorf("%d upstream /supported calls within the TTL, want 1", n)
+	}
+
+	// Past the TTL the upstream is asked again; while it is down the
+	// stale response is served
+	proxy.SetSupportedCacheTTL(time.Nanosecond)
+	up.down.Store(true)
+	stale, err := proxy.Supported(context.Background())
+	if err != nil {
+		t.Fatalf("upstream down with a cached response: %v", err)
+	}
+	if len(stale.Kinds) != len(first.Kinds) || up.count("/supported") != 2 {
+		t.Errorf("served %d kinds after %d upstream calls, want the cached %d after a refetch", len(stale.Kinds), up.count("/supported"), len(first.Kinds))
+	}
+
+	// Nothing cached yet: the failure surfaces
+	if _, err := facilitator.NewProxyFacilitator(up.URL).Supported(context.Background()); err == nil {
+		t.Error("a proxy with nothing cached answered /supported with the upstream down")
+	}
+}
@@ -0,0 +1,53 @@
+package facilitator_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestSettleAttachesSignedReceipt(t *testing.T) {
+	chain := newTestChain(t)
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := crypto.GenerateKey()
+	signer := types.KeySigner{Key: key}
+	fac.SetReceiptSigner(signer)
+
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := fac.Settle(context.Background(), &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Success || resp.Receipt == nil {
+		t.Fatalf("settle %+v, want a receipt", resp)
+	}
+
+	receipt, auth := resp.Receipt, payload.Payload.Authorization
+	if !strings.EqualFold(receipt.Payer, auth.From.Hex()) || !strings.EqualFold(receipt.PayTo, payTo.Hex()) || receipt.Amount != "1000" || receipt.Nonce != auth.Nonce.String() || receipt.TransactionHash != resp.TransactionHash.Hash {
+		t.Errorf("receipt %+v does not match the settled payment", receipt)
+	}
+	recovered, err := receipt.VerifySignature(resp.ReceiptSignature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered != signer.Address() {
+		t.Errorf("receipt signed by %s, want %s", recovered.Hex(), signer.Address().Hex())
+	}
+}
@@ -0,0 +1,168 @@
+package facilitator
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultReconcileInterval is how often in-flight settlements are rechecked
+const DefaultReconcileInterval = time.Minute
+
+// ReconcileTarget looks up settlement transactions on one network
+// evm.Provider implements it
+type ReconcileTarget interface {
+	// TransactionReceipt returns ethereum.NotFound for unknown or pending transactions
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*gethtypes.Receipt, error)
+	MarkNonceUsed(from, nonce string, validBefore int64)
+}
+
+// ReconcileStats summarizes reconciliation so far
+type ReconcileStats struct {
+	Runs      uint64    `json:"runs"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+	Checked   uint64    `json:"checked"`
+	Confirmed uint64    `json:"confirmed"`
+	Reverted  uint64    `json:"reverted"`
+	Expired   uint64    `json:"expired"`
+	Pending   int       `json:"pending"` // In flight after the last run
+	Errors    uint64    `json:"errors"`
+}
+
+// Reconciler resolves journal entries left submitted or unknown, e.g. by a
+// crash between sending a settlement and seeing its receipt
+type Reconciler struct {
+	journal *accounting.SettlementJournal
+	targets map[types.Network]ReconcileTarget
+	clock   types.Clock
+
+	// Called with each entry a run resolves (optional; see SetEventPublisher)
+	onResolve func(entry accounting.JournalEntry)
+
+	mu    sync.Mutex
+	stats ReconcileStats
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewReconciler creates a reconciler for the journal's entries on the
+// networks in targets; a nil clock uses the system clock
+func NewReconciler(journal *accounting.SettlementJournal, targets map[types.Network]ReconcileTarget, clock types.Clock) *Reconciler {
+	if clock == nil {
+		clock = types.SystemClock{}
+	}
+	return &Reconciler{
+		journal: journal,
+		targets: targets,
+		clock:   clock,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start reconciles immediately and then every interval (DefaultReconcileInterval
+// if interval <= 0) until Stop
+func (r *Reconciler) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReconcileInterval
+	}
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			r.RunOnce(context.Background())
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop ends the background loop started by Start and waits for it
+func (r *Reconciler) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+	})
+}
+
+// RunOnce checks every in-flight entry once and returns the updated stats
+//
+// A successful receipt marks the entry confirmed and its nonce used, a
+// failed one reverted. Without a receipt the entry stays in flight until its
+// validBefore passes, after which the authorization can no longer be
+// executed and the entry is marked expired.
+func (r *Reconciler) RunOnce(ctx context.Context) ReconcileStats {
+	var run ReconcileStats
+	for _, entry := range r.journal.InFlight() {
+		target, ok := r.targets[entry.Network]
+		if !ok {
+			run.Pending++
+			continue
+		}
+		run.Checked++
+
+		receipt, err := target.TransactionReceipt(ctx, common.HexToHash(entry.TransactionHash))
+		switch {
+		case err == nil && receipt.Status == gethtypes.ReceiptStatusSuccessful:
+			target.MarkNonceUsed(entry.Payer, entry.Nonce, entry.ValidBefore)
+			r.resolve(entry, accounting.JournalConfirmed)
+			run.Confirmed++
+		case err == nil:
+			r.resolve(entry, accounting.JournalReverted)
+			run.Reverted++
+		case errors.Is(err, ethereum.NotFound):
+			if entry.ValidBefore > 0 && r.clock.Now().Unix() >= entry.ValidBefore {
+				r.resolve(entry, accounting.JournalExpired)
+				run.Expired++
+				continue
+			}
+			run.Pending++
+		default:
+			log.Printf("facilitator.Reconcile: receipt lookup for %s on %s failed: %v", entry.TransactionHash, entry.Network, err)
+			run.Errors++
+			run.Pending++
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Runs++
+	r.stats.LastRun = r.clock.Now()
+	r.stats.Checked += run.Checked
+	r.stats.Confirmed += run.Confirmed
+	r.stats.Reverted += run.Reverted
+	r.stats.Expired += run.Expired
+	r.stats.Errors += run.Errors
+	r.stats.Pending = run.Pending
+	return r.stats
+}
+
+// resolve sets the final status of an in-flight entry
+func (r *Reconciler) resolve(entry accounting.JournalEntry, status accounting.JournalStatus) {
+	r.journal.SetStatus(entry.ID, status)
+	if r.onResolve != nil {
+		entry.Status = status
+		r.onResolve(entry)
+	}
+}
+
+// Stats returns the totals across all runs
+func (r *Reconciler) Stats() ReconcileStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
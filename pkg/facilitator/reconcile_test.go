@@ -0,0 +1,214 @@
+package facilitator_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// fakeReceipts is a ReconcileTarget answering receipt lookups from a table;
+// hashes missing from it are not found
+type fakeReceipts struct {
+	mu       sync.Mutex
+	receipts map[common.Hash]*gethtypes.Receipt
+	failing  map[common.Hash]bool // Lookups fail with an RPC error
+	marked   []string
+}
+
+func (f *fakeReceipts) TransactionReceipt(ctx context.Context, hash common.Hash) (*gethtypes.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing[hash] {
+		return nil, errors.New("connection refused")
+	}
+	if receipt, ok := f.receipts[hash]; ok {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeReceipts) MarkNonceUsed(from, nonce string, validBefore int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.marked = append(f.marked, from+":"+nonce)
+}
+
+// stepClock reads a settable instant
+type stepClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *stepClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func TestReconcilerResolvesInFlightSettlements(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	hash := func(b byte) common.Hash { return common.BytesToHash([]byte{b}) }
+	entry := func(id string, status accounting.JournalStatus, network types.Network, tx byte, validBefore time.Time) accounting.JournalEntry {
+		return accounting.JournalEntry{
+			ID:              id,
+			Status:          status,
+			Network:         network,
+			Payer:           "0x00000000000000000000000000000000000000a1",
+			PayTo:           "0x00000000000000000000000000000000000000b0",
+			Amount:          "1000",
+			Nonce:           "0x" + id,
+			ValidBefore:     validBefore.Unix(),
+			TransactionHash: hash(tx).Hex(),
+		}
+	}
+
+	// Entries left in flight by a crash, read back from the journal file
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := accounting.OpenSettlementJournal(path, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []accounting.JournalEntry{
+		entry("mined", accounting.JournalSubmitted, types.NetworkBaseSepolia, 1, now.Add(time.Hour)),
+		entry("reverted", accounting.JournalUnknown, types.NetworkBaseSepolia, 2, now.Add(time.Hour)),
+		entry("pending", accounting.JournalSubmitted, types.NetworkBaseSepolia, 3, now.Add(time.Minute)),
+		entry("lapsed", accounting.JournalUnknown, types.NetworkBaseSepolia, 4, now.Add(-time.Second)),
+		entry("rpc-down", accounting.JournalSubmitted, types.NetworkBaseSepolia, 5, now.Add(-time.Second)),
+		entry("elsewhere", accounting.JournalSubmitted, types.NetworkBase, 6, now.Add(-time.Second)),
+		entry("settled", accounting.JournalConfirmed, types.NetworkBaseSepolia, 7, now.Add(-time.Second)),
+	} {
+		journal.Record(e)
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if journal, err = accounting.OpenSettlementJournal(path, 16); err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	target := &fakeReceipts{
+		receipts: map[common.Hash]*gethtypes.Receipt{
+			hash(1): {Status: gethtypes.ReceiptStatusSuccessful},
+			hash(2): {Status: gethtypes.ReceiptStatusFailed},
+		},
+		failing: map[common.Hash]bool{hash(5): true},
+	}
+	clock := &stepClock{now: now}
+	r := facilitator.NewReconciler(journal, map[types.Network]facilitator.ReconcileTarget{types.NetworkBaseSepolia: target}, clock)
+
+	stats := r.RunOnce(context.Background())
+	want := facilitator.ReconcileStats{Runs: 1, LastRun: now, Checked: 5, Confirmed: 1, Reverted: 1, Expired: 1, Pending: 3, Errors: 1}
+	if stats != want {
+		t.Errorf("first run: %+v, want %+v", stats, want)
+	}
+	status := func(id string) accounting.JournalStatus {
+		for _, e := range journal.Entries("", 0) {
+			if e.ID == id {
+				return e.Status
+			}
+		}
+		return ""
+	}
+	for id, want := range map[string]accounting.JournalStatus{
+		"mined":     accounting.JournalConfirmed,
+		"reverted":  accounting.JournalReverted,
+		"pending":   accounting.JournalSubmitted,
+		"lapsed":    accounting.JournalExpired,
+		"rpc-down":  accounting.JournalSubmitted, // Kept until the node answers
+		"elsewhere": accounting.JournalSubmitted, // No target for the network
+		"settled":   accounting.JournalConfirmed,
+	} {
+		if got := status(id); got != want {
+			t.Errorf("%s: status %q, want %q", id, got, want)
+		}
+	}
+	if len(target.marked) != 1 || target.marked[0] != "0x00000000000000000000000000000000000000a1:0xmined" {
+		t.Errorf("nonces marked used %q, want only the mined settlement's", target.marked)
+	}
+
+	// The pending transaction never mines before its validBefore
+	clock.Set(now.Add(2 * time.Minute))
+	target.mu.Lock()
+	delete(target.failing, hash(5))
+	target.mu.Unlock()
+	stats = r.RunOnce(context.Background())
+	if stats.Runs != 2 || stats.Checked != 7 || stats.Expired != 3 || stats.Pending != 1 || stats.Errors != 1 {
+		t.Errorf("second run: %+v, want pending and rpc-down expired", stats)
+	}
+	if got := status("pending"); got != accounting.JournalExpired {
+		t.Errorf("pending after validBefore: status %q, want expired", got)
+	}
+	if r.Stats() != stats {
+		t.Errorf("Stats() = %+v, want the totals %+v", r.Stats(), stats)
+	}
+
+	// Resolutions are persisted for the next restart
+	if err := journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := accounting.OpenSettlementJournal(path, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	inFlight := reopened.InFlight()
+	if len(inFlight) != 1 || inFlight[0].ID != "elsewhere" {
+		t.Errorf("in flight after reopening: %+v, want only the entry without a target", inFlight)
+	}
+}
+
+func TestStartReconcilerReportsInStats(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := fac.StartReconciler(time.Hour); r != nil {
+		t.Error("reconciler started without a journal")
+	}
+	if _, ok := fac.Stats()["reconciliation"]; ok {
+		t.Error("stats report reconciliation without a reconciler")
+	}
+
+	journal := accounting.NewSettlementJournal(16)
+	journal.Record(accounting.JournalEntry{ID: "lost", Status: accounting.JournalUnknown, Network: testchain.Network, TransactionHash: common.BytesToHash([]byte{9}).Hex(), ValidBefore: 1})
+	fac.SetJournal(journal)
+	r := fac.StartReconciler(time.Hour)
+	if r == nil {
+		t.Fatal("no reconciler started with a journal")
+	}
+	defer r.Stop()
+
+	// The first run happens on start
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Stats().Runs == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	stats, ok := fac.Stats()["reconciliation"].(facilitator.ReconcileStats)
+	if !ok || stats.Runs != 1 || stats.Checked != 1 {
+		t.Errorf("stats reconciliation %+v, want one run checking the lost settlement", fac.Stats()["reconciliation"])
+	}
+}
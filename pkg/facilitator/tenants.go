@@ -0,0 +1,164 @@
+package facilitator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/chain/evm"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// TenantPolicy is what one tenant of a shared facilitator may do. Requests
+// name their tenant with types.WithTenantID (see middleware.TenantMiddleware);
+// an empty list leaves that dimension open.
+type TenantPolicy struct {
+	ID       string
+	Networks []types.Network  // Networks the tenant may pay on
+	PayTo    []string         // Addresses the tenant's payments may go to
+	Assets   []common.Address // Tokens the tenant accepts, within each network's whitelist
+	Signers  []common.Address // Settle with these signers only (their keys must be configured)
+}
+
+// TenantStats counts one tenant's requests since startup
+type TenantStats struct {
+	Verified         uint64 `json:"verified"` // Valid verifications
+	Settled          uint64 `json:"settled"`  // Successful settlements
+	PolicyViolations uint64 `json:"policyViolations"`
+}
+
+// tenant is a TenantPolicy prepared for lookups
+type tenant struct {
+	id       string
+	networks map[types.Network]bool
+	payTo    map[string]bool // Lowercase
+	assets   map[common.Address]bool
+	signers  []common.Address
+
+	verified, settled, violations atomic.Uint64
+}
+
+// SetTenants restricts each tenant's requests to its policy; requests
+// without a tenant are not restricted, except that no request settles with
+// another tenant's signers. Set tenants before serving requests.
+func (f *LocalFacilitator) SetTenants(policies []TenantPolicy) error {
+	tenants := make(map[string]*tenant, len(policies))
+	reserved := make(map[common.Address]bool)
+	for _, policy := range policies {
+		if policy.ID == "" {
+			return fmt.Errorf("tenant policies need an ID")
+		}
+		if tenants[policy.ID] != nil {
+			return fmt.Errorf("tenant %q is configured twice", policy.ID)
+		}
+		t := &tenant{
+			id:       policy.ID,
+			networks: make(map[types.Network]bool, len(policy.Networks)),
+			payTo:    make(map[string]bool, len(policy.PayTo)),
+			assets:   make(map[common.Address]bool, len(policy.Assets)),
+			signers:  append([]common.Address(nil), policy.Signers...),
+		}
+		for _, net := range policy.Networks {
+			t.networks[net] = true
+		}
+		for _, payTo := range policy.PayTo {
+			t.payTo[strings.ToLower(types.NormalizeEVMAddress(payTo))] = true
+		}
+		for _, asset := range policy.Assets {
+			t.assets[asset] = true
+		}
+		for _, signer := range policy.Signers {
+			reserved[signer] = true
+		}
+		tenants[policy.ID] = t
+	}
+	f.tenants, f.reservedSigners = tenants, reserved
+	return nil
+}
+
+// checkTenant applies the policy of the request's tenant, returning the
+// tenant (nil for requests without one) or the violation refusing it
+func (f *LocalFacilitator) checkTenant(ctx context.Context, payload *types.PaymentPayload, requirements *types.PaymentRequirements) (*tenant, *types.FacilitatorError) {
+	return f.checkTenantPolicy(ctx, payload.Network, requirements.PayTo, requirements.Asset, types.PayerFromPayload(payload))
+}
+
+// checkTenantCancel applies the network and asset limits of the request's
+// tenant to a cancellation, which pays no one
+func (f *LocalFacilitator) checkTenantCancel(ctx context.Context, request *types.CancelRequest) (*tenant, *types.FacilitatorError) {
+	payer := types.NewEvmAddress(request.Authorization.Authorizer)
+	return f.checkTenantPolicy(ctx, request.Network, "", request.Asset, &payer)
+}
+
+// checkTenantPolicy checks a request on net paying payTo ("" for none) in
+// asset against the policy of its tenant
+func (f *LocalFacilitator) checkTenantPolicy(ctx context.Context, net types.Network, payTo string, asset common.Address, payer *types.MixedAddress) (*tenant, *types.FacilitatorError) {
+	id := types.TenantIDFromContext(ctx)
+	if id == "" {
+		return nil, nil
+	}
+	t := f.tenants[id]
+	if t == nil {
+		return nil, types.NewPolicyViolationError(payer, fmt.Sprintf("unknown tenant %q", id))
+	}
+
+	var message string
+	switch {
+	case len(t.networks) > 0 && !t.networks[net]:
+		message = fmt.Sprintf("tenant %s may not use network %s", id, net)
+	case payTo != "" && len(t.payTo) > 0 && !t.payTo[strings.ToLower(types.NormalizeEVMAddress(payTo))]:
+		message = fmt.Sprintf("tenant %s may not pay to %s", id, payTo)
+	case len(t.assets) > 0 && !t.assets[asset]:
+		message = fmt.Sprintf("tenant %s does not accept asset %s", id, asset.Hex())
+	default:
+		return t, nil
+	}
+	t.violations.Add(1)
+	return t, types.NewPolicyViolationError(payer, message)
+}
+
+// settleContext limits settlement to the tenant's signers; requests of
+// other tenants (and without one) keep off the signers reserved by a tenant
+func (f *LocalFacilitator) settleContext(ctx context.Context, t *tenant, provider *evm.Provider) context.Context {
+	if t != nil && len(t.signers) > 0 {
+		return evm.WithSignerSet(ctx, t.signers)
+	}
+	if len(f.reservedSigners) == 0 {
+		return ctx
+	}
+	var shared []common.Address
+	for _, signer := range provider.SignerAddresses() {
+		if !f.reservedSigners[signer] {
+			shared = append(shared, signer)
+		}
+	}
+	return evm.WithSignerSet(ctx, shared)
+}
+
+// recordVerified counts a valid verification (no-op without a tenant)
+func (t *tenant) recordVerified() {
+	if t != nil {
+		t.verified.Add(1)
+	}
+}
+
+// recordSettled counts a successful settlement (no-op without a tenant)
+func (t *tenant) recordSettled() {
+	if t != nil {
+		t.settled.Add(1)
+	}
+}
+
+// TenantStats returns the request counts of every tenant, by ID
+func (f *LocalFacilitator) TenantStats() map[string]TenantStats {
+	stats := make(map[string]TenantStats, len(f.tenants))
+	for id, t := range f.tenants {
+		stats[id] = TenantStats{
+			Verified:         t.verified.Load(),
+			Settled:          t.settled.Load(),
+			PolicyViolations: t.violations.Load(),
+		}
+	}
+	return stats
+}
@@ -0,0 +1,174 @@
+package facilitator_test
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func newTestChain(t *testing.T) *testchain.Chain {
+	t.Helper()
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	return chain
+}
+
+func randomNonce(t *testing.T) types.Nonce {
+	t.Helper()
+	var nonce types.Nonce
+	if _, err := rand.Read(nonce[:]); err != nil {
+		t.Fatal(err)
+	}
+	return nonce
+}
+
+func TestCancelAppliesTenantPolicy(t *testing.T) {
+	chain := newTestChain(t)
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	elsewhere := common.HexToAddress("0x00000000000000000000000000000000000000e1")
+	err = fac.SetTenants([]facilitator.TenantPolicy{
+		{ID: "own", Signers: []common.Address{chain.Signer.Address}},
+		{ID: "unconfigured", Signers: []common.Address{elsewhere}},
+		{ID: "mainnet", Networks: []types.Network{"base"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		tenant string
+		want   types.ReasonCode // "" for a cancellation that goes through
+	}{
+		{"unknown", types.ReasonPolicyViolation},
+		{"mainnet", types.ReasonPolicyViolation},
+		{"unconfigured", types.ReasonPolicyViolation}, // Its signer has no key here
+		{"", types.ReasonPolicyViolation},             // The only signer is reserved for "own"
+		{"own", ""},
+	} {
+		nonce := randomNonce(t)
+		request, err := chain.CancelRequest(chain.Accounts[0], nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := fac.Cancel(types.WithTenantID(context.Background(), tc.tenant), request)
+		if err != nil {
+			t.Fatalf("tenant %q: %v", tc.tenant, err)
+		}
+		if tc.want != "" {
+			if resp.Success || resp.ReasonCode != tc.want {
+				t.Errorf("tenant %q: success %v, reason %q (%s); want %q", tc.tenant, resp.Success, resp.ReasonCode, resp.Error, tc.want)
+			}
+			continue
+		}
+		if !resp.Success {
+			t.Fatalf("tenant %q: cancel refused: %s (%s)", tc.tenant, resp.Error, resp.ReasonCode)
+		}
+		used, err := chain.AuthorizationUsed(chain.Accounts[0].Address, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !used {
+			t.Errorf("tenant %q: the token does not report the nonce cancelled", tc.tenant)
+		}
+	}
+	if got := fac.TenantStats()["mainnet"].PolicyViolations; got != 1 {
+		t.Errorf("mainnet tenant violations = %d, want 1", got)
+	}
+}
+
+func TestTenantsAreHeldToTheirPolicies(t *testing.T) {
+	chain := newTestChain(t)
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	fac, err := facilitator.NewBuilder().
+		WithEVMNetwork(testchain.Network, chain.Options()).
+		WithTenants([]facilitator.TenantPolicy{
+			{ID: "payouts", Networks: []types.Network{testchain.Network}, PayTo: []string{types.FormatXDCAddress(payTo.Hex())}},
+			{ID: "checkout", Signers: []common.Address{chain.Signer.Address}},
+		}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(payTo common.Address, net types.Network) *types.VerifyRequest {
+		t.Helper()
+		requirements := chain.Requirements(payTo, big.NewInt(1000))
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload.Network, requirements.Network = net, net
+		return &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements}
+	}
+	verify := func(tenant string, request *types.VerifyRequest) *types.VerifyResponse {
+		t.Helper()
+		resp, err := fac.Verify(types.WithTenantID(context.Background(), tenant), request)
+		if err != nil {
+			t.Fatalf("tenant %q: %v", tenant, err)
+		}
+		return resp
+	}
+
+	// Polygon is outside the payouts policy; checkout may try it, and finds
+	// the network unsupported here
+	polygon := request(payTo, types.NetworkPolygon)
+	if resp := verify("payouts", polygon); resp.IsValid || resp.ReasonCode != types.ReasonPolicyViolation {
+		t.Errorf("payouts on polygon: valid %v, reason %q (%s); want %q", resp.IsValid, resp.ReasonCode, resp.Reason, types.ReasonPolicyViolation)
+	}
+	if resp := verify("checkout", polygon); resp.IsValid || resp.ReasonCode == types.ReasonPolicyViolation {
+		t.Errorf("checkout on polygon: valid %v, reason %q; want a refusal outside its policy", resp.IsValid, resp.ReasonCode)
+	}
+	elsewhere := request(common.HexToAddress("0x00000000000000000000000000000000000000b1"), testchain.Network)
+	if resp := verify("payouts", elsewhere); resp.IsValid || resp.ReasonCode != types.ReasonPolicyViolation {
+		t.Errorf("payouts to another payTo: valid %v, reason %q; want %q", resp.IsValid, resp.ReasonCode, types.ReasonPolicyViolation)
+	}
+	if resp := verify("checkout", elsewhere); !resp.IsValid {
+		t.Errorf("checkout to any payTo: refused as %s (%s)", resp.ReasonCode, resp.Reason)
+	}
+
+	// Each tenant settles its own payments, checkout with its reserved signer
+	for _, tenant := range []string{"payouts", "checkout"} {
+		verified := request(payTo, testchain.Network)
+		if resp := verify(tenant, verified); !resp.IsValid {
+			t.Fatalf("%s: refused as %s (%s)", tenant, resp.ReasonCode, resp.Reason)
+		}
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := fac.Settle(types.WithTenantID(context.Background(), tenant), &types.SettleRequest{PaymentPayload: verified.PaymentPayload, PaymentRequirements: verified.PaymentRequirements})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tenant == "payouts" {
+			// The only signer is reserved for checkout
+			if resp.Success {
+				t.Errorf("payouts settled with checkout's signer")
+			}
+			continue
+		}
+		if !resp.Success {
+			t.Fatalf("%s: settle failed: %s (%s)", tenant, resp.Error, resp.ReasonCode)
+		}
+	}
+
+	stats := fac.TenantStats()
+	if got := stats["payouts"]; got.PolicyViolations != 2 || got.Verified != 1 || got.Settled != 0 {
+		t.Errorf("payouts stats %+v, want 2 violations and one verification", got)
+	}
+	if got := stats["checkout"]; got.PolicyViolations != 0 || got.Verified != 2 || got.Settled != 1 {
+		t.Errorf("checkout stats %+v, want 2 verifications and one settlement", got)
+	}
+}
@@ -0,0 +1,56 @@
+package facilitator_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+)
+
+func TestBuilderVerifyOnly(t *testing.T) {
+	chain := newTestChain(t)
+	options := chain.Options()
+	options.Keys = nil
+	if _, err := facilitator.NewBuilder().WithEVMNetwork(testchain.Network, options).Build(); err == nil || !strings.Contains(err.Error(), "no EVM private keys") {
+		t.Errorf("build without keys: error %v", err)
+	}
+
+	fac, err := facilitator.NewBuilder().WithVerifyOnly().WithEVMNetwork(testchain.Network, options).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settles, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct {
+		fac        *facilitator.LocalFacilitator
+		settlement bool
+	}{
+		"verify-only": {fac, false},
+		"full":        {settles, true},
+	} {
+		supported, err := tc.fac.Supported(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(supported.Kinds) != 1 || supported.Kinds[0].Network != testchain.Network || supported.Kinds[0].Settlement != tc.settlement {
+			t.Errorf("%s: supported kinds %+v, want %s with settlement %v", name, supported.Kinds, testchain.Network, tc.settlement)
+		}
+	}
+
+	supported, err := fac.Supported(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(supported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `"settlement":false`) {
+		t.Errorf("/supported %s does not flag the kind as verify-only", raw)
+	}
+}
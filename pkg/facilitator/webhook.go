@@ -0,0 +1,77 @@
+package facilitator
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Headers of settlement event webhooks
+const (
+	HeaderEventID        = "X-Event-ID"
+	HeaderEventType      = "X-Event-Type"
+	HeaderEventSignature = "X-Event-Signature" // sha256=<hex HMAC-SHA256 of the body>
+)
+
+// WebhookPublisher POSTs each settlement event as JSON to a URL
+// Any answer but a 2xx is an error, so the event is retried.
+type WebhookPublisher struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookPublisher creates a publisher posting to rawURL; with a secret,
+// each request carries an HMAC-SHA256 of its body in HeaderEventSignature.
+// A nil client uses http.DefaultClient (requests are bounded by the
+// publish context).
+func NewWebhookPublisher(rawURL, secret string, client *http.Client) (*WebhookPublisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q (want an http(s) URL)", rawURL)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookPublisher{url: rawURL, secret: []byte(secret), client: client}, nil
+}
+
+// Publish implements EventPublisher
+func (p *WebhookPublisher) Publish(ctx context.Context, event SettlementEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal settlement event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, event.ID)
+	req.Header.Set(HeaderEventType, string(event.Type))
+	if len(p.secret) > 0 {
+		mac := hmac.New(sha256.New, p.secret)
+		mac.Write(body)
+		req.Header.Set(HeaderEventSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return nil
+}
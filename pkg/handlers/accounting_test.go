@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/accounting"
+)
+
+func TestGasAccountingHandler(t *testing.T) {
+	ledger := accounting.NewGasLedger(nil)
+	for _, day := range []string{"2026-03-01", "2026-03-02", "2026-03-03"} {
+		ts, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, payTo := range []string{"0x00000000000000000000000000000000000000b0", "0x00000000000000000000000000000000000000b1"} {
+			ledger.Record(context.Background(), accounting.GasRecord{PayTo: payTo, Network: "base", GasUsed: 50000, EffectiveGasPrice: big.NewInt(1_000_000_000), Time: ts.Add(12 * time.Hour)})
+		}
+	}
+	h := NewHandler(nil)
+	h.SetGasLedger(ledger)
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.GasAccountingHandler(rec, httptest.NewRequest(http.MethodGet, "/accounting/gas"+query, nil))
+		return rec
+	}
+
+	rec := get("?payTo=0x00000000000000000000000000000000000000B0&from=2026-03-02&to=2026-03-03")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Totals []accounting.GasTotal `json:"totals"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Totals) != 2 || body.Totals[0].Date != "2026-03-02" || body.Totals[1].Date != "2026-03-03" {
+		t.Fatalf("totals %+v, want 2 and 3 March", body.Totals)
+	}
+	for _, total := range body.Totals {
+		if total.PayTo != "0x00000000000000000000000000000000000000b0" || total.GasUsed != 50000 || total.FeeNative != "50000000000000" {
+			t.Errorf("total %+v", total)
+		}
+	}
+
+	if rec := get(""); rec.Code != http.StatusOK {
+		t.Errorf("unfiltered: status %d", rec.Code)
+	}
+	for _, query := range []string{"?from=yesterday", "?to=2026-13-01"} {
+		if rec := get(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+	rec = httptest.NewRecorder()
+	NewHandler(nil).GasAccountingHandler(rec, httptest.NewRequest(http.MethodGet, "/accounting/gas", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without a ledger: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
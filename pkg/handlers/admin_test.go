@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/middleware"
+)
+
+func TestAdminNetworksDisableAndEnable(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(fac).SetupRoutes(mux)
+	server := middleware.AdminAuthMiddleware("admin-secret")(mux)
+	call := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+	disabled := func() map[string]string {
+		rec := call(http.MethodGet, "/admin/networks", "admin-secret")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /admin/networks: status %d: %s", rec.Code, rec.Body.String())
+		}
+		var body struct {
+			Disabled map[string]string `json:"disabled"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body.Disabled
+	}
+
+	disable := "/admin/networks/" + string(testchain.Network) + "/disable"
+	if rec := call(http.MethodPost, disable, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("disable without the token: status %d, want 401", rec.Code)
+	}
+	if rec := call(http.MethodPost, disable, "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("disable with a wrong token: status %d, want 401", rec.Code)
+	}
+	if len(disabled()) != 0 {
+		t.Fatalf("networks disabled before any admin call: %v", disabled())
+	}
+
+	if rec := call(http.MethodPost, disable, "admin-secret"); rec.Code != http.StatusOK {
+		t.Fatalf("disable: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, ok := disabled()[string(testchain.Network)]; !ok {
+		t.Errorf("disabled networks %v, want %s", disabled(), testchain.Network)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/verify", nil))
+	var supported struct {
+		Kinds []struct {
+			Network string `json:"network"`
+		} `json:"kinds"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &supported); err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range supported.Kinds {
+		if kind.Network == string(testchain.Network) {
+			t.Errorf("/supported still lists the disabled %s", kind.Network)
+		}
+	}
+
+	if rec := call(http.MethodPost, "/admin/networks/"+string(testchain.Network)+"/enable", "admin-secret"); rec.Code != http.StatusOK {
+		t.Fatalf("enable: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(disabled()) != 0 {
+		t.Errorf("networks still disabled after enabling: %v", disabled())
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodPost, "/admin/networks/base/disable", http.StatusBadRequest},
+		{http.MethodPost, "/admin/networks/" + string(testchain.Network) + "/pause", http.StatusNotFound},
+		{http.MethodGet, disable, http.StatusMethodNotAllowed},
+		{http.MethodPost, "/admin/networks", http.StatusMethodNotAllowed},
+	} {
+		if rec := call(tc.method, tc.path, "admin-secret"); rec.Code != tc.status {
+			t.Errorf("%s %s: status %d, want %d", tc.method, tc.path, rec.Code, tc.status)
+		}
+	}
+}
+
+func TestAdminNetworksNeedsSwitchableFacilitator(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(nil).SetupRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/networks", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404 when networks cannot be disabled", rec.Code)
+	}
+}
+
+func TestStatsIncludeLatencyPercentiles(t *testing.T) {
+	stats := func(h *Handler) (int, map[string]json.RawMessage) {
+		mux := http.NewServeMux()
+		h.SetupRoutes(mux)
+		tracker := h.latency
+		var server http.Handler = mux
+		if tracker != nil {
+			server = middleware.LatencyMiddleware(tracker)(mux)
+			server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/verify", nil))
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/stats", nil))
+		var body map[string]json.RawMessage
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	h := NewHandler(facilitator.NewLocalFacilitator())
+	if code, body := stats(h); code != http.StatusOK || body["latency"] != nil {
+		t.Errorf("without a tracker: status %d, latency %s; want facilitator stats only", code, body["latency"])
+	}
+	h.SetLatencyTracker(middleware.NewLatencyTracker(0))
+	code, body := stats(h)
+	if code != http.StatusOK || len(body) < 2 {
+		t.Fatalf("with a tracker: status %d, body %v; want facilitator stats and latency", code, body)
+	}
+	var latency middleware.LatencySnapshot
+	if err := json.Unmarshal(body["latency"], &latency); err != nil {
+		t.Fatal(err)
+	}
+	if got := latency.Routes["/verify"]; latency.WindowSeconds != 300 || got.Count != 1 || got.Status["2xx"] != 1 {
+		t.Errorf("latency %+v, want the /verify request over five minutes", latency)
+	}
+
+	// A facilitator without stats still reports latency
+	bare := NewHandler(nil)
+	bare.SetLatencyTracker(middleware.NewLatencyTracker(0))
+	if code, body := stats(bare); code != http.StatusOK || len(body) != 1 || body["latency"] == nil {
+		t.Errorf("tracker only: status %d, body %v; want only latency", code, body)
+	}
+	if code, _ := stats(NewHandler(nil)); code != http.StatusNotFound {
+		t.Errorf("neither: status %d, want 404", code)
+	}
+}
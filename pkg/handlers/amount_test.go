@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// malformedAmounts are amounts neither side of a payment may carry
+var malformedAmounts = []string{
+	"-1",
+	"0",
+	"00",
+	"1e6",
+	"0x3e8",
+	"115792089237316195423570985008687907853269984665640564039457584007913129639936", // 2^256
+}
+
+func TestVerifyAndSettleRejectMalformedAmounts(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(fac).SetupRoutes(mux)
+	post := func(path string, body interface{}) []byte {
+		t.Helper()
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST %s: status %d: %s", path, rec.Code, rec.Body.String())
+		}
+		return rec.Body.Bytes()
+	}
+
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setters := map[string]func(*types.VerifyRequest, string){
+		"maxAmountRequired":   func(r *types.VerifyRequest, amount string) { r.PaymentRequirements.MaxAmountRequired = amount },
+		"authorization value": func(r *types.VerifyRequest, amount string) { r.PaymentPayload.Payload.Authorization.Value = amount },
+	}
+	for _, amount := range malformedAmounts {
+		for field, set := range setters {
+			request := types.VerifyRequest{X402Version: 1, PaymentPayload: *payload, PaymentRequirements: requirements}
+			set(&request, amount)
+
+			var verified types.VerifyResponse
+			if err := json.Unmarshal(post("/verify", request), &verified); err != nil {
+				t.Fatal(err)
+			}
+			if verified.IsValid || !strings.Contains(verified.Reason, field) {
+				t.Errorf("verify with %s %q: valid %v, reason %q; want the %[1]s rejected", field, amount, verified.IsValid, verified.Reason)
+			}
+			var settled types.SettleResponse
+			if err := json.Unmarshal(post("/settle", types.SettleRequest{PaymentPayload: request.PaymentPayload, PaymentRequirements: request.PaymentRequirements}), &settled); err != nil {
+				t.Fatal(err)
+			}
+			if settled.Success || settled.TransactionHash != nil {
+				t.Errorf("settle with %s %q: %+v, want it refused before submission", field, amount, settled)
+			}
+		}
+	}
+	nonce, err := payload.Payload.Authorization.Nonce.Nonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used, err := chain.AuthorizationUsed(chain.Accounts[0].Address, nonce); err != nil || used {
+		t.Errorf("authorization used %v (%v); no malformed payment should reach the chain", used, err)
+	}
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// decodeVerifyRequest parses a v1 or v2 verify body into the internal format
+// Unknown fields are ignored unless strict; missing or mistyped required
+// fields are reported as a *requestError
+func decodeVerifyRequest(body io.Reader, strict bool) (*types.VerifyRequest, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	version := types.DetectX402Version(data)
+	if err := validateRequest(data, version, false); err != nil {
+		return nil, err
+	}
+	if version == 2 {
+		return decodeV2(data, strict)
+	}
+
+	var req types.VerifyRequest
+	if err := decodeJSON(data, &req, strict); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// decodeSettleRequest parses a v1 or v2 settle body into the internal format
+// (see decodeVerifyRequest)
+func decodeSettleRequest(body io.Reader, strict bool) (*types.SettleRequest, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	version := types.DetectX402Version(data)
+	if err := validateRequest(data, version, true); err != nil {
+		return nil, err
+	}
+	if version == 2 {
+		req, err := decodeV2(data, strict)
+		if err != nil {
+			return nil, err
+		}
+		var settle struct {
+			VerificationID         string `json:"verificationId"`
+			InclusionMarginSeconds *int64 `json:"inclusionMarginSeconds"`
+		}
+		json.Unmarshal(data, &settle)
+		return &types.SettleRequest{
+			PaymentPayload:         req.PaymentPayload,
+			PaymentRequirements:    req.PaymentRequirements,
+			VerificationID:         settle.VerificationID,
+			InclusionMarginSeconds: settle.InclusionMarginSeconds,
+		}, nil
+	}
+
+	var req types.SettleRequest
+	if err := decodeJSON(data, &req, strict); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// decodeCancelRequest parses a cancel body
+func decodeCancelRequest(body io.Reader, strict bool) (*types.CancelRequest, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	var req types.CancelRequest
+	if err := decodeJSON(data, &req, strict); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// decodeV2 parses a v2 request body and translates it to the internal format
+func decodeV2(data []byte, strict bool) (*types.VerifyRequest, error) {
+	var req types.VerifyRequestV2
+	if err := decodeJSON(data, &req, strict); err != nil {
+		return nil, err
+	}
+	if req.PaymentPayload.X402Version == 0 {
+		req.PaymentPayload.X402Version = 2
+	}
+	return req.ToV1()
+}
+
+// decodeJSON decodes data into v, rejecting unknown fields when strict;
+// type mismatches the schema check missed come back as a *requestError
+func decodeJSON(data []byte, v interface{}, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	err := dec.Decode(v)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &requestError{Fields: []fieldError{{
+			Field:    typeErr.Field,
+			Expected: typeErr.Type.String(),
+			Got:      typeErr.Value,
+		}}}
+	}
+	return err
+}
+
+// fieldError describes one missing or mistyped request field
+type fieldError struct {
+	Field    string `json:"field"` // Dotted path, e.g. paymentPayload.payload.signature
+	Expected string `json:"expected"`
+	Got      string `json:"got"` // "missing", the JSON type found, or what is wrong with a hex value
+}
+
+// requestError is a request body that does not match the request schema
+type requestError struct {
+	Fields []fieldError
+}
+
+func (e *requestError) Error() string {
+	problems := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		if field.Got == "missing" {
+			problems[i] = fmt.Sprintf("%s is missing (expected %s)", field.Field, field.Expected)
+		} else {
+			problems[i] = fmt.Sprintf("%s must be %s, got %s", field.Field, field.Expected, field.Got)
+		}
+	}
+	return strings.Join(problems, "; ")
+}
+
+// fieldSpec is one field of the request schema
+type fieldSpec struct {
+	name     string
+	kind     string // JSON type: string, number, boolean or object
+	required bool
+	hex      bool        // A string of hex bytes (see types.SetStrictHex)
+	fields   []fieldSpec // For objects
+}
+
+// authorizationSpec is the EIP-3009 authorization shared by v1 and v2
+var authorizationSpec = fieldSpec{name: "authorization", kind: "object", required: true, fields: []fieldSpec{
+	{name: "from", kind: "string", required: true},
+	{name: "to", kind: "string", required: true},
+	{name: "value", kind: "string", required: true},
+	{name: "validAfter", kind: "string", required: true},
+	{name: "validBefore", kind: "string", required: true},
+	{name: "nonce", kind: "string", required: true, hex: true},
+}}
+
+var evmPayloadSpec = fieldSpec{name: "payload", kind: "object", required: true, fields: []fieldSpec{
+	{name: "signature", kind: "string", required: true, hex: true},
+	authorizationSpec,
+}}
+
+// Request schemas: only what decoding and verification rely on; anything
+// else (newer optional fields included) is left alone
+var (
+	requirementsSpecV1 = []fieldSpec{
+		{name: "scheme", kind: "string", required: true},
+		{name: "network", kind: "string", required: true},
+		{name: "payTo", kind: "string", required: true},
+		{name: "maxAmountRequired", kind: "string", required: true},
+		{name: "asset", kind: "string"},
+		{name: "resource", kind: "string"},
+		{name: "description", kind: "string"},
+		{name: "mimeType", kind: "string"},
+		{name: "maxTimeoutSeconds", kind: "number"},
+		{name: "minTimeoutSeconds", kind: "number"},
+	}
+	requestSpecV1 = []fieldSpec{
+		{name: "x402Version", kind: "number"},
+		{name: "paymentPayload", kind: "object", required: true, fields: []fieldSpec{
+			{name: "x402Version", kind: "number"},
+			{name: "scheme", kind: "string", required: true},
+			{name: "network", kind: "string", required: true},
+			evmPayloadSpec,
+		}},
+		{name: "paymentRequirements", kind: "object", required: true, fields: requirementsSpecV1},
+	}
+
+	requirementsSpecV2 = []fieldSpec{
+		{name: "scheme", kind: "string", required: true},
+		{name: "network", kind: "string", required: true},
+		{name: "amount", kind: "string", required: true},
+		{name: "asset", kind: "string", required: true},
+		{name: "payTo", kind: "string", required: true},
+		{name: "maxTimeoutSeconds", kind: "number"},
+		{name: "minTimeoutSeconds", kind: "number"},
+	}
+	requestSpecV2 = []fieldSpec{
+		{name: "x402Version", kind: "number"},
+		{name: "paymentPayload", kind: "object", required: true, fields: []fieldSpec{
+			{name: "x402Version", kind: "number"},
+			{name: "resource", kind: "object", fields: []fieldSpec{
+				{name: "url", kind: "string", required: true},
+				{name: "description", kind: "string"},
+				{name: "mimeType", kind: "string"},
+			}},
+			{name: "accepted", kind: "object", required: true, fields: requirementsSpecV2},
+			evmPayloadSpec,
+		}},
+		{name: "paymentRequirements", kind: "object", required: true, fields: requirementsSpecV2},
+	}
+
+	settleSpecs = []fieldSpec{
+		{name: "verificationId", kind: "string"},
+		{name: "inclusionMarginSeconds", kind: "number"},
+	}
+)
+
+// validateRequest checks a request body against the schema for its version
+func validateRequest(data []byte, version int, settle bool) error {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	specs := requestSpecV1
+	if version == 2 {
+		specs = requestSpecV2
+	}
+	if settle {
+		specs = append(specs[:len(specs):len(specs)], settleSpecs...)
+	}
+
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return &requestError{Fields: []fieldError{{Field: "(body)", Expected: "object", Got: jsonKind(doc)}}}
+	}
+	var errs []fieldError
+	checkFields("", obj, specs, &errs)
+	if len(errs) > 0 {
+		return &requestError{Fields: errs}
+	}
+	return nil
+}
+
+// checkFields appends the fields of obj that violate specs to errs
+func checkFields(prefix string, obj map[string]interface{}, specs []fieldSpec, errs *[]fieldError) {
+	for _, spec := range specs {
+		path := prefix + spec.name
+		value, present := obj[spec.name]
+		if !present || value == nil {
+			if spec.required {
+				*errs = append(*errs, fieldError{Field: path, Expected: spec.kind, Got: "missing"})
+			}
+			continue
+		}
+		if kind := jsonKind(value); kind != spec.kind {
+			*errs = append(*errs, fieldError{Field: path, Expected: spec.kind, Got: kind})
+			continue
+		}
+		if spec.hex {
+			var hexErr *types.HexError
+			if _, _, err := types.ParseHex(value.(string), types.StrictHex()); errors.As(err, &hexErr) {
+				*errs = append(*errs, fieldError{Field: path, Expected: "0x-prefixed hex", Got: hexErr.Reason})
+				continue
+			}
+		}
+		if spec.fields != nil {
+			checkFields(path+".", value.(map[string]interface{}), spec.fields, errs)
+		}
+	}
+}
+
+// jsonKind names the JSON type of a decoded value
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
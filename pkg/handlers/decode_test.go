@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// decodeFields fill the request templates with well-formed placeholder values
+var decodeFields = fixtureFields{
+	Network:     "base-sepolia",
+	Asset:       "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+	PayTo:       "0x00000000000000000000000000000000000000b0",
+	Amount:      "1000",
+	Signature:   "0x" + strings.Repeat("ab", 65),
+	From:        "0x00000000000000000000000000000000000000a0",
+	To:          "0x00000000000000000000000000000000000000b0",
+	Value:       "1000",
+	ValidAfter:  "0",
+	ValidBefore: "9999999999",
+	Nonce:       "0x" + strings.Repeat("11", 32),
+}
+
+// editPath returns body with the field at the dotted path set to value,
+// or removed when value is nil
+func editPath(t *testing.T, body []byte, path string, value interface{}) []byte {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatal(err)
+	}
+	keys := strings.Split(path, ".")
+	obj := doc
+	for _, key := range keys[:len(keys)-1] {
+		obj = obj[key].(map[string]interface{})
+	}
+	if value == nil {
+		delete(obj, keys[len(keys)-1])
+	} else {
+		obj[keys[len(keys)-1]] = value
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeVerifyRequestReportsFields(t *testing.T) {
+	v1 := fixtureRequest(t, "verify_v1.json", decodeFields)
+	v2 := fixtureRequest(t, "verify_v2.json", decodeFields)
+	for _, tc := range []struct {
+		name   string
+		body   []byte
+		fields []fieldError // Nil when the request decodes
+	}{
+		{"v1 as rendered", v1, nil},
+		{"v2 as rendered", v2, nil},
+		{"unknown top-level field", editPath(t, v1, "resourceId", "abc"), nil},
+		{"unknown payload field", editPath(t, v1, "paymentPayload.extra", map[string]interface{}{"quote": "q1"}), nil},
+		{"unknown v2 requirements field", editPath(t, v2, "paymentRequirements.extra", map[string]interface{}{"name": "USDC"}), nil},
+		{
+			"missing signature", editPath(t, v1, "paymentPayload.payload.signature", nil),
+			[]fieldError{{Field: "paymentPayload.payload.signature", Expected: "string", Got: "missing"}},
+		},
+		{
+			"missing v2 signature", editPath(t, v2, "paymentPayload.payload.signature", nil),
+			[]fieldError{{Field: "paymentPayload.payload.signature", Expected: "string", Got: "missing"}},
+		},
+		{
+			"string maxTimeoutSeconds", editPath(t, v1, "paymentRequirements.maxTimeoutSeconds", "300"),
+			[]fieldError{{Field: "paymentRequirements.maxTimeoutSeconds", Expected: "number", Got: "string"}},
+		},
+		{
+			"numeric amount and missing network", editPath(t, editPath(t, v1, "paymentRequirements.maxAmountRequired", 1000), "paymentPayload.network", nil),
+			[]fieldError{
+				{Field: "paymentPayload.network", Expected: "string", Got: "missing"},
+				{Field: "paymentRequirements.maxAmountRequired", Expected: "string", Got: "number"},
+			},
+		},
+		{
+			"authorization as a string", editPath(t, v1, "paymentPayload.payload.authorization", "signed"),
+			[]fieldError{{Field: "paymentPayload.payload.authorization", Expected: "object", Got: "string"}},
+		},
+		{
+			"body not an object", []byte(`["verify"]`),
+			[]fieldError{{Field: "(body)", Expected: "object", Got: "array"}},
+		},
+	} {
+		_, err := decodeVerifyRequest(bytes.NewReader(tc.body), false)
+		if tc.fields == nil {
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			}
+			continue
+		}
+		var reqErr *requestError
+		if !errors.As(err, &reqErr) {
+			t.Errorf("%s: error %v, want a field report", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(reqErr.Fields, tc.fields) {
+			t.Errorf("%s: fields %+v, want %+v", tc.name, reqErr.Fields, tc.fields)
+		}
+	}
+}
+
+func TestDecodeStrictRejectsUnknownFields(t *testing.T) {
+	body := editPath(t, fixtureRequest(t, "verify_v1.json", decodeFields), "resourceId", "abc")
+	if _, err := decodeVerifyRequest(bytes.NewReader(body), true); err == nil || !strings.Contains(err.Error(), "resourceId") {
+		t.Errorf("strict decoding: error %v, want the unknown field named", err)
+	}
+	if _, err := decodeSettleRequest(bytes.NewReader(body), true); err == nil {
+		t.Error("strict settle decoding accepted an unknown field")
+	}
+	if _, err := decodeSettleRequest(bytes.NewReader(body), false); err != nil {
+		t.Errorf("lenient settle decoding: %v", err)
+	}
+}
+
+func TestVerifyHandlerListsBadFields(t *testing.T) {
+	handler := NewHandler(nil)
+	body := editPath(t, fixtureRequest(t, "verify_v1.json", decodeFields), "paymentPayload.payload.signature", nil)
+	rec := httptest.NewRecorder()
+	handler.VerifyHandler(rec, httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	var resp struct {
+		Error  string       `json:"error"`
+		Fields []fieldError `json:"fields"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := []fieldError{{Field: "paymentPayload.payload.signature", Expected: "string", Got: "missing"}}
+	if !reflect.DeepEqual(resp.Fields, want) || !strings.Contains(resp.Error, "paymentPayload.payload.signature is missing") {
+		t.Errorf("400 body %+v, want the missing signature listed", resp)
+	}
+}
+
+func TestDecodeReportsMalformedHex(t *testing.T) {
+	v1 := fixtureRequest(t, "verify_v1.json", decodeFields)
+	signature := "paymentPayload.payload.signature"
+	nonce := "paymentPayload.payload.authorization.nonce"
+	for _, tc := range []struct {
+		name, path, value string
+		lenient, strict   string // Got of the field error; "" when accepted
+	}{
+		{"unprefixed signature", signature, strings.Repeat("ab", 65), "", "missing 0x prefix"},
+		{"uppercase prefix", nonce, "0X" + strings.Repeat("11", 32), "", "missing 0x prefix"},
+		{"padded nonce", nonce, " 0x" + strings.Repeat("11", 32) + " ", "", "surrounding whitespace"},
+		{"uppercase digits", signature, "0x" + strings.Repeat("AB", 65), "", ""},
+		{"odd signature", signature, "0x" + strings.Repeat("ab", 65)[1:], "odd number of hex digits", "odd number of hex digits"},
+		{"non-hex nonce", nonce, "0x" + strings.Repeat("zz", 32), "not hex", "not hex"},
+	} {
+		body := editPath(t, v1, tc.path, tc.value)
+		for strict, got := range map[bool]string{false: tc.lenient, true: tc.strict} {
+			types.SetStrictHex(strict)
+			_, err := decodeVerifyRequest(bytes.NewReader(body), false)
+			types.SetStrictHex(false)
+			if got == "" {
+				if err != nil {
+					t.Errorf("%s (strict %v): %v", tc.name, strict, err)
+				}
+				continue
+			}
+			var reqErr *requestError
+			want := []fieldError{{Field: tc.path, Expected: "0x-prefixed hex", Got: got}}
+			if !errors.As(err, &reqErr) || !reflect.DeepEqual(reqErr.Fields, want) {
+				t.Errorf("%s (strict %v): error %v, want fields %+v", tc.name, strict, err, want)
+			}
+		}
+	}
+}
+
+func TestDecodeSettleInclusionMargin(t *testing.T) {
+	for _, fixture := range []string{"verify_v1.json", "verify_v2.json"} {
+		body := fixtureRequest(t, fixture, decodeFields)
+		decoded, err := decodeSettleRequest(bytes.NewReader(body), false)
+		if err != nil || decoded.InclusionMarginSeconds != nil {
+			t.Errorf("%s without a margin: %+v (%v), want none", fixture, decoded, err)
+		}
+
+		decoded, err = decodeSettleRequest(bytes.NewReader(withField(t, body, "inclusionMarginSeconds", 0)), false)
+		if err != nil || decoded.InclusionMarginSeconds == nil || *decoded.InclusionMarginSeconds != 0 {
+			t.Errorf("%s with a zero margin: %+v (%v), want it kept apart from none", fixture, decoded, err)
+		}
+
+		_, err = decodeSettleRequest(bytes.NewReader(withField(t, body, "inclusionMarginSeconds", "30s")), false)
+		var reqErr *requestError
+		if !errors.As(err, &reqErr) || len(reqErr.Fields) != 1 || reqErr.Fields[0].Field != "inclusionMarginSeconds" {
+			t.Errorf("%s with a string margin: error %v, want the field reported", fixture, err)
+		}
+	}
+	if _, err := decodeVerifyRequest(bytes.NewReader(withField(t, fixtureRequest(t, "verify_v1.json", decodeFields), "inclusionMarginSeconds", 0)), true); err == nil {
+		t.Error("strict verify decoding accepted a settle-only field")
+	}
+}
+
+func TestDecodeMinTimeoutSeconds(t *testing.T) {
+	for _, fixture := range []string{"verify_v1.json", "verify_v2.json"} {
+		body := fixtureRequest(t, fixture, decodeFields)
+		decoded, err := decodeVerifyRequest(bytes.NewReader(editPath(t, body, "paymentRequirements.minTimeoutSeconds", 90)), true)
+		if err != nil || decoded.PaymentRequirements.MinTimeoutSeconds != 90 {
+			t.Errorf("%s: %+v (%v), want a 90 second minimum", fixture, decoded, err)
+		}
+
+		_, err = decodeVerifyRequest(bytes.NewReader(editPath(t, body, "paymentRequirements.minTimeoutSeconds", "90")), false)
+		var reqErr *requestError
+		if !errors.As(err, &reqErr) || len(reqErr.Fields) != 1 || reqErr.Fields[0].Field != "paymentRequirements.minTimeoutSeconds" {
+			t.Errorf("%s with a string minimum: error %v, want the field reported", fixture, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestDevFundStatuses(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := facilitator.NewBuilder().WithEVMNetwork(types.NetworkBaseSepolia, chain.Options()).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fund := func(h *Handler, body string) int {
+		mux := http.NewServeMux()
+		h.SetupRoutes(mux)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/dev/fund", strings.NewReader(body)))
+		return rec.Code
+	}
+	request := `{"address":"0x00000000000000000000000000000000000000f1","network":"base-sepolia"}`
+
+	if code := fund(NewHandler(fac), request); code != http.StatusNotFound {
+		t.Errorf("outside development mode: status %d, want %d", code, http.StatusNotFound)
+	}
+
+	h := NewHandler(fac)
+	h.SetFaucet(facilitator.NewFaucet(fac, nil, 1))
+	for _, tc := range []struct {
+		name string
+		body string
+		code int
+	}{
+		{"first grant", request, http.StatusOK},
+		{"over the daily cap", request, http.StatusTooManyRequests},
+		{"mainnet", `{"address":"0x00000000000000000000000000000000000000f1","network":"base"}`, http.StatusForbidden},
+		{"malformed body", `{"address":`, http.StatusBadRequest},
+	} {
+		if code := fund(h, tc.body); code != tc.code {
+			t.Errorf("%s: status %d, want %d", tc.name, code, tc.code)
+		}
+	}
+}
@@ -1,18 +1,35 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/accounting"
 	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/middleware"
 	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/version"
 )
 
 // Handler manages HTTP handlers for the facilitator
 type Handler struct {
 	facilitator facilitator.Facilitator
+	gasLedger   *accounting.GasLedger         // nil disables /accounting/gas
+	journal     *accounting.SettlementJournal // nil disables /accounting/settlements
+	strict      bool                          // Reject unknown request fields
+	faucet      *facilitator.Faucet           // nil disables /dev/fund
+	latency     *middleware.LatencyTracker    // nil leaves latency out of /admin/stats
+	docs        bool                          // Serve /docs
+	mounted     map[string]string             // Mounted path by default path (see SetupRoutesWithConfig)
+	patterns    map[string]route              // Route by the mux pattern it is mounted at (see SettleRoutes)
 }
 
 // NewHandler creates a new HTTP handler
@@ -22,6 +39,39 @@ func NewHandler(fac facilitator.Facilitator) *Handler {
 	}
 }
 
+// SetGasLedger enables GET /accounting/gas backed by ledger
+func (h *Handler) SetGasLedger(ledger *accounting.GasLedger) {
+	h.gasLedger = ledger
+}
+
+// SetJournal records every settlement attempt in journal and enables
+// GET /accounting/settlements
+func (h *Handler) SetJournal(journal *accounting.SettlementJournal) {
+	h.journal = journal
+}
+
+// SetFaucet enables POST /dev/fund backed by faucet (development mode only)
+func (h *Handler) SetFaucet(faucet *facilitator.Faucet) {
+	h.faucet = faucet
+}
+
+// SetDocs enables GET /docs, Swagger UI for /openapi.json (development mode only)
+func (h *Handler) SetDocs(enabled bool) {
+	h.docs = enabled
+}
+
+// SetLatencyTracker adds the tracker's per-route latency percentiles to
+// GET /admin/stats (wrap the routes in middleware.LatencyMiddleware to feed it)
+func (h *Handler) SetLatencyTracker(tracker *middleware.LatencyTracker) {
+	h.latency = tracker
+}
+
+// SetStrictDecoding rejects request bodies with unknown fields, as earlier
+// releases did; by default they are ignored
+func (h *Handler) SetStrictDecoding(strict bool) {
+	h.strict = strict
+}
+
 // VerifyHandler handles /verify requests
 func (h *Handler) VerifyHandler(w http.ResponseWriter, r *http.Request) {
 	// GET returns endpoint information
@@ -35,34 +85,45 @@ func (h *Handler) VerifyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse request (fail on unknown/misnamed fields)
-	var req types.VerifyRequest
-	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields()
-	if err := dec.Decode(&req); err != nil {
-		// Check if error is due to request size limit
-		if errors.Is(err, errors.New("http: request body too large")) ||
-		   (err.Error() == "http: request body too large") {
-			respondError(w, http.StatusRequestEntityTooLarge, "request body exceeds maximum allowed size (1MB)")
-			return
-		}
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+	// Parse request (fail on missing or mistyped fields)
+	req, err := decodeVerifyRequest(r.Body, h.strict)
+	if err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	settlementID, err := requestSettlementID(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Verify payment
-	resp, err := h.facilitator.Verify(r.Context(), &req)
+	resp, err := h.facilitator.Verify(types.WithSettlementID(r.Context(), settlementID), req)
 	if err != nil {
 		// Protocol-level errors return 200 with invalid response
 		if facErr, ok := err.(*types.FacilitatorError); ok {
-			respondJSON(w, http.StatusOK, types.NewInvalidResponse(facErr.Message, facErr.Payer))
+			if status := unavailableStatus(facErr); status != 0 {
+				respondError(w, status, facErr.Message)
+				return
+			}
+			response := types.NewInvalidResponseFromError(facErr)
+			if response.Payer == nil {
+				response.Payer = types.PayerFromPayload(&req.PaymentPayload)
+			}
+			response.SettlementID = settlementID
+			respondJSON(w, http.StatusOK, response)
 			return
 		}
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("verification failed: %v", err))
 		return
 	}
 
-	respondJSON(w, http.StatusOK, resp)
+	// Copied, as the facilitator may share a response between requests
+	response := *resp
+	if response.SettlementID == "" {
+		response.SettlementID = settlementID
+	}
+	respondJSON(w, http.StatusOK, response)
 }
 
 // SettleHandler handles /settle requests
@@ -79,44 +140,217 @@ func (h *Handler) SettleHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse request
-	var req types.SettleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		// Check if error is due to request size limit
-		if errors.Is(err, errors.New("http: request body too large")) ||
-		   (err.Error() == "http: request body too large") {
-			respondError(w, http.StatusRequestEntityTooLarge, "request body exceeds maximum allowed size (1MB)")
-			return
-		}
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+	req, err := decodeSettleRequest(r.Body, h.strict)
+	if err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+	settlementID, err := requestSettlementID(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	r = r.WithContext(types.WithSettlementID(r.Context(), settlementID))
 
-	// Settle payment
-	resp, err := h.facilitator.Settle(r.Context(), &req)
+	// Settle payment; the facilitator updates the same journal entry as
+	// the transaction progresses
+	journalID := accounting.NewJournalID()
+	ctx := accounting.WithJournalID(r.Context(), journalID)
+	resp, err := h.facilitator.Settle(ctx, req)
 	if err != nil {
 		// Protocol-level errors return 200 with error in response
 		if facErr, ok := err.(*types.FacilitatorError); ok {
+			h.recordSettlement(r, journalID, req, &types.SettleResponse{ReasonCode: facErr.Code})
+			if status := unavailableStatus(facErr); status != 0 {
+				respondError(w, status, facErr.Message)
+				return
+			}
 			respondJSON(w, http.StatusOK, types.SettleResponse{
-				Success: false,
-				Error:   facErr.Message,
+				Success:      false,
+				Error:        facErr.Message,
+				ReasonCode:   facErr.Code,
+				SettlementID: settlementID,
 			})
 			return
 		}
+		h.recordSettlement(r, journalID, req, &types.SettleResponse{})
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("settlement failed: %v", err))
 		return
 	}
 
+	h.recordSettlement(r, journalID, req, resp)
+	response := *resp
+	if response.SettlementID == "" {
+		response.SettlementID = settlementID
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// requestSettlementID returns the settlement ID of a /verify or /settle
+// request: the caller's X-Payment-Idempotency-Key, else a fresh one
+func requestSettlementID(r *http.Request) (string, error) {
+	id := r.Header.Get(types.HeaderIdempotencyKey)
+	if id == "" {
+		return types.NewSettlementID(), nil
+	}
+	if err := types.ValidateSettlementID(id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// recordSettlement adds a settlement attempt to the journal, attributed to
+// the API key and client certificate that authenticated the request (see
+// middleware.AuthMiddleware and middleware.ClientCertMiddleware)
+func (h *Handler) recordSettlement(r *http.Request, journalID string, req *types.SettleRequest, resp *types.SettleResponse) {
+	if h.journal == nil {
+		return
+	}
+	status := accounting.JournalFailed
+	switch {
+	case resp.Success:
+		status = accounting.JournalConfirmed
+	case resp.ReasonCode == types.ReasonTimeout:
+		status = accounting.JournalUnknown
+	}
+	auth := req.PaymentPayload.Payload.Authorization
+	entry := accounting.JournalEntry{
+		ID:             journalID,
+		Status:         status,
+		Network:        req.PaymentPayload.Network,
+		Payer:          auth.From.Hex(),
+		PayTo:          auth.To.Hex(),
+		Amount:         auth.Value,
+		FacilitatorFee: req.PaymentRequirements.FacilitatorFee(),
+		Nonce:          auth.Nonce.String(),
+		ReasonCode:     string(resp.ReasonCode),
+		APIKey:         middleware.APIKeyLabel(r.Context()),
+		ClientCert:     middleware.ClientCertIdentity(r.Context()),
+		Tenant:         types.TenantIDFromContext(r.Context()),
+		SettlementID:   types.SettlementIDFromContext(r.Context()),
+	}
+	if resp.TransactionHash != nil {
+		entry.TransactionHash = resp.TransactionHash.Hash
+	}
+	h.journal.Record(entry)
+}
+
+// SimulateHandler handles /settle/simulate requests
+func (h *Handler) SimulateHandler(w http.ResponseWriter, r *http.Request) {
+	// GET returns endpoint information
+	if r.Method == http.MethodGet {
+		h.getSimulateInfo(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Parse request
+	req, err := decodeSettleRequest(r.Body, h.strict)
+	if err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+
+	// Simulate settlement
+	resp, err := h.facilitator.Simulate(r.Context(), req)
+	if err != nil {
+		// Protocol-level errors return 200 with invalid response
+		if facErr, ok := err.(*types.FacilitatorError); ok {
+			if status := unavailableStatus(facErr); status != 0 {
+				respondError(w, status, facErr.Message)
+				return
+			}
+			payer := facErr.Payer
+			if payer == nil {
+				payer = types.PayerFromPayload(&req.PaymentPayload)
+			}
+			respondJSON(w, http.StatusOK, types.SimulateResponse{
+				Valid:      false,
+				Reason:     facErr.Message,
+				ReasonCode: facErr.Code,
+				Payer:      payer,
+			})
+			return
+		}
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("simulation failed: %v", err))
+		return
+	}
+
 	respondJSON(w, http.StatusOK, resp)
 }
 
-// SupportedHandler handles GET /supported requests
+// CancelHandler handles /cancel requests
+func (h *Handler) CancelHandler(w http.ResponseWriter, r *http.Request) {
+	// GET returns endpoint information
+	if r.Method == http.MethodGet {
+		h.getCancelInfo(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Parse request
+	req, err := decodeCancelRequest(r.Body, h.strict)
+	if err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+
+	// Cancel authorization
+	resp, err := h.facilitator.Cancel(r.Context(), req)
+	if err != nil {
+		// Protocol-level errors return 200 with error in response
+		if facErr, ok := err.(*types.FacilitatorError); ok {
+			if status := unavailableStatus(facErr); status != 0 {
+				respondError(w, status, facErr.Message)
+				return
+			}
+			respondJSON(w, http.StatusOK, types.CancelResponse{
+				Success:    false,
+				Error:      facErr.Message,
+				ReasonCode: facErr.Code,
+			})
+			return
+		}
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("cancellation failed: %v", err))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// supportedFilterer is implemented by facilitators that filter their
+// supported kinds themselves (see facilitator.LocalFacilitator.SupportedFiltered)
+type supportedFilterer interface {
+	SupportedFiltered(ctx context.Context, filter types.SupportedFilter) (*types.SupportedPaymentKindsResponse, error)
+}
+
+// SupportedHandler handles GET /supported?network=&scheme=&token_symbol=&settleable=
+// requests; the filters are optional, and a filtered response echoes them
 func (h *Handler) SupportedHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	filter, err := types.ParseSupportedFilter(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	resp, err := h.facilitator.Supported(r.Context())
+	var resp *types.SupportedPaymentKindsResponse
+	if filterer, ok := h.facilitator.(supportedFilterer); ok {
+		resp, err = filterer.SupportedFiltered(r.Context(), filter)
+	} else if resp, err = h.facilitator.Supported(r.Context()); err == nil {
+		resp = resp.Filter(filter)
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get supported kinds: %v", err))
 		return
@@ -125,11 +359,404 @@ func (h *Handler) SupportedHandler(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, resp)
 }
 
+// GasAccountingHandler handles GET /accounting/gas?payTo=&from=&to= requests
+// from and to are inclusive dates (YYYY-MM-DD, UTC) and all filters are optional
+func (h *Handler) GasAccountingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.gasLedger == nil {
+		respondError(w, http.StatusNotFound, "gas accounting is not enabled")
+		return
+	}
+
+	query := r.URL.Query()
+	var from, to time.Time
+	var err error
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid from date: %v", err))
+			return
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid to date: %v", err))
+			return
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"totals": h.gasLedger.Totals(query.Get("payTo"), from, to),
+	})
+}
+
+// SettlementJournalHandler handles GET /accounting/settlements?apiKey=&limit=
+// requests, returning recent settlement attempts newest first
+func (h *Handler) SettlementJournalHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.journal == nil {
+		respondError(w, http.StatusNotFound, "settlement journal is not enabled")
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q", v))
+			return
+		}
+		limit = n
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"settlements": h.journal.Entries(query.Get("apiKey"), limit),
+	})
+}
+
+// SettlementHandler handles GET /settlements/{id}: the journal entries of
+// the settlement ID returned by /verify and /settle, newest first (see
+// NonceStatusHandler for /settlements/by-nonce)
+func (h *Handler) SettlementHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.Trim(strings.TrimPrefix(r.URL.Path, "/settlements"), "/") == "by-nonce" {
+		h.NonceStatusHandler(w, r)
+		return
+	}
+	if h.journal == nil {
+		respondError(w, http.StatusNotFound, "settlement journal is not enabled")
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/settlements"), "/")
+	if id == "" || strings.Contains(id, "/") {
+		respondError(w, http.StatusNotFound, "want /settlements/{id}")
+		return
+	}
+
+	entries := h.journal.BySettlementID(id)
+	if len(entries) == 0 {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("no settlement with ID %q", id))
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"settlementId": id,
+		"status":       entries[0].Status,
+		"settlements":  entries,
+	})
+}
+
+// authorizationStateProvider is implemented by facilitators that can read
+// an authorization's state on-chain
+type authorizationStateProvider interface {
+	AuthorizationState(ctx context.Context, net types.Network, asset string, from common.Address, nonce types.Nonce) (used bool, block uint64, err error)
+}
+
+// NonceStatusHandler handles GET /settlements/by-nonce?from=&nonce=, with
+// optional network and asset (default: the network's USDC): whether the
+// authorization was settled by this facilitator, according to the journal,
+// or else whether it was consumed on-chain, read at the latest block of
+// network (of the journal entries if omitted). Settlements of ours evicted
+// from the journal are reported as consumed_on_chain.
+func (h *Handler) NonceStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	from := strings.TrimSpace(query.Get("from"))
+	if !common.IsHexAddress(from) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid from address %q", from))
+		return
+	}
+	nonce, err := types.ParseNonce(strings.TrimSpace(query.Get("nonce")))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	net := types.Network(strings.ToLower(strings.TrimSpace(query.Get("network"))))
+	if parsed, err := types.ParseNetworkID(string(net)); err == nil {
+		net = parsed
+	}
+
+	status := map[string]interface{}{
+		"from":  common.HexToAddress(from).Hex(),
+		"nonce": nonce.String(),
+	}
+	var entries []accounting.JournalEntry
+	if h.journal != nil {
+		entries = h.journal.ByNonce(from, nonce.String())
+	}
+	if len(entries) > 0 {
+		status["settlements"] = entries
+		if net == "" {
+			net = entries[0].Network
+		}
+	}
+	if entry, state, ok := journalNonceState(entries); ok {
+		status["state"] = state
+		status["network"] = entry.Network
+		status["transactionHash"] = entry.TransactionHash
+		respondJSON(w, http.StatusOK, status)
+		return
+	}
+
+	provider, ok := h.facilitator.(authorizationStateProvider)
+	if !ok || net == "" {
+		respondError(w, http.StatusNotFound, "no settlement of this nonce in the journal; pass network to look it up on-chain")
+		return
+	}
+	used, block, err := provider.AuthorizationState(r.Context(), net, strings.TrimSpace(query.Get("asset")), common.HexToAddress(from), nonce)
+	if err != nil {
+		if facErr, ok := err.(*types.FacilitatorError); ok {
+			status := unavailableStatus(facErr)
+			if status == 0 {
+				status = http.StatusBadRequest
+			}
+			respondError(w, status, facErr.Message)
+			return
+		}
+		respondError(w, http.StatusBadGateway, fmt.Sprintf("authorization state lookup failed: %v", err))
+		return
+	}
+	status["state"] = types.NonceUnused
+	if used {
+		status["state"] = types.NonceConsumed
+	}
+	status["network"] = net
+	status["observedBlock"] = block
+	respondJSON(w, http.StatusOK, status)
+}
+
+// journalNonceState returns the journal entry that settled a nonce, or else
+// the one whose transaction is still in flight
+func journalNonceState(entries []accounting.JournalEntry) (accounting.JournalEntry, types.NonceState, bool) {
+	for _, entry := range entries {
+		if entry.Status == accounting.JournalConfirmed {
+			return entry, types.NonceSettled, true
+		}
+	}
+	for _, entry := range entries {
+		if entry.Status.InFlight() && entry.TransactionHash != "" {
+			return entry, types.NoncePending, true
+		}
+	}
+	return accounting.JournalEntry{}, "", false
+}
+
+// statsProvider is implemented by facilitators that expose operational statistics
+type statsProvider interface {
+	Stats() map[string]interface{}
+}
+
+// StatsHandler handles GET /admin/stats requests
+func (h *Handler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	provider, ok := h.facilitator.(statsProvider)
+	if !ok && h.latency == nil {
+		respondError(w, http.StatusNotFound, "stats are not available for this facilitator")
+		return
+	}
+	stats := make(map[string]interface{})
+	if ok {
+		for key, value := range provider.Stats() {
+			stats[key] = value
+		}
+	}
+	if h.latency != nil {
+		stats["latency"] = h.latency.Snapshot()
+	}
+	respondJSON(w, http.StatusOK, stats)
+}
+
 // HealthHandler handles GET /health requests
 func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
+// DevFundHandler handles POST /dev/fund {address, network}: a testnet USDC
+// grant from the faucet, when development mode enabled it
+func (h *Handler) DevFundHandler(w http.ResponseWriter, r *http.Request) {
+	if h.faucet == nil {
+		respondError(w, http.StatusNotFound, "the faucet is only available in development mode")
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Address string        `json:"address"`
+		Network types.Network `json:"network"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	grant, err := h.faucet.Fund(r.Context(), req.Network, req.Address)
+	switch {
+	case errors.Is(err, facilitator.ErrFaucetMainnet):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, facilitator.ErrFaucetLimit):
+		respondError(w, http.StatusTooManyRequests, err.Error())
+	case err != nil:
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("funding failed: %v", err))
+	default:
+		respondJSON(w, http.StatusOK, grant)
+	}
+}
+
+// VersionHandler handles GET /version: the build and enabled features
+func (h *Handler) VersionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	respondJSON(w, http.StatusOK, h.BuildInfo(r.Context()))
+}
+
+// BuildInfo returns the build information, with the features derived from
+// what the facilitator currently supports
+func (h *Handler) BuildInfo(ctx context.Context) version.Info {
+	var settle, solana bool
+	if supported, err := h.facilitator.Supported(ctx); err == nil {
+		for _, kind := range supported.Kinds {
+			settle = settle || kind.Settlement
+			solana = solana || kind.Network.IsSolana()
+		}
+	}
+	var features []string
+	if settle {
+		features = append(features, version.FeatureSettle)
+	}
+	if solana {
+		features = append(features, version.FeatureSolana)
+	}
+	return version.Get(features...)
+}
+
+// networkSwitcher is implemented by facilitators whose networks can be
+// disabled at runtime
+type networkSwitcher interface {
+	DisableNetwork(network types.Network) error
+	EnableNetwork(network types.Network) error
+	DisabledNetworks() map[types.Network]time.Time
+}
+
+// AdminNetworksHandler handles GET /admin/networks (the disabled networks)
+// and POST /admin/networks/{network}/disable and /enable
+func (h *Handler) AdminNetworksHandler(w http.ResponseWriter, r *http.Request) {
+	switcher, ok := h.facilitator.(networkSwitcher)
+	if !ok {
+		respondError(w, http.StatusNotFound, "networks cannot be disabled on this facilitator")
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/networks"), "/")
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]interface{}{"disabled": switcher.DisabledNetworks()})
+		return
+	}
+
+	net, action, ok := strings.Cut(rest, "/")
+	if !ok || (action != "disable" && action != "enable") {
+		respondError(w, http.StatusNotFound, "want /admin/networks/{network}/disable or /enable")
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var err error
+	if action == "disable" {
+		err = switcher.DisableNetwork(types.Network(net))
+	} else {
+		err = switcher.EnableNetwork(types.Network(net))
+	}
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.Printf("admin: network %s %sd", net, action)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"network":  net,
+		"enabled":  action == "enable",
+		"disabled": switcher.DisabledNetworks(),
+	})
+}
+
+// networkHealthProvider is implemented by facilitators that monitor whether
+// each network can settle
+type networkHealthProvider interface {
+	NetworkHealth() map[types.Network]bool
+}
+
+// rpcCircuitProvider is implemented by facilitators that stop verifying on
+// networks whose RPC is failing
+type rpcCircuitProvider interface {
+	RPCCircuits() map[types.Network]string
+}
+
+// ReadyHandler handles GET /health/ready requests
+// It answers 503 only when every network is degraded, so one network whose
+// signers ran out of gas (or whose RPC is failing) does not take the whole
+// facilitator out of rotation
+func (h *Handler) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	reporter, ok := h.facilitator.(networkHealthProvider)
+	if !ok {
+		respondJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+		return
+	}
+
+	health := reporter.NetworkHealth()
+	networks := make(map[types.Network]string, len(health))
+	healthy := 0
+	for net, ok := range health {
+		if ok {
+			networks[net] = "ok"
+			healthy++
+		} else {
+			networks[net] = "degraded"
+		}
+	}
+
+	status, code := "ready", http.StatusOK
+	switch {
+	case len(health) > 0 && healthy == 0:
+		status, code = "unavailable", http.StatusServiceUnavailable
+	case healthy < len(health):
+		status = "degraded"
+	}
+	body := map[string]interface{}{
+		"status":   status,
+		"networks": networks,
+	}
+	if circuits, ok := h.facilitator.(rpcCircuitProvider); ok {
+		if states := circuits.RPCCircuits(); len(states) > 0 {
+			body["rpcCircuits"] = states
+		}
+	}
+	respondJSON(w, code, body)
+}
+
 // getVerifyInfo returns machine-readable description of the /verify endpoint
 func (h *Handler) getVerifyInfo(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]interface{}{
@@ -154,6 +781,33 @@ func (h *Handler) getSettleInfo(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// getSimulateInfo returns machine-readable description of the /settle/simulate endpoint
+func (h *Handler) getSimulateInfo(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"endpoint":    "/settle/simulate",
+		"description": "POST to dry-run x402 settlement (verify + gas estimate, no state change)",
+		"body": map[string]string{
+			"paymentPayload":      "PaymentPayload",
+			"paymentRequirements": "PaymentRequirements",
+		},
+	})
+}
+
+// getCancelInfo returns machine-readable description of the /cancel endpoint
+func (h *Handler) getCancelInfo(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"endpoint":    "/cancel",
+		"description": "POST to cancel an unsettled ERC-3009 authorization on-chain",
+		"body": map[string]string{
+			"x402Version":   "number",
+			"network":       "Network",
+			"asset":         "address",
+			"authorization": "{authorizer, nonce}",
+			"signature":     "EIP-712 CancelAuthorization signature",
+		},
+	})
+}
+
 // Helper functions
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -166,10 +820,42 @@ func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, map[string]string{"error": message})
 }
 
+// unavailableStatus returns the status of a facilitator error meaning the
+// payment could not be checked at all, rather than a refusal (0 otherwise)
+func unavailableStatus(facErr *types.FacilitatorError) int {
+	switch facErr.Type {
+	case "Timeout":
+		return http.StatusGatewayTimeout
+	case "ServiceDegraded":
+		return http.StatusServiceUnavailable
+	}
+	return 0
+}
+
+// respondDecodeError answers a request body that failed to decode, listing
+// the offending fields when the body did not match the request schema
+func respondDecodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || err.Error() == "http: request body too large" {
+		respondError(w, http.StatusRequestEntityTooLarge, "request body exceeds maximum allowed size (1MB)")
+		return
+	}
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		respondJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":  fmt.Sprintf("invalid request: %v", err),
+			"fields": reqErr.Fields,
+		})
+		return
+	}
+	respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+}
+
 // SetupRoutes sets up all HTTP routes
+// It panics if mux already has one of the patterns; use
+// SetupRoutesWithConfig to get an error instead.
 func (h *Handler) SetupRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/verify", h.VerifyHandler)
-	mux.HandleFunc("/settle", h.SettleHandler)
-	mux.HandleFunc("/supported", h.SupportedHandler)
-	mux.HandleFunc("/health", h.HealthHandler)
+	if err := h.SetupRoutesWithConfig(mux, RouteConfig{}); err != nil {
+		panic(err)
+	}
 }
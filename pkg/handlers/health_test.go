@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// healthFacilitator reports fixed per-network health
+type healthFacilitator struct {
+	facilitator.Facilitator
+	health map[types.Network]bool
+}
+
+func (f healthFacilitator) NetworkHealth() map[types.Network]bool {
+	return f.health
+}
+
+func TestReadyHandlerReportsDegradedNetworks(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		fac    facilitator.Facilitator
+		code   int
+		status string
+	}{
+		{"not monitored", nil, http.StatusOK, "ready"},
+		{"all healthy", healthFacilitator{health: map[types.Network]bool{types.NetworkBase: true, types.NetworkXDC: true}}, http.StatusOK, "ready"},
+		{"one degraded", healthFacilitator{health: map[types.Network]bool{types.NetworkBase: true, types.NetworkXDC: false}}, http.StatusOK, "degraded"},
+		{"all degraded", healthFacilitator{health: map[types.Network]bool{types.NetworkBase: false}}, http.StatusServiceUnavailable, "unavailable"},
+	} {
+		mux := http.NewServeMux()
+		NewHandler(tc.fac).SetupRoutes(mux)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+		var body struct {
+			Status   string                   `json:"status"`
+			Networks map[types.Network]string `json:"networks"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != tc.code || body.Status != tc.status {
+			t.Errorf("%s: status %d %q, want %d %q", tc.name, rec.Code, body.Status, tc.code, tc.status)
+		}
+		if f, ok := tc.fac.(healthFacilitator); ok {
+			for net, healthy := range f.health {
+				want := "ok"
+				if !healthy {
+					want = "degraded"
+				}
+				if body.Networks[net] != want {
+					t.Errorf("%s: %s reported %q, want %q", tc.name, net, body.Networks[net], want)
+				}
+			}
+		}
+	}
+}
+
+// circuitFacilitator reports fixed RPC circuit states beside network health
+type circuitFacilitator struct {
+	healthFacilitator
+	circuits map[types.Network]string
+}
+
+func (f circuitFacilitator) RPCCircuits() map[types.Network]string {
+	return f.circuits
+}
+
+func TestReadyHandlerReportsRPCCircuits(t *testing.T) {
+	fac := circuitFacilitator{
+		healthFacilitator: healthFacilitator{health: map[types.Network]bool{types.NetworkBase: false, types.NetworkXDC: true}},
+		circuits:          map[types.Network]string{types.NetworkBase: "open"},
+	}
+	mux := http.NewServeMux()
+	NewHandler(fac).SetupRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	var body struct {
+		Status      string                   `json:"status"`
+		RPCCircuits map[types.Network]string `json:"rpcCircuits"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || body.Status != "degraded" || body.RPCCircuits[types.NetworkBase] != "open" {
+		t.Errorf("status %d %q, circuits %v; want base's open circuit reported", rec.Code, body.Status, body.RPCCircuits)
+	}
+}
@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testcerts"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/accounting"
+	"github.com/x402-rs/x402-go/pkg/config"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/middleware"
+	"github.com/x402-rs/x402-go/pkg/transport"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestSettleJournalAttributesAPIKey(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := middleware.ParseAPIKeys("shop:sk_shop,sk_bare")
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal := accounting.NewSettlementJournal(16)
+	h := NewHandler(fac)
+	h.SetJournal(journal)
+	mux := http.NewServeMux()
+	h.SetupRoutes(mux)
+	handler := middleware.AuthMiddleware(keys)(mux)
+
+	settle := func(key string) *httptest.ResponseRecorder {
+		t.Helper()
+		requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/settle", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, key := range []string{"", "sk_wrong"} {
+		if rec := settle(key); rec.Code != http.StatusUnauthorized {
+			t.Errorf("settle with key %q: status %d, want %d", key, rec.Code, http.StatusUnauthorized)
+		}
+	}
+	if n := len(journal.Entries("", 0)); n != 0 {
+		t.Fatalf("refused settles left %d journal entries", n)
+	}
+
+	for key, label := range map[string]string{"sk_shop": "shop", "sk_bare": "key-2"} {
+		rec := settle(key)
+		var resp types.SettleResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK || !resp.Success {
+			t.Fatalf("settle with key %s: status %d: %s", label, rec.Code, rec.Body.String())
+		}
+		entries := journal.Entries(label, 0)
+		if len(entries) != 1 || entries[0].APIKey != label || entries[0].Status != accounting.JournalConfirmed {
+			t.Errorf("journal entries for %s: %+v, want one confirmed settlement", label, entries)
+		}
+	}
+	if n := len(journal.Entries("", 0)); n != 2 {
+		t.Errorf("%d journal entries, want one per accepted key", n)
+	}
+}
+
+func TestSettleJournalRecordsFacilitatorFee(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := types.FeePolicy{FlatAmount: big.NewInt(100), BasisPoints: 100}
+	fac.SetFeePolicy(testchain.Network, policy)
+	journal := accounting.NewSettlementJournal(16)
+	h := NewHandler(fac)
+	h.SetJournal(journal)
+	mux := http.NewServeMux()
+	h.SetupRoutes(mux)
+
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(10_000))
+	if err := policy.ApplyFee(&requirements); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/settle", bytes.NewReader(data)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("settle: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	entries := journal.Entries("", 0)
+	if len(entries) != 1 || entries[0].Amount != "10200" || entries[0].FacilitatorFee != "200" {
+		t.Fatalf("journal entries %+v, want the settlement of 10200 with its fee of 200", entries)
+	}
+	var listed struct {
+		Settlements []map[string]interface{} `json:"settlements"`
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/accounting/settlements", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil || len(listed.Settlements) != 1 || listed.Settlements[0]["facilitator_fee"] != "200" {
+		t.Errorf("/accounting/settlements: %s, want the fee listed", rec.Body.String())
+	}
+}
+
+func TestSettleJournalAttributesClientCertificate(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal := accounting.NewSettlementJournal(16)
+	h := NewHandler(fac)
+	h.SetJournal(journal)
+	mux := http.NewServeMux()
+	h.SetupRoutes(mux)
+
+	// Served as cmd/facilitator does with TLS_CLIENT_AUTH=require
+	ca := testcerts.NewAuthority(t, "Internal CA")
+	server := httptest.NewUnstartedServer(middleware.ClientCertMiddleware(ca.Pool())(mux))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.Issue(t, "facilitator", testcerts.Server, "127.0.0.1").Certificate},
+		ClientCAs:    ca.Pool(),
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	clientFor := func(opts ...transport.TLSOption) *http.Client {
+		rt, err := transport.AuthorizedTransport(transport.DefaultConfig(), append(opts, transport.WithRootCAs(ca.Pool()))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &http.Client{Transport: rt}
+	}
+	settle := func(client *http.Client) (*http.Response, error) {
+		t.Helper()
+		requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client.Post(server.URL+"/settle", "application/json", bytes.NewReader(data))
+	}
+
+	anonymous := clientFor()
+	resp, err := settle(anonymous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("settle without a certificate: status %d, want 401", resp.StatusCode)
+	}
+	if resp, err := anonymous.Get(server.URL + "/supported"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("/supported without a certificate: %v, %v; want it open", resp, err)
+	}
+
+	stranger := testcerts.NewAuthority(t, "Other CA").Issue(t, "intruder", testcerts.Client)
+	// The client offers no certificate the server's CAs do not sign, so this is refused as anonymous
+	if resp, err := settle(clientFor(transport.WithClientCertificate(stranger.CertFile, stranger.KeyFile))); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("settle with an untrusted certificate: status %d, want 401", resp.StatusCode)
+		}
+	}
+	if n := len(journal.Entries("", 0)); n != 0 {
+		t.Fatalf("refused settles left %d journal entries", n)
+	}
+
+	shop := ca.Issue(t, "shop", testcerts.Client)
+	resp, err = settle(clientFor(transport.WithClientCertificate(shop.CertFile, shop.KeyFile)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settled types.SettleResponse
+	json.NewDecoder(resp.Body).Decode(&settled)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !settled.Success {
+		t.Fatalf("settle with a trusted certificate: status %d, %+v", resp.StatusCode, settled)
+	}
+	entries := journal.Entries("", 0)
+	if len(entries) != 1 || entries[0].ClientCert != "shop" || entries[0].Status != accounting.JournalConfirmed {
+		t.Errorf("journal entries %+v, want one confirmed settlement by shop", entries)
+	}
+}
+
+func TestSettleJournalAttributesTenant(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	tenants, err := config.NewTenantRegistry([]config.TenantConfig{{ID: "shop", APIKeys: []string{"sk_shop"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tenants.Stop()
+	fac, err := facilitator.NewBuilder().
+		WithEVMNetwork(testchain.Network, chain.Options()).
+		WithTenants(tenants.Policies()).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal := accounting.NewSettlementJournal(16)
+	h := NewHandler(fac)
+	h.SetJournal(journal)
+	mux := http.NewServeMux()
+	h.SetupRoutes(mux)
+	handler := middleware.TenantMiddleware(tenants)(mux)
+
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/settle", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer sk_shop")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("settle: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	entries := journal.Entries("", 0)
+	if len(entries) != 1 || entries[0].Tenant != "shop" || entries[0].Status != accounting.JournalConfirmed {
+		t.Errorf("journal entries %+v, want one confirmed settlement for tenant shop", entries)
+	}
+	if got := fac.TenantStats()["shop"].Settled; got != 1 {
+		t.Errorf("shop settled %d payments, want 1", got)
+	}
+}
+
+func TestSettlementIDTracesVerifySettleAndJournal(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal := accounting.NewSettlementJournal(16)
+	h := NewHandler(fac)
+	h.SetJournal(journal)
+	mux := http.NewServeMux()
+	h.SetupRoutes(mux)
+
+	post := func(path, key string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(types.HeaderIdempotencyKey, key)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, clientKey := range []string{"", "order-2026-10-14_0001"} {
+		requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// /verify assigns the ID unless the client chose one
+		var verified types.VerifyResponse
+		rec := post("/verify", clientKey, types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+		if err := json.Unmarshal(rec.Body.Bytes(), &verified); err != nil || !verified.IsValid {
+			t.Fatalf("verify: status %d: %s", rec.Code, rec.Body.String())
+		}
+		id := verified.SettlementID
+		if id == "" || (clientKey != "" && id != clientKey) {
+			t.Fatalf("verify with key %q: settlement ID %q", clientKey, id)
+		}
+
+		// The resource server settles under it
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Fatal(err)
+		}
+		var settled types.SettleResponse
+		rec = post("/settle", id, types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+		if err := json.Unmarshal(rec.Body.Bytes(), &settled); err != nil || !settled.Success {
+			t.Fatalf("settle: status %d: %s", rec.Code, rec.Body.String())
+		}
+		if settled.SettlementID != id || settled.Receipt == nil || settled.Receipt.SettlementID != id {
+			t.Errorf("settle: settlement ID %q, receipt %+v; want %q in both", settled.SettlementID, settled.Receipt, id)
+		}
+
+		// And anyone holding it can look the settlement up
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/settlements/"+id, nil))
+		var lookup struct {
+			SettlementID string                    `json:"settlementId"`
+			Status       accounting.JournalStatus  `json:"status"`
+			Settlements  []accounting.JournalEntry `json:"settlements"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &lookup); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("lookup: status %d: %s", rec.Code, rec.Body.String())
+		}
+		if lookup.SettlementID != id || lookup.Status != accounting.JournalConfirmed || len(lookup.Settlements) != 1 ||
+			lookup.Settlements[0].TransactionHash != settled.TransactionHash.Hash {
+			t.Errorf("lookup of %s: %+v, want the confirmed settlement", id, lookup)
+		}
+	}
+
+	for _, path := range []string{"/settlements/" + types.NewSettlementID(), "/settlements/", "/settlements/a/b"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", path, rec.Code)
+		}
+	}
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := post("/verify", "too short", types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements}); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), types.HeaderIdempotencyKey) {
+		t.Errorf("malformed key: status %d (%s), want a 400 naming the header", rec.Code, rec.Body.String())
+	}
+}
+
+func TestNonceStatusReportsEachState(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal := accounting.NewSettlementJournal(16)
+	h := NewHandler(fac)
+	h.SetJournal(journal)
+	mux := http.NewServeMux()
+	h.SetupRoutes(mux)
+
+	requirements := chain.Requirements(common.HexToAddress("0x00000000000000000000000000000000000000b0"), big.NewInt(1000))
+	authorize := func() *types.SettleRequest {
+		t.Helper()
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements}
+	}
+	settledByUs, settledElsewhere, unused, inFlight := authorize(), authorize(), authorize(), authorize()
+	if err := chain.AdjustTime(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(settledByUs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/settle", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var settled types.SettleResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &settled); err != nil || !settled.Success {
+		t.Fatalf("settle: status %d: %s", rec.Code, rec.Body.String())
+	}
+	// Settled past the journal, as another facilitator would
+	if resp, err := fac.Settle(context.Background(), settledElsewhere); err != nil || !resp.Success {
+		t.Fatalf("direct settle: %+v (%v)", resp, err)
+	}
+	auth := inFlight.PaymentPayload.Payload.Authorization
+	journal.Record(accounting.JournalEntry{Network: testchain.Network, Payer: auth.From.Hex(), Nonce: auth.Nonce.String(), Status: accounting.JournalSubmitted, TransactionHash: "0xfeed"})
+
+	type nonceStatus struct {
+		State           types.NonceState          `json:"state"`
+		Network         types.Network             `json:"network"`
+		TransactionHash string                    `json:"transactionHash"`
+		ObservedBlock   uint64                    `json:"observedBlock"`
+		Settlements     []accounting.JournalEntry `json:"settlements"`
+	}
+	lookup := func(request *types.SettleRequest, extra string) (int, nonceStatus) {
+		t.Helper()
+		auth := request.PaymentPayload.Payload.Authorization
+		target := "/settlements/by-nonce?from=" + strings.ToLower(auth.From.Hex()) + "&nonce=" + auth.Nonce.String() + extra
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var status nonceStatus
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec.Code, status
+	}
+
+	if code, status := lookup(settledByUs, ""); code != http.StatusOK || status.State != types.NonceSettled || settled.TransactionHash == nil ||
+		!strings.EqualFold(status.TransactionHash, settled.TransactionHash.Hash) || status.Network != testchain.Network || len(status.Settlements) != 1 {
+		t.Errorf("settled by us: status %d, %+v; want settled in %v", code, status, settled.TransactionHash)
+	}
+	if code, status := lookup(inFlight, ""); code != http.StatusOK || status.State != types.NoncePending || status.TransactionHash != "0xfeed" {
+		t.Errorf("in flight: status %d, %+v; want it pending", code, status)
+	}
+	if code, status := lookup(settledElsewhere, "&network=testchain"); code != http.StatusOK || status.State != types.NonceConsumed || status.ObservedBlock == 0 || status.TransactionHash != "" {
+		t.Errorf("settled elsewhere: status %d, %+v; want it consumed on-chain at a block", code, status)
+	}
+	if code, status := lookup(unused, "&network=testchain&asset="+testchain.TokenAddress.Hex()); code != http.StatusOK || status.State != types.NonceUnused || status.ObservedBlock == 0 {
+		t.Errorf("unused: status %d, %+v", code, status)
+	}
+
+	for name, tc := range map[string]struct {
+		request *types.SettleRequest
+		extra   string
+		code    int
+	}{
+		"no network to look up":   {unused, "", http.StatusNotFound},
+		"unsupported network":     {unused, "&network=base", http.StatusBadRequest},
+		"asset off the whitelist": {unused, "&network=testchain&asset=0x00000000000000000000000000000000000000c0", http.StatusBadRequest},
+	} {
+		if code, _ := lookup(tc.request, tc.extra); code != tc.code {
+			t.Errorf("%s: status %d, want %d", name, code, tc.code)
+		}
+	}
+	for _, target := range []string{
+		"/settlements/by-nonce?from=0xb0&nonce=" + auth.Nonce.String(),
+		"/settlements/by-nonce?from=" + auth.From.Hex() + "&nonce=0x1234",
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
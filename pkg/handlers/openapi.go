@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/version"
+)
+
+// OpenAPIDocument returns an OpenAPI 3.1 description of /verify, /settle,
+// /supported and /health at their default paths. The schemas are generated
+// from the request and response types by reflection, so they follow the
+// types; request schemas require what the request decoder requires.
+func OpenAPIDocument() map[string]interface{} {
+	return openAPIDocument(nil)
+}
+
+// openAPIDocument builds the document with each default path renamed as
+// mounted says (see SetupRoutesWithConfig); endpoints mounted at "" are left out
+func openAPIDocument(mounted map[string]string) map[string]interface{} {
+	g := newSchemaGenerator()
+	g.require(reflect.TypeOf(types.VerifyRequest{}), requestSpecV1)
+	g.require(reflect.TypeOf(types.SettleRequest{}), requestSpecV1)
+	g.require(reflect.TypeOf(types.PaymentPayload{}), subfields(requestSpecV1, "paymentPayload"))
+	g.require(reflect.TypeOf(types.PaymentRequirements{}), requirementsSpecV1)
+	g.require(reflect.TypeOf(types.ExactEvmPayload{}), evmPayloadSpec.fields)
+	g.require(reflect.TypeOf(types.ExactEvmPayloadAuthorization{}), authorizationSpec.fields)
+
+	errorBody := func(description string) map[string]interface{} {
+		return jsonContent(description, g.ref(reflect.TypeOf(apiError{})))
+	}
+	paymentEndpoint := func(summary string, request, response interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary": summary,
+				"parameters": []interface{}{map[string]interface{}{
+					"name":        types.HeaderIdempotencyKey,
+					"in":          "header",
+					"description": "Settlement ID to verify and settle under (16 to 64 letters, digits, '-' or '_'); the facilitator assigns one if absent",
+					"schema":      map[string]interface{}{"type": "string"},
+				}},
+				"requestBody": map[string]interface{}{"required": true, "content": map[string]interface{}{"application/json": map[string]interface{}{"schema": g.ref(reflect.TypeOf(request))}}},
+				"responses": map[string]interface{}{
+					"200": jsonContent("Outcome; payments failing verification are answered here too, with a reason code", g.ref(reflect.TypeOf(response))),
+					"400": errorBody("Malformed request body"),
+					"413": errorBody("Request body too large"),
+					"500": errorBody("Facilitator failure"),
+					"503": errorBody("The network's RPC is failing; verification is paused until it recovers"),
+					"504": errorBody("Timed out waiting for the chain"),
+				},
+			},
+		}
+	}
+	endpoints := map[string]map[string]interface{}{
+		"/verify": paymentEndpoint("Verify a payment without settling it", types.VerifyRequest{}, types.VerifyResponse{}),
+		"/settle": paymentEndpoint("Verify and settle a payment on-chain", types.SettleRequest{}, types.SettleResponse{}),
+		"/supported": {"get": map[string]interface{}{
+			"summary": "List the payment kinds the facilitator accepts",
+			"parameters": []interface{}{
+				queryParameter("network", "Only kinds on this network (name or CAIP-2 ID)", "string"),
+				queryParameter("scheme", "Only kinds of this scheme", "string"),
+				queryParameter("token_symbol", "Only kinds of tokens with this symbol (case-insensitive)", "string"),
+				queryParameter("settleable", "Only kinds the facilitator can (true) or cannot (false) settle", "boolean"),
+			},
+			"responses": map[string]interface{}{
+				"200": jsonContent("Supported payment kinds passing the filters; values matching nothing give an empty list", g.ref(reflect.TypeOf(types.SupportedPaymentKindsResponse{}))),
+				"400": errorBody("Invalid settleable value"),
+			},
+		}},
+		"/health": {"get": map[string]interface{}{
+			"summary":   "Liveness check",
+			"responses": map[string]interface{}{"200": jsonContent("The facilitator is running", g.ref(reflect.TypeOf(healthStatus{})))},
+		}},
+	}
+
+	paths := make(map[string]interface{}, len(endpoints))
+	for path, item := range endpoints {
+		if mounted != nil {
+			if path = mounted[path]; path == "" {
+				continue
+			}
+		}
+		paths[path] = item
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "x402 facilitator",
+			"description": "Verifies and settles x402 payments",
+			"version":     version.Version,
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.components},
+	}
+}
+
+// apiError is the body of error responses (see respondError)
+type apiError struct {
+	Error  string       `json:"error"`
+	Fields []fieldError `json:"fields,omitempty"` // Offending fields of a malformed body
+}
+
+// healthStatus is the body of GET /health
+type healthStatus struct {
+	Status string `json:"status"`
+}
+
+// queryParameter describes an optional query parameter
+func queryParameter(name, description, kind string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        name,
+		"in":          "query",
+		"description": description,
+		"schema":      map[string]interface{}{"type": kind},
+	}
+}
+
+// jsonContent describes a JSON response
+func jsonContent(description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+	}
+}
+
+// subfields returns the fields of the object field name in specs
+func subfields(specs []fieldSpec, name string) []fieldSpec {
+	for _, spec := range specs {
+		if spec.name == name {
+			return spec.fields
+		}
+	}
+	return nil
+}
+
+// evmAddressPattern matches the EVM addresses requests accept (0x or xdc prefix)
+const evmAddressPattern = "^(0x|xdc)[0-9a-fA-F]{40}$"
+
+// hexBytesPattern matches the canonical form of types.HexBytes (the one
+// STRICT_HEX accepts)
+const hexBytesPattern = "^0x([0-9a-fA-F]{2})*$"
+
+// schemaGenerator turns Go types into JSON schemas, collecting each named
+// struct (and enum) once as a component
+type schemaGenerator struct {
+	components map[string]interface{}
+	required   map[reflect.Type][]string // Overrides the omitempty rule
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		components: make(map[string]interface{}),
+		required:   make(map[reflect.Type][]string),
+	}
+}
+
+// require makes the required properties of t the required fields of specs
+func (g *schemaGenerator) require(t reflect.Type, specs []fieldSpec) {
+	required := []string{}
+	for _, spec := range specs {
+		if spec.required {
+			required = append(required, spec.name)
+		}
+	}
+	g.required[t] = required
+}
+
+// ref returns a reference to the component for struct type t, generating it
+// on first use
+func (g *schemaGenerator) ref(t reflect.Type) map[string]interface{} {
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, ok := g.components[name]; !ok {
+		g.components[name] = true // Placeholder, for recursive types
+		g.components[name] = g.object(t)
+	}
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// enum returns a reference to a string enum component
+func (g *schemaGenerator) enum(name, description string, values []string) map[string]interface{} {
+	if _, ok := g.components[name]; !ok {
+		g.components[name] = map[string]interface{}{"type": "string", "description": description, "enum": values}
+	}
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// schema returns the schema for values of type t
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case reflect.TypeOf(common.Address{}):
+		return map[string]interface{}{"type": "string", "pattern": evmAddressPattern}
+	case reflect.TypeOf(types.HexBytes{}):
+		return map[string]interface{}{"type": "string", "pattern": hexBytesPattern}
+	case reflect.TypeOf(json.RawMessage{}):
+		return map[string]interface{}{} // Any JSON value
+	case reflect.TypeOf(time.Time{}):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(types.ReasonCode("")):
+		codes := make([]string, len(types.ReasonCodes))
+		for i, code := range types.ReasonCodes {
+			codes[i] = string(code)
+		}
+		return g.enum("ReasonCode", "Machine-readable cause of a failed verification or settlement", codes)
+	case reflect.TypeOf(types.Scheme("")):
+		return g.enum("Scheme", "Payment scheme", []string{string(types.SchemeExact), string(types.SchemePermit2)})
+	case reflect.TypeOf(types.X402Version("")):
+		return map[string]interface{}{"type": "string", "examples": []string{string(types.X402VersionV1), string(types.X402VersionV2)}}
+	case reflect.TypeOf(types.Network("")):
+		// Operators can register further networks, so this is no enum
+		return map[string]interface{}{"type": "string", "examples": []string{string(types.NetworkBase), string(types.NetworkBaseSepolia), string(types.NetworkSolana)}}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"} // Base64
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	}
+	return map[string]interface{}{}
+}
+
+// object returns the object schema of struct type t: a property per field
+// encoding/json writes, required unless omitempty (or as require set)
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	g.addFields(t, properties, &required)
+	if override, ok := g.required[t]; ok {
+		required = override
+	}
+	obj := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	return obj
+}
+
+// addFields adds the JSON properties of t's fields, flattening embedded structs
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// OpenAPIHandler handles GET /openapi.json: the OpenAPI document, with the
+// paths the endpoints are mounted at
+func (h *Handler) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	respondJSON(w, http.StatusOK, openAPIDocument(h.mounted))
+}
+
+// docsPage renders Swagger UI (from a CDN) for the document at SpecURL
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>x402 facilitator API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`))
+
+// DocsHandler handles GET /docs: Swagger UI for /openapi.json, when
+// development mode enabled it
+func (h *Handler) DocsHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.docs {
+		respondError(w, http.StatusNotFound, "the API docs are only available in development mode")
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	specURL := "/openapi.json"
+	if h.mounted != nil {
+		specURL = h.mounted[specURL]
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := docsPage.Execute(w, struct{ SpecURL string }{specURL}); err != nil {
+		http.Error(w, fmt.Sprintf("rendering docs: %v", err), http.StatusInternalServerError)
+	}
+}
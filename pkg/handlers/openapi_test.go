@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// schemaValidator checks JSON values against the document's schemas,
+// supporting the subset of JSON Schema the generator emits
+type schemaValidator struct {
+	components map[string]interface{}
+}
+
+func newSchemaValidator(t *testing.T, doc map[string]interface{}) *schemaValidator {
+	t.Helper()
+	// Validate against the document as clients read it: decoded JSON
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return &schemaValidator{components: decoded.Components.Schemas}
+}
+
+// validate returns the first violation of schema by value at path, "" if none
+func (v *schemaValidator) validate(schema map[string]interface{}, value interface{}, path string) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		component, ok := v.components[strings.TrimPrefix(ref, "#/components/schemas/")].(map[string]interface{})
+		if !ok {
+			return fmt.Sprintf("%s: dangling reference %s", path, ref)
+		}
+		return v.validate(component, value, path)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || allowed == value
+		}
+		if !found {
+			return fmt.Sprintf("%s: %v is not in the enum", path, value)
+		}
+	}
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Sprintf("%s: %v is not an object", path, value)
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				return fmt.Sprintf("%s: required property %s is missing", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, field := range obj {
+			propertySchema, ok := properties[name].(map[string]interface{})
+			if !ok {
+				if additional == nil {
+					return fmt.Sprintf("%s: undocumented property %s", path, name)
+				}
+				propertySchema = additional
+			}
+			if violation := v.validate(propertySchema, field, path+"."+name); violation != "" {
+				return violation
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Sprintf("%s: %v is not an array", path, value)
+		}
+		for i, item := range items {
+			if violation := v.validate(schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s[%d]", path, i)); violation != "" {
+				return violation
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Sprintf("%s: %v is not a string", path, value)
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(s) {
+			return fmt.Sprintf("%s: %q does not match %s", path, s, pattern)
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return fmt.Sprintf("%s: %v is not a number", path, value)
+		}
+		if schema["type"] == "integer" && n != float64(int64(n)) {
+			return fmt.Sprintf("%s: %v is not an integer", path, value)
+		}
+		if minimum, ok := schema["minimum"].(float64); ok && n < minimum {
+			return fmt.Sprintf("%s: %v is below %v", path, value, minimum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Sprintf("%s: %v is not a boolean", path, value)
+		}
+	}
+	return ""
+}
+
+// decoded round-trips value through JSON as a client would receive it
+func decoded(t *testing.T, value interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestOpenAPISchemasMatchPayloads(t *testing.T) {
+	v := newSchemaValidator(t, OpenAPIDocument())
+	requirements := x402test.Requirements()
+	payload, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer := types.NewEvmAddress(common.HexToAddress("0x00000000000000000000000000000000000000a1"))
+	healthy := true
+	txHash := types.TransactionHash{Type: "evm", Hash: common.HexToHash("0x01").Hex()}
+
+	for component, sample := range map[string]interface{}{
+		"VerifyRequest":       types.VerifyRequest{X402Version: 1, PaymentPayload: *payload, PaymentRequirements: requirements},
+		"SettleRequest":       types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements},
+		"PaymentPayload":      payload,
+		"PaymentRequirements": requirements,
+		"VerifyResponse":      types.VerifyResponse{IsValid: false, Reason: "payment expired", ReasonCode: types.ReasonExpired, Payer: &payer},
+		"SettleResponse":      types.SettleResponse{Success: true, TransactionHash: &txHash, GasUsed: 52000, SettlementID: "settlement-0123456789"},
+		"SupportedPaymentKindsResponse": types.SupportedPaymentKindsResponse{Kinds: []types.SupportedPaymentKind{{
+			Version:      types.X402VersionV1,
+			Scheme:       types.SchemeExact,
+			Network:      requirements.Network,
+			Token:        types.MixedAddress{Type: "evm", Address: requirements.Asset.Hex()},
+			X402Versions: types.SupportedX402Versions,
+			Healthy:      &healthy,
+			Settlement:   true,
+		}}},
+	} {
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + component}
+		if violation := v.validate(ref, decoded(t, sample), component); violation != "" {
+			t.Errorf("%s sample breaks its schema: %s", component, violation)
+		}
+	}
+
+	// The schemas are not vacuous: requests lacking what the decoder requires fail
+	request := decoded(t, types.VerifyRequest{X402Version: 1, PaymentPayload: *payload, PaymentRequirements: requirements}).(map[string]interface{})
+	delete(request["paymentPayload"].(map[string]interface{})["payload"].(map[string]interface{}), "signature")
+	if violation := v.validate(map[string]interface{}{"$ref": "#/components/schemas/VerifyRequest"}, request, "VerifyRequest"); !strings.Contains(violation, "signature") {
+		t.Errorf("request without a signature: violation %q, want the missing signature", violation)
+	}
+	response := decoded(t, types.VerifyResponse{ReasonCode: "not_a_code"})
+	if violation := v.validate(map[string]interface{}{"$ref": "#/components/schemas/VerifyResponse"}, response, "VerifyResponse"); !strings.Contains(violation, "enum") {
+		t.Errorf("unknown reason code: violation %q, want it outside the enum", violation)
+	}
+}
+
+func TestOpenAPIReasonCodeEnumListsEveryCode(t *testing.T) {
+	enum := OpenAPIDocument()["components"].(map[string]interface{})["schemas"].(map[string]interface{})["ReasonCode"].(map[string]interface{})["enum"].([]string)
+	if len(enum) != len(types.ReasonCodes) {
+		t.Fatalf("enum has %d codes, want the %d of types.ReasonCodes", len(enum), len(types.ReasonCodes))
+	}
+	for i, code := range types.ReasonCodes {
+		if enum[i] != string(code) {
+			t.Errorf("enum[%d] = %q, want %q", i, enum[i], code)
+		}
+	}
+}
+
+func TestOpenAPIHandlerFollowsMountedPaths(t *testing.T) {
+	h := NewHandler(nil)
+	mux := http.NewServeMux()
+	if err := h.SetupRoutesWithConfig(mux, RouteConfig{Prefix: "/x402", Paths: map[string]string{"/verify": "/v1/verify", "/health": ""}}); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/x402/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /x402/openapi.json: status %d", rec.Code)
+	}
+	var doc struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi %q, want 3.1.0", doc.OpenAPI)
+	}
+	for path, want := range map[string]bool{"/x402/v1/verify": true, "/x402/settle": true, "/x402/supported": true, "/verify": false, "/x402/health": false} {
+		if _, ok := doc.Paths[path]; ok != want {
+			t.Errorf("path %s documented %v, want %v", path, ok, want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/x402/openapi.json", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /openapi.json: status %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestDocsOnlyInDevelopmentMode(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		h := NewHandler(nil)
+		h.SetDocs(enabled)
+		mux := http.NewServeMux()
+		if err := h.SetupRoutesWithConfig(mux, RouteConfig{Prefix: "/x402"}); err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/x402/docs", nil))
+		if !enabled {
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("docs disabled: status %d, want %d", rec.Code, http.StatusNotFound)
+			}
+			continue
+		}
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+			t.Fatalf("docs enabled: status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(rec.Body.String(), `"/x402/openapi.json"`) {
+			t.Errorf("docs page does not load the mounted document: %s", rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/x402-rs/x402-go/pkg/middleware"
+)
+
+// RouteConfig controls where SetupRoutesWithConfig mounts the endpoints
+type RouteConfig struct {
+	// Prefix is put in front of every path, e.g. "/x402" serves /x402/verify
+	// ("" or "/" mounts at the root)
+	Prefix string
+	// Paths renames endpoints by their default path, e.g.
+	// {"/verify": "/v1/verify"}; an empty path disables the endpoint.
+	// Renamed paths get the prefix too.
+	Paths map[string]string
+}
+
+// route is one endpoint at its default path
+type route struct {
+	path    string
+	handler http.HandlerFunc
+	subtree bool // Also serves the paths below path (the handler parses them)
+	settles bool // Spends the facilitator's gas, so POSTs need settlement credentials
+	admin   bool // Needs the admin token
+	byPayer bool // POSTs are rate limited per payer
+}
+
+// routes lists the endpoints at their default paths
+func (h *Handler) routes() []route {
+	return []route{
+		{path: "/verify", handler: h.VerifyHandler, byPayer: true},
+		{path: "/settle", handler: h.SettleHandler, settles: true, byPayer: true},
+		{path: "/settle/simulate", handler: h.SimulateHandler, settles: true},
+		{path: "/cancel", handler: h.CancelHandler, settles: true},
+		{path: "/supported", handler: h.SupportedHandler},
+		{path: "/accounting/gas", handler: h.GasAccountingHandler},
+		{path: "/accounting/settlements", handler: h.SettlementJournalHandler},
+		{path: "/settlements", handler: h.SettlementHandler, subtree: true},
+		{path: "/admin/stats", handler: h.StatsHandler, admin: true},
+		{path: "/admin/networks", handler: h.AdminNetworksHandler, subtree: true, admin: true},
+		{path: "/health", handler: h.HealthHandler},
+		{path: "/health/ready", handler: h.ReadyHandler},
+		{path: "/version", handler: h.VersionHandler},
+		{path: "/openapi.json", handler: h.OpenAPIHandler},
+		{path: "/docs", handler: h.DocsHandler},
+		{path: "/dev/fund", handler: h.DevFundHandler}, // Testnet only, limited per address
+	}
+}
+
+// SetupRoutesWithPrefix registers all endpoints under prefix (see
+// SetupRoutesWithConfig)
+func (h *Handler) SetupRoutesWithPrefix(mux *http.ServeMux, prefix string) error {
+	return h.SetupRoutesWithConfig(mux, RouteConfig{Prefix: prefix})
+}
+
+// SetupRoutesWithConfig registers the endpoints on mux as config describes
+// A pattern mux already has (e.g. an application's own /health) returns an
+// error naming it instead of panicking; endpoints registered before it stay
+// registered, so disable or rename the clashing endpoint and use a fresh mux.
+func (h *Handler) SetupRoutesWithConfig(mux *http.ServeMux, config RouteConfig) error {
+	prefix, err := normalizeRoutePrefix(config.Prefix)
+	if err != nil {
+		return err
+	}
+	routes := h.routes()
+	known := make(map[string]bool, len(routes))
+	for _, rt := range routes {
+		known[rt.path] = true
+	}
+	for from, to := range config.Paths {
+		if !known[from] {
+			return fmt.Errorf("unknown route %q (want one of the default paths, e.g. /verify)", from)
+		}
+		if to != "" && (!strings.HasPrefix(to, "/") || to == "/" || strings.ContainsAny(to, " \t?#{}")) {
+			return fmt.Errorf("invalid path %q for route %s", to, from)
+		}
+	}
+
+	h.mounted = make(map[string]string, len(routes))
+	h.patterns = make(map[string]route)
+	for _, rt := range routes {
+		path := rt.path
+		if to, ok := config.Paths[rt.path]; ok {
+			if to == "" {
+				h.mounted[rt.path] = ""
+				continue
+			}
+			path = strings.TrimSuffix(to, "/")
+		}
+		mounted := prefix + path
+		h.mounted[rt.path] = mounted
+
+		var handler http.Handler = rt.handler
+		if mounted != rt.path {
+			handler = rebasePath(mounted, rt.path, handler)
+		}
+		if err := handleRoute(mux, mounted, handler); err != nil {
+			return err
+		}
+		h.patterns[mounted] = rt
+		if rt.subtree {
+			if err := handleRoute(mux, mounted+"/", handler); err != nil {
+				return err
+			}
+			h.patterns[mounted+"/"] = rt
+		}
+	}
+	return nil
+}
+
+// SettleRoutes matches the requests mux dispatches to a gas-spending
+// endpoint, wherever SetupRoutesWithConfig mounted it on mux: pass it as
+// middleware.StackConfig.SettleRoutes so the settlement credentials guard
+// exactly what is served. Spellings mux redirects (e.g. //settle) match the
+// route they redirect to.
+func (h *Handler) SettleRoutes(mux *http.ServeMux) middleware.RouteMatcher {
+	return h.matchRoutes(mux, func(rt route) bool { return rt.settles })
+}
+
+// AdminRoutes matches the requests mux dispatches to an admin endpoint
+// (middleware.StackConfig.AdminRoutes), as SettleRoutes does for settlement
+func (h *Handler) AdminRoutes(mux *http.ServeMux) middleware.RouteMatcher {
+	return h.matchRoutes(mux, func(rt route) bool { return rt.admin })
+}
+
+// PayerRoutes matches the requests mux dispatches to /verify or /settle
+// (middleware.StackConfig.PayerRoutes), as SettleRoutes does for settlement
+func (h *Handler) PayerRoutes(mux *http.ServeMux) middleware.RouteMatcher {
+	return h.matchRoutes(mux, func(rt route) bool { return rt.byPayer })
+}
+
+// matchRoutes matches the requests mux dispatches to a route that has want
+func (h *Handler) matchRoutes(mux *http.ServeMux, want func(route) bool) middleware.RouteMatcher {
+	patterns := h.patterns
+	return func(r *http.Request) bool {
+		_, pattern := mux.Handler(r)
+		rt, ok := patterns[pattern]
+		return ok && want(rt)
+	}
+}
+
+// normalizeRoutePrefix returns prefix as "/segment[/segment...]" without a
+// trailing slash, "" for the root
+func normalizeRoutePrefix(prefix string) (string, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" || prefix == "/" {
+		return "", nil
+	}
+	if !strings.HasPrefix(prefix, "/") || strings.ContainsAny(prefix, " \t?#{}") {
+		return "", fmt.Errorf("invalid route prefix %q (want a path such as /x402)", prefix)
+	}
+	return strings.TrimSuffix(prefix, "/"), nil
+}
+
+// handleRoute registers pattern on mux, turning the panic ServeMux raises
+// for a duplicate or conflicting pattern into an error
+func handleRoute(mux *http.ServeMux, pattern string, handler http.Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cannot register route %s: %v", pattern, r)
+		}
+	}()
+	mux.Handle(pattern, handler)
+	return nil
+}
+
+// rebasePath serves a request for mounted (or a path below it) to next as
+// if it had been made to the default path, since some handlers parse the path
+func rebasePath(mounted, defaultPath string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, mounted)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		r2 := r.Clone(r.Context())
+		r2.URL.Path, r2.URL.RawPath = defaultPath+rest, ""
+		next.ServeHTTP(w, r2)
+	})
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/config"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/middleware"
+)
+
+// spa stands in for the frontend the server mounts at "/"
+var spa = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("spa"))
+})
+
+func TestSettleRoutesGuardEveryGasSpendingRoute(t *testing.T) {
+	keys, err := middleware.ParseAPIKeys("shop:sk_test_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, config := range map[string]RouteConfig{
+		"default":  {},
+		"prefixed": {Prefix: "/x402"},
+		"renamed":  {Paths: map[string]string{"/settle": "/v1/pay", "/cancel": "/v1/revoke"}},
+	} {
+		h := NewHandler(nil)
+		mux := http.NewServeMux()
+		if err := h.SetupRoutesWithConfig(mux, config); err != nil {
+			t.Fatal(err)
+		}
+		mux.Handle("/", spa)
+		server := middleware.DefaultStack(middleware.StackConfig{
+			SettleAPIKeys: keys,
+			SettleRoutes:  h.SettleRoutes(mux),
+		}).Then(mux)
+		post := func(path, key string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "http://facilitator.test"+path, nil)
+			if key != "" {
+				req.Header.Set("Authorization", "Bearer "+key)
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			return rec
+		}
+
+		var guarded int
+		for _, rt := range h.routes() {
+			mounted := h.mounted[rt.path]
+			if !rt.settles {
+				if rec := post(mounted, ""); rec.Code == http.StatusUnauthorized {
+					t.Errorf("%s: POST %s needs no key, but was refused", name, mounted)
+				}
+				continue
+			}
+			guarded++
+			if rec := post(mounted, ""); rec.Code != http.StatusUnauthorized {
+				t.Errorf("%s: unauthenticated POST %s: status %d, want %d", name, mounted, rec.Code, http.StatusUnauthorized)
+			}
+			if rec := post(mounted, "sk_test_key"); rec.Code == http.StatusUnauthorized {
+				t.Errorf("%s: POST %s with a key was refused", name, mounted)
+			}
+			// Spellings the mux serves elsewhere (the SPA) or redirects to the
+			// route must not reach the endpoint without a key
+			for _, variant := range []string{mounted + "/", "/" + mounted, mounted + "/.", mounted + "/sub"} {
+				rec := post(variant, "")
+				if rec.Code != http.StatusUnauthorized && rec.Body.String() != "spa" {
+					t.Errorf("%s: unauthenticated POST %s: status %d, body %q; want 401 or the SPA", name, variant, rec.Code, rec.Body.String())
+				}
+			}
+		}
+		if guarded != 3 {
+			t.Errorf("%s: %d gas-spending routes guarded, want 3 (/settle, /settle/simulate, /cancel)", name, guarded)
+		}
+	}
+}
+
+func TestAdminRoutesGuardRenamedAdminPaths(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenants, err := config.NewTenantRegistry([]config.TenantConfig{{ID: "shop", APIKeys: []string{"sk_shop"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tenants.Stop()
+	h := NewHandler(fac)
+	mux := http.NewServeMux()
+	if err := h.SetupRoutesWithConfig(mux, RouteConfig{Paths: map[string]string{"/admin/networks": "/ops/networks", "/admin/stats": "/ops/stats"}}); err != nil {
+		t.Fatal(err)
+	}
+	mux.Handle("/", spa)
+	server := middleware.DefaultStack(middleware.StackConfig{
+		AdminToken:  "admin-secret",
+		AdminRoutes: h.AdminRoutes(mux),
+		Tenants:     tenants,
+	}).Then(mux)
+	call := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://facilitator.test"+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	disable := "/ops/networks/" + string(testchain.Network) + "/disable"
+	for _, path := range []string{disable, "/" + disable, "/ops/stats"} {
+		if rec := call(http.MethodPost, path, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("unauthenticated POST %s: status %d, want %d", path, rec.Code, http.StatusUnauthorized)
+		}
+	}
+	if rec := call(http.MethodGet, "/ops/networks", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated GET /ops/networks: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if disabled := fac.DisabledNetworks(); len(disabled) != 0 {
+		t.Fatalf("unauthenticated requests disabled %v", disabled)
+	}
+
+	// The admin token passes, and the tenant check leaves admin routes alone
+	if rec := call(http.MethodPost, disable, "admin-secret"); rec.Code != http.StatusOK {
+		t.Errorf("POST %s with the admin token: status %d: %s", disable, rec.Code, rec.Body.String())
+	}
+	if _, ok := fac.DisabledNetworks()[testchain.Network]; !ok {
+		t.Errorf("disabled networks %v, want %s", fac.DisabledNetworks(), testchain.Network)
+	}
+}
+
+func TestPayerRoutesLimitRenamedPaths(t *testing.T) {
+	limiter := middleware.NewRateLimiter(1, 1)
+	defer limiter.Stop()
+	h := NewHandler(facilitator.NewLocalFacilitator())
+	mux := http.NewServeMux()
+	if err := h.SetupRoutesWithConfig(mux, RouteConfig{Paths: map[string]string{"/verify": "/v1/check"}}); err != nil {
+		t.Fatal(err)
+	}
+	server := middleware.DefaultStack(middleware.StackConfig{
+		PayerLimiter: limiter,
+		PayerRoutes:  h.PayerRoutes(mux),
+	}).Then(mux)
+
+	body := `{"paymentPayload":{"payload":{"authorization":{"from":"0x00000000000000000000000000000000000000a1"}}}}`
+	var codes []int
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://facilitator.test/v1/check", strings.NewReader(body)))
+		codes = append(codes, rec.Code)
+	}
+	if codes[0] == http.StatusTooManyRequests || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("two POSTs to the renamed /verify by one payer: statuses %v, want the second limited", codes)
+	}
+}
+
+// status serves method path on h and returns the status
+func status(h http.Handler, method, path string) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, "http://facilitator.test"+path, strings.NewReader("{}")))
+	return rec.Code
+}
+
+func TestSetupRoutesWithPrefix(t *testing.T) {
+	mux := http.NewServeMux()
+	if err := NewHandler(facilitator.NewLocalFacilitator()).SetupRoutesWithPrefix(mux, "/x402/"); err != nil {
+		t.Fatal(err)
+	}
+	if code := status(mux, http.MethodGet, "/x402/version"); code != http.StatusOK {
+		t.Errorf("GET /x402/version: status %d, want 200", code)
+	}
+	if code := status(mux, http.MethodPost, "/x402/verify"); code == http.StatusNotFound {
+		t.Error("POST /x402/verify is not served")
+	}
+	for _, path := range []string{"/verify", "/version", "/health"} {
+		if code := status(mux, http.MethodGet, path); code != http.StatusNotFound {
+			t.Errorf("GET %s without the prefix: status %d, want 404", path, code)
+		}
+	}
+
+	if err := NewHandler(facilitator.NewLocalFacilitator()).SetupRoutesWithPrefix(http.NewServeMux(), "x402"); err == nil {
+		t.Error("a prefix without a leading slash was accepted")
+	}
+}
+
+func TestSetupRoutesRenamesAndDisables(t *testing.T) {
+	h := NewHandler(facilitator.NewLocalFacilitator())
+	mux := http.NewServeMux()
+	if err := h.SetupRoutesWithConfig(mux, RouteConfig{Prefix: "/x402", Paths: map[string]string{"/version": "/v1/about", "/docs": ""}}); err != nil {
+		t.Fatal(err)
+	}
+	if code := status(mux, http.MethodGet, "/x402/v1/about"); code != http.StatusOK {
+		t.Errorf("renamed /version: status %d, want 200", code)
+	}
+	for _, path := range []string{"/x402/version", "/x402/docs"} {
+		if code := status(mux, http.MethodGet, path); code != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", path, code)
+		}
+	}
+
+	for name, paths := range map[string]map[string]string{
+		"unknown route": {"/nope": "/v1/nope"},
+		"relative path": {"/verify": "v1/verify"},
+		"root path":     {"/verify": "/"},
+	} {
+		if err := NewHandler(facilitator.NewLocalFacilitator()).SetupRoutesWithConfig(http.NewServeMux(), RouteConfig{Paths: paths}); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestSetupRoutesReportsConflicts(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/health", spa)
+	err := NewHandler(facilitator.NewLocalFacilitator()).SetupRoutesWithConfig(mux, RouteConfig{})
+	if err == nil || !strings.Contains(err.Error(), "/health") {
+		t.Fatalf("registering over the application's /health: %v, want an error naming it", err)
+	}
+
+	// Moving the clashing endpoint aside mounts the rest
+	mux = http.NewServeMux()
+	mux.Handle("/health", spa)
+	if err := NewHandler(facilitator.NewLocalFacilitator()).SetupRoutesWithConfig(mux, RouteConfig{Paths: map[string]string{"/health": "/facilitator/health"}}); err != nil {
+		t.Fatal(err)
+	}
+	if code := status(mux, http.MethodGet, "/facilitator/health"); code == http.StatusNotFound {
+		t.Error("the moved /health is not served")
+	}
+}
+
+func TestRebasePathServesDefaultPath(t *testing.T) {
+	var seen string
+	handler := rebasePath("/x402/settlements", "/settlements", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.Path
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/x402/settlements/abc", nil))
+	if seen != "/settlements/abc" {
+		t.Errorf("handler saw %q, want the default path", seen)
+	}
+	if code := status(handler, http.MethodGet, "/elsewhere"); code != http.StatusNotFound {
+		t.Errorf("a path outside the mount: status %d, want 404", code)
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/version"
+)
+
+func TestSupportedListsSolanaMints(t *testing.T) {
+	fac, err := facilitator.NewBuilder().
+		WithSolanaNetwork(types.NetworkSolana).
+		WithSolanaNetwork(types.NetworkSolanaDevnet).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(fac).SetupRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/supported", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Kinds []struct {
+			Network types.Network `json:"network"`
+			Token   struct {
+				Type    string `json:"type"`
+				Address string `json:"address"`
+			} `json:"token"`
+			TokenSymbol string `json:"token_symbol"`
+			Decimals    int    `json:"decimals"`
+		} `json:"kinds"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[types.Network]string{
+		types.NetworkSolana:       "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+		types.NetworkSolanaDevnet: "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
+	}
+	seen := make(map[types.Network]bool)
+	for _, kind := range body.Kinds {
+		mint, ok := want[kind.Network]
+		if !ok {
+			t.Errorf("unexpected network %s in /supported", kind.Network)
+			continue
+		}
+		seen[kind.Network] = true
+		if kind.Token.Type != "solana" || kind.Token.Address != mint || kind.TokenSymbol != "USDC" || kind.Decimals != 6 {
+			t.Errorf("%s: %+v, want USDC at %s with 6 decimals", kind.Network, kind, mint)
+		}
+	}
+	if len(seen) != len(want) {
+		t.Errorf("/supported lists %v, want both Solana networks", seen)
+	}
+}
+
+func TestSolanaNetworkWithoutMintIsRejected(t *testing.T) {
+	if _, err := facilitator.NewBuilder().WithSolanaNetwork(types.NetworkBase).Build(); err == nil {
+		t.Error("a network without a Solana mint was listed")
+	}
+}
+
+func TestVersionReportsEnabledFeatures(t *testing.T) {
+	fac, err := facilitator.NewBuilder().WithSolanaNetwork(types.NetworkSolanaDevnet).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(fac).SetupRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var info version.Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != version.Version || len(info.X402Versions) == 0 {
+		t.Errorf("build info %+v", info)
+	}
+	features := make(map[string]bool)
+	for _, feature := range info.Features {
+		features[feature] = true
+	}
+	if !features[version.FeatureSolana] {
+		t.Errorf("features %v, want solana", info.Features)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/version", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /version: status %d", rec.Code)
+	}
+}
+
+// listingFacilitator lists fixed kinds and cannot filter them itself
+type listingFacilitator struct {
+	facilitator.Facilitator
+	kinds []types.SupportedPaymentKind
+}
+
+func (f listingFacilitator) Supported(context.Context) (*types.SupportedPaymentKindsResponse, error) {
+	return &types.SupportedPaymentKindsResponse{Kinds: f.kinds}, nil
+}
+
+// getSupported requests /supported with query and decodes the response
+func getSupported(t *testing.T, mux http.Handler, query string) (int, types.SupportedPaymentKindsResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/supported"+query, nil))
+	var resp types.SupportedPaymentKindsResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: %v (%s)", query, err, rec.Body.String())
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestSupportedFilters(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	local, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	listing := listingFacilitator{kinds: []types.SupportedPaymentKind{
+		{Scheme: types.SchemeExact, Network: testchain.Network, TokenSymbol: "USDC", Settlement: true},
+		{Scheme: types.SchemeExact, Network: types.NetworkBase, TokenSymbol: "USDC", Settlement: false},
+	}}
+
+	for name, fac := range map[string]facilitator.Facilitator{"local": local, "listing": listing} {
+		mux := http.NewServeMux()
+		NewHandler(fac).SetupRoutes(mux)
+
+		_, all := getSupported(t, mux, "")
+		if all.Filters != nil || len(all.Kinds) == 0 {
+			t.Fatalf("%s: unfiltered response %+v", name, all)
+		}
+		for _, tc := range []struct {
+			query string
+			want  int // Kinds on the test chain expected to pass
+		}{
+			{"?network=testchain", 1},
+			{"?network=TestChain&scheme=exact", 1},
+			{"?scheme=exact&token_symbol=usdc", 1},
+			{"?network=testchain&settleable=true", 1},
+			{"?network=testchain&settleable=false", 0},
+			{"?network=testchain&token_symbol=EURC", 0},
+			{"?network=atlantis", 0},
+			{"?scheme=upto", 0},
+		} {
+			code, resp := getSupported(t, mux, tc.query)
+			if code != http.StatusOK {
+				t.Errorf("%s %s: status %d", name, tc.query, code)
+				continue
+			}
+			if resp.Kinds == nil || resp.Filters == nil {
+				t.Errorf("%s %s: kinds %v, filters %v; want a list and the filters echoed", name, tc.query, resp.Kinds, resp.Filters)
+				continue
+			}
+			onChain := 0
+			for _, kind := range resp.Kinds {
+				if !resp.Filters.Matches(kind) {
+					t.Errorf("%s %s: %+v does not pass the echoed filters %+v", name, tc.query, kind, *resp.Filters)
+				}
+				if kind.Network == testchain.Network && kind.Scheme == types.SchemeExact {
+					onChain++
+				}
+			}
+			if onChain != tc.want {
+				t.Errorf("%s %s: %d exact kinds on the test chain, want %d", name, tc.query, onChain, tc.want)
+			}
+		}
+
+		_, unsettled := getSupported(t, mux, "?settleable=false")
+		for _, kind := range unsettled.Kinds {
+			if kind.Settlement {
+				t.Errorf("%s: settleable=false listed %+v", name, kind)
+			}
+		}
+		if code, _ := getSupported(t, mux, "?settleable=maybe"); code != http.StatusBadRequest {
+			t.Errorf("%s: settleable=maybe answered %d, want %d", name, code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// withField adds field to the JSON object body
+func withField(t *testing.T, body []byte, field string, value interface{}) []byte {
+	t.Helper()
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	fields[field] = value
+	data, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSettleAcceptsVerificationIDInBothVersions(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(fac).SetupRoutes(mux)
+	post := func(path string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	for _, fixture := range []string{"verify_v1.json", "verify_v2.json"} {
+		requirements := chain.Requirements(payTo, big.NewInt(1000))
+		payload, err := chain.Authorize(chain.Accounts[0], requirements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		auth := payload.Payload.Authorization
+		body := fixtureRequest(t, fixture, fixtureFields{
+			Network:     string(testchain.Network),
+			Asset:       testchain.TokenAddress.Hex(),
+			PayTo:       payTo.Hex(),
+			Amount:      "1000",
+			Signature:   payload.Payload.Signature.String(),
+			From:        auth.From.Hex(),
+			To:          auth.To.Hex(),
+			Value:       auth.Value,
+			ValidAfter:  auth.ValidAfter,
+			ValidBefore: auth.ValidBefore,
+			Nonce:       auth.Nonce.String(),
+		})
+		if err := chain.AdjustTime(time.Second); err != nil {
+			t.Fatal(err)
+		}
+
+		rec := post("/verify", body)
+		var verified types.VerifyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &verified); err != nil {
+			t.Fatal(err)
+		}
+		if !verified.IsValid || !strings.HasPrefix(verified.VerificationID, "0x") {
+			t.Fatalf("%s verify: %s", fixture, rec.Body.String())
+		}
+
+		// The decoded request carries the ID to the facilitator
+		settleBody := withField(t, body, "verificationId", verified.VerificationID)
+		decoded, err := decodeSettleRequest(bytes.NewReader(settleBody), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.VerificationID != verified.VerificationID {
+			t.Errorf("%s: decoded verification ID %q, want %q", fixture, decoded.VerificationID, verified.VerificationID)
+		}
+
+		rec = post("/settle", settleBody)
+		var settled types.SettleResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &settled); err != nil {
+			t.Fatal(err)
+		}
+		if !settled.Success {
+			t.Errorf("%s settle with a verification ID: %s", fixture, rec.Body.String())
+		}
+	}
+}
+
+// refusingFacilitator refuses every payment with err
+type refusingFacilitator struct {
+	facilitator.Facilitator
+	err *types.FacilitatorError
+}
+
+func (f refusingFacilitator) Verify(context.Context, *types.VerifyRequest) (*types.VerifyResponse, error) {
+	return nil, f.err
+}
+
+func (f refusingFacilitator) Settle(context.Context, *types.SettleRequest) (*types.SettleResponse, error) {
+	return nil, f.err
+}
+
+func (f refusingFacilitator) Simulate(context.Context, *types.SettleRequest) (*types.SimulateResponse, error) {
+	return nil, f.err
+}
+
+func TestRefusalsFallBackToPayloadPayer(t *testing.T) {
+	from := "0x00000000000000000000000000000000000000A1"
+	body := fixtureRequest(t, "verify_v1.json", fixtureFields{
+		Network:     "base-sepolia",
+		Asset:       "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+		PayTo:       "0x00000000000000000000000000000000000000b0",
+		Amount:      "1000",
+		Signature:   "0x" + strings.Repeat("11", 65),
+		From:        from,
+		To:          "0x00000000000000000000000000000000000000b0",
+		Value:       "1000",
+		ValidAfter:  "0",
+		ValidBefore: "4102444800",
+		Nonce:       "0x" + strings.Repeat("22", 32),
+	})
+	mux := http.NewServeMux()
+	NewHandler(refusingFacilitator{err: types.NewUnsupportedNetworkError(nil)}).SetupRoutes(mux)
+
+	for _, path := range []string{"/verify", "/settle/simulate"} {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var resp struct {
+			Payer *types.MixedAddress `json:"payer"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: %v (%s)", path, err, rec.Body.String())
+		}
+		if rec.Code != http.StatusOK || resp.Payer == nil || !strings.EqualFold(resp.Payer.Address, from) {
+			t.Errorf("%s: status %d, payer %+v; want the refusal to name %s", path, rec.Code, resp.Payer, from)
+		}
+	}
+}
+
+func TestServiceDegradedAnswers503(t *testing.T) {
+	body := fixtureRequest(t, "verify_v1.json", fixtureFields{
+		Network:     "base-sepolia",
+		Asset:       "0x036CbD53842c5426634e7929541eC2318f3dCF7e",
+		PayTo:       "0x00000000000000000000000000000000000000b0",
+		Amount:      "1000",
+		Signature:   "0x" + strings.Repeat("11", 65),
+		From:        "0x00000000000000000000000000000000000000A1",
+		To:          "0x00000000000000000000000000000000000000b0",
+		Value:       "1000",
+		ValidAfter:  "0",
+		ValidBefore: "4102444800",
+		Nonce:       "0x" + strings.Repeat("22", 32),
+	})
+	for name, err := range map[string]*types.FacilitatorError{
+		"degraded": types.NewServiceDegradedError("RPC for base-sepolia is failing"),
+		"timeout":  types.NewTimeoutError("balance check timed out"),
+	} {
+		mux := http.NewServeMux()
+		NewHandler(refusingFacilitator{err: err}).SetupRoutes(mux)
+		want := http.StatusServiceUnavailable
+		if name == "timeout" {
+			want = http.StatusGatewayTimeout
+		}
+		for _, path := range []string{"/verify", "/settle", "/settle/simulate"} {
+			req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != want || !strings.Contains(rec.Body.String(), err.Message) {
+				t.Errorf("%s %s: status %d: %s; want %d with the message", name, path, rec.Code, rec.Body.String(), want)
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/internal/testchain"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// fixtureFields fill the testdata request templates
+type fixtureFields struct {
+	Network, Asset, PayTo, Amount                              string
+	Signature, From, To, Value, ValidAfter, ValidBefore, Nonce string
+}
+
+// fixtureRequest renders the testdata template name with fields
+func fixtureRequest(t *testing.T, name string, fields fixtureFields) []byte {
+	t.Helper()
+	tmpl, err := template.ParseFiles("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, fields); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestVerifyAcceptsV1AndV2Identically(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(fac).SetupRoutes(mux)
+
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := payload.Payload.Authorization
+	valid := fixtureFields{
+		Network:     string(testchain.Network),
+		Asset:       testchain.TokenAddress.Hex(),
+		PayTo:       payTo.Hex(),
+		Amount:      "1000",
+		Signature:   payload.Payload.Signature.String(),
+		From:        auth.From.Hex(),
+		To:          auth.To.Hex(),
+		Value:       auth.Value,
+		ValidAfter:  auth.ValidAfter,
+		ValidBefore: auth.ValidBefore,
+		Nonce:       auth.Nonce.String(),
+	}
+	underpaid := valid
+	underpaid.Amount = "1001"
+
+	for name, fields := range map[string]fixtureFields{"valid": valid, "underpaid": underpaid} {
+		var responses []types.VerifyResponse
+		for _, fixture := range []string{"verify_v1.json", "verify_v2.json"} {
+			req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(fixtureRequest(t, fixture, fields)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s %s: status %d: %s", name, fixture, rec.Code, rec.Body.String())
+			}
+			var resp types.VerifyResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			responses = append(responses, resp)
+		}
+		v1, v2 := responses[0], responses[1]
+		if v1.IsValid != v2.IsValid || v1.ReasonCode != v2.ReasonCode || v1.Payer == nil || v2.Payer == nil || v1.Payer.Address != v2.Payer.Address {
+			t.Errorf("%s: v1 answered %+v, v2 %+v; want the same verdict", name, v1, v2)
+		}
+		if want := name == "valid"; v1.IsValid != want {
+			t.Errorf("%s: valid %v (%s), want %v", name, v1.IsValid, v1.Reason, want)
+		}
+		if name == "underpaid" && v1.ReasonCode != types.ReasonInsufficientValue {
+			t.Errorf("underpaid: reason code %q, want %q", v1.ReasonCode, types.ReasonInsufficientValue)
+		}
+	}
+}
+
+func TestVerifyAcceptsXDCPrefixedAddresses(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(fac).SetupRoutes(mux)
+
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := payload.Payload.Authorization
+	fields := fixtureFields{
+		Network:     string(testchain.Network),
+		Asset:       types.FormatXDCAddress(testchain.TokenAddress.Hex()),
+		PayTo:       types.FormatXDCAddress(payTo.Hex()),
+		Amount:      "1000",
+		Signature:   payload.Payload.Signature.String(),
+		From:        types.FormatXDCAddress(auth.From.Hex()),
+		To:          types.FormatXDCAddress(auth.To.Hex()),
+		Value:       auth.Value,
+		ValidAfter:  auth.ValidAfter,
+		ValidBefore: auth.ValidBefore,
+		Nonce:       auth.Nonce.String(),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(fixtureRequest(t, "verify_v1.json", fields)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp types.VerifyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsValid || resp.Payer == nil || resp.Payer.Address != auth.From.Hex() {
+		t.Errorf("xdc-prefixed payment: valid %v (%s), payer %v; want it valid for %s", resp.IsValid, resp.Reason, resp.Payer, auth.From.Hex())
+	}
+}
+
+func TestVerifyNormalizesLegacyHexUnlessStrict(t *testing.T) {
+	chain, err := testchain.New(1, big.NewInt(10_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	fac, err := chain.Facilitator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewHandler(fac).SetupRoutes(mux)
+
+	payTo := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	requirements := chain.Requirements(payTo, big.NewInt(1000))
+	payload, err := chain.Authorize(chain.Accounts[0], requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := payload.Payload.Authorization
+	body := fixtureRequest(t, "verify_v1.json", fixtureFields{
+		Network:     string(testchain.Network),
+		Asset:       testchain.TokenAddress.Hex(),
+		PayTo:       payTo.Hex(),
+		Amount:      "1000",
+		Signature:   strings.TrimPrefix(payload.Payload.Signature.String(), "0x"),
+		From:        auth.From.Hex(),
+		To:          auth.To.Hex(),
+		Value:       auth.Value,
+		ValidAfter:  auth.ValidAfter,
+		ValidBefore: auth.ValidBefore,
+		Nonce:       "0X" + strings.ToUpper(strings.TrimPrefix(auth.Nonce.String(), "0x")),
+	})
+
+	for _, strict := range []bool{false, true} {
+		types.SetStrictHex(strict)
+		req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		types.SetStrictHex(false)
+
+		if strict {
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "missing 0x prefix") {
+				t.Errorf("strict: status %d: %s; want the legacy hex refused", rec.Code, rec.Body.String())
+			}
+			continue
+		}
+		var resp types.VerifyResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("lenient: status %d: %s", rec.Code, rec.Body.String())
+		}
+		if !resp.IsValid {
+			t.Errorf("lenient: refused as %s (%s), want the normalized payment valid", resp.ReasonCode, resp.Reason)
+		}
+	}
+}
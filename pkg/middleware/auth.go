@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// settlePaths are the endpoints that spend gas at their default paths
+var settlePaths = map[string]bool{
+	"/settle":          true,
+	"/settle/simulate": true,
+	"/cancel":          true, // The facilitator pays for cancelAuthorization
+}
+
+// RouteMatcher reports whether r is for one of a set of routes
+type RouteMatcher func(r *http.Request) bool
+
+// DefaultSettleRoutes matches the gas-spending endpoints at their default
+// paths, in any spelling that cleans to one (e.g. /settle/ or //cancel).
+// Servers that mount or rename routes match the routes they registered
+// instead (see handlers.Handler.SettleRoutes).
+func DefaultSettleRoutes(r *http.Request) bool {
+	return settlePaths[path.Clean("/"+r.URL.Path)]
+}
+
+// DefaultAdminRoutes matches the admin endpoints at their default paths
+// (anything below /admin/); servers that rename them match the routes they
+// registered instead (see handlers.Handler.AdminRoutes)
+func DefaultAdminRoutes(r *http.Request) bool {
+	return strings.HasPrefix(path.Clean("/"+r.URL.Path)+"/", "/admin/")
+}
+
+// APIKeys maps settlement API keys to their labels
+// Keys are stored as SHA-256 digests so lookups compare fixed-length values
+type APIKeys struct {
+	digests [][sha256.Size]byte
+	labels  []string
+}
+
+// ParseAPIKeys parses a comma-separated key list (SETTLE_API_KEYS)
+// Entries are "label:key" or a bare key, which is labelled key-<n>
+func ParseAPIKeys(list string) (*APIKeys, error) {
+	keys := &APIKeys{}
+	for i, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		label, key, ok := strings.Cut(entry, ":")
+		if !ok {
+			label, key = fmt.Sprintf("key-%d", i+1), entry
+		}
+		if err := keys.add(label, key); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// LoadAPIKeysFile reads "label key" lines (blank lines and # comments
+// are skipped) from path
+func LoadAPIKeysFile(path string) (*APIKeys, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	keys := &APIKeys{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want \"label key\"", path, line)
+		}
+		if err := keys.add(fields[0], fields[1]); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Merge adds the keys of other
+func (k *APIKeys) Merge(other *APIKeys) error {
+	for i, digest := range other.digests {
+		if k.index(digest) >= 0 {
+			return fmt.Errorf("API key %q is configured twice", other.labels[i])
+		}
+		k.digests = append(k.digests, digest)
+		k.labels = append(k.labels, other.labels[i])
+	}
+	return nil
+}
+
+// Len returns the number of configured keys
+func (k *APIKeys) Len() int {
+	return len(k.digests)
+}
+
+// Lookup returns the label of key, comparing against every configured key
+// in constant time
+func (k *APIKeys) Lookup(key string) (string, bool) {
+	if i := k.index(sha256.Sum256([]byte(key))); i >= 0 {
+		return k.labels[i], true
+	}
+	return "", false
+}
+
+// Add adds key with label
+func (k *APIKeys) Add(label, key string) error {
+	return k.add(label, key)
+}
+
+func (k *APIKeys) add(label, key string) error {
+	if label == "" || key == "" {
+		return fmt.Errorf("API key entries need a label and a key")
+	}
+	digest := sha256.Sum256([]byte(key))
+	if k.index(digest) >= 0 {
+		return fmt.Errorf("API key %q is configured twice", label)
+	}
+	k.digests = append(k.digests, digest)
+	k.labels = append(k.labels, label)
+	return nil
+}
+
+// index finds digest without stopping at the first match, so timing does
+// not reveal which key (if any) matched
+func (k *APIKeys) index(digest [sha256.Size]byte) int {
+	found := -1
+	for i := range k.digests {
+		if subtle.ConstantTimeCompare(k.digests[i][:], digest[:]) == 1 {
+			found = i
+		}
+	}
+	return found
+}
+
+type apiKeyContextKey struct{}
+
+// APIKeyLabel returns the label of the API key that authenticated the
+// request, or "" if it was not authenticated
+func APIKeyLabel(ctx context.Context) string {
+	label, _ := ctx.Value(apiKeyContextKey{}).(string)
+	return label
+}
+
+// AuthMiddleware creates HTTP middleware requiring an "Authorization: Bearer
+// <key>" header on POST /settle, /settle/simulate and /cancel
+// Other endpoints (verify, supported, GET endpoint info) stay public. The
+// key's label is available to handlers through APIKeyLabel.
+func AuthMiddleware(keys *APIKeys) func(http.Handler) http.Handler {
+	return AuthMiddlewareFor(keys, DefaultSettleRoutes)
+}
+
+// AuthMiddlewareFor is AuthMiddleware guarding the POSTs settles matches
+func AuthMiddlewareFor(keys *APIKeys, settles RouteMatcher) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || !settles(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="x402-settle"`)
+				http.Error(w, "Settlement requires an API key (Authorization: Bearer <key>)", http.StatusUnauthorized)
+				return
+			}
+			label, ok := keys.Lookup(strings.TrimSpace(token))
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="x402-settle", error="invalid_token"`)
+				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, label)))
+		})
+	}
+}
+
+// AdminAuthMiddleware creates HTTP middleware guarding /admin/ endpoints with
+// "Authorization: Bearer <token>"
+// Without a token, read-only admin endpoints (GET) stay open as before and
+// state-changing ones (e.g. disabling a network) are refused.
+func AdminAuthMiddleware(token string) func(http.Handler) http.Handler {
+	return AdminAuthMiddlewareFor(token, DefaultAdminRoutes)
+}
+
+// AdminAuthMiddlewareFor is AdminAuthMiddleware guarding the requests admin
+// matches
+func AdminAuthMiddlewareFor(token string, admin RouteMatcher) func(http.Handler) http.Handler {
+	digest := sha256.Sum256([]byte(token))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !admin(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if token == "" {
+				if r.Method == http.MethodGet || r.Method == http.MethodHead {
+					next.ServeHTTP(w, r)
+					return
+				}
+				http.Error(w, "Admin changes are disabled (set ADMIN_TOKEN)", http.StatusForbidden)
+				return
+			}
+
+			scheme, given, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			givenDigest := sha256.Sum256([]byte(strings.TrimSpace(given)))
+			if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare(givenDigest[:], digest[:]) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="x402-admin"`)
+				http.Error(w, "Admin endpoints require the admin token (Authorization: Bearer <token>)", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
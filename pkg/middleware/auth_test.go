@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testAPIKeys(t *testing.T) *APIKeys {
+	t.Helper()
+	keys, err := ParseAPIKeys("shop:sk_test_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// reached records whether a request got past the middleware
+func reached(hit *bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hit = true
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestAuthMiddlewareRefusesUnauthenticatedCancel(t *testing.T) {
+	var hit bool
+	handler := AuthMiddleware(testAPIKeys(t))(reached(&hit))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/cancel", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST /cancel without a key: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if hit {
+		t.Error("POST /cancel without a key reached the handler")
+	}
+
+	hit = false
+	req := httptest.NewRequest(http.MethodPost, "/cancel", nil)
+	req.Header.Set("Authorization", "Bearer sk_test_key")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !hit {
+		t.Errorf("POST /cancel with a key: status %d, reached %v; want it served", rec.Code, hit)
+	}
+}
+
+func TestAuthMiddlewareLeavesPublicEndpointsOpen(t *testing.T) {
+	handler := AuthMiddleware(testAPIKeys(t))
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPost, "/verify"},
+		{http.MethodGet, "/supported"},
+		{http.MethodGet, "/settle"}, // Endpoint info
+	} {
+		var hit bool
+		rec := httptest.NewRecorder()
+		handler(reached(&hit)).ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if !hit {
+			t.Errorf("%s %s without a key was refused with %d", tc.method, tc.path, rec.Code)
+		}
+	}
+}
+
+func TestAuthMiddlewareRejectsUnknownKey(t *testing.T) {
+	var hit bool
+	req := httptest.NewRequest(http.MethodPost, "/settle", nil)
+	req.Header.Set("Authorization", "Bearer sk_wrong")
+	rec := httptest.NewRecorder()
+	AuthMiddleware(testAPIKeys(t))(reached(&hit)).ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || hit {
+		t.Errorf("POST /settle with an unknown key: status %d, reached %v", rec.Code, hit)
+	}
+}
+
+func TestDefaultSettleRoutesMatchEverySpelling(t *testing.T) {
+	for path, want := range map[string]bool{
+		"/settle":           true,
+		"/settle/":          true,
+		"//settle":          true,
+		"/settle/simulate/": true,
+		"/cancel/.":         true,
+		"/x/../cancel":      true,
+		"/verify":           false,
+		"/settlements/abc":  false,
+		"/settle/other":     false,
+	} {
+		if got := DefaultSettleRoutes(httptest.NewRequest(http.MethodPost, "http://facilitator.test"+path, nil)); got != want {
+			t.Errorf("DefaultSettleRoutes(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestAuthMiddlewareForGuardsMatchedRoutes(t *testing.T) {
+	settles := func(r *http.Request) bool { return r.URL.Path == "/v1/pay" }
+	handler := AuthMiddlewareFor(testAPIKeys(t), settles)
+	for path, guarded := range map[string]bool{"/v1/pay": true, "/settle": false} {
+		var hit bool
+		rec := httptest.NewRecorder()
+		handler(reached(&hit)).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		if hit == guarded {
+			t.Errorf("POST %s without a key: status %d, reached %v", path, rec.Code, hit)
+		}
+	}
+}
+
+func TestAuthMiddlewareExposesKeyLabel(t *testing.T) {
+	var label string
+	handler := AuthMiddleware(testAPIKeys(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		label = APIKeyLabel(r.Context())
+	}))
+	for _, header := range []string{"Bearer sk_test_key", "bearer sk_test_key"} {
+		label = ""
+		req := httptest.NewRequest(http.MethodPost, "/settle", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || label != "shop" {
+			t.Errorf("%s: status %d, label %q; want the shop key accepted", header, rec.Code, label)
+		}
+	}
+	if got := APIKeyLabel(httptest.NewRequest(http.MethodGet, "/", nil).Context()); got != "" {
+		t.Errorf("label %q outside the middleware", got)
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys(" shop:sk_shop , ,sk_bare")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.Len() != 2 {
+		t.Fatalf("%d keys, want 2", keys.Len())
+	}
+	for key, want := range map[string]string{"sk_shop": "shop", "sk_bare": "key-3"} {
+		if label, ok := keys.Lookup(key); !ok || label != want {
+			t.Errorf("Lookup(%s) = %q, %v; want %q", key, label, ok, want)
+		}
+	}
+	if _, ok := keys.Lookup("sk_other"); ok {
+		t.Error("an unconfigured key was found")
+	}
+	for _, list := range []string{"a:sk_1,b:sk_1", "shop:", ":sk_1"} {
+		if _, err := ParseAPIKeys(list); err == nil {
+			t.Errorf("ParseAPIKeys(%q) succeeded, want an error", list)
+		}
+	}
+}
+
+func TestLoadAPIKeysFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keys")
+	if err := os.WriteFile(path, []byte("# settle keys\nshop sk_shop\n\nbilling sk_billing\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadAPIKeysFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label, ok := keys.Lookup("sk_billing"); !ok || label != "billing" || keys.Len() != 2 {
+		t.Errorf("Lookup(sk_billing) = %q, %v with %d keys", label, ok, keys.Len())
+	}
+	if err := keys.Merge(testAPIKeys(t)); err != nil || keys.Len() != 3 {
+		t.Errorf("Merge: %v, %d keys", err, keys.Len())
+	}
+	if err := keys.Merge(testAPIKeys(t)); err == nil {
+		t.Error("merging the same key twice succeeded")
+	}
+
+	bad := filepath.Join(dir, "bad")
+	if err := os.WriteFile(bad, []byte("shop sk_shop extra\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAPIKeysFile(bad); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("malformed line: %v, want an error naming line 1", err)
+	}
+}
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	for _, tc := range []struct {
+		name, token, method, path, auth string
+		status                          int
+	}{
+		{"non-admin path", "admin-secret", http.MethodPost, "/settle", "", http.StatusOK},
+		{"valid token", "admin-secret", http.MethodPost, "/admin/networks/base/disable", "Bearer admin-secret", http.StatusOK},
+		{"missing token", "admin-secret", http.MethodGet, "/admin/networks", "", http.StatusUnauthorized},
+		{"wrong token", "admin-secret", http.MethodPost, "/admin/networks/base/disable", "Bearer nope", http.StatusUnauthorized},
+		{"wrong scheme", "admin-secret", http.MethodPost, "/admin/networks/base/disable", "Basic admin-secret", http.StatusUnauthorized},
+		{"unset token allows reads", "", http.MethodGet, "/admin/networks", "", http.StatusOK},
+		{"unset token allows HEAD", "", http.MethodHead, "/admin/networks", "", http.StatusOK},
+		{"unset token refuses changes", "", http.MethodPost, "/admin/networks/base/disable", "Bearer anything", http.StatusForbidden},
+	} {
+		var hit bool
+		handler := AdminAuthMiddleware(tc.token)(reached(&hit))
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.auth != "" {
+			req.Header.Set("Authorization", tc.auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.status || hit != (tc.status == http.StatusOK) {
+			t.Errorf("%s: status %d, reached %v; want %d", tc.name, rec.Code, hit, tc.status)
+		}
+		if tc.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: 401 without a WWW-Authenticate challenge", tc.name)
+		}
+	}
+}
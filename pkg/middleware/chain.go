@@ -0,0 +1,291 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"net/http"
+	"strings"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Middleware wraps a handler; the *Middleware functions of this package
+// all are (or return) one
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies middlewares in the order they were added: the first one
+// added is the outermost, seeing each request first and its response last
+//
+//	handler := middleware.NewChain().
+//		Use(middleware.RequestID()).
+//		Use(middleware.CORS(middleware.CORSConfig{})).
+//		Use(middleware.SizeLimit(1 << 20)).
+//		Then(mux)
+type Chain struct {
+	middlewares []Middleware
+}
+
+// NewChain creates a chain of middlewares (outermost first)
+func NewChain(middlewares ...Middleware) *Chain {
+	c := &Chain{}
+	for _, m := range middlewares {
+		c.Use(m)
+	}
+	return c
+}
+
+// Use adds m inside the middlewares added so far; nil is skipped, so an
+// optional middleware can be added unconditionally
+func (c *Chain) Use(m Middleware) *Chain {
+	if m != nil {
+		c.middlewares = append(c.middlewares, m)
+	}
+	return c
+}
+
+// Then wraps handler in the chain
+func (c *Chain) Then(handler http.Handler) http.Handler {
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		handler = c.middlewares[i](handler)
+	}
+	return handler
+}
+
+// RequestID tags every request with an ID (see RequestIDMiddleware)
+func RequestID() Middleware {
+	return RequestIDMiddleware
+}
+
+// LogFormat selects the request log written by Logging
+type LogFormat string
+
+// Log formats
+const (
+	LogFormatDetailed LogFormat = "detailed" // Multi-line, human-readable (LoggingMiddleware)
+	LogFormatCompact  LogFormat = "compact"  // One line per request (CompactLoggingMiddleware)
+	LogFormatJSON     LogFormat = "json"     // One JSON object per request (StructuredLoggingMiddleware)
+	LogFormatBody     LogFormat = "body"     // JSON with redacted bodies, for debugging (BodyLoggingMiddleware)
+	LogFormatNone     LogFormat = "none"     // No request log
+)
+
+// DefaultLogBodyBytes is how much of each body LogFormatBody logs
+const DefaultLogBodyBytes = 4096
+
+// Logging logs every request in format (an unknown format logs detailed);
+// LogFormatBody logs up to DefaultLogBodyBytes of the bodies (see BodyLogging)
+func Logging(format LogFormat) Middleware {
+	switch format {
+	case LogFormatCompact:
+		return CompactLoggingMiddleware
+	case LogFormatJSON:
+		return StructuredLoggingMiddleware
+	case LogFormatBody:
+		return BodyLogging(DefaultLogBodyBytes)
+	case LogFormatNone:
+		return nil
+	default:
+		return LoggingMiddleware
+	}
+}
+
+// BodyLogging logs every request as JSON with up to maxBodyBytes of its
+// redacted request and response bodies
+func BodyLogging(maxBodyBytes int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return BodyLoggingMiddleware(next, maxBodyBytes)
+	}
+}
+
+// DefaultMaxBodyBytes is the request body limit of DefaultStack
+const DefaultMaxBodyBytes = 1 << 20 // 1MB
+
+// SizeLimit caps request bodies at maxBytes to prevent DoS attacks; reading
+// past it fails, so body-reading middlewares belong inside it
+func SizeLimit(maxBytes int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit throttles requests per client IP with limiter (nil disables it)
+func RateLimit(limiter *RateLimiter) Middleware {
+	if limiter == nil {
+		return nil
+	}
+	return RateLimitMiddleware(limiter)
+}
+
+// CORSConfig controls the CORS headers added by CORS
+type CORSConfig struct {
+	// Origins browsers may call from (empty allows any origin); credentials
+	// are never allowed either way
+	AllowedOrigins []string
+}
+
+// corsAllowHeaders lists the request headers browsers may send, including
+// every known payment header name
+var corsAllowHeaders = strings.Join(append([]string{"Content-Type", "Content-Encoding", "Authorization", types.HeaderRequestID, types.HeaderTenantID, types.HeaderIdempotencyKey}, types.PaymentHeaderAliases...), ", ")
+
+// CORS adds CORS headers to responses and answers preflight requests
+// By default it reflects any origin (the public API pattern) without credentials
+func CORS(config CORSConfig) Middleware {
+	allowed := make(map[string]bool, len(config.AllowedOrigins))
+	for _, origin := range config.AllowedOrigins {
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+
+			switch {
+			case origin != "" && (len(allowed) == 0 || allowed[origin]):
+				// Reflect the origin back
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Vary", "Origin")
+			case origin != "":
+				// Origin not allowed: no CORS headers, the browser blocks it
+				w.Header().Set("Vary", "Origin")
+			case len(allowed) == 0:
+				// For non-CORS requests (same-origin, curl, postman, etc.)
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+
+			// IMPORTANT: Do NOT set Access-Control-Allow-Credentials
+			// This is a public API and should never use credentials
+
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RoutePrefix serves prefix and the paths below it to next with the prefix
+// removed, and 404s everything else ("" serves everything unchanged)
+func RoutePrefix(prefix string) Middleware {
+	if prefix == "" {
+		return nil
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rest, ok := strings.CutPrefix(r.URL.Path, prefix)
+			if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+				http.NotFound(w, r)
+				return
+			}
+			if rest == "" {
+				rest = "/"
+			}
+			r2 := r.Clone(r.Context())
+			r2.URL.Path, r2.URL.RawPath = rest, ""
+			next.ServeHTTP(w, r2)
+		})
+	}
+}
+
+// StackConfig configures DefaultStack; zero fields leave their middleware
+// out unless noted
+type StackConfig struct {
+	RoutePrefix string     // Mount everything under this path prefix
+	CORS        CORSConfig // Always applied
+	RateLimiter *RateLimiter
+
+	// Request bodies may be gzip-compressed up to MaxBodyBytes decompressed
+	// (0 uses DefaultMaxBodyBytes); responses over CompressMinSize bytes
+	// are gzipped (0 uses 1024)
+	MaxBodyBytes    int64
+	CompressMinSize int
+
+	AdminToken    string         // Guards /admin/ (empty refuses admin changes)
+	AdminRoutes   RouteMatcher   // The routes AdminToken guards (nil: DefaultAdminRoutes)
+	ClientCAs     *x509.CertPool // Settlement requires a client certificate from these CAs
+	SettleAPIKeys *APIKeys       // Settlement requires one of these keys
+	SettleRoutes  RouteMatcher   // The routes ClientCAs and SettleAPIKeys guard (nil: DefaultSettleRoutes)
+	PayerLimiter  *RateLimiter   // Throttles /verify and /settle per payer
+	PayerRoutes   RouteMatcher   // The routes PayerLimiter throttles (nil: DefaultPayerRoutes)
+
+	// Attributes requests to tenants and applies their rate limits
+	Tenants TenantResolver
+
+	LogFormat    LogFormat // "" logs detailed
+	LogBodyBytes int       // Body bytes LogFormatBody logs (0 uses DefaultLogBodyBytes)
+
+	// Duplicate payment headers are always refused; PaymentHeaderLimit > 0
+	// also refuses larger ones
+	PaymentHeaderLimit int
+	Latency            *LatencyTracker
+}
+
+// DefaultStack is the middleware of the facilitator server, outermost first:
+//
+//   - route prefix, so everything below sees the default paths
+//   - request ID, so every response, even a refusal below, carries one
+//   - CORS, so browsers can read refusals (e.g. 429) and preflights never
+//     reach the rate limit
+//   - rate limit per client IP, before any body is read
+//   - compression, outside the size limit so the limit applies to the
+//     decompressed body
+//   - size limit, before anything reads the body
+//   - admin token, tenant, client certificate and API key checks
+//   - per-payer rate limit (reads the body) and request logging
+//   - payment header checks and latency tracking, next to the handler
+func DefaultStack(config StackConfig) *Chain {
+	maxBody := config.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = DefaultMaxBodyBytes
+	}
+	compressMin := config.CompressMinSize
+	if compressMin <= 0 {
+		compressMin = 1024
+	}
+	logging := Logging(config.LogFormat)
+	if config.LogFormat == LogFormatBody && config.LogBodyBytes > 0 {
+		logging = BodyLogging(config.LogBodyBytes)
+	}
+
+	admin := config.AdminRoutes
+	if admin == nil {
+		admin = DefaultAdminRoutes
+	}
+	chain := NewChain().
+		Use(RoutePrefix(config.RoutePrefix)).
+		Use(RequestID()).
+		Use(CORS(config.CORS)).
+		Use(RateLimit(config.RateLimiter)).
+		Use(CompressionMiddleware(maxBody, compressMin)).
+		Use(SizeLimit(maxBody)).
+		Use(AdminAuthMiddlewareFor(config.AdminToken, admin))
+	if config.Tenants != nil {
+		chain.Use(TenantMiddlewareFor(config.Tenants, admin))
+	}
+	settles := config.SettleRoutes
+	if settles == nil {
+		settles = DefaultSettleRoutes
+	}
+	if config.ClientCAs != nil {
+		chain.Use(ClientCertMiddlewareFor(config.ClientCAs, settles))
+	}
+	if config.SettleAPIKeys != nil && config.SettleAPIKeys.Len() > 0 {
+		chain.Use(AuthMiddlewareFor(config.SettleAPIKeys, settles))
+	}
+	if config.PayerLimiter != nil {
+		payers := config.PayerRoutes
+		if payers == nil {
+			payers = DefaultPayerRoutes
+		}
+		chain.Use(PayerRateLimitMiddlewareFor(config.PayerLimiter, payers))
+	}
+	chain.Use(logging).Use(PaymentHeaderMiddleware(config.PaymentHeaderLimit))
+	if config.Latency != nil {
+		chain.Use(LatencyMiddleware(config.Latency))
+	}
+	return chain
+}
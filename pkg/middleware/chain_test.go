@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// traced appends name to trace on the way in and name+" done" on the way out
+func traced(trace *[]string, name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*trace = append(*trace, name)
+			next.ServeHTTP(w, r)
+			*trace = append(*trace, name+" done")
+		})
+	}
+}
+
+func TestChainAppliesMiddlewaresOutermostFirst(t *testing.T) {
+	var trace []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace = append(trace, "handler")
+	})
+	want := []string{"a", "b", "c", "handler", "c done", "b done", "a done"}
+
+	for name, chain := range map[string]*Chain{
+		"Use":      NewChain().Use(traced(&trace, "a")).Use(nil).Use(traced(&trace, "b")).Use(traced(&trace, "c")),
+		"NewChain": NewChain(traced(&trace, "a"), traced(&trace, "b"), nil, traced(&trace, "c")),
+	} {
+		trace = nil
+		chain.Then(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if !reflect.DeepEqual(trace, want) {
+			t.Errorf("%s: trace %v, want %v", name, trace, want)
+		}
+	}
+
+	var hit bool
+	NewChain().Then(served(&hit)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !hit {
+		t.Error("an empty chain did not serve the handler")
+	}
+}
+
+// served records whether a request reached the handler
+func served(hit *bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hit = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func TestDefaultStackRefusalsCarryRequestIDAndCORS(t *testing.T) {
+	limiter := NewRateLimiter(60, 1)
+	defer limiter.Stop()
+	limiter.SetClock(&manualClock{now: time.Unix(1_700_000_000, 0)}) // No refills
+	logs := captureLog(t)
+	var hits int
+	handler := DefaultStack(StackConfig{RateLimiter: limiter, LogFormat: LogFormatJSON}).
+		Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+
+	request := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/supported", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Preflights are answered by CORS and never spend the rate limit
+	for i := 0; i < 3; i++ {
+		if rec := request(http.MethodOptions); rec.Code != http.StatusOK {
+			t.Fatalf("preflight %d: status %d", i+1, rec.Code)
+		}
+	}
+	if rec := request(http.MethodGet); rec.Code != http.StatusOK || hits != 1 {
+		t.Fatalf("first GET after preflights: status %d, %d handler hits", rec.Code, hits)
+	}
+	logs.Reset()
+
+	rec := request(http.MethodGet)
+	if rec.Code != http.StatusTooManyRequests || hits != 1 {
+		t.Fatalf("second GET: status %d, %d handler hits; want it rate limited", rec.Code, hits)
+	}
+	if rec.Header().Get(types.HeaderRequestID) == "" {
+		t.Error("the 429 carries no request ID")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("the 429 allows origin %q, want it readable by the browser", got)
+	}
+	// Logging sits inside the rate limit, so refused requests are not logged
+	if logs.Len() != 0 {
+		t.Errorf("rate-limited request was logged: %s", logs.String())
+	}
+}
+
+func TestDefaultStackLimitsBodiesBeforeLogging(t *testing.T) {
+	logs := captureLog(t)
+	var readErr error
+	handler := DefaultStack(StackConfig{MaxBodyBytes: 16, LogFormat: LogFormatBody}).
+		Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, readErr = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+	body := `{"padding":"` + strings.Repeat("x", 64) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(body))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var tooLarge *http.MaxBytesError
+	if !errors.As(readErr, &tooLarge) {
+		t.Fatalf("handler read error %v, want the size limit", readErr)
+	}
+	if strings.Contains(logs.String(), strings.Repeat("x", 64)) {
+		t.Errorf("the body logger saw past the size limit: %s", logs.String())
+	}
+	entry := logEntry(t, logs)
+	if note, _ := entry["request_body"].(string); entry["path"] != "/verify" || !strings.HasSuffix(note, "bytes omitted]") {
+		t.Errorf("log entry %v, want the cut-off body omitted", entry)
+	}
+}
+
+func TestDefaultStackStripsRoutePrefix(t *testing.T) {
+	var path, requestID string
+	handler := DefaultStack(StackConfig{RoutePrefix: "/x402", LogFormat: LogFormatNone}).
+		Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path, requestID = r.URL.Path, types.RequestIDFromContext(r.Context())
+		}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/x402/supported", nil))
+	if path != "/supported" || requestID == "" || rec.Header().Get(types.HeaderRequestID) != requestID {
+		t.Errorf("handler saw path %q with request ID %q (response %q)", path, requestID, rec.Header().Get(types.HeaderRequestID))
+	}
+
+	path = ""
+	for _, outside := range []string{"/supported", "/x4020/supported"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, outside, nil))
+		if rec.Code != http.StatusNotFound || path != "" {
+			t.Errorf("%s: status %d, handler saw %q; want a 404", outside, rec.Code, path)
+		}
+	}
+}
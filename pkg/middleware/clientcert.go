@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+)
+
+type clientCertContextKey struct{}
+
+// ClientCertIdentity returns the identity (subject common name, or the full
+// subject without one) of the TLS client certificate that authenticated the
+// request, or "" if none did
+func ClientCertIdentity(ctx context.Context) string {
+	identity, _ := ctx.Value(clientCertContextKey{}).(string)
+	return identity
+}
+
+// ClientCertMiddleware creates HTTP middleware requiring a TLS client
+// certificate chaining to roots on POST /settle, /settle/simulate and /cancel
+// The server's TLS config should request client certificates
+// (tls.VerifyClientCertIfGiven) so other endpoints stay reachable without
+// one; the chain is verified here for client authentication either way.
+// The certificate's identity is available through ClientCertIdentity.
+func ClientCertMiddleware(roots *x509.CertPool) func(http.Handler) http.Handler {
+	return ClientCertMiddlewareFor(roots, DefaultSettleRoutes)
+}
+
+// ClientCertMiddlewareFor is ClientCertMiddleware guarding the POSTs settles
+// matches
+func ClientCertMiddlewareFor(roots *x509.CertPool, settles RouteMatcher) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || !settles(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+				http.Error(w, "Settlement requires a TLS client certificate", http.StatusUnauthorized)
+				return
+			}
+			leaf := r.TLS.PeerCertificates[0]
+			intermediates := x509.NewCertPool()
+			for _, cert := range r.TLS.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			if _, err := leaf.Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			}); err != nil {
+				http.Error(w, "TLS client certificate is not trusted for settlement", http.StatusForbidden)
+				return
+			}
+
+			identity := leaf.Subject.CommonName
+			if identity == "" {
+				identity = leaf.Subject.String()
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientCertContextKey{}, identity)))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/x402-rs/x402-go/internal/testcerts"
+)
+
+func TestClientCertMiddleware(t *testing.T) {
+	ca := testcerts.NewAuthority(t, "Internal CA")
+	var identity string
+	handler := ClientCertMiddleware(ca.Pool())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity = ClientCertIdentity(r.Context())
+	}))
+	call := func(method, path string, chain ...*x509.Certificate) int {
+		identity = ""
+		req := httptest.NewRequest(method, path, nil)
+		if chain != nil {
+			req.TLS = &tls.ConnectionState{PeerCertificates: chain}
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	shop := ca.Issue(t, "shop", testcerts.Client).Leaf
+	for _, path := range []string{"/settle", "/settle/simulate"} {
+		if code := call(http.MethodPost, path, shop); code != http.StatusOK || identity != "shop" {
+			t.Errorf("%s with a trusted certificate: status %d, identity %q; want shop", path, code, identity)
+		}
+		if code := call(http.MethodPost, path); code != http.StatusUnauthorized {
+			t.Errorf("%s without a certificate: status %d, want 401", path, code)
+		}
+	}
+
+	for name, cert := range map[string]*x509.Certificate{
+		"another authority": testcerts.NewAuthority(t, "Other CA").Issue(t, "intruder", testcerts.Client).Leaf,
+		"a server cert":     ca.Issue(t, "facilitator", testcerts.Server, "127.0.0.1").Leaf,
+	} {
+		if code := call(http.MethodPost, "/settle", cert); code != http.StatusForbidden {
+			t.Errorf("certificate from %s: status %d, want 403", name, code)
+		}
+	}
+
+	// Endpoints that spend no gas stay open, and carry no identity
+	if code := call(http.MethodPost, "/verify"); code != http.StatusOK {
+		t.Errorf("/verify without a certificate: status %d, want 200", code)
+	}
+	if code := call(http.MethodGet, "/settle"); code != http.StatusOK {
+		t.Errorf("GET /settle without a certificate: status %d, want 200", code)
+	}
+	if code := call(http.MethodPost, "/verify", shop); code != http.StatusOK || identity != "" {
+		t.Errorf("/verify with a certificate: status %d, identity %q; want no identity recorded", code, identity)
+	}
+}
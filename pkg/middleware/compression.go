@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// CompressionMiddleware transparently handles compressed request and response bodies
+// maxDecompressedBytes: cap on the inflated request body (guards against zip bombs)
+// minResponseSize: JSON responses smaller than this are sent uncompressed
+func CompressionMiddleware(maxDecompressedBytes int64, minResponseSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Decompress request body based on Content-Encoding
+			if encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding != "" && encoding != "identity" {
+				body, err := decompressBody(r.Body, encoding)
+				if err != nil {
+					http.Error(w, "unsupported or malformed Content-Encoding", http.StatusUnsupportedMediaType)
+					return
+				}
+				r.Body = &cappedReadCloser{reader: body, closer: r.Body, remaining: maxDecompressedBytes, limit: maxDecompressedBytes}
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+				r.ContentLength = -1
+			}
+
+			// Streams and protocol upgrades are passed through untouched
+			if !acceptsGzip(r) || isUpgrade(r) || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, status: http.StatusOK, minSize: minResponseSize}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// decompressBody wraps body in a reader for the given content encoding
+func decompressBody(body io.Reader, encoding string) (io.Reader, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "deflate":
+		// HTTP's deflate is the zlib format (RFC 9110), not raw DEFLATE
+		return zlib.NewReader(body)
+	default:
+		return nil, http.ErrNotSupported
+	}
+}
+
+// acceptsGzip reports whether the client advertised gzip support
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		if strings.ToLower(strings.TrimSpace(fields[0])) != "gzip" {
+			continue
+		}
+		if len(fields) > 1 && strings.ReplaceAll(strings.TrimSpace(fields[1]), " ", "") == "q=0" {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// isUpgrade reports whether r asks to switch protocols (e.g. websockets)
+func isUpgrade(r *http.Request) bool {
+	for _, token := range strings.Split(r.Header.Get("Connection"), ",") {
+		if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+			return true
+		}
+	}
+	return false
+}
+
+// cappedReadCloser limits how many decompressed bytes can be read
+// Exceeding the limit yields the same error as http.MaxBytesReader so
+// handlers answer 413 consistently
+type cappedReadCloser struct {
+	reader    io.Reader
+	closer    io.Closer
+	remaining int64
+	limit     int64
+}
+
+func (c *cappedReadCloser) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		// Probe for one more byte to tell "exactly at limit" from "over limit"
+		var probe [1]byte
+		if n, _ := c.reader.Read(probe[:]); n > 0 {
+			return 0, &http.MaxBytesError{Limit: c.limit}
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.reader.Read(p)
+	c.remaining -= int64(n)
+	return n, err
+}
+
+func (c *cappedReadCloser) Close() error {
+	return c.closer.Close()
+}
+
+// compressWriter buffers the start of a response and gzips it once it is
+// known to be JSON and larger than minSize. Flush sends what is buffered
+// and Hijack hands over the connection, so streaming handlers work behind it.
+type compressWriter struct {
+	http.ResponseWriter
+	status   int
+	minSize  int
+	buf      bytes.Buffer
+	gz       *gzip.Writer
+	decided  bool
+	hijacked bool
+}
+
+func (cw *compressWriter) WriteHeader(statusCode int) {
+	if !cw.decided {
+		cw.status = statusCode
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.decided {
+		if cw.gz != nil {
+			return cw.gz.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf.Write(b)
+	if cw.buf.Len() >= cw.minSize || strings.HasPrefix(cw.Header().Get("Content-Type"), "text/event-stream") {
+		if err := cw.decide(cw.compressible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// compressible reports whether the response is JSON without an existing encoding
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	return h.Get("Content-Encoding") == "" &&
+		strings.Contains(h.Get("Content-Type"), "application/json")
+}
+
+// decide commits the headers and flushes any buffered bytes
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	if compress {
+		h := cw.Header()
+		h.Set("Content-Encoding", "gzip")
+		h.Add("Vary", "Accept-Encoding")
+		h.Del("Content-Length")
+		cw.ResponseWriter.WriteHeader(cw.status)
+		cw.gz = gzip.NewWriter(cw.ResponseWriter)
+		_, err := cw.gz.Write(cw.buf.Bytes())
+		return err
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if cw.buf.Len() > 0 {
+		_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+		return err
+	}
+	return nil
+}
+
+// Flush commits the headers, sends the buffered and gzipped bytes so far
+// and flushes the underlying writer
+func (cw *compressWriter) Flush() {
+	cw.FlushError()
+}
+
+// FlushError is Flush reporting the error (see http.ResponseController)
+func (cw *compressWriter) FlushError() error {
+	// Write decides once minSize bytes are buffered, so anything still
+	// undecided is small enough to send uncompressed
+	if !cw.decided {
+		if err := cw.decide(false); err != nil {
+			return err
+		}
+	}
+	if cw.gz != nil {
+		if err := cw.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection to the handler; nothing is written after it
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(cw.ResponseWriter).Hijack()
+	if err == nil {
+		cw.decided, cw.hijacked = true, true
+	}
+	return conn, rw, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// close flushes small responses uncompressed and finishes the gzip stream
+func (cw *compressWriter) close() {
+	if cw.hijacked {
+		return
+	}
+	if !cw.decided {
+		cw.decide(false)
+	}
+	if cw.gz != nil {
+		cw.gz.Close()
+	}
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// echoBody answers with the request body as JSON, or 413 past the cap
+func echoBody(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func deflated(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func postEncoded(handler http.Handler, encoding string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+	req.Header.Set("Content-Encoding", encoding)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCompressionDecodesRequestBodies(t *testing.T) {
+	handler := CompressionMiddleware(1<<20, 1<<20)(http.HandlerFunc(echoBody))
+	payload := []byte(`{"x402Version":1,"paymentPayload":{"scheme":"exact"}}`)
+
+	for _, tc := range []struct {
+		encoding string
+		body     []byte
+	}{
+		{"gzip", gzipped(t, payload)},
+		{"deflate", deflated(t, payload)},
+		{"identity", payload},
+	} {
+		rec := postEncoded(handler, tc.encoding, tc.body)
+		if rec.Code != http.StatusOK || rec.Body.String() != string(payload) {
+			t.Errorf("%s: status %d, body %q; want the payload back", tc.encoding, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestCompressionRejectsMalformedBodies(t *testing.T) {
+	handler := CompressionMiddleware(1<<20, 1<<20)(http.HandlerFunc(echoBody))
+	for _, encoding := range []string{"gzip", "deflate", "br"} {
+		rec := postEncoded(handler, encoding, []byte("not compressed"))
+		if rec.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("%s: status %d, want %d", encoding, rec.Code, http.StatusUnsupportedMediaType)
+		}
+	}
+}
+
+func TestCompressionCapsDecompressedSize(t *testing.T) {
+	const limit = 1 << 10
+	handler := CompressionMiddleware(limit, 1<<20)(http.HandlerFunc(echoBody))
+
+	// 8 MiB of zeros compresses to a few KiB
+	bomb := make([]byte, 8<<20)
+	for _, tc := range []struct {
+		encoding string
+		body     []byte
+	}{
+		{"gzip", gzipped(t, bomb)},
+		{"deflate", deflated(t, bomb)},
+	} {
+		if rec := postEncoded(handler, tc.encoding, tc.body); rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s bomb: status %d, want %d", tc.encoding, rec.Code, http.StatusRequestEntityTooLarge)
+		}
+	}
+
+	// Exactly at the cap is still accepted
+	if rec := postEncoded(handler, "gzip", gzipped(t, make([]byte, limit))); rec.Code != http.StatusOK {
+		t.Errorf("body at the cap: status %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestCompressionCompressesLargeJSONResponses(t *testing.T) {
+	handler := CompressionMiddleware(1<<20, 100)(http.HandlerFunc(echoBody))
+	for _, tc := range []struct {
+		size int
+		want bool
+	}{
+		{10, false},
+		{1000, true},
+	} {
+		body := []byte(`"` + strings.Repeat("a", tc.size) + `"`)
+		req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		compressed := rec.Header().Get("Content-Encoding") == "gzip"
+		if compressed != tc.want {
+			t.Errorf("%d byte response: compressed %v, want %v", tc.size, compressed, tc.want)
+			continue
+		}
+		got := rec.Body.Bytes()
+		if compressed {
+			zr, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err = io.ReadAll(zr); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !bytes.Equal(got, body) {
+			t.Errorf("%d byte response: body did not survive the round trip", tc.size)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// DefaultLatencyWindow is how far back latency percentiles look
+const DefaultLatencyWindow = 5 * time.Minute
+
+// DefaultLatencyRoutes are the facilitator endpoints tracked when
+// NewLatencyTracker is given no routes
+var DefaultLatencyRoutes = []string{"/verify", "/settle", "/settle/simulate", "/cancel", "/supported"}
+
+const (
+	// latencyBuckets is how many slices the window is divided into; the
+	// oldest slice is dropped as a whole, so the window slides in steps
+	// of window/latencyBuckets
+	latencyBuckets = 30
+	// latencySamples caps the latencies kept per slice and route (a
+	// uniform reservoir sample of them beyond that), bounding memory
+	latencySamples = 256
+)
+
+// LatencyTracker keeps per-route request latencies over a sliding window
+// and reports their percentiles and response status classes
+// Memory is fixed by the number of routes: each keeps at most
+// latencyBuckets*latencySamples latencies whatever the traffic.
+type LatencyTracker struct {
+	window time.Duration
+	width  time.Duration // Window slice length
+	routes map[string]*routeLatency
+	clock  types.Clock
+}
+
+// routeLatency is the window of one route
+type routeLatency struct {
+	mu      sync.Mutex
+	buckets [latencyBuckets]latencyBucket
+}
+
+// latencyBucket holds one window slice of a route's requests
+type latencyBucket struct {
+	slice   int64 // Slice number (time / width) the bucket holds
+	count   uint64
+	max     float64
+	samples []float64 // Milliseconds, at most latencySamples
+	status  [5]uint64 // 1xx..5xx
+}
+
+// statusClasses names the latencyBucket.status counters
+var statusClasses = [5]string{"1xx", "2xx", "3xx", "4xx", "5xx"}
+
+// RouteLatency summarizes one route's requests in the window
+// Percentiles are estimated from a bounded sample; Count, Max and the
+// status counts are exact.
+type RouteLatency struct {
+	Count  uint64            `json:"count"`
+	P50    float64           `json:"p50_ms"`
+	P95    float64           `json:"p95_ms"`
+	P99    float64           `json:"p99_ms"`
+	Max    float64           `json:"max_ms"`
+	Status map[string]uint64 `json:"status"` // By class: "2xx", "4xx", ...
+}
+
+// LatencySnapshot is the tracker's view of the window, as shown in /admin/stats
+type LatencySnapshot struct {
+	WindowSeconds int                     `json:"window_seconds"`
+	Routes        map[string]RouteLatency `json:"routes"`
+}
+
+// NewLatencyTracker tracks requests to routes (exact paths,
+// DefaultLatencyRoutes if none) over window (DefaultLatencyWindow if <= 0)
+func NewLatencyTracker(window time.Duration, routes ...string) *LatencyTracker {
+	if window <= 0 {
+		window = DefaultLatencyWindow
+	}
+	if len(routes) == 0 {
+		routes = DefaultLatencyRoutes
+	}
+	t := &LatencyTracker{
+		window: window,
+		width:  max(window/latencyBuckets, time.Millisecond),
+		routes: make(map[string]*routeLatency, len(routes)),
+		clock:  types.SystemClock{},
+	}
+	for _, route := range routes {
+		t.routes[route] = &routeLatency{}
+	}
+	return t
+}
+
+// SetClock replaces the time source deciding which window slice a request
+// falls in; call it before the tracker is in use
+func (t *LatencyTracker) SetClock(clock types.Clock) {
+	t.clock = clock
+}
+
+// Observe records a request to route that took d and answered status;
+// requests to untracked routes are ignored
+func (t *LatencyTracker) Observe(route string, d time.Duration, status int) {
+	rl := t.routes[route]
+	if rl == nil {
+		return
+	}
+	slice := t.clock.Now().UnixNano() / int64(t.width)
+	ms := float64(d) / float64(time.Millisecond)
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	b := &rl.buckets[slice%latencyBuckets]
+	if b.slice != slice {
+		*b = latencyBucket{slice: slice, samples: b.samples[:0]}
+	}
+	b.count++
+	b.max = max(b.max, ms)
+	if class := status / 100; class >= 1 && class <= 5 {
+		b.status[class-1]++
+	}
+	// Reservoir sampling keeps every request equally likely to be sampled
+	if len(b.samples) < latencySamples {
+		b.samples = append(b.samples, ms)
+	} else if i := rand.Uint64N(b.count); i < latencySamples {
+		b.samples[i] = ms
+	}
+}
+
+// Snapshot returns every tracked route's latencies over the window
+func (t *LatencyTracker) Snapshot() LatencySnapshot {
+	current := t.clock.Now().UnixNano() / int64(t.width)
+	snapshot := LatencySnapshot{
+		WindowSeconds: int(t.window / time.Second),
+		Routes:        make(map[string]RouteLatency, len(t.routes)),
+	}
+	for route, rl := range t.routes {
+		snapshot.Routes[route] = rl.summarize(current)
+	}
+	return snapshot
+}
+
+// weightedSample is a sampled latency standing for weight requests
+type weightedSample struct {
+	ms     float64
+	weight float64
+}
+
+// summarize merges the buckets still inside the window ending at slice current
+func (rl *routeLatency) summarize(current int64) RouteLatency {
+	summary := RouteLatency{Status: make(map[string]uint64)}
+	var samples []weightedSample
+
+	rl.mu.Lock()
+	for i := range rl.buckets {
+		b := &rl.buckets[i]
+		if b.count == 0 || b.slice <= current-latencyBuckets || b.slice > current {
+			continue
+		}
+		summary.Count += b.count
+		summary.Max = max(summary.Max, b.max)
+		for class, n := range b.status {
+			if n > 0 {
+				summary.Status[statusClasses[class]] += n
+			}
+		}
+		// A sampled bucket's latencies each stand for count/len requests
+		weight := float64(b.count) / float64(len(b.samples))
+		for _, ms := range b.samples {
+			samples = append(samples, weightedSample{ms: ms, weight: weight})
+		}
+	}
+	rl.mu.Unlock()
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].ms < samples[j].ms })
+	summary.P50 = weightedPercentile(samples, summary.Count, 0.50)
+	summary.P95 = weightedPercentile(samples, summary.Count, 0.95)
+	summary.P99 = weightedPercentile(samples, summary.Count, 0.99)
+	return summary
+}
+
+// weightedPercentile returns the smallest sampled latency at or below which
+// fraction q of the total requests fall (samples sorted ascending)
+func weightedPercentile(samples []weightedSample, total uint64, q float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	target := q * float64(total)
+	var cumulative float64
+	for _, s := range samples {
+		cumulative += s.weight
+		if cumulative >= target {
+			return s.ms
+		}
+	}
+	return samples[len(samples)-1].ms
+}
+
+// LatencyMiddleware creates HTTP middleware recording the latency and
+// status of requests to the tracker's routes
+func LatencyMiddleware(tracker *LatencyTracker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tracker.routes[r.URL.Path] == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			recorder := NewResponseRecorder(w)
+			next.ServeHTTP(recorder, r)
+			tracker.Observe(r.URL.Path, time.Since(start), recorder.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// latencyClock starts on a window slice boundary
+func latencyClock() *manualClock {
+	return &manualClock{now: time.Unix(1_700_000_000, 0)}
+}
+
+func TestLatencyPercentilesExact(t *testing.T) {
+	tracker := NewLatencyTracker(5 * time.Minute)
+	clock := latencyClock()
+	tracker.SetClock(clock)
+
+	// 1..1000ms, 100 to a slice so no slice is sampled
+	for ms := 1; ms <= 1000; ms++ {
+		tracker.Observe("/verify", time.Duration(ms)*time.Millisecond, http.StatusOK)
+		if ms%100 == 0 {
+			clock.Advance(10 * time.Second)
+		}
+	}
+	clock.Advance(-10 * time.Second) // Back into the last slice written
+	got := tracker.Snapshot().Routes["/verify"]
+	if got.Count != 1000 || got.P50 != 500 || got.P95 != 950 || got.P99 != 990 || got.Max != 1000 {
+		t.Errorf("summary %+v, want 1000 requests with p50 500, p95 950, p99 990 and max 1000", got)
+	}
+}
+
+func TestLatencyPercentilesSampledWithinTolerance(t *testing.T) {
+	tracker := NewLatencyTracker(5 * time.Minute)
+	tracker.SetClock(latencyClock())
+
+	// Far more requests than one slice keeps, drawn uniformly from 0..1000ms
+	const requests = 100_000
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < requests; i++ {
+		tracker.Observe("/settle", time.Duration(rng.Float64()*1000*float64(time.Millisecond)), http.StatusOK)
+	}
+	got := tracker.Snapshot().Routes["/settle"]
+	if got.Count != requests {
+		t.Errorf("count %d, want the exact %d", got.Count, requests)
+	}
+	for name, tc := range map[string]struct{ got, want float64 }{
+		"p50": {got.P50, 500},
+		"p95": {got.P95, 950},
+		"p99": {got.P99, 990},
+	} {
+		if math.Abs(tc.got-tc.want) > 50 {
+			t.Errorf("%s = %.1fms, want %.0fms within 50ms", name, tc.got, tc.want)
+		}
+	}
+	if got.Max < 990 || got.Max > 1000 {
+		t.Errorf("max %.1fms, want the exact largest latency", got.Max)
+	}
+}
+
+func TestLatencyWindowSlides(t *testing.T) {
+	tracker := NewLatencyTracker(5 * time.Minute)
+	clock := latencyClock()
+	tracker.SetClock(clock)
+
+	tracker.Observe("/verify", 900*time.Millisecond, http.StatusOK)
+	clock.Advance(time.Minute)
+	tracker.Observe("/verify", 100*time.Millisecond, http.StatusOK)
+
+	clock.Advance(4*time.Minute - 10*time.Second) // The first request's slice is the oldest kept
+	if got := tracker.Snapshot().Routes["/verify"]; got.Count != 2 || got.Max != 900 {
+		t.Errorf("inside the window: %+v, want both requests", got)
+	}
+	clock.Advance(10 * time.Second)
+	if got := tracker.Snapshot().Routes["/verify"]; got.Count != 1 || got.Max != 100 || got.P99 != 100 {
+		t.Errorf("after five minutes: %+v, want only the later request", got)
+	}
+	clock.Advance(time.Hour)
+	if got := tracker.Snapshot().Routes["/verify"]; got.Count != 0 || got.P50 != 0 || len(got.Status) != 0 {
+		t.Errorf("after an idle hour: %+v, want an empty window", got)
+	}
+
+	// A reused slot starts afresh rather than adding to the old slice
+	tracker.Observe("/verify", 5*time.Millisecond, http.StatusOK)
+	if got := tracker.Snapshot().Routes["/verify"]; got.Count != 1 || got.Max != 5 {
+		t.Errorf("after reuse: %+v, want only the new request", got)
+	}
+}
+
+func TestLatencyStatusClasses(t *testing.T) {
+	tracker := NewLatencyTracker(0)
+	tracker.SetClock(latencyClock())
+	for _, status := range []int{200, 200, 204, 302, 400, 402, 404, 500, 503, 0, 999} {
+		tracker.Observe("/settle", time.Millisecond, status)
+	}
+	got := tracker.Snapshot().Routes["/settle"]
+	want := map[string]uint64{"2xx": 3, "3xx": 1, "4xx": 3, "5xx": 2}
+	if len(got.Status) != len(want) {
+		t.Errorf("status classes %v, want %v", got.Status, want)
+	}
+	for class, n := range want {
+		if got.Status[class] != n {
+			t.Errorf("%s = %d, want %d", class, got.Status[class], n)
+		}
+	}
+	if got.Count != 11 {
+		t.Errorf("count %d, want every request including unclassified statuses", got.Count)
+	}
+}
+
+func TestLatencyMemoryBounded(t *testing.T) {
+	tracker := NewLatencyTracker(5 * time.Minute)
+	clock := latencyClock()
+	tracker.SetClock(clock)
+	for i := 0; i < 200_000; i++ {
+		tracker.Observe("/verify", time.Duration(i%1000)*time.Millisecond, http.StatusOK)
+		if i%1000 == 0 {
+			clock.Advance(time.Second)
+		}
+	}
+	tracker.Observe("/untracked", time.Second, http.StatusOK)
+
+	rl := tracker.routes["/verify"]
+	for i := range rl.buckets {
+		if n := len(rl.buckets[i].samples); n > latencySamples {
+			t.Errorf("slot %d keeps %d samples, want at most %d", i, n, latencySamples)
+		}
+	}
+	if _, ok := tracker.routes["/untracked"]; ok {
+		t.Error("an untracked route was added")
+	}
+	if _, ok := tracker.Snapshot().Routes["/untracked"]; ok {
+		t.Error("an untracked route shows in the snapshot")
+	}
+}
+
+func TestLatencyMiddlewareRecordsTrackedRoutes(t *testing.T) {
+	tracker := NewLatencyTracker(0)
+	handler := LatencyMiddleware(tracker)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/settle" {
+			w.WriteHeader(http.StatusPaymentRequired)
+		}
+	}))
+	for _, path := range []string{"/verify", "/settle", "/settle", "/health"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	snapshot := tracker.Snapshot()
+	if snapshot.WindowSeconds != 300 {
+		t.Errorf("window %ds, want the default five minutes", snapshot.WindowSeconds)
+	}
+	if got := snapshot.Routes["/verify"]; got.Count != 1 || got.Status["2xx"] != 1 {
+		t.Errorf("/verify: %+v, want one 2xx", got)
+	}
+	if got := snapshot.Routes["/settle"]; got.Count != 2 || got.Status["4xx"] != 2 {
+		t.Errorf("/settle: %+v, want two 4xx", got)
+	}
+	if _, ok := snapshot.Routes["/health"]; ok {
+		t.Error("/health was tracked")
+	}
+	if len(snapshot.Routes) != len(DefaultLatencyRoutes) {
+		t.Errorf("%d routes in the snapshot, want the %d defaults", len(snapshot.Routes), len(DefaultLatencyRoutes))
+	}
+}
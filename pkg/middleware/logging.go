@@ -1,27 +1,48 @@
 package middleware
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
 )
 
-// ResponseRecorder wraps http.ResponseWriter to capture status and body
+// ResponseRecorder wraps http.ResponseWriter to capture status and, when
+// Body is set, up to maxBody bytes of the response body. Writes go straight
+// through, and Flush, Hijack, Push, CloseNotify and ReadFrom reach the
+// underlying writer, so streaming and websocket handlers work behind it.
 type ResponseRecorder struct {
 	http.ResponseWriter
 	StatusCode int
-	Body       *bytes.Buffer
+	Body       *bytes.Buffer // nil unless created with NewBodyRecorder
+	maxBody    int
 }
 
+// NewResponseRecorder captures only the status code (no body buffering)
 func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{
+		ResponseWriter: w,
+		StatusCode:     200,
+	}
+}
+
+// NewBodyRecorder also captures the first maxBody bytes of the response body
+func NewBodyRecorder(w http.ResponseWriter, maxBody int) *ResponseRecorder {
 	return &ResponseRecorder{
 		ResponseWriter: w,
 		StatusCode:     200,
 		Body:           &bytes.Buffer{},
+		maxBody:        maxBody,
 	}
 }
 
@@ -31,10 +52,74 @@ func (r *ResponseRecorder) WriteHeader(statusCode int) {
 }
 
 func (r *ResponseRecorder) Write(b []byte) (int, error) {
-	r.Body.Write(b)
+	if r.Body != nil {
+		if room := r.maxBody - r.Body.Len(); room > 0 {
+			if len(b) < room {
+				room = len(b)
+			}
+			r.Body.Write(b[:room])
+		}
+	}
 	return r.ResponseWriter.Write(b)
 }
 
+// Flush passes through to the underlying writer so streamed responses
+// (server-sent events, chunked downloads) are not held back by logging
+func (r *ResponseRecorder) Flush() {
+	r.FlushError()
+}
+
+// FlushError is Flush reporting writers that cannot flush (see
+// http.ResponseController)
+func (r *ResponseRecorder) FlushError() error {
+	return http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection to the handler (websockets); the request is
+// logged as 101 Switching Protocols
+func (r *ResponseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		r.StatusCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Push initiates an HTTP/2 server push when the underlying writer supports it
+func (r *ResponseRecorder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := r.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// CloseNotify passes through to the underlying writer; without support the
+// returned channel never fires
+func (r *ResponseRecorder) CloseNotify() <-chan bool {
+	if cn, ok := r.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// ReadFrom keeps the underlying writer's sendfile fast path for file
+// responses when no body is being captured
+func (r *ResponseRecorder) ReadFrom(src io.Reader) (int64, error) {
+	if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok && r.Body == nil {
+		return rf.ReadFrom(src)
+	}
+	return io.Copy(struct{ io.Writer }{r}, src)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 // isStaticAsset checks if the request path is for a static asset
 func isStaticAsset(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
@@ -67,8 +152,12 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Log request (metadata only, no body)
-		log.Printf("→ %s %s %s", r.Method, r.URL.Path, r.RemoteAddr)
+		// Log request (metadata only, no body; the payment header is never logged)
+		if _, name := types.PaymentHeaderValue(r.Header, types.PaymentHeaderAliases); name != "" {
+			log.Printf("→ %s %s %s (%s: %s)", r.Method, r.URL.Path, r.RemoteAddr, name, redacted)
+		} else {
+			log.Printf("→ %s %s %s", r.Method, r.URL.Path, r.RemoteAddr)
+		}
 
 		// Capture response
 		recorder := NewResponseRecorder(w)
@@ -119,12 +208,140 @@ func StructuredLoggingMiddleware(next http.Handler) http.Handler {
 			"user_agent":     r.UserAgent(),
 			"content_length": r.ContentLength,
 		}
+		if value, _ := types.PaymentHeaderValue(r.Header, types.PaymentHeaderAliases); value != "" {
+			logEntry["payment_header"] = redacted
+		}
+		if id := types.RequestIDFromContext(r.Context()); id != "" {
+			logEntry["request_id"] = id
+		}
 
 		logJSON, _ := json.Marshal(logEntry)
 		log.Println(string(logJSON))
 	})
 }
 
+// BodyLoggingMiddleware logs requests in JSON format including headers and
+// up to maxBodyBytes of the request and response bodies (opt-in; for debugging)
+//
+// Sensitive data is redacted before logging: the X-Payment-Payload and
+// credential headers, and JSON fields named like signatures, authorizations
+// and private keys. Non-JSON or truncated bodies are logged by size only.
+func BodyLoggingMiddleware(next http.Handler, maxBodyBytes int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		// Capture the request body as the handler reads it
+		reqBody := &cappedBuffer{max: maxBodyBytes}
+		if r.Body != nil {
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(r.Body, reqBody), r.Body}
+		}
+
+		recorder := NewBodyRecorder(w, maxBodyBytes)
+		next.ServeHTTP(recorder, r)
+
+		logEntry := map[string]interface{}{
+			"timestamp":     start.Format(time.RFC3339),
+			"method":        r.Method,
+			"path":          r.URL.Path,
+			"status":        recorder.StatusCode,
+			"duration_ms":   time.Since(start).Milliseconds(),
+			"remote_addr":   r.RemoteAddr,
+			"headers":       redactHeaders(r.Header),
+			"request_body":  redactBody(reqBody.Bytes(), reqBody.truncated),
+			"response_body": redactBody(recorder.Body.Bytes(), recorder.Body.Len() >= maxBodyBytes),
+		}
+
+		logJSON, _ := json.Marshal(logEntry)
+		log.Println(string(logJSON))
+	})
+}
+
+// redacted replaces sensitive values in logs
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are never logged verbatim
+// The payment header carries the signed payment and is redacted under all its names
+var sensitiveHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"X-Api-Key":     true,
+}
+
+func init() {
+	for _, name := range types.PaymentHeaderAliases {
+		sensitiveHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+}
+
+// sensitiveField matches JSON field names whose values are redacted
+var sensitiveField = regexp.MustCompile(`(?i)signature|authorization|private_?key|secret|mnemonic`)
+
+// redactHeaders returns a copy of h with sensitive header values replaced
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for key, values := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+			out[key] = redacted
+			continue
+		}
+		out[key] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+// redactBody returns a JSON body with sensitive fields replaced, or a size
+// note for bodies that are empty, truncated or not JSON
+func redactBody(body []byte, truncated bool) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	var obj interface{}
+	if truncated || json.Unmarshal(body, &obj) != nil {
+		return fmt.Sprintf("[%d bytes omitted]", len(body))
+	}
+	return redactValue(obj)
+}
+
+// redactValue walks decoded JSON, replacing the values of sensitive fields
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, child := range val {
+			if sensitiveField.MatchString(key) {
+				val[key] = redacted
+			} else {
+				val[key] = redactValue(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactValue(child)
+		}
+	}
+	return v
+}
+
+// cappedBuffer keeps the first max bytes written to it
+type cappedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
 // formatJSON pretty-prints JSON if valid, otherwise returns original string
 func formatJSON(data []byte) string {
 	var obj interface{}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// secretSignature stands in for a payment signature that must never be logged
+const secretSignature = "0xdeadbeefcafe"
+
+// captureLog redirects the standard logger, without prefixes, for the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	out, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+	})
+	return &buf
+}
+
+// logEntry decodes the single JSON line a structured middleware logged
+func logEntry(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry); err != nil {
+		t.Fatalf("log line %q: %v", buf.String(), err)
+	}
+	return entry
+}
+
+func paymentRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(body))
+	req.Header.Set(types.HeaderPaymentPayload, secretSignature)
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestLoggingFormatsRedactPaymentHeader(t *testing.T) {
+	for name, mw := range map[string]func(http.Handler) http.Handler{
+		"default":    LoggingMiddleware,
+		"compact":    CompactLoggingMiddleware,
+		"structured": StructuredLoggingMiddleware,
+		"body":       func(next http.Handler) http.Handler { return BodyLoggingMiddleware(next, 1024) },
+	} {
+		for _, header := range types.PaymentHeaderAliases {
+			buf := captureLog(t)
+			req := paymentRequest(`{}`)
+			req.Header.Del(types.HeaderPaymentPayload)
+			req.Header.Set(header, secretSignature)
+			mw(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+			if strings.Contains(buf.String(), secretSignature) {
+				t.Errorf("%s: the %s header reached the log: %s", name, header, buf.String())
+			}
+		}
+	}
+}
+
+func TestStructuredLoggingMarksPaymentHeader(t *testing.T) {
+	buf := captureLog(t)
+	StructuredLoggingMiddleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), paymentRequest(`{}`))
+	entry := logEntry(t, buf)
+	if entry["payment_header"] != redacted || entry["status"] != float64(http.StatusNotFound) {
+		t.Errorf("log entry %v, want a redacted payment header and status 404", entry)
+	}
+}
+
+func TestBodyLoggingRedactsSensitiveFields(t *testing.T) {
+	buf := captureLog(t)
+	var seen []byte
+	handler := BodyLoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true,"payer":"0xabc","private_key":"` + secretSignature + `"}`))
+	}), 4096)
+
+	body := `{"paymentPayload":{"payload":{"signature":"` + secretSignature + `","authorization":{"nonce":"` + secretSignature + `"}}},"amounts":[{"privateKey":"` + secretSignature + `","value":"1000"}]}`
+	req := paymentRequest(body)
+	req.Header.Set("Authorization", "Bearer "+secretSignature)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if string(seen) != body {
+		t.Errorf("handler read %q, want the request body unchanged", seen)
+	}
+	if strings.Contains(buf.String(), secretSignature) {
+		t.Fatalf("a secret reached the log: %s", buf.String())
+	}
+	entry := logEntry(t, buf)
+	headers := entry["headers"].(map[string]interface{})
+	if headers[types.HeaderPaymentPayload] != redacted || headers["Authorization"] != redacted || headers["Content-Type"] != "application/json" {
+		t.Errorf("headers %v", headers)
+	}
+	payload := entry["request_body"].(map[string]interface{})["paymentPayload"].(map[string]interface{})["payload"].(map[string]interface{})
+	if payload["signature"] != redacted || payload["authorization"] != redacted {
+		t.Errorf("request payload %v, want signature and authorization redacted", payload)
+	}
+	amount := entry["request_body"].(map[string]interface{})["amounts"].([]interface{})[0].(map[string]interface{})
+	if amount["privateKey"] != redacted || amount["value"] != "1000" {
+		t.Errorf("request amount %v, want only the key redacted", amount)
+	}
+	response := entry["response_body"].(map[string]interface{})
+	if response["private_key"] != redacted || response["payer"] != "0xabc" || response["success"] != true {
+		t.Errorf("response body %v", response)
+	}
+}
+
+func TestBodyLoggingOmitsTruncatedAndNonJSONBodies(t *testing.T) {
+	buf := captureLog(t)
+	handler := BodyLoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("plain text " + secretSignature))
+	}), 16)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, paymentRequest(`{"signature":"`+secretSignature+`","padding":"more than sixteen bytes"}`))
+
+	if rec.Body.String() != "plain text "+secretSignature {
+		t.Errorf("client received %q, want the full response", rec.Body.String())
+	}
+	if strings.Contains(buf.String(), secretSignature) {
+		t.Fatalf("a secret reached the log: %s", buf.String())
+	}
+	entry := logEntry(t, buf)
+	for _, field := range []string{"request_body", "response_body"} {
+		if note, _ := entry[field].(string); !strings.HasSuffix(note, "bytes omitted]") {
+			t.Errorf("%s logged as %v, want a size note", field, entry[field])
+		}
+	}
+}
+
+func TestResponseRecorderBuffersOnlyWhenAsked(t *testing.T) {
+	rec := NewResponseRecorder(httptest.NewRecorder())
+	rec.Write([]byte("response"))
+	if rec.Body != nil {
+		t.Error("status recorder buffered the body")
+	}
+
+	capped := NewBodyRecorder(httptest.NewRecorder(), 4)
+	capped.Write([]byte("response"))
+	if capped.Body.String() != "resp" {
+		t.Errorf("body recorder kept %q, want the first 4 bytes", capped.Body.String())
+	}
+}
+
+// BenchmarkLoggingLargeResponse compares the default structured format,
+// which only records the status, with opt-in body capture
+func BenchmarkLoggingLargeResponse(b *testing.B) {
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(out)
+	payload := bytes.Repeat([]byte("x"), 1<<20)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	})
+
+	for name, handler := range map[string]http.Handler{
+		"status only":  StructuredLoggingMiddleware(next),
+		"body capture": BodyLoggingMiddleware(next, len(payload)),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				handler.ServeHTTP(discardWriter{}, httptest.NewRequest(http.MethodGet, "/download", nil))
+			}
+		})
+	}
+}
+
+// discardWriter is a ResponseWriter that keeps nothing
+type discardWriter struct{}
+
+func (discardWriter) Header() http.Header         { return http.Header{} }
+func (discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (discardWriter) WriteHeader(int)             {}
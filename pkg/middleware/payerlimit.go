@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Rate limit dimensions reported in the X-RateLimit-Scope header
+const (
+	RateLimitScopeIP     = "ip"
+	RateLimitScopePayer  = "payer"
+	RateLimitScopeTenant = "tenant"
+)
+
+// payerPaths are the endpoints limited per payer at their default paths
+var payerPaths = map[string]bool{
+	"/verify": true,
+	"/settle": true,
+}
+
+// DefaultPayerRoutes matches /verify and /settle at their default paths;
+// servers that rename them match the routes they registered instead (see
+// handlers.Handler.PayerRoutes)
+func DefaultPayerRoutes(r *http.Request) bool {
+	return payerPaths[path.Clean("/"+r.URL.Path)]
+}
+
+// payerField is the JSON path of the payer address in verify/settle requests
+var payerField = []string{"paymentPayload", "payload", "authorization", "from"}
+
+// PayerRateLimitMiddleware creates HTTP middleware that rate limits POST /verify
+// and /settle by the payer address in the request payload
+// The limiter's buckets are keyed by lowercased payer address instead of IP,
+// so gateways proxying many payers from one IP are not penalized
+func PayerRateLimitMiddleware(limiter *RateLimiter) func(http.Handler) http.Handler {
+	return PayerRateLimitMiddlewareFor(limiter, DefaultPayerRoutes)
+}
+
+// PayerRateLimitMiddlewareFor is PayerRateLimitMiddleware limiting the POSTs
+// payers matches
+func PayerRateLimitMiddlewareFor(limiter *RateLimiter, payers RouteMatcher) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.Body == nil || !payers(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			payer := peekPayer(r)
+			if payer != "" && !limiter.Allow(strings.ToLower(payer)) {
+				w.Header().Set("X-RateLimit-Scope", RateLimitScopePayer)
+				http.Error(w, "Rate limit exceeded for payer. Please try again later.", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// peekPayer streams the request body just far enough to find the payer
+// address and puts the consumed bytes back in front of the unread remainder,
+// so the handler still decodes the body once from the start
+// Returns "" if the body has no payer (the handler rejects it later)
+func peekPayer(r *http.Request) string {
+	var consumed bytes.Buffer
+	payer := findPayer(io.TeeReader(r.Body, &consumed))
+
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(&consumed, r.Body), r.Body}
+	return payer
+}
+
+// jsonFrame is an open object or array while walking JSON tokens
+type jsonFrame struct {
+	object    bool
+	key       string // current key (objects only)
+	expectKey bool   // next string token is a key
+}
+
+// findPayer walks JSON tokens until it reaches the payer field
+func findPayer(body io.Reader) string {
+	var stack []jsonFrame
+
+	dec := json.NewDecoder(body)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{':
+				stack = append(stack, jsonFrame{object: true, expectKey: true})
+			case '[':
+				stack = append(stack, jsonFrame{})
+			default:
+				stack = stack[:len(stack)-1]
+				if len(stack) == 0 {
+					return ""
+				}
+				if top := &stack[len(stack)-1]; top.object {
+					top.expectKey = true
+				}
+			}
+			continue
+		}
+
+		if len(stack) == 0 {
+			return ""
+		}
+		top := &stack[len(stack)-1]
+		if top.object && top.expectKey {
+			top.key, _ = tok.(string)
+			top.expectKey = false
+			continue
+		}
+		if value, ok := tok.(string); ok && atPayerField(stack) {
+			return value
+		}
+		if top.object {
+			top.expectKey = true
+		}
+	}
+}
+
+// atPayerField reports whether the current value sits at payerField
+func atPayerField(stack []jsonFrame) bool {
+	if len(stack) != len(payerField) {
+		return false
+	}
+	for i, name := range payerField {
+		if !stack[i].object || !strings.EqualFold(stack[i].key, name) {
+			return false
+		}
+	}
+	return true
+}
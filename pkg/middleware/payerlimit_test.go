@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// payerBody is a verify request paid by payer
+func payerBody(payer string) string {
+	return `{"x402Version":1,"paymentPayload":{"scheme":"exact","payload":{"signature":"0x01","authorization":{"to":"0xb0","from":"` + payer + `","value":"1000"}}},"paymentRequirements":{"payTo":"0xb0","extra":{"from":"0xdecoy"}}}`
+}
+
+func TestFindPayer(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body, want string
+	}{
+		"verify request":         {payerBody("0xAbC1"), "0xAbC1"},
+		"requirements first":     {`{"paymentRequirements":{"from":"0xdecoy"},"paymentPayload":{"payload":{"authorization":{"from":"0x02"}}}}`, "0x02"},
+		"arrays before":          {`{"accepts":[{"from":"0xdecoy"},[1,2]],"paymentPayload":{"payload":{"authorization":{"from":"0x03"}}}}`, "0x03"},
+		"key case":               {`{"PaymentPayload":{"Payload":{"Authorization":{"From":"0x04"}}}}`, "0x04"},
+		"from at the wrong path": {`{"paymentPayload":{"authorization":{"from":"0xdecoy"}}}`, ""},
+		"no payer":               {`{"paymentPayload":{"payload":{}}}`, ""},
+		"not JSON":               {`from=0x05`, ""},
+		"empty":                  {``, ""},
+	} {
+		if got := findPayer(strings.NewReader(tc.body)); got != tc.want {
+			t.Errorf("%s: payer %q, want %q", name, got, tc.want)
+		}
+	}
+}
+
+// limitedServer puts the IP limiter in front of the payer limiter, as the
+// facilitator does, and records the bodies that reach the handler
+func limitedServer(t *testing.T, ipBurst, payerBurst int, bodies *[]string) http.Handler {
+	t.Helper()
+	ipLimiter := NewRateLimiter(1, ipBurst)
+	payerLimiter := NewRateLimiter(1, payerBurst)
+	t.Cleanup(ipLimiter.Stop)
+	t.Cleanup(payerLimiter.Stop)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*bodies = append(*bodies, string(body))
+	})
+	return RateLimitMiddleware(ipLimiter)(PayerRateLimitMiddleware(payerLimiter)(handler))
+}
+
+func post(h http.Handler, path, remoteAddr, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPayerLimitOneIPManyPayers(t *testing.T) {
+	var bodies []string
+	h := limitedServer(t, 100, 1, &bodies)
+
+	for i := 0; i < 20; i++ {
+		body := payerBody(fmt.Sprintf("0x%040x", i))
+		if rec := post(h, "/verify", "203.0.113.1:1234", body); rec.Code != http.StatusOK {
+			t.Fatalf("payer %d behind a shared gateway IP: status %d", i, rec.Code)
+		}
+		if bodies[len(bodies)-1] != body {
+			t.Fatalf("handler read %q, want the body intact", bodies[len(bodies)-1])
+		}
+	}
+
+	// A payer's second request trips its own bucket, whatever the case
+	rec := post(h, "/settle", "203.0.113.1:1234", payerBody(fmt.Sprintf("0x%040X", 0)))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("X-RateLimit-Scope") != RateLimitScopePayer {
+		t.Errorf("repeat payer: status %d, scope %q; want 429 scoped to the payer", rec.Code, rec.Header().Get("X-RateLimit-Scope"))
+	}
+}
+
+func TestPayerLimitOnePayerManyIPs(t *testing.T) {
+	var bodies []string
+	h := limitedServer(t, 1, 3, &bodies)
+	body := payerBody("0x00000000000000000000000000000000000000a1")
+
+	for i := 0; i < 3; i++ {
+		if rec := post(h, "/settle", fmt.Sprintf("198.51.100.%d:1234", i), body); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, rec.Code)
+		}
+	}
+	rec := post(h, "/settle", "198.51.100.99:1234", body)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("X-RateLimit-Scope") != RateLimitScopePayer {
+		t.Errorf("payer rotating IPs: status %d, scope %q; want 429 scoped to the payer", rec.Code, rec.Header().Get("X-RateLimit-Scope"))
+	}
+
+	// The IP dimension reports itself
+	rec = post(h, "/settle", "198.51.100.0:1234", payerBody("0x00000000000000000000000000000000000000a2"))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("X-RateLimit-Scope") != RateLimitScopeIP {
+		t.Errorf("repeat IP: status %d, scope %q; want 429 scoped to the IP", rec.Code, rec.Header().Get("X-RateLimit-Scope"))
+	}
+}
+
+func TestPayerLimitOnlyCoversVerifyAndSettle(t *testing.T) {
+	limiter := NewRateLimiter(1, 1)
+	defer limiter.Stop()
+	h := PayerRateLimitMiddleware(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	body := payerBody("0x00000000000000000000000000000000000000a3")
+
+	for i := 0; i < 3; i++ {
+		if rec := post(h, "/simulate", "192.0.2.1:1", body); rec.Code != http.StatusOK {
+			t.Fatalf("/simulate request %d: status %d", i, rec.Code)
+		}
+	}
+	post(h, "/verify", "192.0.2.1:1", body)
+	if rec := post(h, "/verify", "192.0.2.1:1", body); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("repeat /verify: status %d, want 429", rec.Code)
+	}
+	// Bodies without a payer are left to the handler to reject
+	for i := 0; i < 3; i++ {
+		if rec := post(h, "/verify", "192.0.2.1:1", `{}`); rec.Code != http.StatusOK {
+			t.Errorf("payerless request %d: status %d", i, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// PaymentHeaderMiddleware creates HTTP middleware refusing requests that
+// carry more than one payment header value (under any of
+// types.PaymentHeaderAliases) or one above limit bytes, with a 400 and the
+// reason code; limit <= 0 checks duplicates only
+// The facilitator reads payments from request bodies, but a gateway in
+// front of it may not, and an ambiguous header must not reach either.
+func PaymentHeaderMiddleware(limit int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := types.CheckPaymentHeaders(r.Header, types.PaymentHeaderAliases, limit); err != nil {
+				response := map[string]interface{}{"error": "invalid payment header: " + err.Error()}
+				var headerErr *types.PaymentHeaderError
+				if errors.As(err, &headerErr) {
+					response["reasonCode"] = headerErr.Code
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(response)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
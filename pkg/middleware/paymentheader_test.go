@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// headerOf builds a header from name, value pairs, as a client adding
+// each line would
+func headerOf(pairs ...string) http.Header {
+	h := http.Header{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		h.Add(pairs[i], pairs[i+1])
+	}
+	return h
+}
+
+func TestPaymentHeaderMiddleware(t *testing.T) {
+	big := strings.Repeat("x", types.DefaultPaymentHeaderLimit+1)
+	for _, tc := range []struct {
+		name   string
+		limit  int
+		header http.Header
+		code   types.ReasonCode // Empty when the request is served
+	}{
+		{"no payment", types.DefaultPaymentHeaderLimit, http.Header{}, ""},
+		{"one payment", types.DefaultPaymentHeaderLimit, headerOf(types.HeaderXPayment, "payload"), ""},
+		{"repeated", types.DefaultPaymentHeaderLimit, headerOf(types.HeaderPaymentPayload, "a", types.HeaderPaymentPayload, "b"), types.ReasonDuplicatePayment},
+		{"two aliases", types.DefaultPaymentHeaderLimit, headerOf(types.HeaderXPayment, "a", types.HeaderPayment, "b"), types.ReasonDuplicatePayment},
+		{"oversized", types.DefaultPaymentHeaderLimit, headerOf(types.HeaderXPayment, big), types.ReasonPaymentTooLarge},
+		{"oversized without a limit", 0, headerOf(types.HeaderXPayment, big), ""},
+		{"repeated without a limit", 0, headerOf(types.HeaderXPayment, "a", types.HeaderXPayment, "b"), types.ReasonDuplicatePayment},
+	} {
+		var hit bool
+		handler := PaymentHeaderMiddleware(tc.limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hit = true
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/verify", nil)
+		req.Header = tc.header
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if tc.code == "" {
+			if !hit || rec.Code != http.StatusOK {
+				t.Errorf("%s: status %d, reached %v; want it served", tc.name, rec.Code, hit)
+			}
+			continue
+		}
+		var body struct {
+			Error      string           `json:"error"`
+			ReasonCode types.ReasonCode `json:"reasonCode"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		if hit || rec.Code != http.StatusBadRequest || body.ReasonCode != tc.code || body.Error == "" {
+			t.Errorf("%s: status %d, reached %v, body %s; want a 400 with %s", tc.name, rec.Code, hit, rec.Body.String(), tc.code)
+		}
+	}
+}
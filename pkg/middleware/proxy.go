@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies is a set of CIDR ranges whose forwarding headers are honored
+type TrustedProxies struct {
+	nets []*net.IPNet
+}
+
+// ParseTrustedProxies parses a comma-separated list of CIDRs or bare IPs
+// (e.g. "10.0.0.0/8, 192.168.1.10, ::1"). An empty list trusts no proxies.
+func ParseTrustedProxies(list string) (*TrustedProxies, error) {
+	tp := &TrustedProxies{}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address: %s", entry)
+			}
+			if ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy CIDR %s: %w", entry, err)
+		}
+		tp.nets = append(tp.nets, ipNet)
+	}
+	return tp, nil
+}
+
+// Contains reports whether ip falls within a trusted range
+func (tp *TrustedProxies) Contains(ip string) bool {
+	if tp == nil {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range tp.nets {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP extracts the real client IP from the request
+// Forwarding headers are only honored when the direct peer is a trusted proxy;
+// otherwise anyone could spoof X-Forwarded-For to dodge per-IP limits
+func (tp *TrustedProxies) ClientIP(r *http.Request) string {
+	remote := remoteIP(r)
+	if !tp.Contains(remote) {
+		return remote
+	}
+
+	// Walk X-Forwarded-For right to left and take the first untrusted hop,
+	// since only entries appended by our own proxies can be believed
+	if hops := parseXForwardedFor(r.Header.Values("X-Forwarded-For")); len(hops) > 0 {
+		for i := len(hops) - 1; i >= 0; i-- {
+			if !tp.Contains(hops[i]) {
+				return hops[i]
+			}
+		}
+		// Every hop is trusted, so the left-most is the originating client
+		return hops[0]
+	}
+
+	// Try X-Real-IP header (set by some proxies)
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); xri != "" {
+		return xri
+	}
+
+	return remote
+}
+
+// remoteIP returns the host part of the request's RemoteAddr
+func remoteIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// parseXForwardedFor flattens (possibly repeated) X-Forwarded-For header values
+func parseXForwardedFor(values []string) []string {
+	var ips []string
+	for _, value := range values {
+		for _, ip := range strings.Split(value, ",") {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return ips
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	tp, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.10, ::1, fd00::/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ip, want := range map[string]bool{
+		"10.1.2.3":     true,
+		"192.168.1.10": true,
+		"192.168.1.11": false,
+		"::1":          true,
+		"fd00::1":      true,
+		"2001:db8::1":  false,
+		"not an ip":    false,
+	} {
+		if got := tp.Contains(ip); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	for _, list := range []string{"10.0.0.0/33", "not-an-ip"} {
+		if _, err := ParseTrustedProxies(list); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) accepted an invalid entry", list)
+		}
+	}
+	if tp, err := ParseTrustedProxies(""); err != nil || tp.Contains("10.0.0.1") {
+		t.Errorf("empty list: err %v, or it trusts a peer", err)
+	}
+}
+
+func TestTrustedProxiesClientIP(t *testing.T) {
+	tp, err := ParseTrustedProxies("10.0.0.0/8, fd00::/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name    string
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{
+			name:    "spoofed header from an untrusted peer",
+			remote:  "203.0.113.7:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4"}, "X-Real-IP": {"1.2.3.4"}},
+			want:    "203.0.113.7",
+		},
+		{
+			name:    "single trusted proxy",
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"198.51.100.2"}},
+			want:    "198.51.100.2",
+		},
+		{
+			name:    "chained proxies take the right-most untrusted hop",
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.2, 10.0.0.2"}},
+			want:    "198.51.100.2",
+		},
+		{
+			name:    "repeated headers are one list",
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4", "198.51.100.2", "10.0.0.2"}},
+			want:    "198.51.100.2",
+		},
+		{
+			name:    "every hop trusted",
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			want:    "10.0.0.3",
+		},
+		{
+			name:    "X-Real-IP from a trusted proxy",
+			remote:  "10.0.0.1:5000",
+			headers: map[string][]string{"X-Real-IP": {"198.51.100.2"}},
+			want:    "198.51.100.2",
+		},
+		{
+			name:   "trusted proxy without forwarding headers",
+			remote: "10.0.0.1:5000",
+			want:   "10.0.0.1",
+		},
+		{
+			name:    "IPv6 untrusted peer",
+			remote:  "[2001:db8::7]:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4"}},
+			want:    "2001:db8::7",
+		},
+		{
+			name:    "IPv6 trusted proxy",
+			remote:  "[fd00::1]:5000",
+			headers: map[string][]string{"X-Forwarded-For": {"2001:db8::9, fd00::2"}},
+			want:    "2001:db8::9",
+		},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/verify", nil)
+		req.RemoteAddr = tc.remote
+		for name, values := range tc.headers {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		if got := tp.ClientIP(req); got != tc.want {
+			t.Errorf("%s: ClientIP = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNilTrustedProxiesIgnoresForwardingHeaders(t *testing.T) {
+	var tp *TrustedProxies
+	req := httptest.NewRequest(http.MethodPost, "/verify", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+	if got := tp.ClientIP(req); got != "10.0.0.1" {
+		t.Errorf("ClientIP = %s, want the direct peer", got)
+	}
+}
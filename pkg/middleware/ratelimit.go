@@ -1,70 +1,131 @@
 package middleware
 
 import (
-	"net"
+	"hash/maphash"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
 )
 
+// rateLimitShards is the number of independently locked visitor maps
+const rateLimitShards = 64
+
 // RateLimiter implements a token bucket rate limiter per IP address
+// Visitors are spread over shards with their own locks so concurrent
+// requests from different IPs rarely contend, and idle visitors are
+// evicted by a background goroutine (see Stop) instead of in Allow
 type RateLimiter struct {
-	mu       sync.RWMutex
-	visitors map[string]*visitor
+	shards [rateLimitShards]rateLimitShard
+	seed   maphash.Seed
 
 	// Rate limiting configuration
 	requestsPerMinute int
 	burstSize         int
 
+	// Proxies whose X-Forwarded-For / X-Real-IP headers are trusted
+	trustedProxies atomic.Pointer[TrustedProxies]
+
+	// Time source for token refills (holds a clockBox)
+	clock atomic.Value
+
 	// Cleanup
 	cleanupInterval time.Duration
-	lastCleanup     time.Time
+	stop            chan struct{}
+	stopOnce        sync.Once
+}
+
+// rateLimitShard is one lock-protected slice of the visitors map
+type rateLimitShard struct {
+	mu       sync.Mutex
+	visitors map[string]*visitor
+}
+
+// clockBox gives atomic.Value a single concrete type for any types.Clock
+type clockBox struct {
+	types.Clock
 }
 
 // visitor tracks rate limit state for a single IP
 type visitor struct {
-	tokens         float64
-	lastRefill     time.Time
-	lastRequest    time.Time
-	requestCount   int
+	tokens       float64
+	lastRefill   time.Time
+	lastRequest  time.Time
+	requestCount int
 }
 
 // NewRateLimiter creates a new rate limiter
 // requestsPerMinute: number of requests allowed per minute per IP
 // burstSize: maximum burst of requests allowed
+// It starts a cleanup goroutine; call Stop when the limiter is discarded
 func NewRateLimiter(requestsPerMinute, burstSize int) *RateLimiter {
-	return &RateLimiter{
-		visitors:          make(map[string]*visitor),
+	rl := &RateLimiter{
+		seed:              maphash.MakeSeed(),
 		requestsPerMinute: requestsPerMinute,
 		burstSize:         burstSize,
 		cleanupInterval:   5 * time.Minute,
-		lastCleanup:       time.Now(),
+		stop:              make(chan struct{}),
 	}
+	for i := range rl.shards {
+		rl.shards[i].visitors = make(map[string]*visitor)
+	}
+	rl.clock.Store(clockBox{types.SystemClock{}})
+	go rl.cleanupLoop()
+	return rl
+}
+
+// SetTrustedProxies configures which peers may supply forwarding headers
+// With no trusted proxies the direct RemoteAddr is always used
+func (rl *RateLimiter) SetTrustedProxies(tp *TrustedProxies) {
+	rl.trustedProxies.Store(tp)
+}
+
+// SetClock replaces the time source used for token refills
+func (rl *RateLimiter) SetClock(clock types.Clock) {
+	rl.clock.Store(clockBox{clock})
+}
+
+// Stop ends the background cleanup goroutine
+func (rl *RateLimiter) Stop() {
+	rl.stopOnce.Do(func() { close(rl.stop) })
+}
+
+// ClientIP returns the IP the limiter keys the request by
+func (rl *RateLimiter) ClientIP(r *http.Request) string {
+	return rl.trustedProxies.Load().ClientIP(r)
+}
+
+// now reads the limiter's clock
+func (rl *RateLimiter) now() time.Time {
+	return rl.clock.Load().(clockBox).Now()
+}
+
+// shard returns the shard holding ip
+func (rl *RateLimiter) shard(ip string) *rateLimitShard {
+	return &rl.shards[maphash.String(rl.seed, ip)%rateLimitShards]
 }
 
 // Allow checks if a request from the given IP should be allowed
 func (rl *RateLimiter) Allow(ip string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	// Periodic cleanup of old visitors
-	if time.Since(rl.lastCleanup) > rl.cleanupInterval {
-		rl.cleanup()
-	}
+	now := rl.now()
+	shard := rl.shard(ip)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
 	// Get or create visitor
-	v, exists := rl.visitors[ip]
+	v, exists := shard.visitors[ip]
 	if !exists {
 		v = &visitor{
 			tokens:      float64(rl.burstSize),
-			lastRefill:  time.Now(),
-			lastRequest: time.Now(),
+			lastRefill:  now,
+			lastRequest: now,
 		}
-		rl.visitors[ip] = v
+		shard.visitors[ip] = v
 	}
 
 	// Refill tokens based on time elapsed
-	now := time.Now()
 	elapsed := now.Sub(v.lastRefill).Seconds()
 	tokensToAdd := elapsed * (float64(rl.requestsPerMinute) / 60.0)
 
@@ -85,32 +146,55 @@ func (rl *RateLimiter) Allow(ip string) bool {
 	return false
 }
 
+// cleanupLoop periodically evicts idle visitors until Stop
+func (rl *RateLimiter) cleanupLoop() {
+	ticker := time.NewTicker(rl.cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rl.cleanup()
+		case <-rl.stop:
+			return
+		}
+	}
+}
+
 // cleanup removes visitors that haven't made requests in the last 10 minutes
+// Shards are locked one at a time so Allow is never blocked on all of them
 func (rl *RateLimiter) cleanup() {
-	cutoff := time.Now().Add(-10 * time.Minute)
-	for ip, v := range rl.visitors {
-		if v.lastRequest.Before(cutoff) {
-			delete(rl.visitors, ip)
+	cutoff := rl.now().Add(-10 * time.Minute)
+	for i := range rl.shards {
+		shard := &rl.shards[i]
+		shard.mu.Lock()
+		for ip, v := range shard.visitors {
+			if v.lastRequest.Before(cutoff) {
+				delete(shard.visitors, ip)
+			}
 		}
+		shard.mu.Unlock()
 	}
-	rl.lastCleanup = time.Now()
 }
 
 // GetStats returns statistics about the rate limiter (for monitoring)
 func (rl *RateLimiter) GetStats() map[string]interface{} {
-	rl.mu.RLock()
-	defer rl.mu.RUnlock()
-
+	activeIPs := 0
 	totalRequests := 0
-	for _, v := range rl.visitors {
-		totalRequests += v.requestCount
+	for i := range rl.shards {
+		shard := &rl.shards[i]
+		shard.mu.Lock()
+		activeIPs += len(shard.visitors)
+		for _, v := range shard.visitors {
+			totalRequests += v.requestCount
+		}
+		shard.mu.Unlock()
 	}
 
 	return map[string]interface{}{
-		"active_ips":      len(rl.visitors),
-		"total_requests":  totalRequests,
+		"active_ips":       activeIPs,
+		"total_requests":   totalRequests,
 		"requests_per_min": rl.requestsPerMinute,
-		"burst_size":      rl.burstSize,
+		"burst_size":       rl.burstSize,
 	}
 }
 
@@ -119,11 +203,12 @@ func RateLimitMiddleware(limiter *RateLimiter) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract IP address
-			ip := getClientIP(r)
+			ip := limiter.ClientIP(r)
 
 			// Check rate limit
 			if !limiter.Allow(ip) {
-				http.Error(w, "Rate limit exceeded. Please try again later.", http.StatusTooManyRequests)
+				w.Header().Set("X-RateLimit-Scope", RateLimitScopeIP)
+				http.Error(w, "Rate limit exceeded for client IP. Please try again later.", http.StatusTooManyRequests)
 				return
 			}
 
@@ -135,94 +220,3 @@ func RateLimitMiddleware(limiter *RateLimiter) func(http.Handler) http.Handler {
 		})
 	}
 }
-
-// getClientIP extracts the real client IP from the request
-// Handles proxies, load balancers, and direct connections
-func getClientIP(r *http.Request) string {
-	// Try X-Forwarded-For header (set by proxies)
-	xff := r.Header.Get("X-Forwarded-For")
-	if xff != "" {
-		// Take the first IP in the chain
-		ips := parseXForwardedFor(xff)
-		if len(ips) > 0 {
-			return ips[0]
-		}
-	}
-
-	// Try X-Real-IP header (set by some proxies)
-	xri := r.Header.Get("X-Real-IP")
-	if xri != "" {
-		return xri
-	}
-
-	// Fall back to RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-	return ip
-}
-
-// parseXForwardedFor parses the X-Forwarded-For header value
-func parseXForwardedFor(header string) []string {
-	var ips []string
-	for _, ip := range splitAndTrim(header, ",") {
-		if ip != "" {
-			ips = append(ips, ip)
-		}
-	}
-	return ips
-}
-
-// splitAndTrim splits a string and trims whitespace from each part
-func splitAndTrim(s, sep string) []string {
-	var result []string
-	for _, part := range splitString(s, sep) {
-		trimmed := trimWhitespace(part)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-	return result
-}
-
-// splitString splits a string by separator
-func splitString(s, sep string) []string {
-	if s == "" {
-		return []string{}
-	}
-	var parts []string
-	start := 0
-	for i := 0; i < len(s); i++ {
-		if i+len(sep) <= len(s) && s[i:i+len(sep)] == sep {
-			parts = append(parts, s[start:i])
-			start = i + len(sep)
-			i += len(sep) - 1
-		}
-	}
-	parts = append(parts, s[start:])
-	return parts
-}
-
-// trimWhitespace removes leading and trailing whitespace
-func trimWhitespace(s string) string {
-	start := 0
-	end := len(s)
-
-	// Trim leading whitespace
-	for start < end && isWhitespace(s[start]) {
-		start++
-	}
-
-	// Trim trailing whitespace
-	for end > start && isWhitespace(s[end-1]) {
-		end--
-	}
-
-	return s[start:end]
-}
-
-// isWhitespace checks if a byte is whitespace
-func isWhitespace(b byte) bool {
-	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
-}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// manualClock only moves when advanced
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestRateLimiterRefillsFromInjectedClock(t *testing.T) {
+	rl := NewRateLimiter(60, 2) // One token a second
+	defer rl.Stop()
+	clock := &manualClock{now: time.Unix(1_700_000_000, 0)}
+	rl.SetClock(clock)
+
+	for i := 0; i < 2; i++ {
+		if !rl.Allow("203.0.113.1") {
+			t.Fatalf("request %d within the burst was limited", i+1)
+		}
+	}
+	if rl.Allow("203.0.113.1") {
+		t.Fatal("request beyond the burst was allowed")
+	}
+
+	// Real time passing does not refill the bucket
+	time.Sleep(20 * time.Millisecond)
+	if rl.Allow("203.0.113.1") {
+		t.Error("allowed before the clock advanced")
+	}
+
+	clock.Advance(time.Second)
+	if !rl.Allow("203.0.113.1") {
+		t.Error("limited after a second's refill")
+	}
+	if rl.Allow("203.0.113.1") {
+		t.Error("a second refilled more than one token")
+	}
+
+	// Refills stop at the burst size
+	clock.Advance(time.Hour)
+	for i := 0; i < 2; i++ {
+		if !rl.Allow("203.0.113.1") {
+			t.Fatalf("request %d after an idle hour was limited", i+1)
+		}
+	}
+	if rl.Allow("203.0.113.1") {
+		t.Error("an idle hour refilled beyond the burst")
+	}
+}
+
+func TestRateLimiterConcurrentAllow(t *testing.T) {
+	const ips, burst, goroutines, calls = 20, 5, 200, 50
+	rl := NewRateLimiter(1, burst)
+	defer rl.Stop()
+	rl.SetClock(&manualClock{now: time.Unix(1_700_000_000, 0)}) // No refills
+
+	var allowed [ips]atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < calls; i++ {
+				ip := (g + i) % ips
+				if rl.Allow(fmt.Sprintf("198.51.100.%d", ip)) {
+					allowed[ip].Add(1)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for ip := range allowed {
+		if n := allowed[ip].Load(); n != burst {
+			t.Errorf("IP %d: %d requests allowed, want the burst of %d", ip, n, burst)
+		}
+	}
+	stats := rl.GetStats()
+	if stats["active_ips"] != ips || stats["total_requests"] != ips*burst {
+		t.Errorf("stats %v, want %d IPs and %d requests across shards", stats, ips, ips*burst)
+	}
+}
+
+func TestRateLimiterCleanupEvictsIdleVisitors(t *testing.T) {
+	rl := NewRateLimiter(60, 1)
+	defer rl.Stop()
+	clock := &manualClock{now: time.Unix(1_700_000_000, 0)}
+	rl.SetClock(clock)
+
+	for i := 0; i < 10; i++ {
+		rl.Allow(fmt.Sprintf("203.0.113.%d", i))
+	}
+	clock.Advance(9 * time.Minute)
+	rl.Allow("203.0.113.0") // Still active
+	clock.Advance(2 * time.Minute)
+	rl.cleanup()
+
+	if n := rl.GetStats()["active_ips"]; n != 1 {
+		t.Errorf("%v visitors after cleanup, want only the active one", n)
+	}
+	rl.Stop() // Stopping twice is harmless
+}
+
+// BenchmarkRateLimiterAllow compares contention on one shard (a single IP)
+// with requests spread over the shards (many IPs)
+func BenchmarkRateLimiterAllow(b *testing.B) {
+	for _, ips := range []int{1, 1024} {
+		b.Run(fmt.Sprintf("%d IPs", ips), func(b *testing.B) {
+			rl := NewRateLimiter(1<<30, 1<<30)
+			defer rl.Stop()
+			keys := make([]string, ips)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+			}
+			var next atomic.Uint64
+			b.RunParallel(func(pb *testing.PB) {
+				i := next.Add(1)
+				for pb.Next() {
+					rl.Allow(keys[i%uint64(len(keys))])
+					i += 7
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// maxRequestIDLength bounds caller-supplied request IDs
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware gives every request an ID, reusing the caller's
+// X-Request-ID when it is short printable ASCII and generating one otherwise
+// The ID is echoed in the response header and available to handlers (and
+// the facilitator) through types.RequestIDFromContext.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(types.HeaderRequestID)
+		if !validRequestID(id) {
+			id = types.NewRequestID()
+		}
+		w.Header().Set(types.HeaderRequestID, id)
+		next.ServeHTTP(w, r.WithContext(types.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID rejects empty, oversized and non-printable IDs, which would
+// otherwise be written verbatim into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
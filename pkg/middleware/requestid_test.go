@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	for _, tc := range []struct {
+		name, given string
+		kept        bool
+	}{
+		{"caller ID", "trace-42", true},
+		{"no ID", "", false},
+		{"oversized ID", strings.Repeat("x", maxRequestIDLength+1), false},
+		{"ID with spaces", "trace 42", false},
+		{"ID with a newline", "trace\n42", false},
+	} {
+		var seen string
+		handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = types.RequestIDFromContext(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/verify", nil)
+		if tc.given != "" {
+			req.Header.Set(types.HeaderRequestID, tc.given)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if seen == "" || rec.Header().Get(types.HeaderRequestID) != seen {
+			t.Errorf("%s: handler saw %q, response echoed %q", tc.name, seen, rec.Header().Get(types.HeaderRequestID))
+		}
+		if kept := seen == tc.given; kept != tc.kept {
+			t.Errorf("%s: request ID %q, kept the caller's %v; want %v", tc.name, seen, kept, tc.kept)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loggingFormats are the logging middlewares a handler may sit behind
+var loggingFormats = map[string]func(http.Handler) http.Handler{
+	"default":    LoggingMiddleware,
+	"compact":    CompactLoggingMiddleware,
+	"structured": StructuredLoggingMiddleware,
+	"body":       func(next http.Handler) http.Handler { return BodyLoggingMiddleware(next, 1024) },
+}
+
+func TestLoggingStreamsServerSentEvents(t *testing.T) {
+	captureLog(t)
+	for name, mw := range loggingFormats {
+		release := make(chan struct{})
+		server := httptest.NewServer(mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: first\n\n")
+			w.(http.Flusher).Flush()
+			<-release // The first event must arrive while the handler is still running
+			fmt.Fprint(w, "data: second\n\n")
+		})))
+
+		resp, err := http.Get(server.URL + "/settlements/stream")
+		if err != nil {
+			t.Fatal(err)
+		}
+		events := make(chan string)
+		go func() {
+			defer close(events)
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				if line := scanner.Text(); line != "" {
+					events <- line
+				}
+			}
+		}()
+		select {
+		case event := <-events:
+			if event != "data: first" {
+				t.Errorf("%s: first event %q", name, event)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("%s: the first event was not flushed to the client", name)
+		}
+		close(release)
+		if event := <-events; event != "data: second" {
+			t.Errorf("%s: second event %q", name, event)
+		}
+		resp.Body.Close()
+		server.Close()
+	}
+}
+
+// afterServing closes done once next has served (and logged) a request
+func afterServing(done chan struct{}, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestLoggingAllowsHijacking(t *testing.T) {
+	buf := captureLog(t)
+	for name, mw := range loggingFormats {
+		buf.Reset()
+		logged := make(chan struct{})
+		server := httptest.NewServer(afterServing(logged, mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, rw, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			defer conn.Close()
+			rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n")
+			rw.Flush()
+			line, _ := rw.ReadString('\n')
+			rw.WriteString("echo " + line)
+			rw.Flush()
+		}))))
+
+		conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprint(conn, "GET /ws HTTP/1.1\r\nHost: test\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n")
+		reader := bufio.NewReader(conn)
+		status, _ := reader.ReadString('\n')
+		if !strings.Contains(status, "101") {
+			t.Errorf("%s: status line %q, want 101", name, status)
+		}
+		for line, _ := reader.ReadString('\n'); line != "\r\n" && line != ""; line, _ = reader.ReadString('\n') {
+		}
+		fmt.Fprint(conn, "ping\n")
+		if echoed, _ := reader.ReadString('\n'); echoed != "echo ping\n" {
+			t.Errorf("%s: hijacked connection answered %q", name, echoed)
+		}
+		conn.Close()
+		<-logged
+		server.Close()
+		if !strings.Contains(buf.String(), "101") {
+			t.Errorf("%s: log %q does not record 101 Switching Protocols", name, buf.String())
+		}
+	}
+}
+
+func TestResponseRecorderOptionalInterfaces(t *testing.T) {
+	// Writers without the optional interfaces degrade instead of panicking
+	rec := NewResponseRecorder(discardWriter{})
+	rec.Flush()
+	if _, _, err := rec.Hijack(); err != http.ErrNotSupported {
+		t.Errorf("Hijack on a plain writer: %v, want ErrNotSupported", err)
+	}
+	if err := rec.Push("/style.css", nil); err != http.ErrNotSupported {
+		t.Errorf("Push on a plain writer: %v, want ErrNotSupported", err)
+	}
+	if rec.CloseNotify() == nil {
+		t.Error("CloseNotify returned a nil channel")
+	}
+	if _, ok := rec.Unwrap().(discardWriter); !ok {
+		t.Errorf("Unwrap returned %T, want the underlying writer", rec.Unwrap())
+	}
+
+	// ReadFrom still captures the body when asked to
+	target := httptest.NewRecorder()
+	capture := NewBodyRecorder(target, 4)
+	if n, err := io.Copy(capture, strings.NewReader("response")); err != nil || n != 8 {
+		t.Fatalf("copied %d bytes, %v", n, err)
+	}
+	if target.Body.String() != "response" || capture.Body.String() != "resp" {
+		t.Errorf("client got %q, recorder kept %q", target.Body.String(), capture.Body.String())
+	}
+}
+
+func TestDefaultStackStreamsServerSentEvents(t *testing.T) {
+	captureLog(t)
+	for name, accept := range map[string]string{"event-stream client": "text/event-stream", "any client": "*/*"} {
+		release := make(chan struct{})
+		flushErr := make(chan error, 1)
+		server := httptest.NewServer(DefaultStack(StackConfig{}).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: first\n\n")
+			flushErr <- http.NewResponseController(w).Flush()
+			<-release
+			fmt.Fprint(w, "data: second\n\n")
+		})))
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/settlements/stream", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", accept)
+		req.Header.Set("Accept-Encoding", "gzip")
+		// Headers only arrive once flushed, so a buffered stream never answers
+		responses := make(chan *http.Response, 1)
+		go func() {
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+			}
+			responses <- resp
+		}()
+		var resp *http.Response
+		select {
+		case resp = <-responses:
+		case <-time.After(2 * time.Second):
+			t.Errorf("%s: the first event was not flushed to the client", name)
+			close(release)
+			if resp = <-responses; resp != nil {
+				resp.Body.Close()
+			}
+			server.Close()
+			continue
+		}
+		if err := <-flushErr; err != nil {
+			t.Errorf("%s: flush through the stack: %v", name, err)
+		}
+		if encoding := resp.Header.Get("Content-Encoding"); encoding != "" {
+			t.Errorf("%s: event stream sent with Content-Encoding %q", name, encoding)
+		}
+		events := make(chan string)
+		go func() {
+			defer close(events)
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				if line := scanner.Text(); line != "" {
+					events <- line
+				}
+			}
+		}()
+		select {
+		case event := <-events:
+			if event != "data: first" {
+				t.Errorf("%s: first event %q", name, event)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("%s: the first event was not flushed to the client", name)
+		}
+		close(release)
+		if event := <-events; event != "data: second" {
+			t.Errorf("%s: second event %q", name, event)
+		}
+		resp.Body.Close()
+		server.Close()
+	}
+}
+
+func TestCompressionFlushesGzippedResponses(t *testing.T) {
+	rec := httptest.NewRecorder()
+	CompressionMiddleware(DefaultMaxBodyBytes, 16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"pending","padding":"over sixteen bytes"}`)
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("flush: %v", err)
+		}
+		if !rec.Flushed || rec.Body.Len() == 0 {
+			t.Errorf("flushed %v with %d bytes sent, want the gzipped start sent", rec.Flushed, rec.Body.Len())
+		}
+	})).ServeHTTP(rec, func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/settlements", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		return req
+	}())
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Content-Encoding %q, want gzip", rec.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestDefaultStackAllowsHijacking(t *testing.T) {
+	captureLog(t)
+	server := httptest.NewServer(DefaultStack(StackConfig{}).Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+	})))
+	defer server.Close()
+
+	for name, connection := range map[string]string{"upgrade": "Upgrade", "keep-alive": "keep-alive"} {
+		conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(conn, "GET /ws HTTP/1.1\r\nHost: test\r\nAccept-Encoding: gzip\r\nUpgrade: echo\r\nConnection: %s\r\n\r\n", connection)
+		if status, _ := bufio.NewReader(conn).ReadString('\n'); !strings.Contains(status, "101") {
+			t.Errorf("%s: status line %q, want 101", name, status)
+		}
+		conn.Close()
+	}
+}
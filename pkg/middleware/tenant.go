@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// TenantResolver identifies the tenants of a shared facilitator
+type TenantResolver interface {
+	// ResolveTenant returns the tenant named by the X-Tenant-ID header (id)
+	// or owning the bearer token (apiKey), either of which may be "", and
+	// the limiter throttling it (nil for none)
+	ResolveTenant(id, apiKey string) (tenant string, limiter *RateLimiter, err error)
+}
+
+// TenantMiddleware creates HTTP middleware that attributes every request to
+// a tenant, by X-Tenant-ID header or API key, throttles it with the tenant's
+// rate limit and makes it available through types.TenantIDFromContext.
+// POST requests naming no tenant are refused; /admin/ is left alone.
+func TenantMiddleware(resolver TenantResolver) func(http.Handler) http.Handler {
+	return TenantMiddlewareFor(resolver, DefaultAdminRoutes)
+}
+
+// TenantMiddlewareFor is TenantMiddleware leaving alone the admin requests
+// admin matches
+func TenantMiddlewareFor(resolver TenantResolver, admin RouteMatcher) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if admin(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id := strings.TrimSpace(r.Header.Get(types.HeaderTenantID))
+			var apiKey string
+			if scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " "); strings.EqualFold(scheme, "Bearer") {
+				apiKey = strings.TrimSpace(token)
+			}
+			if id == "" && apiKey == "" {
+				if r.Method == http.MethodPost {
+					http.Error(w, "Requests must name their tenant ("+types.HeaderTenantID+" header or the tenant's API key)", http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tenant, limiter, err := resolver.ResolveTenant(id, apiKey)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			if limiter != nil && !limiter.Allow(tenant) {
+				w.Header().Set("X-RateLimit-Scope", RateLimitScopeTenant)
+				http.Error(w, "Rate limit exceeded for tenant. Please try again later.", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(types.WithTenantID(r.Context(), tenant)))
+		})
+	}
+}
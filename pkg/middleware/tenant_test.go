@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// staticTenants resolves tenants by header alone, all throttled by limiter
+type staticTenants struct {
+	ids     map[string]bool
+	limiter *RateLimiter
+}
+
+func (s staticTenants) ResolveTenant(id, apiKey string) (string, *RateLimiter, error) {
+	if apiKey == "sk_shop" && (id == "" || id == "shop") {
+		return "shop", s.limiter, nil
+	}
+	if !s.ids[id] {
+		return "", nil, errors.New("unknown tenant")
+	}
+	return id, s.limiter, nil
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(60, 1)
+	defer limiter.Stop()
+	limiter.SetClock(&manualClock{now: time.Unix(1_700_000_000, 0)}) // No refills
+	var tenant string
+	handler := TenantMiddleware(staticTenants{ids: map[string]bool{"shop": true, "ops": true, "team": true}, limiter: limiter})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant = types.TenantIDFromContext(r.Context())
+		}))
+
+	for _, tc := range []struct {
+		name, method, path, id, key string
+		code                        int
+		tenant                      string
+	}{
+		{"header", http.MethodPost, "/verify", "ops", "", http.StatusOK, "ops"},
+		{"API key", http.MethodPost, "/settle", "", "sk_shop", http.StatusOK, "shop"},
+		{"unknown tenant", http.MethodPost, "/verify", "nobody", "", http.StatusForbidden, ""},
+		{"anonymous POST", http.MethodPost, "/verify", "", "", http.StatusUnauthorized, ""},
+		{"anonymous GET", http.MethodGet, "/supported", "", "", http.StatusOK, ""},
+		{"admin", http.MethodPost, "/admin/networks", "", "", http.StatusOK, ""},
+		{"over the tenant's limit", http.MethodPost, "/verify", "ops", "", http.StatusTooManyRequests, ""},
+		{"another tenant", http.MethodPost, "/verify", "team", "", http.StatusOK, "team"},
+	} {
+		tenant = ""
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.id != "" {
+			req.Header.Set(types.HeaderTenantID, tc.id)
+		}
+		if tc.key != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.code || tenant != tc.tenant {
+			t.Errorf("%s: status %d for tenant %q, want %d for %q", tc.name, rec.Code, tenant, tc.code, tc.tenant)
+		}
+		if tc.code == http.StatusTooManyRequests && rec.Header().Get("X-RateLimit-Scope") != RateLimitScopeTenant {
+			t.Errorf("%s: rate limit scope %q", tc.name, rec.Header().Get("X-RateLimit-Scope"))
+		}
+	}
+}
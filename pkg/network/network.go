@@ -3,6 +3,9 @@ package network
 import (
 	"fmt"
 	"math/big"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/x402-rs/x402-go/pkg/types"
@@ -12,15 +15,19 @@ import (
 type ChainID uint64
 
 const (
-	ChainIDBaseSepolia   ChainID = 84532
-	ChainIDBase          ChainID = 8453
-	ChainIDAvalancheFuji ChainID = 43113
-	ChainIDAvalanche     ChainID = 43114
-	ChainIDPolygonAmoy   ChainID = 80002
-	ChainIDPolygon       ChainID = 137
-	ChainIDSei           ChainID = 1329
-	ChainIDSeiTestnet    ChainID = 1328
-	ChainIDXDC           ChainID = 50
+	ChainIDBaseSepolia     ChainID = 84532
+	ChainIDBase            ChainID = 8453
+	ChainIDAvalancheFuji   ChainID = 43113
+	ChainIDAvalanche       ChainID = 43114
+	ChainIDPolygonAmoy     ChainID = 80002
+	ChainIDPolygon         ChainID = 137
+	ChainIDSei             ChainID = 1329
+	ChainIDSeiTestnet      ChainID = 1328
+	ChainIDXDC             ChainID = 50
+	ChainIDArbitrum        ChainID = 42161
+	ChainIDArbitrumSepolia ChainID = 421614
+	ChainIDOptimism        ChainID = 10
+	ChainIDOptimismSepolia ChainID = 11155420
 )
 
 // NetworkInfo contains metadata about a network
@@ -31,87 +38,91 @@ type NetworkInfo struct {
 	IsEVM   bool
 }
 
+// Default EIP-712 domain of USDC (FiatTokenV2) deployments
+const (
+	DefaultTokenDomainName    = "USD Coin"
+	DefaultTokenDomainVersion = "2"
+)
+
 // USDCDeployment represents a USDC token deployment on a network
 type USDCDeployment struct {
 	Network      types.Network
 	TokenAddress common.Address
 	TokenSymbol  string
 	Decimals     uint8
+
+	// EIP-712 domain of the token (empty uses the default USD Coin / 2)
+	DomainName    string
+	DomainVersion string
+
+	// How the token's ERC-3009 functions take the signature (empty is a
+	// single bytes argument)
+	SignatureEncoding SignatureEncoding
 }
 
-var (
-	// NetworkInfoMap maps network names to their information
-	NetworkInfoMap = map[types.Network]NetworkInfo{
-		types.NetworkBaseSepolia: {
-			Network: types.NetworkBaseSepolia,
-			ChainID: ChainIDBaseSepolia,
-			Name:    "Base Sepolia",
-			IsEVM:   true,
-		},
-		types.NetworkBase: {
-			Network: types.NetworkBase,
-			ChainID: ChainIDBase,
-			Name:    "Base",
-			IsEVM:   true,
-		},
-		types.NetworkAvalancheFuji: {
-			Network: types.NetworkAvalancheFuji,
-			ChainID: ChainIDAvalancheFuji,
-			Name:    "Avalanche Fuji",
-			IsEVM:   true,
-		},
-		types.NetworkAvalanche: {
-			Network: types.NetworkAvalanche,
-			ChainID: ChainIDAvalanche,
-			Name:    "Avalanche C-Chain",
-			IsEVM:   true,
-		},
-		types.NetworkPolygonAmoy: {
-			Network: types.NetworkPolygonAmoy,
-			ChainID: ChainIDPolygonAmoy,
-			Name:    "Polygon Amoy",
-			IsEVM:   true,
-		},
-		types.NetworkPolygon: {
-			Network: types.NetworkPolygon,
-			ChainID: ChainIDPolygon,
-			Name:    "Polygon",
-			IsEVM:   true,
-		},
-		types.NetworkSei: {
-			Network: types.NetworkSei,
-			ChainID: ChainIDSei,
-			Name:    "Sei",
-			IsEVM:   true,
-		},
-		types.NetworkSeiTestnet: {
-			Network: types.NetworkSeiTestnet,
-			ChainID: ChainIDSeiTestnet,
-			Name:    "Sei Testnet",
-			IsEVM:   true,
-		},
-		types.NetworkXDC: {
-			Network: types.NetworkXDC,
-			ChainID: ChainIDXDC,
-			Name:    "XDC",
-			IsEVM:   true,
-		},
-		types.NetworkSolana: {
-			Network: types.NetworkSolana,
-			Name:    "Solana",
-			IsEVM:   false,
-		},
-		types.NetworkSolanaDevnet: {
-			Network: types.NetworkSolanaDevnet,
-			Name:    "Solana Devnet",
-			IsEVM:   false,
-		},
+// SignatureEncoding is the signature parameter layout of a token's
+// transferWithAuthorization and cancelAuthorization
+type SignatureEncoding string
+
+const (
+	// SignatureBytes takes the 65-byte signature as one bytes argument
+	// (FiatToken v2.2 and later)
+	SignatureBytes SignatureEncoding = ""
+	// SignatureVRS takes the signature split into uint8 v, bytes32 r and
+	// bytes32 s (FiatToken before v2.2 and bridged forks such as Polygon PoS USDC.e)
+	SignatureVRS SignatureEncoding = "vrs"
+)
+
+// ParseSignatureEncoding parses "bytes" (or empty) or "vrs"
+func ParseSignatureEncoding(s string) (SignatureEncoding, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "bytes":
+		return SignatureBytes, nil
+	case "vrs":
+		return SignatureVRS, nil
+	default:
+		return "", fmt.Errorf("unknown signature encoding %q (want bytes or vrs)", s)
 	}
+}
+
+// SolanaTokenDeployment represents an SPL token mint on a Solana network
+type SolanaTokenDeployment struct {
+	Network     types.Network
+	Mint        string // Base58 mint address
+	TokenSymbol string
+	Decimals    uint8
+}
+
+// registry holds the network metadata kept here rather than in the types
+// registry: display names and token deployments. Chain IDs and EVM-ness come
+// from types.RegisterNetwork.
+var registry = struct {
+	sync.RWMutex
+	names  map[types.Network]string
+	usdc   map[types.Network]USDCDeployment
+	solana map[types.Network]SolanaTokenDeployment
+}{
+	names: map[types.Network]string{
+		types.NetworkBaseSepolia:     "Base Sepolia",
+		types.NetworkBase:            "Base",
+		types.NetworkAvalancheFuji:   "Avalanche Fuji",
+		types.NetworkAvalanche:       "Avalanche C-Chain",
+		types.NetworkPolygonAmoy:     "Polygon Amoy",
+		types.NetworkPolygon:         "Polygon",
+		types.NetworkSei:             "Sei",
+		types.NetworkSeiTestnet:      "Sei Testnet",
+		types.NetworkXDC:             "XDC",
+		types.NetworkArbitrum:        "Arbitrum One",
+		types.NetworkArbitrumSepolia: "Arbitrum Sepolia",
+		types.NetworkOptimism:        "OP Mainnet",
+		types.NetworkOptimismSepolia: "OP Sepolia",
+		types.NetworkSolana:          "Solana",
+		types.NetworkSolanaDevnet:    "Solana Devnet",
+	},
 
-	// USDCDeployments maps networks to their USDC token deployments
 	// NOTE: Asset whitelist validation is enforced in pkg/chain/evm/provider.go Verify()
 	// Currently whitelisted: Base mainnet USDC only (0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913)
-	USDCDeployments = map[types.Network]USDCDeployment{
+	usdc: map[types.Network]USDCDeployment{
 		types.NetworkBaseSepolia: {
 			Network:      types.NetworkBaseSepolia,
 			TokenAddress: common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e"),
@@ -153,31 +164,183 @@ var (
 			TokenAddress: common.HexToAddress("0xD4B5f10D61916Bd6E0860144a91Ac658dE8a1437"),
 			TokenSymbol:  "USDC",
 			Decimals:     6,
+			// XDC's USDC signs under its own domain name
+			DomainName:    "USDC",
+			DomainVersion: "2",
+		},
+		types.NetworkArbitrum: {
+			Network:      types.NetworkArbitrum,
+			TokenAddress: common.HexToAddress("0xaf88d065e77c8cC2239327C5EDb3A432268e5831"),
+			TokenSymbol:  "USDC",
+			Decimals:     6,
+		},
+		types.NetworkArbitrumSepolia: {
+			Network:      types.NetworkArbitrumSepolia,
+			TokenAddress: common.HexToAddress("0x75faf114eafb1BDbe2F0316DF893fd58CE46AA4d"),
+			TokenSymbol:  "USDC",
+			Decimals:     6,
+		},
+		types.NetworkOptimism: {
+			Network:      types.NetworkOptimism,
+			TokenAddress: common.HexToAddress("0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85"),
+			TokenSymbol:  "USDC",
+			Decimals:     6,
 		},
+		types.NetworkOptimismSepolia: {
+			Network:      types.NetworkOptimismSepolia,
+			TokenAddress: common.HexToAddress("0x5fd84259d66Cd46123540766Be93DFE6D43130D7"),
+			TokenSymbol:  "USDC",
+			Decimals:     6,
+		},
+	},
+
+	solana: map[types.Network]SolanaTokenDeployment{
+		types.NetworkSolana: {
+			Network:     types.NetworkSolana,
+			Mint:        "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v",
+			TokenSymbol: "USDC",
+			Decimals:    6,
+		},
+		types.NetworkSolanaDevnet: {
+			Network:     types.NetworkSolanaDevnet,
+			Mint:        "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU",
+			TokenSymbol: "USDC",
+			Decimals:    6,
+		},
+	},
+}
+
+// ValidatorAddress is the EIP-6492 validator contract address
+var ValidatorAddress = common.HexToAddress("0xdAcD51A54883eb67D95FAEb2BBfdC4a9a6BD2a3B")
+
+// RegisterNetwork registers an EVM network (see types.RegisterNetwork) under
+// a display name (empty uses the network name)
+func RegisterNetwork(net types.Network, chainID ChainID, name string) error {
+	if err := types.RegisterNetwork(net, uint64(chainID), true); err != nil {
+		return err
 	}
+	if name == "" {
+		name = string(net)
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.names[net]; !ok {
+		registry.names[net] = name
+	}
+	return nil
+}
 
-	// ValidatorAddress is the EIP-6492 validator contract address
-	ValidatorAddress = common.HexToAddress("0xdAcD51A54883eb67D95FAEb2BBfdC4a9a6BD2a3B")
-)
+// RegisterUSDCDeployment adds or replaces the USDC deployment of a
+// registered EVM network
+func RegisterUSDCDeployment(deployment USDCDeployment) error {
+	if !deployment.Network.IsEVM() {
+		return fmt.Errorf("unknown EVM network: %s", deployment.Network)
+	}
+	if deployment.TokenSymbol == "" {
+		deployment.TokenSymbol = "USDC"
+	}
+	if deployment.Decimals == 0 {
+		deployment.Decimals = 6
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.usdc[deployment.Network] = deployment
+	return nil
+}
 
 // GetNetworkInfo returns information about a network
 func GetNetworkInfo(network types.Network) (NetworkInfo, error) {
-	info, ok := NetworkInfoMap[network]
-	if !ok {
+	if !network.IsRegistered() {
 		return NetworkInfo{}, fmt.Errorf("unknown network: %s", network)
 	}
-	return info, nil
+	chainID, _ := network.ChainID()
+	registry.RLock()
+	name, ok := registry.names[network]
+	registry.RUnlock()
+	if !ok {
+		name = string(network)
+	}
+	return NetworkInfo{
+		Network: network,
+		ChainID: ChainID(chainID),
+		Name:    name,
+		IsEVM:   network.IsEVM(),
+	}, nil
+}
+
+// Networks returns information about every registered network, sorted by name
+func Networks() []NetworkInfo {
+	registered := types.RegisteredNetworks()
+	infos := make([]NetworkInfo, 0, len(registered))
+	for _, net := range registered {
+		if info, err := GetNetworkInfo(net); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos
 }
 
 // GetUSDCDeployment returns the USDC deployment for a network
 func GetUSDCDeployment(network types.Network) (USDCDeployment, error) {
-	deployment, ok := USDCDeployments[network]
+	registry.RLock()
+	deployment, ok := registry.usdc[network]
+	registry.RUnlock()
 	if !ok {
 		return USDCDeployment{}, fmt.Errorf("no USDC deployment for network: %s", network)
 	}
 	return deployment, nil
 }
 
+// USDCDeployments returns every registered USDC deployment, sorted by network
+func USDCDeployments() []USDCDeployment {
+	registry.RLock()
+	deployments := make([]USDCDeployment, 0, len(registry.usdc))
+	for _, deployment := range registry.usdc {
+		deployments = append(deployments, deployment)
+	}
+	registry.RUnlock()
+	sort.Slice(deployments, func(i, j int) bool { return deployments[i].Network < deployments[j].Network })
+	return deployments
+}
+
+// TokenDomain returns the EIP-712 domain name and version of token on network,
+// falling back to the default USDC domain for unregistered tokens
+func TokenDomain(network types.Network, token common.Address) (name, version string) {
+	name, version = DefaultTokenDomainName, DefaultTokenDomainVersion
+	deployment, err := GetUSDCDeployment(network)
+	if err != nil || deployment.TokenAddress != token {
+		return name, version
+	}
+	if deployment.DomainName != "" {
+		name = deployment.DomainName
+	}
+	if deployment.DomainVersion != "" {
+		version = deployment.DomainVersion
+	}
+	return name, version
+}
+
+// TokenSignatureEncoding returns the signature encoding of token on network
+// (SignatureBytes for unregistered tokens)
+func TokenSignatureEncoding(network types.Network, token common.Address) SignatureEncoding {
+	deployment, err := GetUSDCDeployment(network)
+	if err != nil || deployment.TokenAddress != token {
+		return SignatureBytes
+	}
+	return deployment.SignatureEncoding
+}
+
+// GetSolanaTokenDeployment returns the USDC mint for a Solana network
+func GetSolanaTokenDeployment(network types.Network) (SolanaTokenDeployment, error) {
+	registry.RLock()
+	deployment, ok := registry.solana[network]
+	registry.RUnlock()
+	if !ok {
+		return SolanaTokenDeployment{}, fmt.Errorf("no USDC mint for network: %s", network)
+	}
+	return deployment, nil
+}
+
 // ParseAmount parses a decimal amount string to wei/smallest unit
 func ParseAmount(amount string, decimals uint8) (*big.Int, error) {
 	// This is a simplified version - in production use decimal parsing library
@@ -0,0 +1,113 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestTokenDomain(t *testing.T) {
+	token := func(net types.Network) common.Address {
+		deployment, err := GetUSDCDeployment(net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return deployment.TokenAddress
+	}
+	xdc, base := token(types.NetworkXDC), token(types.NetworkBase)
+	for _, tc := range []struct {
+		name            string
+		network         types.Network
+		token           common.Address
+		domain, version string
+	}{
+		{"xdc USDC", types.NetworkXDC, xdc, "USDC", "2"},
+		{"base USDC", types.NetworkBase, base, DefaultTokenDomainName, DefaultTokenDomainVersion},
+		{"other token on xdc", types.NetworkXDC, base, DefaultTokenDomainName, DefaultTokenDomainVersion},
+		{"unknown network", "testchain", xdc, DefaultTokenDomainName, DefaultTokenDomainVersion},
+	} {
+		if domain, version := TokenDomain(tc.network, tc.token); domain != tc.domain || version != tc.version {
+			t.Errorf("%s: domain %q/%q, want %q/%q", tc.name, domain, version, tc.domain, tc.version)
+		}
+	}
+}
+
+func TestBuiltInL2Deployments(t *testing.T) {
+	for net, chainID := range map[types.Network]ChainID{
+		types.NetworkArbitrum:        42161,
+		types.NetworkArbitrumSepolia: 421614,
+		types.NetworkOptimism:        10,
+		types.NetworkOptimismSepolia: 11155420,
+	} {
+		info, err := GetNetworkInfo(net)
+		if err != nil || info.ChainID != chainID || !info.IsEVM {
+			t.Errorf("%s: info %+v (%v), want EVM chain %d", net, info, err, chainID)
+		}
+		if deployment, err := GetUSDCDeployment(net); err != nil || deployment.Decimals != 6 || deployment.TokenAddress == (common.Address{}) {
+			t.Errorf("%s: USDC deployment %+v (%v)", net, deployment, err)
+		}
+	}
+}
+
+func TestRegisterNetworkWithUSDC(t *testing.T) {
+	net := types.Network("network-test-chain")
+	if _, err := GetNetworkInfo(net); err == nil {
+		t.Fatal("unregistered network has info")
+	}
+	if err := RegisterUSDCDeployment(USDCDeployment{Network: net}); err == nil {
+		t.Error("USDC registered on an unknown network")
+	}
+	if err := RegisterNetwork(net, 990301, ""); err != nil {
+		t.Fatal(err)
+	}
+	token := common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	if err := RegisterUSDCDeployment(USDCDeployment{Network: net, TokenAddress: token}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := GetNetworkInfo(net)
+	if err != nil || info.ChainID != 990301 || info.Name != string(net) || !IsEVMNetwork(net) {
+		t.Errorf("info %+v (%v)", info, err)
+	}
+	deployment, err := GetUSDCDeployment(net)
+	if err != nil || deployment.TokenAddress != token || deployment.TokenSymbol != "USDC" || deployment.Decimals != 6 {
+		t.Errorf("USDC deployment %+v (%v), want defaults filled in", deployment, err)
+	}
+	listed := false
+	for _, info := range Networks() {
+		listed = listed || info.Network == net
+	}
+	if !listed {
+		t.Error("registered network missing from Networks")
+	}
+}
+
+func TestSignatureEncoding(t *testing.T) {
+	for input, want := range map[string]SignatureEncoding{"": SignatureBytes, "bytes": SignatureBytes, " VRS ": SignatureVRS} {
+		if got, err := ParseSignatureEncoding(input); err != nil || got != want {
+			t.Errorf("ParseSignatureEncoding(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseSignatureEncoding("split"); err == nil {
+		t.Error("an unknown encoding parsed")
+	}
+
+	const net types.Network = "vrs-network-test"
+	token := common.HexToAddress("0x00000000000000000000000000000000000000c7")
+	if err := RegisterNetwork(net, 990_835, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterUSDCDeployment(USDCDeployment{Network: net, TokenAddress: token, SignatureEncoding: SignatureVRS}); err != nil {
+		t.Fatal(err)
+	}
+	if got := TokenSignatureEncoding(net, token); got != SignatureVRS {
+		t.Errorf("registered token encoding %q, want vrs", got)
+	}
+	if got := TokenSignatureEncoding(net, common.HexToAddress("0x00000000000000000000000000000000000000c8")); got != SignatureBytes {
+		t.Errorf("other token encoding %q, want bytes", got)
+	}
+	if got := TokenSignatureEncoding(types.NetworkBase, token); got != SignatureBytes {
+		t.Errorf("token on another network %q, want bytes", got)
+	}
+}
@@ -0,0 +1,279 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// formatM is the format information of every level M mask, from the
+// table in ISO/IEC 18004 annex C
+var formatM = [8]int{
+	0b101010000010010, 0b101000100100101, 0b101111001111100, 0b101101101001011,
+	0b100010111111001, 0b100000011001110, 0b100111110010111, 0b100101010100000,
+}
+
+// readFormat returns both copies of the format information of c
+func readFormat(c *Code) (first, second int) {
+	set := func(bits *int, i int, dark bool) {
+		if dark {
+			*bits |= 1 << i
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		set(&first, i, c.Dark(8, i))
+	}
+	set(&first, 6, c.Dark(8, 7))
+	set(&first, 7, c.Dark(8, 8))
+	set(&first, 8, c.Dark(7, 8))
+	for i := 9; i < 15; i++ {
+		set(&first, i, c.Dark(14-i, 8))
+	}
+	for i := 0; i < 8; i++ {
+		set(&second, i, c.Dark(c.Size-1-i, 8))
+	}
+	for i := 8; i < 15; i++ {
+		set(&second, i, c.Dark(8, c.Size-15+i))
+	}
+	return first, second
+}
+
+// masked reports whether mask inverts the module at x, y
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// decode reads c back as a scanner would: the mask from the format
+// information, the codewords in zigzag order, each block checked by its
+// Reed-Solomon syndromes, then the byte mode segment
+func decode(t *testing.T, c *Code) []byte {
+	t.Helper()
+	format, copy2 := readFormat(c)
+	if format != copy2 {
+		t.Fatalf("format copies differ: %015b and %015b", format, copy2)
+	}
+	mask := -1
+	for m, bits := range formatM {
+		if bits == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("format %015b is not a level M format", format)
+	}
+
+	var codewords []byte
+	var cur byte
+	n := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if upward {
+					y = c.Size - 1 - vert
+				}
+				if c.isFunction[y][x] {
+					continue
+				}
+				cur = cur<<1 | b2i(c.Dark(x, y) != masked(mask, x, y))
+				if n++; n%8 == 0 {
+					codewords = append(codewords, cur)
+				}
+			}
+		}
+	}
+
+	// De-interleave: data codewords a column at a time, the long blocks
+	// having one more, then the error correction codewords
+	numBlocks, blockECC := eccBlocks[c.Version], eccPerBlock[c.Version]
+	raw := rawDataModules(c.Version) / 8
+	if len(codewords) != raw {
+		t.Fatalf("%d codewords, want %d", len(codewords), raw)
+	}
+	numShort := numBlocks - raw%numBlocks
+	shortData := raw/numBlocks - blockECC
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i <= shortData; i++ {
+		for j := range blocks {
+			if i < shortData || j >= numShort {
+				blocks[j] = append(blocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	for i := 0; i < blockECC; i++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], codewords[k])
+			k++
+		}
+	}
+
+	var data []byte
+	for j, block := range blocks {
+		// A codeword's polynomial vanishes at the generator's roots 2^0..2^(ecc-1)
+		root := byte(1)
+		for i := 0; i < blockECC; i++ {
+			var syndrome byte
+			for _, b := range block {
+				syndrome = gfMul(syndrome, root) ^ b
+			}
+			if syndrome != 0 {
+				t.Fatalf("block %d: syndrome %d is %d", j, i, syndrome)
+			}
+			root = gfMul(root, 2)
+		}
+		data = append(data, block[:len(block)-blockECC]...)
+	}
+
+	read := func(offset, width int) int {
+		v := 0
+		for i := offset; i < offset+width; i++ {
+			v = v<<1 | int(data[i/8]>>(7-i%8)&1)
+		}
+		return v
+	}
+	if mode := read(0, 4); mode != 0x4 {
+		t.Fatalf("mode %04b, want byte mode", mode)
+	}
+	count := read(4, countBits(c.Version))
+	out := make([]byte, count)
+	for i := range out {
+		out[i] = byte(read(4+countBits(c.Version)+8*i, 8))
+	}
+	return out
+}
+
+func b2i(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestEncodeDecodesBack(t *testing.T) {
+	link := "ethereum:pay-0x036CbD53842c5426634e7929541eC2318f3dCF7e@84532/transfer?address=0x00000000000000000000000000000000000000B0&uint256=10000"
+	for _, tc := range []struct {
+		data    string
+		version int
+	}{
+		{"", 1},
+		{"HELLO WORLD", 1},
+		{strings.Repeat("a", 14), 1}, // The most version 1-M holds
+		{strings.Repeat("a", 15), 2},
+		{link, 8},
+		{strings.Repeat("x", 300), 13},
+		{strings.Repeat("y", 2331), 40}, // The most version 40-M holds
+	} {
+		code, err := Encode([]byte(tc.data))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", len(tc.data), err)
+		}
+		if code.Version != tc.version || code.Size != 17+4*tc.version {
+			t.Errorf("%d bytes: version %d size %d, want version %d", len(tc.data), code.Version, code.Size, tc.version)
+		}
+		if got := decode(t, code); !bytes.Equal(got, []byte(tc.data)) {
+			t.Errorf("%d bytes: decoded %q", len(tc.data), got)
+		}
+	}
+
+	if _, err := Encode(bytes.Repeat([]byte("z"), 2332)); err == nil {
+		t.Error("encoded more than version 40-M holds")
+	}
+}
+
+func TestFunctionPatterns(t *testing.T) {
+	code, err := Encode(bytes.Repeat([]byte("v"), 110)) // Version 7, the first with version information
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Finder patterns: dark rings at distance 0, 1 and 3 from each center
+	for _, center := range [][2]int{{3, 3}, {code.Size - 4, 3}, {3, code.Size - 4}} {
+		for dy := -3; dy <= 3; dy++ {
+			for dx := -3; dx <= 3; dx++ {
+				dist := max(abs(dx), abs(dy))
+				if want := dist != 2; code.Dark(center[0]+dx, center[1]+dy) != want {
+					t.Fatalf("finder at %v: module %+d,%+d dark %v", center, dx, dy, !want)
+				}
+			}
+		}
+	}
+	// Timing patterns alternate between the finders
+	for i := 8; i < code.Size-8; i++ {
+		if code.Dark(i, 6) != (i%2 == 0) || code.Dark(6, i) != (i%2 == 0) {
+			t.Fatalf("timing module %d is wrong", i)
+		}
+	}
+	if !code.Dark(8, code.Size-8) {
+		t.Error("the dark module is light")
+	}
+	if code.Dark(-1, 0) || code.Dark(code.Size, 0) {
+		t.Error("the quiet zone is dark")
+	}
+
+	// Version 7 carries its version information, 0x07C94 in the standard
+	if code.Version != 7 {
+		t.Fatalf("version %d, want 7", code.Version)
+	}
+	var info, transposed int
+	for i := 0; i < 18; i++ {
+		a, b := code.Size-11+i%3, i/3
+		if code.Dark(a, b) {
+			info |= 1 << i
+		}
+		if code.Dark(b, a) {
+			transposed |= 1 << i
+		}
+	}
+	if info != 0x07C94 || transposed != info {
+		t.Errorf("version information %05X and %05X, want 07C94", info, transposed)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	code, err := Encode([]byte("HELLO WORLD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := code.SVG()
+	if !strings.HasPrefix(svg, "<svg ") || !strings.Contains(svg, `viewBox="0 0 29 29"`) {
+		t.Errorf("SVG %q, want a 21 module code in a 4 module quiet zone", svg[:min(len(svg), 120)])
+	}
+	// One unit square per dark module, offset by the quiet zone
+	dark := 0
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Dark(x, y) {
+				dark++
+			}
+		}
+	}
+	if n := strings.Count(svg, "h1v1h-1z"); n != dark {
+		t.Errorf("%d squares drawn, want %d", n, dark)
+	}
+	if !strings.Contains(svg, fmt.Sprintf("M%d,%dh1v1h-1z", 4, 4)) {
+		t.Error("the top left finder corner is not drawn at the quiet zone offset")
+	}
+}
@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/internal/testcerts"
+)
+
+// mutualTLSServer starts a server with a certificate from ca that requires
+// client certificates from ca, answering with the client's common name
+func mutualTLSServer(t *testing.T, ca *testcerts.Authority) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.Issue(t, "facilitator", testcerts.Server, "127.0.0.1", "facilitator.internal").Certificate},
+		ClientCAs:    ca.Pool(),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Refused handshakes are expected
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(rt http.RoundTripper, url string) (string, error) {
+	resp, err := (&http.Client{Transport: rt}).Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestAuthorizedTransportMutualTLS(t *testing.T) {
+	ca := testcerts.NewAuthority(t, "Internal CA")
+	server := mutualTLSServer(t, ca)
+	client := ca.Issue(t, "shop", testcerts.Client)
+
+	rt, err := AuthorizedTransport(DefaultConfig(), WithClientCertificate(client.CertFile, client.KeyFile), WithRootCAs(ca.Pool()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, err := get(rt, server.URL); err != nil || body != "shop" {
+		t.Errorf("with a client certificate: %q, %v; want the handshake to succeed as shop", body, err)
+	}
+
+	// The server refuses a client without a certificate, or with one from another authority
+	rt, err = AuthorizedTransport(DefaultConfig(), WithRootCAs(ca.Pool()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(rt, server.URL); err == nil {
+		t.Error("handshake without a client certificate succeeded")
+	}
+	stranger := testcerts.NewAuthority(t, "Other CA").Issue(t, "intruder", testcerts.Client)
+	rt, err = AuthorizedTransport(DefaultConfig(), WithClientCertificate(stranger.CertFile, stranger.KeyFile), WithRootCAs(ca.Pool()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(rt, server.URL); err == nil {
+		t.Error("handshake with an untrusted client certificate succeeded")
+	}
+
+	// The client refuses a server its roots do not trust
+	rt, err = AuthorizedTransport(DefaultConfig(), WithClientCertificate(client.CertFile, client.KeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(rt, server.URL); err == nil {
+		t.Error("trusted a server certificate from a private CA without WithRootCAs")
+	}
+}
+
+func TestAuthorizedTransportServerName(t *testing.T) {
+	ca := testcerts.NewAuthority(t, "Internal CA")
+	client := ca.Issue(t, "shop", testcerts.Client)
+	server := mutualTLSServer(t, ca)
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1) // Not a name on the certificate
+
+	for name, tc := range map[string]struct {
+		serverName string
+		ok         bool
+	}{
+		"host dialed":          {"", false},
+		"name on the cert":     {"facilitator.internal", true},
+		"name not on the cert": {"other.internal", false},
+	} {
+		opts := []TLSOption{WithClientCertificate(client.CertFile, client.KeyFile), WithRootCAs(ca.Pool())}
+		if tc.serverName != "" {
+			opts = append(opts, WithServerName(tc.serverName))
+		}
+		rt, err := AuthorizedTransport(DefaultConfig(), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := get(rt, url); (err == nil) != tc.ok {
+			t.Errorf("%s: error %v, want success %v", name, err, tc.ok)
+		}
+	}
+}
+
+func TestAuthorizedTransportKeepsConfig(t *testing.T) {
+	rt, err := AuthorizedTransport(Config{MaxIdleConnsPerHost: 7}, WithServerName("facilitator.internal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := rt.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 7 || transport.TLSClientConfig.ServerName != "facilitator.internal" || transport.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("transport pools %d idle conns, TLS config %+v", transport.MaxIdleConnsPerHost, transport.TLSClientConfig)
+	}
+}
+
+func TestCertificateLoadingFailures(t *testing.T) {
+	ca := testcerts.NewAuthority(t, "Internal CA")
+	client := ca.Issue(t, "shop", testcerts.Client)
+	if _, err := AuthorizedTransport(DefaultConfig(), WithClientCertificate(client.CertFile, "missing.key")); err == nil {
+		t.Error("loaded a client certificate without its key file")
+	}
+	if _, err := AuthorizedTransport(DefaultConfig(), WithClientCertificate(client.KeyFile, client.CertFile)); err == nil {
+		t.Error("loaded a client certificate from swapped files")
+	}
+
+	pool, err := LoadCertPool(ca.CertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ca.Issue(t, "shop", testcerts.Client).Leaf.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Errorf("pool from %s does not trust the authority: %v", ca.CertFile, err)
+	}
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate"), 0o600)
+	if _, err := LoadCertPool(empty); err == nil {
+		t.Error("loaded a pool from a file without certificates")
+	}
+	if _, err := LoadCertPool(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("loaded a pool from a missing file")
+	}
+}
@@ -0,0 +1,120 @@
+// Package transport builds tuned HTTP transports shared by the facilitator's
+// RPC client, the server middleware and PayingClient.
+package transport
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// Config tunes connection pooling for an HTTP client
+// Zero values fall back to the DefaultConfig values
+type Config struct {
+	MaxIdleConns        int           // Idle connections kept across all hosts
+	MaxIdleConnsPerHost int           // Idle connections kept per host
+	IdleConnTimeout     time.Duration // How long an idle connection is kept
+	TLSHandshakeTimeout time.Duration
+	DisableHTTP2        bool // Force HTTP/1.1 (HTTP/2 is attempted by default)
+
+	// OnConn, if set, is called for every request with whether it reused a
+	// pooled connection (see ConnStats)
+	OnConn func(reused bool)
+}
+
+// DefaultConfig returns the defaults: generous per-host pooling so bursts of
+// verifications to one facilitator or RPC node reuse connections
+func DefaultConfig() Config {
+	return Config{
+		MaxIdleConns:        200,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// withDefaults fills zero fields from DefaultConfig
+func (c Config) withDefaults() Config {
+	d := DefaultConfig()
+	if c.MaxIdleConns <= 0 {
+		c.MaxIdleConns = d.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost <= 0 {
+		c.MaxIdleConnsPerHost = d.MaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout <= 0 {
+		c.IdleConnTimeout = d.IdleConnTimeout
+	}
+	if c.TLSHandshakeTimeout <= 0 {
+		c.TLSHandshakeTimeout = d.TLSHandshakeTimeout
+	}
+	return c
+}
+
+// RoundTripper returns a pooled transport for the config
+func (c Config) RoundTripper() http.RoundTripper {
+	return c.roundTripper(nil)
+}
+
+// roundTripper builds the transport, with tlsConfig if not nil
+func (c Config) roundTripper(tlsConfig *tls.Config) http.RoundTripper {
+	c = c.withDefaults()
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		t.TLSClientConfig = tlsConfig
+	}
+	t.MaxIdleConns = c.MaxIdleConns
+	t.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	t.IdleConnTimeout = c.IdleConnTimeout
+	t.TLSHandshakeTimeout = c.TLSHandshakeTimeout
+	t.ForceAttemptHTTP2 = !c.DisableHTTP2
+
+	if c.OnConn == nil {
+		return t
+	}
+	return &tracingRoundTripper{base: t, onConn: c.OnConn}
+}
+
+// tracingRoundTripper reports connection reuse for each request
+type tracingRoundTripper struct {
+	base   http.RoundTripper
+	onConn func(reused bool)
+}
+
+func (t *tracingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.onConn(info.Reused)
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	return t.base.RoundTrip(req)
+}
+
+// ConnStats counts new vs reused connections; use Observe as Config.OnConn
+type ConnStats struct {
+	reused atomic.Uint64
+	opened atomic.Uint64
+}
+
+// Observe records one connection acquisition
+func (s *ConnStats) Observe(reused bool) {
+	if reused {
+		s.reused.Add(1)
+	} else {
+		s.opened.Add(1)
+	}
+}
+
+// Reused returns how many requests reused a pooled connection
+func (s *ConnStats) Reused() uint64 {
+	return s.reused.Load()
+}
+
+// Opened returns how many requests had to open a new connection
+func (s *ConnStats) Opened() uint64 {
+	return s.opened.Load()
+}
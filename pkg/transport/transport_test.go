@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDefaultsFillZeroFields(t *testing.T) {
+	rt := Config{MaxIdleConnsPerHost: 7}.RoundTripper().(*http.Transport)
+	d := DefaultConfig()
+	if rt.MaxIdleConnsPerHost != 7 || rt.MaxIdleConns != d.MaxIdleConns || rt.IdleConnTimeout != d.IdleConnTimeout || rt.TLSHandshakeTimeout != d.TLSHandshakeTimeout {
+		t.Errorf("transport pools %d/%d idle conns for %v, handshake %v", rt.MaxIdleConnsPerHost, rt.MaxIdleConns, rt.IdleConnTimeout, rt.TLSHandshakeTimeout)
+	}
+	if !rt.ForceAttemptHTTP2 {
+		t.Error("HTTP/2 is not attempted by default")
+	}
+	if (Config{DisableHTTP2: true}).RoundTripper().(*http.Transport).ForceAttemptHTTP2 {
+		t.Error("DisableHTTP2 still attempts HTTP/2")
+	}
+	if _, ok := (Config{OnConn: func(bool) {}}).RoundTripper().(*tracingRoundTripper); !ok {
+		t.Error("OnConn is not traced")
+	}
+}
+
+func TestSequentialRequestsReuseOneConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	var stats ConnStats
+	client := &http.Client{Timeout: 5 * time.Second, Transport: Config{OnConn: stats.Observe}.RoundTripper()}
+	const requests = 200
+	for i := 0; i < requests; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if stats.Opened() != 1 || stats.Reused() != requests-1 {
+		t.Errorf("%d connections opened, %d reused; want 1 and %d", stats.Opened(), stats.Reused(), requests-1)
+	}
+}
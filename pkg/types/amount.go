@@ -0,0 +1,92 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// maxUint256 is the largest value an on-chain uint256 amount can hold
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// ParseTokenAmount parses a canonical base-10 token amount that fits in uint256
+// Signs, exponents, hex, whitespace and leading zeros are rejected
+func ParseTokenAmount(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("amount is empty")
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return nil, fmt.Errorf("amount %q must be a base-10 integer", s)
+		}
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return nil, fmt.Errorf("amount %q must not have leading zeros", s)
+	}
+	value, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("amount %q must be a base-10 integer", s)
+	}
+	if value.Cmp(maxUint256) > 0 {
+		return nil, fmt.Errorf("amount %q exceeds uint256", s)
+	}
+	return value, nil
+}
+
+// ValidateAmounts checks the required and authorized amounts before any RPC work
+// MaxAmountRequired must be positive unless AllowZeroAmount is set; the
+// authorized value must be positive unless a zero amount is allowed and required
+func ValidateAmounts(payload *PaymentPayload, requirements *PaymentRequirements) *FacilitatorError {
+	payer := PayerFromPayload(payload)
+
+	required, err := ParseTokenAmount(requirements.MaxAmountRequired)
+	if err != nil {
+		return NewInvalidAmountError(payer, fmt.Sprintf("invalid maxAmountRequired: %v", err))
+	}
+	if required.Sign() == 0 && !requirements.AllowZeroAmount {
+		return NewInvalidAmountError(payer, "maxAmountRequired must be greater than zero")
+	}
+
+	value, err := ParseTokenAmount(payload.Payload.Authorization.Value)
+	if err != nil {
+		return NewInvalidAmountError(payer, fmt.Sprintf("invalid authorization value: %v", err))
+	}
+	if value.Sign() == 0 && required.Sign() != 0 {
+		return NewInvalidAmountError(payer, "authorization value must be greater than zero")
+	}
+	return nil
+}
+
+// ParseDecimalAmount converts a decimal token amount to smallest units,
+// exactly: more fractional digits than decimals is an error, not rounding
+func ParseDecimalAmount(amount string, decimals uint8) (*big.Int, error) {
+	whole, frac, _ := strings.Cut(strings.TrimSpace(amount), ".")
+	if whole == "" && frac == "" {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	if len(frac) > int(decimals) {
+		return nil, fmt.Errorf("amount %q has more than %d decimal places", amount, decimals)
+	}
+	digits := whole + frac + strings.Repeat("0", int(decimals)-len(frac))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("invalid amount %q", amount)
+		}
+	}
+	value, _ := new(big.Int).SetString(digits, 10)
+	return value, nil
+}
+
+// FormatDecimalAmount writes smallest units as a decimal amount, without
+// trailing fractional zeros (the inverse of ParseDecimalAmount)
+func FormatDecimalAmount(amount *big.Int, decimals uint8) string {
+	digits := amount.String()
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-int(decimals)], strings.TrimRight(digits[len(digits)-int(decimals):], "0")
+	if frac == "" {
+		return whole
+	}
+	return whole + "." + frac
+}
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// overflow is 2^256, one more than the largest uint256
+const overflow = "115792089237316195423570985008687907853269984665640564039457584007913129639936"
+
+func TestParseTokenAmount(t *testing.T) {
+	for _, tc := range []struct {
+		amount string
+		valid  bool
+	}{
+		{"1", true},
+		{"0", true},
+		{"1000000", true},
+		{overflow[:77] + "5", true}, // 2^256 - 1
+		{"", false},
+		{"-1", false},
+		{"+1", false},
+		{"00", false},
+		{"01", false},
+		{"1e6", false},
+		{"0x10", false},
+		{"ff", false},
+		{" 1", false},
+		{"1.5", false},
+		{overflow, false},
+	} {
+		_, err := ParseTokenAmount(tc.amount)
+		if (err == nil) != tc.valid {
+			t.Errorf("ParseTokenAmount(%q): error %v, want valid %v", tc.amount, err, tc.valid)
+		}
+	}
+}
+
+func TestValidateAmounts(t *testing.T) {
+	payer := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	for _, tc := range []struct {
+		required, value string
+		allowZero       bool
+		wantErr         string
+	}{
+		{"1000", "1000", false, ""},
+		{"0", "0", true, ""},
+		{"0", "1000", true, ""},
+		{"0", "0", false, "greater than zero"},
+		{"1000", "0", false, "greater than zero"},
+		{"-1", "1000", false, "maxAmountRequired"},
+		{"1000", "-1", false, "authorization value"},
+		{"00", "1000", false, "leading zeros"},
+		{"1e6", "1000000", false, "base-10"},
+		{"1000", "0x3e8", false, "base-10"},
+		{overflow, "1000", false, "uint256"},
+		{"1000", overflow, false, "uint256"},
+	} {
+		payload := &PaymentPayload{Payload: ExactEvmPayload{Authorization: ExactEvmPayloadAuthorization{From: payer, Value: tc.value}}}
+		requirements := &PaymentRequirements{MaxAmountRequired: tc.required, AllowZeroAmount: tc.allowZero}
+		err := ValidateAmounts(payload, requirements)
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("required %q, value %q: %v", tc.required, tc.value, err)
+		case tc.wantErr != "" && err == nil:
+			t.Errorf("required %q, value %q: accepted, want an error mentioning %q", tc.required, tc.value, tc.wantErr)
+		case tc.wantErr != "" && (err.Type != "InvalidAmount" || !strings.Contains(err.Message, tc.wantErr)):
+			t.Errorf("required %q, value %q: %s %q, want InvalidAmount mentioning %q", tc.required, tc.value, err.Type, err.Message, tc.wantErr)
+		}
+		if err != nil && (err.Payer == nil || err.Payer.Address != payer.Hex()) {
+			t.Errorf("required %q, value %q: payer %+v, want %s", tc.required, tc.value, err.Payer, payer.Hex())
+		}
+	}
+}
+
+func TestDecimalAmounts(t *testing.T) {
+	for _, tc := range []struct {
+		amount   string
+		decimals uint8
+		units    string
+		text     string // FormatDecimalAmount of units
+	}{
+		{"1.5", 18, "1500000000000000000", "1.5"},
+		{"0.025", 6, "25000", "0.025"},
+		{" 10 ", 6, "10000000", "10"},
+		{".5", 1, "5", "0.5"},
+		{"7.", 2, "700", "7"},
+		{"42", 0, "42", "42"},
+		{"0.000001", 6, "1", "0.000001"},
+		{"0", 8, "0", "0"},
+	} {
+		units, err := ParseDecimalAmount(tc.amount, tc.decimals)
+		if err != nil || units.String() != tc.units {
+			t.Errorf("ParseDecimalAmount(%q, %d) = %v (%v), want %s", tc.amount, tc.decimals, units, err, tc.units)
+			continue
+		}
+		if text := FormatDecimalAmount(units, tc.decimals); text != tc.text {
+			t.Errorf("FormatDecimalAmount(%s, %d) = %q, want %q", units, tc.decimals, text, tc.text)
+		}
+	}
+
+	for _, bad := range []string{"", ".", "1.2345678", "-1", "1e6", "1,5", "0x10"} {
+		if units, err := ParseDecimalAmount(bad, 6); err == nil {
+			t.Errorf("ParseDecimalAmount(%q) = %s, want an error", bad, units)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package types
+
+import "github.com/ethereum/go-ethereum/common"
+
+// CancelAuthorization identifies the ERC-3009 authorization a payer revokes
+type CancelAuthorization struct {
+	Authorizer common.Address `json:"authorizer"` // The payer (authorization.from)
+	Nonce      HexBytes       `json:"nonce"`      // The authorization's nonce
+}
+
+// CancelRequest asks the facilitator to cancel an unsettled authorization
+// on-chain, so it can no longer be settled within its validity window
+type CancelRequest struct {
+	X402Version   int                 `json:"x402Version"`
+	Network       Network             `json:"network"`
+	Asset         common.Address      `json:"asset"` // Token contract the authorization is for
+	Authorization CancelAuthorization `json:"authorization"`
+	Signature     HexBytes            `json:"signature"` // EIP-712 CancelAuthorization signature by the authorizer
+}
+
+// CancelResponse is the response from cancelling an authorization
+type CancelResponse struct {
+	Success         bool             `json:"success"`
+	TransactionHash *TransactionHash `json:"transaction_hash,omitempty"`
+	Error           string           `json:"error,omitempty"`
+	ReasonCode      ReasonCode       `json:"reason_code,omitempty"`
+	RevertCode      string           `json:"revert_code,omitempty"` // FacilitatorError type of the on-chain revert
+}
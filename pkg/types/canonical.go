@@ -0,0 +1,118 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// CanonicalJSON encodes v as canonical JSON: object keys sorted, no
+// insignificant whitespace, no HTML escaping, numbers kept as written.
+// Two values that differ only in field order or formatting encode to the
+// same bytes, so the result is safe to sign.
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalizeJSON(raw)
+}
+
+// CanonicalizeJSON rewrites a JSON document in canonical form (see CanonicalJSON)
+func CanonicalizeJSON(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	// encoding/json sorts map keys; only HTML escaping needs turning off
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// CanonicalHash returns the keccak256 hash of v's normalized canonical JSON,
+// a stable key for caching values that are equal in meaning. On top of
+// CanonicalJSON, 0x-prefixed hex strings (addresses, nonces, signatures) are
+// lowercased and empty members (null, "", {} and []) are dropped, so a
+// checksummed and a lowercase address, or an omitted and an empty optional
+// field, hash the same. Use CanonicalJSON, not this form, for signing.
+func CanonicalHash(v interface{}) (common.Hash, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return common.Hash{}, err
+	}
+	canonical, err := CanonicalJSON(normalizeForHash(doc))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(canonical), nil
+}
+
+// HashPayload returns the canonical hash of a payment payload (see CanonicalHash)
+func HashPayload(payload *PaymentPayload) (common.Hash, error) {
+	return CanonicalHash(payload)
+}
+
+// HashRequirements returns the canonical hash of payment requirements (see CanonicalHash)
+func HashRequirements(requirements *PaymentRequirements) (common.Hash, error) {
+	return CanonicalHash(requirements)
+}
+
+// normalizeForHash lowercases hex strings and drops empty object members
+func normalizeForHash(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, member := range v {
+			member = normalizeForHash(member)
+			if isEmptyJSON(member) {
+				delete(v, key)
+				continue
+			}
+			v[key] = member
+		}
+		return v
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeForHash(elem)
+		}
+		return v
+	case string:
+		if len(v) > 2 && (v[:2] == "0x" || v[:2] == "0X") && isHex(v[2:]) {
+			return strings.ToLower(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isEmptyJSON(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalHashIgnoresOrderCaseAndEmptyFields(t *testing.T) {
+	a := `{"x402Version":1,"scheme":"exact","network":"base","payload":{"signature":"0xABCDEF","authorization":{"from":"0x00000000000000000000000000000000000000A1","to":"0x00000000000000000000000000000000000000b0","value":"1000","nonce":"0xFF"}},"extra":{}}`
+	b := `{
+		"payload": {"authorization": {"nonce": "0xff", "value": "1000", "to": "0x00000000000000000000000000000000000000B0", "from": "0x00000000000000000000000000000000000000a1"}, "signature": "0xabcdef"},
+		"network": "base", "scheme": "exact", "x402Version": 1, "resource": ""
+	}`
+	hashA, err := CanonicalHash(json.RawMessage(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashB, err := CanonicalHash(json.RawMessage(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashA != hashB {
+		t.Errorf("equivalent documents hash to %s and %s", hashA, hashB)
+	}
+
+	for name, changed := range map[string]string{
+		"value":      strings.Replace(a, `"1000"`, `"1001"`, 1),
+		"network":    strings.Replace(a, `"base"`, `"base-sepolia"`, 1),
+		"non-hex":    strings.Replace(a, `"exact"`, `"EXACT"`, 1),
+		"extra kept": strings.Replace(a, `"extra":{}`, `"extra":{"k":"v"}`, 1),
+	} {
+		hash, err := CanonicalHash(json.RawMessage(changed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hash == hashA {
+			t.Errorf("changing the %s left the hash unchanged", name)
+		}
+	}
+
+	// The signing form keeps case, so existing signatures stay valid
+	canonical, err := CanonicalizeJSON([]byte(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(canonical), "0x00000000000000000000000000000000000000A1") || !strings.Contains(string(canonical), `"extra":{}`) {
+		t.Errorf("CanonicalizeJSON normalized the document: %s", canonical)
+	}
+}
+
+func TestHashRequirementsMatchesEquivalentRequirements(t *testing.T) {
+	decode := func(doc string) *PaymentRequirements {
+		var r PaymentRequirements
+		if err := json.Unmarshal([]byte(doc), &r); err != nil {
+			t.Fatal(err)
+		}
+		return &r
+	}
+	a := decode(`{"scheme":"exact","network":"base","maxAmountRequired":"1000","payTo":"0x00000000000000000000000000000000000000B0","asset":"0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913","extra":{"name":"USD Coin","version":"2"}}`)
+	b := decode(`{"extra":{"version":"2","name":"USD Coin"},"asset":"0x833589fcd6edb6e08f4c7c32d4f71b54bda02913","payTo":"0x00000000000000000000000000000000000000b0","maxAmountRequired":"1000","network":"base","scheme":"exact"}`)
+	hashA, err := HashRequirements(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashB, err := HashRequirements(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashA != hashB {
+		t.Errorf("equivalent requirements hash to %s and %s", hashA, hashB)
+	}
+	b.MaxAmountRequired = "2000"
+	if hash, _ := HashRequirements(b); hash == hashA {
+		t.Error("a different amount hashed the same")
+	}
+}
+
+func TestHashPayloadMatchesEquivalentPayloads(t *testing.T) {
+	decode := func(doc string) *PaymentPayload {
+		var p PaymentPayload
+		if err := json.Unmarshal([]byte(doc), &p); err != nil {
+			t.Fatal(err)
+		}
+		return &p
+	}
+	a := decode(`{"x402Version":1,"scheme":"exact","network":"base","payload":{"signature":"0xABCD","authorization":{"from":"0x00000000000000000000000000000000000000A1","to":"0x00000000000000000000000000000000000000b0","value":"1000","validAfter":"0","validBefore":"10","nonce":"0x00000000000000000000000000000000000000000000000000000000000000FF"}}}`)
+	b := decode(`{"payload":{"authorization":{"nonce":"0x00000000000000000000000000000000000000000000000000000000000000ff","validBefore":"10","validAfter":"0","value":"1000","to":"0x00000000000000000000000000000000000000B0","from":"0x00000000000000000000000000000000000000a1"},"signature":"0xabcd"},"network":"base","scheme":"exact","x402Version":1}`)
+	hashA, err := HashPayload(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashB, err := HashPayload(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashA != hashB {
+		t.Errorf("equivalent payloads hash to %s and %s", hashA, hashB)
+	}
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ChallengeExtraKey is the requirements/payload Extra key carrying a PaymentChallenge
+const ChallengeExtraKey = "challenge"
+
+// PaymentChallenge binds a payment to one 402 response.
+//
+// A server in challenge mode puts a single-use nonce and the resource in
+// the requirements' extra field. The client echoes the extra field in its
+// payload and uses AuthorizationNonce as the EIP-3009 nonce, so the
+// signature itself commits to the challenge and the resource.
+type PaymentChallenge struct {
+	Nonce    string `json:"nonce"`
+	Resource string `json:"resource"`
+}
+
+// AuthorizationNonce returns the authorization nonce derived from the challenge:
+// keccak256(nonce || resource), hex-encoded
+func (c *PaymentChallenge) AuthorizationNonce() string {
+	hash := crypto.Keccak256Hash([]byte(c.Nonce), []byte(c.Resource))
+	return hash.Hex()
+}
+
+// ParseChallenge extracts the challenge from an extra field
+// Returns nil if none is present
+func ParseChallenge(extra json.RawMessage) (*PaymentChallenge, error) {
+	var challenge PaymentChallenge
+	found, err := GetExtraField(extra, ChallengeExtraKey, &challenge)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &challenge, nil
+}
@@ -0,0 +1,17 @@
+package types
+
+import "time"
+
+// Clock is a source of the current time
+// Components that validate timing take one so tests can control time
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+// Now implements Clock
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// WalletLink returns the EIP-681 link a wallet opens to pay the requirements
+// with a plain token transfer, for people paying by hand:
+//
+//	ethereum:pay-<asset>@<chainId>/transfer?address=<payTo>&uint256=<amount>
+//
+// Such a transfer is not an x402 payment (no authorization is signed); the
+// server has to observe it on-chain.
+func (r *PaymentRequirements) WalletLink() (string, error) {
+	if !r.Network.IsEVM() {
+		return "", fmt.Errorf("no wallet link for non-EVM network %s", r.Network)
+	}
+	chainID, ok := r.Network.ChainID()
+	if !ok {
+		return "", fmt.Errorf("unknown chain ID for network %s", r.Network)
+	}
+	payTo := NormalizeEVMAddress(r.PayTo)
+	if !common.IsHexAddress(payTo) {
+		return "", fmt.Errorf("invalid payTo address %q", r.PayTo)
+	}
+	amount, ok := new(big.Int).SetString(r.MaxAmountRequired, 10)
+	if !ok || amount.Sign() <= 0 {
+		return "", fmt.Errorf("invalid amount %q", r.MaxAmountRequired)
+	}
+	return fmt.Sprintf("ethereum:pay-%s@%d/transfer?address=%s&uint256=%s",
+		r.Asset.Hex(), chainID, common.HexToAddress(payTo).Hex(), amount), nil
+}
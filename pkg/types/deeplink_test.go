@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestWalletLink(t *testing.T) {
+	usdc := common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e")
+	requirements := func(network Network, payTo, amount string) *PaymentRequirements {
+		return &PaymentRequirements{Network: network, PayTo: payTo, MaxAmountRequired: amount, Asset: usdc}
+	}
+
+	// The recipient is checksummed whatever form it was given in
+	for _, payTo := range []string{
+		"0x00000000000000000000000000000000000000b0",
+		"0x00000000000000000000000000000000000000B0",
+		"xdc00000000000000000000000000000000000000b0",
+	} {
+		link, err := requirements(NetworkBaseSepolia, payTo, "10000").WalletLink()
+		if err != nil {
+			t.Fatalf("%s: %v", payTo, err)
+		}
+		want := "ethereum:pay-0x036CbD53842c5426634e7929541eC2318f3dCF7e@84532/transfer?address=0x00000000000000000000000000000000000000B0&uint256=10000"
+		if link != want {
+			t.Errorf("%s: link %s, want %s", payTo, link, want)
+		}
+	}
+
+	for name, r := range map[string]*PaymentRequirements{
+		"non-EVM network":  requirements(NetworkSolanaDevnet, "0x00000000000000000000000000000000000000b0", "10000"),
+		"unknown network":  requirements("no-such-chain", "0x00000000000000000000000000000000000000b0", "10000"),
+		"invalid payTo":    requirements(NetworkBaseSepolia, "shop.eth", "10000"),
+		"zero amount":      requirements(NetworkBaseSepolia, "0x00000000000000000000000000000000000000b0", "0"),
+		"negative amount":  requirements(NetworkBaseSepolia, "0x00000000000000000000000000000000000000b0", "-1"),
+		"malformed amount": requirements(NetworkBaseSepolia, "0x00000000000000000000000000000000000000b0", "1e6"),
+	} {
+		if link, err := r.WalletLink(); err == nil {
+			t.Errorf("%s: link %s, want an error", name, link)
+		}
+	}
+}
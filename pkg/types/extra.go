@@ -0,0 +1,266 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// GetExtraField decodes one key of a requirements Extra object into out
+// Returns false if Extra is empty or the key is absent (well-known members
+// are easier read through ParseExtra)
+func GetExtraField(extra json.RawMessage, key string, out interface{}) (bool, error) {
+	if len(extra) == 0 || string(extra) == "null" {
+		return false, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(extra, &fields); err != nil {
+		return false, fmt.Errorf("invalid extra: %w", err)
+	}
+	raw, ok := fields[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return true, fmt.Errorf("invalid extra.%s: %w", key, err)
+	}
+	return true, nil
+}
+
+// SetExtraField returns a copy of Extra with key set to value, preserving
+// other keys; unlike MergeExtra it re-sorts them
+func SetExtraField(extra json.RawMessage, key string, value interface{}) (json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if len(extra) > 0 && string(extra) != "null" {
+		if err := json.Unmarshal(extra, &fields); err != nil {
+			return nil, fmt.Errorf("invalid extra: %w", err)
+		}
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	fields[key] = raw
+	return json.Marshal(fields)
+}
+
+// Extra keys of well-known members without a type of their own
+const (
+	AuthorizationTypeExtraKey = "authorizationType"
+	FeeBreakdownExtraKey      = "feeBreakdown"
+	SplitsExtraKey            = "splits"
+)
+
+// RequirementsExtra is the extra object of payment requirements, and of
+// payloads echoing parts of it. Well-known members decode into the typed
+// fields; any other key is kept verbatim in Unknown.
+//
+// A parsed document marshals back byte for byte while it is unmodified, so
+// signatures and hashes over it still hold. Once modified, untouched members
+// keep their original bytes and order, and new members follow them.
+type RequirementsExtra struct {
+	AuthorizationType  string            // Token authorization the payment is signed as, e.g. "eip3009"
+	FeeBreakdown       *FeeBreakdown     // Price and facilitator fee within the amount
+	Splits             []PayoutSplit     // Payout splits (see ParseSplits)
+	Challenge          *PaymentChallenge // Server challenge (see ParseChallenge)
+	Quote              *PaymentQuote     // Price quote (see ParseQuote)
+	ResourceBinding    *ResourceBinding  // Payload only (see ParseResourceBinding)
+	RequirementsSigner string            // Address that signed the requirements (see SignRequirements)
+	Permit2            *Permit2Extra     // permit2 spender (see ParsePermit2Extra)
+
+	// Unknown holds the other members, keyed by name, as raw JSON
+	Unknown map[string]json.RawMessage
+
+	raw      json.RawMessage            // Document as parsed
+	order    []string                   // Its keys, in document order
+	original map[string]json.RawMessage // Its members as parsed
+	decoded  map[string]string          // Encoding of each well-known member right after parsing
+}
+
+// extraMember is a well-known member of RequirementsExtra
+type extraMember struct {
+	key   string
+	value interface{} // Pointer to the field
+}
+
+// members lists the well-known members with pointers to their fields
+func (e *RequirementsExtra) members() []extraMember {
+	return []extraMember{
+		{AuthorizationTypeExtraKey, &e.AuthorizationType},
+		{FeeBreakdownExtraKey, &e.FeeBreakdown},
+		{SplitsExtraKey, &e.Splits},
+		{ChallengeExtraKey, &e.Challenge},
+		{QuoteExtraKey, &e.Quote},
+		{ResourceBindingExtraKey, &e.ResourceBinding},
+		{RequirementsSignerExtraKey, &e.RequirementsSigner},
+		{Permit2ExtraKey, &e.Permit2},
+	}
+}
+
+// ParseExtra decodes an extra field; empty or null extra gives an empty
+// RequirementsExtra. A malformed well-known member is an error.
+func ParseExtra(extra json.RawMessage) (*RequirementsExtra, error) {
+	var parsed RequirementsExtra
+	if len(bytes.TrimSpace(extra)) == 0 {
+		return &parsed, nil
+	}
+	if err := json.Unmarshal(extra, &parsed); err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// MergeExtra returns extra with the set members of update (non-zero fields
+// and Unknown entries) written over it; other members are preserved
+func MergeExtra(extra json.RawMessage, update RequirementsExtra) (json.RawMessage, error) {
+	merged, err := ParseExtra(extra)
+	if err != nil {
+		return nil, err
+	}
+	dst, src := merged.members(), update.members()
+	for i := range src {
+		if value := reflect.ValueOf(src[i].value).Elem(); !value.IsZero() {
+			reflect.ValueOf(dst[i].value).Elem().Set(value)
+		}
+	}
+	for key, value := range update.Unknown {
+		if merged.Unknown == nil {
+			merged.Unknown = make(map[string]json.RawMessage)
+		}
+		merged.Unknown[key] = value
+	}
+	// Not json.Marshal, which would compact and HTML-escape the original bytes
+	return merged.MarshalJSON()
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (e *RequirementsExtra) UnmarshalJSON(data []byte) error {
+	*e = RequirementsExtra{raw: append(json.RawMessage(nil), data...)}
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+
+	// Walk the object to keep the key order
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("invalid extra: must be a JSON object")
+	}
+	e.original = make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("invalid extra: %w", err)
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return fmt.Errorf("invalid extra.%s: %w", key, err)
+		}
+		if _, seen := e.original[key]; !seen {
+			e.order = append(e.order, key)
+		}
+		e.original[key] = value
+	}
+
+	known := make(map[string]bool)
+	e.decoded = make(map[string]string)
+	for _, member := range e.members() {
+		known[member.key] = true
+		if value, ok := e.original[member.key]; ok {
+			if err := json.Unmarshal(value, member.value); err != nil {
+				return fmt.Errorf("invalid extra.%s: %w", member.key, err)
+			}
+			encoded, _ := json.Marshal(member.value)
+			e.decoded[member.key] = string(encoded)
+		}
+	}
+	for _, key := range e.order {
+		if !known[key] {
+			if e.Unknown == nil {
+				e.Unknown = make(map[string]json.RawMessage)
+			}
+			e.Unknown[key] = e.original[key]
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (e RequirementsExtra) MarshalJSON() ([]byte, error) {
+	members := make(map[string]extraMember)
+	for _, member := range e.members() {
+		members[member.key] = member
+	}
+
+	changed := false
+	var buf bytes.Buffer
+	write := func(key string, value []byte) {
+		if buf.Len() > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	// Members of the parsed document, in its order
+	seen := make(map[string]bool, len(e.order))
+	for _, key := range e.order {
+		seen[key] = true
+		member, ok := members[key]
+		if !ok {
+			value, present := e.Unknown[key]
+			if !present {
+				changed = true
+				continue
+			}
+			changed = changed || !bytes.Equal(value, e.original[key])
+			write(key, value)
+			continue
+		}
+		encoded, err := json.Marshal(member.value)
+		if err != nil {
+			return nil, err
+		}
+		if string(encoded) == e.decoded[key] {
+			write(key, e.original[key])
+			continue
+		}
+		changed = true
+		if !reflect.ValueOf(member.value).Elem().IsZero() {
+			write(key, encoded)
+		}
+	}
+
+	// Members added since
+	for _, member := range e.members() {
+		if seen[member.key] || reflect.ValueOf(member.value).Elem().IsZero() {
+			continue
+		}
+		encoded, err := json.Marshal(member.value)
+		if err != nil {
+			return nil, err
+		}
+		changed = true
+		write(member.key, encoded)
+	}
+	added := make([]string, 0, len(e.Unknown))
+	for key := range e.Unknown {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		changed = true
+		write(key, e.Unknown[key])
+	}
+
+	if !changed && len(e.raw) > 0 {
+		return append([]byte(nil), e.raw...), nil
+	}
+	return append(append([]byte{'{'}, buf.Bytes()...), '}'), nil
+}
@@ -0,0 +1,139 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestRequirementsExtraRoundTripsByteForByte(t *testing.T) {
+	for _, doc := range []string{
+		`{"name":"USD Coin","version":"2"}`,
+		// Whitespace, key order, escapes and number forms are all kept
+		`{ "zeta": 1.50, "alpha" : "<tag>&amp;", "feeBreakdown": {"total":"10100","resourceAmount":"10000","facilitatorFee":"100"},
+		   "nested": {"b": [1, 2e3], "a": null} }`,
+		`{"splits":[{"to":"0x00000000000000000000000000000000000000b1","bps":5000}],"unicode":"été"}`,
+		`{}`,
+		`null`,
+	} {
+		parsed, err := ParseExtra(json.RawMessage(doc))
+		if err != nil {
+			t.Fatalf("%s: %v", doc, err)
+		}
+		data, err := parsed.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != doc {
+			t.Errorf("re-serialized %s as %s", doc, data)
+		}
+		// Inside a larger document it encodes as the raw extra would
+		embedded, _ := json.Marshal(parsed)
+		if raw, _ := json.Marshal(json.RawMessage(doc)); string(embedded) != string(raw) {
+			t.Errorf("json.Marshal gave %s, want %s as for the raw extra", embedded, raw)
+		}
+	}
+}
+
+func TestParseExtraDecodesKnownMembers(t *testing.T) {
+	doc := `{
+		"authorizationType": "eip3009",
+		"feeBreakdown": {"resourceAmount": "10000", "facilitatorFee": "100", "total": "10100"},
+		"splits": [{"to": "0x00000000000000000000000000000000000000b1", "bps": 7000}, {"to": "0x00000000000000000000000000000000000000b2", "bps": 3000}],
+		"challenge": {"nonce": "challenge-1", "resource": "shop.test/item"},
+		"quote": {"id": "q-1", "expiresAt": "2026-01-01T00:00:00Z"},
+		"resourceBinding": {"salt": "0x01", "resource": "https://shop.test/item"},
+		"requirementsSigner": "0x00000000000000000000000000000000000000a1",
+		"permit2": {"spender": "0x00000000000000000000000000000000000000aa"},
+		"name": "USD Coin"
+	}`
+	extra, err := ParseExtra(json.RawMessage(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RequirementsExtra{
+		AuthorizationType: "eip3009",
+		FeeBreakdown:      &FeeBreakdown{ResourceAmount: "10000", FacilitatorFee: "100", Total: "10100"},
+		Splits: []PayoutSplit{
+			{To: common.HexToAddress("0x00000000000000000000000000000000000000b1"), Bps: 7000},
+			{To: common.HexToAddress("0x00000000000000000000000000000000000000b2"), Bps: 3000},
+		},
+		Challenge:          &PaymentChallenge{Nonce: "challenge-1", Resource: "shop.test/item"},
+		Quote:              &PaymentQuote{ID: "q-1", ExpiresAt: "2026-01-01T00:00:00Z"},
+		ResourceBinding:    &ResourceBinding{Salt: "0x01", Resource: "https://shop.test/item"},
+		RequirementsSigner: "0x00000000000000000000000000000000000000a1",
+		Permit2:            &Permit2Extra{Spender: common.HexToAddress("0x00000000000000000000000000000000000000aa")},
+		Unknown:            map[string]json.RawMessage{"name": json.RawMessage(`"USD Coin"`)},
+	}
+	got, wantMembers := extra.members(), want.members()
+	for i := range got {
+		if !reflect.DeepEqual(got[i].value, wantMembers[i].value) {
+			t.Errorf("%s: parsed %+v, want %+v", got[i].key, reflect.ValueOf(got[i].value).Elem(), reflect.ValueOf(wantMembers[i].value).Elem())
+		}
+	}
+	if !reflect.DeepEqual(extra.Unknown, want.Unknown) {
+		t.Errorf("unknown members %s, want only the token name", extra.Unknown)
+	}
+
+	// Each member is read back by its own accessor too
+	if splits, err := ParseSplits(json.RawMessage(doc)); err != nil || !reflect.DeepEqual(splits, want.Splits) {
+		t.Errorf("ParseSplits: %+v (%v)", splits, err)
+	}
+	if permit2, err := ParsePermit2Extra(json.RawMessage(doc)); err != nil || *permit2 != *want.Permit2 {
+		t.Errorf("ParsePermit2Extra: %+v (%v)", permit2, err)
+	}
+
+	for name, malformed := range map[string]string{
+		"not an object":      `["splits"]`,
+		"malformed member":   `{"feeBreakdown":"10100"}`,
+		"malformed splits":   `{"splits":{"to":"0x01"}}`,
+		"truncated document": `{"name":"USD Coin"`,
+	} {
+		if _, err := ParseExtra(json.RawMessage(malformed)); err == nil {
+			t.Errorf("%s: parsed %s", name, malformed)
+		}
+	}
+	if extra, err := ParseExtra(nil); err != nil || extra.AuthorizationType != "" || extra.Unknown != nil {
+		t.Errorf("no extra: parsed %+v (%v), want it empty", extra, err)
+	}
+}
+
+func TestMergeExtraKeepsUntouchedMembers(t *testing.T) {
+	const doc = `{"name":"USD Coin", "version":"2", "challenge":{"nonce":"old","resource":"shop.test/a"}}`
+	challenge := &PaymentChallenge{Nonce: "new", Resource: "shop.test/a"}
+	merged, err := MergeExtra(json.RawMessage(doc), RequirementsExtra{
+		Challenge: challenge,
+		Quote:     &PaymentQuote{ID: "q-1", ExpiresAt: "2026-01-01T00:00:00Z"},
+		Unknown:   map[string]json.RawMessage{"zeta": json.RawMessage(`true`), "alpha": json.RawMessage(`1`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Original members keep their bytes and place; new ones follow, known
+	// members first and unknown ones sorted
+	const want = `{"name":"USD Coin","version":"2","challenge":{"nonce":"new","resource":"shop.test/a"},"quote":{"id":"q-1","expiresAt":"2026-01-01T00:00:00Z"},"alpha":1,"zeta":true}`
+	if string(merged) != want {
+		t.Errorf("merged\n%s\nwant\n%s", merged, want)
+	}
+
+	// Unset fields of the update leave members alone
+	if unchanged, err := MergeExtra(json.RawMessage(doc), RequirementsExtra{}); err != nil || string(unchanged) != doc {
+		t.Errorf("empty update gave %s (%v), want the document unchanged", unchanged, err)
+	}
+	if _, err := MergeExtra(json.RawMessage(`{"challenge":7}`), RequirementsExtra{Challenge: challenge}); err == nil {
+		t.Error("merged into a document with a malformed member")
+	}
+
+	// Clearing a parsed member drops it; removing an unknown key drops that
+	extra, err := ParseExtra(json.RawMessage(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra.Challenge = nil
+	delete(extra.Unknown, "version")
+	if data, _ := json.Marshal(extra); string(data) != `{"name":"USD Coin"}` {
+		t.Errorf("after removing members: %s", data)
+	}
+}
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// FeePolicy is a facilitator surcharge added on top of the resource price
+//
+// The fee is FlatAmount plus BasisPoints of the resource amount, both in the
+// token's smallest unit. The bps part is rounded up so the facilitator never
+// recovers less than configured.
+type FeePolicy struct {
+	FlatAmount  *big.Int
+	BasisPoints int64
+}
+
+// Fee returns the surcharge for a resource amount
+func (p FeePolicy) Fee(resourceAmount *big.Int) *big.Int {
+	fee := new(big.Int)
+	if p.FlatAmount != nil {
+		fee.Add(fee, p.FlatAmount)
+	}
+	if p.BasisPoints > 0 {
+		// ceil(amount * bps / 10000)
+		bps := new(big.Int).Mul(resourceAmount, big.NewInt(p.BasisPoints))
+		bps.Add(bps, big.NewInt(9999))
+		bps.Quo(bps, big.NewInt(10000))
+		fee.Add(fee, bps)
+	}
+	return fee
+}
+
+// Breakdown computes the fee breakdown for a resource amount
+func (p FeePolicy) Breakdown(resourceAmount *big.Int) FeeBreakdown {
+	fee := p.Fee(resourceAmount)
+	return FeeBreakdown{
+		ResourceAmount: resourceAmount.String(),
+		FacilitatorFee: fee.String(),
+		Total:          new(big.Int).Add(resourceAmount, fee).String(),
+	}
+}
+
+// Advertise returns the wire form of the policy for /supported
+func (p FeePolicy) Advertise() *FacilitatorFee {
+	flat := "0"
+	if p.FlatAmount != nil {
+		flat = p.FlatAmount.String()
+	}
+	return &FacilitatorFee{
+		FlatAmount:  flat,
+		BasisPoints: p.BasisPoints,
+	}
+}
+
+// ApplyFee rewrites requirements so MaxAmountRequired includes the fee and
+// Extra carries the breakdown (MaxAmountRequired is taken as the resource price)
+func (p FeePolicy) ApplyFee(requirements *PaymentRequirements) error {
+	resourceAmount, ok := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount: %s", requirements.MaxAmountRequired)
+	}
+	breakdown := p.Breakdown(resourceAmount)
+
+	extra, err := MergeExtra(requirements.Extra, RequirementsExtra{FeeBreakdown: &breakdown})
+	if err != nil {
+		return err
+	}
+	requirements.Extra = extra
+	requirements.MaxAmountRequired = breakdown.Total
+	return nil
+}
+
+// ParseFeePolicies parses "network:flat:bps" entries separated by commas
+// (e.g. "base:100:0,base-sepolia:0:25")
+func ParseFeePolicies(spec string) (map[Network]FeePolicy, error) {
+	policies := make(map[Network]FeePolicy)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid fee policy %q (want network:flat:bps)", entry)
+		}
+		flat, ok := new(big.Int).SetString(parts[1], 10)
+		if !ok || flat.Sign() < 0 {
+			return nil, fmt.Errorf("invalid flat fee in %q", entry)
+		}
+		bps, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil || bps < 0 || bps > 10000 {
+			return nil, fmt.Errorf("invalid basis points in %q", entry)
+		}
+		policies[Network(parts[0])] = FeePolicy{FlatAmount: flat, BasisPoints: bps}
+	}
+	return policies, nil
+}
+
+// FacilitatorFee returns the facilitator fee within the required amount, from
+// the fee breakdown ApplyFee put in Extra ("" without one)
+func (r *PaymentRequirements) FacilitatorFee() string {
+	if extra, err := ParseExtra(r.Extra); err == nil && extra.FeeBreakdown != nil {
+		return extra.FeeBreakdown.FacilitatorFee
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFeePolicyFee(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy FeePolicy
+		amount int64
+		want   int64
+	}{
+		{"flat", FeePolicy{FlatAmount: big.NewInt(100)}, 10_000, 100},
+		{"bps of 0.01 USDC", FeePolicy{BasisPoints: 25}, 10_000, 25},
+		{"bps rounds a fraction of a unit up", FeePolicy{BasisPoints: 25}, 333, 1}, // 0.8325 units
+		{"bps of one unit", FeePolicy{BasisPoints: 1}, 1, 1},                       // 0.0001 units
+		{"bps of 1 USDC", FeePolicy{BasisPoints: 30}, 1_000_000, 3000},             // 0.003 USDC exactly
+		{"bps just past a unit", FeePolicy{BasisPoints: 30}, 1_000_001, 3001},      // 3000.003 units
+		{"flat plus bps", FeePolicy{FlatAmount: big.NewInt(100), BasisPoints: 100}, 10_000, 200},
+		{"no fee", FeePolicy{}, 10_000, 0},
+	} {
+		if got := tc.policy.Fee(big.NewInt(tc.amount)); got.Cmp(big.NewInt(tc.want)) != 0 {
+			t.Errorf("%s: fee on %d = %s, want %d", tc.name, tc.amount, got, tc.want)
+		}
+	}
+}
+
+func TestFeePolicyApplyFee(t *testing.T) {
+	requirements := PaymentRequirements{Network: "base-sepolia", MaxAmountRequired: "10000"}
+	if err := (FeePolicy{FlatAmount: big.NewInt(50), BasisPoints: 25}).ApplyFee(&requirements); err != nil {
+		t.Fatal(err)
+	}
+	if requirements.MaxAmountRequired != "10075" {
+		t.Errorf("MaxAmountRequired = %s, want the price plus 75", requirements.MaxAmountRequired)
+	}
+	extra, err := ParseExtra(requirements.Extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := extra.FeeBreakdown; b == nil || b.ResourceAmount != "10000" || b.FacilitatorFee != "75" || b.Total != "10075" {
+		t.Errorf("fee breakdown %+v", extra.FeeBreakdown)
+	}
+
+	if err := (FeePolicy{BasisPoints: 25}).ApplyFee(&PaymentRequirements{MaxAmountRequired: "0.01"}); err == nil {
+		t.Error("ApplyFee accepted a decimal amount")
+	}
+}
+
+func TestParseFeePolicies(t *testing.T) {
+	policies, err := ParseFeePolicies("base:100:0, base-sepolia:0:25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := policies["base"]; p.FlatAmount.Int64() != 100 || p.BasisPoints != 0 {
+		t.Errorf("base policy %+v", p)
+	}
+	if p := policies["base-sepolia"]; p.FlatAmount.Int64() != 0 || p.BasisPoints != 25 {
+		t.Errorf("base-sepolia policy %+v", p)
+	}
+	for _, spec := range []string{"base:100", "base:-1:0", "base:0:10001", "base:x:0"} {
+		if _, err := ParseFeePolicies(spec); err == nil {
+			t.Errorf("ParseFeePolicies(%q) accepted an invalid policy", spec)
+		}
+	}
+}
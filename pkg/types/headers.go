@@ -0,0 +1,117 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HTTP header names used by x402 implementations
+const (
+	// HeaderXPayment is the payment header named by the x402 specification
+	HeaderXPayment = "X-PAYMENT"
+	// HeaderPaymentPayload is the payment header of earlier x402-go releases
+	HeaderPaymentPayload = "X-Payment-Payload"
+	// HeaderPayment is the bare payment header some stacks send
+	HeaderPayment = "Payment"
+
+	// HeaderPaymentRequired carries the requirements in a 402
+	HeaderPaymentRequired = "X-Payment-Required"
+
+	// QueryPaymentParam carries a base64url payment payload in the URL,
+	// for servers accepting query payments (see server.WithQueryPayment)
+	QueryPaymentParam = "x402_payment"
+
+	// PaymentPageParam carries the token of an HTML payment page, which
+	// polls and reloads the resource with it (see server.WithPaymentPage)
+	PaymentPageParam = "x402_page"
+)
+
+// PaymentHeaderAliases lists every known name of the payment header, the
+// specification name first
+var PaymentHeaderAliases = []string{HeaderXPayment, HeaderPaymentPayload, HeaderPayment}
+
+// PaymentHeaderValue returns the value of the first of names set on h, and
+// the name it was found under
+func PaymentHeaderValue(h http.Header, names []string) (value, name string) {
+	for _, name := range names {
+		if value := h.Get(name); value != "" {
+			return value, name
+		}
+	}
+	return "", ""
+}
+
+// DefaultPaymentHeaderLimit is the largest accepted payment header value in
+// bytes, far above any real payload
+const DefaultPaymentHeaderLimit = 16 << 10
+
+// PaymentHeaderError is a payment header rejected before it is parsed
+type PaymentHeaderError struct {
+	Code    ReasonCode // ReasonDuplicatePayment or ReasonPaymentTooLarge
+	Message string
+}
+
+func (e *PaymentHeaderError) Error() string {
+	return e.Message
+}
+
+// CheckPaymentHeaders rejects a request carrying more than one payment value
+// across names (a header repeated, or sent under two aliases), or a value
+// above limit bytes (no limit if limit <= 0)
+// Get only returns the first value, so a second one could be verified by
+// one hop and forwarded by another; such requests are ambiguous and refused.
+func CheckPaymentHeaders(h http.Header, names []string, limit int) error {
+	var found []string
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		key := http.CanonicalHeaderKey(name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		for _, value := range h[key] {
+			if limit > 0 && len(value) > limit {
+				return &PaymentHeaderError{
+					Code:    ReasonPaymentTooLarge,
+					Message: fmt.Sprintf("%s header is %d bytes, above the %d byte limit", name, len(value), limit),
+				}
+			}
+			found = append(found, name)
+		}
+	}
+	if len(found) > 1 {
+		return &PaymentHeaderError{
+			Code:    ReasonDuplicatePayment,
+			Message: fmt.Sprintf("request carries %d payment header values (%s); send exactly one", len(found), strings.Join(found, ", ")),
+		}
+	}
+	return nil
+}
+
+// AppendQueryPayment returns rawURL with payloadJSON, base64url-encoded,
+// in the QueryPaymentParam query parameter
+func AppendQueryPayment(rawURL string, payloadJSON []byte) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	param := QueryPaymentParam + "=" + base64.RawURLEncoding.EncodeToString(payloadJSON)
+	if u.RawQuery == "" {
+		u.RawQuery = param
+	} else {
+		u.RawQuery += "&" + param
+	}
+	return u.String(), nil
+}
+
+// DecodeQueryPayment decodes a QueryPaymentParam value (padding optional)
+func DecodeQueryPayment(value string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not base64url: %w", QueryPaymentParam, err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPaymentHeaderValueTriesNamesInOrder(t *testing.T) {
+	h := http.Header{}
+	h.Set(HeaderPayment, "bare")
+	h.Set(HeaderPaymentPayload, "legacy")
+	if value, name := PaymentHeaderValue(h, PaymentHeaderAliases); value != "legacy" || name != HeaderPaymentPayload {
+		t.Errorf("PaymentHeaderValue = %q from %q, want the earlier alias", value, name)
+	}
+	h.Set("x-payment", "spec")
+	if value, name := PaymentHeaderValue(h, PaymentHeaderAliases); value != "spec" || name != HeaderXPayment {
+		t.Errorf("PaymentHeaderValue = %q from %q, want the specification name first", value, name)
+	}
+	if value, name := PaymentHeaderValue(h, []string{"X-Other"}); value != "" || name != "" {
+		t.Errorf("PaymentHeaderValue = %q from %q for an unset name", value, name)
+	}
+}
+
+// headerOf builds a header from name, value pairs, as a client adding
+// each line would
+func headerOf(pairs ...string) http.Header {
+	h := http.Header{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		h.Add(pairs[i], pairs[i+1])
+	}
+	return h
+}
+
+func TestCheckPaymentHeaders(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		limit  int
+		code   ReasonCode // Empty when the headers are accepted
+	}{
+		{"none", http.Header{}, 16, ""},
+		{"one", headerOf(HeaderXPayment, "payload"), 16, ""},
+		{"at the limit", headerOf(HeaderXPayment, "0123456789abcdef"), 16, ""},
+		{"above the limit", headerOf(HeaderXPayment, "0123456789abcdefg"), 16, ReasonPaymentTooLarge},
+		{"no limit", headerOf(HeaderXPayment, string(make([]byte, 1<<20))), 0, ""},
+		{"repeated", headerOf(HeaderXPayment, "first", HeaderXPayment, "second"), 16, ReasonDuplicatePayment},
+		{"two aliases", headerOf(HeaderXPayment, "first", HeaderPaymentPayload, "second"), 16, ReasonDuplicatePayment},
+		{"repeat oversized", headerOf(HeaderPayment, "small", HeaderPayment, "0123456789abcdefg"), 16, ReasonPaymentTooLarge},
+	} {
+		err := CheckPaymentHeaders(tc.header, PaymentHeaderAliases, tc.limit)
+		if tc.code == "" {
+			if err != nil {
+				t.Errorf("%s: %v, want it accepted", tc.name, err)
+			}
+			continue
+		}
+		headerErr, ok := err.(*PaymentHeaderError)
+		if !ok || headerErr.Code != tc.code {
+			t.Errorf("%s: %v, want a %s error", tc.name, err, tc.code)
+		}
+	}
+
+	// A name listed twice, or in another case, is only counted once
+	h := headerOf(HeaderXPayment, "payload")
+	if err := CheckPaymentHeaders(h, []string{"x-payment", HeaderXPayment}, 0); err != nil {
+		t.Errorf("one value under a name listed twice: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package types
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaxHexBytes bounds a decoded HexBytes value; it leaves room for ERC-6492
+// wrapped smart-wallet signatures, which are far longer than 65 bytes
+const MaxHexBytes = 16 * 1024
+
+// HexBytes is a byte string carried in JSON as 0x-prefixed hex, such as a
+// payment signature or authorization nonce. Decoding follows SetStrictHex.
+type HexBytes []byte
+
+var strictHex atomic.Bool
+
+// SetStrictHex rejects hex without the 0x prefix (or with 0X), and with
+// surrounding whitespace, when decoding HexBytes. Lenient decoding, the
+// default, accepts those legacy forms but logs a deprecation warning; odd,
+// non-hex and over-long values are rejected either way.
+func SetStrictHex(strict bool) {
+	strictHex.Store(strict)
+}
+
+// StrictHex reports whether HexBytes decoding is strict (see SetStrictHex)
+func StrictHex() bool {
+	return strictHex.Load()
+}
+
+// HexError is a value that is not acceptable hex
+type HexError struct {
+	Value  string // Possibly truncated
+	Reason string
+}
+
+func (e *HexError) Error() string {
+	return fmt.Sprintf("invalid hex %q: %s", e.Value, e.Reason)
+}
+
+// ParseHex decodes s, strictly or not (see SetStrictHex), and reports
+// whether it was in a legacy form that had to be normalized
+func ParseHex(s string, strict bool) (b HexBytes, legacy bool, err error) {
+	digits := s
+	if trimmed := strings.TrimSpace(digits); trimmed != digits {
+		if strict {
+			return nil, false, hexError(s, "surrounding whitespace")
+		}
+		digits, legacy = trimmed, true
+	}
+	switch {
+	case strings.HasPrefix(digits, "0x"):
+		digits = digits[2:]
+	case strict:
+		return nil, false, hexError(s, "missing 0x prefix")
+	case strings.HasPrefix(digits, "0X"):
+		digits, legacy = digits[2:], true
+	default:
+		legacy = true
+	}
+	if len(digits)%2 != 0 {
+		return nil, false, hexError(s, "odd number of hex digits")
+	}
+	if len(digits)/2 > MaxHexBytes {
+		return nil, false, hexError(s, fmt.Sprintf("longer than %d bytes", MaxHexBytes))
+	}
+	b = make(HexBytes, len(digits)/2)
+	if _, err := hex.Decode(b, []byte(digits)); err != nil {
+		return nil, false, hexError(s, "not hex")
+	}
+	return b, legacy, nil
+}
+
+// DecodeHex decodes s in the current mode (see SetStrictHex)
+func DecodeHex(s string) (HexBytes, error) {
+	b, legacy, err := ParseHex(s, StrictHex())
+	if err != nil {
+		return nil, err
+	}
+	if legacy {
+		legacyHex.note(s)
+	}
+	return b, nil
+}
+
+func hexError(s, reason string) *HexError {
+	if len(s) > 80 {
+		s = s[:80] + "..."
+	}
+	return &HexError{Value: s, Reason: reason}
+}
+
+// String returns the canonical form: 0x and lowercase hex digits
+func (b HexBytes) String() string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// MarshalJSON encodes b in its canonical form
+func (b HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// UnmarshalJSON decodes a hex string (see SetStrictHex); null leaves b nil
+func (b *HexBytes) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*b = nil
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := DecodeHex(s)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// Nonce returns b as an authorization nonce, which must be 32 bytes
+func (b HexBytes) Nonce() (Nonce, error) {
+	var nonce Nonce
+	if len(b) != len(nonce) {
+		return nonce, fmt.Errorf("invalid nonce %s: want 32 bytes, got %d", b, len(b))
+	}
+	copy(nonce[:], b)
+	return nonce, nil
+}
+
+// legacyHexLogInterval spaces the deprecation warnings of lenient decoding
+const legacyHexLogInterval = time.Minute
+
+// legacyHex counts the values lenient decoding normalized, logging a
+// warning at most once per legacyHexLogInterval
+var legacyHex legacyHexLog
+
+type legacyHexLog struct {
+	mu         sync.Mutex
+	suppressed int
+	lastLogged time.Time
+}
+
+func (l *legacyHexLog) note(s string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if time.Since(l.lastLogged) < legacyHexLogInterval {
+		l.suppressed++
+		return
+	}
+	more := ""
+	if l.suppressed > 0 {
+		more = fmt.Sprintf(" (and %d more since the last warning)", l.suppressed)
+	}
+	log.Printf("Deprecated: hex value %q is not in 0x-prefixed form; it was accepted, but STRICT_HEX=true rejects it%s", hexError(s, "").Value, more)
+	l.suppressed, l.lastLogged = 0, time.Now()
+}
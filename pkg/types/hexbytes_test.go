@@ -0,0 +1,127 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withStrictHex sets the hex mode for the test
+func withStrictHex(t *testing.T, strict bool) {
+	t.Helper()
+	was := StrictHex()
+	SetStrictHex(strict)
+	t.Cleanup(func() { SetStrictHex(was) })
+}
+
+func TestParseHex(t *testing.T) {
+	for _, tc := range []struct {
+		name, value string
+		want        string // Canonical form; "" when refused
+		legacy      bool   // Accepted only leniently
+		reason      string // Of the refusal, in strict mode if legacy
+	}{
+		{"canonical", "0xabcdef01", "0xabcdef01", false, ""},
+		{"uppercase digits", "0xABCDEF01", "0xabcdef01", false, ""},
+		{"empty", "0x", "0x", false, ""},
+		{"no prefix", "abcdef01", "0xabcdef01", true, "missing 0x prefix"},
+		{"uppercase prefix", "0XABCDEF01", "0xabcdef01", true, "missing 0x prefix"},
+		{"surrounding whitespace", " 0xabcdef01\n", "0xabcdef01", true, "surrounding whitespace"},
+		{"whitespace and no prefix", "\tabcdef01 ", "0xabcdef01", true, "surrounding whitespace"},
+		{"odd length", "0xabcdef0", "", false, "odd number of hex digits"},
+		{"not hex", "0xabcdefgh", "", false, "not hex"},
+		{"inner whitespace", "0xabcd ef01", "", false, "odd number of hex digits"},
+		{"double prefix", "0x0xabcdef", "", false, "not hex"},
+		{"too long", "0x" + strings.Repeat("00", MaxHexBytes+1), "", false, "longer than"},
+		{"at the limit", "0x" + strings.Repeat("00", MaxHexBytes), "0x" + strings.Repeat("00", MaxHexBytes), false, ""},
+	} {
+		for _, strict := range []bool{false, true} {
+			b, legacy, err := ParseHex(tc.value, strict)
+			refused := tc.want == "" || (strict && tc.legacy)
+			if refused {
+				hexErr, ok := err.(*HexError)
+				if !ok || !strings.Contains(hexErr.Reason, tc.reason) {
+					t.Errorf("%s (strict %v): error %v, want one for %q", tc.name, strict, err, tc.reason)
+				}
+				continue
+			}
+			if err != nil || b.String() != tc.want || legacy != tc.legacy {
+				t.Errorf("%s (strict %v): %s, legacy %v, %v; want %s, legacy %v", tc.name, strict, b, legacy, err, tc.want, tc.legacy)
+			}
+		}
+	}
+}
+
+func TestHexBytesJSON(t *testing.T) {
+	var payload struct {
+		Signature HexBytes `json:"signature"`
+		Nonce     HexBytes `json:"nonce"`
+	}
+	nonce := strings.Repeat("11", 32)
+
+	withStrictHex(t, false)
+	if err := json.Unmarshal([]byte(`{"signature":"ABCDEF01","nonce":" 0x`+nonce+` "}`), &payload); err != nil {
+		t.Fatalf("lenient: %v", err)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"signature":"0xabcdef01","nonce":"0x` + nonce + `"}`; string(data) != want {
+		t.Errorf("re-encoded as %s, want %s", data, want)
+	}
+	if n, err := payload.Nonce.Nonce(); err != nil || n.String() != "0x"+nonce {
+		t.Errorf("nonce %s, %v", n, err)
+	}
+	if _, err := payload.Signature.Nonce(); err == nil {
+		t.Error("a 4-byte value made a nonce")
+	}
+
+	withStrictHex(t, true)
+	for _, body := range []string{`{"signature":"ABCDEF01"}`, `{"nonce":" 0x` + nonce + ` "}`, `{"signature":"0xabc"}`, `{"signature":42}`} {
+		if err := json.Unmarshal([]byte(body), &payload); err == nil {
+			t.Errorf("strict: %s accepted", body)
+		}
+	}
+	payload.Signature = HexBytes{1}
+	if err := json.Unmarshal([]byte(`{"signature":null}`), &payload); err != nil || payload.Signature != nil {
+		t.Errorf("null: %v, signature %v; want it cleared", err, payload.Signature)
+	}
+}
+
+func TestLenientHexWarnsAtMostOncePerInterval(t *testing.T) {
+	var buf bytes.Buffer
+	out, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+	})
+	legacyHex.mu.Lock()
+	legacyHex.suppressed, legacyHex.lastLogged = 0, time.Time{}
+	legacyHex.mu.Unlock()
+	withStrictHex(t, false)
+
+	for _, value := range []string{"abcdef01", "0Xabcdef01", " 0xabcdef01", "0xabcdef01"} {
+		if _, err := DecodeHex(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := strings.Count(buf.String(), "Deprecated"); n != 1 || !strings.Contains(buf.String(), `"abcdef01"`) {
+		t.Errorf("logged %q, want one warning naming the first value", buf.String())
+	}
+
+	// The next warning counts those suppressed meanwhile
+	legacyHex.mu.Lock()
+	legacyHex.lastLogged = time.Now().Add(-legacyHexLogInterval)
+	legacyHex.mu.Unlock()
+	buf.Reset()
+	DecodeHex("abcdef02")
+	if !strings.Contains(buf.String(), "and 2 more") {
+		t.Errorf("logged %q, want the 2 suppressed warnings counted", buf.String())
+	}
+}
@@ -0,0 +1,118 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// networkEntry is what the registry knows about a network
+type networkEntry struct {
+	chainID uint64 // 0 for non-EVM networks
+	evm     bool
+}
+
+// networks is the network registry, seeded with the built-in networks and
+// extended with RegisterNetwork
+var networks = struct {
+	sync.RWMutex
+	entries map[Network]networkEntry
+}{entries: map[Network]networkEntry{
+	NetworkBaseSepolia:     {chainID: 84532, evm: true},
+	NetworkBase:            {chainID: 8453, evm: true},
+	NetworkAvalancheFuji:   {chainID: 43113, evm: true},
+	NetworkAvalanche:       {chainID: 43114, evm: true},
+	NetworkPolygonAmoy:     {chainID: 80002, evm: true},
+	NetworkPolygon:         {chainID: 137, evm: true},
+	NetworkSei:             {chainID: 1329, evm: true},
+	NetworkSeiTestnet:      {chainID: 1328, evm: true},
+	NetworkXDC:             {chainID: 50, evm: true},
+	NetworkArbitrum:        {chainID: 42161, evm: true},
+	NetworkArbitrumSepolia: {chainID: 421614, evm: true},
+	NetworkOptimism:        {chainID: 10, evm: true},
+	NetworkOptimismSepolia: {chainID: 11155420, evm: true},
+	NetworkSolana:          {},
+	NetworkSolanaDevnet:    {},
+}}
+
+// RegisterNetwork adds a network to the registry, so IsEVM, ChainID and the
+// network package know it. Registering a network again with the same chain
+// ID is a no-op; changing a registered network's chain ID, or reusing
+// another EVM network's chain ID, is an error.
+func RegisterNetwork(name Network, chainID uint64, isEVM bool) error {
+	if name == "" {
+		return fmt.Errorf("network name is required")
+	}
+	if isEVM && chainID == 0 {
+		return fmt.Errorf("network %s: EVM networks need a chain ID", name)
+	}
+	if !isEVM {
+		chainID = 0
+	}
+
+	networks.Lock()
+	defer networks.Unlock()
+	if existing, ok := networks.entries[name]; ok {
+		if existing.chainID != chainID || existing.evm != isEVM {
+			return fmt.Errorf("network %s is already registered with chain ID %d", name, existing.chainID)
+		}
+		return nil
+	}
+	if isEVM {
+		for other, entry := range networks.entries {
+			if entry.evm && entry.chainID == chainID {
+				return fmt.Errorf("chain ID %d is already registered as %s", chainID, other)
+			}
+		}
+	}
+	networks.entries[name] = networkEntry{chainID: chainID, evm: isEVM}
+	return nil
+}
+
+// lookupNetwork returns the registry entry of n
+func lookupNetwork(n Network) (networkEntry, bool) {
+	networks.RLock()
+	defer networks.RUnlock()
+	entry, ok := networks.entries[n]
+	return entry, ok
+}
+
+// networkByChainID returns the registered EVM network with chainID
+func networkByChainID(chainID uint64) (Network, bool) {
+	networks.RLock()
+	defer networks.RUnlock()
+	for network, entry := range networks.entries {
+		if entry.evm && entry.chainID == chainID {
+			return network, true
+		}
+	}
+	return "", false
+}
+
+// IsRegistered returns true if the network is built in or registered
+func (n Network) IsRegistered() bool {
+	_, ok := lookupNetwork(n)
+	return ok
+}
+
+// ChainID returns the EVM chain ID of the network (false for unregistered
+// and non-EVM networks)
+func (n Network) ChainID() (uint64, bool) {
+	entry, ok := lookupNetwork(n)
+	if !ok || !entry.evm {
+		return 0, false
+	}
+	return entry.chainID, true
+}
+
+// RegisteredNetworks returns every registered network, sorted by name
+func RegisteredNetworks() []Network {
+	networks.RLock()
+	list := make([]Network, 0, len(networks.entries))
+	for n := range networks.entries {
+		list = append(list, n)
+	}
+	networks.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
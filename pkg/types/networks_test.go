@@ -0,0 +1,112 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestBuiltInNetworksAreRegistered(t *testing.T) {
+	for network, want := range map[Network]uint64{
+		NetworkBase:            8453,
+		NetworkArbitrum:        42161,
+		NetworkArbitrumSepolia: 421614,
+		NetworkOptimism:        10,
+		NetworkOptimismSepolia: 11155420,
+	} {
+		if chainID, ok := network.ChainID(); !ok || chainID != want || !network.IsEVM() {
+			t.Errorf("%s: chain ID %d (%v), EVM %v; want %d", network, chainID, ok, network.IsEVM(), want)
+		}
+	}
+	if _, ok := NetworkSolana.ChainID(); ok || NetworkSolana.IsEVM() || !NetworkSolana.IsRegistered() {
+		t.Error("solana is registered as an EVM network")
+	}
+}
+
+func TestCAIP2RoundTripsRegisteredNetworks(t *testing.T) {
+	custom := Network("caip2-test")
+	if err := RegisterNetwork(custom, 990202, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := custom.CAIP2(); got != "eip155:990202" {
+		t.Errorf("custom network: CAIP-2 %q, want eip155:990202", got)
+	}
+	if got := NetworkArbitrum.CAIP2(); got != "eip155:42161" {
+		t.Errorf("arbitrum: CAIP-2 %q, want eip155:42161", got)
+	}
+
+	for _, network := range RegisteredNetworks() {
+		id := network.CAIP2()
+		parsed, err := ParseNetworkID(id)
+		if err != nil || parsed != network {
+			t.Errorf("%s: CAIP-2 %q parses as %q (%v), want the network back", network, id, parsed, err)
+		}
+		if chainID, ok := network.ChainID(); ok && id != fmt.Sprintf("eip155:%d", chainID) {
+			t.Errorf("%s: CAIP-2 %q, want its chain ID %d", network, id, chainID)
+		}
+	}
+	if _, err := ParseNetworkID("eip155:990203"); err == nil {
+		t.Error("an unregistered chain ID parsed")
+	}
+}
+
+func TestRegisterNetwork(t *testing.T) {
+	custom := Network("registry-test")
+	if custom.IsEVM() || custom.IsRegistered() {
+		t.Fatal("unregistered network reported as known")
+	}
+	if err := RegisterNetwork(custom, 990101, true); err != nil {
+		t.Fatal(err)
+	}
+	if chainID, ok := custom.ChainID(); !ok || chainID != 990101 || !custom.IsEVM() {
+		t.Errorf("registered network: chain ID %d (%v), EVM %v", chainID, ok, custom.IsEVM())
+	}
+	found := false
+	for _, network := range RegisteredNetworks() {
+		found = found || network == custom
+	}
+	if !found {
+		t.Error("registered network missing from RegisteredNetworks")
+	}
+
+	// Registering the same network again is harmless
+	if err := RegisterNetwork(custom, 990101, true); err != nil {
+		t.Errorf("re-registration: %v", err)
+	}
+	for name, register := range map[string]func() error{
+		"changed chain ID":     func() error { return RegisterNetwork(custom, 990102, true) },
+		"Base's chain ID":      func() error { return RegisterNetwork("base-copy", 8453, true) },
+		"EVM without chain ID": func() error { return RegisterNetwork("registry-test-zero", 0, true) },
+		"no name":              func() error { return RegisterNetwork("", 990103, true) },
+		"changed to non-EVM":   func() error { return RegisterNetwork(custom, 0, false) },
+	} {
+		if err := register(); err == nil {
+			t.Errorf("%s: registered", name)
+		}
+	}
+
+	nonEVM := Network("registry-test-ledger")
+	if err := RegisterNetwork(nonEVM, 990104, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := nonEVM.ChainID(); ok || nonEVM.IsEVM() || !nonEVM.IsRegistered() {
+		t.Error("non-EVM network got a chain ID")
+	}
+}
+
+func TestRegisterNetworkConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			network := Network("registry-test-concurrent")
+			if err := RegisterNetwork(network, 990200, true); err != nil {
+				t.Error(err)
+			}
+			network.IsEVM()
+			RegisteredNetworks()
+		}()
+	}
+	wg.Wait()
+}
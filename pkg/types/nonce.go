@@ -0,0 +1,55 @@
+package types
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Nonce is an ERC-3009 authorization nonce (bytes32)
+type Nonce [32]byte
+
+// ParseNonce parses a 0x-prefixed (or bare) hex nonce of exactly 32 bytes,
+// in either case; short, long and non-hex values are rejected rather than
+// padded or truncated
+func ParseNonce(s string) (Nonce, error) {
+	var nonce Nonce
+	digits := s
+	if len(digits) >= 2 && (digits[:2] == "0x" || digits[:2] == "0X") {
+		digits = digits[2:]
+	}
+	if len(digits) != 2*len(nonce) {
+		return nonce, fmt.Errorf("invalid nonce %q: want 32 bytes (64 hex digits), got %d digits", s, len(digits))
+	}
+	if _, err := hex.Decode(nonce[:], []byte(digits)); err != nil {
+		return nonce, fmt.Errorf("invalid nonce %q: %w", s, err)
+	}
+	return nonce, nil
+}
+
+// String returns the canonical form: 0x and 64 lowercase hex digits
+func (n Nonce) String() string {
+	return "0x" + hex.EncodeToString(n[:])
+}
+
+// NormalizeNonce returns the canonical form of a nonce string
+// Nonces that differ only in case or prefix normalize to the same value,
+// so replay protection must key on this form
+func NormalizeNonce(s string) (string, error) {
+	nonce, err := ParseNonce(strings.TrimSpace(s))
+	if err != nil {
+		return "", err
+	}
+	return nonce.String(), nil
+}
+
+// NonceState is what became of an authorization nonce, as reported by
+// GET /settlements/by-nonce
+type NonceState string
+
+const (
+	NonceSettled  NonceState = "settled"           // Settled by this facilitator
+	NoncePending  NonceState = "pending"           // Submitted by this facilitator, outcome not known yet
+	NonceConsumed NonceState = "consumed_on_chain" // Used or cancelled by a transaction the journal has no record of
+	NonceUnused   NonceState = "unused"            // Still valid on-chain
+)
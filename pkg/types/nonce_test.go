@@ -0,0 +1,51 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNonce(t *testing.T) {
+	canonical := "0x" + strings.Repeat("ab", 32)
+	for _, s := range []string{
+		canonical,
+		strings.ToUpper(canonical),
+		"0x" + strings.Repeat("AB", 32),
+		strings.Repeat("ab", 32),
+	} {
+		nonce, err := ParseNonce(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if nonce.String() != canonical {
+			t.Errorf("%s parsed as %s, want %s", s, nonce, canonical)
+		}
+	}
+
+	for name, s := range map[string]string{
+		"31 bytes": "0x" + strings.Repeat("ab", 31),
+		"33 bytes": "0x" + strings.Repeat("ab", 33),
+		"odd":      "0x" + strings.Repeat("ab", 31) + "abc",
+		"non-hex":  "0x" + strings.Repeat("zz", 32),
+		"empty":    "",
+		"prefix":   "0x",
+	} {
+		if nonce, err := ParseNonce(s); err == nil {
+			t.Errorf("%s: parsed %q as %s", name, s, nonce)
+		}
+	}
+}
+
+func TestNormalizeNonceMergesVariants(t *testing.T) {
+	lower := "0x" + strings.Repeat("0f", 32)
+	for _, variant := range []string{strings.ToUpper(lower), " " + lower + "\n", strings.TrimPrefix(lower, "0x")} {
+		normalized, err := NormalizeNonce(variant)
+		if err != nil || normalized != lower {
+			t.Errorf("%q normalized to %q (%v), want %q", variant, normalized, err, lower)
+		}
+	}
+	if _, err := NormalizeNonce("0x" + strings.Repeat("0f", 31)); err == nil {
+		t.Error("normalized a short nonce")
+	}
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Permit2Address is the canonical Uniswap Permit2 deployment, at the same
+// address on every EVM chain
+var Permit2Address = common.HexToAddress("0x000000000022D473030F116dDEE9F6B43aC78BA3")
+
+// Permit2ExtraKey is the requirements Extra key carrying a Permit2Extra
+const Permit2ExtraKey = "permit2"
+
+// Permit2Extra names the spender a permit2 payment must be signed for. The
+// facilitator advertises it in its /supported kinds (one of its settlement
+// signers, which submits the transfer); servers copy it into the
+// requirements' extra field.
+type Permit2Extra struct {
+	Spender common.Address `json:"spender"`
+}
+
+// ParsePermit2Extra extracts the permit2 spender from an extra field
+// Returns nil if none is present
+func ParsePermit2Extra(extra json.RawMessage) (*Permit2Extra, error) {
+	var permit2 Permit2Extra
+	found, err := GetExtraField(extra, Permit2ExtraKey, &permit2)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &permit2, nil
+}
+
+// Permit2Payload is the part of a Permit2 PermitWitnessTransferFrom not
+// already in the payload's authorization. In the permit2 scheme the
+// authorization maps onto the permit: From is the token owner, Value the
+// permitted amount, ValidBefore the deadline and Nonce the (unordered,
+// uint256) Permit2 nonce; To and Value are signed as the witness, so the
+// spender can only pay the amount to payTo. ValidAfter is not signed.
+type Permit2Payload struct {
+	Spender common.Address `json:"spender"`
+}
@@ -0,0 +1,48 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParsePermit2Extra(t *testing.T) {
+	spender := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	for _, tc := range []struct {
+		name    string
+		extra   string
+		spender *common.Address // Nil when no spender is named
+		err     bool
+	}{
+		{"no extra", ``, nil, false},
+		{"other fields only", `{"name":"USD Coin","version":"2"}`, nil, false},
+		{"spender", `{"name":"USD Coin","permit2":{"spender":"0x00000000000000000000000000000000000000aa"}}`, &spender, false},
+		{"malformed spender", `{"permit2":{"spender":42}}`, nil, true},
+	} {
+		extra, err := ParsePermit2Extra(json.RawMessage(tc.extra))
+		if (err != nil) != tc.err {
+			t.Errorf("%s: error %v, want one %v", tc.name, err, tc.err)
+			continue
+		}
+		switch {
+		case tc.spender == nil && extra != nil:
+			t.Errorf("%s: parsed %+v, want no spender", tc.name, extra)
+		case tc.spender != nil && (extra == nil || extra.Spender != *tc.spender):
+			t.Errorf("%s: parsed %+v, want spender %s", tc.name, extra, tc.spender.Hex())
+		}
+	}
+
+	// The permit2 payload is omitted from exact payments
+	data, err := json.Marshal(ExactEvmPayload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["permit2"]; ok {
+		t.Errorf("exact payload %s carries a permit2 field", data)
+	}
+}
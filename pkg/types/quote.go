@@ -0,0 +1,42 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// QuoteExtraKey is the requirements/payload Extra key carrying a PaymentQuote
+const QuoteExtraKey = "quote"
+
+// Quote headers on 402 responses, mirroring the quote in requirements.Extra
+const (
+	HeaderQuoteID        = "X-Payment-Quote-Id"
+	HeaderQuoteExpiresAt = "X-Payment-Quote-Expires-At"
+)
+
+// PaymentQuote states how long the price in a 402 holds. A server quoting
+// prices puts it in the requirements' extra field; the client echoes it in
+// its payload, and a payment against an expired quote is rejected with
+// ReasonQuoteExpired so the client fetches fresh requirements.
+type PaymentQuote struct {
+	ID        string `json:"id"`
+	ExpiresAt string `json:"expiresAt"` // RFC3339
+}
+
+// Expired reports whether the quote has lapsed at now; an unparseable
+// expiry counts as expired
+func (q *PaymentQuote) Expired(now time.Time) bool {
+	expiresAt, err := time.Parse(time.RFC3339, q.ExpiresAt)
+	return err != nil || !now.Before(expiresAt)
+}
+
+// ParseQuote extracts the quote from an extra field
+// Returns nil if none is present
+func ParseQuote(extra json.RawMessage) (*PaymentQuote, error) {
+	var quote PaymentQuote
+	found, err := GetExtraField(extra, QuoteExtraKey, &quote)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &quote, nil
+}
@@ -0,0 +1,72 @@
+package types
+
+// ReasonCode is a stable, machine-readable cause for a failed verification or
+// settlement. Reason / Error remain the human-readable text; clients should
+// branch on the code instead (e.g. re-sign on expired, top up on
+// insufficient_funds).
+type ReasonCode string
+
+const (
+	ReasonUnsupportedNetwork ReasonCode = "unsupported_network"
+	ReasonNetworkMismatch    ReasonCode = "network_mismatch"
+	ReasonSchemeMismatch     ReasonCode = "scheme_mismatch"
+	ReasonUnsupportedVersion ReasonCode = "unsupported_version"
+	ReasonReceiverMismatch   ReasonCode = "receiver_mismatch"
+	ReasonSelfPayTo          ReasonCode = "self_pay_to" // payTo is one of the facilitator's own signers
+	ReasonUnsupportedAsset   ReasonCode = "unsupported_asset"
+	ReasonInvalidTiming      ReasonCode = "invalid_timing" // Malformed or too long validity window
+	ReasonExpired            ReasonCode = "expired"
+	ReasonNotYetValid        ReasonCode = "not_yet_valid"
+	ReasonWindowTooShort     ReasonCode = "window_too_short"   // Expires before a settlement could land; re-sign with a fresh window
+	ReasonValidityTooShort   ReasonCode = "validity_too_short" // Validity window below the requirements' minTimeoutSeconds
+	ReasonInvalidAmount      ReasonCode = "invalid_amount"
+	ReasonInsufficientValue  ReasonCode = "insufficient_value"
+	ReasonAmountNotAccepted  ReasonCode = "amount_not_accepted" // Overpayment refused by the settlement amount policy
+	ReasonInsufficientFunds  ReasonCode = "insufficient_funds"
+	ReasonInvalidSignature   ReasonCode = "invalid_signature"
+	ReasonNonceReused        ReasonCode = "nonce_reused"
+	ReasonInvalidSplits      ReasonCode = "invalid_splits"
+	ReasonFeeNotCovered      ReasonCode = "fee_not_covered"
+	ReasonChallengeFailed    ReasonCode = "challenge_failed"
+	ReasonQuoteExpired       ReasonCode = "quote_expired"     // Price quote lapsed; fetch fresh requirements
+	ReasonResourceMismatch   ReasonCode = "resource_mismatch" // Payment not bound to the requested resource
+	ReasonNetworkDisabled    ReasonCode = "network_disabled"  // Network temporarily disabled by the operator
+	ReasonDecodingError      ReasonCode = "decoding_error"
+	ReasonDuplicatePayment   ReasonCode = "duplicate_payment_header" // More than one payment header value
+	ReasonPaymentTooLarge    ReasonCode = "payment_header_too_large" // Payment header above the size limit
+	ReasonContractCallError  ReasonCode = "contract_call_error"      // On-chain call reverted
+	ReasonRPCError           ReasonCode = "rpc_error"                // Node unreachable or call failed
+	ReasonTimeout            ReasonCode = "timeout"
+	ReasonSettlementFailed   ReasonCode = "settlement_failed"   // Transaction could not be sent or mined
+	ReasonSettlementDisabled ReasonCode = "settlement_disabled" // Verify-only facilitator
+
+	// Permit2 scheme
+	ReasonUnsupportedScheme     ReasonCode = "unsupported_scheme"     // Scheme not accepted by this facilitator
+	ReasonInsufficientAllowance ReasonCode = "insufficient_allowance" // Token not approved to Permit2 for the amount
+	ReasonInvalidSpender        ReasonCode = "invalid_spender"        // Permit2 spender is not a signer of this facilitator
+
+	// Gas sponsorship
+	ReasonGasBudgetExceeded ReasonCode = "gas_budget_exceeded" // The payTo's daily gas budget is used up
+
+	// Verification hooks
+	ReasonPayerDenied ReasonCode = "payer_denied" // Payer on the operator's denylist
+
+	// Tenancy
+	ReasonPolicyViolation ReasonCode = "policy_violation" // Payment outside what the tenant may use
+
+	// RPC circuit breaker
+	ReasonServiceDegraded ReasonCode = "service_degraded" // Network's RPC failing; retry later
+)
+
+// ReasonCodes lists every ReasonCode, e.g. for the enum of an API schema
+var ReasonCodes = []ReasonCode{
+	ReasonUnsupportedNetwork, ReasonNetworkMismatch, ReasonSchemeMismatch, ReasonUnsupportedVersion,
+	ReasonReceiverMismatch, ReasonSelfPayTo, ReasonUnsupportedAsset, ReasonInvalidTiming, ReasonExpired,
+	ReasonNotYetValid, ReasonWindowTooShort, ReasonValidityTooShort, ReasonInvalidAmount, ReasonInsufficientValue, ReasonAmountNotAccepted,
+	ReasonInsufficientFunds, ReasonInvalidSignature, ReasonNonceReused, ReasonInvalidSplits,
+	ReasonFeeNotCovered, ReasonChallengeFailed, ReasonQuoteExpired, ReasonResourceMismatch,
+	ReasonNetworkDisabled, ReasonDecodingError, ReasonDuplicatePayment, ReasonPaymentTooLarge,
+	ReasonContractCallError, ReasonRPCError, ReasonTimeout, ReasonSettlementFailed,
+	ReasonSettlementDisabled, ReasonUnsupportedScheme, ReasonInsufficientAllowance, ReasonInvalidSpender,
+	ReasonGasBudgetExceeded, ReasonPayerDenied, ReasonPolicyViolation, ReasonServiceDegraded,
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestErrorConstructorsProduceDocumentedCodes(t *testing.T) {
+	payer := NewEvmAddress(common.HexToAddress("0x00000000000000000000000000000000000000a1"))
+	documented := make(map[ReasonCode]bool, len(ReasonCodes))
+	for _, code := range ReasonCodes {
+		if documented[code] {
+			t.Errorf("%s is listed twice in ReasonCodes", code)
+		}
+		documented[code] = true
+	}
+
+	for _, tc := range []struct {
+		err  *FacilitatorError
+		want ReasonCode
+	}{
+		{NewUnsupportedNetworkError(&payer), ReasonUnsupportedNetwork},
+		{NewUnsupportedAssetError("0x01"), ReasonUnsupportedAsset},
+		{NewNetworkMismatchError("base", "polygon", &payer), ReasonNetworkMismatch},
+		{NewSchemeMismatchError(SchemeExact, "upto", &payer), ReasonSchemeMismatch},
+		{NewUnsupportedVersionError(9), ReasonUnsupportedVersion},
+		{NewReceiverMismatchError("0x01", "0x02", payer), ReasonReceiverMismatch},
+		{NewInvalidTimingError(payer, "window too long"), ReasonInvalidTiming},
+		{NewNotYetValidError(payer, "not yet"), ReasonNotYetValid},
+		{NewExpiredError(payer, "expired"), ReasonExpired},
+		{NewInsufficientFundsError(payer), ReasonInsufficientFunds},
+		{NewInsufficientValueError(payer), ReasonInsufficientValue},
+		{NewInvalidAmountError(&payer, "negative"), ReasonInvalidAmount},
+		{NewInvalidSignatureError(payer, "bad signature"), ReasonInvalidSignature},
+		{NewNonceAlreadyUsedError(payer), ReasonNonceReused},
+		{NewTimeoutError("node too slow"), ReasonTimeout},
+		{NewDecodingError("bad hex"), ReasonDecodingError},
+		{NewContractCallError("reverted"), ReasonContractCallError},
+		{NewServiceDegradedError("circuit open"), ReasonServiceDegraded},
+		{NewPolicyViolationError(&payer, "network not allowed"), ReasonPolicyViolation},
+	} {
+		if tc.err.Code != tc.want {
+			t.Errorf("%s: code %q, want %q", tc.err.Type, tc.err.Code, tc.want)
+		}
+		if !documented[tc.err.Code] {
+			t.Errorf("%s: code %q is not in ReasonCodes", tc.err.Type, tc.err.Code)
+		}
+		if tc.err.Type == "" || tc.err.Message == "" {
+			t.Errorf("%s error lacks a type or message: %+v", tc.want, tc.err)
+		}
+	}
+}
+
+func TestInvalidResponseCarriesCodeOverTheWire(t *testing.T) {
+	payer := NewEvmAddress(common.HexToAddress("0x00000000000000000000000000000000000000a1"))
+	data, err := json.Marshal(NewInvalidResponseFromError(NewExpiredError(payer, "payment expired")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wire struct {
+		IsValid    bool   `json:"isValid"`
+		Reason     string `json:"reason"`
+		ReasonCode string `json:"reasonCode"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		t.Fatal(err)
+	}
+	if wire.IsValid || wire.Reason != "payment expired" || wire.ReasonCode != "expired" {
+		t.Errorf("verify response %s, want reasonCode expired", data)
+	}
+
+	data, err = json.Marshal(SettleResponse{Error: "nonce used", ReasonCode: ReasonNonceReused})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settled map[string]interface{}
+	if err := json.Unmarshal(data, &settled); err != nil {
+		t.Fatal(err)
+	}
+	if settled["reason_code"] != "nonce_reused" || settled["error"] != "nonce used" {
+		t.Errorf("settle response %s, want reason_code nonce_reused beside the error text", data)
+	}
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PaymentReceipt records what a successful settlement transferred, so the
+// payer can check it against the authorization it signed
+type PaymentReceipt struct {
+	Network         Network `json:"network"`
+	Payer           string  `json:"payer"`
+	PayTo           string  `json:"payTo"`
+	Amount          string  `json:"amount"`
+	Nonce           string  `json:"nonce"`
+	TransactionHash string  `json:"transactionHash,omitempty"`
+	Signer          string  `json:"signer,omitempty"`       // Facilitator address that signed the receipt
+	SettlementID    string  `json:"settlementId,omitempty"` // See HeaderIdempotencyKey
+}
+
+// NewPaymentReceipt builds the receipt for a settled EVM payload
+func NewPaymentReceipt(payload *PaymentPayload, txHash string) *PaymentReceipt {
+	auth := payload.Payload.Authorization
+	return &PaymentReceipt{
+		Network:         payload.Network,
+		Payer:           auth.From.Hex(),
+		PayTo:           auth.To.Hex(),
+		Amount:          auth.Value,
+		Nonce:           auth.Nonce.String(),
+		TransactionHash: txHash,
+	}
+}
+
+// Sign names the signer's address as the receipt signer and returns an
+// EIP-191 signature over the receipt's canonical JSON
+func (r *PaymentReceipt) Sign(ctx context.Context, signer HashSigner) (string, error) {
+	r.Signer = signer.Address().Hex()
+	return signCanonical(ctx, r, signer)
+}
+
+// VerifySignature checks signature against the receipt and returns the signer,
+// which must match the signer named in the receipt
+func (r *PaymentReceipt) VerifySignature(signature string) (common.Address, error) {
+	if !common.IsHexAddress(r.Signer) {
+		return common.Address{}, errors.New("receipt does not name a signer")
+	}
+	signer, err := recoverCanonical(r, signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid receipt signature: %w", err)
+	}
+	if signer != common.HexToAddress(r.Signer) {
+		return common.Address{}, fmt.Errorf("receipt signed by %s, not the named signer %s", signer.Hex(), r.Signer)
+	}
+	return signer, nil
+}
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ResourceBindingExtraKey is the payload Extra key carrying a ResourceBinding
+const ResourceBindingExtraKey = "resourceBinding"
+
+// ResourceBinding ties a payment to the resource it was made for.
+//
+// The client picks a random salt and uses AuthorizationNonce as the EIP-3009
+// nonce, so the signature commits to the resource; a payment made for one
+// route cannot be presented to another that shares its price and payTo.
+type ResourceBinding struct {
+	Salt     string `json:"salt"`
+	Resource string `json:"resource"`
+}
+
+// NewResourceBinding creates a binding to resource with a random salt
+func NewResourceBinding(resource string) (*ResourceBinding, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &ResourceBinding{Salt: "0x" + hex.EncodeToString(salt), Resource: resource}, nil
+}
+
+// AuthorizationNonce returns the authorization nonce derived from the binding:
+// keccak256(salt || resource), hex-encoded
+func (b *ResourceBinding) AuthorizationNonce() string {
+	return crypto.Keccak256Hash([]byte(b.Salt), []byte(b.Resource)).Hex()
+}
+
+// ParseResourceBinding extracts the binding from a payload extra field
+// Returns nil if none is present
+func ParseResourceBinding(extra json.RawMessage) (*ResourceBinding, error) {
+	var binding ResourceBinding
+	found, err := GetExtraField(extra, ResourceBindingExtraKey, &binding)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+// CheckResourceBinding verifies the payment's authorization nonce commits to
+// requirements.Resource, either through a ResourceBinding or through a
+// PaymentChallenge issued for the same host and path
+func CheckResourceBinding(payload *PaymentPayload, requirements *PaymentRequirements) error {
+	if requirements.Resource == "" {
+		return errors.New("requirements do not name a resource")
+	}
+	nonce := payload.Payload.Authorization.Nonce.String()
+
+	binding, err := ParseResourceBinding(payload.Extra)
+	if err != nil {
+		return fmt.Errorf("invalid resource binding: %w", err)
+	}
+	if binding != nil {
+		if binding.Resource != requirements.Resource {
+			return fmt.Errorf("payment is bound to %s, not %s", binding.Resource, requirements.Resource)
+		}
+		if !strings.EqualFold(nonce, binding.AuthorizationNonce()) {
+			return errors.New("authorization nonce is not derived from the resource binding")
+		}
+		return nil
+	}
+
+	challenge, err := ParseChallenge(payload.Extra)
+	if err != nil {
+		return fmt.Errorf("invalid challenge: %w", err)
+	}
+	if challenge != nil {
+		u, err := url.Parse(requirements.Resource)
+		if err != nil || challenge.Resource != u.Host+u.EscapedPath() {
+			return fmt.Errorf("payment challenge was issued for %s, not %s", challenge.Resource, requirements.Resource)
+		}
+		if !strings.EqualFold(nonce, challenge.AuthorizationNonce()) {
+			return errors.New("authorization nonce is not bound to the challenge")
+		}
+		return nil
+	}
+
+	return errors.New("payment is not bound to a resource")
+}
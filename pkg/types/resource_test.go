@@ -0,0 +1,76 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+// boundPayload returns a payload whose nonce is derived from nonce, carrying
+// extra
+func boundPayload(t *testing.T, nonce, extra string) *PaymentPayload {
+	t.Helper()
+	decoded, err := DecodeHex(nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := &PaymentPayload{Extra: []byte(extra)}
+	payload.Payload.Authorization.Nonce = decoded
+	return payload
+}
+
+func TestCheckResourceBinding(t *testing.T) {
+	const resource = "https://shop.test/cheap-endpoint"
+	binding, err := NewResourceBinding(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewResourceBinding(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binding.Salt == other.Salt || binding.AuthorizationNonce() == other.AuthorizationNonce() {
+		t.Fatal("two bindings to one resource share a salt or nonce")
+	}
+	bound, err := SetExtraField(nil, ResourceBindingExtraKey, binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := &PaymentChallenge{Nonce: "challenge-1", Resource: "shop.test/cheap-endpoint"}
+	challenged, err := MergeExtra(nil, RequirementsExtra{Challenge: challenge})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		payload  *PaymentPayload
+		resource string
+		err      string // Empty when the binding holds
+	}{
+		{"bound", boundPayload(t, binding.AuthorizationNonce(), string(bound)), resource, ""},
+		{"bound, nonce in upper case", boundPayload(t, "0x"+strings.ToUpper(binding.AuthorizationNonce()[2:]), string(bound)), resource, ""},
+		{"bound to another route", boundPayload(t, binding.AuthorizationNonce(), string(bound)), "https://shop.test/expensive-endpoint", "bound to " + resource},
+		{"nonce from another salt", boundPayload(t, other.AuthorizationNonce(), string(bound)), resource, "not derived"},
+		{"challenge", boundPayload(t, challenge.AuthorizationNonce(), string(challenged)), resource, ""},
+		{"challenge for another route", boundPayload(t, challenge.AuthorizationNonce(), string(challenged)), "https://shop.test/expensive-endpoint", "issued for"},
+		{"nonce not from the challenge", boundPayload(t, binding.AuthorizationNonce(), string(challenged)), resource, "not bound to the challenge"},
+		{"unbound", boundPayload(t, binding.AuthorizationNonce(), ""), resource, "not bound to a resource"},
+		{"malformed binding", boundPayload(t, binding.AuthorizationNonce(), `{"resourceBinding":"x"}`), resource, "invalid resource binding"},
+		{"no resource required", boundPayload(t, binding.AuthorizationNonce(), string(bound)), "", "do not name a resource"},
+	} {
+		err := CheckResourceBinding(tc.payload, &PaymentRequirements{Resource: tc.resource})
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: error %v, want %q", tc.name, err, tc.err)
+		}
+	}
+
+	if parsed, err := ParseResourceBinding(bound); err != nil || parsed == nil || *parsed != *binding {
+		t.Errorf("ParseResourceBinding = %+v, %v; want %+v", parsed, err, binding)
+	}
+	if parsed, err := ParseResourceBinding(nil); parsed != nil || err != nil {
+		t.Errorf("ParseResourceBinding(nil) = %+v, %v", parsed, err)
+	}
+}
@@ -0,0 +1,641 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONSchema is a parsed JSON Schema (draft-07) document, such as the
+// OutputSchema of payment requirements
+// Validate covers the draft-07 validation keywords; "format" is treated as
+// an annotation and only local "$ref"s ("#", "#/definitions/...") resolve.
+type JSONSchema struct {
+	raw  json.RawMessage
+	root interface{} // map[string]interface{} or bool
+}
+
+// jsonSchemaTypes are the draft-07 primitive type names
+var jsonSchemaTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "string": true, "integer": true,
+}
+
+// ParseJSONSchema decodes and structurally validates a draft-07 schema
+// document: every keyword must have the type the specification gives it
+func ParseJSONSchema(data []byte) (*JSONSchema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if err := checkSchema("#", root); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return &JSONSchema{raw: compact.Bytes(), root: root}, nil
+}
+
+// ParseOutputSchema returns the requirements' OutputSchema, or nil if they
+// carry none
+func (r *PaymentRequirements) ParseOutputSchema() (*JSONSchema, error) {
+	trimmed := bytes.TrimSpace(r.OutputSchema)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return nil, nil
+	}
+	return ParseJSONSchema(trimmed)
+}
+
+// Raw returns the schema document as compact JSON
+func (s *JSONSchema) Raw() json.RawMessage {
+	return append(json.RawMessage(nil), s.raw...)
+}
+
+// Document returns the decoded schema object, or nil for a boolean schema
+func (s *JSONSchema) Document() map[string]interface{} {
+	doc, _ := s.root.(map[string]interface{})
+	return doc
+}
+
+// MarshalJSON implements json.Marshaler
+func (s *JSONSchema) MarshalJSON() ([]byte, error) {
+	return s.Raw(), nil
+}
+
+// Validate checks a JSON document against the schema; the error names the
+// JSON pointer of the first mismatch
+func (s *JSONSchema) Validate(instance []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(instance, &value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return s.validate(s.root, value, "", 0)
+}
+
+// Structural validation
+
+// checkSchema checks the keywords of the schema at path have valid values
+func checkSchema(path string, schema interface{}) error {
+	if _, ok := schema.(bool); ok {
+		return nil
+	}
+	obj, ok := schema.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: schema must be an object or a boolean", path)
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := obj[key]
+		at := path + "/" + key
+		switch key {
+		case "$schema", "$id", "$ref", "$comment", "title", "description", "format", "contentMediaType", "contentEncoding":
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("%s: must be a string", at)
+			}
+		case "pattern":
+			s, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s: must be a string", at)
+			}
+			if _, err := regexp.Compile(s); err != nil {
+				return fmt.Errorf("%s: invalid regular expression: %v", at, err)
+			}
+		case "type":
+			if err := checkSchemaType(at, value); err != nil {
+				return err
+			}
+		case "enum":
+			values, ok := value.([]interface{})
+			if !ok || len(values) == 0 {
+				return fmt.Errorf("%s: must be a non-empty array", at)
+			}
+		case "required":
+			if err := checkStringSet(at, value); err != nil {
+				return err
+			}
+		case "maxLength", "minLength", "maxItems", "minItems", "maxProperties", "minProperties":
+			n, ok := value.(float64)
+			if !ok || n < 0 || n != math.Trunc(n) {
+				return fmt.Errorf("%s: must be a non-negative integer", at)
+			}
+		case "multipleOf":
+			n, ok := value.(float64)
+			if !ok || n <= 0 {
+				return fmt.Errorf("%s: must be a number greater than 0", at)
+			}
+		case "maximum", "minimum", "exclusiveMaximum", "exclusiveMinimum":
+			if _, ok := value.(float64); !ok {
+				return fmt.Errorf("%s: must be a number", at)
+			}
+		case "uniqueItems", "readOnly", "writeOnly":
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("%s: must be a boolean", at)
+			}
+		case "examples":
+			if _, ok := value.([]interface{}); !ok {
+				return fmt.Errorf("%s: must be an array", at)
+			}
+		case "additionalItems", "contains", "additionalProperties", "propertyNames", "if", "then", "else", "not":
+			if err := checkSchema(at, value); err != nil {
+				return err
+			}
+		case "items":
+			if list, ok := value.([]interface{}); ok {
+				for i, item := range list {
+					if err := checkSchema(at+"/"+strconv.Itoa(i), item); err != nil {
+						return err
+					}
+				}
+			} else if err := checkSchema(at, value); err != nil {
+				return err
+			}
+		case "allOf", "anyOf", "oneOf":
+			list, ok := value.([]interface{})
+			if !ok || len(list) == 0 {
+				return fmt.Errorf("%s: must be a non-empty array of schemas", at)
+			}
+			for i, item := range list {
+				if err := checkSchema(at+"/"+strconv.Itoa(i), item); err != nil {
+					return err
+				}
+			}
+		case "properties", "patternProperties", "definitions":
+			schemas, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: must be an object of schemas", at)
+			}
+			for name, sub := range schemas {
+				if key == "patternProperties" {
+					if _, err := regexp.Compile(name); err != nil {
+						return fmt.Errorf("%s: invalid regular expression %q: %v", at, name, err)
+					}
+				}
+				if err := checkSchema(at+"/"+escapePointer(name), sub); err != nil {
+					return err
+				}
+			}
+		case "dependencies":
+			deps, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: must be an object", at)
+			}
+			for name, dep := range deps {
+				depAt := at + "/" + escapePointer(name)
+				if _, isList := dep.([]interface{}); isList {
+					if err := checkStringSet(depAt, dep); err != nil {
+						return err
+					}
+				} else if err := checkSchema(depAt, dep); err != nil {
+					return err
+				}
+			}
+		}
+		// Other keywords ("default", "const", extensions) take any value
+	}
+	return nil
+}
+
+// checkSchemaType requires a type name or an array of unique type names
+func checkSchemaType(at string, value interface{}) error {
+	switch t := value.(type) {
+	case string:
+		if !jsonSchemaTypes[t] {
+			return fmt.Errorf("%s: unknown type %q", at, t)
+		}
+		return nil
+	case []interface{}:
+		if len(t) == 0 {
+			return fmt.Errorf("%s: must not be empty", at)
+		}
+		seen := make(map[string]bool, len(t))
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok || !jsonSchemaTypes[name] {
+				return fmt.Errorf("%s: unknown type %v", at, item)
+			}
+			if seen[name] {
+				return fmt.Errorf("%s: duplicate type %q", at, name)
+			}
+			seen[name] = true
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: must be a type name or an array of type names", at)
+}
+
+// checkStringSet requires an array of unique strings
+func checkStringSet(at string, value interface{}) error {
+	list, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("%s: must be an array of strings", at)
+	}
+	seen := make(map[string]bool, len(list))
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return fmt.Errorf("%s: must be an array of strings", at)
+		}
+		if seen[s] {
+			return fmt.Errorf("%s: duplicate entry %q", at, s)
+		}
+		seen[s] = true
+	}
+	return nil
+}
+
+// Instance validation
+
+// maxSchemaDepth bounds $ref recursion
+const maxSchemaDepth = 64
+
+// validate checks value (at JSON pointer path) against schema
+func (s *JSONSchema) validate(schema, value interface{}, path string, depth int) error {
+	if depth > maxSchemaDepth {
+		return fmt.Errorf("%s: schema nests too deeply (recursive $ref?)", pointerOrRoot(path))
+	}
+	if b, ok := schema.(bool); ok {
+		if !b {
+			return fmt.Errorf("%s: no value is allowed here", pointerOrRoot(path))
+		}
+		return nil
+	}
+	obj, _ := schema.(map[string]interface{})
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%s: %s", pointerOrRoot(path), fmt.Sprintf(format, args...))
+	}
+
+	if ref, ok := obj["$ref"].(string); ok {
+		// In draft-07 $ref replaces its sibling keywords
+		target, err := s.resolveRef(ref)
+		if err != nil {
+			return fail("%v", err)
+		}
+		return s.validate(target, value, path, depth+1)
+	}
+
+	if t, ok := obj["type"]; ok && !matchesType(t, value) {
+		return fail("expected %s, got %s", typeNames(t), jsonTypeOf(value))
+	}
+	if enum, ok := obj["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("value is not one of the enumerated values")
+		}
+	}
+	if constant, ok := obj["const"]; ok && !reflect.DeepEqual(constant, value) {
+		return fail("value does not equal the constant")
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if err := validateNumber(obj, v, fail); err != nil {
+			return err
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if n, ok := obj["maxLength"].(float64); ok && length > n {
+			return fail("string is longer than %v characters", n)
+		}
+		if n, ok := obj["minLength"].(float64); ok && length < n {
+			return fail("string is shorter than %v characters", n)
+		}
+		if pattern, ok := obj["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				return fail("string does not match pattern %q", pattern)
+			}
+		}
+	case []interface{}:
+		if err := s.validateArray(obj, v, path, depth, fail); err != nil {
+			return err
+		}
+	case map[string]interface{}:
+		if err := s.validateObject(obj, v, path, depth, fail); err != nil {
+			return err
+		}
+	}
+
+	if allOf, ok := obj["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			if err := s.validate(sub, value, path, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	if anyOf, ok := obj["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if s.validate(sub, value, path, depth+1) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fail("value matches none of anyOf")
+		}
+	}
+	if oneOf, ok := obj["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, sub := range oneOf {
+			if s.validate(sub, value, path, depth+1) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fail("value matches %d of oneOf (want exactly 1)", matched)
+		}
+	}
+	if not, ok := obj["not"]; ok && s.validate(not, value, path, depth+1) == nil {
+		return fail("value matches the schema in not")
+	}
+	if cond, ok := obj["if"]; ok {
+		branch, has := obj["else"]
+		if s.validate(cond, value, path, depth+1) == nil {
+			branch, has = obj["then"]
+		}
+		if has {
+			if err := s.validate(branch, value, path, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateNumber applies the numeric keywords
+func validateNumber(obj map[string]interface{}, v float64, fail func(string, ...interface{}) error) error {
+	if n, ok := obj["maximum"].(float64); ok && v > n {
+		return fail("%v is greater than the maximum %v", v, n)
+	}
+	if n, ok := obj["exclusiveMaximum"].(float64); ok && v >= n {
+		return fail("%v is not less than %v", v, n)
+	}
+	if n, ok := obj["minimum"].(float64); ok && v < n {
+		return fail("%v is less than the minimum %v", v, n)
+	}
+	if n, ok := obj["exclusiveMinimum"].(float64); ok && v <= n {
+		return fail("%v is not greater than %v", v, n)
+	}
+	if n, ok := obj["multipleOf"].(float64); ok && n > 0 {
+		if q := v / n; math.Abs(q-math.Round(q)) > 1e-9 {
+			return fail("%v is not a multiple of %v", v, n)
+		}
+	}
+	return nil
+}
+
+// validateArray applies the array keywords
+func (s *JSONSchema) validateArray(obj map[string]interface{}, items []interface{}, path string, depth int, fail func(string, ...interface{}) error) error {
+	if n, ok := obj["maxItems"].(float64); ok && float64(len(items)) > n {
+		return fail("array has more than %v items", n)
+	}
+	if n, ok := obj["minItems"].(float64); ok && float64(len(items)) < n {
+		return fail("array has fewer than %v items", n)
+	}
+	if unique, _ := obj["uniqueItems"].(bool); unique {
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if reflect.DeepEqual(items[i], items[j]) {
+					return fail("items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+
+	switch schema := obj["items"].(type) {
+	case nil:
+	case []interface{}:
+		for i, item := range items {
+			sub := obj["additionalItems"]
+			if i < len(schema) {
+				sub = schema[i]
+			} else if sub == nil {
+				break
+			}
+			if err := s.validate(sub, item, path+"/"+strconv.Itoa(i), depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		for i, item := range items {
+			if err := s.validate(schema, item, path+"/"+strconv.Itoa(i), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if contains, ok := obj["contains"]; ok {
+		found := false
+		for i, item := range items {
+			if s.validate(contains, item, path+"/"+strconv.Itoa(i), depth+1) == nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("no item matches contains")
+		}
+	}
+	return nil
+}
+
+// validateObject applies the object keywords
+func (s *JSONSchema) validateObject(obj, props map[string]interface{}, path string, depth int, fail func(string, ...interface{}) error) error {
+	if n, ok := obj["maxProperties"].(float64); ok && float64(len(props)) > n {
+		return fail("object has more than %v properties", n)
+	}
+	if n, ok := obj["minProperties"].(float64); ok && float64(len(props)) < n {
+		return fail("object has fewer than %v properties", n)
+	}
+	if required, ok := obj["required"].([]interface{}); ok {
+		for _, name := range required {
+			if key, _ := name.(string); key != "" {
+				if _, present := props[key]; !present {
+					return fail("missing required property %q", key)
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	properties, _ := obj["properties"].(map[string]interface{})
+	patterns, _ := obj["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := obj["additionalProperties"]
+	propertyNames, hasPropertyNames := obj["propertyNames"]
+	for _, name := range names {
+		value := props[name]
+		at := path + "/" + escapePointer(name)
+		if hasPropertyNames {
+			if err := s.validate(propertyNames, name, at, depth+1); err != nil {
+				return err
+			}
+		}
+		matched := false
+		if sub, ok := properties[name]; ok {
+			matched = true
+			if err := s.validate(sub, value, at, depth+1); err != nil {
+				return err
+			}
+		}
+		for pattern, sub := range patterns {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(name) {
+				matched = true
+				if err := s.validate(sub, value, at, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		if !matched && hasAdditional {
+			if err := s.validate(additional, value, at, depth+1); err != nil {
+				if b, ok := additional.(bool); ok && !b {
+					return fail("unexpected property %q", name)
+				}
+				return err
+			}
+		}
+	}
+
+	if deps, ok := obj["dependencies"].(map[string]interface{}); ok {
+		for name, dep := range deps {
+			if _, present := props[name]; !present {
+				continue
+			}
+			if list, isList := dep.([]interface{}); isList {
+				for _, needed := range list {
+					if key, _ := needed.(string); key != "" {
+						if _, present := props[key]; !present {
+							return fail("property %q requires property %q", name, key)
+						}
+					}
+				}
+			} else if err := s.validate(dep, props, path, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveRef resolves a local JSON pointer reference against the root
+func (s *JSONSchema) resolveRef(ref string) (interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q (only local references resolve)", ref)
+	}
+	node := s.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			next, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+	}
+	return node, nil
+}
+
+// matchesType reports whether value has the type (or one of the types) named
+func matchesType(t, value interface{}) bool {
+	names, ok := t.([]interface{})
+	if !ok {
+		names = []interface{}{t}
+	}
+	actual := jsonTypeOf(value)
+	for _, name := range names {
+		switch name {
+		case actual:
+			return true
+		case "number":
+			if actual == "integer" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonTypeOf names the JSON type of a decoded value (whole numbers are "integer")
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// typeNames renders a "type" keyword for messages
+func typeNames(t interface{}) string {
+	names, ok := t.([]interface{})
+	if !ok {
+		return fmt.Sprint(t)
+	}
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprint(name)
+	}
+	return strings.Join(parts, " or ")
+}
+
+// escapePointer escapes a property name as a JSON pointer token
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+// pointerOrRoot renders the root pointer "" as "/" in messages
+func pointerOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
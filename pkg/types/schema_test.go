@@ -0,0 +1,116 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// weatherSchema describes the example paid weather response
+const weatherSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"required": ["city", "temperature", "readings"],
+	"properties": {
+		"city": {"type": "string", "minLength": 1},
+		"temperature": {"type": "number", "minimum": -90, "maximum": 60},
+		"unit": {"enum": ["C", "F"]},
+		"readings": {"type": "array", "items": {"$ref": "#/definitions/reading"}, "maxItems": 3}
+	},
+	"additionalProperties": false,
+	"definitions": {
+		"reading": {"type": "object", "required": ["at"], "properties": {"at": {"type": "integer"}}}
+	}
+}`
+
+func TestParseJSONSchemaAcceptsDraft07Documents(t *testing.T) {
+	for name, doc := range map[string]string{
+		"weather":      weatherSchema,
+		"true":         `true`,
+		"empty":        `{}`,
+		"type list":    `{"type": ["string", "null"]}`,
+		"combinators":  `{"anyOf": [{"type": "string"}, {"type": "integer"}], "not": {"const": 0}}`,
+		"format":       `{"type": "string", "format": "date-time"}`,
+		"pattern":      `{"type": "string", "pattern": "^[a-z]+$"}`,
+		"dependencies": `{"dependencies": {"a": ["b"], "c": {"required": ["d"]}}}`,
+	} {
+		schema, err := ParseJSONSchema([]byte(doc))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !json.Valid(schema.Raw()) || strings.ContainsAny(string(schema.Raw()), "\n\t") {
+			t.Errorf("%s: raw %q, want compact JSON", name, schema.Raw())
+		}
+	}
+}
+
+func TestParseJSONSchemaRejectsMalformedDocuments(t *testing.T) {
+	for name, doc := range map[string]string{
+		"not JSON":            `{"type": "object"`,
+		"not an object":       `"object"`,
+		"unknown type":        `{"type": "strin"}`,
+		"type not a string":   `{"type": 7}`,
+		"required not array":  `{"required": "city"}`,
+		"required not names":  `{"required": [1]}`,
+		"minimum not number":  `{"minimum": "0"}`,
+		"negative maxLength":  `{"maxLength": -1}`,
+		"properties not map":  `{"properties": []}`,
+		"bad nested property": `{"properties": {"city": {"type": "text"}}}`,
+		"bad pattern":         `{"pattern": "("}`,
+		"empty anyOf":         `{"anyOf": []}`,
+	} {
+		if _, err := ParseJSONSchema([]byte(doc)); err == nil {
+			t.Errorf("%s: %s was accepted", name, doc)
+		}
+	}
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema, err := ParseJSONSchema([]byte(weatherSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Validate([]byte(`{"city": "Oslo", "temperature": -3.5, "unit": "C", "readings": [{"at": 1700000000}]}`)); err != nil {
+		t.Errorf("matching response: %v", err)
+	}
+
+	for name, tc := range map[string]struct{ instance, pointer string }{
+		"missing field":   {`{"city": "Oslo", "temperature": 1}`, "readings"},
+		"wrong type":      {`{"city": "Oslo", "temperature": "cold", "readings": []}`, "/temperature"},
+		"out of range":    {`{"city": "Oslo", "temperature": 99, "readings": []}`, "/temperature"},
+		"empty string":    {`{"city": "", "temperature": 1, "readings": []}`, "/city"},
+		"not in enum":     {`{"city": "Oslo", "temperature": 1, "unit": "K", "readings": []}`, "/unit"},
+		"extra property":  {`{"city": "Oslo", "temperature": 1, "readings": [], "wind": 3}`, "wind"},
+		"referenced item": {`{"city": "Oslo", "temperature": 1, "readings": [{"at": 1.5}]}`, "/readings/0/at"},
+		"too many items":  {`{"city": "Oslo", "temperature": 1, "readings": [{"at": 1}, {"at": 2}, {"at": 3}, {"at": 4}]}`, "/readings"},
+		"not an object":   {`[]`, "/:"},
+		"not JSON at all": {`<html>`, ""},
+	} {
+		err := schema.Validate([]byte(tc.instance))
+		if err == nil {
+			t.Errorf("%s: %s matched the schema", name, tc.instance)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.pointer) {
+			t.Errorf("%s: error %q does not point at %s", name, err, tc.pointer)
+		}
+	}
+}
+
+func TestRequirementsParseOutputSchema(t *testing.T) {
+	for _, empty := range []string{"", "null", "  "} {
+		r := PaymentRequirements{OutputSchema: json.RawMessage(empty)}
+		if schema, err := r.ParseOutputSchema(); schema != nil || err != nil {
+			t.Errorf("OutputSchema %q: %v, %v; want no schema", empty, schema, err)
+		}
+	}
+	r := PaymentRequirements{OutputSchema: json.RawMessage(`{"type": "object"}`)}
+	if schema, err := r.ParseOutputSchema(); err != nil || schema.Document()["type"] != "object" {
+		t.Errorf("object schema: %v, %v", schema, err)
+	}
+	r.OutputSchema = json.RawMessage(`{"type": "obj"}`)
+	if _, err := r.ParseOutputSchema(); err == nil {
+		t.Error("an invalid output schema parsed")
+	}
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// HeaderIdempotencyKey carries the settlement ID a payment is verified and
+// settled under: a client may pick it, else the facilitator assigns one at
+// /verify and resource servers send it back with /settle
+const HeaderIdempotencyKey = "X-Payment-Idempotency-Key"
+
+// Length bounds of a client-chosen settlement ID
+const (
+	minSettlementIDLength = 16
+	maxSettlementIDLength = 64
+)
+
+// NewSettlementID returns a random (version 4) UUID
+func NewSettlementID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// ValidateSettlementID checks a client-chosen settlement ID: 16 to 64
+// letters, digits, '-' or '_' (a UUID qualifies), so it is hard to guess
+// and safe to log
+func ValidateSettlementID(id string) error {
+	if len(id) < minSettlementIDLength || len(id) > maxSettlementIDLength {
+		return fmt.Errorf("%s must be %d to %d characters long, got %d", HeaderIdempotencyKey, minSettlementIDLength, maxSettlementIDLength, len(id))
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("%s may only contain letters, digits, '-' and '_'", HeaderIdempotencyKey)
+		}
+	}
+	return nil
+}
+
+type settlementIDContextKey struct{}
+
+// WithSettlementID tags ctx with the settlement ID of the payment it verifies or settles
+func WithSettlementID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, settlementIDContextKey{}, id)
+}
+
+// SettlementIDFromContext returns the settlement ID set by WithSettlementID, or ""
+func SettlementIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(settlementIDContextKey{}).(string)
+	return id
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestSettlementIDs(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := NewSettlementID()
+		if !uuidV4.MatchString(id) || seen[id] {
+			t.Fatalf("settlement ID %q: want a fresh version 4 UUID", id)
+		}
+		seen[id] = true
+		if err := ValidateSettlementID(id); err != nil {
+			t.Errorf("generated ID %q refused: %v", id, err)
+		}
+	}
+
+	for id, valid := range map[string]bool{
+		"order-2026-10-14_0001":  true,
+		strings.Repeat("a", 16):  true,
+		strings.Repeat("a", 64):  true,
+		strings.Repeat("a", 15):  false,
+		strings.Repeat("a", 65):  false,
+		"order 2026-10-14 0001":  false,
+		"order-2026-10-14\n0001": false,
+		"order/2026/10/14/0001":  false,
+		"order-2026-10-14-ü0001": false,
+	} {
+		if err := ValidateSettlementID(id); (err == nil) != valid {
+			t.Errorf("ValidateSettlementID(%q): error %v, want valid %v", id, err, valid)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package types
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// HashSigner signs 32-byte digests as one address, returning [R || S || V]
+// with V of 0 or 1 (evm.Signer implementations satisfy it, including KMS
+// and remote signers)
+type HashSigner interface {
+	Address() common.Address
+	SignHash(ctx context.Context, hash []byte) ([]byte, error)
+}
+
+// KeySigner is a HashSigner backed by an in-memory private key
+type KeySigner struct {
+	Key *ecdsa.PrivateKey
+}
+
+// Address implements HashSigner
+func (s KeySigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.Key.PublicKey)
+}
+
+// SignHash implements HashSigner
+func (s KeySigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.Key)
+}
+
+// RequirementsSignerExtraKey is the requirements Extra key naming the address
+// that signed the requirements
+const RequirementsSignerExtraKey = "requirementsSigner"
+
+// SignRequirements returns a copy of requirements naming the signer in
+// Extra, and an EIP-191 signature over its canonical JSON (hex, 65 bytes).
+// The signer field is part of what is signed, so it cannot be swapped.
+func SignRequirements(requirements *PaymentRequirements, key *ecdsa.PrivateKey) (*PaymentRequirements, string, error) {
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	signed := *requirements
+	extra, err := MergeExtra(requirements.Extra, RequirementsExtra{RequirementsSigner: signer.Hex()})
+	if err != nil {
+		return nil, "", err
+	}
+	signed.Extra = extra
+
+	signature, err := signCanonical(context.Background(), &signed, KeySigner{Key: key})
+	if err != nil {
+		return nil, "", err
+	}
+	return &signed, signature, nil
+}
+
+// VerifyRequirementsSignature checks signature against requirements and
+// returns the signer, which must match the signer named in Extra
+func VerifyRequirementsSignature(requirements *PaymentRequirements, signature string) (common.Address, error) {
+	extra, err := ParseExtra(requirements.Extra)
+	if err != nil {
+		return common.Address{}, err
+	}
+	named := extra.RequirementsSigner
+	if !common.IsHexAddress(named) {
+		return common.Address{}, errors.New("requirements do not name a signer")
+	}
+
+	signer, err := recoverCanonical(requirements, signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid requirements signature: %w", err)
+	}
+	if signer != common.HexToAddress(named) {
+		return common.Address{}, fmt.Errorf("requirements signed by %s, not the named signer %s", signer.Hex(), named)
+	}
+	return signer, nil
+}
+
+// signCanonical signs the EIP-191 hash of v's canonical JSON (hex, 65 bytes, V of 27/28)
+func signCanonical(ctx context.Context, v interface{}, signer HashSigner) (string, error) {
+	canonical, err := CanonicalJSON(v)
+	if err != nil {
+		return "", err
+	}
+	sig, err := signer.SignHash(ctx, accounts.TextHash(canonical))
+	if err != nil {
+		return "", err
+	}
+	if len(sig) != 65 {
+		return "", errors.New("signer returned a malformed signature")
+	}
+	sig[64] += 27
+	return "0x" + hex.EncodeToString(sig), nil
+}
+
+// recoverCanonical returns the address that produced signature over v (see signCanonical)
+func recoverCanonical(v interface{}, signature string) (common.Address, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return common.Address{}, errors.New("malformed signature")
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	canonical, err := CanonicalJSON(v)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pubKey, err := crypto.SigToPub(accounts.TextHash(canonical), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
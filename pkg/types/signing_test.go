@@ -0,0 +1,94 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestCanonicalJSONIsStableAcrossFieldOrder(t *testing.T) {
+	a, err := CanonicalizeJSON([]byte(`{"b": 1, "a": {"y": "<tag>", "x": 1.50}, "c": [3, 1]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := CanonicalizeJSON([]byte("{\n\t\"c\": [3,1], \"a\": {\"x\": 1.50, \"y\": \"<tag>\"}, \"b\": 1}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"a":{"x":1.50,"y":"<tag>"},"b":1,"c":[3,1]}`
+	if string(a) != want || string(b) != want {
+		t.Errorf("canonical forms %s and %s, want %s", a, b, want)
+	}
+	if _, err := CanonicalizeJSON([]byte(`{"a":`)); err == nil {
+		t.Error("malformed JSON was canonicalized")
+	}
+}
+
+func testRequirements() *PaymentRequirements {
+	return &PaymentRequirements{
+		Scheme:            SchemeExact,
+		Network:           NetworkBaseSepolia,
+		PayTo:             "0x00000000000000000000000000000000000000b0",
+		MaxAmountRequired: "1000",
+		Asset:             common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e"),
+		MaxTimeoutSeconds: 60,
+	}
+}
+
+func TestSignRequirementsRoundTrip(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, signature, err := SignRequirements(testRequirements(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := VerifyRequirementsSignature(signed, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := crypto.PubkeyToAddress(key.PublicKey); signer != want {
+		t.Errorf("recovered %s, want %s", signer.Hex(), want.Hex())
+	}
+	if extra, _ := ParseExtra(signed.Extra); extra.RequirementsSigner != signer.Hex() {
+		t.Errorf("Extra names %q, want the signer", extra.RequirementsSigner)
+	}
+}
+
+func TestVerifyRequirementsSignatureDetectsTampering(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	signed, signature, err := SignRequirements(testRequirements(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tamperedPayTo := *signed
+	tamperedPayTo.PayTo = "0x00000000000000000000000000000000000000e1"
+
+	// An attacker re-signing with their own key must also rename the signer
+	resigned, resignature, err := SignRequirements(&tamperedPayTo, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := *resigned
+	swapped.Extra = signed.Extra
+
+	for name, tc := range map[string]struct {
+		requirements *PaymentRequirements
+		signature    string
+		err          string
+	}{
+		"tampered payTo":      {&tamperedPayTo, signature, "not the named signer"},
+		"signer name swapped": {&swapped, resignature, "not the named signer"},
+		"unsigned":            {testRequirements(), signature, "do not name a signer"},
+		"malformed signature": {signed, "0x1234", "malformed signature"},
+	} {
+		if _, err := VerifyRequirementsSignature(tc.requirements, tc.signature); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: error %v, want %q", name, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// base58Alphabet is the Bitcoin/Solana base58 alphabet
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// DecodeBase58 decodes a base58 string, rejecting characters outside the alphabet
+func DecodeBase58(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty base58 string")
+	}
+
+	// Big-endian base conversion, one input digit at a time
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base58Alphabet, s[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q at position %d", s[i], i)
+		}
+		carry := digit
+		for j := len(out) - 1; j >= 0; j-- {
+			carry += int(out[j]) * 58
+			out[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			out = append([]byte{byte(carry)}, out...)
+			carry >>= 8
+		}
+	}
+
+	// Each leading '1' encodes a leading zero byte
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	return append(make([]byte, zeros), out...), nil
+}
+
+// ValidateSolanaAddress checks that addr is a base58 encoded 32-byte public key
+func ValidateSolanaAddress(addr string) error {
+	raw, err := DecodeBase58(addr)
+	if err != nil {
+		return fmt.Errorf("invalid solana address: %w", err)
+	}
+	if len(raw) != 32 {
+		return fmt.Errorf("invalid solana address: decodes to %d bytes, want 32", len(raw))
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a MixedAddress, validating Solana addresses and
+// converting xdc-prefixed EVM addresses to 0x
+func (m *MixedAddress) UnmarshalJSON(data []byte) error {
+	type plain MixedAddress
+	var addr plain
+	if err := json.Unmarshal(data, &addr); err != nil {
+		return err
+	}
+	switch addr.Type {
+	case "solana":
+		if err := ValidateSolanaAddress(addr.Address); err != nil {
+			return err
+		}
+	case "evm":
+		addr.Address = NormalizeEVMAddress(addr.Address)
+	}
+	*m = MixedAddress(addr)
+	return nil
+}
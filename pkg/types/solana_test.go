@@ -0,0 +1,75 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+const (
+	solanaUSDCMint = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	devnetUSDCMint = "4zMMC9srt5Ri5X14GAgXhaHii3GnPAEERYPJgZJDncDU"
+)
+
+func TestDecodeBase58(t *testing.T) {
+	for input, want := range map[string][]byte{
+		"1":    {0},
+		"11":   {0, 0},
+		"2":    {1},
+		"z":    {57},
+		"21":   {58},
+		"5Q":   {0xff},
+		"5R":   {0x01, 0x00},
+		"LUv":  {0xff, 0xff},
+		"1LUv": {0, 0xff, 0xff},
+	} {
+		got, err := DecodeBase58(input)
+		if err != nil {
+			t.Errorf("%q: %v", input, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%q decodes to %x, want %x", input, got, want)
+		}
+	}
+	for _, input := range []string{"", "0", "O", "I", "l", "abc+"} {
+		if _, err := DecodeBase58(input); err == nil {
+			t.Errorf("%q decoded, want an error", input)
+		}
+	}
+}
+
+func TestNewSolanaAddress(t *testing.T) {
+	for _, mint := range []string{solanaUSDCMint, devnetUSDCMint, "11111111111111111111111111111111"} {
+		addr, err := NewSolanaAddress(mint)
+		if err != nil || addr.Type != "solana" || addr.Address != mint {
+			t.Errorf("%s: %+v, %v", mint, addr, err)
+		}
+	}
+	for name, input := range map[string]string{
+		"EVM address":       "0x00000000000000000000000000000000000000b0",
+		"not base58":        "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt10",
+		"too short":         "EPjFWdd5AufqSSqeM2qN1xzyb",
+		"too long":          solanaUSDCMint + "EPjF",
+		"empty":             "",
+		"surrounding space": " " + solanaUSDCMint,
+	} {
+		if _, err := NewSolanaAddress(input); err == nil {
+			t.Errorf("%s: %q accepted", name, input)
+		}
+	}
+}
+
+func TestMixedAddressJSONValidatesSolana(t *testing.T) {
+	var addr MixedAddress
+	if err := json.Unmarshal([]byte(`{"type":"solana","address":"`+devnetUSDCMint+`"}`), &addr); err != nil || addr.Address != devnetUSDCMint {
+		t.Errorf("valid mint: %+v, %v", addr, err)
+	}
+	if err := json.Unmarshal([]byte(`{"type":"solana","address":"0x036CbD53842c5426634e7929541eC2318f3dCF7e"}`), &addr); err == nil {
+		t.Error("an EVM address decoded as a Solana address")
+	}
+	// Other address types are not base58-checked
+	if err := json.Unmarshal([]byte(`{"type":"offchain","address":"invoice-17"}`), &addr); err != nil || addr.Address != "invoice-17" {
+		t.Errorf("offchain address: %+v, %v", addr, err)
+	}
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PayoutSplit sends a share (in basis points) of a payment to one recipient
+// Splits are carried in PaymentRequirements.Extra under "splits"; PayTo must
+// then be the facilitator-operated splitter contract for the network
+type PayoutSplit struct {
+	To  common.Address `json:"to"`
+	Bps int64          `json:"bps"`
+}
+
+// ParseSplits reads and validates the payout splits from requirements Extra
+// Returns nil (and no error) when no splits are present
+func ParseSplits(extra json.RawMessage) ([]PayoutSplit, error) {
+	var splits []PayoutSplit
+	found, err := GetExtraField(extra, SplitsExtraKey, &splits)
+	if err != nil || !found {
+		return nil, err
+	}
+	if err := ValidateSplits(splits); err != nil {
+		return nil, err
+	}
+	return splits, nil
+}
+
+// ValidateSplits checks recipients are set and shares sum to exactly 10000 bps
+func ValidateSplits(splits []PayoutSplit) error {
+	if len(splits) == 0 {
+		return fmt.Errorf("splits must not be empty")
+	}
+	var total int64
+	for i, split := range splits {
+		if split.To == (common.Address{}) {
+			return fmt.Errorf("split %d: recipient must not be the zero address", i)
+		}
+		if split.Bps <= 0 {
+			return fmt.Errorf("split %d: bps must be positive", i)
+		}
+		total += split.Bps
+	}
+	if total != 10000 {
+		return fmt.Errorf("split bps must sum to 10000, got %d", total)
+	}
+	return nil
+}
+
+// SplitAmounts divides total according to the splits
+// Each share is rounded down; the rounding remainder goes to the first recipient
+func SplitAmounts(total *big.Int, splits []PayoutSplit) []*big.Int {
+	amounts := make([]*big.Int, len(splits))
+	distributed := new(big.Int)
+	for i, split := range splits {
+		share := new(big.Int).Mul(total, big.NewInt(split.Bps))
+		share.Quo(share, big.NewInt(10000))
+		amounts[i] = share
+		distributed.Add(distributed, share)
+	}
+	if len(amounts) > 0 {
+		amounts[0].Add(amounts[0], new(big.Int).Sub(total, distributed))
+	}
+	return amounts
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	platform = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	seller   = common.HexToAddress("0x00000000000000000000000000000000000000a2")
+)
+
+func TestValidateSplits(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		splits  []PayoutSplit
+		wantErr string
+	}{
+		{"two recipients", []PayoutSplit{{platform, 1000}, {seller, 9000}}, ""},
+		{"single recipient", []PayoutSplit{{seller, 10000}}, ""},
+		{"empty", nil, "must not be empty"},
+		{"under 10000", []PayoutSplit{{platform, 1000}, {seller, 8999}}, "sum to 10000"},
+		{"over 10000", []PayoutSplit{{platform, 1001}, {seller, 9000}}, "sum to 10000"},
+		{"zero share", []PayoutSplit{{platform, 0}, {seller, 10000}}, "positive"},
+		{"negative share", []PayoutSplit{{platform, -1000}, {seller, 11000}}, "positive"},
+		{"zero address", []PayoutSplit{{common.Address{}, 10000}}, "zero address"},
+	} {
+		err := ValidateSplits(tc.splits)
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("%s: error %v, want one mentioning %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestParseSplits(t *testing.T) {
+	extra := json.RawMessage(`{"name":"USDC","splits":[{"to":"` + platform.Hex() + `","bps":250},{"to":"` + seller.Hex() + `","bps":9750}]}`)
+	splits, err := ParseSplits(extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(splits) != 2 || splits[0] != (PayoutSplit{platform, 250}) || splits[1] != (PayoutSplit{seller, 9750}) {
+		t.Errorf("parsed splits %+v", splits)
+	}
+
+	if splits, err := ParseSplits(json.RawMessage(`{"name":"USDC"}`)); splits != nil || err != nil {
+		t.Errorf("no splits: got %+v, %v; want nil", splits, err)
+	}
+	for _, bad := range []string{
+		`{"splits":[{"to":"` + seller.Hex() + `","bps":9999}]}`,
+		`{"splits":"everything to the seller"}`,
+	} {
+		if _, err := ParseSplits(json.RawMessage(bad)); err == nil {
+			t.Errorf("ParseSplits(%s) accepted invalid splits", bad)
+		}
+	}
+}
+
+func TestSplitAmounts(t *testing.T) {
+	for _, tc := range []struct {
+		total  int64
+		splits []PayoutSplit
+		want   []int64
+	}{
+		{10000, []PayoutSplit{{platform, 1000}, {seller, 9000}}, []int64{1000, 9000}},
+		// 333 * 0.25 = 83.25 and 333 * 0.75 = 249.75: the unit lost to rounding goes first
+		{333, []PayoutSplit{{platform, 2500}, {seller, 7500}}, []int64{84, 249}},
+		{1, []PayoutSplit{{platform, 5000}, {seller, 5000}}, []int64{1, 0}},
+		{100, []PayoutSplit{{platform, 3333}, {seller, 3333}, {common.HexToAddress("0xa3"), 3334}}, []int64{34, 33, 33}},
+	} {
+		amounts := SplitAmounts(big.NewInt(tc.total), tc.splits)
+		sum := new(big.Int)
+		for i, amount := range amounts {
+			sum.Add(sum, amount)
+			if amount.Int64() != tc.want[i] {
+				t.Errorf("total %d: share %d = %s, want %d", tc.total, i, amount, tc.want[i])
+			}
+		}
+		if sum.Int64() != tc.total {
+			t.Errorf("total %d: shares sum to %s", tc.total, sum)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package types
+
+import "github.com/ethereum/go-ethereum/common"
+
+// RequirementsHeaderRef is the X-Payment-Required value used when even the
+// summary is too large for a header; the requirements are only in the body
+const RequirementsHeaderRef = "see body"
+
+// RequirementsSummary is the compact form of PaymentRequirements carried in
+// the X-Payment-Required header; the full document is in the 402 body.
+// v1 summaries set MaxAmountRequired and a network name, v2 summaries set
+// Amount and a CAIP-2 network id.
+type RequirementsSummary struct {
+	Scheme            Scheme `json:"scheme"`
+	Network           string `json:"network"`
+	MaxAmountRequired string `json:"maxAmountRequired,omitempty"`
+	Amount            string `json:"amount,omitempty"`
+	Asset             string `json:"asset"`
+	PayTo             string `json:"payTo"`
+	MaxTimeoutSeconds int    `json:"maxTimeoutSeconds,omitempty"`
+	MinTimeoutSeconds int    `json:"minTimeoutSeconds,omitempty"`
+}
+
+// Summary returns the header summary of the requirements in the given wire version
+func (r *PaymentRequirements) Summary(version int) RequirementsSummary {
+	summary := RequirementsSummary{
+		Scheme:            r.Scheme,
+		Network:           string(r.Network),
+		MaxAmountRequired: r.MaxAmountRequired,
+		Asset:             r.Asset.Hex(),
+		PayTo:             r.PayTo,
+		MaxTimeoutSeconds: r.MaxTimeoutSeconds,
+		MinTimeoutSeconds: r.MinTimeoutSeconds,
+	}
+	if version == 2 {
+		summary.Network = r.Network.CAIP2()
+		summary.Amount = r.MaxAmountRequired
+		summary.MaxAmountRequired = ""
+	}
+	return summary
+}
+
+// MergeFallback fills fields of r left empty from fallback, e.g. the
+// header's summary when the body's requirements are incomplete
+func (r *PaymentRequirements) MergeFallback(fallback *PaymentRequirements) {
+	if r.Scheme == "" {
+		r.Scheme = fallback.Scheme
+	}
+	if r.Network == "" {
+		r.Network = fallback.Network
+	}
+	if r.MaxAmountRequired == "" {
+		r.MaxAmountRequired = fallback.MaxAmountRequired
+	}
+	if r.Asset == (common.Address{}) {
+		r.Asset = fallback.Asset
+	}
+	if r.PayTo == "" {
+		r.PayTo = fallback.PayTo
+	}
+	if r.MaxTimeoutSeconds == 0 {
+		r.MaxTimeoutSeconds = fallback.MaxTimeoutSeconds
+	}
+	if r.MinTimeoutSeconds == 0 {
+		r.MinTimeoutSeconds = fallback.MinTimeoutSeconds
+	}
+	if len(r.Extra) == 0 {
+		r.Extra = fallback.Extra
+	}
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SupportedFilter narrows a list of supported payment kinds; zero fields
+// match everything. GET /supported takes the same filters as query
+// parameters (network, scheme, token_symbol, settleable).
+type SupportedFilter struct {
+	Network     Network `json:"network,omitempty"`
+	Scheme      Scheme  `json:"scheme,omitempty"`
+	TokenSymbol string  `json:"token_symbol,omitempty"` // Case-insensitive
+	Settleable  *bool   `json:"settleable,omitempty"`   // Kinds that can (or cannot) settle
+}
+
+// IsZero reports whether the filter matches every kind
+func (f SupportedFilter) IsZero() bool {
+	return f.Network == "" && f.Scheme == "" && f.TokenSymbol == "" && f.Settleable == nil
+}
+
+// MatchesNetwork reports whether kinds on net can match the filter
+func (f SupportedFilter) MatchesNetwork(net Network) bool {
+	return f.Network == "" || f.Network == net
+}
+
+// Matches reports whether kind passes the filter
+func (f SupportedFilter) Matches(kind SupportedPaymentKind) bool {
+	return f.MatchesNetwork(kind.Network) &&
+		(f.Scheme == "" || f.Scheme == kind.Scheme) &&
+		(f.TokenSymbol == "" || strings.EqualFold(f.TokenSymbol, kind.TokenSymbol)) &&
+		(f.Settleable == nil || *f.Settleable == kind.Settlement)
+}
+
+// Filter returns the kinds of r that pass f, with f echoed in Filters
+// (r itself if f is zero)
+func (r *SupportedPaymentKindsResponse) Filter(f SupportedFilter) *SupportedPaymentKindsResponse {
+	if f.IsZero() {
+		return r
+	}
+	filtered := &SupportedPaymentKindsResponse{Kinds: []SupportedPaymentKind{}, Filters: &f}
+	for _, kind := range r.Kinds {
+		if f.Matches(kind) {
+			filtered.Kinds = append(filtered.Kinds, kind)
+		}
+	}
+	return filtered
+}
+
+// ParseSupportedFilter reads a filter from the query parameters of
+// GET /supported; network (a name or CAIP-2 ID) and scheme are
+// case-insensitive. Values naming nothing the facilitator supports are
+// kept, so they match no kind.
+func ParseSupportedFilter(query url.Values) (SupportedFilter, error) {
+	f := SupportedFilter{
+		Network:     Network(strings.ToLower(strings.TrimSpace(query.Get("network")))),
+		Scheme:      Scheme(strings.ToLower(strings.TrimSpace(query.Get("scheme")))),
+		TokenSymbol: strings.TrimSpace(query.Get("token_symbol")),
+	}
+	if net, err := ParseNetworkID(string(f.Network)); err == nil {
+		f.Network = net
+	}
+	if raw := strings.TrimSpace(query.Get("settleable")); raw != "" {
+		settleable, err := strconv.ParseBool(raw)
+		if err != nil {
+			return f, fmt.Errorf("invalid settleable %q: want true or false", raw)
+		}
+		f.Settleable = &settleable
+	}
+	return f, nil
+}
+
+// Query returns the filter as GET /supported query parameters
+func (f SupportedFilter) Query() url.Values {
+	query := url.Values{}
+	if f.Network != "" {
+		query.Set("network", string(f.Network))
+	}
+	if f.Scheme != "" {
+		query.Set("scheme", string(f.Scheme))
+	}
+	if f.TokenSymbol != "" {
+		query.Set("token_symbol", f.TokenSymbol)
+	}
+	if f.Settleable != nil {
+		query.Set("settleable", strconv.FormatBool(*f.Settleable))
+	}
+	return query
+}
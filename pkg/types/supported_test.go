@@ -0,0 +1,73 @@
+package types
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseSupportedFilter(t *testing.T) {
+	f, err := ParseSupportedFilter(url.Values{
+		"network":      {" eip155:8453 "},
+		"scheme":       {"EXACT"},
+		"token_symbol": {"usdc"},
+		"settleable":   {"true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Network != NetworkBase || f.Scheme != SchemeExact || f.TokenSymbol != "usdc" || f.Settleable == nil || !*f.Settleable {
+		t.Errorf("filter %+v, want base, exact, usdc and settleable", f)
+	}
+	again, err := ParseSupportedFilter(f.Query())
+	if err != nil || again.Network != f.Network || again.Scheme != f.Scheme || again.TokenSymbol != f.TokenSymbol || *again.Settleable != *f.Settleable {
+		t.Errorf("round trip through %v gave %+v (%v)", f.Query(), again, err)
+	}
+
+	if f, err := ParseSupportedFilter(url.Values{"network": {"Atlantis"}}); err != nil || f.Network != "atlantis" {
+		t.Errorf("unknown network parsed as %+v (%v), want it kept to match nothing", f, err)
+	}
+	if _, err := ParseSupportedFilter(url.Values{"settleable": {"maybe"}}); err == nil {
+		t.Error("settleable=maybe parsed")
+	}
+	if f, err := ParseSupportedFilter(url.Values{}); err != nil || !f.IsZero() || len(f.Query()) != 0 {
+		t.Errorf("empty query parsed as %+v (%v), want the zero filter", f, err)
+	}
+}
+
+func TestSupportedResponseFilter(t *testing.T) {
+	resp := &SupportedPaymentKindsResponse{Kinds: []SupportedPaymentKind{
+		{Scheme: SchemeExact, Network: NetworkBase, TokenSymbol: "USDC", Settlement: true},
+		{Scheme: SchemePermit2, Network: NetworkBase, TokenSymbol: "USDC", Settlement: true},
+		{Scheme: SchemeExact, Network: NetworkBaseSepolia, TokenSymbol: "EURC", Settlement: false},
+	}}
+	if got := resp.Filter(SupportedFilter{}); got != resp {
+		t.Error("the zero filter copied the response")
+	}
+
+	yes, no := true, false
+	for _, tc := range []struct {
+		name   string
+		filter SupportedFilter
+		want   int
+	}{
+		{"network", SupportedFilter{Network: NetworkBase}, 2},
+		{"scheme", SupportedFilter{Scheme: SchemeExact}, 2},
+		{"token symbol", SupportedFilter{TokenSymbol: "eurc"}, 1},
+		{"settleable", SupportedFilter{Settleable: &yes}, 2},
+		{"not settleable", SupportedFilter{Settleable: &no}, 1},
+		{"network and scheme", SupportedFilter{Network: NetworkBase, Scheme: SchemePermit2}, 1},
+		{"conflicting", SupportedFilter{Network: NetworkBase, TokenSymbol: "EURC"}, 0},
+		{"unknown network", SupportedFilter{Network: "atlantis"}, 0},
+	} {
+		got := resp.Filter(tc.filter)
+		if got.Kinds == nil || len(got.Kinds) != tc.want {
+			t.Errorf("%s: %d kinds (%v), want %d", tc.name, len(got.Kinds), got.Kinds, tc.want)
+		}
+		if got.Filters == nil || *got.Filters != tc.filter {
+			t.Errorf("%s: echoed filters %+v, want %+v", tc.name, got.Filters, tc.filter)
+		}
+	}
+	if len(resp.Kinds) != 3 {
+		t.Errorf("filtering changed the response to %d kinds", len(resp.Kinds))
+	}
+}
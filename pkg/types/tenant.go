@@ -0,0 +1,20 @@
+package types
+
+import "context"
+
+// HeaderTenantID names the tenant of a shared facilitator a request is made for
+const HeaderTenantID = "X-Tenant-ID"
+
+type tenantContextKey struct{}
+
+// WithTenantID tags ctx with the tenant the request is served for
+func WithTenantID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, id)
+}
+
+// TenantIDFromContext returns the tenant set by WithTenantID, or "" for
+// requests outside any tenant
+func TenantIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(tenantContextKey{}).(string)
+	return id
+}
@@ -13,6 +13,7 @@ type X402Version string
 
 const (
 	X402VersionV1 X402Version = "1"
+	X402VersionV2 X402Version = "2"
 )
 
 // Scheme represents the payment scheme
@@ -20,23 +21,30 @@ type Scheme string
 
 const (
 	SchemeExact Scheme = "exact"
+	// SchemePermit2 pays any ERC-20 the payer approved to Permit2 with a
+	// SignatureTransfer (see Permit2Payload)
+	SchemePermit2 Scheme = "permit2"
 )
 
 // Network represents supported blockchain networks
 type Network string
 
 const (
-	NetworkBaseSepolia   Network = "base-sepolia"
-	NetworkBase          Network = "base"
-	NetworkAvalancheFuji Network = "avalanche-fuji"
-	NetworkAvalanche     Network = "avalanche"
-	NetworkPolygonAmoy   Network = "polygon-amoy"
-	NetworkPolygon       Network = "polygon"
-	NetworkSei           Network = "sei"
-	NetworkSeiTestnet    Network = "sei-testnet"
-	NetworkXDC           Network = "xdc"
-	NetworkSolana        Network = "solana"
-	NetworkSolanaDevnet  Network = "solana-devnet"
+	NetworkBaseSepolia     Network = "base-sepolia"
+	NetworkBase            Network = "base"
+	NetworkAvalancheFuji   Network = "avalanche-fuji"
+	NetworkAvalanche       Network = "avalanche"
+	NetworkPolygonAmoy     Network = "polygon-amoy"
+	NetworkPolygon         Network = "polygon"
+	NetworkSei             Network = "sei"
+	NetworkSeiTestnet      Network = "sei-testnet"
+	NetworkXDC             Network = "xdc"
+	NetworkArbitrum        Network = "arbitrum"
+	NetworkArbitrumSepolia Network = "arbitrum-sepolia"
+	NetworkOptimism        Network = "optimism"
+	NetworkOptimismSepolia Network = "optimism-sepolia"
+	NetworkSolana          Network = "solana"
+	NetworkSolanaDevnet    Network = "solana-devnet"
 )
 
 // MixedAddress represents an address on any supported chain
@@ -54,11 +62,15 @@ func NewEvmAddress(addr common.Address) MixedAddress {
 }
 
 // NewSolanaAddress creates a new Solana address
-func NewSolanaAddress(addr string) MixedAddress {
+// addr must be base58 encoding exactly 32 bytes
+func NewSolanaAddress(addr string) (MixedAddress, error) {
+	if err := ValidateSolanaAddress(addr); err != nil {
+		return MixedAddress{}, err
+	}
 	return MixedAddress{
 		Type:    "solana",
 		Address: addr,
-	}
+	}, nil
 }
 
 // NewOffchainAddress creates a new off-chain address
@@ -80,9 +92,11 @@ type PaymentRequirements struct {
 	Description       string          `json:"description"`
 	MimeType          string          `json:"mimeType"`
 	MaxTimeoutSeconds int             `json:"maxTimeoutSeconds"`
+	MinTimeoutSeconds int             `json:"minTimeoutSeconds,omitempty"` // Shortest validity window accepted (0: the facilitator's default)
 	Asset             common.Address  `json:"asset"`
 	OutputSchema      json.RawMessage `json:"outputSchema"`
 	Extra             json.RawMessage `json:"extra"`
+	AllowZeroAmount   bool            `json:"allowZeroAmount,omitempty"` // Free-but-authenticated resources
 }
 
 // ExactEvmPayloadAuthorization represents EIP-712 transfer authorization data
@@ -92,13 +106,14 @@ type ExactEvmPayloadAuthorization struct {
 	Value       string         `json:"value"`
 	ValidAfter  string         `json:"validAfter"`
 	ValidBefore string         `json:"validBefore"`
-	Nonce       string         `json:"nonce"` // hex-encoded
+	Nonce       HexBytes       `json:"nonce"` // bytes32
 }
 
 // ExactEvmPayload contains the EVM payment payload
 type ExactEvmPayload struct {
-	Signature     string                       `json:"signature"` // hex-encoded
+	Signature     HexBytes                     `json:"signature"`
 	Authorization ExactEvmPayloadAuthorization `json:"authorization"`
+	Permit2       *Permit2Payload              `json:"permit2,omitempty"` // permit2 scheme only
 }
 
 // ExactSolanaPayload contains the Solana payment payload
@@ -118,6 +133,7 @@ type PaymentPayload struct {
 	Scheme      Scheme          `json:"scheme"`
 	Network     Network         `json:"network"`
 	Payload     ExactEvmPayload `json:"payload"`
+	Extra       json.RawMessage `json:"extra,omitempty"` // Echo of the requirements' extra (challenge mode)
 }
 
 // VerifyRequest is the request to verify a payment
@@ -131,30 +147,65 @@ type VerifyRequest struct {
 type SettleRequest struct {
 	PaymentPayload      PaymentPayload      `json:"paymentPayload"`
 	PaymentRequirements PaymentRequirements `json:"paymentRequirements"`
+	VerificationID      string              `json:"verificationId,omitempty"` // From a recent VerifyResponse
+
+	// Time left before validBefore below which the facilitator refuses to
+	// submit, overriding its own inclusion margin (0 submits anything still
+	// valid); for callers who accept the risk of a late, reverted settlement
+	InclusionMarginSeconds *int64 `json:"inclusionMarginSeconds,omitempty"`
 }
 
 // VerifyResponse is the response from payment verification
 type VerifyResponse struct {
-	IsValid  bool          `json:"isValid"`
-	Payer  *MixedAddress `json:"payer,omitempty"`
-	Reason string        `json:"reason,omitempty"`
+	IsValid    bool          `json:"isValid"`
+	Payer      *MixedAddress `json:"payer,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+	ReasonCode ReasonCode    `json:"reasonCode,omitempty"`
+
+	// Opaque, short-lived token for a valid payload; passing it back in
+	// SettleRequest spares the facilitator a second full verification
+	VerificationID string `json:"verificationId,omitempty"`
+
+	// ID the payment is settled and journaled under (see HeaderIdempotencyKey)
+	SettlementID string `json:"settlementId,omitempty"`
 }
 
 // NewValidResponse creates a successful verification response
 func NewValidResponse(payer MixedAddress) VerifyResponse {
 	return VerifyResponse{
 		IsValid: true,
-		Payer: &payer,
+		Payer:   &payer,
 	}
 }
 
 // NewInvalidResponse creates a failed verification response
 func NewInvalidResponse(reason string, payer *MixedAddress) VerifyResponse {
 	return VerifyResponse{
-		IsValid:  false,
-		Reason: reason,
-		Payer:  payer,
+		IsValid: false,
+		Reason:  reason,
+		Payer:   payer,
+	}
+}
+
+// NewInvalidResponseFromError creates a failed verification response from a FacilitatorError
+func NewInvalidResponseFromError(err *FacilitatorError) VerifyResponse {
+	return VerifyResponse{
+		IsValid:    false,
+		Reason:     err.Message,
+		ReasonCode: err.Code,
+		Payer:      err.Payer,
+	}
+}
+
+// PayerFromPayload returns the payer named by the payload's authorization,
+// so a refusal can report it whatever check failed; nil when the
+// authorization carries no from address
+func PayerFromPayload(payload *PaymentPayload) *MixedAddress {
+	if payload == nil || payload.Payload.Authorization.From == (common.Address{}) {
+		return nil
 	}
+	payer := NewEvmAddress(payload.Payload.Authorization.From)
+	return &payer
 }
 
 // TransactionHash represents a transaction hash on any chain
@@ -165,23 +216,67 @@ type TransactionHash struct {
 
 // SettleResponse is the response from payment settlement
 type SettleResponse struct {
-	Success         bool             `json:"success"`
-	TransactionHash *TransactionHash `json:"transaction_hash,omitempty"`
-	Error           string           `json:"error,omitempty"`
+	Success              bool             `json:"success"`
+	TransactionHash      *TransactionHash `json:"transaction_hash,omitempty"`
+	SplitTransactionHash *TransactionHash `json:"split_transaction_hash,omitempty"` // Payout distribution for split payments
+	Error                string           `json:"error,omitempty"`
+	ReasonCode           ReasonCode       `json:"reason_code,omitempty"`
+	RevertCode           string           `json:"revert_code,omitempty"` // FacilitatorError type of the on-chain revert
+	GasUsed              uint64           `json:"gas_used,omitempty"`
+	EffectiveGasPrice    string           `json:"effective_gas_price,omitempty"` // wei
+	AmountPolicy         string           `json:"amount_policy,omitempty"`       // Settlement amount policy applied (EVM)
+	Receipt              *PaymentReceipt  `json:"receipt,omitempty"`
+	ReceiptSignature     string           `json:"receipt_signature,omitempty"` // See PaymentReceipt.Sign
+	SettlementID         string           `json:"settlement_id,omitempty"`     // See HeaderIdempotencyKey
+}
+
+// SimulateResponse is the response from a settlement dry run
+type SimulateResponse struct {
+	Valid        bool          `json:"valid"`            // Verification outcome
+	Reason       string        `json:"reason,omitempty"` // Why verification failed
+	ReasonCode   ReasonCode    `json:"reason_code,omitempty"`
+	Payer        *MixedAddress `json:"payer,omitempty"`
+	EstimatedGas uint64        `json:"estimated_gas,omitempty"`
+	GasPrice     string        `json:"gas_price,omitempty"`     // wei
+	EstimatedFee string        `json:"estimated_fee,omitempty"` // wei of native token
+	Reverted     bool          `json:"reverted"`
+	RevertReason string        `json:"revert_reason,omitempty"`
+	RevertCode   string        `json:"revert_code,omitempty"`
 }
 
 // SupportedPaymentKind represents a supported payment type
 type SupportedPaymentKind struct {
-	Version     X402Version  `json:"version"`
-	Scheme      Scheme       `json:"scheme"`
-	Network     Network      `json:"network"`
-	Token       MixedAddress `json:"token"`
-	TokenSymbol string       `json:"token_symbol"`
+	Version      X402Version     `json:"version"`
+	Scheme       Scheme          `json:"scheme"`
+	Network      Network         `json:"network"`
+	Token        MixedAddress    `json:"token"`
+	TokenSymbol  string          `json:"token_symbol"`
+	Decimals     uint8           `json:"decimals,omitempty"`
+	X402Versions []int           `json:"x402Versions"`      // Wire versions accepted for this kind
+	Fee          *FacilitatorFee `json:"fee,omitempty"`     // Surcharge required on top of the resource price
+	Healthy      *bool           `json:"healthy,omitempty"` // False while settlement signers are low on gas (nil if not monitored)
+	Settlement   bool            `json:"settlement"`        // False on verify-only facilitators, which cannot settle this kind
+	Extra        json.RawMessage `json:"extra,omitempty"`   // Scheme parameters, e.g. the permit2 spender (see Permit2Extra)
+}
+
+// FacilitatorFee advertises a facilitator surcharge (amounts in the token's smallest unit)
+type FacilitatorFee struct {
+	FlatAmount  string `json:"flatAmount"`
+	BasisPoints int64  `json:"basisPoints"`
+}
+
+// FeeBreakdown splits a payment amount into resource price and facilitator fee
+// It is carried in PaymentRequirements.Extra under "feeBreakdown"
+type FeeBreakdown struct {
+	ResourceAmount string `json:"resourceAmount"`
+	FacilitatorFee string `json:"facilitatorFee"`
+	Total          string `json:"total"`
 }
 
 // SupportedPaymentKindsResponse lists all supported payment kinds
 type SupportedPaymentKindsResponse struct {
-	Kinds []SupportedPaymentKind `json:"kinds"`
+	Kinds   []SupportedPaymentKind `json:"kinds"`
+	Filters *SupportedFilter       `json:"filters,omitempty"` // The filters applied to Kinds, if any
 }
 
 // Error types
@@ -189,6 +284,7 @@ type SupportedPaymentKindsResponse struct {
 // FacilitatorError represents errors that can occur during facilitation
 type FacilitatorError struct {
 	Type    string
+	Code    ReasonCode // Stable code carried across the HTTP boundary
 	Message string
 	Payer   *MixedAddress
 }
@@ -205,14 +301,24 @@ func (e *FacilitatorError) Error() string {
 func NewUnsupportedNetworkError(payer *MixedAddress) *FacilitatorError {
 	return &FacilitatorError{
 		Type:    "UnsupportedNetwork",
+		Code:    ReasonUnsupportedNetwork,
 		Message: "network not supported by this facilitator",
 		Payer:   payer,
 	}
 }
 
+func NewUnsupportedAssetError(asset string) *FacilitatorError {
+	return &FacilitatorError{
+		Type:    "UnsupportedAsset",
+		Code:    ReasonUnsupportedAsset,
+		Message: fmt.Sprintf("unsupported asset: %s (only whitelisted USDC contracts are accepted)", asset),
+	}
+}
+
 func NewNetworkMismatchError(expected, actual Network, payer *MixedAddress) *FacilitatorError {
 	return &FacilitatorError{
 		Type:    "NetworkMismatch",
+		Code:    ReasonNetworkMismatch,
 		Message: fmt.Sprintf("expected %s, got %s", expected, actual),
 		Payer:   payer,
 	}
@@ -221,14 +327,24 @@ func NewNetworkMismatchError(expected, actual Network, payer *MixedAddress) *Fac
 func NewSchemeMismatchError(expected, actual Scheme, payer *MixedAddress) *FacilitatorError {
 	return &FacilitatorError{
 		Type:    "SchemeMismatch",
+		Code:    ReasonSchemeMismatch,
 		Message: fmt.Sprintf("expected %s, got %s", expected, actual),
 		Payer:   payer,
 	}
 }
 
+func NewUnsupportedVersionError(version int) *FacilitatorError {
+	return &FacilitatorError{
+		Type:    "UnsupportedVersion",
+		Code:    ReasonUnsupportedVersion,
+		Message: fmt.Sprintf("unsupported version: %d", version),
+	}
+}
+
 func NewReceiverMismatchError(expected, actual string, payer MixedAddress) *FacilitatorError {
 	return &FacilitatorError{
 		Type:    "ReceiverMismatch",
+		Code:    ReasonReceiverMismatch,
 		Message: fmt.Sprintf("expected %s, got %s", expected, actual),
 		Payer:   &payer,
 	}
@@ -237,6 +353,25 @@ func NewReceiverMismatchError(expected, actual string, payer MixedAddress) *Faci
 func NewInvalidTimingError(payer MixedAddress, message string) *FacilitatorError {
 	return &FacilitatorError{
 		Type:    "InvalidTiming",
+		Code:    ReasonInvalidTiming,
+		Message: message,
+		Payer:   &payer,
+	}
+}
+
+func NewNotYetValidError(payer MixedAddress, message string) *FacilitatorError {
+	return &FacilitatorError{
+		Type:    "InvalidTiming",
+		Code:    ReasonNotYetValid,
+		Message: message,
+		Payer:   &payer,
+	}
+}
+
+func NewExpiredError(payer MixedAddress, message string) *FacilitatorError {
+	return &FacilitatorError{
+		Type:    "InvalidTiming",
+		Code:    ReasonExpired,
 		Message: message,
 		Payer:   &payer,
 	}
@@ -245,6 +380,7 @@ func NewInvalidTimingError(payer MixedAddress, message string) *FacilitatorError
 func NewInsufficientFundsError(payer MixedAddress) *FacilitatorError {
 	return &FacilitatorError{
 		Type:    "InsufficientFunds",
+		Code:    ReasonInsufficientFunds,
 		Message: "payer has insufficient balance",
 		Payer:   &payer,
 	}
@@ -253,22 +389,51 @@ func NewInsufficientFundsError(payer MixedAddress) *FacilitatorError {
 func NewInsufficientValueError(payer MixedAddress) *FacilitatorError {
 	return &FacilitatorError{
 		Type:    "InsufficientValue",
+		Code:    ReasonInsufficientValue,
 		Message: "payment amount less than required",
 		Payer:   &payer,
 	}
 }
 
+func NewInvalidAmountError(payer *MixedAddress, message string) *FacilitatorError {
+	return &FacilitatorError{
+		Type:    "InvalidAmount",
+		Code:    ReasonInvalidAmount,
+		Message: message,
+		Payer:   payer,
+	}
+}
+
 func NewInvalidSignatureError(payer MixedAddress, message string) *FacilitatorError {
 	return &FacilitatorError{
 		Type:    "InvalidSignature",
+		Code:    ReasonInvalidSignature,
 		Message: message,
 		Payer:   &payer,
 	}
 }
 
+func NewNonceAlreadyUsedError(payer MixedAddress) *FacilitatorError {
+	return &FacilitatorError{
+		Type:    "NonceAlreadyUsed",
+		Code:    ReasonNonceReused,
+		Message: "authorization nonce already used or canceled",
+		Payer:   &payer,
+	}
+}
+
+func NewTimeoutError(message string) *FacilitatorError {
+	return &FacilitatorError{
+		Type:    "Timeout",
+		Code:    ReasonTimeout,
+		Message: message,
+	}
+}
+
 func NewDecodingError(message string) *FacilitatorError {
 	return &FacilitatorError{
 		Type:    "DecodingError",
+		Code:    ReasonDecodingError,
 		Message: message,
 	}
 }
@@ -276,10 +441,30 @@ func NewDecodingError(message string) *FacilitatorError {
 func NewContractCallError(message string) *FacilitatorError {
 	return &FacilitatorError{
 		Type:    "ContractCallError",
+		Code:    ReasonContractCallError,
+		Message: message,
+	}
+}
+
+// NewServiceDegradedError refuses a request without trying it, while the
+// network's RPC is failing (see evm.WithRPCCircuitBreaker)
+func NewServiceDegradedError(message string) *FacilitatorError {
+	return &FacilitatorError{
+		Type:    "ServiceDegraded",
+		Code:    ReasonServiceDegraded,
 		Message: message,
 	}
 }
 
+func NewPolicyViolationError(payer *MixedAddress, message string) *FacilitatorError {
+	return &FacilitatorError{
+		Type:    "PolicyViolation",
+		Code:    ReasonPolicyViolation,
+		Message: message,
+		Payer:   payer,
+	}
+}
+
 // Helper functions
 
 // UnixTimestamp returns the current Unix timestamp in seconds
@@ -297,11 +482,17 @@ func (p *PaymentPayload) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// IsEVM returns true if the network is EVM-compatible
+// IsEVM returns true if the network is registered as EVM-compatible
 func (n Network) IsEVM() bool {
+	entry, ok := lookupNetwork(n)
+	return ok && entry.evm
+}
+
+// IsTestnet returns true if the network is a test network
+func (n Network) IsTestnet() bool {
 	switch n {
-	case NetworkBaseSepolia, NetworkBase, NetworkAvalancheFuji, NetworkAvalanche,
-		NetworkPolygonAmoy, NetworkPolygon, NetworkSei, NetworkSeiTestnet, NetworkXDC:
+	case NetworkBaseSepolia, NetworkAvalancheFuji, NetworkPolygonAmoy, NetworkSeiTestnet,
+		NetworkArbitrumSepolia, NetworkOptimismSepolia, NetworkSolanaDevnet:
 		return true
 	default:
 		return false
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPayerFromPayload(t *testing.T) {
+	from := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	payer := PayerFromPayload(&PaymentPayload{Payload: ExactEvmPayload{Authorization: ExactEvmPayloadAuthorization{From: from}}})
+	if payer == nil || payer.Type != "evm" || payer.Address != from.Hex() {
+		t.Errorf("payer %+v, want evm %s", payer, from.Hex())
+	}
+
+	for name, payload := range map[string]*PaymentPayload{"nil payload": nil, "no from": {}} {
+		if payer := PayerFromPayload(payload); payer != nil {
+			t.Errorf("%s: payer %+v, want none", name, payer)
+		}
+	}
+}
+
+func TestMinTimeoutCarriesAcrossFormats(t *testing.T) {
+	requirements := PaymentRequirements{
+		Scheme:            SchemeExact,
+		Network:           NetworkBaseSepolia,
+		MaxAmountRequired: "1000",
+		PayTo:             "0x00000000000000000000000000000000000000b0",
+		MaxTimeoutSeconds: 300,
+		MinTimeoutSeconds: 90,
+		Asset:             common.HexToAddress("0x036CbD53842c5426634e7929541eC2318f3dCF7e"),
+	}
+
+	v2 := requirements.ToV2()
+	back, err := v2.ToV1(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2.MinTimeoutSeconds != 90 || back.MinTimeoutSeconds != 90 {
+		t.Errorf("v2 minimum %d, back in v1 %d; want 90 both ways", v2.MinTimeoutSeconds, back.MinTimeoutSeconds)
+	}
+	for _, version := range []int{1, 2} {
+		if summary := requirements.Summary(version); summary.MinTimeoutSeconds != 90 {
+			t.Errorf("v%d summary minimum %d, want 90", version, summary.MinTimeoutSeconds)
+		}
+	}
+
+	var partial PaymentRequirements
+	partial.MergeFallback(&requirements)
+	if partial.MinTimeoutSeconds != 90 {
+		t.Errorf("merged minimum %d, want the fallback's", partial.MinTimeoutSeconds)
+	}
+	own := PaymentRequirements{MinTimeoutSeconds: 30}
+	own.MergeFallback(&requirements)
+	if own.MinTimeoutSeconds != 30 {
+		t.Errorf("merged minimum %d, want its own kept", own.MinTimeoutSeconds)
+	}
+
+	requirements.MinTimeoutSeconds = 0
+	data, err := json.Marshal(requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "minTimeoutSeconds") {
+		t.Errorf("unset minimum serialized: %s", data)
+	}
+}
@@ -0,0 +1,208 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// X402 protocol v2 wire format
+//
+// v2 renames maxAmountRequired to amount, identifies networks by CAIP-2 id
+// (e.g. "eip155:8453"), and moves resource metadata out of the requirements.
+// Internally everything is translated to the v1 structs; the version is kept
+// in PaymentPayload.X402Version so callers can tell which one was used.
+
+// SupportedX402Versions lists the wire versions this implementation accepts
+var SupportedX402Versions = []int{1, 2}
+
+// IsSupportedX402Version reports whether a payload version can be processed
+func IsSupportedX402Version(version int) bool {
+	for _, v := range SupportedX402Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceInfoV2 describes the resource being paid for (v2)
+type ResourceInfoV2 struct {
+	URL          string          `json:"url"`
+	Description  string          `json:"description,omitempty"`
+	MimeType     string          `json:"mimeType,omitempty"`
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty"` // JSON Schema of the response body
+}
+
+// PaymentRequirementsV2 is the v2 form of PaymentRequirements
+type PaymentRequirementsV2 struct {
+	Scheme            Scheme          `json:"scheme"`
+	Network           string          `json:"network"` // CAIP-2, e.g. "eip155:84532"
+	Amount            string          `json:"amount"`
+	Asset             string          `json:"asset"`
+	PayTo             string          `json:"payTo"`
+	MaxTimeoutSeconds int             `json:"maxTimeoutSeconds"`
+	MinTimeoutSeconds int             `json:"minTimeoutSeconds,omitempty"`
+	Extra             json.RawMessage `json:"extra,omitempty"`
+}
+
+// PaymentPayloadV2 is the v2 form of PaymentPayload
+type PaymentPayloadV2 struct {
+	X402Version int                   `json:"x402Version"`
+	Resource    *ResourceInfoV2       `json:"resource,omitempty"`
+	Accepted    PaymentRequirementsV2 `json:"accepted"`
+	Payload     ExactEvmPayload       `json:"payload"`
+}
+
+// VerifyRequestV2 is the v2 form of VerifyRequest (also used for settle)
+type VerifyRequestV2 struct {
+	X402Version         int                   `json:"x402Version"`
+	PaymentPayload      PaymentPayloadV2      `json:"paymentPayload"`
+	PaymentRequirements PaymentRequirementsV2 `json:"paymentRequirements"`
+	VerificationID      string                `json:"verificationId,omitempty"` // Settle only (see SettleRequest)
+}
+
+// CAIP2 returns the CAIP-2 identifier for a network (e.g. "eip155:8453")
+// from its registered chain ID; other networks are returned unchanged
+func (n Network) CAIP2() string {
+	if chainID, ok := n.ChainID(); ok {
+		return fmt.Sprintf("eip155:%d", chainID)
+	}
+	return string(n)
+}
+
+// ParseNetworkID resolves a v1 network name or the CAIP-2 identifier of a
+// registered EVM network
+func ParseNetworkID(id string) (Network, error) {
+	ref, ok := strings.CutPrefix(id, "eip155:")
+	if !ok {
+		return Network(id), nil
+	}
+	chainID, err := strconv.ParseUint(ref, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid CAIP-2 network: %s", id)
+	}
+	if network, ok := networkByChainID(chainID); ok {
+		return network, nil
+	}
+	return "", fmt.Errorf("unknown CAIP-2 network: %s", id)
+}
+
+// ToV1 translates v2 requirements to the internal representation
+func (r *PaymentRequirementsV2) ToV1(resource *ResourceInfoV2) (PaymentRequirements, error) {
+	network, err := ParseNetworkID(r.Network)
+	if err != nil {
+		return PaymentRequirements{}, err
+	}
+	if r.Asset != "" && !common.IsHexAddress(r.Asset) {
+		return PaymentRequirements{}, fmt.Errorf("invalid asset address: %s", r.Asset)
+	}
+
+	requirements := PaymentRequirements{
+		Version:           X402VersionV2,
+		Scheme:            r.Scheme,
+		Network:           network,
+		PayTo:             r.PayTo,
+		MaxAmountRequired: r.Amount,
+		MaxTimeoutSeconds: r.MaxTimeoutSeconds,
+		MinTimeoutSeconds: r.MinTimeoutSeconds,
+		Asset:             common.HexToAddress(r.Asset),
+		Extra:             r.Extra,
+	}
+	if resource != nil {
+		requirements.Resource = resource.URL
+		requirements.Description = resource.Description
+		requirements.MimeType = resource.MimeType
+		requirements.OutputSchema = resource.OutputSchema
+	}
+	return requirements, nil
+}
+
+// ToV2 translates internal requirements to the v2 wire form
+func (r *PaymentRequirements) ToV2() PaymentRequirementsV2 {
+	return PaymentRequirementsV2{
+		Scheme:            r.Scheme,
+		Network:           r.Network.CAIP2(),
+		Amount:            r.MaxAmountRequired,
+		Asset:             r.Asset.Hex(),
+		PayTo:             r.PayTo,
+		MaxTimeoutSeconds: r.MaxTimeoutSeconds,
+		MinTimeoutSeconds: r.MinTimeoutSeconds,
+		Extra:             r.Extra,
+	}
+}
+
+// ResourceInfoV2 extracts the v2 resource metadata from internal requirements
+func (r *PaymentRequirements) ResourceInfoV2() *ResourceInfoV2 {
+	outputSchema := r.OutputSchema
+	if trimmed := bytes.TrimSpace(outputSchema); len(trimmed) == 0 || string(trimmed) == "null" {
+		outputSchema = nil
+	}
+	if r.Resource == "" && r.Description == "" && r.MimeType == "" && outputSchema == nil {
+		return nil
+	}
+	return &ResourceInfoV2{
+		URL:          r.Resource,
+		Description:  r.Description,
+		MimeType:     r.MimeType,
+		OutputSchema: outputSchema,
+	}
+}
+
+// ToV1 translates a v2 payload to the internal representation
+// X402Version stays 2 so downstream code knows which wire format was used
+func (p *PaymentPayloadV2) ToV1() (PaymentPayload, error) {
+	network, err := ParseNetworkID(p.Accepted.Network)
+	if err != nil {
+		return PaymentPayload{}, err
+	}
+	return PaymentPayload{
+		X402Version: p.X402Version,
+		Scheme:      p.Accepted.Scheme,
+		Network:     network,
+		Payload:     p.Payload,
+		Extra:       p.Accepted.Extra,
+	}, nil
+}
+
+// ToV1 translates a v2 verify/settle request to the internal VerifyRequest
+func (r *VerifyRequestV2) ToV1() (*VerifyRequest, error) {
+	payload, err := r.PaymentPayload.ToV1()
+	if err != nil {
+		return nil, err
+	}
+	requirements, err := r.PaymentRequirements.ToV1(r.PaymentPayload.Resource)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifyRequest{
+		X402Version:         r.X402Version,
+		PaymentPayload:      payload,
+		PaymentRequirements: requirements,
+	}, nil
+}
+
+// DetectX402Version returns the wire version of a verify/settle request body
+// (top-level x402Version, falling back to paymentPayload.x402Version, default 1)
+func DetectX402Version(data []byte) int {
+	var probe struct {
+		X402Version    int `json:"x402Version"`
+		PaymentPayload struct {
+			X402Version int `json:"x402Version"`
+		} `json:"paymentPayload"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return 1
+	}
+	if probe.X402Version != 0 {
+		return probe.X402Version
+	}
+	if probe.PaymentPayload.X402Version != 0 {
+		return probe.PaymentPayload.X402Version
+	}
+	return 1
+}
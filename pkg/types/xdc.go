@@ -0,0 +1,95 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// XDCAddressPrefix is the prefix XDC-native wallets write in place of 0x
+const XDCAddressPrefix = "xdc"
+
+// NormalizeEVMAddress converts an xdc-prefixed address to its canonical 0x
+// form; anything else (including invalid addresses) is returned unchanged
+func NormalizeEVMAddress(addr string) string {
+	if len(addr) == 43 && strings.EqualFold(addr[:3], XDCAddressPrefix) && isHex(addr[3:]) {
+		return "0x" + addr[3:]
+	}
+	return addr
+}
+
+// FormatXDCAddress writes a 0x (or already xdc-prefixed) address with the
+// xdc prefix
+func FormatXDCAddress(addr string) string {
+	addr = NormalizeEVMAddress(addr)
+	if len(addr) == 42 && (strings.HasPrefix(addr, "0x") || strings.HasPrefix(addr, "0X")) {
+		return XDCAddressPrefix + addr[2:]
+	}
+	return addr
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// UnmarshalJSON decodes requirements, accepting xdc-prefixed payTo and asset
+func (r *PaymentRequirements) UnmarshalJSON(data []byte) error {
+	type plain PaymentRequirements
+	aux := struct {
+		*plain
+		Asset string `json:"asset"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if !r.Network.IsSolana() {
+		r.PayTo = NormalizeEVMAddress(r.PayTo)
+	}
+	if aux.Asset == "" {
+		return nil
+	}
+	return r.Asset.UnmarshalText([]byte(NormalizeEVMAddress(aux.Asset)))
+}
+
+// UnmarshalJSON decodes an authorization, accepting xdc-prefixed addresses
+func (a *ExactEvmPayloadAuthorization) UnmarshalJSON(data []byte) error {
+	type plain ExactEvmPayloadAuthorization
+	aux := struct {
+		*plain
+		From string `json:"from"`
+		To   string `json:"to"`
+	}{plain: (*plain)(a)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.From != "" {
+		if err := a.From.UnmarshalText([]byte(NormalizeEVMAddress(aux.From))); err != nil {
+			return err
+		}
+	}
+	if aux.To != "" {
+		if err := a.To.UnmarshalText([]byte(NormalizeEVMAddress(aux.To))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes alternative-format requirements, accepting
+// xdc-prefixed payTo and asset
+func (a *AlternativePaymentRequirements) UnmarshalJSON(data []byte) error {
+	type plain AlternativePaymentRequirements
+	if err := json.Unmarshal(data, (*plain)(a)); err != nil {
+		return err
+	}
+	if !Network(a.Network).IsSolana() {
+		a.PayTo = NormalizeEVMAddress(a.PayTo)
+		a.Asset = NormalizeEVMAddress(a.Asset)
+	}
+	return nil
+}
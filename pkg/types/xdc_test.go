@@ -0,0 +1,84 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	xdcPayTo = "0x00000000000000000000000000000000000000B0"
+	xdcAsset = "0x2A8E898b6242355c290E1f4Fc966b8788729A4D4"
+)
+
+func TestNormalizeEVMAddress(t *testing.T) {
+	for in, want := range map[string]string{
+		"xdc00000000000000000000000000000000000000B0": xdcPayTo,
+		"XDC00000000000000000000000000000000000000B0": xdcPayTo,
+		xdcPayTo:  xdcPayTo,
+		"xdc00b0": "xdc00b0", // Too short
+		"xdc00000000000000000000000000000000000000zz": "xdc00000000000000000000000000000000000000zz", // Not hex
+		"": "",
+		"So11111111111111111111111111111111111111112": "So11111111111111111111111111111111111111112",
+	} {
+		if got := NormalizeEVMAddress(in); got != want {
+			t.Errorf("NormalizeEVMAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+	for in, want := range map[string]string{
+		xdcPayTo: "xdc00000000000000000000000000000000000000B0",
+		"xdc00000000000000000000000000000000000000B0": "xdc00000000000000000000000000000000000000B0",
+		"not an address": "not an address",
+	} {
+		if got := FormatXDCAddress(in); got != want {
+			t.Errorf("FormatXDCAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestXDCPrefixedAddressesDecodeToCanonicalForm(t *testing.T) {
+	xdc := FormatXDCAddress
+
+	var requirements PaymentRequirements
+	if err := json.Unmarshal([]byte(`{"scheme":"exact","network":"xdc","payTo":"`+xdc(xdcPayTo)+`","asset":"`+xdc(xdcAsset)+`","maxAmountRequired":"1000"}`), &requirements); err != nil {
+		t.Fatal(err)
+	}
+	if requirements.PayTo != xdcPayTo || requirements.Asset != common.HexToAddress(xdcAsset) || requirements.MaxAmountRequired != "1000" {
+		t.Errorf("requirements %+v, want 0x payTo and asset", requirements)
+	}
+
+	var auth ExactEvmPayloadAuthorization
+	if err := json.Unmarshal([]byte(`{"from":"`+xdc(xdcAsset)+`","to":"`+xdc(xdcPayTo)+`","value":"1000"}`), &auth); err != nil {
+		t.Fatal(err)
+	}
+	if auth.From != common.HexToAddress(xdcAsset) || auth.To != common.HexToAddress(xdcPayTo) || auth.Value != "1000" {
+		t.Errorf("authorization %+v, want 0x addresses", auth)
+	}
+
+	var alternative AlternativePaymentRequirements
+	if err := json.Unmarshal([]byte(`{"network":"xdc","payTo":"`+xdc(xdcPayTo)+`","asset":"`+xdc(xdcAsset)+`"}`), &alternative); err != nil {
+		t.Fatal(err)
+	}
+	if alternative.PayTo != xdcPayTo || alternative.Asset != xdcAsset {
+		t.Errorf("alternative requirements %+v, want 0x payTo and asset", alternative)
+	}
+
+	var addr MixedAddress
+	if err := json.Unmarshal([]byte(`{"type":"evm","address":"`+xdc(xdcPayTo)+`"}`), &addr); err != nil || addr.Address != xdcPayTo {
+		t.Errorf("MixedAddress %+v (%v), want %s", addr, err, xdcPayTo)
+	}
+
+	// Solana payTo is left alone
+	if err := json.Unmarshal([]byte(`{"network":"solana-devnet","payTo":"xdc00000000000000000000000000000000000000B0"}`), &requirements); err != nil {
+		t.Fatal(err)
+	}
+	if requirements.PayTo != "xdc00000000000000000000000000000000000000B0" {
+		t.Errorf("solana payTo rewritten to %q", requirements.PayTo)
+	}
+
+	// Malformed prefixed addresses still fail to decode
+	if err := json.Unmarshal([]byte(`{"from":"xdc00b0"}`), &auth); err == nil {
+		t.Error("short xdc address decoded")
+	}
+}
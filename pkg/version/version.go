@@ -0,0 +1,93 @@
+// Package version reports which facilitator build is running
+//
+// Version, Commit and Date are set at build time:
+//
+//	go build -ldflags "-X github.com/x402-rs/x402-go/pkg/version.Version=v1.2.3 \
+//	  -X github.com/x402-rs/x402-go/pkg/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/x402-rs/x402-go/pkg/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Without them, the commit and date fall back to the VCS stamp Go embeds in
+// module builds, else "unknown".
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Build information (see the package documentation)
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Feature names reported in Info.Features
+const (
+	FeatureSettle = "settle" // At least one network can settle (not verify-only)
+	FeatureSolana = "solana" // Solana networks are advertised
+)
+
+// Info describes a facilitator build
+type Info struct {
+	Version      string   `json:"version"`
+	Commit       string   `json:"commit"`
+	BuildDate    string   `json:"buildDate"`
+	GoVersion    string   `json:"goVersion"`
+	X402Versions []int    `json:"x402Versions"`
+	Features     []string `json:"features"`
+}
+
+// Get returns the build information with the given enabled features
+func Get(features ...string) Info {
+	info := Info{
+		Version:      Version,
+		Commit:       Commit,
+		BuildDate:    Date,
+		GoVersion:    runtime.Version(),
+		X402Versions: types.SupportedX402Versions,
+		Features:     append([]string{}, features...),
+	}
+	if info.Commit == "" || info.BuildDate == "" {
+		revision, time := vcsStamp()
+		if info.Commit == "" {
+			info.Commit = revision
+		}
+		if info.BuildDate == "" {
+			info.BuildDate = time
+		}
+	}
+	return info
+}
+
+// vcsStamp returns the revision and commit time embedded by the Go toolchain
+func vcsStamp() (revision, time string) {
+	revision, time = "unknown", "unknown"
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return revision, time
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.time":
+			time = setting.Value
+		}
+	}
+	return revision, time
+}
+
+// String formats the information for a log line
+func (i Info) String() string {
+	features := "none"
+	if len(i.Features) > 0 {
+		features = strings.Join(i.Features, ",")
+	}
+	return fmt.Sprintf("x402 facilitator %s (commit %s, built %s, %s, x402 versions %v, features: %s)",
+		i.Version, i.Commit, i.BuildDate, i.GoVersion, i.X402Versions, features)
+}
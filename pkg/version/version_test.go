@@ -0,0 +1,75 @@
+package version
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+func TestGetDefaultsWithoutLdflags(t *testing.T) {
+	info := Get()
+	if info.Version != "dev" || info.GoVersion != runtime.Version() {
+		t.Errorf("version %q on %s, want dev on %s", info.Version, info.GoVersion, runtime.Version())
+	}
+	// Test binaries carry no VCS stamp
+	if info.Commit == "" || info.BuildDate == "" {
+		t.Errorf("commit %q, date %q; want a stamp or unknown", info.Commit, info.BuildDate)
+	}
+	if !reflect.DeepEqual(info.X402Versions, types.SupportedX402Versions) || len(info.Features) != 0 {
+		t.Errorf("x402 versions %v, features %v", info.X402Versions, info.Features)
+	}
+}
+
+func TestGetUsesLdflags(t *testing.T) {
+	saved := [3]string{Version, Commit, Date}
+	defer func() { Version, Commit, Date = saved[0], saved[1], saved[2] }()
+	Version, Commit, Date = "v1.2.3", "abc123", "2026-01-02T03:04:05Z"
+
+	info := Get(FeatureSettle, FeatureSolana)
+	if info.Version != "v1.2.3" || info.Commit != "abc123" || info.BuildDate != "2026-01-02T03:04:05Z" {
+		t.Errorf("info %+v, want the injected build", info)
+	}
+	if line := info.String(); !strings.Contains(line, "v1.2.3") || !strings.Contains(line, "commit abc123") || !strings.Contains(line, "features: settle,solana") {
+		t.Errorf("log line %q", line)
+	}
+	if line := Get().String(); !strings.Contains(line, "features: none") {
+		t.Errorf("log line %q, want no features", line)
+	}
+}
+
+func TestInfoJSONShape(t *testing.T) {
+	data, err := json.Marshal(Get(FeatureSettle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for field, kind := range map[string]string{
+		"version":      "string",
+		"commit":       "string",
+		"buildDate":    "string",
+		"goVersion":    "string",
+		"x402Versions": "array",
+		"features":     "array",
+	} {
+		var got string
+		switch fields[field].(type) {
+		case string:
+			got = "string"
+		case []interface{}:
+			got = "array"
+		}
+		if got != kind {
+			t.Errorf("%s is %T, want a JSON %s", field, fields[field], kind)
+		}
+	}
+	if len(fields) != 6 {
+		t.Errorf("fields %v, want exactly six", fields)
+	}
+}
@@ -0,0 +1,271 @@
+package x402test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+// Behavior selects how a FakeFacilitator answers
+type Behavior string
+
+const (
+	// BehaviorVerify checks payloads as a facilitator would, minus the
+	// chain: payload version, scheme and network, recipient, amount,
+	// validity window, signature, and nonces it has settled (or
+	// UsedNonce). Settlement always succeeds for a payload that verifies.
+	BehaviorVerify Behavior = "verify"
+	// BehaviorAccept accepts and settles every payment unchecked
+	BehaviorAccept Behavior = "accept"
+	// BehaviorReject answers every verify as invalid_signature
+	BehaviorReject Behavior = "reject"
+	// BehaviorSettleFails verifies like BehaviorVerify, then fails every settlement
+	BehaviorSettleFails Behavior = "settle_fails"
+	// BehaviorUnavailable answers everything with 503
+	BehaviorUnavailable Behavior = "unavailable"
+)
+
+// FakeFacilitator is an in-process facilitator serving /verify, /settle,
+// /settle/simulate and /supported; point X402Middleware at URL
+type FakeFacilitator struct {
+	// URL is the base URL of the server
+	URL string
+
+	behavior Behavior
+	server   *httptest.Server
+
+	mu      sync.Mutex
+	used    map[string]bool // Settled nonces, key: "payer:nonce" (lowercase)
+	verify  int
+	settles []types.PaymentPayload
+}
+
+// NewFakeFacilitator starts a fake facilitator that stops when the test ends
+func NewFakeFacilitator(t testing.TB, behavior Behavior) *FakeFacilitator {
+	t.Helper()
+	f := &FakeFacilitator{behavior: behavior, used: make(map[string]bool)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/verify", f.handleVerify)
+	mux.HandleFunc("/settle", f.handleSettle)
+	mux.HandleFunc("/settle/simulate", f.handleSimulate)
+	mux.HandleFunc("/supported", f.handleSupported)
+	f.server = httptest.NewServer(f.unavailable(mux))
+	f.URL = f.server.URL
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// VerifyCount returns how many /verify requests were answered
+func (f *FakeFacilitator) VerifyCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.verify
+}
+
+// Settled returns the payloads settled so far, in order
+func (f *FakeFacilitator) Settled() []types.PaymentPayload {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]types.PaymentPayload(nil), f.settles...)
+}
+
+// unavailable answers 503 under BehaviorUnavailable
+func (f *FakeFacilitator) unavailable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.behavior == BehaviorUnavailable {
+			http.Error(w, "facilitator unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleVerify answers POST /verify
+func (f *FakeFacilitator) handleVerify(w http.ResponseWriter, r *http.Request) {
+	var req types.VerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	f.mu.Lock()
+	f.verify++
+	f.mu.Unlock()
+
+	resp := f.check(&req.PaymentPayload, &req.PaymentRequirements)
+	resp.SettlementID = settlementID(r)
+	respond(w, http.StatusOK, resp)
+}
+
+// settlementID echoes the request's settlement ID, or assigns one
+func settlementID(r *http.Request) string {
+	if id := r.Header.Get(types.HeaderIdempotencyKey); id != "" {
+		return id
+	}
+	return types.NewSettlementID()
+}
+
+// handleSettle answers POST /settle, marking the nonce used on success
+func (f *FakeFacilitator) handleSettle(w http.ResponseWriter, r *http.Request) {
+	var req types.SettleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	payload := &req.PaymentPayload
+	id := settlementID(r)
+
+	if verified := f.check(payload, &req.PaymentRequirements); !verified.IsValid {
+		respond(w, http.StatusOK, types.SettleResponse{Success: false, Error: verified.Reason, ReasonCode: verified.ReasonCode, SettlementID: id})
+		return
+	}
+	if f.behavior == BehaviorSettleFails {
+		respond(w, http.StatusOK, types.SettleResponse{Success: false, Error: "transaction reverted", ReasonCode: types.ReasonSettlementFailed, SettlementID: id})
+		return
+	}
+
+	f.mu.Lock()
+	f.used[nonceKey(payload)] = true
+	f.settles = append(f.settles, *payload)
+	f.mu.Unlock()
+
+	// A made-up but stable transaction hash
+	txHash := crypto.Keccak256Hash([]byte(payload.Payload.Signature)).Hex()
+	receipt := types.NewPaymentReceipt(payload, txHash)
+	receipt.SettlementID = id
+	respond(w, http.StatusOK, types.SettleResponse{
+		Success:         true,
+		TransactionHash: &types.TransactionHash{Type: "evm", Hash: txHash},
+		Receipt:         receipt,
+		SettlementID:    id,
+	})
+}
+
+// handleSimulate answers POST /settle/simulate with the verification
+// outcome; nothing is estimated
+func (f *FakeFacilitator) handleSimulate(w http.ResponseWriter, r *http.Request) {
+	var req types.SettleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	verified := f.check(&req.PaymentPayload, &req.PaymentRequirements)
+	respond(w, http.StatusOK, types.SimulateResponse{
+		Valid:      verified.IsValid,
+		Reason:     verified.Reason,
+		ReasonCode: verified.ReasonCode,
+		Payer:      verified.Payer,
+	})
+}
+
+// handleSupported lists exact USDC on every registered EVM network
+func (f *FakeFacilitator) handleSupported(w http.ResponseWriter, r *http.Request) {
+	var kinds []types.SupportedPaymentKind
+	for _, deployment := range network.USDCDeployments() {
+		if !deployment.Network.IsEVM() {
+			continue
+		}
+		kinds = append(kinds, types.SupportedPaymentKind{
+			Version:      types.X402VersionV1,
+			Scheme:       types.SchemeExact,
+			Network:      deployment.Network,
+			Token:        types.MixedAddress{Type: "evm", Address: deployment.TokenAddress.Hex()},
+			TokenSymbol:  deployment.TokenSymbol,
+			Decimals:     deployment.Decimals,
+			X402Versions: []int{1, 2},
+		})
+	}
+	respond(w, http.StatusOK, types.SupportedPaymentKindsResponse{Kinds: kinds})
+}
+
+// check verifies payload against requirements as the behavior says
+func (f *FakeFacilitator) check(payload *types.PaymentPayload, requirements *types.PaymentRequirements) types.VerifyResponse {
+	auth := payload.Payload.Authorization
+	payer := &types.MixedAddress{Type: "evm", Address: auth.From.Hex()}
+	invalid := func(code types.ReasonCode, format string, args ...interface{}) types.VerifyResponse {
+		return types.VerifyResponse{IsValid: false, Payer: payer, Reason: fmt.Sprintf(format, args...), ReasonCode: code}
+	}
+
+	switch f.behavior {
+	case BehaviorAccept:
+		return types.VerifyResponse{IsValid: true, Payer: payer}
+	case BehaviorReject:
+		return invalid(types.ReasonInvalidSignature, "rejected by the fake facilitator")
+	}
+
+	if !types.IsSupportedX402Version(payload.X402Version) {
+		return invalid(types.ReasonUnsupportedVersion, "unsupported version: %d", payload.X402Version)
+	}
+	if payload.Scheme != requirements.Scheme {
+		return invalid(types.ReasonSchemeMismatch, "scheme %s does not match %s", payload.Scheme, requirements.Scheme)
+	}
+	if payload.Network != requirements.Network {
+		return invalid(types.ReasonNetworkMismatch, "network %s does not match %s", payload.Network, requirements.Network)
+	}
+	if auth.To != common.HexToAddress(requirements.PayTo) {
+		return invalid(types.ReasonReceiverMismatch, "authorization pays %s, not %s", auth.To.Hex(), requirements.PayTo)
+	}
+
+	value, ok := new(big.Int).SetString(auth.Value, 10)
+	required, requiredOK := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+	if !ok || !requiredOK {
+		return invalid(types.ReasonInvalidAmount, "invalid amount %q", auth.Value)
+	}
+	if value.Cmp(required) < 0 {
+		return invalid(types.ReasonInsufficientValue, "authorized %s, %s required", value, required)
+	}
+
+	validAfter, errAfter := strconv.ParseInt(auth.ValidAfter, 10, 64)
+	validBefore, errBefore := strconv.ParseInt(auth.ValidBefore, 10, 64)
+	if errAfter != nil || errBefore != nil {
+		return invalid(types.ReasonInvalidTiming, "invalid validity window")
+	}
+	now := time.Now().Unix()
+	if now >= validBefore {
+		return invalid(types.ReasonExpired, "authorization expired at %d", validBefore)
+	}
+	if now <= validAfter {
+		return invalid(types.ReasonNotYetValid, "authorization is valid after %d", validAfter)
+	}
+
+	domain, err := tokenDomain(payload.Network, requirements.Asset)
+	if err != nil {
+		return invalid(types.ReasonUnsupportedNetwork, "%v", err)
+	}
+	if valid, err := eip712.VerifySignature(&auth, payload.Payload.Signature, domain); err != nil || !valid {
+		return invalid(types.ReasonInvalidSignature, "signature is not from %s", auth.From.Hex())
+	}
+
+	f.mu.Lock()
+	used := f.used[nonceKey(payload)] || auth.Nonce.String() == UsedNonce
+	f.mu.Unlock()
+	if used {
+		return invalid(types.ReasonNonceReused, "nonce %s was already used", auth.Nonce)
+	}
+	return types.VerifyResponse{IsValid: true, Payer: payer}
+}
+
+// nonceKey identifies an authorization nonce on-chain (per payer)
+func nonceKey(payload *types.PaymentPayload) string {
+	auth := payload.Payload.Authorization
+	return strings.ToLower(auth.From.Hex() + ":" + auth.Nonce.String())
+}
+
+// respond writes data as a JSON response
+func respond(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
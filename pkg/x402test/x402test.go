@@ -0,0 +1,315 @@
+// Package x402test provides fixtures for testing x402 servers and clients
+// without a chain: a well-known payer key, correctly signed (and
+// deliberately broken) payment payloads, and a fake facilitator to point
+// X402Middleware at:
+//
+//	fac := x402test.NewFakeFacilitator(t, x402test.BehaviorVerify)
+//	mw := server.NewX402Middleware(fac.URL)
+//	requirements := x402test.Requirements()
+//	handler := mw.Protect(myHandler, &server.PriceTag{Requirements: requirements})
+//
+//	rec := httptest.NewRecorder()
+//	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/paid", nil)) // 402
+//
+//	payload, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), nil)
+//	header, err := x402test.PaymentHeader(payload)
+//	req := httptest.NewRequest("GET", "/paid", nil)
+//	req.Header.Set(types.HeaderXPayment, header)
+//	rec = httptest.NewRecorder()
+//	handler.ServeHTTP(rec, req) // 200
+//
+// The payer key is public test material (the first Hardhat/Anvil account):
+// never fund it on a real network.
+package x402test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+)
+
+const (
+	// PayerPrivateKey is the payer key the fixtures sign with by default
+	PayerPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	// OtherPrivateKey is a second well-known key, e.g. for a payment signed
+	// by someone other than its From address
+	OtherPrivateKey = "0x59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"
+
+	// Network is the network of Requirements
+	Network = types.NetworkBaseSepolia
+	// Amount is the price of Requirements (0.01 USDC)
+	Amount = "10000"
+)
+
+var (
+	// PayerAddress is the address of PayerPrivateKey
+	PayerAddress = common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	// PayTo is the recipient of Requirements
+	PayTo = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	// UsedNonce is an authorization nonce every FakeFacilitator treats as
+	// already settled (see TamperReusedNonce)
+	UsedNonce = "0x" + hex.EncodeToString(crypto.Keccak256([]byte("x402test.UsedNonce")))
+)
+
+// PayerKey returns PayerPrivateKey
+func PayerKey() *ecdsa.PrivateKey {
+	return mustKey(PayerPrivateKey)
+}
+
+// OtherKey returns OtherPrivateKey
+func OtherKey() *ecdsa.PrivateKey {
+	return mustKey(OtherPrivateKey)
+}
+
+// mustKey parses a fixture key
+func mustKey(hexKey string) *ecdsa.PrivateKey {
+	key, err := crypto.HexToECDSA(hexKey[2:])
+	if err != nil {
+		panic(fmt.Sprintf("x402test: invalid fixture key: %v", err))
+	}
+	return key
+}
+
+// Requirements returns requirements for Amount of USDC on Network, paid to
+// PayTo, valid for 60 seconds
+func Requirements() types.PaymentRequirements {
+	deployment, err := network.GetUSDCDeployment(Network)
+	if err != nil {
+		panic(fmt.Sprintf("x402test: %v", err))
+	}
+	return types.PaymentRequirements{
+		Version:           types.X402VersionV1,
+		Scheme:            types.SchemeExact,
+		Network:           Network,
+		PayTo:             PayTo.Hex(),
+		MaxAmountRequired: Amount,
+		Description:       "x402test fixture",
+		MimeType:          "application/json",
+		MaxTimeoutSeconds: 60,
+		Asset:             deployment.TokenAddress,
+	}
+}
+
+// PayloadOptions adjusts generated payloads; the zero value (or nil) signs
+// a payment valid from now for the requirements' MaxTimeoutSeconds
+type PayloadOptions struct {
+	// Signing time (default time.Now); the payment is valid from a second
+	// before it
+	Now time.Time
+	// Validity window (default MaxTimeoutSeconds, or an hour without one,
+	// and at least MinTimeoutSeconds)
+	ValidFor time.Duration
+	// Authorization nonce (0x-prefixed 32-byte hex). By default it is
+	// derived from a fresh resource binding when the requirements name a
+	// resource (as PayingClient does), otherwise random. Fix Now and Nonce
+	// to get the same payload on every run.
+	Nonce string
+	// Amount to authorize (default MaxAmountRequired)
+	Value string
+}
+
+// GenerateValidPayload signs a payment for requirements with key, the way
+// PayingClient does: a challenge in the requirements dictates the nonce, a
+// resource binding is attached when they name a resource, and a price
+// quote is echoed back
+func GenerateValidPayload(requirements types.PaymentRequirements, key *ecdsa.PrivateKey, opts *PayloadOptions) (*types.PaymentPayload, error) {
+	if opts == nil {
+		opts = &PayloadOptions{}
+	}
+	if !requirements.Network.IsEVM() {
+		return nil, fmt.Errorf("x402test: unsupported network %s", requirements.Network)
+	}
+
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	validFor := opts.ValidFor
+	if validFor <= 0 {
+		validFor = time.Hour
+		if requirements.MaxTimeoutSeconds > 0 {
+			validFor = time.Duration(requirements.MaxTimeoutSeconds) * time.Second
+		}
+		validFor = max(validFor, time.Duration(requirements.MinTimeoutSeconds)*time.Second)
+	}
+	value := opts.Value
+	if value == "" {
+		value = requirements.MaxAmountRequired
+	}
+
+	challenge, err := types.ParseChallenge(requirements.Extra)
+	if err != nil {
+		return nil, fmt.Errorf("x402test: invalid challenge: %w", err)
+	}
+	var binding *types.ResourceBinding
+	nonce := opts.Nonce
+	switch {
+	case challenge != nil:
+		nonce = challenge.AuthorizationNonce()
+	case nonce != "":
+	case requirements.Resource != "":
+		if binding, err = types.NewResourceBinding(requirements.Resource); err != nil {
+			return nil, err
+		}
+		nonce = binding.AuthorizationNonce()
+	default:
+		var random types.Nonce
+		if _, err := rand.Read(random[:]); err != nil {
+			return nil, err
+		}
+		nonce = random.String()
+	}
+	nonceBytes, err := types.DecodeHex(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("x402test: invalid nonce: %w", err)
+	}
+
+	payload := &types.PaymentPayload{
+		X402Version: 1,
+		Scheme:      types.SchemeExact,
+		Network:     requirements.Network,
+		Payload: types.ExactEvmPayload{
+			Authorization: types.ExactEvmPayloadAuthorization{
+				From:        crypto.PubkeyToAddress(key.PublicKey),
+				To:          common.HexToAddress(requirements.PayTo),
+				Value:       value,
+				ValidAfter:  fmt.Sprintf("%d", now.Unix()-1),
+				ValidBefore: fmt.Sprintf("%d", now.Add(validFor).Unix()),
+				Nonce:       nonceBytes,
+			},
+		},
+	}
+	if challenge != nil {
+		payload.Extra = requirements.Extra
+	}
+	if binding != nil {
+		if payload.Extra, err = types.MergeExtra(nil, types.RequirementsExtra{ResourceBinding: binding}); err != nil {
+			return nil, err
+		}
+	}
+	if quote, _ := types.ParseQuote(requirements.Extra); quote != nil && challenge == nil {
+		if payload.Extra, err = types.MergeExtra(payload.Extra, types.RequirementsExtra{Quote: quote}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := sign(payload, requirements.Asset, key); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// Tamper names a way GenerateTamperedPayload breaks a payload
+type Tamper string
+
+const (
+	TamperSignature   Tamper = "bad_signature"   // Signed by OtherPrivateKey, claiming to be from the payer
+	TamperExpired     Tamper = "expired"         // Validity window ended an hour ago
+	TamperRecipient   Tamper = "wrong_recipient" // Pays someone other than requirements.PayTo
+	TamperReusedNonce Tamper = "reused_nonce"    // Carries UsedNonce, already settled
+	TamperAmount      Tamper = "short_amount"    // Authorizes less than MaxAmountRequired
+)
+
+// Tampers lists every Tamper, for table-driven tests
+var Tampers = []Tamper{TamperSignature, TamperExpired, TamperRecipient, TamperReusedNonce, TamperAmount}
+
+// ReasonFor returns the reason code a facilitator answers a tampered payload with
+func ReasonFor(tamper Tamper) types.ReasonCode {
+	switch tamper {
+	case TamperSignature:
+		return types.ReasonInvalidSignature
+	case TamperExpired:
+		return types.ReasonExpired
+	case TamperRecipient:
+		return types.ReasonReceiverMismatch
+	case TamperReusedNonce:
+		return types.ReasonNonceReused
+	case TamperAmount:
+		return types.ReasonInsufficientValue
+	}
+	return ""
+}
+
+// GenerateTamperedPayload signs a payload for requirements with key, then
+// breaks it as tamper says; every other field stays valid, so a verifier
+// must reject it for that reason alone
+func GenerateTamperedPayload(requirements types.PaymentRequirements, key *ecdsa.PrivateKey, tamper Tamper, opts *PayloadOptions) (*types.PaymentPayload, error) {
+	var o PayloadOptions
+	if opts != nil {
+		o = *opts
+	}
+	switch tamper {
+	case TamperSignature:
+		payload, err := GenerateValidPayload(requirements, key, &o)
+		if err != nil {
+			return nil, err
+		}
+		// Re-sign the same authorization with the wrong key
+		if err := sign(payload, requirements.Asset, OtherKey()); err != nil {
+			return nil, err
+		}
+		return payload, nil
+	case TamperExpired:
+		if o.Now.IsZero() {
+			o.Now = time.Now()
+		}
+		o.Now = o.Now.Add(-2 * time.Hour)
+		o.ValidFor = time.Hour
+	case TamperRecipient:
+		requirements.PayTo = common.HexToAddress("0x000000000000000000000000000000000000bEEF").Hex()
+	case TamperReusedNonce:
+		o.Nonce = UsedNonce
+		requirements.Extra = nil // A challenge would dictate another nonce
+	case TamperAmount:
+		amount, ok := new(big.Int).SetString(requirements.MaxAmountRequired, 10)
+		if !ok || amount.Sign() <= 0 {
+			return nil, fmt.Errorf("x402test: cannot shorten amount %q", requirements.MaxAmountRequired)
+		}
+		o.Value = new(big.Int).Sub(amount, big.NewInt(1)).String()
+	default:
+		return nil, fmt.Errorf("x402test: unknown tamper %q", tamper)
+	}
+	return GenerateValidPayload(requirements, key, &o)
+}
+
+// PaymentHeader encodes payload as a payment header value
+func PaymentHeader(payload *types.PaymentPayload) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// sign (re)signs the payload's authorization with key under asset's domain
+func sign(payload *types.PaymentPayload, asset common.Address, key *ecdsa.PrivateKey) error {
+	domain, err := tokenDomain(payload.Network, asset)
+	if err != nil {
+		return err
+	}
+	signature, err := eip712.SignTransferWithAuthorization(&payload.Payload.Authorization, domain, key)
+	if err != nil {
+		return fmt.Errorf("x402test: signing failed: %w", err)
+	}
+	payload.Payload.Signature = signature
+	return nil
+}
+
+// tokenDomain returns the EIP-712 domain of asset on net
+func tokenDomain(net types.Network, asset common.Address) (eip712.Domain, error) {
+	chainID, ok := net.ChainID()
+	if !ok {
+		return eip712.Domain{}, fmt.Errorf("x402test: unknown chain ID for network %s", net)
+	}
+	return eip712.TokenDomain(net, new(big.Int).SetUint64(chainID), asset), nil
+}
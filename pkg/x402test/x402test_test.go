@@ -0,0 +1,189 @@
+package x402test_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/x402-rs/x402-go/middleware/server"
+	"github.com/x402-rs/x402-go/pkg/eip712"
+	"github.com/x402-rs/x402-go/pkg/facilitator"
+	"github.com/x402-rs/x402-go/pkg/network"
+	"github.com/x402-rs/x402-go/pkg/types"
+	"github.com/x402-rs/x402-go/pkg/x402test"
+)
+
+// TestFull402Flow is the flow the package documentation shows
+func TestFull402Flow(t *testing.T) {
+	fac := x402test.NewFakeFacilitator(t, x402test.BehaviorVerify)
+	requirements := x402test.Requirements()
+	handler := server.NewX402Middleware(fac.URL).Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("paid"))
+	}), &server.PriceTag{Requirements: requirements})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/paid", nil))
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("unpaid: status %d, want 402", rec.Code)
+	}
+
+	payload, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := x402test.PaymentHeader(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/paid", nil)
+	req.Header.Set(types.HeaderXPayment, header)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "paid" {
+		t.Errorf("paid: status %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// domain is the EIP-712 domain the fixtures sign under
+func domain(t *testing.T) eip712.Domain {
+	t.Helper()
+	requirements := x402test.Requirements()
+	info, err := network.GetNetworkInfo(requirements.Network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return eip712.TokenDomain(requirements.Network, new(big.Int).SetUint64(uint64(info.ChainID)), requirements.Asset)
+}
+
+func TestGenerateValidPayload(t *testing.T) {
+	requirements := x402test.Requirements()
+	payload, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := payload.Payload.Authorization
+	signer, err := eip712.RecoverSigner(&auth, payload.Payload.Signature, domain(t))
+	if err != nil || signer != x402test.PayerAddress {
+		t.Errorf("payload signed by %s (%v), want %s", signer.Hex(), err, x402test.PayerAddress.Hex())
+	}
+	if auth.From != x402test.PayerAddress || auth.To != x402test.PayTo || auth.Value != x402test.Amount {
+		t.Errorf("authorization %+v does not pay the requirements", auth)
+	}
+
+	// A fixed time and nonce give the same payload every run
+	opts := &x402test.PayloadOptions{Now: time.Unix(1_700_000_000, 0), Nonce: "0xab000000000000000000000000000000000000000000000000000000000000cd"}
+	first, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := json.Marshal(first)
+	b, _ := json.Marshal(second)
+	if string(a) != string(b) {
+		t.Errorf("fixed options gave different payloads:\n%s\n%s", a, b)
+	}
+	if first.Payload.Authorization.ValidAfter != "1699999999" {
+		t.Errorf("validAfter %s, want a second before Now", first.Payload.Authorization.ValidAfter)
+	}
+
+	// The window is never shorter than the requirements' minimum
+	short := requirements
+	short.MaxTimeoutSeconds, short.MinTimeoutSeconds = 30, 90
+	windowed, err := x402test.GenerateValidPayload(short, x402test.PayerKey(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := windowed.Payload.Authorization; auth.ValidAfter != "1699999999" || auth.ValidBefore != "1700000090" {
+		t.Errorf("window %s to %s, want 90 seconds from Now, the minimum", auth.ValidAfter, auth.ValidBefore)
+	}
+
+	// Requirements naming a resource get a payment bound to it
+	requirements.Resource = "https://shop.test/paid"
+	bound, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := types.CheckResourceBinding(bound, &requirements); err != nil {
+		t.Errorf("payment is not bound to the resource: %v", err)
+	}
+}
+
+func TestTamperedPayloadsFailForTheirReason(t *testing.T) {
+	fac := x402test.NewFakeFacilitator(t, x402test.BehaviorVerify)
+	client := facilitator.NewClient(fac.URL, nil)
+	requirements := x402test.Requirements()
+
+	for _, tamper := range x402test.Tampers {
+		payload, err := x402test.GenerateTamperedPayload(requirements, x402test.PayerKey(), tamper, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tamper, err)
+		}
+		resp, err := client.Verify(context.Background(), &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements})
+		if err != nil {
+			t.Fatalf("%s: %v", tamper, err)
+		}
+		if resp.IsValid || resp.ReasonCode != x402test.ReasonFor(tamper) {
+			t.Errorf("%s: valid %v, reason code %q; want %q", tamper, resp.IsValid, resp.ReasonCode, x402test.ReasonFor(tamper))
+		}
+	}
+	if _, err := x402test.GenerateTamperedPayload(requirements, x402test.PayerKey(), "sideways", nil); err == nil {
+		t.Error("an unknown tamper was accepted")
+	}
+}
+
+func TestFakeFacilitatorBehaviors(t *testing.T) {
+	requirements := x402test.Requirements()
+	payload, err := x402test.GenerateValidPayload(requirements, x402test.PayerKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	settle := &types.SettleRequest{PaymentPayload: *payload, PaymentRequirements: requirements}
+
+	// Verify settles once, then treats the nonce as used
+	fac := x402test.NewFakeFacilitator(t, x402test.BehaviorVerify)
+	client := facilitator.NewClient(fac.URL, nil)
+	if resp, err := client.Settle(ctx, settle); err != nil || !resp.Success {
+		t.Fatalf("first settle: %+v (%v)", resp, err)
+	}
+	if resp, err := client.Settle(ctx, settle); err != nil || resp.Success || resp.ReasonCode != types.ReasonNonceReused {
+		t.Errorf("replayed settle: %+v (%v), want %q", resp, err, types.ReasonNonceReused)
+	}
+	if settled := fac.Settled(); len(settled) != 1 || settled[0].Payload.Authorization.Nonce.String() != payload.Payload.Authorization.Nonce.String() {
+		t.Errorf("settled %d payloads, want the one", len(settled))
+	}
+
+	reject := facilitator.NewClient(x402test.NewFakeFacilitator(t, x402test.BehaviorReject).URL, nil)
+	if resp, err := reject.Verify(ctx, &types.VerifyRequest{PaymentPayload: *payload, PaymentRequirements: requirements}); err != nil || resp.IsValid || resp.ReasonCode != types.ReasonInvalidSignature {
+		t.Errorf("reject: %+v (%v)", resp, err)
+	}
+
+	fails := facilitator.NewClient(x402test.NewFakeFacilitator(t, x402test.BehaviorSettleFails).URL, nil)
+	if resp, err := fails.Settle(ctx, settle); err != nil || resp.Success || resp.ReasonCode != types.ReasonSettlementFailed {
+		t.Errorf("settle_fails: %+v (%v)", resp, err)
+	}
+
+	unavailable := facilitator.NewClient(x402test.NewFakeFacilitator(t, x402test.BehaviorUnavailable).URL, nil)
+	if _, err := unavailable.Supported(ctx); err == nil {
+		t.Error("unavailable facilitator answered /supported")
+	}
+
+	supported, err := client.Supported(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := false
+	for _, kind := range supported.Kinds {
+		listed = listed || kind.Network == x402test.Network
+	}
+	if !listed {
+		t.Errorf("/supported %+v does not list %s", supported.Kinds, x402test.Network)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !embedfrontend
+
+package web
+
+import "io/fs"
+
+// Embedded reports false: the binary was built without -tags embedfrontend
+func Embedded() (fs.FS, bool) {
+	return nil, false
+}
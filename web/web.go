@@ -0,0 +1,117 @@
+// Package web serves the facilitator's frontend, a single-page app built
+// from frontend/ into web/dist (npm run build).
+//
+// Release builds compile the build into the binary:
+//
+//	go build -tags embedfrontend ./cmd/facilitator
+//
+// Development builds read it from disk instead (see Load), so a rebuild of
+// the frontend shows without restarting the facilitator.
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// DistDir is where the frontend build lands, relative to the repository root
+const DistDir = "web/dist"
+
+// Cache-Control values of the responses of Handler
+const (
+	CacheImmutable = "public, max-age=31536000, immutable" // Content-hashed files under assets/
+	CacheIndex     = "no-cache"                            // index.html, so new builds show at once
+	CacheDefault   = "public, max-age=3600"                // Other files (favicon, robots.txt, ...)
+)
+
+// assetsDir is where the bundler writes assets, each named after a hash of
+// its content (e.g. assets/index-BkX3A9cd.js)
+const assetsDir = "assets/"
+
+// ErrNotFound is returned by Load when no frontend build is available
+var ErrNotFound = errors.New("frontend build not found")
+
+// Load returns the frontend to serve and where it comes from: the build
+// in dir on disk in development, else the one embedded in the binary
+func Load(dev bool, dir string) (fs.FS, string, error) {
+	if dev {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			return nil, "", fmt.Errorf("%w at %s", ErrNotFound, dir)
+		}
+		return os.DirFS(dir), dir, nil
+	}
+	if fsys, ok := Embedded(); ok {
+		return fsys, "the binary", nil
+	}
+	return nil, "", fmt.Errorf("%w in the binary (build with -tags embedfrontend, or set DEV_MODE=true to serve %s)", ErrNotFound, dir)
+}
+
+// Handler serves the files of fsys, and index.html for other paths without
+// a file extension (the app's client-side routes); a missing file with an
+// extension is a 404. Paths are resolved within fsys only, so no request
+// reaches a file outside it.
+func Handler(fsys fs.FS) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "index.html"
+		}
+		if !fs.ValidPath(name) {
+			http.NotFound(w, r)
+			return
+		}
+
+		if serveFile(w, r, fsys, name) {
+			return
+		}
+		if path.Ext(name) != "" || !serveFile(w, r, fsys, "index.html") {
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// serveFile serves the regular file name of fsys with its Cache-Control,
+// reporting false if there is none
+func serveFile(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) bool {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return false
+		}
+		content = bytes.NewReader(data)
+	}
+
+	w.Header().Set("Cache-Control", cacheControl(name))
+	http.ServeContent(w, r, name, info.ModTime(), content)
+	return true
+}
+
+// cacheControl returns the Cache-Control of the file name
+func cacheControl(name string) string {
+	switch {
+	case name == "index.html":
+		return CacheIndex
+	case strings.HasPrefix(name, assetsDir):
+		return CacheImmutable
+	default:
+		return CacheDefault
+	}
+}
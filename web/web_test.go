@@ -0,0 +1,112 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// appBuild is a frontend build as the bundler writes it
+var appBuild = fstest.MapFS{
+	"index.html":               {Data: []byte("<!doctype html><title>app</title>")},
+	"favicon.ico":              {Data: []byte("icon")},
+	"assets/index-BkX3A9cd.js": {Data: []byte("console.log('app')")},
+	"assets/logo-9f8e7d6c.svg": {Data: []byte("<svg/>")},
+}
+
+func get(t *testing.T, handler http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestHandlerCacheHeaders(t *testing.T) {
+	handler := Handler(appBuild)
+	for _, tc := range []struct {
+		path, body, cache string
+	}{
+		{"/", "<!doctype html><title>app</title>", CacheIndex},
+		{"/index.html", "<!doctype html><title>app</title>", CacheIndex},
+		{"/payments/42", "<!doctype html><title>app</title>", CacheIndex}, // A client-side route
+		{"/assets/index-BkX3A9cd.js", "console.log('app')", CacheImmutable},
+		{"/assets/logo-9f8e7d6c.svg", "<svg/>", CacheImmutable},
+		{"/favicon.ico", "icon", CacheDefault},
+	} {
+		rec := get(t, handler, tc.path)
+		if rec.Code != http.StatusOK || rec.Body.String() != tc.body {
+			t.Errorf("%s: status %d, body %q; want %q", tc.path, rec.Code, rec.Body.String(), tc.body)
+		}
+		if got := rec.Header().Get("Cache-Control"); got != tc.cache {
+			t.Errorf("%s: Cache-Control %q, want %q", tc.path, got, tc.cache)
+		}
+	}
+
+	// Missing files are 404s, not the app, and are not cached as assets
+	for _, path := range []string{"/assets/missing-0000.js", "/robots.txt"} {
+		if rec := get(t, handler, path); rec.Code != http.StatusNotFound || rec.Header().Get("Cache-Control") != "" {
+			t.Errorf("%s: status %d, Cache-Control %q; want an uncached 404", path, rec.Code, rec.Header().Get("Cache-Control"))
+		}
+	}
+	// A directory is no file: it falls back to the app
+	if rec := get(t, handler, "/assets"); rec.Header().Get("Cache-Control") != CacheIndex {
+		t.Errorf("/assets: status %d, Cache-Control %q; want index.html", rec.Code, rec.Header().Get("Cache-Control"))
+	}
+}
+
+func TestHandlerStaysWithinItsFS(t *testing.T) {
+	root := t.TempDir()
+	build := filepath.Join(root, "dist")
+	if err := os.MkdirAll(filepath.Join(build, "assets"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{
+		filepath.Join(build, "index.html"):  "app",
+		filepath.Join(root, "secret.txt"):   "secret",
+		filepath.Join(root, "secret"):       "secret",
+		filepath.Join(build, "assets/a.js"): "asset",
+	} {
+		if err := os.WriteFile(name, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsys, source, err := Load(true, build)
+	if err != nil || source != build {
+		t.Fatalf("Load: %v (source %q)", err, source)
+	}
+	handler := Handler(fsys)
+
+	for _, path := range []string{
+		"/../secret.txt",
+		"/..%2fsecret.txt",
+		"/%2e%2e/secret.txt",
+		"/assets/../../secret.txt",
+		"/assets/..%2f..%2fsecret",
+		"/..%5csecret.txt",
+		"/%2e%2e%5c%2e%2e%5csecret",
+		"//../secret",
+	} {
+		rec := get(t, handler, path)
+		if rec.Body.String() == "secret" {
+			t.Errorf("%s served a file outside the build", path)
+			continue
+		}
+		if rec.Code != http.StatusNotFound && rec.Body.String() != "app" {
+			t.Errorf("%s: status %d, body %q; want index.html or a 404", path, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	if _, _, err := Load(true, filepath.Join(t.TempDir(), "missing")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("dev mode without a build: error %v, want ErrNotFound", err)
+	}
+	// Tests build without -tags embedfrontend
+	if _, _, err := Load(false, DistDir); !errors.Is(err, ErrNotFound) {
+		t.Errorf("no embedded build: error %v, want ErrNotFound", err)
+	}
+}